@@ -24,6 +24,8 @@ import (
 	"time"
 
 	"github.com/chimera-pool/chimera-pool-core/internal/api"
+	"github.com/chimera-pool/chimera-pool-core/internal/notifications"
+	"github.com/chimera-pool/chimera-pool-core/internal/payouts"
 	"github.com/chimera-pool/chimera-pool-core/internal/stats"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
@@ -92,6 +94,30 @@ func main() {
 	// Start CSRF token cleanup goroutine
 	cleanupExpiredCSRFTokens()
 
+	// Deliver queued alert webhooks (alerts are queued by any service)
+	webhookRepo := notifications.NewSQLNotificationRepository(db)
+	webhookDispatcher := notifications.NewWebhookDispatcher(notifications.DefaultWebhookConfig(), webhookRepo)
+	webhookCtx, stopWebhooks := context.WithCancel(context.Background())
+	defer stopWebhooks()
+	go webhookDispatcher.Run(webhookCtx)
+
+	// Payouts held by the payout policy wait here for admin approval
+	payoutApprovals := newPayoutApprovals(db, config.Payouts)
+	// Miners hear about rejected payouts through their alert webhooks
+	payoutAlerts := notifications.NewNotificationService(notifications.DefaultNotificationConfig())
+	payoutAlerts.SetPreferencesProvider(webhookRepo)
+	payoutAlerts.SetRepository(webhookRepo)
+	payoutAlerts.RegisterSender(webhookDispatcher)
+	payoutApprovals.SetNotifier(payouts.NewNotificationAdapter(payoutAlerts))
+
+	// The balance ledger is checked on a schedule; admins hear about a failed
+	// check through their pool alert webhooks
+	payoutLedger := payouts.NewLedger(payouts.NewSQLLedgerStore(db), payouts.DefaultLedgerCurrency)
+	ledgerAuditor := newLedgerAuditor(payoutLedger, config.Payouts)
+	ledgerAuditor.SetNotifier(payouts.NewNotificationAdapter(payoutAlerts))
+	ledgerAuditor.Start()
+	defer ledgerAuditor.Stop()
+
 	// API routes
 	apiGroup := router.Group("/api/v1")
 	{
@@ -141,6 +167,7 @@ func main() {
 			protected.GET("/user/wallets", handleGetUserWallets(db))
 			protected.GET("/user/wallets/preview", handleWalletPayoutPreview(db))
 			protected.GET("/user/miners/:id/wallets", handleGetMinerWalletAssignments(db))
+			protected.GET("/user/miners/:id/vardiff", handleGetMinerVardiffTrace(db, redisClient))
 			protected.GET("/user/stats", handleUserStats(db))
 			protected.GET("/user/stats/hashrate", handleUserHashrateHistory(db))
 			protected.GET("/user/stats/shares", handleUserSharesHistory(db))
@@ -317,6 +344,22 @@ func main() {
 			admin.GET("/roles", handleAdminGetRoles(db))
 		}
 
+		// Outbound alert webhooks: user endpoints, and pool-wide ones for admins
+		api.RegisterWebhookRoutes(protected.Group("/user"), admin,
+			api.NewWebhookHandlers(webhookRepo, webhookDispatcher))
+
+		// Held payout review and payout dry runs
+		api.RegisterPayoutApprovalRoutes(admin,
+			api.NewPayoutApprovalHandlers(payoutApprovals, newPayoutPlanner(db, config.Payouts, payoutApprovals)))
+
+		// Audited balance adjustments and on-demand ledger checks
+		api.RegisterLedgerRoutes(admin, api.NewLedgerHandlers(payoutLedger, ledgerAuditor))
+
+		// Payout mode backtests for miners, and candidate configurations for admins
+		api.RegisterPayoutBacktestRoutes(protected.Group("/user"), admin,
+			api.NewPayoutBacktestHandlers(payouts.NewPayoutBacktestService(
+				payouts.NewSQLBacktestSource(db), payouts.DefaultBacktestConfig())))
+
 		// Public network info route
 		apiGroup.GET("/network/active", handleGetActiveNetwork(db))
 	}
@@ -427,6 +470,9 @@ type Config struct {
 	FrontendURL  string
 	ResendAPIKey string
 	EmailFrom    string
+
+	// Payout configuration, shared with the payout service
+	Payouts payouts.PayoutServiceConfig
 }
 
 func loadConfig() *Config {
@@ -444,7 +490,57 @@ func loadConfig() *Config {
 		FrontendURL:  getEnv("FRONTEND_URL", "http://localhost:3000"),
 		ResendAPIKey: getEnv("RESEND_API_KEY", ""),
 		EmailFrom:    getEnv("EMAIL_FROM", "Chimera Pool <noreply@chimeriapool.com>"),
+
+		Payouts: payouts.LoadPayoutServiceConfig(),
+	}
+}
+
+// newPayoutApprovals builds the approval service that holds risky payouts,
+// with the payout service's limits
+func newPayoutApprovals(db *sql.DB, config payouts.PayoutServiceConfig) *payouts.PayoutApprovalService {
+	approvals := payouts.NewPayoutApprovalService(payouts.NewSQLPayoutApprovalRepository(db), config.Approval)
+	approvals.SetLedger(payouts.NewLedger(payouts.NewSQLLedgerStore(db), payouts.DefaultLedgerCurrency))
+	return approvals
+}
+
+// newLedgerAuditor builds the scheduled ledger check. The ledger is compared
+// with the node's wallet when wallet credentials are set.
+func newLedgerAuditor(ledger *payouts.Ledger, config payouts.PayoutServiceConfig) *payouts.LedgerAuditor {
+	var wallet payouts.WalletClient
+	if config.Wallet.RPCUser != "" {
+		client, err := payouts.NewLitecoinWalletClient(config.Wallet)
+		if err != nil {
+			log.Printf("Warning: ledger not compared with the node: %v", err)
+		} else {
+			wallet = client
+		}
 	}
+	return payouts.NewLedgerAuditor(ledger, wallet, payouts.DefaultLedgerCurrency, config.Auditor)
+}
+
+// newPayoutPlanner builds a payout processor that is only used to plan dry
+// runs, configured like the one that sends payouts. It returns nil when no
+// wallet credentials are set.
+func newPayoutPlanner(db *sql.DB, serviceConfig payouts.PayoutServiceConfig, policy payouts.PayoutPolicy) api.PayoutPlanner {
+	if serviceConfig.Wallet.RPCUser == "" {
+		return nil
+	}
+
+	wallet, err := payouts.NewLitecoinWalletClient(serviceConfig.Wallet)
+	if err != nil {
+		log.Printf("Warning: payout dry runs unavailable: %v", err)
+		return nil
+	}
+
+	repo := payouts.NewSQLPayoutRepository(db)
+	ledger := payouts.NewLedger(payouts.NewSQLLedgerStore(db), payouts.DefaultLedgerCurrency)
+	processor := payouts.NewPayoutProcessor(wallet, repo, ledger, serviceConfig.Processor)
+	if serviceConfig.Processor.Batching.Enabled {
+		processor.SetBatching(wallet, repo)
+	}
+	processor.SetPolicy(policy)
+	processor.SetSplitter(payouts.NewWalletSplitter(payouts.NewSQLSplitRuleRepository(db), serviceConfig.Splits))
+	return processor
 }
 
 func getEnv(key, defaultValue string) string {
@@ -7216,6 +7312,44 @@ func handleGetMinerWalletAssignments(db *sql.DB) gin.HandlerFunc {
 	}
 }
 
+// handleGetMinerVardiffTrace returns a miner's recent difficulty decisions,
+// newest first, as published by the stratum server
+func handleGetMinerVardiffTrace(db *sql.DB, redisClient *redis.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetInt64("user_id")
+		minerID := c.Param("id")
+
+		// Verify miner ownership
+		var ownerID int64
+		err := db.QueryRow("SELECT user_id FROM miners WHERE id = $1", minerID).Scan(&ownerID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Miner not found"})
+			return
+		}
+		if ownerID != userID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Not authorized"})
+			return
+		}
+
+		decisions := []json.RawMessage{}
+		if redisClient != nil {
+			entries, err := redisClient.LRange(c.Request.Context(), "vardiff:trace:miner:"+minerID, 0, -1).Result()
+			if err != nil && err != redis.Nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch vardiff trace"})
+				return
+			}
+			for _, entry := range entries {
+				decisions = append(decisions, json.RawMessage(entry))
+			}
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"miner_id":  minerID,
+			"decisions": decisions,
+		})
+	}
+}
+
 // handleSetMinerWalletAssignment assigns a wallet to a specific miner
 func handleSetMinerWalletAssignment(db *sql.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/chimera-pool/chimera-pool-core/internal/payouts"
+)
+
+func TestPayoutPlanner_UsesPayoutServiceConfig(t *testing.T) {
+	t.Setenv("LTC_RPC_USER", "chimera")
+	t.Setenv("LTC_MIN_PAYOUT", "5000000")
+	t.Setenv("LTC_PAYOUT_MAX_AMOUNT", "250000000")
+	t.Setenv("LTC_PAYOUT_REQUIRED_APPROVALS", "3")
+
+	config := loadConfig()
+	db, err := sql.Open("postgres", "postgres://localhost/chimera_pool?sslmode=disable")
+	require.NoError(t, err)
+	defer db.Close()
+
+	approvals := newPayoutApprovals(db, config.Payouts)
+	planner, ok := newPayoutPlanner(db, config.Payouts, approvals).(*payouts.PayoutProcessor)
+	require.True(t, ok)
+	assert.Equal(t, int64(5000000), planner.Config().MinPayoutAmount)
+	assert.Equal(t, time.Minute, planner.Config().ProcessInterval)
+
+	policy, ok := planner.Policy().(*payouts.PayoutApprovalService)
+	require.True(t, ok)
+	assert.Equal(t, int64(250000000), policy.Config().MaxPayoutAmount)
+	assert.Equal(t, 3, policy.Config().RequiredApprovals)
+	assert.Equal(t, payouts.DefaultApprovalConfig().MaxUserDailyAmount, policy.Config().MaxUserDailyAmount)
+}
+
+func TestPayoutPlanner_NeedsWalletCredentials(t *testing.T) {
+	t.Setenv("LTC_RPC_USER", "")
+	assert.Nil(t, newPayoutPlanner(nil, loadConfig().Payouts, nil))
+}
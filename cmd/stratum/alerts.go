@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+
+	"github.com/chimera-pool/chimera-pool-core/internal/notifications"
+)
+
+// =============================================================================
+// ALERTS
+// Worker and block alerts go out through the notification service. Webhook
+// deliveries are only queued here; the API server delivers them.
+// =============================================================================
+
+// newNotificationService creates the notification service with the Discord,
+// email and webhook senders, backed by the notification tables
+func newNotificationService(db *sql.DB) (*notifications.NotificationService, *notifications.SQLNotificationRepository) {
+	repo := notifications.NewSQLNotificationRepository(db)
+	service := notifications.NewNotificationService(notifications.DefaultNotificationConfig())
+	service.SetPreferencesProvider(repo)
+	service.SetRepository(repo)
+	service.RegisterSender(notifications.NewDiscordWebhookSender(notifications.DiscordConfig{}))
+	// Email is only available when SMTP_HOST and SMTP_FROM are set
+	service.RegisterSender(notifications.NewSMTPEmailSender(notifications.EmailConfig{
+		Host:     getEnv("SMTP_HOST", ""),
+		Port:     getEnvInt("SMTP_PORT", 587),
+		Username: getEnv("SMTP_USER", ""),
+		Password: getEnv("SMTP_PASSWORD", ""),
+		From:     getEnv("SMTP_FROM", ""),
+	}))
+	service.RegisterSender(notifications.NewWebhookDispatcher(notifications.DefaultWebhookConfig(), repo))
+	return service, repo
+}
+
+// notifyBlockFound sends a found block to the pool-wide alert destinations
+func (s *StratumServer) notifyBlockFound(height, reward int64) {
+	if s.notifier == nil {
+		return
+	}
+	coin := "LTC"
+	if s.networkLoader != nil {
+		if activeNet := s.networkLoader.GetActiveNetwork(); activeNet != nil {
+			coin = activeNet.Symbol
+		}
+	}
+	s.notifier.SendPoolAlert(context.Background(), notifications.NewBlockFoundAlert(height, reward, coin))
+	log.Printf("📣 Block %d queued for pool alert webhooks", height)
+}
@@ -10,6 +10,9 @@ import (
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/chimera-pool/chimera-pool-core/internal/stratum"
+	"github.com/chimera-pool/chimera-pool-core/internal/stratum/vardiff"
 )
 
 // wrapMockDB wraps a sqlmock DB into a ResilientDB for testing
@@ -58,17 +61,22 @@ func TestMinerStructHasUserTracking(t *testing.T) {
 	assert.Equal(t, "testuser", miner.Username, "Miner should have Username field")
 }
 
-// TestAuthorizeUserLookup tests that authorization looks up user by username
-func TestAuthorizeUserLookup(t *testing.T) {
-	// Create mock database
-	db, mock, err := sqlmock.New()
-	require.NoError(t, err)
-	defer db.Close()
+// newTestAuthenticator knows user 34 under the given name, with miner 5 as its default worker
+func newTestAuthenticator(username string) *stratum.MockAuthenticator {
+	auth := stratum.NewMockAuthenticator()
+	auth.AllowAll = false
+	auth.AddUser(&stratum.UserInfo{ID: 34, Username: username, IsActive: true})
+	auth.AddMiner(&stratum.MinerInfo{ID: 5, UserID: 34, WorkerName: "default", IsActive: true})
+	return auth
+}
 
+// TestAuthorizeUserLookup tests that authorization resolves the user and miner IDs
+func TestAuthorizeUserLookup(t *testing.T) {
 	server := &StratumServer{
-		config: &Config{Difficulty: 1.0},
-		db:     wrapMockDB(db),
-		miners: make(map[string]*Miner),
+		config:         &Config{Difficulty: 1.0},
+		miners:         make(map[string]*Miner),
+		authenticator:  newTestAuthenticator("picaxe"),
+		vardiffManager: vardiff.NewManager(vardiff.DefaultConfig()),
 	}
 
 	mockConn := &MockConn{}
@@ -81,21 +89,6 @@ func TestAuthorizeUserLookup(t *testing.T) {
 
 	// Test case: User exists
 	t.Run("user exists", func(t *testing.T) {
-		// Mock user lookup - now queries both id and username, checks username OR email
-		mock.ExpectQuery("SELECT id, username FROM users WHERE").
-			WithArgs("picaxe").
-			WillReturnRows(sqlmock.NewRows([]string{"id", "username"}).AddRow(int64(34), "picaxe"))
-
-		// Mock miner lookup (not found)
-		mock.ExpectQuery("SELECT id FROM miners WHERE user_id").
-			WithArgs(int64(34), "picaxe").
-			WillReturnError(sql.ErrNoRows)
-
-		// Mock miner insert - address is now IP only (port stripped), includes network_id
-		mock.ExpectExec("INSERT INTO miners").
-			WithArgs(int64(34), "picaxe", "192.168.1.100", sqlmock.AnyArg()).
-			WillReturnResult(sqlmock.NewResult(1, 1))
-
 		req := StratumRequest{
 			ID:     1,
 			Method: "mining.authorize",
@@ -106,20 +99,19 @@ func TestAuthorizeUserLookup(t *testing.T) {
 		assert.NoError(t, err)
 		assert.True(t, miner.Authorized)
 		assert.Equal(t, int64(34), miner.UserID)
+		assert.Equal(t, int64(5), miner.MinerDBID)
 		assert.Equal(t, "picaxe", miner.Username)
+		assert.Equal(t, "default", miner.WorkerName)
 	})
 }
 
 // TestAuthorizeUserNotFound tests authorization fails for unknown users
 func TestAuthorizeUserNotFound(t *testing.T) {
-	db, mock, err := sqlmock.New()
-	require.NoError(t, err)
-	defer db.Close()
-
 	server := &StratumServer{
-		config: &Config{Difficulty: 1.0},
-		db:     wrapMockDB(db),
-		miners: make(map[string]*Miner),
+		config:         &Config{Difficulty: 1.0},
+		miners:         make(map[string]*Miner),
+		authenticator:  newTestAuthenticator("picaxe"),
+		vardiffManager: vardiff.NewManager(vardiff.DefaultConfig()),
 	}
 
 	mockConn := &MockConn{}
@@ -130,18 +122,13 @@ func TestAuthorizeUserNotFound(t *testing.T) {
 		Difficulty: 1.0,
 	}
 
-	// Mock user lookup - not found (queries id+username, checks username OR email)
-	mock.ExpectQuery("SELECT id, username FROM users WHERE").
-		WithArgs("unknown_user").
-		WillReturnError(sql.ErrNoRows)
-
 	req := StratumRequest{
 		ID:     1,
 		Method: "mining.authorize",
 		Params: []interface{}{"unknown_user", "x"},
 	}
 
-	err = server.handleAuthorize(miner, req)
+	err := server.handleAuthorize(miner, req)
 	assert.NoError(t, err) // No error returned, but response indicates failure
 	assert.False(t, miner.Authorized)
 	assert.Equal(t, int64(0), miner.UserID)
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/chimera-pool/chimera-pool-core/internal/stratum/blocknotify"
+)
+
+// =============================================================================
+// NEW-BLOCK NOTIFICATION
+// Templates are refreshed as soon as the node reports a new block, via its
+// ZMQ hashblock feed or getblocktemplate longpoll. The fixed poll only runs
+// while neither is available.
+// =============================================================================
+
+// Block notification settings
+const (
+	templatePollInterval = 5 * time.Second
+	// longpollTimeout bounds a single longpoll; the node answers sooner on any template change
+	longpollTimeout = 20 * time.Minute
+)
+
+// blockTemplateUpdater keeps the current job in step with the node until shutdown
+func (s *StratumServer) blockTemplateUpdater() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-s.done
+		cancel()
+	}()
+
+	s.blockWatcher.Run(ctx)
+}
+
+// newBlockWatcher builds the watcher from config.BlockNotify, always ending
+// with the poll so templates keep flowing if every push source is down
+func (s *StratumServer) newBlockWatcher() *blocknotify.Watcher {
+	var sources []blocknotify.Source
+	for _, name := range s.config.BlockNotify {
+		switch strings.TrimSpace(name) {
+		case "zmq":
+			if s.config.LitecoinZMQBlock == "" {
+				log.Println("⚠️ ZMQ block notifications need LITECOIN_ZMQ_HASHBLOCK; skipping")
+				continue
+			}
+			sources = append(sources, blocknotify.NewZMQSource(s.config.LitecoinZMQBlock))
+		case "longpoll":
+			sources = append(sources, blocknotify.NewLongpollSource(s.longpollBlockTemplate))
+		case "poll":
+			// Added last below
+		default:
+			log.Printf("⚠️ Unknown block notifier %q ignored", name)
+		}
+	}
+	sources = append(sources, blocknotify.NewPollSource(templatePollInterval))
+
+	watcher := blocknotify.NewWatcher(blocknotify.DefaultConfig(), s.onBlockEvent, sources...)
+	log.Printf("🔔 Block notifications: %s", strings.Join(watcher.SourceNames(), " → "))
+	return watcher
+}
+
+// onBlockEvent refreshes the template unless the source already did
+func (s *StratumServer) onBlockEvent(event blocknotify.Event) {
+	if event.BlockHash != "" {
+		log.Printf("🔔 New block %s announced via %s", event.BlockHash, event.Source)
+	}
+	if !event.TemplateFetched {
+		s.updateBlockTemplate()
+	}
+}
+
+// longpollBlockTemplate waits for the node's template to change from
+// longpollID, applies the new template and returns its longpoll ID
+func (s *StratumServer) longpollBlockTemplate(ctx context.Context, longpollID string) (string, error) {
+	request := map[string]interface{}{"rules": []string{"segwit", "mweb"}}
+	if longpollID != "" {
+		request["longpollid"] = longpollID
+	}
+
+	node, err := s.upstreams.SelectForTemplate()
+	if err != nil {
+		return "", err
+	}
+	result, err := s.doLitecoinRPCContext(ctx, node, "getblocktemplate", []interface{}{request}, longpollTimeout)
+	if err != nil {
+		if isNodeFault(err) && ctx.Err() == nil {
+			s.upstreams.RecordFailure(node.Name, err)
+		}
+		return "", err
+	}
+	var tmpl BlockTemplate
+	if err := json.Unmarshal(result, &tmpl); err != nil {
+		return "", err
+	}
+	if err := s.checkTemplateTip(node, &tmpl); err != nil {
+		return "", err
+	}
+
+	s.applyBlockTemplate(&tmpl)
+	return tmpl.LongPollID, nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/chimera-pool/chimera-pool-core/internal/stratum/blocknotify"
+)
+
+// fakeLongpollNode serves getblocktemplate, answering each longpoll with the next template
+func fakeLongpollNode(t *testing.T, templates []map[string]interface{}) (*httptest.Server, *[]string) {
+	var mu sync.Mutex
+	var seen []string
+	call := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string                   `json:"method"`
+			Params []map[string]interface{} `json:"params"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.Equal(t, "getblocktemplate", req.Method)
+
+		mu.Lock()
+		id, _ := req.Params[0]["longpollid"].(string)
+		seen = append(seen, id)
+		tmpl := templates[call]
+		call++
+		mu.Unlock()
+
+		json.NewEncoder(w).Encode(map[string]interface{}{"result": tmpl, "error": nil})
+	}))
+	t.Cleanup(server.Close)
+	return server, &seen
+}
+
+func testTemplate(height int, prevHash, longpollID string) map[string]interface{} {
+	return map[string]interface{}{
+		"version":           536870912,
+		"previousblockhash": prevHash,
+		"coinbasevalue":     625000000,
+		"bits":              "1a0ffff0",
+		"curtime":           1700000000,
+		"height":            height,
+		"longpollid":        longpollID,
+	}
+}
+
+func TestLongpollBlockTemplate_AppliesTemplatesAndBroadcastsNewTip(t *testing.T) {
+	node, seen := fakeLongpollNode(t, []map[string]interface{}{
+		testTemplate(100, strings.Repeat("aa", 32), "lp-1"),
+		testTemplate(100, strings.Repeat("aa", 32), "lp-2"), // Fee update on the same tip
+		testTemplate(100, strings.Repeat("bb", 32), "lp-3"), // Reorg at the same height
+	})
+
+	s := newValidationTestServer()
+	s.upstreams = testUpstreams(node.URL)
+	conn := &MockConn{}
+	s.miners["m1"] = &Miner{ID: "m1", Conn: conn, Authorized: true}
+
+	id, err := s.longpollBlockTemplate(context.Background(), "")
+	require.NoError(t, err)
+	assert.Equal(t, "lp-1", id)
+	assert.Equal(t, 1, strings.Count(string(conn.written), "mining.notify"))
+
+	id, err = s.longpollBlockTemplate(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, "lp-2", id)
+	assert.Equal(t, 1, strings.Count(string(conn.written), "mining.notify"), "same tip is not rebroadcast")
+
+	id, err = s.longpollBlockTemplate(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, "lp-3", id)
+	assert.Equal(t, 2, strings.Count(string(conn.written), "mining.notify"), "new previous hash is broadcast")
+	assert.Contains(t, string(conn.written), ",true]", "new tips are sent with clean_jobs")
+
+	assert.Equal(t, []string{"", "lp-1", "lp-2"}, *seen)
+	assert.Len(t, s.jobs, 1, "clean_jobs retires the older jobs")
+}
+
+func TestNewBlockWatcher_SourceOrder(t *testing.T) {
+	s := newValidationTestServer()
+
+	// ZMQ without an endpoint is skipped; poll is always the final fallback
+	s.config.BlockNotify = []string{"zmq", "longpoll", "poll"}
+	assert.Equal(t, []string{"longpoll", "poll"}, s.newBlockWatcher().SourceNames())
+
+	s.config.LitecoinZMQBlock = "tcp://127.0.0.1:28332"
+	s.config.BlockNotify = []string{"zmq", "bogus"}
+	assert.Equal(t, []string{"zmq", "poll"}, s.newBlockWatcher().SourceNames())
+}
+
+func TestOnBlockEvent_SkipsFetchedTemplates(t *testing.T) {
+	calls := 0
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		json.NewEncoder(w).Encode(map[string]interface{}{"result": testTemplate(5, strings.Repeat("cc", 32), ""), "error": nil})
+	}))
+	defer node.Close()
+
+	s := newValidationTestServer()
+	s.upstreams = testUpstreams(node.URL)
+
+	s.onBlockEvent(blocknotify.Event{Source: "longpoll", TemplateFetched: true})
+	assert.Equal(t, 0, calls)
+
+	s.onBlockEvent(blocknotify.Event{Source: "zmq", BlockHash: strings.Repeat("cc", 32)})
+	assert.Equal(t, 1, calls)
+	require.NotNil(t, s.currentJob)
+	assert.Equal(t, int64(5), s.currentJob.Height)
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/chimera-pool/chimera-pool-core/internal/database"
+	"github.com/chimera-pool/chimera-pool-core/internal/shares"
+	"github.com/chimera-pool/chimera-pool-core/internal/stratum"
+	"github.com/chimera-pool/chimera-pool-core/internal/stratum/detector"
+	v2binary "github.com/chimera-pool/chimera-pool-core/internal/stratum/v2/binary"
+)
+
+// =============================================================================
+// POOL COORDINATOR INTEGRATION
+// The coordinator owns the main listener, connection limits, protocol routing
+// and proof-of-work checks; the server's job-aware V1 and V2 logic plugs in as
+// protocol handlers. Shares are persisted in batches, off the submit path.
+// =============================================================================
+
+// shareProcessor validates shares (satisfied by *stratum.PoolCoordinator,
+// which runs them on its batch worker pool, and by *shares.ShareProcessor)
+type shareProcessor interface {
+	ProcessShare(share *shares.Share) shares.ShareProcessingResult
+}
+
+// newPoolCoordinator creates the coordinator that serves every stratum port
+func newPoolCoordinator(config *Config, algorithm string, ports []*detector.PortProfile) *stratum.PoolCoordinator {
+	coordinatorConfig := stratum.DefaultPoolCoordinatorConfig()
+	coordinatorConfig.ListenAddress = fmt.Sprintf("0.0.0.0:%s", config.Port)
+	coordinatorConfig.Ports = ports
+	if algorithm != "" {
+		coordinatorConfig.Algorithm = algorithm
+	}
+	return stratum.NewPoolCoordinator(coordinatorConfig)
+}
+
+// minerID names a connection in logs and in the miners map
+func minerID(conn net.Conn) string {
+	return fmt.Sprintf("%s-%d", conn.RemoteAddr().String(), time.Now().UnixNano())
+}
+
+// protocolHandlers returns the server's handlers for the coordinator's router
+func (s *StratumServer) protocolHandlers() []detector.Handler {
+	handlers := []detector.Handler{
+		&v1ProtocolHandler{server: s},
+		&v2ProtocolHandler{server: s},
+		&fallbackProtocolHandler{server: s},
+	}
+	if s.noiseConfig != nil {
+		handlers = append(handlers, &noiseProtocolHandler{server: s})
+	}
+	return handlers
+}
+
+// forcesSolo reports whether a connection arrived on a solo port
+func forcesSolo(conn net.Conn) bool {
+	profile := detector.ProfileOf(conn)
+	return profile != nil && profile.ForceSolo
+}
+
+// v1ProtocolHandler serves Stratum V1 JSON connections
+type v1ProtocolHandler struct {
+	server *StratumServer
+}
+
+func (h *v1ProtocolHandler) HandleConnection(conn net.Conn) error {
+	h.server.handleV1Connection(conn, minerID(conn))
+	return nil
+}
+
+func (h *v1ProtocolHandler) Protocol() detector.ProtocolVersion { return detector.ProtocolV1 }
+func (h *v1ProtocolHandler) Shutdown() error                    { return nil }
+
+// v2ProtocolHandler serves unencrypted Stratum V2 connections when allowed;
+// V2 inside stratum TLS is always accepted
+type v2ProtocolHandler struct {
+	server *StratumServer
+}
+
+func (h *v2ProtocolHandler) HandleConnection(conn net.Conn) error {
+	id := minerID(conn)
+	if !h.server.config.V2AllowPlaintext && !detector.IsTLS(conn) {
+		log.Printf("Rejected plaintext Stratum V2 from %s (use the Noise port)", id)
+		return nil
+	}
+
+	header := make([]byte, v2binary.HeaderSize)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return err
+	}
+
+	log.Printf("Detected Stratum V2 binary protocol from %s", id)
+	h.server.handleV2Connection(conn, header, id)
+	return nil
+}
+
+func (h *v2ProtocolHandler) Protocol() detector.ProtocolVersion { return detector.ProtocolV2 }
+func (h *v2ProtocolHandler) Shutdown() error                    { return nil }
+
+// fallbackProtocolHandler answers HTTP probes and logs unknown protocols
+type fallbackProtocolHandler struct {
+	server *StratumServer
+}
+
+func (h *fallbackProtocolHandler) HandleConnection(conn net.Conn) error {
+	id := minerID(conn)
+
+	// The detection bytes are still buffered, so this does not block
+	initial := make([]byte, detector.PeekSize)
+	n, _ := io.ReadFull(conn, initial)
+	initial = initial[:n]
+
+	if len(initial) >= 4 && (string(initial[:3]) == "GET" || string(initial[:4]) == "POST") {
+		// HTTP request - health check or metrics scrape (suppress verbose logging)
+		h.server.handleHTTPProbe(conn, initial, id)
+		return nil
+	}
+
+	log.Printf("Unknown protocol from %s, first bytes: %x", id, initial)
+	h.server.handleUnknownProtocol(conn, initial, id)
+	return nil
+}
+
+func (h *fallbackProtocolHandler) Protocol() detector.ProtocolVersion {
+	return detector.ProtocolUnknown
+}
+func (h *fallbackProtocolHandler) Shutdown() error { return nil }
+
+// =============================================================================
+// BATCHED SHARE PERSISTENCE
+// =============================================================================
+
+// batchExecer runs batch inserts through the resilient connection
+type batchExecer struct {
+	db *ResilientDB
+}
+
+func (e batchExecer) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return e.db.ExecContext(ctx, query, args...)
+}
+
+// shareBatchRecorder queues shares on the batch inserter (implements stratum.ShareRecorder)
+type shareBatchRecorder struct {
+	inserter  *database.ShareBatchInserter
+	networkID *uuid.UUID
+}
+
+// newShareBatchRecorder creates a recorder that tags shares with the active network
+func newShareBatchRecorder(inserter *database.ShareBatchInserter, networkID *uuid.UUID) *shareBatchRecorder {
+	return &shareBatchRecorder{inserter: inserter, networkID: networkID}
+}
+
+// RecordShare queues a share; rejectReason is empty for valid shares
+func (r *shareBatchRecorder) RecordShare(share *shares.Share, rejectReason string) {
+	timestamp := share.Timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+	r.inserter.Insert(&database.Share{
+		MinerID:      share.MinerID,
+		UserID:       share.UserID,
+		Difficulty:   share.Difficulty,
+		IsValid:      rejectReason == "",
+		Timestamp:    timestamp,
+		Nonce:        share.Nonce,
+		Hash:         share.Hash,
+		NetworkID:    r.networkID,
+		RejectReason: rejectReason,
+		Solo:         share.Solo,
+	})
+}
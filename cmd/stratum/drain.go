@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"time"
+
+	v2binary "github.com/chimera-pool/chimera-pool-core/internal/stratum/v2/binary"
+)
+
+// =============================================================================
+// GRACEFUL DRAIN
+// On shutdown the coordinator stops accepting, then connected miners are
+// asked to reconnect (client.reconnect on V1, Reconnect on V2) a batch at a
+// time so the farm does not stampede the next instance. Miners can be pointed
+// at a sibling with STRATUM_RECONNECT_TO; otherwise they come back to the
+// same address, served by the restarted (or handed-over) process.
+// =============================================================================
+
+const (
+	defaultDrainBatchSize = 100
+	defaultDrainInterval  = time.Second
+	defaultDrainTimeout   = 2 * time.Minute
+	drainPollInterval     = 100 * time.Millisecond
+)
+
+// drainConfig paces a drain
+type drainConfig struct {
+	BatchSize int           // Miners asked to reconnect per interval
+	Interval  time.Duration // Pause between batches
+	Timeout   time.Duration // How long to wait for miners to leave before closing them
+	Host      string        // Reconnect target; "" means this address
+	Port      uint16
+}
+
+// newDrainConfig reads the drain settings
+func newDrainConfig(config *Config) (drainConfig, error) {
+	drain := drainConfig{
+		BatchSize: config.DrainBatchSize,
+		Interval:  config.DrainInterval,
+		Timeout:   config.DrainTimeout,
+	}
+	if drain.BatchSize <= 0 {
+		drain.BatchSize = defaultDrainBatchSize
+	}
+	if config.ReconnectTo == "" {
+		return drain, nil
+	}
+
+	host, portValue, err := net.SplitHostPort(config.ReconnectTo)
+	if err != nil {
+		return drain, fmt.Errorf("STRATUM_RECONNECT_TO: %w", err)
+	}
+	port, err := strconv.ParseUint(portValue, 10, 16)
+	if err != nil || host == "" {
+		return drain, fmt.Errorf("STRATUM_RECONNECT_TO: invalid address %q", config.ReconnectTo)
+	}
+	drain.Host, drain.Port = host, uint16(port)
+	return drain, nil
+}
+
+// Drain asks every connected miner to reconnect, BatchSize miners per
+// Interval, and waits up to Timeout for them to disconnect. It returns how
+// many are still connected; Shutdown closes those. Call after the
+// coordinator has stopped accepting.
+func (s *StratumServer) Drain(ctx context.Context, drain drainConfig) int {
+	s.minersMutex.RLock()
+	miners := make([]*Miner, 0, len(s.miners))
+	for _, miner := range s.miners {
+		miners = append(miners, miner)
+	}
+	s.minersMutex.RUnlock()
+
+	target := "this address"
+	if drain.Host != "" {
+		target = net.JoinHostPort(drain.Host, strconv.Itoa(int(drain.Port)))
+	}
+	log.Printf("🚰 Draining %d miners to %s (%d every %s)", len(miners), target, drain.BatchSize, drain.Interval)
+
+	ctx, cancel := context.WithTimeout(ctx, drain.Timeout)
+	defer cancel()
+
+	for start := 0; start < len(miners); start += drain.BatchSize {
+		if start > 0 && !sleepCtx(ctx, drain.Interval) {
+			break
+		}
+		end := start + drain.BatchSize
+		if end > len(miners) {
+			end = len(miners)
+		}
+		for _, miner := range miners[start:end] {
+			if err := s.sendReconnect(miner, drain.Host, drain.Port); err != nil {
+				// It will be closed at shutdown
+				log.Printf("[%s] Reconnect request failed: %v", miner.ID, err)
+			}
+		}
+	}
+
+	// Miners drop the connection once they have moved
+	for s.minerCount() > 0 {
+		if !sleepCtx(ctx, drainPollInterval) {
+			break
+		}
+	}
+
+	remaining := s.minerCount()
+	log.Printf("🚰 Drain finished, %d miners still connected", remaining)
+	return remaining
+}
+
+// sendReconnect asks a miner to reconnect to host:port, or to the address it
+// is connected to when host is empty
+func (s *StratumServer) sendReconnect(miner *Miner, host string, port uint16) error {
+	if miner.IsV2 {
+		ser := v2binary.NewSerializer()
+		payload := ser.SerializeReconnect(&v2binary.Reconnect{NewHost: v2binary.STR0_255(host), NewPort: port})
+		frame := ser.SerializeFrame(v2binary.MsgTypeReconnect, 0, payload)
+		_, err := miner.Conn.Write(frame)
+		return err
+	}
+
+	// client.reconnect without params means "same pool URL"
+	params := []interface{}{}
+	if host != "" {
+		params = []interface{}{host, port, 0}
+	}
+	return s.sendNotification(miner, "client.reconnect", params)
+}
+
+// minerCount returns the number of connected miners
+func (s *StratumServer) minerCount() int {
+	s.minersMutex.RLock()
+	defer s.minersMutex.RUnlock()
+	return len(s.miners)
+}
+
+// sleepCtx waits for d and reports false if ctx ended first
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	v2binary "github.com/chimera-pool/chimera-pool-core/internal/stratum/v2/binary"
+)
+
+func TestNewDrainConfig(t *testing.T) {
+	drain, err := newDrainConfig(&Config{ReconnectTo: "pool-b.internal:3333", DrainInterval: time.Second})
+	require.NoError(t, err)
+	assert.Equal(t, "pool-b.internal", drain.Host)
+	assert.Equal(t, uint16(3333), drain.Port)
+	assert.Equal(t, defaultDrainBatchSize, drain.BatchSize)
+
+	drain, err = newDrainConfig(&Config{})
+	require.NoError(t, err)
+	assert.Empty(t, drain.Host)
+
+	for _, invalid := range []string{"pool-b", "pool-b:70000", ":3333"} {
+		_, err := newDrainConfig(&Config{ReconnectTo: invalid})
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestDrainSendsReconnect(t *testing.T) {
+	server := newValidationTestServer()
+	v1 := &Miner{ID: "v1", Conn: &MockConn{}}
+	v2 := &Miner{ID: "v2", Conn: &MockConn{}, IsV2: true}
+	server.miners[v1.ID] = v1
+	server.miners[v2.ID] = v2
+
+	drain := drainConfig{BatchSize: 1, Interval: 5 * time.Millisecond, Timeout: 50 * time.Millisecond, Host: "pool-b.internal", Port: 3334}
+	remaining := server.Drain(context.Background(), drain)
+	assert.Equal(t, 2, remaining, "mock miners never disconnect")
+
+	assert.Contains(t, string(v1.Conn.(*MockConn).written), `"method":"client.reconnect","params":["pool-b.internal",3334,0]`)
+
+	frame := v2.Conn.(*MockConn).written
+	header, err := v2binary.ParseHeader(frame)
+	require.NoError(t, err)
+	assert.Equal(t, v2binary.MsgTypeReconnect, header.MsgType)
+	reconnect, err := v2binary.NewDeserializer(frame[v2binary.HeaderSize:]).DeserializeReconnect()
+	require.NoError(t, err)
+	assert.Equal(t, "pool-b.internal", string(reconnect.NewHost))
+	assert.Equal(t, uint16(3334), reconnect.NewPort)
+}
+
+func TestDrainToSameAddress(t *testing.T) {
+	server := newValidationTestServer()
+	miner := &Miner{ID: "v1", Conn: &MockConn{}}
+	server.miners[miner.ID] = miner
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		server.minersMutex.Lock()
+		delete(server.miners, miner.ID)
+		server.minersMutex.Unlock()
+	}()
+
+	remaining := server.Drain(context.Background(), drainConfig{BatchSize: 10, Timeout: time.Second})
+	assert.Zero(t, remaining)
+	assert.Contains(t, string(miner.Conn.(*MockConn).written), `"method":"client.reconnect","params":[]`)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+
+	"github.com/chimera-pool/chimera-pool-core/internal/stratum"
+)
+
+// =============================================================================
+// LISTENER HANDOFF
+// On SIGUSR2 the server starts a copy of itself that inherits the listening
+// sockets, then drains. The kernel keeps queueing connections on the shared
+// sockets throughout, so a restart refuses no miner; drained miners
+// reconnect straight into the new process.
+// =============================================================================
+
+// listenFDsEnv tells a successor how many listening sockets it inherited,
+// starting at file descriptor 3
+const listenFDsEnv = "STRATUM_LISTEN_FDS"
+
+// firstInheritedFD is the first descriptor after stdin, stdout and stderr
+const firstInheritedFD = 3
+
+// inheritedListeners returns the sockets handed over by a predecessor, if any
+func inheritedListeners() ([]net.Listener, error) {
+	value := os.Getenv(listenFDsEnv)
+	if value == "" {
+		return nil, nil
+	}
+	os.Unsetenv(listenFDsEnv)
+
+	count, err := strconv.Atoi(value)
+	if err != nil || count < 0 {
+		return nil, fmt.Errorf("%s: invalid count %q", listenFDsEnv, value)
+	}
+
+	listeners := make([]net.Listener, 0, count)
+	for i := 0; i < count; i++ {
+		file := os.NewFile(uintptr(firstInheritedFD+i), fmt.Sprintf("stratum-listener-%d", i))
+		listener, err := net.FileListener(file)
+		file.Close() // FileListener holds its own descriptor
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return nil, fmt.Errorf("inherited listener %d: %w", i, err)
+		}
+		listeners = append(listeners, listener)
+	}
+	return listeners, nil
+}
+
+// startSuccessor starts a new instance of this binary that inherits files as
+// its listening sockets
+func startSuccessor(files []*os.File) (*os.Process, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("find executable: %w", err)
+	}
+
+	cmd := exec.Command(executable, os.Args[1:]...)
+	cmd.Env = append(os.Environ(), fmt.Sprintf("%s=%d", listenFDsEnv, len(files)))
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = files
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start successor: %w", err)
+	}
+	return cmd.Process, nil
+}
+
+// handOff starts a successor on the coordinator's listening sockets
+func handOff(coordinator *stratum.PoolCoordinator) error {
+	files, err := coordinator.ListenerFiles()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		for _, file := range files {
+			file.Close()
+		}
+	}()
+
+	process, err := startSuccessor(files)
+	if err != nil {
+		return err
+	}
+	log.Printf("🔁 Handed %d listening sockets to process %d", len(files), process.Pid)
+	return nil
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net"
+	"time"
+
+	"github.com/chimera-pool/chimera-pool-core/internal/payouts"
+	"github.com/chimera-pool/chimera-pool-core/internal/stratum"
+	"github.com/chimera-pool/chimera-pool-core/internal/stratum/blockdag"
+	v2 "github.com/chimera-pool/chimera-pool-core/internal/stratum/v2"
+	v2binary "github.com/chimera-pool/chimera-pool-core/internal/stratum/v2/binary"
+	"github.com/chimera-pool/chimera-pool-core/internal/stratum/v2/noise"
+)
+
+// =============================================================================
+// STRATUM V2 JOB DECLARATION
+// Miners that build their own templates declare them on a dedicated port.
+// Accepted declarations are registered with the SLICE calculator so the
+// work is credited under SLICE payouts.
+// =============================================================================
+
+// SLICE calculator settings used for declared work
+const (
+	sliceCount             = 12
+	sliceDurationSeconds   = 300
+	sliceDecayFactor       = 0.9
+	jdDeclarationRetention = 24 * time.Hour
+)
+
+// startJobDeclaration starts the Job Declaration server on config.JDPort
+func (s *StratumServer) startJobDeclaration() error {
+	calc, err := payouts.NewSLICECalculator(sliceCount, sliceDurationSeconds, sliceDecayFactor, s.config.PoolFeePercent)
+	if err != nil {
+		return fmt.Errorf("create SLICE calculator: %w", err)
+	}
+	s.sliceCalculator = calc
+
+	jdConfig := v2.DefaultJobNegotiationConfig()
+	jdConfig.Enabled = true
+	jdConfig.ListenAddress = fmt.Sprintf("0.0.0.0:%s", s.config.JDPort)
+
+	script, _ := hex.DecodeString(poolOutputScriptHex)
+
+	jd := v2.NewJobDeclaratorServer(jdConfig)
+	jd.SetTemplateProvider(&jobTemplateProvider{server: s})
+	jd.SetPoolOutputScript(script)
+	jd.SetDeclarationHandler(s.registerJobDeclaration)
+	jd.SetCustomJobHandler(s.activateJobDeclaration)
+	if s.noiseConfig != nil {
+		noiseConfig := s.noiseConfig
+		jd.SetConnWrapper(func(conn net.Conn) (net.Conn, error) {
+			conn.SetDeadline(time.Now().Add(noiseHandshakeTimeout))
+			secure, err := noise.ServerHandshake(conn, noiseConfig)
+			if err != nil {
+				return nil, err
+			}
+			conn.SetDeadline(time.Time{})
+			return secure, nil
+		})
+	}
+
+	if err := jd.Start(); err != nil {
+		return err
+	}
+	s.jobDeclarator = jd
+	return nil
+}
+
+// registerJobDeclaration records an accepted declaration with the SLICE calculator
+func (s *StratumServer) registerJobDeclaration(decl *stratum.JobDeclaration, msg *v2binary.DeclareMiningJob) {
+	var userID int64
+	err := s.db.QueryRow(
+		"SELECT id FROM users WHERE (username = $1 OR email = $1) AND is_active = true",
+		decl.MinerID,
+	).Scan(&userID)
+	if err == sql.ErrNoRows {
+		log.Printf("Job declaration %s from unknown user '%s' not credited", decl.DeclarationID, decl.MinerID)
+		return
+	} else if err != nil {
+		log.Printf("Failed to look up job declarator '%s': %v", decl.MinerID, err)
+		return
+	}
+
+	template := decl.Template
+	err = s.sliceCalculator.RegisterJobDeclaration(&payouts.JobDeclaration{
+		MinerID:        userID,
+		JobID:          decl.DeclarationID,
+		PrevHash:       hex.EncodeToString(template.PrevHash),
+		CoinbasePrefix: msg.CoinbasePrefix,
+		CoinbaseSuffix: msg.CoinbaseSuffix,
+		Version:        msg.Version,
+		NBits:          template.Bits,
+		NTime:          template.Timestamp,
+	})
+	if err != nil {
+		log.Printf("Failed to register job declaration %s: %v", decl.DeclarationID, err)
+		return
+	}
+	s.sliceCalculator.CleanupOldDeclarations(jdDeclarationRetention)
+
+	log.Printf("📜 Job declaration %s accepted for %s (id:%d, %d txs)",
+		decl.DeclarationID, decl.MinerID, userID, len(template.Transactions))
+}
+
+// activateJobDeclaration marks a declaration as mined once SetCustomMiningJob succeeds
+func (s *StratumServer) activateJobDeclaration(decl *stratum.JobDeclaration, msg *v2binary.SetCustomMiningJob) {
+	if _, err := s.sliceCalculator.ValidateJobDeclaration(decl.DeclarationID); err != nil {
+		log.Printf("Custom job for declaration %s not credited: %v", decl.DeclarationID, err)
+	}
+}
+
+// jobTemplateProvider exposes the server's current job as a stratum.TemplateProvider
+type jobTemplateProvider struct {
+	server *StratumServer
+}
+
+// GetTemplate converts the current mining job to a block template
+func (p *jobTemplateProvider) GetTemplate() (*stratum.BlockTemplate, error) {
+	p.server.jobMutex.RLock()
+	job := p.server.currentJob
+	p.server.jobMutex.RUnlock()
+	if job == nil {
+		return nil, errV2NoJob
+	}
+
+	version, err := parseHexUint32(job.Version)
+	if err != nil {
+		return nil, errInvalidJob
+	}
+	bits, err := parseHexUint32(job.NBits)
+	if err != nil {
+		return nil, errInvalidJob
+	}
+	ntime, err := parseHexUint32(job.NTime)
+	if err != nil {
+		return nil, errInvalidJob
+	}
+	prevHash, err := v2PrevHash(job)
+	if err != nil {
+		return nil, err
+	}
+
+	txs := make([][]byte, 0, len(job.Transactions))
+	for _, txHex := range job.Transactions {
+		tx, err := hex.DecodeString(txHex)
+		if err != nil {
+			return nil, errInvalidJob
+		}
+		txs = append(txs, tx)
+	}
+
+	return &stratum.BlockTemplate{
+		TemplateID:    job.JobID,
+		Version:       version,
+		PrevHash:      prevHash[:],
+		Timestamp:     ntime,
+		Bits:          bits,
+		Height:        uint64(job.Height),
+		CoinbaseValue: uint64(job.CoinbaseValue),
+		Transactions:  txs,
+		Target:        blockdag.CompactToTarget(bits),
+		Algorithm:     "scrypt",
+		Coin:          "LTC",
+		MinTime:       ntime,
+		MaxTime:       ntime + 7200,
+	}, nil
+}
+
+// GetTemplateForHeight returns the current template if it is for height
+func (p *jobTemplateProvider) GetTemplateForHeight(height uint64) (*stratum.BlockTemplate, error) {
+	template, err := p.GetTemplate()
+	if err != nil {
+		return nil, err
+	}
+	if template.Height != height {
+		return nil, fmt.Errorf("no template for height %d (current %d)", height, template.Height)
+	}
+	return template, nil
+}
+
+// SubscribeTemplates is not supported: jobs are pushed to miners by broadcastJob
+func (p *jobTemplateProvider) SubscribeTemplates(handler func(*stratum.BlockTemplate)) stratum.Subscription {
+	return inactiveSubscription{}
+}
+
+// GetCurrentHeight returns the height of the chain tip the current job builds on
+func (p *jobTemplateProvider) GetCurrentHeight() (uint64, error) {
+	template, err := p.GetTemplate()
+	if err != nil {
+		return 0, err
+	}
+	return template.Height - 1, nil
+}
+
+// GetNetworkDifficulty returns the difficulty encoded in the current job's nBits
+func (p *jobTemplateProvider) GetNetworkDifficulty() (uint64, error) {
+	template, err := p.GetTemplate()
+	if err != nil {
+		return 0, err
+	}
+	return blockdag.TargetToDifficulty(template.Target), nil
+}
+
+// inactiveSubscription is returned where template subscriptions are unsupported
+type inactiveSubscription struct{}
+
+func (inactiveSubscription) Unsubscribe()   {}
+func (inactiveSubscription) IsActive() bool { return false }
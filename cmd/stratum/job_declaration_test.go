@@ -0,0 +1,94 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/chimera-pool/chimera-pool-core/internal/payouts"
+	"github.com/chimera-pool/chimera-pool-core/internal/stratum"
+	v2binary "github.com/chimera-pool/chimera-pool-core/internal/stratum/v2/binary"
+)
+
+func TestJobTemplateProvider_GetTemplate(t *testing.T) {
+	s := newValidationTestServer()
+	provider := &jobTemplateProvider{server: s}
+
+	_, err := provider.GetTemplate()
+	assert.ErrorIs(t, err, errV2NoJob)
+
+	job, _, _ := genesisJob()
+	job.Height = 12
+	job.Transactions = []string{"0200000000"}
+	s.currentJob = job
+
+	template, err := provider.GetTemplate()
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1), template.Version)
+	assert.Equal(t, uint32(0x1e0ffff0), template.Bits)
+	assert.Equal(t, uint64(12), template.Height)
+	assert.Len(t, template.PrevHash, 32)
+	assert.Equal(t, [][]byte{{0x02, 0x00, 0x00, 0x00, 0x00}}, template.Transactions)
+
+	height, err := provider.GetCurrentHeight()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(11), height)
+
+	_, err = provider.GetTemplateForHeight(13)
+	assert.Error(t, err)
+}
+
+func TestRegisterJobDeclaration(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	s := newValidationTestServer()
+	s.db = wrapMockDB(db)
+	s.sliceCalculator, err = payouts.NewSLICECalculator(sliceCount, sliceDurationSeconds, sliceDecayFactor, 1.0)
+	require.NoError(t, err)
+
+	mock.ExpectQuery("SELECT id FROM users WHERE").
+		WithArgs("alice").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(7)))
+
+	decl := &stratum.JobDeclaration{
+		DeclarationID: "decl-1",
+		MinerID:       "alice",
+		Template:      &stratum.BlockTemplate{PrevHash: make([]byte, 32), Bits: 0x1e0ffff0, Timestamp: 1317972665},
+	}
+	msg := &v2binary.DeclareMiningJob{Version: 0x20000000, CoinbasePrefix: []byte{0x01}, CoinbaseSuffix: []byte{0x02}}
+	s.registerJobDeclaration(decl, msg)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	registered := s.sliceCalculator.GetJobDeclaration("decl-1")
+	require.NotNil(t, registered)
+	assert.Equal(t, int64(7), registered.MinerID)
+	assert.Equal(t, uint32(0x20000000), registered.Version)
+	assert.False(t, registered.Validated)
+
+	s.activateJobDeclaration(decl, &v2binary.SetCustomMiningJob{})
+	assert.True(t, s.sliceCalculator.GetJobDeclaration("decl-1").Validated)
+}
+
+func TestRegisterJobDeclaration_UnknownUser(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	s := newValidationTestServer()
+	s.db = wrapMockDB(db)
+	s.sliceCalculator, err = payouts.NewSLICECalculator(sliceCount, sliceDurationSeconds, sliceDecayFactor, 1.0)
+	require.NoError(t, err)
+
+	mock.ExpectQuery("SELECT id FROM users WHERE").
+		WithArgs("mallory").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	decl := &stratum.JobDeclaration{DeclarationID: "decl-2", MinerID: "mallory", Template: &stratum.BlockTemplate{}}
+	s.registerJobDeclaration(decl, &v2binary.DeclareMiningJob{})
+
+	assert.Nil(t, s.sliceCalculator.GetJobDeclaration("decl-2"))
+}
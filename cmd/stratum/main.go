@@ -8,6 +8,7 @@ import (
 	"encoding/binary"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -15,8 +16,10 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -24,14 +27,26 @@ import (
 	_ "github.com/lib/pq"
 	"github.com/redis/go-redis/v9"
 
+	"github.com/chimera-pool/chimera-pool-core/internal/database"
 	"github.com/chimera-pool/chimera-pool-core/internal/geolocation"
 	"github.com/chimera-pool/chimera-pool-core/internal/monitoring/health"
 	"github.com/chimera-pool/chimera-pool-core/internal/monitoring/recovery"
 	"github.com/chimera-pool/chimera-pool-core/internal/network"
+	"github.com/chimera-pool/chimera-pool-core/internal/notifications"
+	"github.com/chimera-pool/chimera-pool-core/internal/payouts"
+	"github.com/chimera-pool/chimera-pool-core/internal/shares"
+	"github.com/chimera-pool/chimera-pool-core/internal/stratum"
+	"github.com/chimera-pool/chimera-pool-core/internal/stratum/blockdag"
+	"github.com/chimera-pool/chimera-pool-core/internal/stratum/blocknotify"
+	"github.com/chimera-pool/chimera-pool-core/internal/stratum/detector"
+	"github.com/chimera-pool/chimera-pool-core/internal/stratum/events"
 	"github.com/chimera-pool/chimera-pool-core/internal/stratum/hashrate"
 	"github.com/chimera-pool/chimera-pool-core/internal/stratum/keepalive"
 	"github.com/chimera-pool/chimera-pool-core/internal/stratum/merkle"
+	"github.com/chimera-pool/chimera-pool-core/internal/stratum/session"
+	"github.com/chimera-pool/chimera-pool-core/internal/stratum/upstream"
 	v2binary "github.com/chimera-pool/chimera-pool-core/internal/stratum/v2/binary"
+	"github.com/chimera-pool/chimera-pool-core/internal/stratum/v2/noise"
 	"github.com/chimera-pool/chimera-pool-core/internal/stratum/vardiff"
 )
 
@@ -58,17 +73,19 @@ func main() {
 	// Initialize and start health monitoring service
 	ctx := context.Background()
 	healthService := initHealthMonitor(config)
+	stopHealth := func() {}
 	if healthService != nil {
 		if err := healthService.Start(ctx); err != nil {
 			log.Printf("⚠️ Failed to start health monitor: %v", err)
 		} else {
 			log.Println("✅ Health monitoring service started")
-			defer func() {
+			stopHealth = sync.OnceFunc(func() {
 				stopCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 				defer cancel()
 				healthService.Stop(stopCtx)
 				log.Println("✅ Health monitoring service stopped")
-			}()
+			})
+			defer stopHealth()
 		}
 	}
 
@@ -81,37 +98,120 @@ func main() {
 		log.Println("✅ Pool metrics provider connected to Prometheus exporter")
 	}
 
-	// Start listening on all interfaces (IPv4 and IPv6)
-	listener, err := net.Listen("tcp4", fmt.Sprintf("0.0.0.0:%s", config.Port))
+	// Authenticate miners through the cached database authenticator
+	authenticator := stratum.NewDatabaseAuthenticator(db.db)
+	server.authenticator = authenticator
+
+	// Persist shares in batches instead of one INSERT per submit
+	shareInserter := database.NewShareBatchInserter(batchExecer{db: db}, database.DefaultBatchInserterConfig())
+	shareInserter.Start()
+	defer shareInserter.Stop()
+	server.shareRecorder = newShareBatchRecorder(shareInserter, server.activeNetworkID)
+
+	// Alerts for found blocks, and worker offline/online and hashrate drops from live sessions
+	notifier, notificationRepo := newNotificationService(db.db)
+	server.notifier = notifier
+	workerMonitor := startWorkerMonitor(server, notificationRepo)
+
+	// The pool coordinator owns the stratum port and validates shares on its
+	// worker pool; the server's V1/V2 logic runs as its protocol handlers
+	algorithm := ""
+	if activeNet := server.networkLoader.GetActiveNetwork(); activeNet != nil {
+		algorithm = activeNet.Algorithm
+	}
+	ports, err := portProfiles(config)
+	if err != nil {
+		log.Fatalf("Invalid stratum port configuration: %v", err)
+	}
+	drain, err := newDrainConfig(config)
+	if err != nil {
+		log.Fatalf("Invalid drain configuration: %v", err)
+	}
+	inherited, err := inheritedListeners()
 	if err != nil {
+		log.Fatalf("Failed to take over listening sockets: %v", err)
+	}
+
+	// stratum+ssl on the ports whose profile allows tls
+	var certReloader *detector.CertReloader
+	if profilesAllow(ports, detector.ProtocolTLS) {
+		certReloader, err = initTLS(config)
+		if err != nil {
+			log.Fatalf("Failed to initialize stratum TLS: %v", err)
+		}
+		tlsCtx, stopTLSWatch := context.WithCancel(ctx)
+		defer stopTLSWatch()
+		watchTLSCertificates(tlsCtx, certReloader)
+	}
+
+	// Encrypted Stratum V2 (Noise NX) on the ports whose profile allows it
+	if profilesAllow(ports, detector.ProtocolV2Noise) {
+		noiseConfig, err := initNoise(config)
+		if err != nil {
+			log.Fatalf("Failed to initialize Stratum V2 Noise: %v", err)
+		}
+		server.noiseConfig = noiseConfig
+	}
+
+	coordinator := newPoolCoordinator(config, algorithm, ports)
+	coordinator.SetAuthenticator(authenticator)
+	coordinator.SetShareRecorder(server.shareRecorder)
+	coordinator.SetVardiffManager(server.vardiffManager)
+	coordinator.SetInheritedListeners(inherited)
+	if certReloader != nil {
+		coordinator.SetTLSConfig(certReloader.TLSConfig())
+	}
+	for _, handler := range server.protocolHandlers() {
+		coordinator.RegisterProtocolHandler(handler)
+	}
+	server.networkLoader.RegisterObserver(func(network *network.NetworkConfig) {
+		if err := coordinator.UseAlgorithm(network.Algorithm); err != nil {
+			log.Printf("⚠️ Share validation unavailable for %s: %v", network.Symbol, err)
+		}
+	})
+	server.shareProcessor = coordinator
+
+	if err := coordinator.Start(); err != nil {
 		log.Fatalf("Failed to start stratum server: %v", err)
 	}
-	defer listener.Close()
+	defer coordinator.Stop()
+	for _, profile := range coordinator.Profiles() {
+		log.Printf("✅ Stratum Server listening on %s", profile)
+	}
+	if !config.V2AllowPlaintext {
+		log.Println("🔒 Plaintext Stratum V2 disabled")
+	}
 
-	// Handle connections in goroutine
-	go func() {
-		log.Printf("✅ Stratum Server listening on port %s", config.Port)
-		for {
-			conn, err := listener.Accept()
-			if err != nil {
-				select {
-				case <-server.done:
-					return
-				default:
-					log.Printf("Accept error: %v", err)
-					continue
-				}
-			}
-			go server.HandleConnection(conn)
+	// Stratum V2 Job Declaration on its own port
+	if config.JDPort != "" {
+		if err := server.startJobDeclaration(); err != nil {
+			log.Fatalf("Failed to start Job Declaration server: %v", err)
 		}
-	}()
+		log.Printf("✅ Stratum V2 Job Declaration listening on port %s", config.JDPort)
+	}
 
-	// Graceful shutdown
+	// Graceful shutdown: drain miners, then flush shares on the way out.
+	// SIGUSR2 first hands the listening sockets to a new process.
 	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM, syscall.SIGUSR2)
+	if sig := <-quit; sig == syscall.SIGUSR2 {
+		// Free the auxiliary ports for the successor before it binds them
+		if server.jobDeclarator != nil {
+			server.jobDeclarator.Stop()
+		}
+		stopHealth()
+		if err := handOff(coordinator); err != nil {
+			log.Printf("⚠️ Listener handoff failed, draining anyway: %v", err)
+		}
+	}
 
 	log.Println("🛑 Shutting down stratum server...")
+	// Miners leaving during the drain are not offline
+	if workerMonitor != nil {
+		workerMonitor.Stop()
+	}
+	coordinator.StopAccepting()
+	server.Drain(ctx, drain)
 	server.Shutdown()
 	log.Println("✅ Stratum server exited gracefully")
 }
@@ -128,6 +228,34 @@ type Config struct {
 	LitecoinRPCURL  string
 	LitecoinRPCUser string
 	LitecoinRPCPass string
+	LitecoinRPCURLs []string // Upstream nodes in priority order; defaults to LitecoinRPCURL
+	// New-block notification
+	BlockNotify      []string // Notifier sources in order of preference: zmq, longpoll, poll
+	LitecoinZMQBlock string   // zmqpubhashblock endpoint, e.g. tcp://litecoind:28332
+	// Stratum V2 transport settings
+	V2NoisePort        string        // Port for Noise-encrypted V2 ("" disables it)
+	V2AuthorityKeyFile string        // Hex secp256k1 secret used to sign Noise certificates
+	V2CertValidity     time.Duration // Validity window of each issued certificate
+	V2AllowPlaintext   bool          // Accept unencrypted V2 on the main port
+	JDPort             string        // Port for the V2 Job Declaration server ("" disables it)
+	PortsFile          string        // YAML port profiles; replaces Port, V2NoisePort and TLSPort when set
+	// Stratum over TLS
+	TLSPort     string // Port for stratum+ssl ("" disables it unless a port profile allows tls)
+	TLSCertFile string // PEM certificate chain, reloaded on change or SIGHUP
+	TLSKeyFile  string // PEM private key
+	// Vardiff policies
+	VardiffPolicy       string            // Default policy: "share-window" or "ema"
+	VardiffPortPolicies map[string]string // Listener port -> policy
+	VardiffUserPolicies map[string]string // Username -> policy, wins over the port
+	// Session resumption
+	SessionGrace time.Duration // How long a dropped V1 session stays resumable (0 disables)
+	// Graceful drain on shutdown
+	ReconnectTo    string        // "host:port" of a sibling instance to send miners to ("" = this address)
+	DrainBatchSize int           // Miners asked to reconnect per interval
+	DrainInterval  time.Duration // Pause between reconnect batches
+	DrainTimeout   time.Duration // Longest wait for miners to leave before closing them
+	// Stratum extensions
+	VersionRollingMask uint32 // Version bits miners may roll (BIP320 bits by default, 0 disables)
 }
 
 func loadConfig() *Config {
@@ -142,6 +270,34 @@ func loadConfig() *Config {
 		LitecoinRPCURL:  getEnv("LITECOIN_RPC_URL", "http://litecoind:9332"),
 		LitecoinRPCUser: getEnv("LITECOIN_RPC_USER", "chimera"),
 		LitecoinRPCPass: getEnv("LITECOIN_RPC_PASS", "ChimeraLTC2024!"),
+		LitecoinRPCURLs: splitList(getEnv("LITECOIN_RPC_URLS", "")),
+		// Block notifications
+		BlockNotify:      strings.Split(getEnv("STRATUM_BLOCK_NOTIFY", "zmq,longpoll,poll"), ","),
+		LitecoinZMQBlock: getEnv("LITECOIN_ZMQ_HASHBLOCK", ""),
+		// Stratum V2
+		V2NoisePort:        getEnv("STRATUM_V2_NOISE_PORT", ""),
+		V2AuthorityKeyFile: getEnv("STRATUM_V2_AUTHORITY_KEY_FILE", "sv2-authority.key"),
+		V2CertValidity:     time.Hour,
+		V2AllowPlaintext:   getEnv("STRATUM_V2_ALLOW_PLAINTEXT", "true") == "true",
+		JDPort:             getEnv("STRATUM_JD_PORT", ""),
+		PortsFile:          getEnv("STRATUM_PORTS_FILE", ""),
+		// Stratum over TLS
+		TLSPort:     getEnv("STRATUM_TLS_PORT", ""),
+		TLSCertFile: getEnv("STRATUM_TLS_CERT_FILE", ""),
+		TLSKeyFile:  getEnv("STRATUM_TLS_KEY_FILE", ""),
+		// Vardiff
+		VardiffPolicy:       getEnv("VARDIFF_POLICY", vardiff.PolicyShareWindow),
+		VardiffPortPolicies: parseAssignments(getEnv("VARDIFF_PORT_POLICIES", "")),
+		VardiffUserPolicies: parseAssignments(getEnv("VARDIFF_USER_POLICIES", "")),
+		// Session resumption
+		SessionGrace: getEnvDuration("STRATUM_SESSION_GRACE", session.DefaultGracePeriod),
+		// Graceful drain
+		ReconnectTo:    getEnv("STRATUM_RECONNECT_TO", ""),
+		DrainBatchSize: getEnvInt("STRATUM_DRAIN_BATCH", defaultDrainBatchSize),
+		DrainInterval:  getEnvDuration("STRATUM_DRAIN_INTERVAL", defaultDrainInterval),
+		DrainTimeout:   getEnvDuration("STRATUM_DRAIN_TIMEOUT", defaultDrainTimeout),
+		// Stratum extensions
+		VersionRollingMask: getEnvVersionMask("STRATUM_VERSION_ROLLING_MASK", defaultVersionRollingMask),
 	}
 }
 
@@ -152,6 +308,59 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+// getEnvInt reads an integer setting
+func getEnvInt(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Ignoring invalid %s=%q: %v", key, value, err)
+		return defaultValue
+	}
+	return n
+}
+
+// getEnvDuration reads a duration setting such as "90s" or "5m"
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Ignoring invalid %s=%q: %v", key, value, err)
+		return defaultValue
+	}
+	return duration
+}
+
+// getEnvVersionMask reads a hex version rolling mask such as "1fffe000"
+func getEnvVersionMask(key string, defaultValue uint32) uint32 {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	mask, err := parseVersionMask(value)
+	if err != nil {
+		log.Printf("Ignoring invalid %s=%q: %v", key, value, err)
+		return defaultValue
+	}
+	return mask
+}
+
+// splitList splits a comma-separated setting, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 // initHealthMonitor initializes the node health monitoring service
 func initHealthMonitor(config *Config) *health.HealthService {
 	// Check if health monitoring is enabled via environment
@@ -328,6 +537,28 @@ func (r *ResilientDB) Exec(query string, args ...interface{}) (sql.Result, error
 	return nil, fmt.Errorf("database operation failed after 3 retries")
 }
 
+// ExecContext executes a query with a context, retrying on connection errors
+func (r *ResilientDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	for i := 0; i < 3; i++ {
+		r.mu.RLock()
+		db := r.db
+		r.mu.RUnlock()
+
+		result, err := db.ExecContext(ctx, query, args...)
+		if err == nil {
+			return result, nil
+		}
+
+		if isConnectionError(err) && ctx.Err() == nil {
+			log.Printf("⚠️ DB connection error on Exec, retrying: %v", err)
+			r.reconnect()
+			continue
+		}
+		return nil, err
+	}
+	return nil, fmt.Errorf("database operation failed after 3 retries")
+}
+
 // QueryRow executes a query that returns a single row with retry
 func (r *ResilientDB) QueryRow(query string, args ...interface{}) *sql.Row {
 	r.mu.RLock()
@@ -412,10 +643,26 @@ type StratumServer struct {
 	minersMutex      sync.RWMutex
 	done             chan struct{}
 	currentJob       *MiningJob
+	jobs             map[string]*MiningJob // Recent jobs by ID, for share validation
+	staleJobs        map[string]time.Time  // Jobs retired by clean_jobs, by retirement time
 	jobMutex         sync.RWMutex
+	jobSeq           uint64
+	shareTracker     *shares.DuplicateTracker
+	shareProcessor   shareProcessor              // Proof-of-work checks, normally the pool coordinator
+	shareRecorder    stratum.ShareRecorder       // Batched share persistence
+	authenticator    stratum.MinerAuthenticator  // Resolves worker names to users and miners
+	noiseConfig      *noise.ServerConfig         // Nil unless the Noise V2 listener is enabled
+	jobDeclarator    stratum.JobDeclaratorServer // Nil unless the Job Declaration port is set
+	sliceCalculator  *payouts.SLICECalculator    // Credits declared jobs
+	blockWatcher     *blocknotify.Watcher        // Drives template updates on new blocks
+	upstreams        *upstream.Selector          // Ranked Litecoin nodes for RPC
 	extranonce1      uint32
 	extranonceMux    sync.Mutex
 	vardiffManager   *vardiff.Manager
+	sessions         *session.Store                     // Parked V1 sessions; nil disables resumption
+	versionMask      atomic.Uint32                      // Version bits miners may roll for the current job
+	events           *events.Bus                        // Live worker events for the worker monitor
+	notifier         *notifications.NotificationService // Worker and block alerts; nil in tests
 	keepaliveManager *keepalive.Manager
 	merkleBuilder    *merkle.Builder
 	hashrateWindows  map[string]*hashrate.Window
@@ -438,6 +685,9 @@ type MiningJob struct {
 	NTime          string
 	Height         int64
 	Target         string
+	CoinbaseValue  int64
+	Transactions   []string // Raw transaction hex, in block order
+	MWEB           string   // Serialized MWEB extension block (Litecoin)
 }
 
 // BlockTemplate from getblocktemplate RPC
@@ -458,6 +708,10 @@ type BlockTemplate struct {
 	CurTime       int64    `json:"curtime"`
 	Bits          string   `json:"bits"`
 	Height        int64    `json:"height"`
+
+	DefaultWitnessCommitment string `json:"default_witness_commitment"`
+	MWEB                     string `json:"mweb"`
+	LongPollID               string `json:"longpollid"`
 }
 
 // TxData represents a transaction in block template
@@ -471,18 +725,29 @@ type TxData struct {
 
 // Miner represents a connected miner
 type Miner struct {
-	ID            string
-	UserID        int64  // Database user ID for share attribution
-	Username      string // Authorized username
-	Address       string
-	Conn          net.Conn
-	Authorized    bool
-	Difficulty    float64
-	SharesValid   int64
-	SharesInvalid int64
-	LastShare     time.Time
-	UserAgent     string // Miner software identifier (e.g., "cpuminer/2.5.1")
-	MinerType     string // Detected type: "cpu", "gpu", "asic", "unknown"
+	ID              string
+	UserID          int64  // Database user ID for share attribution
+	MinerDBID       int64  // miners table ID, set at authorization
+	Username        string // Authorized username
+	WorkerName      string // Worker part of "username.worker"
+	Address         string
+	Conn            net.Conn
+	Authorized      bool
+	Difficulty      float64
+	SharesValid     int64
+	SharesInvalid   int64
+	LastShare       time.Time
+	UserAgent       string // Miner software identifier (e.g., "cpuminer/2.5.1")
+	MinerType       string // Detected type: "cpu", "gpu", "asic", "unknown"
+	Extranonce1     string // Hex extranonce1 assigned at subscribe
+	IsV2            bool   // Connected with the Stratum V2 binary protocol
+	ChannelID       uint32 // V2 mining channel ID
+	ExtendedChannel bool   // V2 channel is extended (client builds the coinbase)
+	Solo            bool   // Connected on a port that forces solo mining
+	SessionID       string // Resumable session ID returned by mining.subscribe
+	// Version rolling negotiated with mining.configure or the V2 setup flag
+	VersionRolling     bool
+	VersionRollingMask uint32 // Mask the miner asked for; ANDed with the pool mask
 }
 
 // StratumRequest represents an incoming stratum request
@@ -501,12 +766,10 @@ type StratumResponse struct {
 
 // NewStratumServer creates a new stratum server
 func NewStratumServer(config *Config, db *ResilientDB, redisClient *redis.Client) *StratumServer {
-	// Configure vardiff - use X100-optimized configuration for Scrypt mining
-	// X100 on Scrypt produces ~15 TH/s (vs ~70 TH/s on BlockDAG Scrpy-variant)
-	vardiffConfig := vardiff.X100OptimizedConfig()
-	// Allow config override for initial difficulty if specified
-	if config.Difficulty > 0 {
-		vardiffConfig.InitialDifficulty = config.Difficulty
+	// Configure vardiff - X100-optimized policies for Scrypt mining
+	vardiffPolicies, err := newVardiffPolicies(config)
+	if err != nil {
+		log.Fatalf("Invalid vardiff configuration: %v", err)
 	}
 
 	// Configure keepalive
@@ -532,18 +795,28 @@ func NewStratumServer(config *Config, db *ResilientDB, redisClient *redis.Client
 		redis:           redisClient,
 		miners:          make(map[string]*Miner),
 		done:            make(chan struct{}),
+		jobs:            make(map[string]*MiningJob),
+		staleJobs:       make(map[string]time.Time),
+		jobSeq:          uint64(time.Now().Unix()),
+		shareTracker:    shares.NewDuplicateTracker(shares.DefaultMaxSharesPerMiner),
 		extranonce1:     1,
-		vardiffManager:  vardiff.NewManager(vardiffConfig),
+		vardiffManager:  vardiff.NewManagerWithPolicies(vardiffPolicies),
 		merkleBuilder:   merkle.NewBuilder(),
 		hashrateWindows: make(map[string]*hashrate.Window),
 		hashrateCalc:    hashrate.NewCalculator(),
 		geoService:      geolocation.NewGeoIPService(db.db),
 		networkLoader:   networkLoader,
 		activeNetworkID: networkLoader.GetActiveNetworkID(),
+		upstreams:       newUpstreamSelector(config),
+		events:          events.NewBus(),
 	}
 
 	log.Println("📍 IP geolocation service initialized for miner location tracking")
 
+	s.vardiffManager.SetObserver(s.publishVardiffDecision)
+	s.versionMask.Store(config.VersionRollingMask)
+	s.sessions = newSessionStore(config, s.vardiffManager)
+
 	// Initialize keepalive with disconnect callback
 	s.keepaliveManager = keepalive.NewManager(keepaliveConfig, func(minerID string) {
 		log.Printf("Keepalive timeout for miner %s, disconnecting", minerID)
@@ -555,18 +828,30 @@ func NewStratumServer(config *Config, db *ResilientDB, redisClient *redis.Client
 		s.minersMutex.Unlock()
 	})
 
-	// Start block template updater
+	// Start upstream health probes and the block template updater
+	go s.upstreamProber()
+	s.blockWatcher = s.newBlockWatcher()
 	go s.blockTemplateUpdater()
+	if s.sessions != nil {
+		go s.sessionSweeper()
+	}
 	return s
 }
 
-// litecoinRPC makes an RPC call to the Litecoin node with retry logic
+// litecoinRPC makes an RPC call to the best upstream Litecoin node with retry logic
 func (s *StratumServer) litecoinRPC(method string, params interface{}) (json.RawMessage, error) {
 	return s.litecoinRPCWithRetry(method, params, 3) // Default 3 retries
 }
 
 // litecoinRPCWithRetry makes an RPC call with exponential backoff retry
 func (s *StratumServer) litecoinRPCWithRetry(method string, params interface{}, maxRetries int) (json.RawMessage, error) {
+	result, _, err := s.rpcWithRetry(s.upstreams.Select, method, params, maxRetries)
+	return result, err
+}
+
+// rpcWithRetry makes an RPC call with exponential backoff retry. Each attempt
+// picks a node again, so a failing node is replaced once it is marked down.
+func (s *StratumServer) rpcWithRetry(pick func() (upstream.Node, error), method string, params interface{}, maxRetries int) (json.RawMessage, upstream.Node, error) {
 	var lastErr error
 	baseDelay := 500 * time.Millisecond
 
@@ -580,9 +865,13 @@ func (s *StratumServer) litecoinRPCWithRetry(method string, params interface{},
 			time.Sleep(delay)
 		}
 
-		result, err := s.doLitecoinRPC(method, params)
+		node, err := pick()
+		if err != nil {
+			return nil, upstream.Node{}, err
+		}
+		result, err := s.doLitecoinRPC(node, method, params)
 		if err == nil {
-			return result, nil
+			return result, node, nil
 		}
 
 		lastErr = err
@@ -597,20 +886,61 @@ func (s *StratumServer) litecoinRPCWithRetry(method string, params interface{},
 			strings.Contains(errStr, "RPC error -1")
 
 		if !isRetryable {
-			return nil, err // Non-retryable error, fail immediately
+			return nil, node, err // Non-retryable error, fail immediately
 		}
 
 		if attempt < maxRetries {
-			log.Printf("RPC call %s failed (attempt %d/%d): %v, retrying...",
-				method, attempt+1, maxRetries+1, err)
+			log.Printf("RPC call %s on %s failed (attempt %d/%d): %v, retrying...",
+				method, node.Name, attempt+1, maxRetries+1, err)
 		}
 	}
 
-	return nil, fmt.Errorf("RPC call %s failed after %d attempts: %v", method, maxRetries+1, lastErr)
+	return nil, upstream.Node{}, fmt.Errorf("RPC call %s failed after %d attempts: %v", method, maxRetries+1, lastErr)
+}
+
+// doLitecoinRPC performs a single RPC call without retry and records the
+// outcome in the node's health score
+func (s *StratumServer) doLitecoinRPC(node upstream.Node, method string, params interface{}) (json.RawMessage, error) {
+	start := time.Now()
+	// Use shorter timeout for individual requests, retry handles recovery
+	result, err := s.doLitecoinRPCContext(context.Background(), node, method, params, 10*time.Second)
+	if isNodeFault(err) {
+		s.upstreams.RecordFailure(node.Name, err)
+	} else {
+		s.upstreams.RecordSuccess(node.Name, time.Since(start))
+	}
+	return result, err
+}
+
+// rpcError is an error reply from the node; the node itself answered
+type rpcError struct {
+	Code    int
+	Message string
+}
+
+func (e *rpcError) Error() string {
+	return fmt.Sprintf("RPC error %d: %s", e.Code, e.Message)
+}
+
+// isNodeFault reports whether err means the node is unusable rather than
+// that it rejected a request: transport errors, warmup and IBD
+func isNodeFault(err error) bool {
+	if err == nil {
+		return false
+	}
+	var rpcErr *rpcError
+	if errors.As(err, &rpcErr) {
+		switch rpcErr.Code {
+		case -9, -10, -28: // Not connected, in initial download, warming up
+			return true
+		}
+		return false
+	}
+	return true
 }
 
-// doLitecoinRPC performs a single RPC call without retry
-func (s *StratumServer) doLitecoinRPC(method string, params interface{}) (json.RawMessage, error) {
+// doLitecoinRPCContext performs a single RPC call to node bounded by ctx and timeout
+func (s *StratumServer) doLitecoinRPCContext(ctx context.Context, node upstream.Node, method string, params interface{}, timeout time.Duration) (json.RawMessage, error) {
 	reqBody := map[string]interface{}{
 		"jsonrpc": "1.0",
 		"id":      "stratum",
@@ -622,15 +952,14 @@ func (s *StratumServer) doLitecoinRPC(method string, params interface{}) (json.R
 		return nil, err
 	}
 
-	req, err := http.NewRequest("POST", s.config.LitecoinRPCURL, bytes.NewReader(bodyBytes))
+	req, err := http.NewRequestWithContext(ctx, "POST", node.URL, bytes.NewReader(bodyBytes))
 	if err != nil {
 		return nil, err
 	}
-	req.SetBasicAuth(s.config.LitecoinRPCUser, s.config.LitecoinRPCPass)
+	req.SetBasicAuth(node.User, node.Password)
 	req.Header.Set("Content-Type", "application/json")
 
-	// Use shorter timeout for individual requests, retry handles recovery
-	client := &http.Client{Timeout: 10 * time.Second}
+	client := &http.Client{Timeout: timeout}
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, err
@@ -670,7 +999,7 @@ func (s *StratumServer) doLitecoinRPC(method string, params interface{}) (json.R
 		return nil, fmt.Errorf("invalid JSON response: %v (body: %.100s)", err, string(respBody))
 	}
 	if rpcResp.Error != nil {
-		return nil, fmt.Errorf("RPC error %d: %s", rpcResp.Error.Code, rpcResp.Error.Message)
+		return nil, &rpcError{Code: rpcResp.Error.Code, Message: rpcResp.Error.Message}
 	}
 	return rpcResp.Result, nil
 }
@@ -678,7 +1007,7 @@ func (s *StratumServer) doLitecoinRPC(method string, params interface{}) (json.R
 // getBlockTemplate fetches a new block template from Litecoin
 func (s *StratumServer) getBlockTemplate() (*BlockTemplate, error) {
 	params := []interface{}{map[string]interface{}{"rules": []string{"segwit", "mweb"}}}
-	result, err := s.litecoinRPC("getblocktemplate", params)
+	result, node, err := s.rpcWithRetry(s.upstreams.SelectForTemplate, "getblocktemplate", params, 3)
 	if err != nil {
 		return nil, err
 	}
@@ -686,15 +1015,22 @@ func (s *StratumServer) getBlockTemplate() (*BlockTemplate, error) {
 	if err := json.Unmarshal(result, &tmpl); err != nil {
 		return nil, err
 	}
+	if err := s.checkTemplateTip(node, &tmpl); err != nil {
+		return nil, err
+	}
 	return &tmpl, nil
 }
 
+// poolOutputScriptHex is the P2WPKH script paying the pool wallet
+const poolOutputScriptHex = "0014846e292b5670116e217563468f6de863fc79c822"
+
 // buildMiningJob creates a mining job from a block template
 func (s *StratumServer) buildMiningJob(tmpl *BlockTemplate) *MiningJob {
-	jobID := fmt.Sprintf("%x", time.Now().Unix())
+	jobID := fmt.Sprintf("%x", atomic.AddUint64(&s.jobSeq, 1))
 
-	// Reverse the previous block hash for stratum (it needs to be little-endian)
-	prevHash := reverseHex(tmpl.PreviousBlockHash)
+	// Stratum sends the previous block hash with its 4-byte words reversed;
+	// miners swap each word back to get the internal byte order
+	prevHash := stratumPrevHash(tmpl.PreviousBlockHash)
 
 	// Build coinbase transaction
 	// Coinbase1: version + input count + prev tx + prev index + script sig length
@@ -728,18 +1064,30 @@ func (s *StratumServer) buildMiningJob(tmpl *BlockTemplate) *MiningJob {
 	valueHex := hex.EncodeToString(valueBytes)
 
 	// Simple P2WPKH output (OP_0 + push20 + pubkeyhash)
-	// For now use a placeholder output
+	outputs := valueHex + // value
+		fmt.Sprintf("%02x", len(poolOutputScriptHex)/2) + poolOutputScriptHex
+	outputCount := 1
+
+	// SegWit witness commitment output (zero value), required when the
+	// template contains witness transactions
+	if tmpl.DefaultWitnessCommitment != "" {
+		outputs += "0000000000000000" +
+			fmt.Sprintf("%02x", len(tmpl.DefaultWitnessCommitment)/2) + tmpl.DefaultWitnessCommitment
+		outputCount++
+	}
+
 	coinbase2 := "ffffffff" + // sequence
-		"01" + // 1 output
-		valueHex + // value
-		"160014" + "846e292b5670116e217563468f6de863fc79c822" + // P2WPKH script
+		fmt.Sprintf("%02x", outputCount) +
+		outputs +
 		"00000000" // locktime
 
 	// Build merkle branches from transactions using the merkle builder
 	var merkleBranches []string
+	transactions := make([]string, 0, len(tmpl.Transactions))
 	if len(tmpl.Transactions) > 0 {
 		var txHashes [][]byte
 		for _, tx := range tmpl.Transactions {
+			transactions = append(transactions, tx.Data)
 			hashBytes, _ := hex.DecodeString(tx.TxID)
 			// Reverse for little-endian merkle
 			for i, j := 0, len(hashBytes)-1; i < j; i, j = i+1, j-1 {
@@ -763,7 +1111,21 @@ func (s *StratumServer) buildMiningJob(tmpl *BlockTemplate) *MiningJob {
 		NTime:          fmt.Sprintf("%08x", tmpl.CurTime),
 		Height:         tmpl.Height,
 		Target:         tmpl.Target,
+		CoinbaseValue:  tmpl.CoinbaseValue,
+		Transactions:   transactions,
+		MWEB:           tmpl.MWEB,
+	}
+}
+
+// stratumPrevHash converts a display-order block hash to the stratum V1
+// prevhash encoding: the same bytes with the order of 4-byte words reversed
+func stratumPrevHash(s string) string {
+	b, _ := hex.DecodeString(s)
+	out := make([]byte, 0, len(b))
+	for i := len(b) - 4; i >= 0; i -= 4 {
+		out = append(out, b[i:i+4]...)
 	}
+	return hex.EncodeToString(out)
 }
 
 // reverseHex reverses a hex string byte by byte
@@ -775,24 +1137,6 @@ func reverseHex(s string) string {
 	return hex.EncodeToString(bytes)
 }
 
-// blockTemplateUpdater periodically fetches new block templates
-func (s *StratumServer) blockTemplateUpdater() {
-	ticker := time.NewTicker(5 * time.Second)
-	defer ticker.Stop()
-
-	// Initial fetch
-	s.updateBlockTemplate()
-
-	for {
-		select {
-		case <-ticker.C:
-			s.updateBlockTemplate()
-		case <-s.done:
-			return
-		}
-	}
-}
-
 // updateBlockTemplate fetches a new template and broadcasts to miners
 func (s *StratumServer) updateBlockTemplate() {
 	tmpl, err := s.getBlockTemplate()
@@ -800,105 +1144,133 @@ func (s *StratumServer) updateBlockTemplate() {
 		log.Printf("Failed to get block template: %v", err)
 		return
 	}
+	s.applyBlockTemplate(tmpl)
+}
 
+// applyBlockTemplate makes tmpl the current job. A new tip (height or
+// previous block hash changed) is broadcast with clean_jobs.
+func (s *StratumServer) applyBlockTemplate(tmpl *BlockTemplate) {
 	job := s.buildMiningJob(tmpl)
 
 	s.jobMutex.Lock()
-	oldHeight := int64(0)
-	if s.currentJob != nil {
-		oldHeight = s.currentJob.Height
-	}
+	previous := s.currentJob
 	s.currentJob = job
+	s.storeJobLocked(job)
 	s.jobMutex.Unlock()
+	s.updateVersionMask(job)
 
 	// Broadcast new job to all miners if block changed
-	if job.Height != oldHeight {
+	if previous == nil || job.Height != previous.Height || job.PrevHash != previous.PrevHash {
 		log.Printf("New block template: height=%d, bits=%s", job.Height, job.NBits)
 		s.broadcastJob(job, true)
 	}
 }
 
-// broadcastJob sends a mining.notify to all connected miners
-func (s *StratumServer) broadcastJob(job *MiningJob, cleanJobs bool) {
-	s.minersMutex.RLock()
-	defer s.minersMutex.RUnlock()
+// maxJobHistory bounds how many jobs at the current height stay valid for submits
+const maxJobHistory = 16
 
-	for _, miner := range s.miners {
-		if miner.Authorized {
-			s.sendNotification(miner, "mining.notify", []interface{}{
-				job.JobID,
-				job.PrevHash,
-				job.Coinbase1,
-				job.Coinbase2,
-				job.MerkleBranches,
-				job.Version,
-				job.NBits,
-				job.NTime,
-				cleanJobs,
-			})
+// staleJobRetention is how long retired job IDs are remembered for stale detection
+const staleJobRetention = 10 * time.Minute
+
+// storeJobLocked records a job for share validation, evicting the oldest
+// jobs once the history is full. Caller must hold jobMutex.
+func (s *StratumServer) storeJobLocked(job *MiningJob) {
+	s.jobs[job.JobID] = job
+
+	// Drop the oldest jobs once the history is full (job IDs are sequential hex)
+	for len(s.jobs) > maxJobHistory {
+		var oldest *MiningJob
+		for _, j := range s.jobs {
+			if oldest == nil || len(j.JobID) < len(oldest.JobID) ||
+				(len(j.JobID) == len(oldest.JobID) && j.JobID < oldest.JobID) {
+				oldest = j
+			}
 		}
+		s.retireJobLocked(oldest.JobID)
 	}
 }
 
-// getNextExtranonce1 returns a unique extranonce1 for each miner
-func (s *StratumServer) getNextExtranonce1() string {
-	s.extranonceMux.Lock()
-	defer s.extranonceMux.Unlock()
-	s.extranonce1++
-	return fmt.Sprintf("%08x", s.extranonce1)
-}
-
-// HandleConnection handles a new miner connection with protocol detection
-func (s *StratumServer) HandleConnection(conn net.Conn) {
-	defer conn.Close()
-
-	minerID := fmt.Sprintf("%s-%d", conn.RemoteAddr().String(), time.Now().UnixNano())
-	// Defer connection logging until we know it's a real miner (not a probe)
-
-	// Set read deadline for protocol detection
-	conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+// rotateJobs retires every job except the current one after a clean_jobs
+// broadcast, so later submits for them are rejected as stale
+func (s *StratumServer) rotateJobs(current *MiningJob) {
+	s.jobMutex.Lock()
+	defer s.jobMutex.Unlock()
 
-	// Peek first bytes to detect protocol
-	peekBuf := make([]byte, 6)
-	n, err := io.ReadFull(conn, peekBuf)
-	if err != nil {
-		// Don't log EOF errors - these are common probe connections from miners
-		if err != io.EOF && err != io.ErrUnexpectedEOF {
-			log.Printf("Failed to read from %s: %v", minerID, err)
+	for id := range s.jobs {
+		if id != current.JobID {
+			s.retireJobLocked(id)
 		}
-		return
 	}
+	s.jobs[current.JobID] = current
+	s.shareTracker.RetainJobs(current.JobID)
 
-	// Reset deadline
-	conn.SetReadDeadline(time.Time{})
+	// Forget retired jobs once no miner can still be working on them
+	for id, retiredAt := range s.staleJobs {
+		if time.Since(retiredAt) > staleJobRetention {
+			delete(s.staleJobs, id)
+		}
+	}
+}
 
-	// Silently detect protocol - no need to log raw bytes
+// retireJobLocked moves a job to the stale set and drops its duplicate
+// tracking. Caller must hold jobMutex.
+func (s *StratumServer) retireJobLocked(jobID string) {
+	delete(s.jobs, jobID)
+	s.staleJobs[jobID] = time.Now()
+	s.shareTracker.RemoveJob(jobID)
+}
 
-	// Detect protocol based on first bytes
-	switch {
-	case peekBuf[0] == '{':
-		// V1 JSON protocol - wrap connection to include peeked bytes
-		wrappedConn := &peekableConn{Conn: conn, peeked: peekBuf[:n]}
-		s.handleV1Connection(wrappedConn, minerID)
+// lookupJob returns a live job by ID. For unknown jobs it reports whether
+// the ID belongs to a job that has been retired (a stale share).
+func (s *StratumServer) lookupJob(jobID string) (job *MiningJob, stale bool) {
+	s.jobMutex.RLock()
+	defer s.jobMutex.RUnlock()
+	if job, ok := s.jobs[jobID]; ok {
+		return job, false
+	}
+	_, stale = s.staleJobs[jobID]
+	return nil, stale
+}
 
-	case string(peekBuf[:3]) == "GET" || string(peekBuf[:4]) == "POST":
-		// HTTP request - health check or metrics scrape (suppress verbose logging)
-		s.handleHTTPProbe(conn, peekBuf[:n], minerID)
+// broadcastJob sends a new job to all connected miners (mining.notify for V1)
+func (s *StratumServer) broadcastJob(job *MiningJob, cleanJobs bool) {
+	if cleanJobs {
+		s.rotateJobs(job)
+	}
 
-	case peekBuf[0] == 0x00 && peekBuf[1] == 0x00:
-		// V2 binary protocol (starts with extension_type 0x0000)
-		log.Printf("Detected Stratum V2 binary protocol from %s", minerID)
-		s.handleV2Connection(conn, peekBuf[:n], minerID)
+	s.minersMutex.RLock()
+	defer s.minersMutex.RUnlock()
 
-	default:
-		// Unknown protocol - try to read more and log for analysis
-		log.Printf("Unknown protocol from %s, first bytes: 0x%02x 0x%02x 0x%02x 0x%02x 0x%02x 0x%02x",
-			minerID, peekBuf[0], peekBuf[1], peekBuf[2], peekBuf[3], peekBuf[4], peekBuf[5])
-		// Read remaining data to understand the protocol
-		s.handleUnknownProtocol(conn, peekBuf[:n], minerID)
+	for _, miner := range s.miners {
+		if !miner.Authorized {
+			continue
+		}
+		if miner.IsV2 {
+			s.sendV2Job(miner, job)
+			continue
+		}
+		s.sendNotification(miner, "mining.notify", []interface{}{
+			job.JobID,
+			job.PrevHash,
+			job.Coinbase1,
+			job.Coinbase2,
+			job.MerkleBranches,
+			job.Version,
+			job.NBits,
+			job.NTime,
+			cleanJobs,
+		})
 	}
 }
 
+// getNextExtranonce1 returns a unique extranonce1 for each miner
+func (s *StratumServer) getNextExtranonce1() string {
+	s.extranonceMux.Lock()
+	defer s.extranonceMux.Unlock()
+	s.extranonce1++
+	return fmt.Sprintf("%08x", s.extranonce1)
+}
+
 // peekableConn wraps a connection to prepend peeked bytes
 type peekableConn struct {
 	net.Conn
@@ -916,8 +1288,8 @@ func (p *peekableConn) Read(b []byte) (int, error) {
 
 // handleV1Connection handles Stratum V1 JSON protocol with improved timeout handling
 func (s *StratumServer) handleV1Connection(conn net.Conn, minerID string) {
-	// Get initial difficulty from vardiff manager
-	initialDiff := s.vardiffManager.GetDifficulty(minerID)
+	// Start vardiff under the listener port's policy; subscribe refines the seed
+	initialDiff := s.vardiffManager.Attach(minerID, vardiff.PortOf(conn.LocalAddr()), vardiff.Hints{})
 
 	miner := &Miner{
 		ID:         minerID,
@@ -925,6 +1297,7 @@ func (s *StratumServer) handleV1Connection(conn net.Conn, minerID string) {
 		Conn:       conn,
 		Authorized: false,
 		Difficulty: initialDiff,
+		Solo:       forcesSolo(conn),
 	}
 
 	s.minersMutex.Lock()
@@ -933,11 +1306,14 @@ func (s *StratumServer) handleV1Connection(conn net.Conn, minerID string) {
 
 	// Start keepalive monitoring for this miner
 	s.keepaliveManager.Start(minerID)
+	defer s.trackWorkerEvents(miner)()
 
 	defer func() {
-		// Stop keepalive and cleanup vardiff on disconnect
+		// Stop keepalive, and park the session or clean up vardiff on disconnect
 		s.keepaliveManager.Stop(minerID)
-		s.vardiffManager.RemoveMiner(minerID)
+		if !s.parkSession(miner) {
+			s.vardiffManager.RemoveMiner(minerID)
+		}
 		s.minersMutex.Lock()
 		delete(s.miners, minerID)
 		s.minersMutex.Unlock()
@@ -988,14 +1364,17 @@ func (s *StratumServer) handleV2Connection(conn net.Conn, initialBytes []byte, m
 		Address:    conn.RemoteAddr().String(),
 		Conn:       conn,
 		Authorized: false,
-		Difficulty: s.config.Difficulty,
+		Difficulty: s.vardiffManager.Attach(minerID, vardiff.PortOf(conn.LocalAddr()), vardiff.Hints{}),
+		Solo:       forcesSolo(conn),
 	}
 
 	s.minersMutex.Lock()
 	s.miners[minerID] = miner
 	s.minersMutex.Unlock()
+	defer s.trackWorkerEvents(miner)()
 
 	defer func() {
+		s.vardiffManager.RemoveMiner(minerID)
 		s.minersMutex.Lock()
 		delete(s.miners, minerID)
 		s.minersMutex.Unlock()
@@ -1079,11 +1458,23 @@ func (s *StratumServer) handleV2SetupConnection(miner *Miner, payload []byte) er
 		miner.ID, setupConn.Protocol, setupConn.MinVersion, setupConn.MaxVersion,
 		setupConn.Vendor, setupConn.HardwareVersion, setupConn.DeviceID)
 
+	// The version rolling flag negotiates the pool mask, like mining.configure
+	flags := setupConn.Flags
+	versionRolling := uint32(v2binary.ExtensionTypeVersionRolling)
+	if flags&versionRolling != 0 {
+		if s.poolVersionMask() != 0 {
+			miner.VersionRolling = true
+			miner.VersionRollingMask = 0xffffffff
+		} else {
+			flags &^= versionRolling
+		}
+	}
+
 	// Send SetupConnectionSuccess
 	ser := v2binary.NewSerializer()
 	successMsg := &v2binary.SetupConnectionSuccess{
 		UsedVersion: setupConn.MaxVersion, // Use max version supported by client
-		Flags:       setupConn.Flags,      // Echo back flags
+		Flags:       flags,                // Echo back the flags we support
 	}
 	payloadBytes := ser.SerializeSetupConnectionSuccess(successMsg)
 	frame := ser.SerializeFrame(v2binary.MsgTypeSetupConnectionSuccess, 0, payloadBytes)
@@ -1106,6 +1497,10 @@ func (s *StratumServer) handleV2Message(miner *Miner, header *v2binary.FrameHead
 		return s.handleV2OpenChannel(miner, payload)
 	case v2binary.MsgTypeSubmitSharesStandard:
 		return s.handleV2SubmitShares(miner, payload)
+	case v2binary.MsgTypeOpenExtendedMiningChannel:
+		return s.handleV2OpenExtendedChannel(miner, payload)
+	case v2binary.MsgTypeSubmitSharesExtended:
+		return s.handleV2SubmitSharesExtended(miner, payload)
 	default:
 		log.Printf("V2 unhandled message type 0x%02x from %s", header.MsgType, miner.ID)
 		return nil
@@ -1123,32 +1518,31 @@ func (s *StratumServer) handleV2OpenChannel(miner *Miner, payload []byte) error
 	log.Printf("V2 OpenStandardMiningChannel from %s: RequestID=%d, User=%s, Hashrate=%.2f",
 		miner.ID, openChan.RequestID, openChan.UserIdentity, openChan.NominalHashrate)
 
-	// Mark miner as authorized
-	miner.Authorized = true
-
-	// Record miner in database
-	_, err = s.db.Exec(
-		"INSERT INTO miners (user_id, name, address, is_active) VALUES (1, $1, $2, true) ON CONFLICT DO NOTHING",
-		string(openChan.UserIdentity), miner.Address,
-	)
-	if err != nil {
-		log.Printf("Failed to record V2 miner: %v", err)
+	// The user identity is a worker name, authorized like mining.authorize
+	if err := s.authorizeUser(miner, strings.TrimSpace(string(openChan.UserIdentity))); err != nil {
+		if errors.Is(err, errUserNotFound) {
+			return s.sendV2OpenStandardChannelError(miner, openChan.RequestID, v2ErrUnknownUser)
+		}
+		return s.sendV2OpenStandardChannelError(miner, openChan.RequestID, v2ErrInternalChannelFailed)
 	}
 
+	// The channel's extranonce prefix is part of the coinbase behind every
+	// merkle root it is sent, and keeps its shares apart from other connections
+	miner.IsV2 = true
+	miner.ChannelID = 1
+	miner.Extranonce1 = s.getNextExtranonce1()
+
+	// Seed vardiff from the channel's nominal hashrate
+	miner.Difficulty = s.vardiffManager.Seed(miner.ID, vardiff.Hints{Hashrate: float64(openChan.NominalHashrate)})
+
 	// Send OpenStandardMiningChannelSuccess
 	ser := v2binary.NewSerializer()
 
-	// Create target (max target = easy difficulty for testing)
-	var target [32]byte
-	for i := 0; i < 32; i++ {
-		target[i] = 0xFF // Very easy target
-	}
-
 	successMsg := &v2binary.OpenStandardMiningChannelSuccess{
 		RequestID:       openChan.RequestID,
-		ChannelID:       1,      // Assign channel ID 1
-		Target:          target, // Easy target for testing
-		ExtraNonce2Size: 4,
+		ChannelID:       miner.ChannelID,
+		Target:          v2Target(miner.Difficulty),
+		ExtraNonce2Size: 0, // Standard channels don't roll the coinbase
 		GroupChannelID:  0,
 	}
 	payloadBytes := ser.SerializeOpenStandardMiningChannelSuccess(successMsg)
@@ -1159,55 +1553,127 @@ func (s *StratumServer) handleV2OpenChannel(miner *Miner, payload []byte) error
 		return fmt.Errorf("send OpenStandardMiningChannelSuccess: %w", err)
 	}
 
-	log.Printf("V2 OpenStandardMiningChannelSuccess sent to %s (ChannelID=1)", miner.ID)
+	log.Printf("V2 OpenStandardMiningChannelSuccess sent to %s (ChannelID=%d)", miner.ID, miner.ChannelID)
 
 	// Send initial mining job
-	return s.sendV2MiningJob(miner, 1)
+	s.jobMutex.RLock()
+	job := s.currentJob
+	s.jobMutex.RUnlock()
+	if job == nil {
+		return nil
+	}
+	return s.sendV2MiningJob(miner, miner.ChannelID, job)
 }
 
-// sendV2MiningJob sends a new mining job to a V2 miner
-func (s *StratumServer) sendV2MiningJob(miner *Miner, channelID uint32) error {
+// sendV2OpenStandardChannelError rejects an OpenStandardMiningChannel request
+func (s *StratumServer) sendV2OpenStandardChannelError(miner *Miner, requestID uint32, code string) error {
+	log.Printf("V2 OpenStandardMiningChannel from %s rejected: %s", miner.ID, code)
+
 	ser := v2binary.NewSerializer()
+	errMsg := &v2binary.OpenStandardMiningChannelError{
+		RequestID: requestID,
+		ErrorCode: v2binary.STR0_255(code),
+	}
+	payloadBytes := ser.SerializeOpenStandardMiningChannelError(errMsg)
+	frame := ser.SerializeFrame(v2binary.MsgTypeOpenStandardMiningChannelError, 0, payloadBytes)
+
+	if _, err := miner.Conn.Write(frame); err != nil {
+		return fmt.Errorf("send OpenStandardMiningChannelError: %w", err)
+	}
+	return nil
+}
+
+// v2JobID maps a job to its numeric V2 job ID (V1 job IDs are the same sequence in hex)
+func v2JobID(job *MiningJob) (uint32, error) {
+	id, err := strconv.ParseUint(job.JobID, 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("job %s has no V2 job ID: %w", job.JobID, err)
+	}
+	return uint32(id), nil
+}
+
+// sendV2MiningJob sends a job to a V2 miner as NewMiningJob + SetNewPrevHash
+func (s *StratumServer) sendV2MiningJob(miner *Miner, channelID uint32, job *MiningJob) error {
+	jobID, err := v2JobID(job)
+	if err != nil {
+		return err
+	}
+	version, err := parseHexUint32(job.Version)
+	if err != nil {
+		return errInvalidJob
+	}
+	merkleRoot, err := s.v2StandardMerkleRoot(miner, job)
+	if err != nil {
+		return err
+	}
 
-	jobID := uint32(time.Now().Unix())
+	ser := v2binary.NewSerializer()
 
-	// Create NewMiningJob
 	jobMsg := &v2binary.NewMiningJob{
 		ChannelID:      channelID,
 		JobID:          jobID,
 		FuturePrevHash: false,
-		Version:        0x20000000, // Standard version
-		VersionMask:    0x1FFFE000, // Standard version rolling mask
+		Version:        version,
+		VersionMask:    s.minerVersionMask(miner), // 0 unless version rolling was negotiated
+		MerkleRoot:     merkleRoot,
 	}
 	payloadBytes := ser.SerializeNewMiningJob(jobMsg)
 	frame := ser.SerializeFrame(v2binary.MsgTypeNewMiningJob, 0, payloadBytes)
 
-	_, err := miner.Conn.Write(frame)
-	if err != nil {
+	if _, err := miner.Conn.Write(frame); err != nil {
 		return fmt.Errorf("send NewMiningJob: %w", err)
 	}
 
-	// Send SetNewPrevHash with a dummy prev hash (would come from node in production)
+	if err := s.sendV2PrevHash(miner, channelID, jobID, job); err != nil {
+		return err
+	}
+
+	log.Printf("V2 NewMiningJob sent to %s (JobID=%d)", miner.ID, jobID)
+	return nil
+}
+
+// v2PrevHash returns a job's previous block hash in internal byte order, as V2 carries it
+func v2PrevHash(job *MiningJob) ([32]byte, error) {
 	var prevHash [32]byte
-	// Fill with some data (in production this comes from the blockchain)
-	binary.LittleEndian.PutUint64(prevHash[:], uint64(time.Now().UnixNano()))
+	prevHashBytes, err := hex.DecodeString(stratumPrevHash(job.PrevHash))
+	if err != nil || len(prevHashBytes) != 32 {
+		return prevHash, errInvalidJob
+	}
+	blockdag.ReverseBytes(prevHashBytes)
+	copy(prevHash[:], prevHashBytes)
+	return prevHash, nil
+}
+
+// sendV2PrevHash sends SetNewPrevHash activating a job that was just sent
+func (s *StratumServer) sendV2PrevHash(miner *Miner, channelID, jobID uint32, job *MiningJob) error {
+	bits, err := parseHexUint32(job.NBits)
+	if err != nil {
+		return errInvalidJob
+	}
+	ntime, err := parseHexUint32(job.NTime)
+	if err != nil {
+		return errInvalidJob
+	}
+
+	prevHash, err := v2PrevHash(job)
+	if err != nil {
+		return err
+	}
 
 	prevHashMsg := &v2binary.SetNewPrevHash{
 		ChannelID: channelID,
 		JobID:     jobID,
 		PrevHash:  prevHash,
-		MinNTime:  uint32(time.Now().Unix()),
-		NBits:     0x1d00ffff, // Easy difficulty bits
+		MinNTime:  ntime,
+		NBits:     bits,
 	}
-	payloadBytes = ser.SerializeSetNewPrevHash(prevHashMsg)
-	frame = ser.SerializeFrame(v2binary.MsgTypeSetNewPrevHash, 0, payloadBytes)
+	ser := v2binary.NewSerializer()
+	payloadBytes := ser.SerializeSetNewPrevHash(prevHashMsg)
+	frame := ser.SerializeFrame(v2binary.MsgTypeSetNewPrevHash, 0, payloadBytes)
 
-	_, err = miner.Conn.Write(frame)
-	if err != nil {
+	if _, err := miner.Conn.Write(frame); err != nil {
 		return fmt.Errorf("send SetNewPrevHash: %w", err)
 	}
-
-	log.Printf("V2 NewMiningJob sent to %s (JobID=%d)", miner.ID, jobID)
 	return nil
 }
 
@@ -1222,23 +1688,59 @@ func (s *StratumServer) handleV2SubmitShares(miner *Miner, payload []byte) error
 	log.Printf("V2 SubmitShares from %s: ChannelID=%d, JobID=%d, Nonce=0x%08x",
 		miner.ID, submit.ChannelID, submit.JobID, submit.Nonce)
 
-	// Accept the share (simplified validation)
-	miner.SharesValid++
-	miner.LastShare = time.Now()
+	if !miner.IsV2 || miner.ExtendedChannel || submit.ChannelID != miner.ChannelID {
+		return s.rejectV2Share(miner, submit.ChannelID, submit.SequenceNum, submit.Nonce, "", v2binary.ErrInvalidChannelID, rejectReasonMalformed)
+	}
+
+	nonce := fmt.Sprintf("%08x", submit.Nonce)
+	jobID := fmt.Sprintf("%x", submit.JobID)
+
+	job, stale := s.lookupJob(jobID)
+	if stale {
+		return s.rejectV2Share(miner, submit.ChannelID, submit.SequenceNum, submit.Nonce, "", v2binary.ErrStaleShare, rejectReasonStale)
+	}
+	if job == nil {
+		return s.rejectV2Share(miner, submit.ChannelID, submit.SequenceNum, submit.Nonce, "", v2binary.ErrInvalidJobID, rejectReasonJobNotFound)
+	}
 
-	// Record share in database
-	_, err = s.db.Exec(
-		"INSERT INTO shares (miner_id, user_id, difficulty, is_valid, nonce, hash) VALUES (1, 1, $1, true, $2, $3)",
-		miner.Difficulty, fmt.Sprintf("%08x", submit.Nonce), "v2share",
-	)
+	// Standard channels roll only the version bits negotiated at setup
+	jobVersion, err := parseHexUint32(job.Version)
+	if err != nil {
+		return s.rejectV2Share(miner, submit.ChannelID, submit.SequenceNum, submit.Nonce, "", v2binary.ErrInvalidJobID, rejectReasonJobNotFound)
+	}
+	versionBits, err := versionBitsOf(jobVersion, s.minerVersionMask(miner), submit.Version)
 	if err != nil {
-		log.Printf("Failed to record V2 share: %v", err)
+		return s.rejectV2Share(miner, submit.ChannelID, submit.SequenceNum, submit.Nonce, "", v2binary.ErrInvalidShare, rejectReasonMalformed)
 	}
 
-	// Update Redis stats
-	ctx := context.Background()
-	s.redis.Incr(ctx, "pool:shares:valid")
-	s.redis.HIncrBy(ctx, fmt.Sprintf("miner:%s", miner.ID), "shares", 1)
+	// Standard channels have no extranonce2
+	key := shareKey(miner.Extranonce1, "", fmt.Sprintf("%08x", submit.NTime), nonce, fmt.Sprintf("%08x", submit.Version))
+	switch err := s.shareTracker.CheckAndRecord(jobID, miner.Extranonce1, key); {
+	case errors.Is(err, shares.ErrDuplicateShare):
+		return s.rejectV2Share(miner, submit.ChannelID, submit.SequenceNum, submit.Nonce, "", v2binary.ErrDuplicateShare, rejectReasonDuplicate)
+	case errors.Is(err, shares.ErrShareLimitReached):
+		return s.rejectV2Share(miner, submit.ChannelID, submit.SequenceNum, submit.Nonce, "", v2binary.ErrInvalidShare, rejectReasonShareLimit)
+	}
+
+	// Verify proof of work against the merkle root the channel was sent
+	result, err := s.validateShare(miner, job, v2StandardExtranonce2, fmt.Sprintf("%08x", submit.NTime), nonce, versionBits)
+	if errors.Is(err, errShareNotVerified) {
+		s.shareTracker.Forget(jobID, miner.Extranonce1, key)
+		log.Printf("[%s] V2 share for job %s not verified: %v", miner.ID, jobID, err)
+		return s.rejectV2Share(miner, submit.ChannelID, submit.SequenceNum, submit.Nonce, "", v2binary.ErrInvalidShare, "") // No reason: not stored
+	}
+	if err != nil {
+		log.Printf("[%s] Malformed V2 share for job %s: %v", miner.ID, jobID, err)
+		return s.rejectV2Share(miner, submit.ChannelID, submit.SequenceNum, submit.Nonce, "", v2binary.ErrInvalidShare, rejectReasonMalformed)
+	}
+	if !result.ShareValid {
+		return s.rejectV2Share(miner, submit.ChannelID, submit.SequenceNum, submit.Nonce, result.Hash, v2binary.ErrLowDifficultyShare, rejectReasonLowDifficulty)
+	}
+	if result.BlockValid {
+		go s.submitBlock(miner, job, result)
+	}
+
+	newDiff, changed := s.acceptShare(miner, nonce, result.Hash)
 
 	// Send SubmitSharesSuccess
 	ser := v2binary.NewSerializer()
@@ -1246,17 +1748,41 @@ func (s *StratumServer) handleV2SubmitShares(miner *Miner, payload []byte) error
 		ChannelID:       submit.ChannelID,
 		LastSequenceNum: submit.SequenceNum,
 		NewSubmits:      1,
-		NewDifficulty:   0, // No difficulty change
 	}
 	payloadBytes := ser.SerializeSubmitSharesSuccess(successMsg)
 	frame := ser.SerializeFrame(v2binary.MsgTypeSubmitSharesSuccess, 0, payloadBytes)
 
-	_, err = miner.Conn.Write(frame)
-	if err != nil {
+	if _, err := miner.Conn.Write(frame); err != nil {
 		return fmt.Errorf("send SubmitSharesSuccess: %w", err)
 	}
 
-	log.Printf("V2 Share accepted from %s (total: %d)", miner.ID, miner.SharesValid)
+	if changed {
+		return s.sendV2SetTarget(miner, newDiff)
+	}
+	return nil
+}
+
+// rejectV2Share records a rejected V2 share and answers with SubmitSharesError
+func (s *StratumServer) rejectV2Share(miner *Miner, channelID, sequenceNum, nonce uint32, hash string, code uint8, reason string) error {
+	miner.SharesInvalid++
+	if reason != "" {
+		s.recordRejectedShare(miner, fmt.Sprintf("%08x", nonce), hash, reason)
+	}
+
+	ser := v2binary.NewSerializer()
+	errMsg := &v2binary.SubmitSharesError{
+		ChannelID:   channelID,
+		SequenceNum: sequenceNum,
+		ErrorCode:   v2binary.ErrorCodeName(code),
+	}
+	payloadBytes := ser.SerializeSubmitSharesError(errMsg)
+	frame := ser.SerializeFrame(v2binary.MsgTypeSubmitSharesError, 0, payloadBytes)
+
+	if _, err := miner.Conn.Write(frame); err != nil {
+		return fmt.Errorf("send SubmitSharesError: %w", err)
+	}
+
+	log.Printf("V2 Share rejected from %s: %s", miner.ID, reason)
 	return nil
 }
 
@@ -1344,8 +1870,7 @@ func (s *StratumServer) handleMessage(miner *Miner, message string) error {
 		// Return empty transactions list - miner may request this for block validation
 		return s.sendResponse(miner, req.ID, []string{}, nil)
 	case "mining.configure":
-		// Return empty config - some miners request this
-		return s.sendResponse(miner, req.ID, map[string]interface{}{}, nil)
+		return s.handleConfigure(miner, req)
 	case "mining.suggest_difficulty":
 		return s.handleSuggestDifficulty(miner, req)
 	default:
@@ -1356,26 +1881,38 @@ func (s *StratumServer) handleMessage(miner *Miner, message string) error {
 
 // handleSubscribe handles mining.subscribe
 func (s *StratumServer) handleSubscribe(miner *Miner, req StratumRequest) error {
-	// Extract user agent from params if provided (e.g., ["cpuminer/2.5.1", "session_id"])
+	// Params are optional: ["cpuminer/2.5.1", "<session id to resume>"]
+	userAgent, resumeID := "", ""
 	if len(req.Params) > 0 {
-		if ua, ok := req.Params[0].(string); ok {
-			miner.UserAgent = ua
-			miner.MinerType = detectMinerType(ua)
+		userAgent, _ = req.Params[0].(string)
+	}
+	if len(req.Params) > 1 {
+		resumeID, _ = req.Params[1].(string)
+	}
+	if userAgent != "" {
+		miner.UserAgent = userAgent
+		miner.MinerType = vardiff.ClassifyUserAgent(userAgent).Name
+	}
 
-			// Set appropriate initial difficulty based on miner type
-			initialDiff := getInitialDifficultyForMinerType(miner.MinerType)
+	if s.resumeSession(miner, resumeID) {
+		log.Printf("[%s] Resumed session %s: diff=%.0f, extranonce1=%s",
+			miner.ID, miner.SessionID, miner.Difficulty, miner.Extranonce1)
+	} else {
+		if userAgent != "" {
+			// Seed the difficulty for the miner's hardware class
+			initialDiff := s.vardiffManager.Seed(miner.ID, vardiff.Hints{UserAgent: userAgent})
 			miner.Difficulty = initialDiff
-			s.vardiffManager.SetDifficulty(miner.ID, initialDiff)
 
 			log.Printf("[%s] Subscribed: type=%s, diff=%.0f, agent=%s",
-				miner.ID, miner.MinerType, initialDiff, ua)
+				miner.ID, miner.MinerType, initialDiff, userAgent)
 		}
+		miner.SessionID = session.NewID()
+		miner.Extranonce1 = s.getNextExtranonce1()
 	}
 
-	// Generate subscription details
-	subscriptionID := fmt.Sprintf("%x", time.Now().UnixNano())
-	extranonce1 := s.getNextExtranonce1()
-	extranonce2Size := 4
+	// The session ID doubles as the subscription ID
+	subscriptionID := miner.SessionID
+	extranonce1 := miner.Extranonce1
 
 	result := []interface{}{
 		[][]string{
@@ -1444,77 +1981,82 @@ func (s *StratumServer) handleAuthorize(miner *Miner, req StratumRequest) error
 	// Trim whitespace from username (some miners send leading/trailing spaces)
 	username = strings.TrimSpace(username)
 
-	// Look up user by username OR email in database
-	var userID int64
-	var actualUsername string
-	err := s.db.QueryRow(
-		"SELECT id, username FROM users WHERE (username = $1 OR email = $1) AND is_active = true",
-		username,
-	).Scan(&userID, &actualUsername)
-
-	if err == sql.ErrNoRows {
-		log.Printf("Authorization failed for %s: user '%s' not found (checked username and email)", miner.ID, username)
-		return s.sendResponse(miner, req.ID, false, "User not found. Please register at the pool website.")
+	if err := s.authorizeUser(miner, username); err != nil {
+		if errors.Is(err, errUserNotFound) {
+			return s.sendResponse(miner, req.ID, false, "User not found. Please register at the pool website.")
+		}
+		return s.sendResponse(miner, req.ID, false, "Authorization failed - database error")
+	}
+
+	return s.sendResponse(miner, req.ID, true, nil)
+}
+
+// errUserNotFound is returned when a miner authorizes as an unknown or inactive user
+var errUserNotFound = errors.New("user not found")
+
+// authorizeUser resolves the worker name a miner authorizes as through the
+// authenticator, marks the miner authorized and refreshes its miners row
+func (s *StratumServer) authorizeUser(miner *Miner, username string) error {
+	result, err := s.authenticator.Authenticate(context.Background(), username, "")
+	if errors.Is(err, stratum.ErrUserNotFound) || errors.Is(err, stratum.ErrUserDisabled) ||
+		errors.Is(err, stratum.ErrInvalidWorkerName) {
+		log.Printf("Authorization failed for %s: user '%s' not found or inactive (checked username and email)", miner.ID, username)
+		return errUserNotFound
 	} else if err != nil {
 		log.Printf("Database error during authorization for %s: %v", miner.ID, err)
-		return s.sendResponse(miner, req.ID, false, "Authorization failed - database error")
+		return fmt.Errorf("authenticate: %w", err)
 	}
 
 	// Set miner authorization with proper user tracking (use actual username from DB)
 	miner.Authorized = true
-	miner.UserID = userID
-	miner.Username = actualUsername
-	log.Printf("[%s] Authorized: %s (id:%d)", miner.ID, actualUsername, userID)
-
-	// Record or update miner in database with correct user_id
-	// First check if miner exists, then insert or update
-	var existingMinerID int64
-	err = s.db.QueryRow(
-		"SELECT id FROM miners WHERE user_id = $1 AND name = $2 LIMIT 1",
-		userID, actualUsername,
-	).Scan(&existingMinerID)
-
-	if err == sql.ErrNoRows {
-		// Create new miner record - strip port from address for inet type
-		ipOnly := miner.Address
-		if host, _, err := net.SplitHostPort(miner.Address); err == nil {
-			ipOnly = host
-		}
-		_, err = s.db.Exec(
-			"INSERT INTO miners (user_id, name, address, is_active, network_id) VALUES ($1, $2, $3, true, $4)",
-			userID, actualUsername, ipOnly, s.activeNetworkID,
-		)
-		if err != nil {
-			log.Printf("Failed to create miner for user %d: %v", userID, err)
+	miner.UserID = result.UserID
+	miner.MinerDBID = result.MinerID
+	miner.Username = result.Username
+	miner.WorkerName = result.WorkerName
+	log.Printf("[%s] Authorized: %s.%s (id:%d)", miner.ID, result.Username, result.WorkerName, result.UserID)
+	s.publishWorkerEvent(miner, events.KindAuthorized, 0)
+
+	// Publish the decisions made before authorization, then apply the user's
+	// vardiff policy if one is assigned
+	s.publishVardiffTrace(miner)
+	worker := result.Username + "." + result.WorkerName
+	if newDiff, changed := s.vardiffManager.SetWorker(miner.ID, result.Username, worker); changed {
+		miner.Difficulty = newDiff
+		if !miner.IsV2 && miner.Extranonce1 != "" {
+			s.sendNotification(miner, "mining.set_difficulty", []interface{}{newDiff})
 		}
-	} else if err == nil {
-		// Update existing miner - strip port from address for inet type
-		ipOnly := miner.Address
-		if host, _, err := net.SplitHostPort(miner.Address); err == nil {
+	}
+
+	// Update the miner record and geolocate its IP in background (don't block authorization)
+	go s.touchMiner(result.MinerID, result.UserID, result.WorkerName, miner.Address)
+
+	return nil
+}
+
+// touchMiner marks a miner active on the current network and records its address
+func (s *StratumServer) touchMiner(minerDBID, userID int64, workerName, address string) {
+	if s.db != nil {
+		// Strip port from address for inet type
+		ipOnly := address
+		if host, _, err := net.SplitHostPort(address); err == nil {
 			ipOnly = host
 		}
-		_, err = s.db.Exec(
+		_, err := s.db.Exec(
 			"UPDATE miners SET address = $1, is_active = true, updated_at = NOW(), network_id = $3 WHERE id = $2",
-			ipOnly, existingMinerID, s.activeNetworkID,
+			ipOnly, minerDBID, s.activeNetworkID,
 		)
 		if err != nil {
-			log.Printf("Failed to update miner %d: %v", existingMinerID, err)
+			log.Printf("Failed to update miner %d: %v", minerDBID, err)
 		}
-	} else {
-		log.Printf("Failed to check miner for user %d: %v", userID, err)
 	}
 
-	// Geolocate miner IP in background (don't block authorization)
-	go func() {
-		if s.geoService != nil {
-			s.geoService.UpdateMinerLocationByUserAndName(userID, actualUsername, miner.Address)
-		}
-	}()
-
-	return s.sendResponse(miner, req.ID, true, nil)
+	if s.geoService != nil {
+		s.geoService.UpdateMinerLocationByUserAndName(userID, workerName, address)
+	}
 }
 
 // handleSubmit handles mining.submit (share submission)
+// Params: [worker_name, job_id, extranonce2, ntime, nonce, (version_bits)]
 func (s *StratumServer) handleSubmit(miner *Miner, req StratumRequest) error {
 	if !miner.Authorized {
 		return s.sendResponse(miner, req.ID, false, "Not authorized")
@@ -1533,91 +2075,132 @@ func (s *StratumServer) handleSubmit(miner *Miner, req StratumRequest) error {
 			miner.ID, miner.Difficulty, minDifficulty)
 		miner.SharesInvalid++
 		// Record invalid share in database for tracking
-		s.db.Exec(`
-			INSERT INTO shares (miner_id, user_id, difficulty, is_valid, nonce, hash, timestamp, network_id) 
-			VALUES ($1, $2, $3, false, $4, $5, NOW(), $6)`,
-			0, miner.UserID, miner.Difficulty, "rejected-low-diff", "invalid", s.activeNetworkID,
-		)
+		s.recordRejectedShare(miner, "rejected-low-diff", "", rejectReasonLowDifficulty)
 		return s.sendResponse(miner, req.ID, false, "Share difficulty too low")
 	}
 
-	// Calculate time since last share for vardiff
-	now := time.Now()
-	shareTime := time.Since(miner.LastShare)
-	if miner.LastShare.IsZero() {
-		shareTime = 10 * time.Second // Default for first share
+	if len(req.Params) < 5 {
+		miner.SharesInvalid++
+		return s.sendResponse(miner, req.ID, false, stratumError(stratumErrOther, "Invalid parameters"))
+	}
+	jobID, _ := req.Params[1].(string)
+	extranonce2, _ := req.Params[2].(string)
+	ntime, _ := req.Params[3].(string)
+	nonce, _ := req.Params[4].(string)
+
+	// BIP310 version rolling adds the rolled bits: [..., nonce, version_bits]
+	var versionBits uint32
+	versionHex := ""
+	if len(req.Params) > 5 {
+		versionHex, _ = req.Params[5].(string)
+		bits, err := parseHexUint32(versionHex)
+		if err != nil {
+			miner.SharesInvalid++
+			s.recordRejectedShare(miner, nonce, "", rejectReasonMalformed)
+			return s.sendResponse(miner, req.ID, false, stratumError(stratumErrOther, errInvalidVersionBits.Error()))
+		}
+		versionBits = bits
+	}
+
+	job, stale := s.lookupJob(jobID)
+	if stale {
+		miner.SharesInvalid++
+		s.recordRejectedShare(miner, nonce, "", rejectReasonStale)
+		return s.sendResponse(miner, req.ID, false, stratumError(stratumErrJobNotFound, "Stale share"))
+	}
+	if job == nil {
+		miner.SharesInvalid++
+		s.recordRejectedShare(miner, nonce, "", rejectReasonJobNotFound)
+		return s.sendResponse(miner, req.ID, false, stratumError(stratumErrJobNotFound, "Job not found"))
+	}
+
+	// Reject replayed work before spending a scrypt hash on it
+	key := shareKey(miner.Extranonce1, extranonce2, ntime, nonce, versionHex)
+	switch err := s.shareTracker.CheckAndRecord(jobID, miner.Extranonce1, key); {
+	case errors.Is(err, shares.ErrDuplicateShare):
+		miner.SharesInvalid++
+		s.recordRejectedShare(miner, nonce, "", rejectReasonDuplicate)
+		return s.sendResponse(miner, req.ID, false, stratumError(stratumErrDuplicateShare, "Duplicate share"))
+	case errors.Is(err, shares.ErrShareLimitReached):
+		// Further shares could not be checked for replay until the next job
+		miner.SharesInvalid++
+		s.recordRejectedShare(miner, nonce, "", rejectReasonShareLimit)
+		return s.sendResponse(miner, req.ID, false, stratumError(stratumErrOther, "Share limit for job reached, wait for new work"))
 	}
 
-	// Record share for vardiff adjustment
-	s.vardiffManager.RecordShare(miner.ID, shareTime)
+	// Verify proof of work against the share and network targets
+	result, err := s.validateShare(miner, job, extranonce2, ntime, nonce, versionBits)
+	if errors.Is(err, errShareNotVerified) {
+		// Overload, not a bad share: answer but don't count it against the
+		// miner, and let it be resubmitted
+		s.shareTracker.Forget(jobID, miner.Extranonce1, key)
+		log.Printf("[%s] Share for job %s not verified: %v", miner.ID, jobID, err)
+		return s.sendResponse(miner, req.ID, false, stratumError(stratumErrOther, "Share not verified, pool busy"))
+	}
+	if err != nil {
+		log.Printf("[%s] Malformed share for job %s: %v", miner.ID, jobID, err)
+		miner.SharesInvalid++
+		s.recordRejectedShare(miner, nonce, "", rejectReasonMalformed)
+		return s.sendResponse(miner, req.ID, false, stratumError(stratumErrOther, err.Error()))
+	}
+	if !result.ShareValid {
+		miner.SharesInvalid++
+		s.recordRejectedShare(miner, nonce, result.Hash, rejectReasonLowDifficulty)
+		return s.sendResponse(miner, req.ID, false, stratumError(stratumErrLowDifficulty, "Low difficulty share"))
+	}
+	if result.BlockValid {
+		go s.submitBlock(miner, job, result)
+	}
+
+	// Send new difficulty to miner (silently - logged in share summaries)
+	if newDiff, changed := s.acceptShare(miner, nonce, result.Hash); changed {
+		s.sendNotification(miner, "mining.set_difficulty", []interface{}{newDiff})
+	}
+	return s.sendResponse(miner, req.ID, true, nil)
+}
+
+// acceptShare credits a valid share: vardiff, database, hashrate and Redis
+// stats. It reports the miner's new difficulty if vardiff changed it.
+func (s *StratumServer) acceptShare(miner *Miner, nonce, hash string) (newDiff float64, changed bool) {
+	// Record the share for vardiff; the first share only starts the clock
+	now := time.Now()
+	shareDifficulty := miner.Difficulty
+	newDiff, _ = s.vardiffManager.RecordShareAt(miner.ID, now)
 
-	// Validate share (simplified - in production would verify against blockchain)
 	miner.SharesValid++
 	miner.LastShare = now
 
 	// Check if difficulty needs adjustment
-	newDiff := s.vardiffManager.GetDifficulty(miner.ID)
 	if newDiff != miner.Difficulty {
 		miner.Difficulty = newDiff
-		// Send new difficulty to miner (silently - logged in share summaries)
-		s.sendNotification(miner, "mining.set_difficulty", []interface{}{newDiff})
-	}
-
-	// Get or create miner record for this user
-	var minerDBID int64
-	err := s.db.QueryRow(`
-		SELECT id FROM miners 
-		WHERE user_id = $1 AND name = $2 
-		LIMIT 1`,
-		miner.UserID, miner.Username,
-	).Scan(&minerDBID)
-
-	if err == sql.ErrNoRows {
-		// Create miner record if it doesn't exist (with network_id)
-		err = s.db.QueryRow(`
-			INSERT INTO miners (user_id, name, address, is_active, network_id) 
-			VALUES ($1, $2, $3, true, $4) 
-			RETURNING id`,
-			miner.UserID, miner.Username, miner.Address, s.activeNetworkID,
-		).Scan(&minerDBID)
-		if err != nil {
-			log.Printf("Failed to create miner record for user %d: %v", miner.UserID, err)
-			minerDBID = 1 // Fallback to prevent share loss
-		}
-	} else if err != nil {
-		log.Printf("Failed to lookup miner for user %d: %v", miner.UserID, err)
-		minerDBID = 1 // Fallback to prevent share loss
+		changed = true
 	}
 
-	// Record share in database with correct user_id, miner_id, and network_id
-	_, err = s.db.Exec(`
-		INSERT INTO shares (miner_id, user_id, difficulty, is_valid, nonce, hash, timestamp, network_id) 
-		VALUES ($1, $2, $3, true, $4, $5, NOW(), $6)`,
-		minerDBID, miner.UserID, miner.Difficulty, "submitted", "hash", s.activeNetworkID,
-	)
-	if err != nil {
-		log.Printf("Failed to record share for user %d: %v", miner.UserID, err)
-	}
+	// Queue the share for batched insertion
+	s.recordShare(miner, shareDifficulty, nonce, hash, "")
+	s.publishWorkerEvent(miner, events.KindShareAccepted, shareDifficulty)
 
 	// Track share for hashrate calculation
 	s.hashrateMux.Lock()
 	if _, exists := s.hashrateWindows[miner.ID]; !exists {
 		s.hashrateWindows[miner.ID] = hashrate.NewWindow(5 * time.Minute)
 	}
-	s.hashrateWindows[miner.ID].AddShare(miner.Difficulty, time.Now())
+	s.hashrateWindows[miner.ID].AddShare(shareDifficulty, time.Now())
 	currentHashrate := s.hashrateWindows[miner.ID].GetHashrate()
 	s.hashrateMux.Unlock()
 
-	// Update miner hashrate in database (every 10 shares to reduce DB load)
-	if miner.SharesValid%10 == 0 {
-		_, err = s.db.Exec(`
-			UPDATE miners SET hashrate = $1, last_seen = NOW() 
-			WHERE user_id = $2 AND name = $3`,
-			currentHashrate, miner.UserID, miner.Username,
-		)
-		if err != nil {
-			log.Printf("Failed to update hashrate for miner %s: %v", miner.Username, err)
-		}
+	// Update miner hashrate in database (every 10 shares, off the submit path)
+	if miner.SharesValid%10 == 0 && s.db != nil {
+		go func(minerDBID int64) {
+			_, err := s.db.Exec(`
+				UPDATE miners SET hashrate = $1, last_seen = NOW() 
+				WHERE id = $2`,
+				currentHashrate, minerDBID,
+			)
+			if err != nil {
+				log.Printf("Failed to update hashrate for miner %d: %v", minerDBID, err)
+			}
+		}(miner.MinerDBID)
 	}
 
 	// Update Redis stats with user-specific tracking
@@ -1632,7 +2215,31 @@ func (s *StratumServer) handleSubmit(miner *Miner, req StratumRequest) error {
 		log.Printf("[%s] %s: %d shares, %s",
 			miner.ID, miner.Username, miner.SharesValid, s.hashrateCalc.Format(currentHashrate))
 	}
-	return s.sendResponse(miner, req.ID, true, nil)
+	return newDiff, changed
+}
+
+// recordRejectedShare stores an invalid share and why it was rejected
+func (s *StratumServer) recordRejectedShare(miner *Miner, nonce, hash, reason string) {
+	s.recordShare(miner, miner.Difficulty, nonce, hash, reason)
+}
+
+// recordShare queues a share for batched insertion; reason is empty for valid
+// shares. Shares from miners without database IDs are not stored, since one
+// bad foreign key would fail the whole batch.
+func (s *StratumServer) recordShare(miner *Miner, difficulty float64, nonce, hash, reason string) {
+	if s.shareRecorder == nil || miner.MinerDBID == 0 || miner.UserID == 0 {
+		return
+	}
+	s.shareRecorder.RecordShare(&shares.Share{
+		MinerID:    miner.MinerDBID,
+		UserID:     miner.UserID,
+		Nonce:      nonce,
+		Hash:       hash,
+		Difficulty: difficulty,
+		Timestamp:  time.Now(),
+		WorkerName: miner.WorkerName,
+		Solo:       miner.Solo,
+	}, reason)
 }
 
 // sendResponse sends a stratum response
@@ -1695,89 +2302,30 @@ func (s *StratumServer) handleSuggestDifficulty(miner *Miner, req StratumRequest
 		return s.sendResponse(miner, req.ID, true, nil)
 	}
 
-	// Clamp to reasonable bounds (0.001 to 1,000,000)
-	if suggestedDiff < 0.001 {
-		suggestedDiff = 0.001
-	}
-	if suggestedDiff > 1000000 {
-		suggestedDiff = 1000000
+	// The miner's policy clamps the suggestion to its bounds, or ignores it
+	newDiff, ok := s.vardiffManager.Suggest(miner.ID, suggestedDiff)
+	if !ok {
+		log.Printf("[%s] Suggested diff=%.0f, ignored by policy", miner.ID, suggestedDiff)
+		return s.sendResponse(miner, req.ID, true, nil)
 	}
+	miner.Difficulty = newDiff
 
-	// Set the suggested difficulty
-	s.vardiffManager.SetDifficulty(miner.ID, suggestedDiff)
-	miner.Difficulty = suggestedDiff
-
-	log.Printf("[%s] Suggested diff=%.0f, accepted", miner.ID, suggestedDiff)
+	log.Printf("[%s] Suggested diff=%.0f, accepted as %.0f", miner.ID, suggestedDiff, newDiff)
 
 	// Send updated difficulty to miner
-	s.sendNotification(miner, "mining.set_difficulty", []interface{}{suggestedDiff})
+	s.sendNotification(miner, "mining.set_difficulty", []interface{}{newDiff})
 
 	return s.sendResponse(miner, req.ID, true, nil)
 }
 
-// detectMinerType analyzes user agent string to detect miner hardware type
-// Returns "cpu", "gpu", "asic", or "unknown"
-func detectMinerType(userAgent string) string {
-	ua := strings.ToLower(userAgent)
-
-	// CPU miners - very specific patterns
-	cpuPatterns := []string{"cpuminer", "minerd", "xmrig"}
-	for _, p := range cpuPatterns {
-		if strings.Contains(ua, p) {
-			return "cpu"
-		}
-	}
-
-	// ASIC miners (check BEFORE GPU since some software like bfgminer/cgminer work with both)
-	// These are definite ASIC indicators
-	asicPatterns := []string{"antminer", "whatsminer", "avalon", "innosilicon", "goldshell", "bitmain", "x100", "x30", "blockdag", "asic", "luckyminer"}
-	for _, p := range asicPatterns {
-		if strings.Contains(ua, p) {
-			return "asic"
-		}
-	}
-
-	// GPU-only miners (NOT cgminer/bfgminer/sgminer which can be used with ASICs)
-	gpuOnlyPatterns := []string{"claymore", "ethminer", "phoenixminer", "t-rex", "gminer", "lolminer", "nbminer"}
-	for _, p := range gpuOnlyPatterns {
-		if strings.Contains(ua, p) {
-			return "gpu"
-		}
-	}
-
-	// cgminer, bfgminer, sgminer can be used with GPUs OR ASICs
-	// Default to higher difficulty (asic-like) to be safe - vardiff will adjust down if needed
-	multipurposePatterns := []string{"cgminer", "bfgminer", "sgminer"}
-	for _, p := range multipurposePatterns {
-		if strings.Contains(ua, p) {
-			return "multipurpose" // Could be GPU or ASIC, start high
-		}
-	}
-
-	return "unknown"
-}
-
-// getInitialDifficultyForMinerType returns appropriate initial difficulty based on miner type
-func getInitialDifficultyForMinerType(minerType string) float64 {
-	switch minerType {
-	case "cpu":
-		return 0.01 // CPU: ~10 KH/s -> difficulty ~0.01 for 10s shares
-	case "gpu", "multipurpose":
-		// bfgminer/cgminer can be GPU or ASIC - start at 1000, vardiff adjusts quickly
-		// At 1000 diff, 15 TH/s ASIC submits ~3.5 shares/sec (manageable)
-		// At 1000 diff, 10 MH/s GPU submits ~1 share/430sec (vardiff will lower)
-		return 1000
-	case "asic":
-		return 35000 // ASIC: ~15 TH/s -> difficulty ~35000 for 10s shares
-	default:
-		return 1000 // Unknown: start moderate, vardiff will adjust
-	}
-}
-
 // Shutdown gracefully shuts down the stratum server
 func (s *StratumServer) Shutdown() {
 	close(s.done)
 
+	if s.jobDeclarator != nil {
+		s.jobDeclarator.Stop()
+	}
+
 	s.minersMutex.Lock()
 	defer s.minersMutex.Unlock()
 
@@ -1818,6 +2366,8 @@ func (s *StratumServer) GetPoolMetrics() *health.PoolMetrics {
 	}
 	s.jobMutex.RUnlock()
 
+	metrics.Upstreams = s.upstreamMetrics()
+
 	return metrics
 }
 
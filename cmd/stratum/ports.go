@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"os"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/chimera-pool/chimera-pool-core/internal/stratum/detector"
+)
+
+// =============================================================================
+// PORT PROFILES
+// Each stratum port serves one class of miners, e.g. 3333 for low-end rigs,
+// 3334 for X100s and 3335 for solo. Profiles come from the YAML file named by
+// STRATUM_PORTS_FILE; without one, STRATUM_PORT, STRATUM_V2_NOISE_PORT and
+// STRATUM_TLS_PORT describe a single mixed port and optional Noise and TLS
+// ports.
+// =============================================================================
+
+// portsFile is the layout of STRATUM_PORTS_FILE
+type portsFile struct {
+	Ports []portConfig `yaml:"ports"`
+}
+
+// portConfig is one port profile as written in the ports file
+type portConfig struct {
+	Name       string   `yaml:"name"`
+	Address    string   `yaml:"address"` // "0.0.0.0:3333" or just "3333"
+	Protocols  []string `yaml:"protocols"`
+	Difficulty struct {
+		Initial float64 `yaml:"initial"`
+		Min     float64 `yaml:"min"`
+		Max     float64 `yaml:"max"`
+	} `yaml:"difficulty"`
+	VardiffPolicy string   `yaml:"vardiff_policy"`
+	Solo          bool     `yaml:"solo"`
+	AllowedCIDRs  []string `yaml:"allowed_cidrs"`
+}
+
+// loadPortProfiles reads port profiles from a YAML file
+func loadPortProfiles(path string) ([]*detector.PortProfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read ports file: %w", err)
+	}
+	return parsePortProfiles(data)
+}
+
+// parsePortProfiles parses and validates the ports file format
+func parsePortProfiles(data []byte) ([]*detector.PortProfile, error) {
+	var file portsFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse ports file: %w", err)
+	}
+	if len(file.Ports) == 0 {
+		return nil, fmt.Errorf("ports file lists no ports")
+	}
+
+	seen := make(map[string]bool)
+	profiles := make([]*detector.PortProfile, 0, len(file.Ports))
+	for _, port := range file.Ports {
+		profile, err := port.profile()
+		if err != nil {
+			return nil, err
+		}
+		if seen[profile.Port()] {
+			return nil, fmt.Errorf("port %s configured twice", profile.Port())
+		}
+		seen[profile.Port()] = true
+		profiles = append(profiles, profile)
+	}
+	return profiles, nil
+}
+
+// profile converts a ports file entry to a validated profile
+func (c portConfig) profile() (*detector.PortProfile, error) {
+	address := c.Address
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = "0.0.0.0:" + address
+	}
+
+	profile := &detector.PortProfile{
+		Name:              c.Name,
+		Address:           address,
+		InitialDifficulty: c.Difficulty.Initial,
+		MinDifficulty:     c.Difficulty.Min,
+		MaxDifficulty:     c.Difficulty.Max,
+		VardiffPolicy:     c.VardiffPolicy,
+		ForceSolo:         c.Solo,
+	}
+	for _, name := range c.Protocols {
+		version, err := detector.ParseProtocol(name)
+		if err != nil {
+			return nil, fmt.Errorf("port %s: %w", c.Address, err)
+		}
+		profile.Protocols = append(profile.Protocols, version)
+	}
+
+	networks, err := detector.ParseCIDRs(c.AllowedCIDRs)
+	if err != nil {
+		return nil, fmt.Errorf("port %s: %w", c.Address, err)
+	}
+	profile.AllowedCIDRs = networks
+
+	if err := profile.Validate(); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// legacyPortProfiles describes the single-port setup from STRATUM_PORT,
+// STRATUM_V2_NOISE_PORT and STRATUM_TLS_PORT
+func legacyPortProfiles(config *Config) []*detector.PortProfile {
+	profiles := []*detector.PortProfile{{
+		Name:      "stratum",
+		Address:   fmt.Sprintf("0.0.0.0:%s", config.Port),
+		Protocols: []detector.ProtocolVersion{detector.ProtocolV1, detector.ProtocolV2},
+	}}
+	if config.V2NoisePort != "" {
+		profiles = append(profiles, &detector.PortProfile{
+			Name:      "stratum-v2-noise",
+			Address:   fmt.Sprintf("0.0.0.0:%s", config.V2NoisePort),
+			Protocols: []detector.ProtocolVersion{detector.ProtocolV2Noise},
+		})
+	}
+	if config.TLSPort != "" {
+		profiles = append(profiles, &detector.PortProfile{
+			Name:      "stratum-tls",
+			Address:   fmt.Sprintf("0.0.0.0:%s", config.TLSPort),
+			Protocols: []detector.ProtocolVersion{detector.ProtocolTLS},
+		})
+	}
+	return profiles
+}
+
+// portProfiles returns the configured profiles, falling back to the legacy
+// single-port settings
+func portProfiles(config *Config) ([]*detector.PortProfile, error) {
+	if config.PortsFile == "" {
+		return legacyPortProfiles(config), nil
+	}
+	profiles, err := loadPortProfiles(config.PortsFile)
+	if err != nil {
+		return nil, err
+	}
+	for _, profile := range profiles {
+		log.Printf("🔌 Port profile %s", profile)
+	}
+	return profiles, nil
+}
+
+// profilesAllow reports whether any profile accepts a protocol
+func profilesAllow(profiles []*detector.PortProfile, version detector.ProtocolVersion) bool {
+	for _, profile := range profiles {
+		if profile.Allows(version) {
+			return true
+		}
+	}
+	return false
+}
+
+// noiseProtocolHandler serves ports whose profile is Noise-encrypted V2
+type noiseProtocolHandler struct {
+	server *StratumServer
+}
+
+func (h *noiseProtocolHandler) HandleConnection(conn net.Conn) error {
+	h.server.HandleNoiseConnection(conn)
+	return nil
+}
+
+func (h *noiseProtocolHandler) Protocol() detector.ProtocolVersion {
+	return detector.ProtocolV2Noise
+}
+func (h *noiseProtocolHandler) Shutdown() error { return nil }
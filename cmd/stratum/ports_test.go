@@ -0,0 +1,73 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/chimera-pool/chimera-pool-core/internal/stratum/detector"
+)
+
+func TestParsePortProfiles(t *testing.T) {
+	profiles, err := parsePortProfiles([]byte(`
+ports:
+  - name: low-end
+    address: "3333"
+    protocols: [v1]
+    difficulty: {initial: 0.01, min: 0.001, max: 1}
+  - name: x100
+    address: 127.0.0.1:3334
+    protocols: [v1, v2]
+    vardiff_policy: ema
+  - name: solo
+    address: "3335"
+    solo: true
+    allowed_cidrs: [10.0.0.0/8, 192.168.1.7]
+`))
+	require.NoError(t, err)
+	require.Len(t, profiles, 3)
+
+	assert.Equal(t, "0.0.0.0:3333", profiles[0].Address)
+	assert.Equal(t, []detector.ProtocolVersion{detector.ProtocolV1}, profiles[0].Protocols)
+	assert.Equal(t, 0.01, profiles[0].InitialDifficulty)
+	assert.Equal(t, "127.0.0.1:3334", profiles[1].Address)
+	assert.Equal(t, "ema", profiles[1].VardiffPolicy)
+	assert.True(t, profiles[2].ForceSolo)
+	assert.Len(t, profiles[2].AllowedCIDRs, 2)
+}
+
+func TestParsePortProfiles_Errors(t *testing.T) {
+	tests := map[string]string{
+		"no ports":         `ports: []`,
+		"duplicate port":   "ports:\n  - address: \"3333\"\n  - address: 0.0.0.0:3333\n",
+		"unknown protocol": "ports:\n  - address: \"3333\"\n    protocols: [v3]\n",
+		"bad cidr":         "ports:\n  - address: \"3333\"\n    allowed_cidrs: [nonsense]\n",
+		"noise mixed":      "ports:\n  - address: \"3336\"\n    protocols: [v1, v2-noise]\n",
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := parsePortProfiles([]byte(data))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestLegacyPortProfiles(t *testing.T) {
+	profiles := legacyPortProfiles(&Config{Port: "3333"})
+	require.Len(t, profiles, 1)
+	assert.True(t, profiles[0].Allows(detector.ProtocolV1))
+	assert.True(t, profiles[0].Allows(detector.ProtocolV2))
+
+	profiles = legacyPortProfiles(&Config{Port: "3333", V2NoisePort: "3336"})
+	require.Len(t, profiles, 2)
+	assert.True(t, profiles[1].NoiseOnly())
+	assert.Equal(t, "3336", profiles[1].Port())
+	assert.True(t, profilesAllow(profiles, detector.ProtocolV2Noise))
+	assert.False(t, profilesAllow(profiles, detector.ProtocolTLS))
+
+	profiles = legacyPortProfiles(&Config{Port: "3333", TLSPort: "3443"})
+	require.Len(t, profiles, 2)
+	assert.Equal(t, "3443", profiles[1].Port())
+	assert.True(t, profilesAllow(profiles, detector.ProtocolTLS))
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"github.com/chimera-pool/chimera-pool-core/internal/stratum/events"
+	"github.com/chimera-pool/chimera-pool-core/internal/stratum/session"
+	"github.com/chimera-pool/chimera-pool-core/internal/stratum/vardiff"
+)
+
+// =============================================================================
+// SESSION RESUMPTION
+// mining.subscribe returns a session ID. A V1 miner that drops and subscribes
+// again with ["agent", "<session id>"] within STRATUM_SESSION_GRACE gets its
+// extranonce1, difficulty, vardiff history and authorization back.
+// =============================================================================
+
+// sessionVardiffKey is the vardiff key a parked session's state is kept under
+func sessionVardiffKey(sessionID string) string {
+	return "session:" + sessionID
+}
+
+// newSessionStore creates the parked session store, or nil when resumption
+// is disabled
+func newSessionStore(config *Config, vardiffManager *vardiff.Manager) *session.Store {
+	if config.SessionGrace <= 0 {
+		return nil
+	}
+	store := session.NewStore(config.SessionGrace)
+	store.SetExpireCallback(func(expired *session.Session) {
+		vardiffManager.RemoveMiner(expired.VardiffKey)
+	})
+	return store
+}
+
+// sessionSweeper expires parked sessions until the server shuts down
+func (s *StratumServer) sessionSweeper() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-s.done
+		cancel()
+	}()
+
+	s.sessions.Run(ctx, 0)
+}
+
+// resumeSession restores a parked session onto a subscribing miner. It
+// returns false if resumption is disabled or the session is unknown or expired.
+func (s *StratumServer) resumeSession(miner *Miner, sessionID string) bool {
+	if s.sessions == nil {
+		return false
+	}
+	parked, ok := s.sessions.Resume(sessionID)
+	if !ok {
+		return false
+	}
+
+	miner.SessionID = parked.ID
+	miner.Extranonce1 = parked.Extranonce1
+	miner.Difficulty = parked.Difficulty
+	if difficulty, ok := s.vardiffManager.Resume(parked.VardiffKey, miner.ID); ok {
+		miner.Difficulty = difficulty
+	}
+	if miner.UserAgent == "" {
+		miner.UserAgent = parked.UserAgent
+		miner.MinerType = vardiff.ClassifyUserAgent(parked.UserAgent).Name
+	}
+
+	if len(parked.Workers) > 0 {
+		worker := parked.Workers[len(parked.Workers)-1]
+		miner.Authorized = true
+		miner.UserID = worker.UserID
+		miner.MinerDBID = worker.MinerID
+		miner.Username = worker.Username
+		miner.WorkerName = worker.WorkerName
+		s.publishVardiffTrace(miner)
+		s.publishWorkerEvent(miner, events.KindAuthorized, 0)
+	}
+	return true
+}
+
+// parkSession keeps a disconnecting miner's session for the grace period. It
+// returns false if there is nothing to park, in which case the caller
+// releases the miner's vardiff state.
+func (s *StratumServer) parkSession(miner *Miner) bool {
+	if s.sessions == nil || miner.SessionID == "" || miner.Extranonce1 == "" {
+		return false
+	}
+
+	parked := &session.Session{
+		ID:          miner.SessionID,
+		Extranonce1: miner.Extranonce1,
+		Difficulty:  miner.Difficulty,
+		VardiffKey:  sessionVardiffKey(miner.SessionID),
+		UserAgent:   miner.UserAgent,
+	}
+	if miner.Authorized {
+		parked.Workers = []session.Worker{{
+			Name:       miner.Username + "." + miner.WorkerName,
+			Username:   miner.Username,
+			WorkerName: miner.WorkerName,
+			UserID:     miner.UserID,
+			MinerID:    miner.MinerDBID,
+		}}
+	}
+	if !s.vardiffManager.Park(miner.ID, parked.VardiffKey) {
+		return false
+	}
+	s.sessions.Park(parked)
+	log.Printf("[%s] Session %s parked for %s", miner.ID, miner.SessionID, s.sessions.GracePeriod())
+	return true
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/chimera-pool/chimera-pool-core/internal/stratum/vardiff"
+)
+
+// subscribeResult sends mining.subscribe and returns the session ID and
+// extranonce1 from the response
+func subscribeResult(t *testing.T, server *StratumServer, miner *Miner, params ...interface{}) (string, string) {
+	t.Helper()
+	conn := miner.Conn.(*MockConn)
+	conn.written = nil
+	require.NoError(t, server.handleSubscribe(miner, StratumRequest{ID: 1, Method: "mining.subscribe", Params: params}))
+
+	line := strings.SplitN(string(conn.written), "\n", 2)[0]
+	var response struct {
+		Result []json.RawMessage `json:"result"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(line), &response))
+	var subscriptions [][]string
+	require.NoError(t, json.Unmarshal(response.Result[0], &subscriptions))
+	var extranonce1 string
+	require.NoError(t, json.Unmarshal(response.Result[1], &extranonce1))
+	return subscriptions[0][1], extranonce1
+}
+
+func TestSubscribeResumesParkedSession(t *testing.T) {
+	server := newValidationTestServer()
+	server.sessions = newSessionStore(&Config{SessionGrace: time.Minute}, server.vardiffManager)
+
+	first := &Miner{ID: "conn-1", Conn: &MockConn{}}
+	first.Difficulty = server.vardiffManager.Attach(first.ID, "3333", vardiff.Hints{})
+	sessionID, extranonce1 := subscribeResult(t, server, first, "cgminer/4.12")
+	assert.Len(t, sessionID, 32)
+
+	first.Authorized, first.UserID, first.MinerDBID = true, 34, 5
+	first.Username, first.WorkerName = "picaxe", "rig1"
+	require.NoError(t, server.vardiffManager.SetDifficulty(first.ID, 512))
+	first.Difficulty = 512
+	require.True(t, server.parkSession(first))
+
+	// A reconnect with the session ID picks up where it left off
+	second := &Miner{ID: "conn-2", Conn: &MockConn{}}
+	second.Difficulty = server.vardiffManager.Attach(second.ID, "3333", vardiff.Hints{})
+	resumedID, resumedExtranonce := subscribeResult(t, server, second, "cgminer/4.12", sessionID)
+	assert.Equal(t, sessionID, resumedID)
+	assert.Equal(t, extranonce1, resumedExtranonce)
+	assert.Equal(t, 512.0, second.Difficulty)
+	assert.Contains(t, string(second.Conn.(*MockConn).written), `"mining.set_difficulty","params":[512]`)
+	assert.True(t, second.Authorized)
+	assert.Equal(t, int64(34), second.UserID)
+	assert.Equal(t, "rig1", second.WorkerName)
+
+	// The session was consumed; a second resume gets a fresh one
+	third := &Miner{ID: "conn-3", Conn: &MockConn{}}
+	server.vardiffManager.Attach(third.ID, "3333", vardiff.Hints{})
+	freshID, freshExtranonce := subscribeResult(t, server, third, "cgminer/4.12", sessionID)
+	assert.NotEqual(t, sessionID, freshID)
+	assert.NotEqual(t, extranonce1, freshExtranonce)
+	assert.False(t, third.Authorized)
+}
+
+func TestSubscribeWithoutSessionStore(t *testing.T) {
+	server := newValidationTestServer()
+
+	miner := &Miner{ID: "conn-1", Conn: &MockConn{}}
+	sessionID, _ := subscribeResult(t, server, miner)
+	assert.Equal(t, miner.SessionID, sessionID)
+	assert.False(t, server.parkSession(miner), "resumption disabled")
+}
@@ -0,0 +1,353 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/chimera-pool/chimera-pool-core/internal/shares"
+	"github.com/chimera-pool/chimera-pool-core/internal/stratum/blockdag"
+)
+
+// extranonce2Size is the number of extranonce2 bytes miners roll
+const extranonce2Size = 4
+
+// v2StandardExtranonce2 is the fixed extranonce2 of a V2 standard channel.
+// Standard channels don't roll the coinbase: the pool sends each job's merkle
+// root, built with the channel's extranonce prefix and this extranonce2.
+var v2StandardExtranonce2 = strings.Repeat("00", extranonce2Size)
+
+// maxNTimeDrift is how far ahead of the local clock a share's ntime may be
+// (nodes reject blocks more than two hours in the future)
+const maxNTimeDrift = 2 * time.Hour
+
+// Standard stratum V1 share rejection codes
+const (
+	stratumErrOther          = 20
+	stratumErrJobNotFound    = 21
+	stratumErrDuplicateShare = 22
+	stratumErrLowDifficulty  = 23
+)
+
+// Reject reasons stored with invalid shares in the shares table
+const (
+	rejectReasonMalformed     = "malformed"
+	rejectReasonJobNotFound   = "job-not-found"
+	rejectReasonStale         = "stale"
+	rejectReasonDuplicate     = "duplicate"
+	rejectReasonLowDifficulty = "low-difficulty"
+	rejectReasonShareLimit    = "share-limit"
+)
+
+// Share validation errors for malformed submissions
+var (
+	errInvalidExtranonce2 = errors.New("invalid extranonce2")
+	errInvalidNTime       = errors.New("invalid ntime")
+	errNTimeOutOfRange    = errors.New("ntime out of range")
+	errInvalidNonce       = errors.New("invalid nonce")
+	errInvalidJob         = errors.New("job data is invalid")
+
+	// errShareNotVerified means the share processor could not check the share
+	// (queue full, timeout or shutdown); the share itself may be fine
+	errShareNotVerified = errors.New("share not verified")
+)
+
+// stratumError builds a standard stratum V1 error triple
+func stratumError(code int, message string) []interface{} {
+	return []interface{}{code, message, nil}
+}
+
+// shareResult is the outcome of checking a submitted share against its job
+type shareResult struct {
+	Hash       string // Proof-of-work hash, big-endian hex
+	BlockHash  string // SHA256d block hash, big-endian hex
+	Header     []byte // Full 80-byte block header
+	Coinbase   []byte // Serialized coinbase transaction
+	ShareValid bool   // Meets the miner's share target
+	BlockValid bool   // Meets the network target
+}
+
+// validateShare rebuilds the block header for a miner's share and checks its
+// proof of work against the miner's difficulty and the network target.
+// versionBits are the version bits the miner rolled (0 if it doesn't roll).
+func (s *StratumServer) validateShare(miner *Miner, job *MiningJob, extranonce2, ntimeHex, nonceHex string, versionBits uint32) (*shareResult, error) {
+	if len(extranonce2) != extranonce2Size*2 {
+		return nil, errInvalidExtranonce2
+	}
+	if _, err := hex.DecodeString(extranonce2); err != nil {
+		return nil, errInvalidExtranonce2
+	}
+
+	ntime, err := parseHexUint32(ntimeHex)
+	if err != nil {
+		return nil, errInvalidNTime
+	}
+	jobTime, err := parseHexUint32(job.NTime)
+	if err != nil {
+		return nil, errInvalidJob
+	}
+	if ntime < jobTime || int64(ntime) > time.Now().Add(maxNTimeDrift).Unix() {
+		return nil, errNTimeOutOfRange
+	}
+
+	nonce, err := parseHexUint32(nonceHex)
+	if err != nil {
+		return nil, errInvalidNonce
+	}
+
+	jobVersion, err := parseHexUint32(job.Version)
+	if err != nil {
+		return nil, errInvalidJob
+	}
+	version, err := rolledVersion(jobVersion, s.minerVersionMask(miner), versionBits)
+	if err != nil {
+		return nil, err
+	}
+
+	coinbase, err := hex.DecodeString(job.Coinbase1 + miner.Extranonce1 + extranonce2 + job.Coinbase2)
+	if err != nil {
+		return nil, errInvalidJob
+	}
+
+	header, err := s.buildBlockHeader(job, doubleSHA256(coinbase), version, ntime)
+	if err != nil {
+		return nil, err
+	}
+
+	networkTarget, err := jobNetworkTarget(job)
+	if err != nil {
+		return nil, err
+	}
+
+	processed := s.shareProcessor.ProcessShare(&shares.Share{
+		MinerID:    miner.MinerDBID,
+		UserID:     miner.UserID,
+		JobID:      job.JobID,
+		Nonce:      nonceHex,
+		Difficulty: miner.Difficulty,
+		Timestamp:  time.Now(),
+		WorkerName: miner.WorkerName,
+		Header:     hex.EncodeToString(header),
+		ExtraNonce: extranonce2,
+		NTime:      ntimeHex,
+	})
+	if processed.Error != "" || processed.ProcessedShare == nil {
+		return nil, fmt.Errorf("%w: %s", errShareNotVerified, processed.Error)
+	}
+	hash, err := hex.DecodeString(processed.ProcessedShare.Hash)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errShareNotVerified, err)
+	}
+	shareValid := processed.Success
+	blockValid := shareValid && blockdag.HashMeetsTarget(hash, networkTarget)
+
+	fullHeader := make([]byte, blockdag.HeaderSize)
+	copy(fullHeader, header)
+	binary.LittleEndian.PutUint32(fullHeader[76:80], nonce)
+
+	blockHash := doubleSHA256(fullHeader)
+	blockdag.ReverseBytes(blockHash)
+
+	return &shareResult{
+		Hash:       hex.EncodeToString(hash),
+		BlockHash:  hex.EncodeToString(blockHash),
+		Header:     fullHeader,
+		Coinbase:   coinbase,
+		ShareValid: shareValid,
+		BlockValid: blockValid,
+	}, nil
+}
+
+// v2StandardMerkleRoot returns the header merkle root of a job on a miner's
+// standard channel
+func (s *StratumServer) v2StandardMerkleRoot(miner *Miner, job *MiningJob) ([32]byte, error) {
+	var root [32]byte
+	coinbase, err := hex.DecodeString(job.Coinbase1 + miner.Extranonce1 + v2StandardExtranonce2 + job.Coinbase2)
+	if err != nil {
+		return root, errInvalidJob
+	}
+	branch, err := s.merkleBuilder.HexToBranch(job.MerkleBranches)
+	if err != nil {
+		return root, errInvalidJob
+	}
+	copy(root[:], s.merkleBuilder.ComputeRoot(doubleSHA256(coinbase), branch))
+	return root, nil
+}
+
+// shareKey identifies a share submission within a job for duplicate
+// detection. extranonce1 keeps identical work from different miners apart;
+// version is the rolled version, empty when the miner doesn't roll.
+func shareKey(extranonce1, extranonce2, ntime, nonce, version string) string {
+	return strings.ToLower(extranonce1 + ":" + extranonce2 + ":" + ntime + ":" + nonce + ":" + version)
+}
+
+// buildBlockHeader assembles the first 76 bytes of the block header (everything but the nonce)
+func (s *StratumServer) buildBlockHeader(job *MiningJob, coinbaseHash []byte, version, ntime uint32) ([]byte, error) {
+	bits, err := parseHexUint32(job.NBits)
+	if err != nil {
+		return nil, errInvalidJob
+	}
+
+	// Undo the stratum word ordering to get the internal byte order
+	prevHash, err := hex.DecodeString(stratumPrevHash(job.PrevHash))
+	if err != nil || len(prevHash) != 32 {
+		return nil, errInvalidJob
+	}
+	blockdag.ReverseBytes(prevHash)
+
+	branch, err := s.merkleBuilder.HexToBranch(job.MerkleBranches)
+	if err != nil {
+		return nil, errInvalidJob
+	}
+	merkleRoot := s.merkleBuilder.ComputeRoot(coinbaseHash, branch)
+
+	header := make([]byte, blockdag.HeaderSize-4)
+	binary.LittleEndian.PutUint32(header[0:4], version)
+	copy(header[4:36], prevHash)
+	copy(header[36:68], merkleRoot)
+	binary.LittleEndian.PutUint32(header[68:72], ntime)
+	binary.LittleEndian.PutUint32(header[72:76], bits)
+	return header, nil
+}
+
+// jobNetworkTarget returns the job's 32-byte big-endian network target,
+// preferring the template's explicit target over the compact bits
+func jobNetworkTarget(job *MiningJob) ([]byte, error) {
+	if job.Target != "" {
+		target, err := hex.DecodeString(job.Target)
+		if err == nil && len(target) == blockdag.TargetSize {
+			return target, nil
+		}
+	}
+	bits, err := parseHexUint32(job.NBits)
+	if err != nil {
+		return nil, errInvalidJob
+	}
+	return blockdag.CompactToTarget(bits), nil
+}
+
+// serializeBlock builds the full block for submitblock:
+// header, transaction count, coinbase, template transactions and the MWEB extension
+func serializeBlock(job *MiningJob, header, coinbase []byte) (string, error) {
+	var buf []byte
+	buf = append(buf, header...)
+	buf = append(buf, encodeVarInt(uint64(len(job.Transactions)+1))...)
+	buf = append(buf, coinbase...)
+
+	for _, txHex := range job.Transactions {
+		tx, err := hex.DecodeString(txHex)
+		if err != nil {
+			return "", fmt.Errorf("decode template transaction: %w", err)
+		}
+		buf = append(buf, tx...)
+	}
+
+	if job.MWEB != "" {
+		mweb, err := hex.DecodeString(job.MWEB)
+		if err != nil {
+			return "", fmt.Errorf("decode mweb block: %w", err)
+		}
+		buf = append(buf, 0x01)
+		buf = append(buf, mweb...)
+	}
+
+	return hex.EncodeToString(buf), nil
+}
+
+// submitBlock sends a solved block to the node and records it as pending
+func (s *StratumServer) submitBlock(miner *Miner, job *MiningJob, result *shareResult) {
+	log.Printf("🎉 BLOCK FOUND by %s (%s) at height %d: %s", miner.Username, miner.ID, job.Height, result.BlockHash)
+
+	blockHex, err := serializeBlock(job, result.Header, result.Coinbase)
+	if err != nil {
+		log.Printf("❌ Failed to serialize block %s: %v", result.BlockHash, err)
+		return
+	}
+
+	resp, err := s.litecoinRPC("submitblock", []interface{}{blockHex})
+	if err != nil {
+		log.Printf("❌ submitblock failed for %s: %v", result.BlockHash, err)
+		return
+	}
+
+	// submitblock returns null on success and a reason string on rejection
+	var reason string
+	if len(resp) > 0 && string(resp) != "null" {
+		json.Unmarshal(resp, &reason)
+	}
+	if reason != "" {
+		log.Printf("❌ Block %s rejected by node: %s", result.BlockHash, reason)
+		return
+	}
+
+	log.Printf("✅ Block %s accepted by node at height %d", result.BlockHash, job.Height)
+
+	networkTarget, _ := jobNetworkTarget(job)
+	_, err = s.db.Exec(`
+		INSERT INTO blocks (height, hash, finder_id, reward, difficulty, status, network_id)
+		VALUES ($1, $2, $3, $4, $5, 'pending', $6)
+		ON CONFLICT DO NOTHING`,
+		job.Height, result.BlockHash, miner.UserID, job.CoinbaseValue,
+		blockdag.TargetToDifficulty(networkTarget), s.activeNetworkID,
+	)
+	if err != nil {
+		log.Printf("Failed to record block %s: %v", result.BlockHash, err)
+	}
+
+	if s.redis != nil {
+		s.redis.Incr(context.Background(), "pool:blocks:found")
+	}
+	go s.notifyBlockFound(job.Height, job.CoinbaseValue)
+
+	// Move miners onto the next block immediately
+	go s.updateBlockTemplate()
+}
+
+// parseHexUint32 parses an 8-character big-endian hex string
+func parseHexUint32(s string) (uint32, error) {
+	if len(s) != 8 {
+		return 0, fmt.Errorf("expected 8 hex characters, got %d", len(s))
+	}
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint32(b), nil
+}
+
+// encodeVarInt encodes a Bitcoin-style CompactSize integer
+func encodeVarInt(n uint64) []byte {
+	switch {
+	case n < 0xfd:
+		return []byte{byte(n)}
+	case n <= 0xffff:
+		b := make([]byte, 3)
+		b[0] = 0xfd
+		binary.LittleEndian.PutUint16(b[1:], uint16(n))
+		return b
+	case n <= 0xffffffff:
+		b := make([]byte, 5)
+		b[0] = 0xfe
+		binary.LittleEndian.PutUint32(b[1:], uint32(n))
+		return b
+	default:
+		b := make([]byte, 9)
+		b[0] = 0xff
+		binary.LittleEndian.PutUint64(b[1:], n)
+		return b
+	}
+}
+
+// doubleSHA256 computes SHA256(SHA256(data))
+func doubleSHA256(data []byte) []byte {
+	first := sha256.Sum256(data)
+	second := sha256.Sum256(first[:])
+	return second[:]
+}
@@ -0,0 +1,500 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/chimera-pool/chimera-pool-core/internal/shares"
+	"github.com/chimera-pool/chimera-pool-core/internal/stratum/hashrate"
+	"github.com/chimera-pool/chimera-pool-core/internal/stratum/merkle"
+	v2binary "github.com/chimera-pool/chimera-pool-core/internal/stratum/v2/binary"
+	"github.com/chimera-pool/chimera-pool-core/internal/stratum/vardiff"
+)
+
+// litecoinGenesisCoinbase is the Litecoin genesis coinbase transaction
+const litecoinGenesisCoinbase = "01000000010000000000000000000000000000000000000000000000000000000000000000ffffffff" +
+	"4804ffff001d0104404e592054696d65732030352f4f63742f32303131205374657665204a6f62732c204170706c65e28099732056" +
+	"6973696f6e6172792c2044696573206174203536ffffffff0100f2052a010000004341040184710fa689ad5023690c80f3a49c8f13" +
+	"f8d45b8c857fbcbc8bc4a8e4d3eb4b10f4d4604fa08dce601aaf0f470216fe1b51850b4acf21b179c45070ac7b03a9ac00000000"
+
+// recordedShare is a share captured by fakeShareRecorder
+type recordedShare struct {
+	share  *shares.Share
+	reason string
+}
+
+// fakeShareRecorder captures shares instead of batching them to the database
+type fakeShareRecorder struct {
+	recorded []recordedShare
+}
+
+func (r *fakeShareRecorder) RecordShare(share *shares.Share, rejectReason string) {
+	r.recorded = append(r.recorded, recordedShare{share: share, reason: rejectReason})
+}
+
+// newValidationTestServer creates a server with only the share validation dependencies
+func newValidationTestServer() *StratumServer {
+	return &StratumServer{
+		config:         &Config{Difficulty: 1.0},
+		miners:         make(map[string]*Miner),
+		jobs:           make(map[string]*MiningJob),
+		staleJobs:      make(map[string]time.Time),
+		shareTracker:   shares.NewDuplicateTracker(0),
+		merkleBuilder:  merkle.NewBuilder(),
+		shareProcessor: shares.NewShareProcessor(),
+		shareRecorder:  &fakeShareRecorder{},
+		vardiffManager: vardiff.NewManager(vardiff.DefaultConfig()),
+	}
+}
+
+// recordedShares returns the shares a validation test server has recorded
+func recordedShares(s *StratumServer) []recordedShare {
+	return s.shareRecorder.(*fakeShareRecorder).recorded
+}
+
+// newValidationTestMiner creates an authorized miner with database IDs
+func newValidationTestMiner(extranonce1 string, difficulty float64) *Miner {
+	return &Miner{
+		ID:          "test-miner-1",
+		UserID:      34,
+		MinerDBID:   5,
+		Username:    "picaxe",
+		Conn:        &MockConn{},
+		Authorized:  true,
+		Difficulty:  difficulty,
+		Extranonce1: extranonce1,
+	}
+}
+
+// genesisJob splits the Litecoin genesis coinbase around an 8-byte extranonce
+// so that the genesis block can be "mined" through the stratum share path
+func genesisJob() (job *MiningJob, extranonce1, extranonce2 string) {
+	const cut = 100
+	job = &MiningJob{
+		JobID:     "1",
+		PrevHash:  strings.Repeat("0", 64),
+		Coinbase1: litecoinGenesisCoinbase[:cut],
+		Coinbase2: litecoinGenesisCoinbase[cut+16:],
+		Version:   "00000001",
+		NBits:     "1e0ffff0",
+		NTime:     "4e8eaab9",
+		Height:    0,
+	}
+	return job, litecoinGenesisCoinbase[cut : cut+8], litecoinGenesisCoinbase[cut+8 : cut+16]
+}
+
+func TestStratumPrevHash(t *testing.T) {
+	display := "0102030405060708" + strings.Repeat("0", 40) + "0000abcd"
+
+	encoded := stratumPrevHash(display)
+	assert.Equal(t, "0000abcd", encoded[:8], "last word comes first")
+	assert.Equal(t, "01020304", encoded[56:], "first word comes last")
+
+	// Word reversal is its own inverse
+	assert.Equal(t, display, stratumPrevHash(encoded))
+}
+
+func TestValidateShare_LitecoinGenesisBlock(t *testing.T) {
+	s := newValidationTestServer()
+	job, en1, en2 := genesisJob()
+
+	result, err := s.validateShare(newValidationTestMiner(en1, 1), job, en2, "4e8eaab9", "7c3f51cd", 0)
+	require.NoError(t, err)
+
+	assert.True(t, result.ShareValid)
+	assert.True(t, result.BlockValid)
+	assert.Equal(t, "12a765e31ffd4059bada1e25190f6e98c99d9714d334efa41a195a7e7e04bfe2", result.BlockHash)
+	assert.Equal(t, "0000050c34a64b415b6b15b37f2216634b5b1669cb9a2e38d76f7213b0671e00", result.Hash)
+	assert.Equal(t, litecoinGenesisCoinbase, hex.EncodeToString(result.Coinbase))
+}
+
+func TestValidateShare_WrongNonceNotABlock(t *testing.T) {
+	s := newValidationTestServer()
+	job, en1, en2 := genesisJob()
+
+	result, err := s.validateShare(newValidationTestMiner(en1, 0.0001), job, en2, "4e8eaab9", "7c3f51ce", 0)
+	require.NoError(t, err)
+	assert.True(t, result.ShareValid, "tiny difficulty accepts almost any hash")
+	assert.False(t, result.BlockValid)
+}
+
+func TestValidateShare_LowDifficulty(t *testing.T) {
+	s := newValidationTestServer()
+	job, en1, en2 := genesisJob()
+
+	// The genesis hash only has ~21 leading zero bits
+	result, err := s.validateShare(newValidationTestMiner(en1, 1e9), job, en2, "4e8eaab9", "7c3f51cd", 0)
+	require.NoError(t, err)
+	assert.False(t, result.ShareValid)
+	assert.False(t, result.BlockValid)
+}
+
+func TestValidateShare_MalformedParams(t *testing.T) {
+	s := newValidationTestServer()
+	job, en1, en2 := genesisJob()
+
+	tests := []struct {
+		name        string
+		extranonce2 string
+		ntime       string
+		nonce       string
+		expected    error
+	}{
+		{"short extranonce2", "00", "4e8eaab9", "7c3f51cd", errInvalidExtranonce2},
+		{"non-hex extranonce2", "zzzzzzzz", "4e8eaab9", "7c3f51cd", errInvalidExtranonce2},
+		{"bad ntime", en2, "xyz", "7c3f51cd", errInvalidNTime},
+		{"ntime before job", en2, "4e8eaab8", "7c3f51cd", errNTimeOutOfRange},
+		{"ntime far future", en2, "ffffffff", "7c3f51cd", errNTimeOutOfRange},
+		{"bad nonce", en2, "4e8eaab9", "12", errInvalidNonce},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.validateShare(newValidationTestMiner(en1, 1), job, tt.extranonce2, tt.ntime, tt.nonce, 0)
+			assert.ErrorIs(t, err, tt.expected)
+		})
+	}
+}
+
+// busyShareProcessor fails every share the way a full batch queue does
+type busyShareProcessor struct{}
+
+func (busyShareProcessor) ProcessShare(share *shares.Share) shares.ShareProcessingResult {
+	return shares.ShareProcessingResult{Error: "queue full - share dropped"}
+}
+
+func TestHandleSubmit_ShareNotVerified(t *testing.T) {
+	s := newValidationTestServer()
+	s.shareProcessor = busyShareProcessor{}
+	job, en1, en2 := genesisJob()
+	s.storeJobLocked(job)
+
+	miner := newValidationTestMiner(en1, 1)
+	_, err := s.validateShare(miner, job, en2, "4e8eaab9", "7c3f51cd", 0)
+	assert.ErrorIs(t, err, errShareNotVerified)
+
+	req := StratumRequest{
+		ID:     7,
+		Method: "mining.submit",
+		Params: []interface{}{"picaxe", job.JobID, en2, "4e8eaab9", "7c3f51cd"},
+	}
+	require.NoError(t, s.handleSubmit(miner, req))
+
+	assert.Contains(t, string(miner.Conn.(*MockConn).written), `"error":[20,"Share not verified, pool busy",null]`)
+	assert.Equal(t, int64(0), miner.SharesInvalid, "an unverified share is not the miner's fault")
+	assert.Empty(t, recordedShares(s))
+
+	// Once the pool has capacity again the retry gets a verdict, not a
+	// duplicate rejection
+	s.shareProcessor = shares.NewShareProcessor()
+	miner.Difficulty = 1e9
+	require.NoError(t, s.handleSubmit(miner, req))
+	require.NoError(t, s.handleSubmit(miner, req))
+
+	recorded := recordedShares(s)
+	require.Len(t, recorded, 2)
+	assert.Equal(t, rejectReasonLowDifficulty, recorded[0].reason)
+	assert.Equal(t, rejectReasonDuplicate, recorded[1].reason, "a share with a verdict stays recorded")
+}
+
+func TestHandleV2SubmitSharesExtended_ResubmitAfterNotVerified(t *testing.T) {
+	s := newExtendedTestServer()
+	s.shareProcessor = busyShareProcessor{}
+	job, en1, en2 := genesisJob()
+	s.storeJobLocked(job)
+
+	miner := newValidationTestMiner(en1, 1e9)
+	miner.IsV2, miner.ExtendedChannel, miner.ChannelID = true, true, 1
+	extranonce, _ := hex.DecodeString(en2)
+	payload := v2binary.NewSerializer().SerializeSubmitSharesExtended(&v2binary.SubmitSharesExtended{
+		ChannelID: 1, SequenceNum: 1, JobID: 1, Nonce: 0x7c3f51cd, NTime: 0x4e8eaab9, Version: 1, Extranonce: extranonce,
+	})
+	require.NoError(t, s.handleV2SubmitSharesExtended(miner, payload))
+	assert.Empty(t, recordedShares(s))
+
+	s.shareProcessor = shares.NewShareProcessor()
+	require.NoError(t, s.handleV2SubmitSharesExtended(miner, payload))
+	recorded := recordedShares(s)
+	require.Len(t, recorded, 1)
+	assert.Equal(t, rejectReasonLowDifficulty, recorded[0].reason, "the retry is hashed, not rejected as a duplicate")
+}
+
+// genesisStandardJob splits the genesis coinbase inside its null prevout, so
+// a standard channel with prefix 00000000 mines the genesis block
+func genesisStandardJob() *MiningJob {
+	job, _, _ := genesisJob()
+	job.Coinbase1 = litecoinGenesisCoinbase[:20]
+	job.Coinbase2 = litecoinGenesisCoinbase[36:]
+	return job
+}
+
+func TestHandleV2SubmitShares_ValidatesProofOfWork(t *testing.T) {
+	s := newValidationTestServer()
+	s.hashrateWindows = make(map[string]*hashrate.Window)
+	s.hashrateCalc = hashrate.NewCalculator()
+	// Stats updates fail fast against a closed port
+	s.redis = redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1})
+	defer s.redis.Close()
+
+	job := genesisStandardJob()
+	miner := newValidationTestMiner("00000000", 1)
+	miner.IsV2 = true
+	miner.ChannelID = 1
+
+	// The job carries the merkle root of the channel's coinbase
+	require.NoError(t, s.sendV2MiningJob(miner, miner.ChannelID, job))
+	frames := readV2Frames(t, miner.Conn.(*MockConn).written)
+	require.Equal(t, v2binary.MsgTypeNewMiningJob, frames[0].msgType)
+	sent, err := v2binary.NewDeserializer(frames[0].payload).DeserializeNewMiningJob()
+	require.NoError(t, err)
+	result, err := s.validateShare(miner, job, v2StandardExtranonce2, "4e8eaab9", "7c3f51cd", 0)
+	require.NoError(t, err)
+	assert.Equal(t, result.Header[36:68], sent.MerkleRoot[:])
+	assert.True(t, result.BlockValid, "a block found on a standard channel is submitted")
+
+	// Keep the submits below the network target so no block is submitted
+	job.Target = strings.Repeat("0", 63) + "1"
+	s.storeJobLocked(job)
+	miner.Conn = &MockConn{}
+
+	submit := &v2binary.SubmitSharesStandard{ChannelID: 1, SequenceNum: 1, JobID: 1, Nonce: 0x7c3f51ce, NTime: 0x4e8eaab9, Version: 1}
+	require.NoError(t, s.handleV2SubmitShares(miner, v2binary.NewSerializer().SerializeSubmitSharesStandard(submit)))
+	submit.SequenceNum, submit.Nonce = 2, 0x7c3f51cd
+	require.NoError(t, s.handleV2SubmitShares(miner, v2binary.NewSerializer().SerializeSubmitSharesStandard(submit)))
+
+	frames = readV2Frames(t, miner.Conn.(*MockConn).written)
+	require.GreaterOrEqual(t, len(frames), 2, "vardiff may follow with SetTarget")
+	require.Equal(t, v2binary.MsgTypeSubmitSharesError, frames[0].msgType)
+	rejected, err := v2binary.NewDeserializer(frames[0].payload).DeserializeSubmitSharesError()
+	require.NoError(t, err)
+	assert.Equal(t, v2binary.STR0_255("difficulty-too-low"), rejected.ErrorCode, "no work, no credit")
+	assert.Equal(t, v2binary.MsgTypeSubmitSharesSuccess, frames[1].msgType)
+
+	assert.Equal(t, int64(1), miner.SharesInvalid)
+	assert.Equal(t, int64(1), miner.SharesValid)
+	recorded := recordedShares(s)
+	require.Len(t, recorded, 2)
+	assert.Equal(t, rejectReasonLowDifficulty, recorded[0].reason)
+	assert.Empty(t, recorded[1].reason)
+	assert.Equal(t, "0000050c34a64b415b6b15b37f2216634b5b1669cb9a2e38d76f7213b0671e00", recorded[1].share.Hash)
+}
+
+func TestSerializeBlock(t *testing.T) {
+	header := make([]byte, 80)
+	coinbase := []byte{0xc0, 0xb0}
+	job := &MiningJob{
+		Transactions: []string{"aa", "bbbb"},
+		MWEB:         "ee",
+	}
+
+	blockHex, err := serializeBlock(job, header, coinbase)
+	require.NoError(t, err)
+	assert.Equal(t, strings.Repeat("00", 80)+"03"+"c0b0"+"aa"+"bbbb"+"01"+"ee", blockHex)
+
+	_, err = serializeBlock(&MiningJob{Transactions: []string{"zz"}}, header, coinbase)
+	assert.Error(t, err)
+}
+
+func TestEncodeVarInt(t *testing.T) {
+	assert.Equal(t, []byte{0xfc}, encodeVarInt(0xfc))
+	assert.Equal(t, []byte{0xfd, 0xfd, 0x00}, encodeVarInt(0xfd))
+	assert.Equal(t, []byte{0xfe, 0x00, 0x00, 0x01, 0x00}, encodeVarInt(0x10000))
+}
+
+func TestRotateJobs_RetiresSupersededJobs(t *testing.T) {
+	s := newValidationTestServer()
+
+	s.storeJobLocked(&MiningJob{JobID: "a", Height: 100})
+	s.storeJobLocked(&MiningJob{JobID: "b", Height: 100})
+	s.shareTracker.CheckAndRecord("a", "m1", "k")
+	s.shareTracker.CheckAndRecord("b", "m1", "k")
+
+	current := &MiningJob{JobID: "c", Height: 101}
+	s.storeJobLocked(current)
+	s.rotateJobs(current)
+
+	job, stale := s.lookupJob("a")
+	assert.Nil(t, job)
+	assert.True(t, stale, "superseded job is reported as stale")
+
+	job, stale = s.lookupJob("c")
+	assert.NotNil(t, job)
+	assert.False(t, stale)
+
+	job, stale = s.lookupJob("never-issued")
+	assert.Nil(t, job)
+	assert.False(t, stale, "unknown job is not stale")
+
+	assert.Equal(t, 0, s.shareTracker.JobCount(), "dedup sets of retired jobs are pruned")
+}
+
+func TestRotateJobs_ForgetsOldStaleJobs(t *testing.T) {
+	s := newValidationTestServer()
+	s.staleJobs["old"] = time.Now().Add(-staleJobRetention - time.Minute)
+
+	current := &MiningJob{JobID: "c"}
+	s.storeJobLocked(current)
+	s.rotateJobs(current)
+
+	_, stale := s.lookupJob("old")
+	assert.False(t, stale)
+}
+
+func TestStoreJob_BoundedHistory(t *testing.T) {
+	s := newValidationTestServer()
+
+	for i := 1; i <= maxJobHistory+4; i++ {
+		s.storeJobLocked(&MiningJob{JobID: fmt.Sprintf("%x", i)})
+	}
+	assert.Len(t, s.jobs, maxJobHistory)
+
+	job, stale := s.lookupJob("1")
+	assert.Nil(t, job, "oldest job is dropped first")
+	assert.True(t, stale)
+
+	job, _ = s.lookupJob("14")
+	assert.NotNil(t, job)
+}
+
+func TestHandleSubmit_UnknownJobRejected(t *testing.T) {
+	s := newValidationTestServer()
+	miner := newValidationTestMiner("00000001", 1.0)
+
+	req := StratumRequest{
+		ID:     3,
+		Method: "mining.submit",
+		Params: []interface{}{"picaxe", "missing", "00000000", "4e8eaab9", "7c3f51cd"},
+	}
+	require.NoError(t, s.handleSubmit(miner, req))
+
+	assert.Contains(t, string(miner.Conn.(*MockConn).written), `"error":[21,"Job not found",null]`)
+	assert.Equal(t, int64(1), miner.SharesInvalid)
+	assert.Equal(t, int64(0), miner.SharesValid)
+
+	recorded := recordedShares(s)
+	require.Len(t, recorded, 1)
+	assert.Equal(t, rejectReasonJobNotFound, recorded[0].reason)
+	assert.Equal(t, int64(5), recorded[0].share.MinerID)
+	assert.Equal(t, int64(34), recorded[0].share.UserID)
+	assert.Equal(t, 1.0, recorded[0].share.Difficulty)
+	assert.Equal(t, "7c3f51cd", recorded[0].share.Nonce)
+}
+
+func TestHandleSubmit_LowDifficultyRejected(t *testing.T) {
+	s := newValidationTestServer()
+	job, en1, en2 := genesisJob()
+	s.storeJobLocked(job)
+	miner := newValidationTestMiner(en1, 1e9)
+
+	req := StratumRequest{
+		ID:     4,
+		Method: "mining.submit",
+		Params: []interface{}{"picaxe", job.JobID, en2, "4e8eaab9", "7c3f51cd"},
+	}
+	require.NoError(t, s.handleSubmit(miner, req))
+
+	assert.Contains(t, string(miner.Conn.(*MockConn).written), `"error":[23,"Low difficulty share",null]`)
+	assert.Equal(t, int64(1), miner.SharesInvalid)
+
+	recorded := recordedShares(s)
+	require.Len(t, recorded, 1)
+	assert.Equal(t, rejectReasonLowDifficulty, recorded[0].reason)
+	assert.Equal(t, 1e9, recorded[0].share.Difficulty)
+	assert.Equal(t, "0000050c34a64b415b6b15b37f2216634b5b1669cb9a2e38d76f7213b0671e00", recorded[0].share.Hash)
+}
+
+func TestHandleSubmit_StaleShareRejected(t *testing.T) {
+	s := newValidationTestServer()
+	job, en1, en2 := genesisJob()
+	s.storeJobLocked(job)
+	s.rotateJobs(&MiningJob{JobID: "2"})
+	miner := newValidationTestMiner(en1, 1.0)
+
+	req := StratumRequest{
+		ID:     5,
+		Method: "mining.submit",
+		Params: []interface{}{"picaxe", job.JobID, en2, "4e8eaab9", "7c3f51cd"},
+	}
+	require.NoError(t, s.handleSubmit(miner, req))
+
+	assert.Contains(t, string(miner.Conn.(*MockConn).written), `"error":[21,"Stale share",null]`)
+	assert.Equal(t, int64(1), miner.SharesInvalid)
+
+	recorded := recordedShares(s)
+	require.Len(t, recorded, 1)
+	assert.Equal(t, rejectReasonStale, recorded[0].reason)
+}
+
+func TestHandleSubmit_DuplicateShareRejected(t *testing.T) {
+	s := newValidationTestServer()
+	job, en1, en2 := genesisJob()
+	s.storeJobLocked(job)
+	miner := newValidationTestMiner(en1, 1e9)
+
+	req := StratumRequest{
+		ID:     6,
+		Method: "mining.submit",
+		Params: []interface{}{"picaxe", job.JobID, en2, "4e8eaab9", "7c3f51cd"},
+	}
+	require.NoError(t, s.handleSubmit(miner, req))
+	req.Params[4] = "7C3F51CD"
+	require.NoError(t, s.handleSubmit(miner, req))
+
+	assert.Contains(t, string(miner.Conn.(*MockConn).written), `"error":[22,"Duplicate share",null]`)
+	assert.Equal(t, int64(2), miner.SharesInvalid)
+
+	// First submit is hashed (and rejected for difficulty), the replay is caught as a duplicate
+	recorded := recordedShares(s)
+	require.Len(t, recorded, 2)
+	assert.Equal(t, rejectReasonLowDifficulty, recorded[0].reason)
+	assert.Equal(t, rejectReasonDuplicate, recorded[1].reason)
+}
+
+func TestHandleSubmit_ShareLimitRejected(t *testing.T) {
+	s := newValidationTestServer()
+	s.shareTracker = shares.NewDuplicateTracker(1)
+	job, en1, en2 := genesisJob()
+	s.storeJobLocked(job)
+	miner := newValidationTestMiner(en1, 1e9)
+
+	req := StratumRequest{
+		ID:     7,
+		Method: "mining.submit",
+		Params: []interface{}{"picaxe", job.JobID, en2, "4e8eaab9", "7c3f51cd"},
+	}
+	require.NoError(t, s.handleSubmit(miner, req))
+	req.Params[4] = "7c3f51ce"
+	require.NoError(t, s.handleSubmit(miner, req))
+	req.Params[4] = "7c3f51cd"
+	require.NoError(t, s.handleSubmit(miner, req))
+
+	assert.Contains(t, string(miner.Conn.(*MockConn).written), `"error":[20,"Share limit for job reached, wait for new work",null]`)
+
+	// The remembered share is still caught when replayed
+	recorded := recordedShares(s)
+	require.Len(t, recorded, 3)
+	assert.Equal(t, rejectReasonLowDifficulty, recorded[0].reason)
+	assert.Equal(t, rejectReasonShareLimit, recorded[1].reason)
+	assert.Equal(t, rejectReasonDuplicate, recorded[2].reason)
+}
+
+func TestRecordShare_SkipsMinersWithoutDatabaseIDs(t *testing.T) {
+	s := newValidationTestServer()
+	miner := newValidationTestMiner("00000001", 1.0)
+	miner.MinerDBID = 0
+
+	s.recordRejectedShare(miner, "7c3f51cd", "", rejectReasonStale)
+	assert.Empty(t, recordedShares(s), "a missing miner_id would fail the whole batch")
+}
+
+func TestShareKey_SeparatesMiners(t *testing.T) {
+	assert.Equal(t, shareKey("0000000A", "00", "4e8eaab9", "7C3F51CD", ""), shareKey("0000000a", "00", "4e8eaab9", "7c3f51cd", ""))
+	assert.NotEqual(t, shareKey("00000001", "00", "4e8eaab9", "7c3f51cd", ""), shareKey("00000002", "00", "4e8eaab9", "7c3f51cd", ""))
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/chimera-pool/chimera-pool-core/internal/stratum/detector"
+)
+
+// =============================================================================
+// STRATUM OVER TLS
+// Ports whose profile allows tls terminate stratum+ssl before protocol
+// detection. The certificate pair in STRATUM_TLS_CERT_FILE/STRATUM_TLS_KEY_FILE
+// is reloaded when either file changes or on SIGHUP; sessions already
+// established keep their certificate and are not dropped.
+// =============================================================================
+
+// initTLS loads the stratum certificate pair
+func initTLS(config *Config) (*detector.CertReloader, error) {
+	if config.TLSCertFile == "" || config.TLSKeyFile == "" {
+		return nil, fmt.Errorf("STRATUM_TLS_CERT_FILE and STRATUM_TLS_KEY_FILE are required")
+	}
+	reloader, err := detector.NewCertReloader(config.TLSCertFile, config.TLSKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("🔒 Stratum TLS certificate loaded from %s", config.TLSCertFile)
+	return reloader, nil
+}
+
+// watchTLSCertificates reloads the certificate pair on file changes and
+// SIGHUP until ctx is done
+func watchTLSCertificates(ctx context.Context, reloader *detector.CertReloader) {
+	logReload := func(err error) {
+		if err != nil {
+			log.Printf("⚠️ Stratum TLS certificate reload failed, keeping the current one: %v", err)
+			return
+		}
+		log.Println("🔒 Stratum TLS certificate reloaded")
+	}
+
+	go reloader.Watch(ctx, detector.DefaultCertPollInterval, logReload)
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		defer signal.Stop(hup)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hup:
+				logReload(reloader.Reload())
+			}
+		}
+	}()
+}
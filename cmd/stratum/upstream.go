@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/chimera-pool/chimera-pool-core/internal/monitoring/health"
+	"github.com/chimera-pool/chimera-pool-core/internal/stratum/upstream"
+)
+
+// =============================================================================
+// UPSTREAM NODE FAILOVER
+// RPC calls go to the best of several Litecoin nodes, ranked by latency,
+// error rate and tip height. Templates only come from nodes at the best
+// known tip.
+// =============================================================================
+
+// newUpstreamSelector builds the ranked node list from LITECOIN_RPC_URLS,
+// falling back to the single LITECOIN_RPC_URL
+func newUpstreamSelector(config *Config) *upstream.Selector {
+	urls := config.LitecoinRPCURLs
+	if len(urls) == 0 {
+		urls = []string{config.LitecoinRPCURL}
+	}
+
+	nodes := make([]upstream.Node, 0, len(urls))
+	for _, rawURL := range urls {
+		nodes = append(nodes, upstream.ParseNode(rawURL, config.LitecoinRPCUser, config.LitecoinRPCPass))
+	}
+
+	names := make([]string, len(nodes))
+	for i, node := range nodes {
+		names[i] = node.Name
+	}
+	log.Printf("🔗 Litecoin upstream nodes: %s", strings.Join(names, ", "))
+
+	return upstream.NewSelector(upstream.DefaultConfig(), nodes)
+}
+
+// upstreamProber refreshes every node's tip height and latency until shutdown
+func (s *StratumServer) upstreamProber() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-s.done
+		cancel()
+	}()
+
+	s.upstreams.Run(ctx, s.probeUpstream)
+}
+
+// probeUpstream returns a node's chain height via getblockcount
+func (s *StratumServer) probeUpstream(ctx context.Context, node upstream.Node) (int64, error) {
+	result, err := s.doLitecoinRPCContext(ctx, node, "getblockcount", []interface{}{}, upstream.DefaultConfig().ProbeTimeout)
+	if err != nil {
+		return 0, err
+	}
+	var height int64
+	if err := json.Unmarshal(result, &height); err != nil {
+		return 0, err
+	}
+	return height, nil
+}
+
+// checkTemplateTip records the tip a template builds on and rejects it if
+// the node that produced it is behind the best known tip
+func (s *StratumServer) checkTemplateTip(node upstream.Node, tmpl *BlockTemplate) error {
+	s.upstreams.RecordTip(node.Name, tmpl.Height-1)
+	if !s.upstreams.AtTip(node.Name) {
+		return fmt.Errorf("template from %s builds on height %d, behind best known tip %d",
+			node.Name, tmpl.Height-1, s.upstreams.BestTip())
+	}
+	return nil
+}
+
+// upstreamMetrics reports every node's status for GetPoolMetrics
+func (s *StratumServer) upstreamMetrics() []health.UpstreamMetrics {
+	if s.upstreams == nil {
+		return nil
+	}
+
+	statuses := s.upstreams.Status()
+	metrics := make([]health.UpstreamMetrics, 0, len(statuses))
+	for _, status := range statuses {
+		metrics = append(metrics, health.UpstreamMetrics{
+			Name:         status.Name,
+			Priority:     status.Priority,
+			Active:       status.Active,
+			Healthy:      status.Healthy,
+			AtTip:        status.AtTip,
+			Score:        status.Score,
+			TipHeight:    status.TipHeight,
+			BlocksBehind: status.BlocksBehind,
+			Latency:      status.Latency,
+			ErrorRate:    status.ErrorRate,
+			Requests:     status.Requests,
+			Errors:       status.Errors,
+		})
+	}
+	return metrics
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/chimera-pool/chimera-pool-core/internal/stratum/upstream"
+)
+
+// testUpstreams returns a selector over the given RPC URLs, in priority order
+func testUpstreams(urls ...string) *upstream.Selector {
+	nodes := make([]upstream.Node, len(urls))
+	for i, u := range urls {
+		nodes[i] = upstream.ParseNode(u, "user", "pass")
+	}
+	return upstream.NewSelector(upstream.DefaultConfig(), nodes)
+}
+
+// fakeNode answers getblockcount and getblocktemplate for a chain of the given height
+type fakeNode struct {
+	server *httptest.Server
+	height atomic.Int64
+	down   atomic.Bool
+	calls  atomic.Int64
+}
+
+func newFakeNode(t *testing.T, height int64) *fakeNode {
+	n := &fakeNode{}
+	n.height.Store(height)
+	n.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n.calls.Add(1)
+		if n.down.Load() {
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<html>503 Service Unavailable</html>"))
+			return
+		}
+		var req struct {
+			Method string `json:"method"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+
+		var result interface{}
+		switch req.Method {
+		case "getblockcount":
+			result = n.height.Load()
+		case "getblocktemplate":
+			result = testTemplate(int(n.height.Load()+1), strings.Repeat("ab", 32), "")
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"result": result, "error": nil})
+	}))
+	t.Cleanup(n.server.Close)
+	return n
+}
+
+func TestUpstream_TemplatesOnlyFromNodesAtTip(t *testing.T) {
+	primary := newFakeNode(t, 100)
+	backup := newFakeNode(t, 101)
+
+	s := newValidationTestServer()
+	s.upstreams = testUpstreams(primary.server.URL, backup.server.URL)
+	s.upstreams.Probe(context.Background(), s.probeUpstream)
+
+	// The primary lags one block, so the backup serves the template
+	tmpl, err := s.getBlockTemplate()
+	require.NoError(t, err)
+	assert.Equal(t, int64(102), tmpl.Height)
+	assert.Equal(t, int64(1), primary.calls.Load(), "only the probe reached the lagging primary")
+
+	// Once the primary catches up it is preferred again
+	primary.height.Store(101)
+	s.upstreams.Probe(context.Background(), s.probeUpstream)
+	before := primary.calls.Load()
+	_, err = s.getBlockTemplate()
+	require.NoError(t, err)
+	assert.Equal(t, before+1, primary.calls.Load())
+
+	metrics := s.GetPoolMetrics()
+	require.Len(t, metrics.Upstreams, 2)
+	assert.Equal(t, primary.server.Listener.Addr().String(), metrics.Upstreams[0].Name)
+	assert.True(t, metrics.Upstreams[0].Active)
+	assert.Equal(t, int64(101), metrics.Upstreams[1].TipHeight)
+}
+
+func TestUpstream_FailsOverWhenPrimaryErrors(t *testing.T) {
+	primary := newFakeNode(t, 100)
+	backup := newFakeNode(t, 100)
+
+	s := newValidationTestServer()
+	s.upstreams = testUpstreams(primary.server.URL, backup.server.URL)
+	primary.down.Store(true)
+
+	// Errors from the primary mark it down; later calls go to the backup
+	for i := 0; i < upstream.DefaultConfig().MaxConsecutiveErrors; i++ {
+		_, err := s.doLitecoinRPC(s.upstreams.Nodes()[0], "getblockcount", nil)
+		require.Error(t, err)
+	}
+
+	result, err := s.litecoinRPC("getblockcount", []interface{}{})
+	require.NoError(t, err)
+	assert.Equal(t, "100", strings.TrimSpace(string(result)))
+
+	status := s.upstreams.Status()
+	assert.Equal(t, backup.server.Listener.Addr().String(), status[0].Name)
+	assert.True(t, status[0].Active)
+	assert.False(t, status[1].Healthy)
+}
+
+func TestUpstream_RejectsLaggingTemplate(t *testing.T) {
+	node := newFakeNode(t, 100)
+
+	s := newValidationTestServer()
+	s.upstreams = testUpstreams(node.server.URL)
+	s.upstreams.RecordTip(s.upstreams.Nodes()[0].Name, 100)
+
+	tmpl := &BlockTemplate{Height: 100} // Builds on 99
+	err := s.checkTemplateTip(s.upstreams.Nodes()[0], tmpl)
+	assert.ErrorContains(t, err, "behind best known tip 100")
+}
+
+func TestIsNodeFault(t *testing.T) {
+	assert.False(t, isNodeFault(nil))
+	assert.True(t, isNodeFault(context.DeadlineExceeded))
+	assert.False(t, isNodeFault(&rpcError{Code: -25, Message: "bad-txns"}))
+	assert.True(t, isNodeFault(&rpcError{Code: -10, Message: "Litecoin is downloading blocks..."}))
+	assert.Equal(t, "RPC error -1: MWEB", (&rpcError{Code: -1, Message: "MWEB"}).Error())
+}
@@ -0,0 +1,349 @@
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+
+	"github.com/chimera-pool/chimera-pool-core/internal/shares"
+	"github.com/chimera-pool/chimera-pool-core/internal/stratum/blockdag"
+	v2binary "github.com/chimera-pool/chimera-pool-core/internal/stratum/v2/binary"
+	"github.com/chimera-pool/chimera-pool-core/internal/stratum/vardiff"
+)
+
+// =============================================================================
+// STRATUM V2 EXTENDED MINING CHANNELS
+// Proxies (e.g. SRI translators) open extended channels, complete the coinbase
+// themselves and roll the extranonce that follows the pool-assigned prefix.
+// =============================================================================
+
+// Extended channel open error codes
+const (
+	v2ErrUnknownUser           = "unknown-user"
+	v2ErrExtranonceSizeTooBig  = "min-extranonce-size-too-large"
+	v2ErrChannelAlreadyOpen    = "channel-already-open"
+	v2ErrInternalChannelFailed = "internal-error"
+)
+
+// errV2NoJob is returned when a job is requested before the first template arrives
+var errV2NoJob = errors.New("no job available")
+
+// v2Target converts a share difficulty to a V2 U256 target (little-endian)
+func v2Target(difficulty float64) [32]byte {
+	var target [32]byte
+	be := blockdag.ScryptDifficultyToTarget(difficulty)
+	for i := range target {
+		target[i] = be[len(be)-1-i]
+	}
+	return target
+}
+
+// v2TargetDifficulty converts a V2 U256 target back to a share difficulty
+func v2TargetDifficulty(target [32]byte) float64 {
+	be := make([]byte, len(target))
+	for i := range target {
+		be[i] = target[len(target)-1-i]
+	}
+	t := new(big.Int).SetBytes(be)
+	if t.Sign() == 0 {
+		return 0
+	}
+	diff, _ := new(big.Float).Quo(new(big.Float).SetInt(blockdag.ScryptDiff1Target), new(big.Float).SetInt(t)).Float64()
+	return diff
+}
+
+// handleV2OpenExtendedChannel handles OpenExtendedMiningChannel
+func (s *StratumServer) handleV2OpenExtendedChannel(miner *Miner, payload []byte) error {
+	deser := v2binary.NewDeserializer(payload)
+	openChan, err := deser.DeserializeOpenExtendedMiningChannel()
+	if err != nil {
+		return fmt.Errorf("deserialize OpenExtendedMiningChannel: %w", err)
+	}
+
+	log.Printf("V2 OpenExtendedMiningChannel from %s: RequestID=%d, User=%s, Hashrate=%.2f, MinExtranonce=%d",
+		miner.ID, openChan.RequestID, openChan.UserIdentity, openChan.NominalHashrate, openChan.MinExtranonceSize)
+
+	// One channel per connection: the miner record is the unit of accounting
+	if miner.IsV2 {
+		return s.sendV2OpenExtendedChannelError(miner, openChan.RequestID, v2ErrChannelAlreadyOpen)
+	}
+	if int(openChan.MinExtranonceSize) > extranonce2Size {
+		return s.sendV2OpenExtendedChannelError(miner, openChan.RequestID, v2ErrExtranonceSizeTooBig)
+	}
+
+	if err := s.authorizeUser(miner, strings.TrimSpace(string(openChan.UserIdentity))); err != nil {
+		if errors.Is(err, errUserNotFound) {
+			return s.sendV2OpenExtendedChannelError(miner, openChan.RequestID, v2ErrUnknownUser)
+		}
+		return s.sendV2OpenExtendedChannelError(miner, openChan.RequestID, v2ErrInternalChannelFailed)
+	}
+
+	miner.IsV2 = true
+	miner.ExtendedChannel = true
+	miner.ChannelID = 1
+	miner.Extranonce1 = s.getNextExtranonce1()
+
+	// Seed from the channel's nominal hashrate, but never hand out a target
+	// easier than the client asked for
+	miner.Difficulty = s.vardiffManager.Seed(miner.ID, vardiff.Hints{Hashrate: float64(openChan.NominalHashrate)})
+	if maxDiff := v2TargetDifficulty(openChan.MaxTarget); maxDiff > miner.Difficulty {
+		miner.Difficulty = maxDiff
+		s.vardiffManager.SetDifficulty(miner.ID, miner.Difficulty)
+	}
+
+	prefix, err := hex.DecodeString(miner.Extranonce1)
+	if err != nil {
+		return fmt.Errorf("decode extranonce prefix: %w", err)
+	}
+
+	ser := v2binary.NewSerializer()
+	successMsg := &v2binary.OpenExtendedMiningChannelSuccess{
+		RequestID:        openChan.RequestID,
+		ChannelID:        miner.ChannelID,
+		Target:           v2Target(miner.Difficulty),
+		ExtranonceSize:   extranonce2Size,
+		ExtranoncePrefix: prefix,
+	}
+	payloadBytes := ser.SerializeOpenExtendedMiningChannelSuccess(successMsg)
+	frame := ser.SerializeFrame(v2binary.MsgTypeOpenExtendedMiningChannelSuccess, 0, payloadBytes)
+
+	if _, err := miner.Conn.Write(frame); err != nil {
+		return fmt.Errorf("send OpenExtendedMiningChannelSuccess: %w", err)
+	}
+
+	log.Printf("V2 OpenExtendedMiningChannelSuccess sent to %s (ChannelID=%d, Prefix=%s)",
+		miner.ID, miner.ChannelID, miner.Extranonce1)
+
+	s.jobMutex.RLock()
+	job := s.currentJob
+	s.jobMutex.RUnlock()
+	if job == nil {
+		return nil
+	}
+	return s.sendV2ExtendedMiningJob(miner, job)
+}
+
+// sendV2OpenExtendedChannelError rejects an OpenExtendedMiningChannel request
+func (s *StratumServer) sendV2OpenExtendedChannelError(miner *Miner, requestID uint32, code string) error {
+	log.Printf("V2 OpenExtendedMiningChannel from %s rejected: %s", miner.ID, code)
+
+	ser := v2binary.NewSerializer()
+	errMsg := &v2binary.OpenExtendedMiningChannelError{
+		RequestID: requestID,
+		ErrorCode: v2binary.STR0_255(code),
+	}
+	payloadBytes := ser.SerializeOpenExtendedMiningChannelError(errMsg)
+	frame := ser.SerializeFrame(v2binary.MsgTypeOpenExtendedMiningChannelError, 0, payloadBytes)
+
+	if _, err := miner.Conn.Write(frame); err != nil {
+		return fmt.Errorf("send OpenExtendedMiningChannelError: %w", err)
+	}
+	return nil
+}
+
+// setV2ExtranoncePrefix moves an extended channel to a new extranonce prefix.
+// The prefix applies to jobs sent after it, so the current job is sent again
+// and shares are checked against the new prefix from then on.
+func (s *StratumServer) setV2ExtranoncePrefix(miner *Miner) error {
+	if !miner.ExtendedChannel {
+		return fmt.Errorf("miner %s has no extended channel", miner.ID)
+	}
+	extranonce1 := s.getNextExtranonce1()
+	prefix, err := hex.DecodeString(extranonce1)
+	if err != nil {
+		return fmt.Errorf("decode extranonce prefix: %w", err)
+	}
+
+	ser := v2binary.NewSerializer()
+	prefixMsg := &v2binary.SetExtranoncePrefix{
+		ChannelID:        miner.ChannelID,
+		ExtranoncePrefix: prefix,
+	}
+	payloadBytes := ser.SerializeSetExtranoncePrefix(prefixMsg)
+	frame := ser.SerializeFrame(v2binary.MsgTypeSetExtranoncePrefix, 0, payloadBytes)
+
+	if _, err := miner.Conn.Write(frame); err != nil {
+		return fmt.Errorf("send SetExtranoncePrefix: %w", err)
+	}
+	miner.Extranonce1 = extranonce1
+
+	log.Printf("V2 SetExtranoncePrefix sent to %s (ChannelID=%d, Prefix=%s)", miner.ID, miner.ChannelID, extranonce1)
+
+	s.jobMutex.RLock()
+	job := s.currentJob
+	s.jobMutex.RUnlock()
+	if job == nil {
+		return nil
+	}
+	return s.sendV2ExtendedMiningJob(miner, job)
+}
+
+// sendV2Job sends a job to a V2 miner in the form its channel type expects
+func (s *StratumServer) sendV2Job(miner *Miner, job *MiningJob) error {
+	if job == nil {
+		return errV2NoJob
+	}
+	if miner.ExtendedChannel {
+		return s.sendV2ExtendedMiningJob(miner, job)
+	}
+	return s.sendV2MiningJob(miner, miner.ChannelID, job)
+}
+
+// sendV2ExtendedMiningJob sends a job to an extended channel as
+// NewExtendedMiningJob + SetNewPrevHash. The coinbase prefix and suffix are
+// the V1 coinb1/coinb2 halves around the extranonce.
+func (s *StratumServer) sendV2ExtendedMiningJob(miner *Miner, job *MiningJob) error {
+	jobID, err := v2JobID(job)
+	if err != nil {
+		return err
+	}
+	version, err := parseHexUint32(job.Version)
+	if err != nil {
+		return errInvalidJob
+	}
+	coinbasePrefix, err := hex.DecodeString(job.Coinbase1)
+	if err != nil {
+		return errInvalidJob
+	}
+	coinbaseSuffix, err := hex.DecodeString(job.Coinbase2)
+	if err != nil {
+		return errInvalidJob
+	}
+	branch, err := s.merkleBuilder.HexToBranch(job.MerkleBranches)
+	if err != nil {
+		return errInvalidJob
+	}
+	merklePath := make([][32]byte, len(branch))
+	for i, h := range branch {
+		copy(merklePath[i][:], h)
+	}
+
+	ser := v2binary.NewSerializer()
+	jobMsg := &v2binary.NewExtendedMiningJob{
+		ChannelID:             miner.ChannelID,
+		JobID:                 jobID,
+		FuturePrevHash:        false,
+		Version:               version,
+		VersionRollingAllowed: s.minerVersionMask(miner) != 0,
+		MerklePath:            merklePath,
+		CoinbaseTxPrefix:      coinbasePrefix,
+		CoinbaseTxSuffix:      coinbaseSuffix,
+	}
+	payloadBytes := ser.SerializeNewExtendedMiningJob(jobMsg)
+	frame := ser.SerializeFrame(v2binary.MsgTypeNewExtendedMiningJob, 0, payloadBytes)
+
+	if _, err := miner.Conn.Write(frame); err != nil {
+		return fmt.Errorf("send NewExtendedMiningJob: %w", err)
+	}
+
+	if err := s.sendV2PrevHash(miner, miner.ChannelID, jobID, job); err != nil {
+		return err
+	}
+
+	log.Printf("V2 NewExtendedMiningJob sent to %s (JobID=%d)", miner.ID, jobID)
+	return nil
+}
+
+// handleV2SubmitSharesExtended validates a share from an extended channel
+func (s *StratumServer) handleV2SubmitSharesExtended(miner *Miner, payload []byte) error {
+	deser := v2binary.NewDeserializer(payload)
+	submit, err := deser.DeserializeSubmitSharesExtended()
+	if err != nil {
+		return fmt.Errorf("deserialize SubmitSharesExtended: %w", err)
+	}
+
+	reject := func(code uint8, reason, hash string) error {
+		return s.rejectV2Share(miner, submit.ChannelID, submit.SequenceNum, submit.Nonce, hash, code, reason)
+	}
+
+	if !miner.ExtendedChannel || submit.ChannelID != miner.ChannelID {
+		return reject(v2binary.ErrInvalidChannelID, rejectReasonMalformed, "")
+	}
+
+	nonce := fmt.Sprintf("%08x", submit.Nonce)
+	ntime := fmt.Sprintf("%08x", submit.NTime)
+	extranonce := hex.EncodeToString(submit.Extranonce)
+	jobID := fmt.Sprintf("%x", submit.JobID)
+
+	job, stale := s.lookupJob(jobID)
+	if stale {
+		return reject(v2binary.ErrStaleShare, rejectReasonStale, "")
+	}
+	if job == nil {
+		return reject(v2binary.ErrInvalidJobID, rejectReasonJobNotFound, "")
+	}
+
+	jobVersion, err := parseHexUint32(job.Version)
+	if err != nil {
+		return reject(v2binary.ErrInvalidJobID, rejectReasonJobNotFound, "")
+	}
+	versionBits, err := versionBitsOf(jobVersion, s.minerVersionMask(miner), submit.Version)
+	if err != nil {
+		return reject(v2binary.ErrInvalidShare, rejectReasonMalformed, "")
+	}
+
+	key := shareKey(miner.Extranonce1, extranonce, ntime, nonce, fmt.Sprintf("%08x", submit.Version))
+	switch err := s.shareTracker.CheckAndRecord(jobID, miner.Extranonce1, key); {
+	case errors.Is(err, shares.ErrDuplicateShare):
+		return reject(v2binary.ErrDuplicateShare, rejectReasonDuplicate, "")
+	case errors.Is(err, shares.ErrShareLimitReached):
+		return reject(v2binary.ErrInvalidShare, rejectReasonShareLimit, "")
+	}
+
+	result, err := s.validateShare(miner, job, extranonce, ntime, nonce, versionBits)
+	if errors.Is(err, errShareNotVerified) {
+		// Not the miner's fault: the share may be submitted again
+		s.shareTracker.Forget(jobID, miner.Extranonce1, key)
+		log.Printf("[%s] V2 share for job %s not verified: %v", miner.ID, jobID, err)
+		return reject(v2binary.ErrInvalidShare, "", "") // No reason: not stored
+	}
+	if err != nil {
+		log.Printf("[%s] Malformed V2 share for job %s: %v", miner.ID, jobID, err)
+		return reject(v2binary.ErrInvalidShare, rejectReasonMalformed, "")
+	}
+	if !result.ShareValid {
+		return reject(v2binary.ErrLowDifficultyShare, rejectReasonLowDifficulty, result.Hash)
+	}
+	if result.BlockValid {
+		go s.submitBlock(miner, job, result)
+	}
+
+	newDiff, changed := s.acceptShare(miner, nonce, result.Hash)
+
+	ser := v2binary.NewSerializer()
+	successMsg := &v2binary.SubmitSharesSuccess{
+		ChannelID:       submit.ChannelID,
+		LastSequenceNum: submit.SequenceNum,
+		NewSubmits:      1,
+	}
+	payloadBytes := ser.SerializeSubmitSharesSuccess(successMsg)
+	frame := ser.SerializeFrame(v2binary.MsgTypeSubmitSharesSuccess, 0, payloadBytes)
+
+	if _, err := miner.Conn.Write(frame); err != nil {
+		return fmt.Errorf("send SubmitSharesSuccess: %w", err)
+	}
+
+	if changed {
+		return s.sendV2SetTarget(miner, newDiff)
+	}
+	return nil
+}
+
+// sendV2SetTarget tells a V2 channel about a new share difficulty
+func (s *StratumServer) sendV2SetTarget(miner *Miner, difficulty float64) error {
+	ser := v2binary.NewSerializer()
+	targetMsg := &v2binary.SetTarget{
+		ChannelID: miner.ChannelID,
+		MaxTarget: v2Target(difficulty),
+	}
+	payloadBytes := ser.SerializeSetTarget(targetMsg)
+	frame := ser.SerializeFrame(v2binary.MsgTypeSetTarget, 0, payloadBytes)
+
+	if _, err := miner.Conn.Write(frame); err != nil {
+		return fmt.Errorf("send SetTarget: %w", err)
+	}
+	return nil
+}
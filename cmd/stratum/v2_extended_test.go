@@ -0,0 +1,224 @@
+package main
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	v2binary "github.com/chimera-pool/chimera-pool-core/internal/stratum/v2/binary"
+)
+
+// v2Frame is a decoded V2 frame written to a MockConn
+type v2Frame struct {
+	msgType uint8
+	payload []byte
+}
+
+// readV2Frames splits everything written to a connection into V2 frames
+func readV2Frames(t *testing.T, data []byte) []v2Frame {
+	var frames []v2Frame
+	for len(data) > 0 {
+		header, err := v2binary.ParseHeader(data)
+		require.NoError(t, err)
+		end := v2binary.HeaderSize + int(header.MsgLength)
+		require.LessOrEqual(t, end, len(data))
+		frames = append(frames, v2Frame{msgType: header.MsgType, payload: data[v2binary.HeaderSize:end]})
+		data = data[end:]
+	}
+	return frames
+}
+
+func newExtendedTestServer() *StratumServer {
+	s := newValidationTestServer()
+	s.authenticator = newTestAuthenticator("farm")
+	return s
+}
+
+func TestV2Target_RoundTrip(t *testing.T) {
+	target := v2Target(1.0)
+	assert.Equal(t, byte(0xff), target[28], "diff-1 target is 0x0000ffff.. big-endian, little-endian on the wire")
+	assert.Equal(t, byte(0x00), target[31])
+	assert.InDelta(t, 1.0, v2TargetDifficulty(target), 1e-9)
+	assert.InDelta(t, 512.0, v2TargetDifficulty(v2Target(512)), 1e-6)
+}
+
+func TestHandleV2OpenExtendedChannel(t *testing.T) {
+	s := newExtendedTestServer()
+	mockConn := &MockConn{}
+	miner := &Miner{ID: "proxy-1", Address: "10.0.0.5:40000", Conn: mockConn, Difficulty: 1.0}
+
+	open := &v2binary.OpenExtendedMiningChannel{
+		RequestID:         9,
+		UserIdentity:      "farm",
+		NominalHashrate:   1e12,
+		MaxTarget:         v2Target(64),
+		MinExtranonceSize: 4,
+	}
+	require.NoError(t, s.handleV2OpenExtendedChannel(miner, v2binary.NewSerializer().SerializeOpenExtendedMiningChannel(open)))
+
+	assert.True(t, miner.IsV2)
+	assert.True(t, miner.ExtendedChannel)
+	assert.Equal(t, int64(34), miner.UserID)
+	assert.Equal(t, int64(5), miner.MinerDBID)
+	assert.InDelta(t, 64.0, miner.Difficulty, 1e-6, "difficulty raised to the client's max target")
+
+	frames := readV2Frames(t, mockConn.written)
+	require.Len(t, frames, 1, "no job is sent before the first template")
+	assert.Equal(t, v2binary.MsgTypeOpenExtendedMiningChannelSuccess, frames[0].msgType)
+
+	success, err := v2binary.NewDeserializer(frames[0].payload).DeserializeOpenExtendedMiningChannelSuccess()
+	require.NoError(t, err)
+	assert.Equal(t, uint32(9), success.RequestID)
+	assert.Equal(t, miner.ChannelID, success.ChannelID)
+	assert.Equal(t, uint16(extranonce2Size), success.ExtranonceSize)
+	assert.Equal(t, miner.Extranonce1, hex.EncodeToString(success.ExtranoncePrefix))
+
+	// A second channel on the same connection is refused
+	mockConn.written = nil
+	require.NoError(t, s.handleV2OpenExtendedChannel(miner, v2binary.NewSerializer().SerializeOpenExtendedMiningChannel(open)))
+	frames = readV2Frames(t, mockConn.written)
+	require.Len(t, frames, 1)
+	assert.Equal(t, v2binary.MsgTypeOpenExtendedMiningChannelError, frames[0].msgType)
+}
+
+func TestHandleV2OpenExtendedChannel_ExtranonceTooLarge(t *testing.T) {
+	s := newExtendedTestServer()
+	mockConn := &MockConn{}
+	miner := &Miner{ID: "proxy-1", Conn: mockConn, Difficulty: 1.0}
+
+	open := &v2binary.OpenExtendedMiningChannel{RequestID: 3, UserIdentity: "farm", MinExtranonceSize: 16}
+	require.NoError(t, s.handleV2OpenExtendedChannel(miner, v2binary.NewSerializer().SerializeOpenExtendedMiningChannel(open)))
+
+	frames := readV2Frames(t, mockConn.written)
+	require.Len(t, frames, 1)
+	require.Equal(t, v2binary.MsgTypeOpenExtendedMiningChannelError, frames[0].msgType)
+
+	errMsg, err := v2binary.NewDeserializer(frames[0].payload).DeserializeOpenExtendedMiningChannelError()
+	require.NoError(t, err)
+	assert.Equal(t, v2binary.STR0_255(v2ErrExtranonceSizeTooBig), errMsg.ErrorCode)
+	assert.False(t, miner.Authorized)
+}
+
+func TestSendV2ExtendedMiningJob(t *testing.T) {
+	s := newValidationTestServer()
+	job, _, _ := genesisJob()
+	job.MerkleBranches = []string{"0100000000000000000000000000000000000000000000000000000000000000"}
+
+	mockConn := &MockConn{}
+	miner := &Miner{ID: "proxy-1", Conn: mockConn, IsV2: true, ExtendedChannel: true, ChannelID: 1}
+	require.NoError(t, s.sendV2Job(miner, job))
+
+	frames := readV2Frames(t, mockConn.written)
+	require.Len(t, frames, 2)
+	assert.Equal(t, v2binary.MsgTypeNewExtendedMiningJob, frames[0].msgType)
+	assert.Equal(t, v2binary.MsgTypeSetNewPrevHash, frames[1].msgType)
+
+	msg, err := v2binary.NewDeserializer(frames[0].payload).DeserializeNewExtendedMiningJob()
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1), msg.JobID)
+	assert.Equal(t, uint32(1), msg.Version)
+	assert.Equal(t, job.Coinbase1, hex.EncodeToString(msg.CoinbaseTxPrefix))
+	assert.Equal(t, job.Coinbase2, hex.EncodeToString(msg.CoinbaseTxSuffix))
+	require.Len(t, msg.MerklePath, 1)
+	assert.Equal(t, byte(0x01), msg.MerklePath[0][0])
+}
+
+func TestSetV2ExtranoncePrefix(t *testing.T) {
+	s := newExtendedTestServer()
+	job, _, _ := genesisJob()
+	s.currentJob = job
+
+	mockConn := &MockConn{}
+	miner := &Miner{ID: "proxy-1", Conn: mockConn, IsV2: true, ExtendedChannel: true, ChannelID: 1, Extranonce1: "00000001"}
+	s.extranonce1 = 1
+	require.NoError(t, s.setV2ExtranoncePrefix(miner))
+	assert.Equal(t, "00000002", miner.Extranonce1)
+
+	frames := readV2Frames(t, mockConn.written)
+	require.Len(t, frames, 3, "the current job is sent again under the new prefix")
+	require.Equal(t, v2binary.MsgTypeSetExtranoncePrefix, frames[0].msgType)
+	assert.Equal(t, v2binary.MsgTypeNewExtendedMiningJob, frames[1].msgType)
+	assert.Equal(t, v2binary.MsgTypeSetNewPrevHash, frames[2].msgType)
+
+	msg, err := v2binary.NewDeserializer(frames[0].payload).DeserializeSetExtranoncePrefix()
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1), msg.ChannelID)
+	assert.Equal(t, "00000002", hex.EncodeToString(msg.ExtranoncePrefix))
+
+	standard := &Miner{ID: "v2-standard", Conn: &MockConn{}, IsV2: true, ChannelID: 1}
+	assert.Error(t, s.setV2ExtranoncePrefix(standard))
+}
+
+func TestHandleV2SubmitSharesExtended_LowDifficultyThenDuplicate(t *testing.T) {
+	s := newExtendedTestServer()
+	job, en1, en2 := genesisJob()
+	s.storeJobLocked(job)
+
+	mockConn := &MockConn{}
+	miner := &Miner{
+		ID:              "proxy-1",
+		UserID:          34,
+		MinerDBID:       5,
+		Username:        "farm",
+		Conn:            mockConn,
+		Authorized:      true,
+		Difficulty:      1e9,
+		Extranonce1:     en1,
+		IsV2:            true,
+		ExtendedChannel: true,
+		ChannelID:       1,
+	}
+
+	extranonce, _ := hex.DecodeString(en2)
+	submit := &v2binary.SubmitSharesExtended{
+		ChannelID:   1,
+		SequenceNum: 1,
+		JobID:       1,
+		Nonce:       0x7c3f51cd,
+		NTime:       0x4e8eaab9,
+		Version:     1,
+		Extranonce:  extranonce,
+	}
+	require.NoError(t, s.handleV2SubmitSharesExtended(miner, v2binary.NewSerializer().SerializeSubmitSharesExtended(submit)))
+	submit.SequenceNum = 2
+	require.NoError(t, s.handleV2SubmitSharesExtended(miner, v2binary.NewSerializer().SerializeSubmitSharesExtended(submit)))
+
+	recorded := recordedShares(s)
+	require.Len(t, recorded, 2)
+	for i, reason := range []string{rejectReasonLowDifficulty, rejectReasonDuplicate} {
+		assert.Equal(t, reason, recorded[i].reason)
+		assert.Equal(t, int64(5), recorded[i].share.MinerID)
+		assert.Equal(t, int64(34), recorded[i].share.UserID)
+		assert.Equal(t, 1e9, recorded[i].share.Difficulty)
+		assert.Equal(t, "7c3f51cd", recorded[i].share.Nonce)
+	}
+
+	frames := readV2Frames(t, mockConn.written)
+	require.Len(t, frames, 2)
+	var codes []v2binary.STR0_255
+	for _, f := range frames {
+		require.Equal(t, v2binary.MsgTypeSubmitSharesError, f.msgType)
+		msg, err := v2binary.NewDeserializer(f.payload).DeserializeSubmitSharesError()
+		require.NoError(t, err)
+		codes = append(codes, msg.ErrorCode)
+	}
+	assert.Equal(t, []v2binary.STR0_255{"difficulty-too-low", "duplicate-share"}, codes)
+	assert.Equal(t, int64(2), miner.SharesInvalid)
+}
+
+func TestHandleV2SubmitSharesExtended_WrongChannel(t *testing.T) {
+	s := newExtendedTestServer()
+	mockConn := &MockConn{}
+	miner := &Miner{ID: "v2-standard", Conn: mockConn, IsV2: true, ChannelID: 1, Difficulty: 1.0}
+
+	submit := &v2binary.SubmitSharesExtended{ChannelID: 1, SequenceNum: 4, JobID: 1, Extranonce: []byte{0, 0, 0, 1}}
+	require.NoError(t, s.handleV2SubmitSharesExtended(miner, v2binary.NewSerializer().SerializeSubmitSharesExtended(submit)))
+
+	frames := readV2Frames(t, mockConn.written)
+	require.Len(t, frames, 1)
+	msg, err := v2binary.NewDeserializer(frames[0].payload).DeserializeSubmitSharesError()
+	require.NoError(t, err)
+	assert.Equal(t, v2binary.STR0_255("invalid-channel-id"), msg.ErrorCode)
+}
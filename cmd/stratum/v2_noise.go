@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"time"
+
+	"github.com/chimera-pool/chimera-pool-core/internal/stratum/detector"
+	v2binary "github.com/chimera-pool/chimera-pool-core/internal/stratum/v2/binary"
+	"github.com/chimera-pool/chimera-pool-core/internal/stratum/v2/noise"
+)
+
+// noiseHandshakeTimeout bounds how long a client may take to finish the handshake
+const noiseHandshakeTimeout = 10 * time.Second
+
+// initNoise loads the persistent authority key and creates this process's
+// Noise static key. Miners pin the authority public key logged here.
+func initNoise(config *Config) (*noise.ServerConfig, error) {
+	authority, err := noise.LoadOrCreateAuthorityKey(config.V2AuthorityKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	static, err := noise.GenerateKeyPair()
+	if err != nil {
+		return nil, fmt.Errorf("generate noise static key: %w", err)
+	}
+
+	log.Printf("🔑 Stratum V2 authority public key: %s", authority.PublicKey())
+	return &noise.ServerConfig{
+		StaticKey:    static,
+		AuthorityKey: authority,
+		CertValidity: config.V2CertValidity,
+	}, nil
+}
+
+// HandleNoiseConnection performs the Noise NX handshake and then serves
+// Stratum V2 over the encrypted channel
+func (s *StratumServer) HandleNoiseConnection(conn net.Conn) {
+	defer conn.Close()
+
+	minerID := fmt.Sprintf("%s-%d", conn.RemoteAddr().String(), time.Now().UnixNano())
+
+	conn.SetDeadline(time.Now().Add(noiseHandshakeTimeout))
+	secure, err := noise.ServerHandshake(conn, s.noiseConfig)
+	if err != nil {
+		if err != io.EOF && err != io.ErrUnexpectedEOF {
+			log.Printf("Noise handshake failed for %s: %v", minerID, err)
+		}
+		return
+	}
+
+	header := make([]byte, v2binary.HeaderSize)
+	if _, err := io.ReadFull(secure, header); err != nil {
+		log.Printf("Failed to read first V2 frame from %s: %v", minerID, err)
+		return
+	}
+	conn.SetDeadline(time.Time{})
+
+	log.Printf("Noise-encrypted Stratum V2 connection from %s", minerID)
+	s.handleV2Connection(detector.WithProfile(secure, detector.ProfileOf(conn)), header, minerID)
+}
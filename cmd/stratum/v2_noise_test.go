@@ -0,0 +1,82 @@
+package main
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/chimera-pool/chimera-pool-core/internal/stratum/detector"
+	v2binary "github.com/chimera-pool/chimera-pool-core/internal/stratum/v2/binary"
+	"github.com/chimera-pool/chimera-pool-core/internal/stratum/v2/noise"
+)
+
+func TestHandleNoiseConnection_SetupConnection(t *testing.T) {
+	authority, err := noise.GenerateAuthorityKey()
+	require.NoError(t, err)
+	static, err := noise.GenerateKeyPair()
+	require.NoError(t, err)
+
+	s := newValidationTestServer()
+	s.noiseConfig = &noise.ServerConfig{StaticKey: static, AuthorityKey: authority, CertValidity: time.Hour}
+
+	client, server := net.Pipe()
+	defer client.Close()
+	go s.HandleNoiseConnection(server)
+
+	secure, err := noise.ClientHandshake(client, authority.PublicKey())
+	require.NoError(t, err)
+
+	ser := v2binary.NewSerializer()
+	setup := ser.SerializeSetupConnection(&v2binary.SetupConnection{MinVersion: 2, MaxVersion: 2, Vendor: "test"})
+	_, err = secure.Write(ser.SerializeFrame(v2binary.MsgTypeSetupConnection, 0, setup))
+	require.NoError(t, err)
+
+	headerBuf := make([]byte, v2binary.HeaderSize)
+	_, err = io.ReadFull(secure, headerBuf)
+	require.NoError(t, err)
+	header, err := v2binary.ParseHeader(headerBuf)
+	require.NoError(t, err)
+	assert.Equal(t, v2binary.MsgTypeSetupConnectionSuccess, header.MsgType)
+
+	payload := make([]byte, header.MsgLength)
+	_, err = io.ReadFull(secure, payload)
+	require.NoError(t, err)
+	success, err := v2binary.NewDeserializer(payload).DeserializeSetupConnectionSuccess()
+	require.NoError(t, err)
+	assert.Equal(t, uint16(2), success.UsedVersion)
+}
+
+func TestHandleConnection_PlaintextV2Disabled(t *testing.T) {
+	s := newValidationTestServer()
+	s.config.V2AllowPlaintext = false
+
+	client, server := net.Pipe()
+	defer client.Close()
+
+	done := make(chan struct{})
+	go func() {
+		router := detector.NewRouter()
+		for _, handler := range s.protocolHandlers() {
+			router.RegisterHandler(handler.Protocol(), handler)
+		}
+		router.Route(server)
+		close(done)
+	}()
+
+	ser := v2binary.NewSerializer()
+	setup := ser.SerializeSetupConnection(&v2binary.SetupConnection{MinVersion: 2, MaxVersion: 2})
+	go client.Write(ser.SerializeFrame(v2binary.MsgTypeSetupConnection, 0, setup))
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("plaintext V2 connection was not closed")
+	}
+	s.minersMutex.RLock()
+	defer s.minersMutex.RUnlock()
+	assert.Empty(t, s.miners)
+}
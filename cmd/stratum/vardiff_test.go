@@ -0,0 +1,78 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/chimera-pool/chimera-pool-core/internal/stratum/vardiff"
+)
+
+func TestParseAssignments(t *testing.T) {
+	assignments := parseAssignments(" 3334=ema, alice = share-window,broken,")
+	assert.Equal(t, map[string]string{"3334": "ema", "alice": "share-window"}, assignments)
+	assert.Empty(t, parseAssignments(""))
+}
+
+func TestNewVardiffPolicies(t *testing.T) {
+	config := &Config{
+		Difficulty:          500,
+		VardiffPolicy:       vardiff.PolicyEMA,
+		VardiffPortPolicies: map[string]string{"3333": vardiff.PolicyShareWindow},
+		VardiffUserPolicies: map[string]string{"picaxe": vardiff.PolicyEMA},
+	}
+	policies, err := newVardiffPolicies(config)
+	require.NoError(t, err)
+
+	assert.Equal(t, vardiff.PolicyEMA, policies.Default().Name)
+	assert.Equal(t, vardiff.PolicyShareWindow, policies.Select("3333", "").Name)
+	assert.Equal(t, vardiff.PolicyEMA, policies.Select("3333", "picaxe").Name)
+	assert.Equal(t, 500.0, policies.Default().Config.InitialDifficulty)
+
+	config.VardiffPortPolicies = map[string]string{"3333": "turbo"}
+	_, err = newVardiffPolicies(config)
+	assert.ErrorIs(t, err, vardiff.ErrUnknownPolicy)
+}
+
+func TestAuthorizeAppliesUserVardiffPolicy(t *testing.T) {
+	policies, err := newVardiffPolicies(&Config{
+		Difficulty:          1.0,
+		VardiffUserPolicies: map[string]string{"picaxe": vardiff.PolicyEMA},
+	})
+	require.NoError(t, err)
+
+	server := newValidationTestServer()
+	server.authenticator = newTestAuthenticator("picaxe")
+	server.vardiffManager = vardiff.NewManagerWithPolicies(policies)
+
+	miner := &Miner{ID: "test-miner-1", Conn: &MockConn{}}
+	miner.Difficulty = server.vardiffManager.Attach(miner.ID, "3333", vardiff.Hints{})
+	require.NoError(t, server.authorizeUser(miner, "picaxe"))
+
+	trace := server.vardiffManager.Trace(miner.ID)
+	require.Len(t, trace, 2)
+	assert.Equal(t, vardiff.PolicyShareWindow, trace[0].Policy)
+	assert.Equal(t, vardiff.DecisionPolicy, trace[1].Kind)
+	assert.Equal(t, vardiff.PolicyEMA, trace[1].Policy)
+	assert.Equal(t, "picaxe.default", trace[1].Worker)
+}
+
+func TestHandleSuggestDifficulty(t *testing.T) {
+	policies, err := newVardiffPolicies(&Config{Difficulty: 1.0})
+	require.NoError(t, err)
+
+	server := newValidationTestServer()
+	server.vardiffManager = vardiff.NewManagerWithPolicies(policies)
+
+	mockConn := &MockConn{}
+	miner := &Miner{ID: "test-miner-1", Conn: mockConn}
+	server.vardiffManager.Attach(miner.ID, "3333", vardiff.Hints{})
+
+	req := StratumRequest{ID: 7, Method: "mining.suggest_difficulty", Params: []interface{}{5e6}}
+	require.NoError(t, server.handleSuggestDifficulty(miner, req))
+
+	assert.Equal(t, 1e6, miner.Difficulty, "clamped to the policy's suggestion bounds")
+	assert.Equal(t, 1e6, server.vardiffManager.GetDifficulty(miner.ID))
+	assert.Contains(t, string(mockConn.written), `"mining.set_difficulty","params":[1000000]`)
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+// =============================================================================
+// STRATUM EXTENSIONS (BIP310)
+// mining.configure negotiates version rolling (ASICBoost) and a minimum
+// difficulty. The negotiated mask is the pool mask (STRATUM_VERSION_ROLLING_MASK)
+// ANDed with the miner's; shares may only change version bits inside it.
+// Bits set in the current job's version are taken out of the pool mask so
+// rolling never clears a deployment signal, and miners are told of the new
+// mask with mining.set_version_mask. V2 SetupConnection's version rolling
+// flag negotiates the full pool mask.
+// =============================================================================
+
+// defaultVersionRollingMask is the BIP320 general purpose version bits
+const defaultVersionRollingMask uint32 = 0x1fffe000
+
+// Version rolling errors
+var (
+	errInvalidVersionBits = errors.New("invalid version bits")
+	errVersionNotAllowed  = errors.New("version bits outside the negotiated mask")
+)
+
+// parseVersionMask parses a hex version mask such as "1fffe000"
+func parseVersionMask(s string) (uint32, error) {
+	mask, err := strconv.ParseUint(strings.TrimPrefix(strings.TrimSpace(s), "0x"), 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid version mask %q: %w", s, err)
+	}
+	return uint32(mask), nil
+}
+
+// versionMaskHex formats a version mask as BIP310 expects
+func versionMaskHex(mask uint32) string {
+	return fmt.Sprintf("%08x", mask)
+}
+
+// poolVersionMask is the mask the pool currently lets miners roll
+func (s *StratumServer) poolVersionMask() uint32 {
+	return s.versionMask.Load()
+}
+
+// minerVersionMask is the mask negotiated with a miner, 0 if it doesn't roll
+func (s *StratumServer) minerVersionMask(miner *Miner) uint32 {
+	if !miner.VersionRolling {
+		return 0
+	}
+	return miner.VersionRollingMask & s.poolVersionMask()
+}
+
+// rolledVersion applies a miner's rolled version bits to a job's version,
+// rejecting bits outside the negotiated mask
+func rolledVersion(jobVersion, mask, versionBits uint32) (uint32, error) {
+	if versionBits&^mask != 0 {
+		return 0, errVersionNotAllowed
+	}
+	return jobVersion&^mask | versionBits, nil
+}
+
+// versionBitsOf returns the rolled bits of a full block version (V2 submits
+// the whole version), rejecting changes outside the negotiated mask
+func versionBitsOf(jobVersion, mask, version uint32) (uint32, error) {
+	if (version^jobVersion)&^mask != 0 {
+		return 0, errVersionNotAllowed
+	}
+	return version & mask, nil
+}
+
+// handleConfigure handles mining.configure
+// Params: [["version-rolling", "minimum-difficulty"], {"version-rolling.mask": "1fffe000", ...}]
+func (s *StratumServer) handleConfigure(miner *Miner, req StratumRequest) error {
+	var extensions []interface{}
+	options := map[string]interface{}{}
+	if len(req.Params) > 0 {
+		extensions, _ = req.Params[0].([]interface{})
+	}
+	if len(req.Params) > 1 {
+		if params, ok := req.Params[1].(map[string]interface{}); ok {
+			options = params
+		}
+	}
+
+	result := map[string]interface{}{}
+	for _, ext := range extensions {
+		name, _ := ext.(string)
+		switch name {
+		case "version-rolling":
+			s.configureVersionRolling(miner, options, result)
+		case "minimum-difficulty":
+			s.configureMinimumDifficulty(miner, options, result)
+		default:
+			log.Printf("[%s] Unsupported mining.configure extension %q", miner.ID, name)
+		}
+	}
+	return s.sendResponse(miner, req.ID, result, nil)
+}
+
+// configureVersionRolling negotiates the version rolling mask
+func (s *StratumServer) configureVersionRolling(miner *Miner, options, result map[string]interface{}) {
+	requested := uint32(0xffffffff)
+	if value, ok := options["version-rolling.mask"].(string); ok {
+		mask, err := parseVersionMask(value)
+		if err != nil {
+			log.Printf("[%s] mining.configure: %v", miner.ID, err)
+			result["version-rolling"] = false
+			return
+		}
+		requested = mask
+	}
+	minBits, _ := options["version-rolling.min-bit-count"].(float64)
+
+	mask := requested & s.poolVersionMask()
+	if mask == 0 || bits.OnesCount32(mask) < int(minBits) {
+		miner.VersionRolling = false
+		result["version-rolling"] = false
+		return
+	}
+
+	miner.VersionRolling = true
+	miner.VersionRollingMask = requested
+	result["version-rolling"] = true
+	result["version-rolling.mask"] = versionMaskHex(mask)
+	log.Printf("[%s] Version rolling enabled: mask=%s", miner.ID, versionMaskHex(mask))
+}
+
+// configureMinimumDifficulty sets the difficulty floor the miner asked for
+func (s *StratumServer) configureMinimumDifficulty(miner *Miner, options, result map[string]interface{}) {
+	minimum, ok := options["minimum-difficulty.value"].(float64)
+	if !ok || minimum <= 0 {
+		result["minimum-difficulty"] = false
+		return
+	}
+
+	newDiff, changed := s.vardiffManager.SetMinimum(miner.ID, minimum)
+	result["minimum-difficulty"] = true
+	log.Printf("[%s] Minimum difficulty %g requested, diff=%g", miner.ID, minimum, newDiff)
+	if !changed {
+		return
+	}
+	miner.Difficulty = newDiff
+	// Before subscribe the new difficulty goes out with the subscription
+	if miner.Extranonce1 != "" {
+		s.sendNotification(miner, "mining.set_difficulty", []interface{}{newDiff})
+	}
+}
+
+// updateVersionMask narrows the pool mask so that rolling cannot change bits
+// the job's version sets, and tells rolling V1 miners when their mask changes
+func (s *StratumServer) updateVersionMask(job *MiningJob) {
+	jobVersion, err := parseHexUint32(job.Version)
+	if err != nil {
+		return
+	}
+	mask := s.config.VersionRollingMask &^ jobVersion
+	previous := s.versionMask.Swap(mask)
+	if previous == mask {
+		return
+	}
+	log.Printf("Version rolling mask changed from %s to %s", versionMaskHex(previous), versionMaskHex(mask))
+
+	s.minersMutex.RLock()
+	defer s.minersMutex.RUnlock()
+	for _, miner := range s.miners {
+		// V2 jobs carry their own mask
+		if miner.IsV2 || !miner.VersionRolling {
+			continue
+		}
+		if miner.VersionRollingMask&previous != miner.VersionRollingMask&mask {
+			s.sendNotification(miner, "mining.set_version_mask", []interface{}{versionMaskHex(s.minerVersionMask(miner))})
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	v2binary "github.com/chimera-pool/chimera-pool-core/internal/stratum/v2/binary"
+)
+
+// newVersionRollingTestServer creates a validation test server with the
+// default pool mask
+func newVersionRollingTestServer() *StratumServer {
+	s := newValidationTestServer()
+	s.config.VersionRollingMask = defaultVersionRollingMask
+	s.versionMask.Store(defaultVersionRollingMask)
+	return s
+}
+
+// configureResult sends mining.configure and decodes the result map
+func configureResult(t *testing.T, s *StratumServer, miner *Miner, params ...interface{}) map[string]interface{} {
+	t.Helper()
+	conn := miner.Conn.(*MockConn)
+	conn.written = nil
+	require.NoError(t, s.handleMessage(miner, mustJSON(t, StratumRequest{ID: 1, Method: "mining.configure", Params: params})))
+
+	// Skip notifications such as mining.set_difficulty
+	for _, line := range strings.Split(strings.TrimSpace(string(conn.written)), "\n") {
+		var resp struct {
+			ID     interface{}            `json:"id"`
+			Result map[string]interface{} `json:"result"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(line), &resp))
+		if resp.ID != nil {
+			return resp.Result
+		}
+	}
+	t.Fatal("no mining.configure response")
+	return nil
+}
+
+func mustJSON(t *testing.T, v interface{}) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestHandleConfigure_VersionRolling(t *testing.T) {
+	s := newVersionRollingTestServer()
+	miner := &Miner{ID: "asic-1", Conn: &MockConn{}}
+
+	result := configureResult(t, s, miner, []interface{}{"version-rolling", "subscribe-extranonce"},
+		map[string]interface{}{"version-rolling.mask": "ffffffff", "version-rolling.min-bit-count": 2})
+	assert.Equal(t, true, result["version-rolling"])
+	assert.Equal(t, "1fffe000", result["version-rolling.mask"])
+	assert.NotContains(t, result, "subscribe-extranonce")
+	assert.Equal(t, uint32(0x1fffe000), s.minerVersionMask(miner))
+
+	// The negotiated mask is the intersection
+	result = configureResult(t, s, miner, []interface{}{"version-rolling"},
+		map[string]interface{}{"version-rolling.mask": "00ffe000"})
+	assert.Equal(t, "00ffe000", result["version-rolling.mask"])
+
+	// Not enough bits to roll
+	result = configureResult(t, s, miner, []interface{}{"version-rolling"},
+		map[string]interface{}{"version-rolling.mask": "00006000", "version-rolling.min-bit-count": 4})
+	assert.Equal(t, false, result["version-rolling"])
+	assert.Zero(t, s.minerVersionMask(miner))
+}
+
+func TestHandleConfigure_MinimumDifficulty(t *testing.T) {
+	s := newVersionRollingTestServer()
+	miner := &Miner{ID: "asic-1", Conn: &MockConn{}, Extranonce1: "00000001", Difficulty: 1}
+
+	minimum := s.vardiffManager.GetConfig().InitialDifficulty * 4
+	result := configureResult(t, s, miner, []interface{}{"minimum-difficulty"},
+		map[string]interface{}{"minimum-difficulty.value": minimum})
+	assert.Equal(t, true, result["minimum-difficulty"])
+	assert.Equal(t, minimum, miner.Difficulty)
+	assert.Contains(t, string(miner.Conn.(*MockConn).written), `"method":"mining.set_difficulty"`)
+
+	result = configureResult(t, s, miner, []interface{}{"minimum-difficulty"},
+		map[string]interface{}{"minimum-difficulty.value": "high"})
+	assert.Equal(t, false, result["minimum-difficulty"])
+}
+
+func TestValidateShare_RolledVersion(t *testing.T) {
+	s := newVersionRollingTestServer()
+	job, en1, en2 := genesisJob()
+	miner := newValidationTestMiner(en1, 1)
+
+	// Without negotiation no bits may be rolled
+	_, err := s.validateShare(miner, job, en2, "4e8eaab9", "7c3f51cd", 0x2000)
+	assert.ErrorIs(t, err, errVersionNotAllowed)
+
+	miner.VersionRolling = true
+	miner.VersionRollingMask = 0xffffffff
+	result, err := s.validateShare(miner, job, en2, "4e8eaab9", "7c3f51cd", 0x2000)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(0x2001), binary.LittleEndian.Uint32(result.Header[0:4]))
+	assert.False(t, result.BlockValid, "rolled header is a different block")
+
+	_, err = s.validateShare(miner, job, en2, "4e8eaab9", "7c3f51cd", 0x40000000)
+	assert.ErrorIs(t, err, errVersionNotAllowed)
+}
+
+func TestHandleSubmit_VersionBitsOutsideMask(t *testing.T) {
+	s := newVersionRollingTestServer()
+	job, en1, en2 := genesisJob()
+	s.storeJobLocked(job)
+	miner := newValidationTestMiner(en1, 1)
+	miner.VersionRolling = true
+	miner.VersionRollingMask = 0xffffffff
+
+	req := StratumRequest{
+		ID:     4,
+		Method: "mining.submit",
+		Params: []interface{}{"picaxe", job.JobID, en2, "4e8eaab9", "7c3f51cd", "e0000000"},
+	}
+	require.NoError(t, s.handleSubmit(miner, req))
+	assert.Contains(t, string(miner.Conn.(*MockConn).written), errVersionNotAllowed.Error())
+
+	req.Params[5] = "not-hex"
+	require.NoError(t, s.handleSubmit(miner, req))
+	assert.Contains(t, string(miner.Conn.(*MockConn).written), errInvalidVersionBits.Error())
+
+	recorded := recordedShares(s)
+	require.Len(t, recorded, 2)
+	assert.Equal(t, rejectReasonMalformed, recorded[0].reason)
+	assert.Equal(t, rejectReasonMalformed, recorded[1].reason)
+}
+
+func TestUpdateVersionMask_NotifiesRollingMiners(t *testing.T) {
+	s := newVersionRollingTestServer()
+	rolling := &Miner{ID: "asic-1", Conn: &MockConn{}, VersionRolling: true, VersionRollingMask: 0xffffffff}
+	plain := &Miner{ID: "cpu-1", Conn: &MockConn{}}
+	s.miners[rolling.ID] = rolling
+	s.miners[plain.ID] = plain
+
+	// A template signalling bit 13 takes it out of the mask
+	s.updateVersionMask(&MiningJob{Version: "20002000"})
+	assert.Equal(t, uint32(0x1fffc000), s.poolVersionMask())
+	assert.Contains(t, string(rolling.Conn.(*MockConn).written), `"method":"mining.set_version_mask","params":["1fffc000"]`)
+	assert.Empty(t, plain.Conn.(*MockConn).written)
+
+	// An unchanged mask is not announced again
+	rolling.Conn.(*MockConn).written = nil
+	s.updateVersionMask(&MiningJob{Version: "20002000"})
+	assert.Empty(t, rolling.Conn.(*MockConn).written)
+}
+
+func TestHandleV2SetupConnection_VersionRollingFlag(t *testing.T) {
+	setup := func(s *StratumServer) (*Miner, *v2binary.SetupConnectionSuccess) {
+		mockConn := &MockConn{}
+		miner := &Miner{ID: "v2-1", Conn: mockConn, IsV2: true}
+		payload := v2binary.NewSerializer().SerializeSetupConnection(&v2binary.SetupConnection{
+			Protocol:   v2binary.ProtocolMining,
+			MinVersion: 2,
+			MaxVersion: 2,
+			Flags:      uint32(v2binary.ExtensionTypeVersionRolling),
+		})
+		require.NoError(t, s.handleV2SetupConnection(miner, payload))
+
+		frames := readV2Frames(t, mockConn.written)
+		require.Len(t, frames, 1)
+		msg, err := v2binary.NewDeserializer(frames[0].payload).DeserializeSetupConnectionSuccess()
+		require.NoError(t, err)
+		return miner, msg
+	}
+
+	s := newVersionRollingTestServer()
+	miner, msg := setup(s)
+	assert.Equal(t, uint32(v2binary.ExtensionTypeVersionRolling), msg.Flags)
+	assert.Equal(t, uint32(0x1fffe000), s.minerVersionMask(miner))
+
+	// Extended jobs let the channel roll
+	mockConn := miner.Conn.(*MockConn)
+	mockConn.written = nil
+	miner.ExtendedChannel = true
+	miner.ChannelID = 1
+	job, _, _ := genesisJob()
+	require.NoError(t, s.sendV2Job(miner, job))
+	job2, err := v2binary.NewDeserializer(readV2Frames(t, mockConn.written)[0].payload).DeserializeNewExtendedMiningJob()
+	require.NoError(t, err)
+	assert.True(t, job2.VersionRollingAllowed)
+
+	// With rolling disabled the flag is not echoed
+	s = newValidationTestServer()
+	miner, msg = setup(s)
+	assert.Zero(t, msg.Flags)
+	assert.False(t, miner.VersionRolling)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"log"
+
+	"github.com/chimera-pool/chimera-pool-core/internal/notifications"
+	"github.com/chimera-pool/chimera-pool-core/internal/stratum/events"
+)
+
+// =============================================================================
+// WORKER EVENTS
+// Connections, authorizations, accepted shares and disconnects are published
+// on an in-process bus. The worker monitor subscribes to it and raises
+// offline, back-online and hashrate-drop alerts as sessions change, using
+// each user's notification settings for the thresholds.
+// =============================================================================
+
+// publishWorkerEvent publishes an event for a miner's connection
+func (s *StratumServer) publishWorkerEvent(miner *Miner, kind events.Kind, difficulty float64) {
+	event := events.WorkerEvent{
+		Kind:         kind,
+		ConnectionID: miner.ID,
+		Address:      miner.Address,
+		Difficulty:   difficulty,
+	}
+	if miner.Authorized {
+		event.UserID = miner.UserID
+		event.WorkerID = miner.MinerDBID
+		event.WorkerName = miner.WorkerName
+	}
+	s.events.Publish(event)
+}
+
+// trackWorkerEvents publishes the connect event and returns the function that
+// publishes the disconnect, to be deferred by the connection handler
+func (s *StratumServer) trackWorkerEvents(miner *Miner) func() {
+	s.publishWorkerEvent(miner, events.KindConnected, 0)
+	return func() {
+		s.publishWorkerEvent(miner, events.KindDisconnected, 0)
+	}
+}
+
+// startWorkerMonitor starts the worker alert monitor on the server's event
+// bus. It returns nil if worker alerts are disabled.
+func startWorkerMonitor(server *StratumServer, repo *notifications.SQLNotificationRepository) *notifications.WorkerMonitor {
+	if getEnv("STRATUM_WORKER_ALERTS", "true") == "false" {
+		log.Println("⚠️ Worker alerts disabled via STRATUM_WORKER_ALERTS=false")
+		return nil
+	}
+
+	monitor := notifications.NewWorkerMonitor(notifications.DefaultWorkerMonitorConfig(), server.notifier, nil)
+	monitor.SetThresholdProvider(repo)
+	monitor.Start()
+	monitor.Subscribe(server.events)
+	log.Println("✅ Worker monitor subscribed to stratum worker events")
+	return monitor
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/chimera-pool/chimera-pool-core/internal/stratum/events"
+	"github.com/chimera-pool/chimera-pool-core/internal/stratum/hashrate"
+)
+
+// TestWorkerEvents_Lifecycle tests the events a session publishes from
+// connect to disconnect
+func TestWorkerEvents_Lifecycle(t *testing.T) {
+	s := newValidationTestServer()
+	s.authenticator = newTestAuthenticator("picaxe")
+	s.hashrateWindows = make(map[string]*hashrate.Window)
+	s.hashrateCalc = hashrate.NewCalculator()
+	// Stats updates fail fast against a closed port
+	s.redis = redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1})
+	defer s.redis.Close()
+	s.events = events.NewBus()
+	ch, cancel := s.events.Subscribe(8)
+	defer cancel()
+
+	miner := &Miner{ID: "test-miner-1", Address: "192.168.1.100:12345", Conn: &MockConn{}, Difficulty: 8}
+	disconnected := s.trackWorkerEvents(miner)
+	require.NoError(t, s.authorizeUser(miner, "picaxe"))
+	s.acceptShare(miner, "7c3f51cd", "00ff")
+	disconnected()
+
+	var got []events.WorkerEvent
+	for len(ch) > 0 {
+		got = append(got, <-ch)
+	}
+	require.Len(t, got, 4)
+
+	assert.Equal(t, events.KindConnected, got[0].Kind)
+	assert.False(t, got[0].Authorized(), "not yet authorized on connect")
+	assert.Equal(t, "192.168.1.100:12345", got[0].Address)
+
+	assert.Equal(t, events.KindAuthorized, got[1].Kind)
+	assert.Equal(t, int64(34), got[1].UserID)
+	assert.Equal(t, int64(5), got[1].WorkerID)
+	assert.Equal(t, "default", got[1].WorkerName)
+
+	assert.Equal(t, events.KindShareAccepted, got[2].Kind)
+	assert.Equal(t, 8.0, got[2].Difficulty)
+
+	assert.Equal(t, events.KindDisconnected, got[3].Kind)
+	assert.Equal(t, int64(5), got[3].WorkerID)
+	for _, event := range got {
+		assert.Equal(t, miner.ID, event.ConnectionID)
+	}
+}
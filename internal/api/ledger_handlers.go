@@ -0,0 +1,114 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/chimera-pool/chimera-pool-core/internal/payouts"
+	"github.com/gin-gonic/gin"
+)
+
+// =============================================================================
+// LEDGER API HANDLERS (Gin)
+// Admins correct miner balances with audited ledger adjustments and run the
+// ledger's invariant check on demand.
+// =============================================================================
+
+// LedgerAdjuster posts manual balance adjustments to the ledger (ISP)
+type LedgerAdjuster interface {
+	Adjust(ctx context.Context, userID, amount int64, admin, reference, memo string) error
+}
+
+// LedgerChecker runs the ledger's invariant check (ISP)
+type LedgerChecker interface {
+	Check(ctx context.Context) (*payouts.LedgerReport, error)
+}
+
+// LedgerHandlers handles ledger API requests
+type LedgerHandlers struct {
+	ledger  LedgerAdjuster
+	checker LedgerChecker
+}
+
+// NewLedgerHandlers creates new ledger handlers
+func NewLedgerHandlers(ledger LedgerAdjuster, checker LedgerChecker) *LedgerHandlers {
+	return &LedgerHandlers{ledger: ledger, checker: checker}
+}
+
+// ledgerAdjustmentRequest is the body of an adjustment. A positive amount
+// credits the miner and a negative one debits them.
+type ledgerAdjustmentRequest struct {
+	UserID    int64  `json:"user_id" binding:"required"`
+	Amount    int64  `json:"amount" binding:"required"`
+	Reference string `json:"reference" binding:"required"`
+	Memo      string `json:"memo" binding:"required"`
+}
+
+// adjust credits or debits a miner's balance. The posting records the admin,
+// the reference (e.g. a support ticket) and the memo; a reference can only
+// be used once.
+func (h *LedgerHandlers) adjust(c *gin.Context) {
+	adminID := getUserIDFromGinContext(c)
+	if adminID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req ledgerAdjustmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id, a non-zero amount, reference and memo are required"})
+		return
+	}
+
+	admin := fmt.Sprintf("admin:%d", adminID)
+	err := h.ledger.Adjust(c.Request.Context(), req.UserID, req.Amount, admin, req.Reference, req.Memo)
+	switch {
+	case errors.Is(err, payouts.ErrDuplicatePosting):
+		c.JSON(http.StatusConflict, gin.H{"error": "reference already used by another adjustment"})
+	case errors.Is(err, payouts.ErrInsufficientBalance):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, payouts.ErrInvalidPosting):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to record adjustment"})
+	default:
+		log.Printf("🧾 Admin %d adjusted user %d balance by %d (%s): %s", adminID, req.UserID, req.Amount, req.Reference, req.Memo)
+		c.JSON(http.StatusCreated, gin.H{"adjustment": gin.H{
+			"user_id":    req.UserID,
+			"amount":     req.Amount,
+			"reference":  req.Reference,
+			"memo":       req.Memo,
+			"created_by": admin,
+		}})
+	}
+}
+
+// invariants runs the ledger check now, alerting if it newly fails
+func (h *LedgerHandlers) invariants(c *gin.Context) {
+	if h.checker == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "ledger checks not configured"})
+		return
+	}
+
+	report, err := h.checker.Check(c.Request.Context())
+	switch {
+	case report == nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check ledger"})
+	case err != nil:
+		c.JSON(http.StatusOK, gin.H{"report": report, "warning": "ledger not compared with the node"})
+	default:
+		c.JSON(http.StatusOK, gin.H{"report": report})
+	}
+}
+
+// RegisterLedgerRoutes registers the ledger routes on an admin group
+func RegisterLedgerRoutes(admin *gin.RouterGroup, handlers *LedgerHandlers) {
+	group := admin.Group("/ledger")
+	{
+		group.POST("/adjustments", handlers.adjust)
+		group.GET("/invariants", handlers.invariants)
+	}
+}
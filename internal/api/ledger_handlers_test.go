@@ -0,0 +1,98 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/chimera-pool/chimera-pool-core/internal/payouts"
+)
+
+// fakeLedgerChecker returns a fixed report
+type fakeLedgerChecker struct {
+	report *payouts.LedgerReport
+	err    error
+}
+
+func (f *fakeLedgerChecker) Check(ctx context.Context) (*payouts.LedgerReport, error) {
+	return f.report, f.err
+}
+
+func setupLedgerRouter(ledger LedgerAdjuster, checker LedgerChecker, adminID int64) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	admin := router.Group("/api/v1/admin", func(c *gin.Context) {
+		c.Set("user_id", adminID)
+		c.Next()
+	})
+	RegisterLedgerRoutes(admin, NewLedgerHandlers(ledger, checker))
+	return router
+}
+
+func TestLedgerHandlers_Adjust(t *testing.T) {
+	ctx := context.Background()
+	store := payouts.NewMemoryLedgerStore()
+	ledger := payouts.NewLedger(store, payouts.DefaultLedgerCurrency)
+	router := setupLedgerRouter(ledger, nil, 7)
+	path := "/api/v1/admin/ledger/adjustments"
+
+	w := servePayoutApprovalRequest(router, http.MethodPost, path, `{"user_id":3,"amount":500000,"reference":"ticket-1","memo":"missed share credit"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"created_by":"admin:7"`)
+
+	balance, err := ledger.UserBalance(ctx, 3)
+	require.NoError(t, err)
+	assert.Equal(t, int64(500000), balance)
+	posting, err := store.GetPosting(ctx, payouts.PostingAdjustment, payouts.DefaultLedgerCurrency, "ticket-1")
+	require.NoError(t, err)
+	assert.Equal(t, "admin:7", posting.CreatedBy, "audited")
+	assert.Equal(t, "missed share credit", posting.Memo)
+
+	w = servePayoutApprovalRequest(router, http.MethodPost, path, `{"user_id":3,"amount":500000,"reference":"ticket-1","memo":"again"}`)
+	assert.Equal(t, http.StatusConflict, w.Code, "reference already used")
+
+	w = servePayoutApprovalRequest(router, http.MethodPost, path, `{"user_id":3,"amount":-900000,"reference":"ticket-2","memo":"overdraw"}`)
+	assert.Equal(t, http.StatusConflict, w.Code, "insufficient balance")
+
+	w = servePayoutApprovalRequest(router, http.MethodPost, path, `{"user_id":3,"amount":-200000,"reference":"ticket-3","memo":"double credit"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	balance, err = ledger.UserBalance(ctx, 3)
+	require.NoError(t, err)
+	assert.Equal(t, int64(300000), balance)
+
+	w = servePayoutApprovalRequest(router, http.MethodPost, path, `{"user_id":3,"amount":100000,"reference":"ticket-4"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "memo required")
+	w = servePayoutApprovalRequest(router, http.MethodPost, path, `{"user_id":3,"amount":0,"reference":"ticket-4","memo":"nothing"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "zero amount")
+
+	w = servePayoutApprovalRequest(setupLedgerRouter(ledger, nil, 0), http.MethodPost, path, `{"user_id":3,"amount":1,"reference":"ticket-5","memo":"anonymous"}`)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestLedgerHandlers_Invariants(t *testing.T) {
+	ledger := payouts.NewLedger(payouts.NewMemoryLedgerStore(), payouts.DefaultLedgerCurrency)
+	path := "/api/v1/admin/ledger/invariants"
+
+	w := servePayoutApprovalRequest(setupLedgerRouter(ledger, nil, 1), http.MethodGet, path, "")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	checker := &fakeLedgerChecker{report: &payouts.LedgerReport{Currency: "LTC", OK: true}}
+	w = servePayoutApprovalRequest(setupLedgerRouter(ledger, checker, 1), http.MethodGet, path, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"ok":true`)
+	assert.NotContains(t, w.Body.String(), "warning")
+
+	checker.err = errors.New("wallet unreachable")
+	w = servePayoutApprovalRequest(setupLedgerRouter(ledger, checker, 1), http.MethodGet, path, "")
+	require.Equal(t, http.StatusOK, w.Code, "the ledger itself was still checked")
+	assert.Contains(t, w.Body.String(), "warning")
+
+	checker.report = nil
+	w = servePayoutApprovalRequest(setupLedgerRouter(ledger, checker, 1), http.MethodGet, path, "")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
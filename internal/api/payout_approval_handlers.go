@@ -0,0 +1,143 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/chimera-pool/chimera-pool-core/internal/payouts"
+	"github.com/gin-gonic/gin"
+)
+
+// =============================================================================
+// PAYOUT APPROVAL API HANDLERS (Gin)
+// Admins review payouts held by the payout policy and preview the next
+// payout run without sending it.
+// =============================================================================
+
+// payoutApprovalListLimit is how many held payouts a list request returns
+const payoutApprovalListLimit = 200
+
+// PayoutApprovalManager lists held payouts and records admin decisions (ISP)
+type PayoutApprovalManager interface {
+	ListApprovals(ctx context.Context, status payouts.ApprovalStatus, limit int) ([]payouts.PayoutApproval, error)
+	Approve(ctx context.Context, approvalID, adminID int64, note string) (*payouts.PayoutApproval, error)
+	Reject(ctx context.Context, approvalID, adminID int64, note string) (*payouts.PayoutApproval, error)
+}
+
+// PayoutPlanner works out the next payout run without sending it (ISP)
+type PayoutPlanner interface {
+	PlanPayouts(ctx context.Context) (*payouts.PayoutPlan, error)
+}
+
+// PayoutApprovalHandlers handles payout approval API requests
+type PayoutApprovalHandlers struct {
+	approvals PayoutApprovalManager
+	planner   PayoutPlanner
+}
+
+// NewPayoutApprovalHandlers creates new payout approval handlers. The
+// planner may be nil when no wallet is configured.
+func NewPayoutApprovalHandlers(approvals PayoutApprovalManager, planner PayoutPlanner) *PayoutApprovalHandlers {
+	return &PayoutApprovalHandlers{approvals: approvals, planner: planner}
+}
+
+// payoutDecisionRequest is the body of approve and reject requests
+type payoutDecisionRequest struct {
+	Note string `json:"note"`
+}
+
+// list returns held payouts, pending ones by default
+func (h *PayoutApprovalHandlers) list(c *gin.Context) {
+	status := payouts.ApprovalStatus(c.DefaultQuery("status", string(payouts.ApprovalStatusPending)))
+	switch status {
+	case payouts.ApprovalStatusPending, payouts.ApprovalStatusApproved, payouts.ApprovalStatusRejected:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid status"})
+		return
+	}
+
+	approvals, err := h.approvals.ListApprovals(c.Request.Context(), status, payoutApprovalListLimit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list payout approvals"})
+		return
+	}
+	if approvals == nil {
+		approvals = []payouts.PayoutApproval{}
+	}
+	c.JSON(http.StatusOK, gin.H{"approvals": approvals})
+}
+
+// approve records the admin's approval of a held payout
+func (h *PayoutApprovalHandlers) approve(c *gin.Context) {
+	h.decide(c, h.approvals.Approve)
+}
+
+// reject fails a held payout and refunds it to the miner's balance
+func (h *PayoutApprovalHandlers) reject(c *gin.Context) {
+	h.decide(c, h.approvals.Reject)
+}
+
+// decide applies an admin decision to the :id approval
+func (h *PayoutApprovalHandlers) decide(c *gin.Context, apply func(ctx context.Context, approvalID, adminID int64, note string) (*payouts.PayoutApproval, error)) {
+	adminID := getUserIDFromGinContext(c)
+	if adminID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	approvalID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid approval id"})
+		return
+	}
+
+	var req payoutDecisionRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+			return
+		}
+	}
+
+	approval, err := apply(c.Request.Context(), approvalID, adminID, req.Note)
+	switch {
+	case errors.Is(err, payouts.ErrApprovalNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, payouts.ErrSelfApproval):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, payouts.ErrAlreadyVoted), errors.Is(err, payouts.ErrApprovalResolved):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to record decision"})
+	default:
+		c.JSON(http.StatusOK, gin.H{"approval": approval})
+	}
+}
+
+// dryRun returns what the next payout run would send, hold and skip
+func (h *PayoutApprovalHandlers) dryRun(c *gin.Context) {
+	if h.planner == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "payout wallet not configured"})
+		return
+	}
+
+	plan, err := h.planner.PlanPayouts(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to plan payouts"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"plan": plan})
+}
+
+// RegisterPayoutApprovalRoutes registers the payout approval routes on an
+// admin group
+func RegisterPayoutApprovalRoutes(admin *gin.RouterGroup, handlers *PayoutApprovalHandlers) {
+	group := admin.Group("/payouts")
+	{
+		group.GET("/approvals", handlers.list)
+		group.POST("/approvals/:id/approve", handlers.approve)
+		group.POST("/approvals/:id/reject", handlers.reject)
+		group.GET("/dry-run", handlers.dryRun)
+	}
+}
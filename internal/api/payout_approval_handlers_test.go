@@ -0,0 +1,165 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/chimera-pool/chimera-pool-core/internal/payouts"
+)
+
+// fakePayoutApprovals is an in-memory PayoutApprovalManager
+type fakePayoutApprovals struct {
+	approvals map[int64]*payouts.PayoutApproval
+	votes     map[int64][]int64
+}
+
+func newFakePayoutApprovals() *fakePayoutApprovals {
+	return &fakePayoutApprovals{
+		approvals: map[int64]*payouts.PayoutApproval{
+			1: {ID: 1, Payout: payouts.PendingPayout{ID: 10, UserID: 3, Amount: 2000000000}, Status: payouts.ApprovalStatusPending, RequiredApprovals: 2},
+		},
+		votes: make(map[int64][]int64),
+	}
+}
+
+func (f *fakePayoutApprovals) ListApprovals(ctx context.Context, status payouts.ApprovalStatus, limit int) ([]payouts.PayoutApproval, error) {
+	var out []payouts.PayoutApproval
+	for _, a := range f.approvals {
+		if a.Status == status {
+			out = append(out, *a)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakePayoutApprovals) Approve(ctx context.Context, approvalID, adminID int64, note string) (*payouts.PayoutApproval, error) {
+	a, ok := f.approvals[approvalID]
+	if !ok {
+		return nil, payouts.ErrApprovalNotFound
+	}
+	if a.Payout.UserID == adminID {
+		return nil, payouts.ErrSelfApproval
+	}
+	for _, id := range f.votes[approvalID] {
+		if id == adminID {
+			return nil, payouts.ErrAlreadyVoted
+		}
+	}
+	f.votes[approvalID] = append(f.votes[approvalID], adminID)
+	a.Votes = append(a.Votes, payouts.ApprovalVote{AdminID: adminID, Decision: payouts.ApprovalDecisionApprove, Note: note})
+	if len(f.votes[approvalID]) >= a.RequiredApprovals {
+		a.Status = payouts.ApprovalStatusApproved
+	}
+	return a, nil
+}
+
+func (f *fakePayoutApprovals) Reject(ctx context.Context, approvalID, adminID int64, note string) (*payouts.PayoutApproval, error) {
+	a, ok := f.approvals[approvalID]
+	if !ok {
+		return nil, payouts.ErrApprovalNotFound
+	}
+	if a.Status != payouts.ApprovalStatusPending {
+		return nil, payouts.ErrApprovalResolved
+	}
+	a.Status = payouts.ApprovalStatusRejected
+	return a, nil
+}
+
+// fakePayoutPlanner returns a fixed plan
+type fakePayoutPlanner struct {
+	err error
+}
+
+func (f *fakePayoutPlanner) PlanPayouts(ctx context.Context) (*payouts.PayoutPlan, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &payouts.PayoutPlan{
+		Batched:      true,
+		Transactions: []payouts.PlannedTransaction{{Outputs: map[string]int64{"ltc1qminer": 5000000}, Amount: 5000000}},
+		TotalAmount:  5000000,
+	}, nil
+}
+
+func setupPayoutApprovalRouter(approvals PayoutApprovalManager, planner PayoutPlanner, adminID int64) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	admin := router.Group("/api/v1/admin", func(c *gin.Context) {
+		c.Set("user_id", adminID)
+		c.Next()
+	})
+	RegisterPayoutApprovalRoutes(admin, NewPayoutApprovalHandlers(approvals, planner))
+	return router
+}
+
+func servePayoutApprovalRequest(router *gin.Engine, method, path, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestPayoutApprovalHandlers_ListAndDecide(t *testing.T) {
+	approvals := newFakePayoutApprovals()
+	first := setupPayoutApprovalRouter(approvals, nil, 1)
+	second := setupPayoutApprovalRouter(approvals, nil, 2)
+	owner := setupPayoutApprovalRouter(approvals, nil, 3)
+
+	w := servePayoutApprovalRequest(first, http.MethodGet, "/api/v1/admin/payouts/approvals", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var listed struct {
+		Approvals []payouts.PayoutApproval `json:"approvals"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &listed))
+	require.Len(t, listed.Approvals, 1)
+	assert.Equal(t, int64(10), listed.Approvals[0].Payout.ID)
+
+	w = servePayoutApprovalRequest(first, http.MethodGet, "/api/v1/admin/payouts/approvals?status=bogus", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = servePayoutApprovalRequest(first, http.MethodGet, "/api/v1/admin/payouts/approvals?status=rejected", "")
+	assert.JSONEq(t, `{"approvals":[]}`, w.Body.String())
+
+	w = servePayoutApprovalRequest(owner, http.MethodPost, "/api/v1/admin/payouts/approvals/1/approve", "")
+	assert.Equal(t, http.StatusForbidden, w.Code, "own payout")
+	w = servePayoutApprovalRequest(first, http.MethodPost, "/api/v1/admin/payouts/approvals/9/approve", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = servePayoutApprovalRequest(first, http.MethodPost, "/api/v1/admin/payouts/approvals/1/approve", `{"note":"verified"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"pending"`)
+	w = servePayoutApprovalRequest(first, http.MethodPost, "/api/v1/admin/payouts/approvals/1/approve", "")
+	assert.Equal(t, http.StatusConflict, w.Code, "second vote by the same admin")
+
+	w = servePayoutApprovalRequest(second, http.MethodPost, "/api/v1/admin/payouts/approvals/1/approve", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"approved"`)
+
+	w = servePayoutApprovalRequest(second, http.MethodPost, "/api/v1/admin/payouts/approvals/1/reject", "")
+	assert.Equal(t, http.StatusConflict, w.Code, "already resolved")
+}
+
+func TestPayoutApprovalHandlers_DryRun(t *testing.T) {
+	approvals := newFakePayoutApprovals()
+
+	w := servePayoutApprovalRequest(setupPayoutApprovalRouter(approvals, nil, 1), http.MethodGet, "/api/v1/admin/payouts/dry-run", "")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	w = servePayoutApprovalRequest(setupPayoutApprovalRouter(approvals, &fakePayoutPlanner{}, 1), http.MethodGet, "/api/v1/admin/payouts/dry-run", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"total_amount":5000000`)
+	assert.Contains(t, w.Body.String(), `"ltc1qminer":5000000`)
+
+	planner := &fakePayoutPlanner{err: errors.New("wallet unreachable")}
+	w = servePayoutApprovalRequest(setupPayoutApprovalRouter(approvals, planner, 1), http.MethodGet, "/api/v1/admin/payouts/dry-run", "")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
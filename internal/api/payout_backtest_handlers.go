@@ -0,0 +1,157 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chimera-pool/chimera-pool-core/internal/payouts"
+	"github.com/gin-gonic/gin"
+)
+
+// =============================================================================
+// PAYOUT BACKTEST API HANDLERS (Gin)
+// Miners compare what each payout mode would have paid them; admins replay
+// history under a candidate fee or window configuration before deploying it.
+// =============================================================================
+
+const (
+	// payoutBacktestDefaultDays is how much history a backtest replays by default
+	payoutBacktestDefaultDays = 7
+	// payoutBacktestMaxDays bounds the history a single backtest replays
+	payoutBacktestMaxDays = 31
+)
+
+// PayoutBacktester replays payout history through the payout modes (ISP)
+type PayoutBacktester interface {
+	BaselineConfig() *payouts.PayoutConfig
+	Backtest(ctx context.Context, req payouts.BacktestRequest) (*payouts.BacktestResult, error)
+}
+
+// PayoutBacktestHandlers handles payout backtest API requests
+type PayoutBacktestHandlers struct {
+	backtester PayoutBacktester
+	now        func() time.Time
+}
+
+// NewPayoutBacktestHandlers creates new payout backtest handlers
+func NewPayoutBacktestHandlers(backtester PayoutBacktester) *PayoutBacktestHandlers {
+	return &PayoutBacktestHandlers{backtester: backtester, now: time.Now}
+}
+
+// payoutBacktestRequest is the body of an admin backtest. Candidate holds
+// only the settings that differ from the current configuration.
+type payoutBacktestRequest struct {
+	From      time.Time            `json:"from"`
+	To        time.Time            `json:"to"`
+	Modes     []payouts.PayoutMode `json:"modes"`
+	Candidate json.RawMessage      `json:"candidate"`
+}
+
+// parsePayoutModes parses a comma separated list of payout modes
+func parsePayoutModes(value string) ([]payouts.PayoutMode, bool) {
+	var modes []payouts.PayoutMode
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		mode := payouts.PayoutMode(name)
+		if !mode.IsValid() {
+			return nil, false
+		}
+		modes = append(modes, mode)
+	}
+	return modes, true
+}
+
+// userBacktest returns what each payout mode would have paid the user over
+// the last ?days days
+func (h *PayoutBacktestHandlers) userBacktest(c *gin.Context) {
+	userID := getUserIDFromGinContext(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", strconv.Itoa(payoutBacktestDefaultDays)))
+	if err != nil || days < 1 || days > payoutBacktestMaxDays {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "days must be between 1 and 31"})
+		return
+	}
+	modes, ok := parsePayoutModes(c.Query("modes"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payout mode"})
+		return
+	}
+
+	to := h.now()
+	result, err := h.backtester.Backtest(c.Request.Context(), payouts.BacktestRequest{
+		From:  to.AddDate(0, 0, -days),
+		To:    to,
+		Modes: modes,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to run backtest"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"report": result.Baseline.ForUser(userID)})
+}
+
+// adminBacktest replays the requested history for every user under the
+// current configuration and, when given, the candidate
+func (h *PayoutBacktestHandlers) adminBacktest(c *gin.Context) {
+	var req payoutBacktestRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+			return
+		}
+	}
+
+	if req.To.IsZero() {
+		req.To = h.now()
+	}
+	if req.From.IsZero() {
+		req.From = req.To.AddDate(0, 0, -payoutBacktestDefaultDays)
+	}
+	if !req.From.Before(req.To) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must be before to"})
+		return
+	}
+	if req.To.Sub(req.From) > payoutBacktestMaxDays*24*time.Hour {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "backtest range cannot exceed 31 days"})
+		return
+	}
+
+	backtest := payouts.BacktestRequest{From: req.From, To: req.To, Modes: req.Modes}
+	if len(req.Candidate) > 0 && string(req.Candidate) != "null" {
+		// Unset candidate settings keep their current values
+		backtest.Candidate = h.backtester.BaselineConfig()
+		if err := json.Unmarshal(req.Candidate, backtest.Candidate); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid candidate configuration"})
+			return
+		}
+	}
+
+	result, err := h.backtester.Backtest(c.Request.Context(), backtest)
+	switch {
+	case errors.Is(err, payouts.ErrInvalidBacktest):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to run backtest"})
+	default:
+		c.JSON(http.StatusOK, gin.H{"result": result})
+	}
+}
+
+// RegisterPayoutBacktestRoutes registers the per-user backtest on a user
+// group and the full backtest on an admin group
+func RegisterPayoutBacktestRoutes(user, admin *gin.RouterGroup, handlers *PayoutBacktestHandlers) {
+	user.GET("/payouts/backtest", handlers.userBacktest)
+	admin.POST("/payouts/backtest", handlers.adminBacktest)
+}
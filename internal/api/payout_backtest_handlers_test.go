@@ -0,0 +1,99 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/chimera-pool/chimera-pool-core/internal/payouts"
+)
+
+// payoutBacktestNow is the time the backtest handlers run at in tests
+var payoutBacktestNow = time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+
+// newTestPayoutBacktester returns a backtest service over one found block
+// shared by users 1 and 2
+func newTestPayoutBacktester() *payouts.PayoutBacktestService {
+	found := payoutBacktestNow.Add(-time.Hour)
+	data := &payouts.BacktestData{
+		Blocks: []payouts.BacktestBlock{
+			{Block: payouts.Block{ID: 1, Height: 100, Reward: 1000000, Difficulty: 1000, Status: payouts.BlockStatusConfirmed, Timestamp: found}},
+		},
+		Shares: []payouts.Share{
+			{ID: 1, UserID: 1, Difficulty: 300, IsValid: true, Timestamp: found.Add(-2 * time.Minute)},
+			{ID: 2, UserID: 2, Difficulty: 100, IsValid: true, Timestamp: found.Add(-time.Minute)},
+		},
+	}
+	return payouts.NewPayoutBacktestService(payouts.NewBacktestDataSource(data), payouts.DefaultBacktestConfig())
+}
+
+func setupPayoutBacktestRouter(backtester PayoutBacktester, userID int64) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	setUser := func(c *gin.Context) {
+		c.Set("user_id", userID)
+		c.Next()
+	}
+	handlers := NewPayoutBacktestHandlers(backtester)
+	handlers.now = func() time.Time { return payoutBacktestNow }
+	RegisterPayoutBacktestRoutes(router.Group("/api/v1/user", setUser), router.Group("/api/v1/admin", setUser), handlers)
+	return router
+}
+
+func TestPayoutBacktestHandlers_UserBacktest(t *testing.T) {
+	router := setupPayoutBacktestRouter(newTestPayoutBacktester(), 2)
+
+	w := servePayoutApprovalRequest(router, http.MethodGet, "/api/v1/user/payouts/backtest?modes=pplns,pps", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var body struct {
+		Report payouts.BacktestReport `json:"report"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	require.Len(t, body.Report.Modes, 2)
+	require.Len(t, body.Report.Users, 1, "only the caller's results")
+	assert.Equal(t, int64(2), body.Report.Users[0].UserID)
+	assert.Equal(t, int64(247500), body.Report.Users[0].Earnings[payouts.PayoutModePPLNS])
+
+	w = servePayoutApprovalRequest(router, http.MethodGet, "/api/v1/user/payouts/backtest?days=90", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = servePayoutApprovalRequest(router, http.MethodGet, "/api/v1/user/payouts/backtest?modes=bogus", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = servePayoutApprovalRequest(setupPayoutBacktestRouter(newTestPayoutBacktester(), 0), http.MethodGet, "/api/v1/user/payouts/backtest", "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestPayoutBacktestHandlers_AdminBacktest(t *testing.T) {
+	router := setupPayoutBacktestRouter(newTestPayoutBacktester(), 1)
+
+	w := servePayoutApprovalRequest(router, http.MethodPost, "/api/v1/admin/payouts/backtest",
+		`{"modes":["pplns"],"candidate":{"fee_pplns":2}}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	var body struct {
+		Result payouts.BacktestResult `json:"result"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	require.Len(t, body.Result.Baseline.Users, 2)
+	require.NotNil(t, body.Result.Comparison)
+	require.Len(t, body.Result.Comparison.Modes, 1)
+	comparison := body.Result.Comparison.Modes[0]
+	assert.Equal(t, int64(990000), comparison.BaselinePaid)
+	assert.Equal(t, int64(980000), comparison.CandidatePaid, "unset candidate settings keep their values")
+
+	w = servePayoutApprovalRequest(router, http.MethodPost, "/api/v1/admin/payouts/backtest", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), `"comparison"`)
+
+	w = servePayoutApprovalRequest(router, http.MethodPost, "/api/v1/admin/payouts/backtest", `{"candidate":{"fee_pplns":150}}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "invalid candidate fee")
+	w = servePayoutApprovalRequest(router, http.MethodPost, "/api/v1/admin/payouts/backtest", `{"modes":["bogus"]}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = servePayoutApprovalRequest(router, http.MethodPost, "/api/v1/admin/payouts/backtest",
+		`{"from":"2026-01-01T00:00:00Z","to":"2026-03-01T00:00:00Z"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "range too long")
+}
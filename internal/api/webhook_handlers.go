@@ -0,0 +1,285 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/chimera-pool/chimera-pool-core/internal/notifications"
+	"github.com/gin-gonic/gin"
+)
+
+// =============================================================================
+// WEBHOOK API HANDLERS (Gin)
+// Users manage their own webhook endpoints; admins manage the pool-wide ones
+// that receive every user's alerts of the chosen types.
+// =============================================================================
+
+// webhookDeliveryLogLimit is how many deliveries the delivery log returns
+const webhookDeliveryLogLimit = 100
+
+// webhookScope resolves whose endpoints a request manages: a user ID, or 0
+// for the pool-wide endpoints
+type webhookScope func(c *gin.Context) (ownerID int64, ok bool)
+
+// userWebhookScope scopes requests to the authenticated user's endpoints
+func userWebhookScope(c *gin.Context) (int64, bool) {
+	userID := getUserIDFromGinContext(c)
+	return userID, userID != 0
+}
+
+// poolWebhookScope scopes requests to the pool-wide endpoints (admin routes)
+func poolWebhookScope(c *gin.Context) (int64, bool) {
+	return 0, true
+}
+
+// WebhookHandlers handles webhook endpoint API requests
+type WebhookHandlers struct {
+	repo       notifications.WebhookRepository
+	dispatcher *notifications.WebhookDispatcher
+}
+
+// NewWebhookHandlers creates new webhook handlers
+func NewWebhookHandlers(repo notifications.WebhookRepository, dispatcher *notifications.WebhookDispatcher) *WebhookHandlers {
+	return &WebhookHandlers{repo: repo, dispatcher: dispatcher}
+}
+
+// webhookRequest is the body of create and update requests
+type webhookRequest struct {
+	URL          *string                   `json:"url"`
+	AlertTypes   []notifications.AlertType `json:"alert_types"`
+	Description  *string                   `json:"description"`
+	Enabled      *bool                     `json:"enabled"`
+	RotateSecret bool                      `json:"rotate_secret"`
+}
+
+// apply validates the request and copies it onto an endpoint
+func (r *webhookRequest) apply(endpoint *notifications.WebhookEndpoint) error {
+	if r.URL != nil {
+		if err := notifications.ValidateWebhookURL(*r.URL); err != nil {
+			return err
+		}
+		endpoint.URL = *r.URL
+	}
+	if r.AlertTypes != nil {
+		for _, t := range r.AlertTypes {
+			if !notifications.IsKnownAlertType(t) {
+				return errors.New("unknown alert type: " + string(t))
+			}
+		}
+		endpoint.AlertTypes = r.AlertTypes
+	}
+	if r.Description != nil {
+		endpoint.Description = *r.Description
+	}
+	if r.Enabled != nil {
+		endpoint.Enabled = *r.Enabled
+	}
+	return nil
+}
+
+// list returns the scope's endpoints
+func (h *WebhookHandlers) list(scope webhookScope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ownerID, ok := scope(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+
+		endpoints, err := h.repo.ListWebhookEndpoints(c.Request.Context(), ownerID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list webhooks"})
+			return
+		}
+		if endpoints == nil {
+			endpoints = []*notifications.WebhookEndpoint{}
+		}
+		c.JSON(http.StatusOK, gin.H{"webhooks": endpoints})
+	}
+}
+
+// create registers an endpoint. The secret is only ever returned here and
+// when it is rotated.
+func (h *WebhookHandlers) create(scope webhookScope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ownerID, ok := scope(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+
+		var req webhookRequest
+		if err := c.ShouldBindJSON(&req); err != nil || req.URL == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "url is required"})
+			return
+		}
+		endpoint := &notifications.WebhookEndpoint{UserID: ownerID, Enabled: true}
+		if err := req.apply(endpoint); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		secret, err := notifications.GenerateWebhookSecret()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create webhook"})
+			return
+		}
+		endpoint.Secret = secret
+		if err := h.repo.CreateWebhookEndpoint(c.Request.Context(), endpoint); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create webhook"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"webhook": endpoint, "secret": secret})
+	}
+}
+
+// update changes an endpoint and optionally rotates its secret
+func (h *WebhookHandlers) update(scope webhookScope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		endpoint, ok := h.ownedEndpoint(c, scope)
+		if !ok {
+			return
+		}
+
+		var req webhookRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+			return
+		}
+		if err := req.apply(endpoint); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		response := gin.H{"webhook": endpoint}
+		if req.RotateSecret {
+			secret, err := notifications.GenerateWebhookSecret()
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update webhook"})
+				return
+			}
+			endpoint.Secret = secret
+			response["secret"] = secret
+		}
+		if err := h.repo.UpdateWebhookEndpoint(c.Request.Context(), endpoint); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update webhook"})
+			return
+		}
+
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// delete removes an endpoint and its delivery log
+func (h *WebhookHandlers) delete(scope webhookScope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		endpoint, ok := h.ownedEndpoint(c, scope)
+		if !ok {
+			return
+		}
+
+		if err := h.repo.DeleteWebhookEndpoint(c.Request.Context(), endpoint.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete webhook"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "deleted"})
+	}
+}
+
+// deliveries returns an endpoint's delivery log, newest first
+func (h *WebhookHandlers) deliveries(scope webhookScope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		endpoint, ok := h.ownedEndpoint(c, scope)
+		if !ok {
+			return
+		}
+
+		deliveries, err := h.repo.ListWebhookDeliveries(c.Request.Context(), endpoint.ID, webhookDeliveryLogLimit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list deliveries"})
+			return
+		}
+		if deliveries == nil {
+			deliveries = []*notifications.WebhookDelivery{}
+		}
+		c.JSON(http.StatusOK, gin.H{"deliveries": deliveries})
+	}
+}
+
+// replay queues a failed delivery again
+func (h *WebhookHandlers) replay(scope webhookScope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		endpoint, ok := h.ownedEndpoint(c, scope)
+		if !ok {
+			return
+		}
+
+		deliveryID, err := strconv.ParseInt(c.Param("deliveryId"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid delivery id"})
+			return
+		}
+		delivery, err := h.repo.GetWebhookDelivery(c.Request.Context(), deliveryID)
+		if err != nil || delivery.EndpointID != endpoint.ID {
+			c.JSON(http.StatusNotFound, gin.H{"error": "delivery not found"})
+			return
+		}
+
+		replay, err := h.dispatcher.Replay(c.Request.Context(), delivery.ID)
+		if errors.Is(err, notifications.ErrWebhookNotReplayable) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to replay delivery"})
+			return
+		}
+		c.JSON(http.StatusAccepted, gin.H{"delivery": replay})
+	}
+}
+
+// ownedEndpoint loads the :id endpoint if it belongs to the scope, writing
+// the error response otherwise
+func (h *WebhookHandlers) ownedEndpoint(c *gin.Context, scope webhookScope) (*notifications.WebhookEndpoint, bool) {
+	ownerID, ok := scope(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return nil, false
+	}
+	endpointID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid webhook id"})
+		return nil, false
+	}
+
+	endpoint, err := h.repo.GetWebhookEndpoint(c.Request.Context(), endpointID)
+	if errors.Is(err, notifications.ErrWebhookNotFound) || (err == nil && endpoint.UserID != ownerID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "webhook not found"})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get webhook"})
+		return nil, false
+	}
+	return endpoint, true
+}
+
+// RegisterWebhookRoutes registers the user webhook routes on an
+// authenticated group and the pool-wide ones on an admin group
+func RegisterWebhookRoutes(user *gin.RouterGroup, admin *gin.RouterGroup, handlers *WebhookHandlers) {
+	register := func(r *gin.RouterGroup, scope webhookScope) {
+		webhooks := r.Group("/webhooks")
+		{
+			webhooks.GET("", handlers.list(scope))
+			webhooks.POST("", handlers.create(scope))
+			webhooks.PUT("/:id", handlers.update(scope))
+			webhooks.DELETE("/:id", handlers.delete(scope))
+			webhooks.GET("/:id/deliveries", handlers.deliveries(scope))
+			webhooks.POST("/:id/deliveries/:deliveryId/replay", handlers.replay(scope))
+		}
+	}
+	register(user, userWebhookScope)
+	register(admin, poolWebhookScope)
+}
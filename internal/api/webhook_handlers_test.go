@@ -0,0 +1,185 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/chimera-pool/chimera-pool-core/internal/notifications"
+)
+
+// fakeWebhookRepository is an in-memory notifications.WebhookRepository
+type fakeWebhookRepository struct {
+	endpoints  map[int64]*notifications.WebhookEndpoint
+	deliveries map[int64]*notifications.WebhookDelivery
+	nextID     int64
+}
+
+func newFakeWebhookRepository() *fakeWebhookRepository {
+	return &fakeWebhookRepository{
+		endpoints:  make(map[int64]*notifications.WebhookEndpoint),
+		deliveries: make(map[int64]*notifications.WebhookDelivery),
+	}
+}
+
+func (f *fakeWebhookRepository) CreateWebhookEndpoint(ctx context.Context, e *notifications.WebhookEndpoint) error {
+	f.nextID++
+	e.ID = f.nextID
+	f.endpoints[e.ID] = e
+	return nil
+}
+
+func (f *fakeWebhookRepository) UpdateWebhookEndpoint(ctx context.Context, e *notifications.WebhookEndpoint) error {
+	f.endpoints[e.ID] = e
+	return nil
+}
+
+func (f *fakeWebhookRepository) DeleteWebhookEndpoint(ctx context.Context, id int64) error {
+	delete(f.endpoints, id)
+	return nil
+}
+
+func (f *fakeWebhookRepository) GetWebhookEndpoint(ctx context.Context, id int64) (*notifications.WebhookEndpoint, error) {
+	if e, ok := f.endpoints[id]; ok {
+		copied := *e
+		return &copied, nil
+	}
+	return nil, notifications.ErrWebhookNotFound
+}
+
+func (f *fakeWebhookRepository) ListWebhookEndpoints(ctx context.Context, userID int64) ([]*notifications.WebhookEndpoint, error) {
+	var out []*notifications.WebhookEndpoint
+	for _, e := range f.endpoints {
+		if e.UserID == userID {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeWebhookRepository) EnqueueWebhookDelivery(ctx context.Context, d *notifications.WebhookDelivery) error {
+	f.nextID++
+	d.ID = f.nextID
+	f.deliveries[d.ID] = d
+	return nil
+}
+
+func (f *fakeWebhookRepository) ClaimWebhookDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*notifications.WebhookDelivery, error) {
+	return nil, nil
+}
+
+func (f *fakeWebhookRepository) UpdateWebhookDelivery(ctx context.Context, d *notifications.WebhookDelivery) error {
+	return nil
+}
+
+func (f *fakeWebhookRepository) GetWebhookDelivery(ctx context.Context, id int64) (*notifications.WebhookDelivery, error) {
+	if d, ok := f.deliveries[id]; ok {
+		return d, nil
+	}
+	return nil, notifications.ErrWebhookNotFound
+}
+
+func (f *fakeWebhookRepository) ListWebhookDeliveries(ctx context.Context, endpointID int64, limit int) ([]*notifications.WebhookDelivery, error) {
+	var out []*notifications.WebhookDelivery
+	for _, d := range f.deliveries {
+		if d.EndpointID == endpointID {
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
+
+func setupWebhookRouter(repo *fakeWebhookRepository, userID int64) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	authed := router.Group("/api/v1", func(c *gin.Context) {
+		c.Set("user_id", userID)
+		c.Next()
+	})
+	handlers := NewWebhookHandlers(repo, notifications.NewWebhookDispatcher(nil, repo))
+	RegisterWebhookRoutes(authed.Group("/user"), authed.Group("/admin"), handlers)
+	return router
+}
+
+func serveWebhookRequest(router *gin.Engine, method, path, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestWebhookHandlers_CreateAndList(t *testing.T) {
+	repo := newFakeWebhookRepository()
+	router := setupWebhookRouter(repo, 7)
+
+	w := serveWebhookRequest(router, http.MethodPost, "/api/v1/user/webhooks",
+		`{"url":"https://ops.example.com/pool","alert_types":["block_found","payout_sent"]}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var created struct {
+		Webhook notifications.WebhookEndpoint `json:"webhook"`
+		Secret  string                        `json:"secret"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.True(t, strings.HasPrefix(created.Secret, "whsec_"))
+	assert.Equal(t, int64(7), created.Webhook.UserID)
+	assert.True(t, created.Webhook.Enabled)
+	assert.NotContains(t, w.Body.String(), `"Secret"`)
+
+	w = serveWebhookRequest(router, http.MethodGet, "/api/v1/user/webhooks", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "https://ops.example.com/pool")
+	assert.NotContains(t, w.Body.String(), created.Secret, "secrets are not listed")
+
+	// Admin routes manage pool-wide endpoints, separate from the user's
+	w = serveWebhookRequest(router, http.MethodGet, "/api/v1/admin/webhooks", "")
+	assert.JSONEq(t, `{"webhooks":[]}`, w.Body.String())
+
+	w = serveWebhookRequest(router, http.MethodPost, "/api/v1/user/webhooks", `{"url":"ftp://example.com"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = serveWebhookRequest(router, http.MethodPost, "/api/v1/user/webhooks",
+		`{"url":"https://example.com","alert_types":["nope"]}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestWebhookHandlers_OwnershipAndReplay(t *testing.T) {
+	repo := newFakeWebhookRepository()
+	mine := &notifications.WebhookEndpoint{UserID: 7, URL: "https://example.com/a", Secret: "s", Enabled: true}
+	theirs := &notifications.WebhookEndpoint{UserID: 8, URL: "https://example.com/b", Secret: "s", Enabled: true}
+	repo.CreateWebhookEndpoint(context.Background(), mine)
+	repo.CreateWebhookEndpoint(context.Background(), theirs)
+	failed := &notifications.WebhookDelivery{EndpointID: mine.ID, EventID: "alert-1", Status: notifications.WebhookFailed}
+	delivered := &notifications.WebhookDelivery{EndpointID: mine.ID, EventID: "alert-2", Status: notifications.WebhookDelivered}
+	repo.EnqueueWebhookDelivery(context.Background(), failed)
+	repo.EnqueueWebhookDelivery(context.Background(), delivered)
+	router := setupWebhookRouter(repo, 7)
+
+	w := serveWebhookRequest(router, http.MethodDelete, "/api/v1/user/webhooks/2", "")
+	assert.Equal(t, http.StatusNotFound, w.Code, "another user's webhook")
+	w = serveWebhookRequest(router, http.MethodPut, "/api/v1/admin/webhooks/1", `{"enabled":false}`)
+	assert.Equal(t, http.StatusNotFound, w.Code, "user webhooks are not pool-wide")
+
+	w = serveWebhookRequest(router, http.MethodPut, "/api/v1/user/webhooks/1", `{"enabled":false,"rotate_secret":true}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"secret":"whsec_`)
+	assert.False(t, repo.endpoints[mine.ID].Enabled)
+
+	w = serveWebhookRequest(router, http.MethodGet, "/api/v1/user/webhooks/1/deliveries", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "alert-1")
+
+	w = serveWebhookRequest(router, http.MethodPost, "/api/v1/user/webhooks/1/deliveries/4/replay", "")
+	assert.Equal(t, http.StatusConflict, w.Code, "delivered webhooks are not replayed")
+	w = serveWebhookRequest(router, http.MethodPost, "/api/v1/user/webhooks/1/deliveries/3/replay", "")
+	require.Equal(t, http.StatusAccepted, w.Code)
+	assert.Contains(t, w.Body.String(), `"replay_of":3`)
+	assert.Len(t, repo.deliveries, 3)
+}
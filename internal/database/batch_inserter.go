@@ -40,10 +40,15 @@ func DefaultBatchInserterConfig() BatchInserterConfig {
 	}
 }
 
+// BatchExecer executes batch statements (ISP: satisfied by *ConnectionPool)
+type BatchExecer interface {
+	Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
 // ShareBatchInserter handles high-throughput share insertion
 type ShareBatchInserter struct {
 	config BatchInserterConfig
-	pool   *ConnectionPool
+	pool   BatchExecer
 
 	// Batching
 	pending   []*Share
@@ -75,7 +80,7 @@ type BatchInsertStats struct {
 }
 
 // NewShareBatchInserter creates a new batch inserter
-func NewShareBatchInserter(pool *ConnectionPool, config BatchInserterConfig) *ShareBatchInserter {
+func NewShareBatchInserter(pool BatchExecer, config BatchInserterConfig) *ShareBatchInserter {
 	if config.BatchSize <= 0 {
 		config.BatchSize = 1000
 	}
@@ -286,7 +291,7 @@ func (bi *ShareBatchInserter) insertBatch(shares []*Share) error {
 func (bi *ShareBatchInserter) buildBatchInsert(shares []*Share) (string, []interface{}) {
 	// Build: INSERT INTO shares (cols) VALUES ($1,$2,...), ($3,$4,...), ...
 
-	cols := []string{"miner_id", "user_id", "difficulty", "is_valid", "nonce", "hash", "timestamp"}
+	cols := []string{"miner_id", "user_id", "difficulty", "is_valid", "nonce", "hash", "timestamp", "network_id", "reject_reason", "solo"}
 	colCount := len(cols)
 
 	var sb strings.Builder
@@ -324,12 +329,23 @@ func (bi *ShareBatchInserter) buildBatchInsert(shares []*Share) (string, []inter
 			share.Nonce,
 			share.Hash,
 			timestamp,
+			share.NetworkID,
+			nullIfEmpty(share.RejectReason),
+			share.Solo,
 		)
 	}
 
 	return sb.String(), args
 }
 
+// nullIfEmpty stores empty strings as NULL
+func nullIfEmpty(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
 // =============================================================================
 // GENERIC BATCH INSERTER
 // For other tables beyond shares
@@ -44,6 +44,9 @@ func TestShareBatchInserter_BuildBatchInsert(t *testing.T) {
 			Nonce:      "xyz789",
 			Hash:       "ghi012",
 			Timestamp:  time.Date(2025, 1, 1, 13, 0, 0, 0, time.UTC),
+
+			RejectReason: "stale",
+			Solo:         true,
 		},
 	}
 
@@ -51,13 +54,13 @@ func TestShareBatchInserter_BuildBatchInsert(t *testing.T) {
 
 	// Verify query structure
 	assert.Contains(t, query, "INSERT INTO shares")
-	assert.Contains(t, query, "miner_id, user_id, difficulty, is_valid, nonce, hash, timestamp")
+	assert.Contains(t, query, "miner_id, user_id, difficulty, is_valid, nonce, hash, timestamp, network_id, reject_reason, solo")
 	assert.Contains(t, query, "VALUES")
 	assert.Contains(t, query, "$1")
-	assert.Contains(t, query, "$14") // 2 rows * 7 columns = 14 params
+	assert.Contains(t, query, "$20") // 2 rows * 10 columns = 20 params
 
 	// Verify args count
-	assert.Len(t, args, 14)
+	assert.Len(t, args, 20)
 
 	// Verify first row values
 	assert.Equal(t, int64(1), args[0])
@@ -66,10 +69,14 @@ func TestShareBatchInserter_BuildBatchInsert(t *testing.T) {
 	assert.Equal(t, true, args[3])
 	assert.Equal(t, "abc123", args[4])
 	assert.Equal(t, "def456", args[5])
+	assert.Nil(t, args[8], "valid shares have no reject reason")
+	assert.Equal(t, false, args[9])
 
 	// Verify second row values
-	assert.Equal(t, int64(2), args[7])
-	assert.Equal(t, int64(20), args[8])
+	assert.Equal(t, int64(2), args[10])
+	assert.Equal(t, int64(20), args[11])
+	assert.Equal(t, "stale", args[18])
+	assert.Equal(t, true, args[19])
 }
 
 func TestShareBatchInserter_BuildBatchInsert_EmptyTimestamp(t *testing.T) {
@@ -299,9 +306,9 @@ func TestShareBatchInserter_BuildBatchInsert_SingleShare(t *testing.T) {
 
 	assert.Contains(t, query, "INSERT INTO shares")
 	assert.Contains(t, query, "$1")
-	assert.Contains(t, query, "$7")
-	assert.NotContains(t, query, "$8") // Only 7 columns for 1 row
-	assert.Len(t, args, 7)
+	assert.Contains(t, query, "$10")
+	assert.NotContains(t, query, "$11") // Only 10 columns for 1 row
+	assert.Len(t, args, 10)
 }
 
 func TestShareBatchInserter_BuildBatchInsert_ManyShares(t *testing.T) {
@@ -326,8 +333,8 @@ func TestShareBatchInserter_BuildBatchInsert_ManyShares(t *testing.T) {
 	query, args := bi.buildBatchInsert(shares)
 
 	assert.Contains(t, query, "INSERT INTO shares")
-	assert.Contains(t, query, "$700") // 100 rows * 7 columns
-	assert.Len(t, args, 700)
+	assert.Contains(t, query, "$1000") // 100 rows * 10 columns
+	assert.Len(t, args, 1000)
 }
 
 func TestNewGenericBatchInserter(t *testing.T) {
@@ -41,6 +41,12 @@ type Share struct {
 	Nonce      string     `json:"nonce" db:"nonce"`
 	Hash       string     `json:"hash" db:"hash"`
 	NetworkID  *uuid.UUID `json:"network_id" db:"network_id"`
+
+	// RejectReason is set for invalid shares, e.g. "stale" or "duplicate"
+	RejectReason string `json:"reject_reason,omitempty" db:"reject_reason"`
+
+	// Solo is set for shares mined on a port that forces solo mode
+	Solo bool `json:"solo" db:"solo"`
 }
 
 // Block represents a found block
@@ -2,6 +2,7 @@ package health
 
 import (
 	"fmt"
+	"io"
 	"net/http"
 	"sync"
 	"time"
@@ -19,6 +20,24 @@ type PoolMetrics struct {
 	WalletBalanceLTC float64 // Wallet balance in LTC
 	Difficulty       float64 // Current network difficulty
 	BlockHeight      int64   // Current block height
+
+	Upstreams []UpstreamMetrics // Upstream node status, best first
+}
+
+// UpstreamMetrics describes one upstream node the pool sends RPC calls to.
+type UpstreamMetrics struct {
+	Name         string        // Node label (host:port)
+	Priority     int           // Position in the configured order (0 = primary)
+	Active       bool          // Node currently selected
+	Healthy      bool          // Node is answering
+	AtTip        bool          // Node is at the best known tip
+	Score        float64       // Health score (0-100)
+	TipHeight    int64         // Last reported chain height
+	BlocksBehind int64         // Blocks behind the best known tip
+	Latency      time.Duration // Average RPC latency
+	ErrorRate    float64       // Average error rate (0-1)
+	Requests     int64         // Total requests sent
+	Errors       int64         // Total failed requests
 }
 
 // PoolMetricsProvider interface for getting pool metrics
@@ -303,10 +322,60 @@ func (p *PrometheusExporter) metricsHandler(w http.ResponseWriter, r *http.Reque
 			fmt.Fprintf(w, "# HELP chimera_pool_block_height Current block height\n")
 			fmt.Fprintf(w, "# TYPE chimera_pool_block_height gauge\n")
 			fmt.Fprintf(w, "chimera_pool_block_height %d\n", poolMetrics.BlockHeight)
+
+			// Upstream nodes
+			if len(poolMetrics.Upstreams) > 0 {
+				writeUpstreamMetrics(w, poolMetrics.Upstreams)
+			}
+		}
+	}
+}
+
+// writeUpstreamMetrics writes one family per upstream metric, labelled by node.
+func writeUpstreamMetrics(w io.Writer, upstreams []UpstreamMetrics) {
+	families := []struct {
+		name, help, kind string
+		value            func(u UpstreamMetrics) string
+	}{
+		{"chimera_upstream_active", "Upstream node currently selected (1=active)", "gauge",
+			func(u UpstreamMetrics) string { return boolMetric(u.Active) }},
+		{"chimera_upstream_healthy", "Upstream node answering RPC (1=healthy)", "gauge",
+			func(u UpstreamMetrics) string { return boolMetric(u.Healthy) }},
+		{"chimera_upstream_at_tip", "Upstream node at the best known tip (1=at tip)", "gauge",
+			func(u UpstreamMetrics) string { return boolMetric(u.AtTip) }},
+		{"chimera_upstream_score", "Upstream node health score (0-100)", "gauge",
+			func(u UpstreamMetrics) string { return fmt.Sprintf("%.2f", u.Score) }},
+		{"chimera_upstream_tip_height", "Chain height reported by the upstream node", "gauge",
+			func(u UpstreamMetrics) string { return fmt.Sprintf("%d", u.TipHeight) }},
+		{"chimera_upstream_blocks_behind", "Blocks the upstream node is behind the best known tip", "gauge",
+			func(u UpstreamMetrics) string { return fmt.Sprintf("%d", u.BlocksBehind) }},
+		{"chimera_upstream_latency_seconds", "Average upstream RPC latency in seconds", "gauge",
+			func(u UpstreamMetrics) string { return fmt.Sprintf("%.6f", u.Latency.Seconds()) }},
+		{"chimera_upstream_error_rate", "Average upstream RPC error rate (0-1)", "gauge",
+			func(u UpstreamMetrics) string { return fmt.Sprintf("%.4f", u.ErrorRate) }},
+		{"chimera_upstream_requests_total", "Total RPC requests sent to the upstream node", "counter",
+			func(u UpstreamMetrics) string { return fmt.Sprintf("%d", u.Requests) }},
+		{"chimera_upstream_errors_total", "Total failed RPC requests to the upstream node", "counter",
+			func(u UpstreamMetrics) string { return fmt.Sprintf("%d", u.Errors) }},
+	}
+
+	for _, family := range families {
+		fmt.Fprintf(w, "# HELP %s %s\n", family.name, family.help)
+		fmt.Fprintf(w, "# TYPE %s %s\n", family.name, family.kind)
+		for _, u := range upstreams {
+			fmt.Fprintf(w, "%s{node=\"%s\",priority=\"%d\"} %s\n", family.name, u.Name, u.Priority, family.value(u))
 		}
 	}
 }
 
+// boolMetric renders a boolean as a Prometheus 0/1 value.
+func boolMetric(v bool) string {
+	if v {
+		return "1"
+	}
+	return "0"
+}
+
 // healthHandler handles /health endpoint for simple health checks.
 func (p *PrometheusExporter) healthHandler(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
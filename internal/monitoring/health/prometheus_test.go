@@ -218,3 +218,22 @@ func TestPrometheusExporter_MultipleNodes(t *testing.T) {
 	assert.Contains(t, body, `node="blockdag"`)
 	assert.Contains(t, body, "chimera_health_monitor_nodes_monitored 2")
 }
+
+func TestWriteUpstreamMetrics(t *testing.T) {
+	var b strings.Builder
+	writeUpstreamMetrics(&b, []UpstreamMetrics{
+		{Name: "ltc-1:9332", Priority: 0, Active: true, Healthy: true, AtTip: true, Score: 92.5, TipHeight: 3026575, Latency: 40 * time.Millisecond, Requests: 10},
+		{Name: "ltc-2:9332", Priority: 1, TipHeight: 3026573, BlocksBehind: 2, ErrorRate: 0.5, Requests: 4, Errors: 2},
+	})
+	body := b.String()
+
+	assert.Contains(t, body, `chimera_upstream_active{node="ltc-1:9332",priority="0"} 1`)
+	assert.Contains(t, body, `chimera_upstream_at_tip{node="ltc-2:9332",priority="1"} 0`)
+	assert.Contains(t, body, `chimera_upstream_blocks_behind{node="ltc-2:9332",priority="1"} 2`)
+	assert.Contains(t, body, `chimera_upstream_latency_seconds{node="ltc-1:9332",priority="0"} 0.040000`)
+	assert.Contains(t, body, `chimera_upstream_errors_total{node="ltc-2:9332",priority="1"} 2`)
+
+	// Each family is declared once regardless of node count
+	assert.Equal(t, 1, strings.Count(body, "# TYPE chimera_upstream_score gauge"))
+	assert.Equal(t, strings.Count(body, "# HELP"), strings.Count(body, "# TYPE"))
+}
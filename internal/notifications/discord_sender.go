@@ -196,13 +196,15 @@ func (s *DiscordWebhookSender) getTitle(alert *Alert) string {
 		emoji = "🎉"
 	case AlertTypePayoutSent:
 		emoji = "💰"
+	case AlertTypePayoutConfirmed:
+		emoji = "✅"
 	case AlertTypePayoutFailed:
 		emoji = "❌"
 	case AlertTypeHashrateDrop:
 		emoji = "📉"
 	case AlertTypeLowBalance:
 		emoji = "⚠️"
-	case AlertTypePoolDown:
+	case AlertTypePoolDown, AlertTypeLedgerImbalance:
 		emoji = "🚨"
 	default:
 		emoji = "ℹ️"
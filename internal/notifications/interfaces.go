@@ -13,15 +13,17 @@ import (
 type AlertType string
 
 const (
-	AlertTypeWorkerOffline  AlertType = "worker_offline"
-	AlertTypeWorkerOnline   AlertType = "worker_online"
-	AlertTypeHashrateDrop   AlertType = "hashrate_drop"
-	AlertTypeBlockFound     AlertType = "block_found"
-	AlertTypePayoutSent     AlertType = "payout_sent"
-	AlertTypePayoutFailed   AlertType = "payout_failed"
-	AlertTypeLowBalance     AlertType = "low_balance"
-	AlertTypePoolDown       AlertType = "pool_down"
-	AlertTypeHighRejectRate AlertType = "high_reject_rate"
+	AlertTypeWorkerOffline   AlertType = "worker_offline"
+	AlertTypeWorkerOnline    AlertType = "worker_online"
+	AlertTypeHashrateDrop    AlertType = "hashrate_drop"
+	AlertTypeBlockFound      AlertType = "block_found"
+	AlertTypePayoutSent      AlertType = "payout_sent"
+	AlertTypePayoutFailed    AlertType = "payout_failed"
+	AlertTypePayoutConfirmed AlertType = "payout_confirmed"
+	AlertTypeLowBalance      AlertType = "low_balance"
+	AlertTypePoolDown        AlertType = "pool_down"
+	AlertTypeHighRejectRate  AlertType = "high_reject_rate"
+	AlertTypeLedgerImbalance AlertType = "ledger_imbalance"
 )
 
 // AlertSeverity represents alert severity levels
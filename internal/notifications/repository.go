@@ -91,7 +91,7 @@ func (r *SQLNotificationRepository) GetUsersForAlert(ctx context.Context, alertT
 		condition = "hashrate_drop_enabled = true"
 	case AlertTypeBlockFound:
 		condition = "block_found_enabled = true"
-	case AlertTypePayoutSent, AlertTypePayoutFailed:
+	case AlertTypePayoutSent, AlertTypePayoutFailed, AlertTypePayoutConfirmed:
 		condition = "payout_enabled = true"
 	default:
 		condition = "true" // All users for unknown types
@@ -3,6 +3,8 @@ package notifications
 import (
 	"context"
 	"fmt"
+	"log"
+	"strings"
 	"sync"
 	"time"
 
@@ -85,13 +87,22 @@ func (s *NotificationService) SetRepository(repo AlertRepository) {
 	s.repository = repo
 }
 
-// SendAlert sends an alert to a user based on their preferences
+// PoolAlertSender delivers alerts to pool-wide destinations, e.g. the
+// webhook endpoints admins register (ISP)
+type PoolAlertSender interface {
+	SendPoolAlert(ctx context.Context, alert *Alert) error
+}
+
+// broadcastKey marks SendAlert calls made by SendAlertToAll, which has
+// already sent the alert to pool-wide destinations
+type broadcastKey struct{}
+
+// SendAlert sends an alert to a user based on their preferences. Pool-wide
+// destinations receive it regardless of the user's preferences.
 func (s *NotificationService) SendAlert(ctx context.Context, alert *Alert) ([]NotificationResult, error) {
-	if alert.ID == "" {
-		alert.ID = uuid.New().String()
-	}
-	if alert.CreatedAt.IsZero() {
-		alert.CreatedAt = time.Now()
+	s.stampAlert(alert)
+	if ctx.Value(broadcastKey{}) == nil {
+		s.sendPoolAlert(ctx, alert)
 	}
 
 	// Get user preferences
@@ -143,6 +154,11 @@ func (s *NotificationService) SendAlertToAll(ctx context.Context, alert *Alert)
 		return nil, fmt.Errorf("failed to get users for alert: %w", err)
 	}
 
+	// Pool-wide destinations get the alert once, not once per user
+	s.stampAlert(alert)
+	s.sendPoolAlert(ctx, alert)
+	ctx = context.WithValue(ctx, broadcastKey{}, true)
+
 	var allResults []NotificationResult
 	for _, user := range users {
 		alert.UserID = user.UserID
@@ -156,6 +172,12 @@ func (s *NotificationService) SendAlertToAll(ctx context.Context, alert *Alert)
 	return allResults, nil
 }
 
+// SendPoolAlert sends an alert only to pool-wide destinations
+func (s *NotificationService) SendPoolAlert(ctx context.Context, alert *Alert) {
+	s.stampAlert(alert)
+	s.sendPoolAlert(ctx, alert)
+}
+
 // GetStats returns notification statistics
 func (s *NotificationService) GetStats() AlertStats {
 	s.mu.RLock()
@@ -192,6 +214,34 @@ func (s *NotificationService) getUserPreferences(ctx context.Context, userID int
 	return s.preferences.GetUserPreferences(ctx, userID)
 }
 
+func (s *NotificationService) stampAlert(alert *Alert) {
+	if alert.ID == "" {
+		alert.ID = uuid.New().String()
+	}
+	if alert.CreatedAt.IsZero() {
+		alert.CreatedAt = time.Now()
+	}
+}
+
+// sendPoolAlert hands an alert to every registered sender that has pool-wide
+// destinations
+func (s *NotificationService) sendPoolAlert(ctx context.Context, alert *Alert) {
+	s.mu.RLock()
+	var pool []PoolAlertSender
+	for _, sender := range s.senders {
+		if p, ok := sender.(PoolAlertSender); ok && sender.IsAvailable() {
+			pool = append(pool, p)
+		}
+	}
+	s.mu.RUnlock()
+
+	for _, p := range pool {
+		if err := p.SendPoolAlert(ctx, alert); err != nil {
+			log.Printf("⚠️ Failed to send pool alert %s: %v", alert.ID, err)
+		}
+	}
+}
+
 func (s *NotificationService) isAlertTypeEnabled(prefs *UserNotificationSettings, alertType AlertType) bool {
 	switch alertType {
 	case AlertTypeWorkerOffline, AlertTypeWorkerOnline:
@@ -200,7 +250,7 @@ func (s *NotificationService) isAlertTypeEnabled(prefs *UserNotificationSettings
 		return prefs.HashrateDropEnabled
 	case AlertTypeBlockFound:
 		return prefs.BlockFoundEnabled
-	case AlertTypePayoutSent, AlertTypePayoutFailed:
+	case AlertTypePayoutSent, AlertTypePayoutFailed, AlertTypePayoutConfirmed:
 		return prefs.PayoutEnabled
 	default:
 		return true // Unknown types default to enabled
@@ -219,6 +269,10 @@ func (s *NotificationService) getEnabledChannels(prefs *UserNotificationSettings
 	if prefs.SMSEnabled && prefs.PhoneNumber != "" {
 		channels = append(channels, ChannelSMS)
 	}
+	// Webhook endpoints choose their own alert types
+	if s.HasSender(ChannelWebhook) {
+		channels = append(channels, ChannelWebhook)
+	}
 
 	return channels
 }
@@ -427,6 +481,26 @@ func NewPayoutFailedAlert(userID int64, amount int64, reason string) *Alert {
 	}
 }
 
+// NewPayoutConfirmedAlert creates a payout confirmed alert
+func NewPayoutConfirmedAlert(userID int64, amount int64, address, txHash string, confirmations int64) *Alert {
+	amountFloat := float64(amount) / 100000000
+	return &Alert{
+		ID:       uuid.New().String(),
+		Type:     AlertTypePayoutConfirmed,
+		Severity: SeverityInfo,
+		Title:    "Payout Confirmed",
+		Message:  fmt.Sprintf("Payout of %.8f LTC to %s confirmed (%d confirmations)", amountFloat, address, confirmations),
+		UserID:   userID,
+		Metadata: map[string]string{
+			"amount":        fmt.Sprintf("%d", amount),
+			"address":       address,
+			"tx_hash":       txHash,
+			"confirmations": fmt.Sprintf("%d", confirmations),
+		},
+		CreatedAt: time.Now(),
+	}
+}
+
 // NewHashrateDropAlert creates a hashrate drop alert
 func NewHashrateDropAlert(userID, workerID int64, workerName string, dropPercent int) *Alert {
 	return &Alert{
@@ -462,3 +536,19 @@ func NewLowBalanceAlert(balance int64, threshold int64) *Alert {
 		CreatedAt: time.Now(),
 	}
 }
+
+// NewLedgerImbalanceAlert creates an alert for a failed balance ledger check
+func NewLedgerImbalanceAlert(currency string, problems []string) *Alert {
+	return &Alert{
+		ID:       uuid.New().String(),
+		Type:     AlertTypeLedgerImbalance,
+		Severity: SeverityCritical,
+		Title:    "Ledger Check Failed",
+		Message:  fmt.Sprintf("%s balance ledger check failed: %s", currency, strings.Join(problems, "; ")),
+		Metadata: map[string]string{
+			"currency": currency,
+			"problems": fmt.Sprintf("%d", len(problems)),
+		},
+		CreatedAt: time.Now(),
+	}
+}
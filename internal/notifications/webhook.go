@@ -0,0 +1,444 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// =============================================================================
+// SIGNED OUTBOUND WEBHOOKS
+// Alerts are POSTed as JSON to registered endpoints. Each request carries
+// X-Chimera-Signature: sha256=HMAC-SHA256(secret, "<timestamp>.<body>") and
+// the timestamp in X-Chimera-Timestamp, so receivers can verify the sender
+// and reject stale replays. Deliveries are queued in the repository and
+// retried with exponential backoff until MaxAttempts, after which they are
+// failed and can be replayed by the endpoint's owner.
+// =============================================================================
+
+// Webhook request headers
+const (
+	WebhookSignatureHeader = "X-Chimera-Signature"
+	WebhookTimestampHeader = "X-Chimera-Timestamp"
+	WebhookEventHeader     = "X-Chimera-Event"
+	WebhookDeliveryHeader  = "X-Chimera-Delivery"
+)
+
+// WebhookDeliveryStatus is the state of a queued delivery
+type WebhookDeliveryStatus string
+
+const (
+	WebhookPending   WebhookDeliveryStatus = "pending"
+	WebhookDelivered WebhookDeliveryStatus = "delivered"
+	WebhookFailed    WebhookDeliveryStatus = "failed"
+)
+
+// Webhook errors
+var (
+	ErrWebhookNotFound      = errors.New("webhook not found")
+	ErrWebhookNotReplayable = errors.New("only failed deliveries can be replayed")
+	ErrWebhookInvalidURL    = errors.New("webhook URL must be an absolute http or https URL")
+	ErrWebhookPrivateTarget = errors.New("webhook target resolves to a private address")
+)
+
+// WebhookEndpoint is a URL that receives signed alert payloads
+type WebhookEndpoint struct {
+	ID          int64       `json:"id"`
+	UserID      int64       `json:"user_id,omitempty"` // 0 for pool-wide endpoints managed by admins
+	URL         string      `json:"url"`
+	Secret      string      `json:"-"`
+	AlertTypes  []AlertType `json:"alert_types"` // Empty receives every alert type
+	Description string      `json:"description,omitempty"`
+	Enabled     bool        `json:"enabled"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+}
+
+// Accepts reports whether the endpoint subscribes to an alert type
+func (e *WebhookEndpoint) Accepts(alertType AlertType) bool {
+	if len(e.AlertTypes) == 0 {
+		return true
+	}
+	for _, t := range e.AlertTypes {
+		if t == alertType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery is one alert queued for one endpoint. The latest attempt's
+// outcome is kept on the row, which makes the delivery list the endpoint's log.
+type WebhookDelivery struct {
+	ID             int64                 `json:"id"`
+	EndpointID     int64                 `json:"endpoint_id"`
+	EventID        string                `json:"event_id"` // Alert ID, stable across retries and replays
+	AlertType      AlertType             `json:"alert_type"`
+	Payload        json.RawMessage       `json:"payload"`
+	Status         WebhookDeliveryStatus `json:"status"`
+	Attempts       int                   `json:"attempts"`
+	NextAttemptAt  time.Time             `json:"next_attempt_at"`
+	LastStatusCode int                   `json:"last_status_code,omitempty"`
+	LastError      string                `json:"last_error,omitempty"`
+	ReplayOf       int64                 `json:"replay_of,omitempty"` // Delivery this one replays
+	CreatedAt      time.Time             `json:"created_at"`
+	DeliveredAt    *time.Time            `json:"delivered_at,omitempty"`
+}
+
+// WebhookPayload is the JSON body POSTed to endpoints
+type WebhookPayload struct {
+	ID        string    `json:"id"`
+	Type      AlertType `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	Alert     *Alert    `json:"alert"`
+}
+
+// WebhookRepository stores endpoints and the delivery queue (ISP)
+type WebhookRepository interface {
+	CreateWebhookEndpoint(ctx context.Context, endpoint *WebhookEndpoint) error
+	UpdateWebhookEndpoint(ctx context.Context, endpoint *WebhookEndpoint) error
+	DeleteWebhookEndpoint(ctx context.Context, endpointID int64) error
+	GetWebhookEndpoint(ctx context.Context, endpointID int64) (*WebhookEndpoint, error)
+	// ListWebhookEndpoints returns a user's endpoints, or the pool-wide ones for userID 0
+	ListWebhookEndpoints(ctx context.Context, userID int64) ([]*WebhookEndpoint, error)
+
+	EnqueueWebhookDelivery(ctx context.Context, delivery *WebhookDelivery) error
+	// ClaimWebhookDeliveries returns pending deliveries due by now and pushes
+	// their next attempt back by lease so that other workers skip them
+	ClaimWebhookDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*WebhookDelivery, error)
+	UpdateWebhookDelivery(ctx context.Context, delivery *WebhookDelivery) error
+	GetWebhookDelivery(ctx context.Context, deliveryID int64) (*WebhookDelivery, error)
+	ListWebhookDeliveries(ctx context.Context, endpointID int64, limit int) ([]*WebhookDelivery, error)
+}
+
+// WebhookConfig holds webhook delivery configuration
+type WebhookConfig struct {
+	MaxAttempts         int           // Attempts before a delivery fails
+	InitialBackoff      time.Duration // Wait after the first failed attempt, doubled each time
+	MaxBackoff          time.Duration // Longest wait between attempts
+	Timeout             time.Duration // Per-request timeout
+	PollInterval        time.Duration // How often the queue is checked
+	BatchSize           int           // Deliveries claimed per poll
+	Lease               time.Duration // How long a claimed delivery is hidden from other workers
+	AllowPrivateTargets bool          // Permit loopback and private addresses (tests, local receivers)
+}
+
+// DefaultWebhookConfig returns sensible defaults
+func DefaultWebhookConfig() *WebhookConfig {
+	return &WebhookConfig{
+		MaxAttempts:    8,
+		InitialBackoff: 30 * time.Second,
+		MaxBackoff:     6 * time.Hour,
+		Timeout:        10 * time.Second,
+		PollInterval:   5 * time.Second,
+		BatchSize:      50,
+		Lease:          time.Minute,
+	}
+}
+
+// WebhookDispatcher queues alerts for webhook endpoints and delivers them.
+// It is the NotificationSender for ChannelWebhook; a process that only
+// queues alerts need not Run it.
+type WebhookDispatcher struct {
+	config *WebhookConfig
+	repo   WebhookRepository
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewWebhookDispatcher creates a webhook dispatcher
+func NewWebhookDispatcher(config *WebhookConfig, repo WebhookRepository) *WebhookDispatcher {
+	if config == nil {
+		config = DefaultWebhookConfig()
+	}
+	dialer := &net.Dialer{Timeout: config.Timeout}
+	if !config.AllowPrivateTargets {
+		// Checked on the resolved address so DNS can't point us inside
+		dialer.Control = rejectPrivateTargets
+	}
+	return &WebhookDispatcher{
+		config: config,
+		repo:   repo,
+		client: &http.Client{
+			Timeout:   config.Timeout,
+			Transport: &http.Transport{DialContext: dialer.DialContext},
+			// Redirects are not followed; a 3xx is a failed attempt
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		now: time.Now,
+	}
+}
+
+// Channel returns the notification channel
+func (d *WebhookDispatcher) Channel() NotificationChannel {
+	return ChannelWebhook
+}
+
+// IsAvailable returns whether the dispatcher has a repository
+func (d *WebhookDispatcher) IsAvailable() bool {
+	return d.repo != nil
+}
+
+// Send queues an alert for the alert user's endpoints. Endpoints are looked
+// up per user, so the destination is unused.
+func (d *WebhookDispatcher) Send(ctx context.Context, alert *Alert, destination string) error {
+	if alert.UserID == 0 {
+		return nil
+	}
+	return d.enqueue(ctx, alert, alert.UserID)
+}
+
+// SendBatch queues several alerts
+func (d *WebhookDispatcher) SendBatch(ctx context.Context, alerts []*Alert, destination string) error {
+	for _, alert := range alerts {
+		if err := d.Send(ctx, alert, destination); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SendPoolAlert queues an alert for the pool-wide endpoints
+func (d *WebhookDispatcher) SendPoolAlert(ctx context.Context, alert *Alert) error {
+	return d.enqueue(ctx, alert, 0)
+}
+
+// enqueue queues an alert for the owner's enabled endpoints that subscribe to it
+func (d *WebhookDispatcher) enqueue(ctx context.Context, alert *Alert, ownerID int64) error {
+	endpoints, err := d.repo.ListWebhookEndpoints(ctx, ownerID)
+	if err != nil {
+		return fmt.Errorf("list webhook endpoints: %w", err)
+	}
+
+	var payload []byte
+	for _, endpoint := range endpoints {
+		if !endpoint.Enabled || !endpoint.Accepts(alert.Type) {
+			continue
+		}
+		if payload == nil {
+			payload, err = json.Marshal(WebhookPayload{ID: alert.ID, Type: alert.Type, CreatedAt: alert.CreatedAt, Alert: alert})
+			if err != nil {
+				return fmt.Errorf("marshal webhook payload: %w", err)
+			}
+		}
+		delivery := &WebhookDelivery{
+			EndpointID:    endpoint.ID,
+			EventID:       alert.ID,
+			AlertType:     alert.Type,
+			Payload:       payload,
+			Status:        WebhookPending,
+			NextAttemptAt: d.now(),
+		}
+		if err := d.repo.EnqueueWebhookDelivery(ctx, delivery); err != nil {
+			return fmt.Errorf("enqueue webhook delivery: %w", err)
+		}
+	}
+	return nil
+}
+
+// Replay queues a failed delivery again as a new delivery, leaving the
+// original in the log
+func (d *WebhookDispatcher) Replay(ctx context.Context, deliveryID int64) (*WebhookDelivery, error) {
+	original, err := d.repo.GetWebhookDelivery(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if original.Status != WebhookFailed {
+		return nil, ErrWebhookNotReplayable
+	}
+
+	replay := &WebhookDelivery{
+		EndpointID:    original.EndpointID,
+		EventID:       original.EventID,
+		AlertType:     original.AlertType,
+		Payload:       original.Payload,
+		Status:        WebhookPending,
+		NextAttemptAt: d.now(),
+		ReplayOf:      original.ID,
+	}
+	if err := d.repo.EnqueueWebhookDelivery(ctx, replay); err != nil {
+		return nil, fmt.Errorf("enqueue webhook replay: %w", err)
+	}
+	return replay, nil
+}
+
+// Run delivers queued webhooks until the context is cancelled
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		d.ProcessDue(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessDue attempts every due delivery once and returns how many were
+// attempted
+func (d *WebhookDispatcher) ProcessDue(ctx context.Context) int {
+	deliveries, err := d.repo.ClaimWebhookDeliveries(ctx, d.now(), d.config.Lease, d.config.BatchSize)
+	if err != nil {
+		log.Printf("⚠️ Failed to claim webhook deliveries: %v", err)
+		return 0
+	}
+
+	for _, delivery := range deliveries {
+		d.attempt(ctx, delivery)
+		if err := d.repo.UpdateWebhookDelivery(ctx, delivery); err != nil {
+			log.Printf("⚠️ Failed to update webhook delivery %d: %v", delivery.ID, err)
+		}
+	}
+	return len(deliveries)
+}
+
+// attempt makes one delivery attempt and records its outcome on the delivery
+func (d *WebhookDispatcher) attempt(ctx context.Context, delivery *WebhookDelivery) {
+	endpoint, err := d.repo.GetWebhookEndpoint(ctx, delivery.EndpointID)
+	if err == nil && !endpoint.Enabled {
+		err = errors.New("endpoint disabled")
+	}
+	if err != nil {
+		// Nothing to retry against; the owner can replay once it is fixed
+		delivery.Status = WebhookFailed
+		delivery.LastError = err.Error()
+		return
+	}
+
+	delivery.Attempts++
+	statusCode, err := d.post(ctx, endpoint, delivery)
+	delivery.LastStatusCode = statusCode
+	if err == nil {
+		now := d.now()
+		delivery.Status = WebhookDelivered
+		delivery.LastError = ""
+		delivery.DeliveredAt = &now
+		return
+	}
+
+	delivery.LastError = err.Error()
+	if delivery.Attempts >= d.config.MaxAttempts {
+		delivery.Status = WebhookFailed
+		return
+	}
+	delivery.Status = WebhookPending
+	delivery.NextAttemptAt = d.now().Add(d.backoff(delivery.Attempts))
+}
+
+// post sends a delivery's payload, signed with the endpoint's secret
+func (d *WebhookDispatcher) post(ctx context.Context, endpoint *WebhookEndpoint, delivery *WebhookDelivery) (int, error) {
+	timestamp := d.now().Unix()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "ChimeraPool-Webhooks/1.0")
+	req.Header.Set(WebhookEventHeader, string(delivery.AlertType))
+	req.Header.Set(WebhookDeliveryHeader, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(WebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(endpoint.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("endpoint returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff is the wait after the given number of failed attempts
+func (d *WebhookDispatcher) backoff(attempts int) time.Duration {
+	wait := d.config.InitialBackoff
+	for i := 1; i < attempts && wait < d.config.MaxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > d.config.MaxBackoff {
+		wait = d.config.MaxBackoff
+	}
+	return wait
+}
+
+// =============================================================================
+// SIGNING AND VALIDATION
+// =============================================================================
+
+// SignWebhookPayload returns the signature header value for a payload
+func SignWebhookPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhookSignature checks a signature header value against a payload
+func VerifyWebhookSignature(secret string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(SignWebhookPayload(secret, timestamp, body)), []byte(signature))
+}
+
+// GenerateWebhookSecret returns a new random endpoint secret
+func GenerateWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate webhook secret: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// ValidateWebhookURL checks that a URL can be registered as an endpoint
+func ValidateWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrWebhookInvalidURL
+	}
+	return nil
+}
+
+// IsKnownAlertType reports whether an alert type exists
+func IsKnownAlertType(alertType AlertType) bool {
+	switch alertType {
+	case AlertTypeWorkerOffline, AlertTypeWorkerOnline, AlertTypeHashrateDrop,
+		AlertTypeBlockFound, AlertTypePayoutSent, AlertTypePayoutFailed, AlertTypePayoutConfirmed,
+		AlertTypeLowBalance, AlertTypePoolDown, AlertTypeHighRejectRate, AlertTypeLedgerImbalance:
+		return true
+	}
+	return false
+}
+
+// rejectPrivateTargets refuses connections to loopback, private and
+// link-local addresses
+func rejectPrivateTargets(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() {
+		return ErrWebhookPrivateTarget
+	}
+	return nil
+}
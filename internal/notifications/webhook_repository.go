@@ -0,0 +1,282 @@
+package notifications
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// =============================================================================
+// WEBHOOK REPOSITORY IMPLEMENTATION
+// =============================================================================
+
+const webhookEndpointColumns = `
+	id, user_id, url, secret, alert_types, description, enabled, created_at, updated_at`
+
+const webhookDeliveryColumns = `
+	id, endpoint_id, event_id, alert_type, payload, status, attempts, next_attempt_at,
+	last_status_code, last_error, replay_of, created_at, delivered_at`
+
+// CreateWebhookEndpoint stores a new endpoint and sets its ID
+func (r *SQLNotificationRepository) CreateWebhookEndpoint(ctx context.Context, endpoint *WebhookEndpoint) error {
+	query := `
+		INSERT INTO webhook_endpoints (user_id, url, secret, alert_types, description, enabled)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, updated_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query,
+		nullInt64(endpoint.UserID), endpoint.URL, endpoint.Secret,
+		pq.Array(alertTypeStrings(endpoint.AlertTypes)), endpoint.Description, endpoint.Enabled,
+	).Scan(&endpoint.ID, &endpoint.CreatedAt, &endpoint.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create webhook endpoint: %w", err)
+	}
+	return nil
+}
+
+// UpdateWebhookEndpoint saves an endpoint's URL, secret, alert types,
+// description and enabled flag
+func (r *SQLNotificationRepository) UpdateWebhookEndpoint(ctx context.Context, endpoint *WebhookEndpoint) error {
+	query := `
+		UPDATE webhook_endpoints
+		SET url = $2, secret = $3, alert_types = $4, description = $5, enabled = $6
+		WHERE id = $1
+		RETURNING updated_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query,
+		endpoint.ID, endpoint.URL, endpoint.Secret,
+		pq.Array(alertTypeStrings(endpoint.AlertTypes)), endpoint.Description, endpoint.Enabled,
+	).Scan(&endpoint.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return ErrWebhookNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update webhook endpoint: %w", err)
+	}
+	return nil
+}
+
+// DeleteWebhookEndpoint removes an endpoint and its delivery log
+func (r *SQLNotificationRepository) DeleteWebhookEndpoint(ctx context.Context, endpointID int64) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM webhook_endpoints WHERE id = $1`, endpointID)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook endpoint: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+// GetWebhookEndpoint retrieves an endpoint by ID
+func (r *SQLNotificationRepository) GetWebhookEndpoint(ctx context.Context, endpointID int64) (*WebhookEndpoint, error) {
+	query := `SELECT ` + webhookEndpointColumns + ` FROM webhook_endpoints WHERE id = $1`
+
+	endpoint, err := scanWebhookEndpoint(r.db.QueryRowContext(ctx, query, endpointID))
+	if err == sql.ErrNoRows {
+		return nil, ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook endpoint: %w", err)
+	}
+	return endpoint, nil
+}
+
+// ListWebhookEndpoints returns a user's endpoints, or the pool-wide ones for userID 0
+func (r *SQLNotificationRepository) ListWebhookEndpoints(ctx context.Context, userID int64) ([]*WebhookEndpoint, error) {
+	query := `SELECT ` + webhookEndpointColumns + ` FROM webhook_endpoints
+		WHERE user_id IS NOT DISTINCT FROM $1
+		ORDER BY id`
+
+	rows, err := r.db.QueryContext(ctx, query, nullInt64(userID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook endpoints: %w", err)
+	}
+	defer rows.Close()
+
+	var endpoints []*WebhookEndpoint
+	for rows.Next() {
+		endpoint, err := scanWebhookEndpoint(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook endpoint: %w", err)
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+	return endpoints, rows.Err()
+}
+
+// EnqueueWebhookDelivery queues a delivery and sets its ID
+func (r *SQLNotificationRepository) EnqueueWebhookDelivery(ctx context.Context, delivery *WebhookDelivery) error {
+	query := `
+		INSERT INTO webhook_deliveries (endpoint_id, event_id, alert_type, payload, status, next_attempt_at, replay_of)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query,
+		delivery.EndpointID, delivery.EventID, delivery.AlertType, []byte(delivery.Payload),
+		delivery.Status, delivery.NextAttemptAt, nullInt64(delivery.ReplayOf),
+	).Scan(&delivery.ID, &delivery.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// ClaimWebhookDeliveries returns pending deliveries due by now, oldest first,
+// and pushes their next attempt back by lease. SKIP LOCKED lets several API
+// instances share the queue.
+func (r *SQLNotificationRepository) ClaimWebhookDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*WebhookDelivery, error) {
+	query := `
+		UPDATE webhook_deliveries
+		SET next_attempt_at = $2
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= $1
+			ORDER BY next_attempt_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + webhookDeliveryColumns
+
+	rows, err := r.db.QueryContext(ctx, query, now, now.Add(lease), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+	return scanWebhookDeliveries(rows)
+}
+
+// UpdateWebhookDelivery records the outcome of a delivery attempt
+func (r *SQLNotificationRepository) UpdateWebhookDelivery(ctx context.Context, delivery *WebhookDelivery) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = $2, attempts = $3, next_attempt_at = $4,
+		    last_status_code = $5, last_error = $6, delivered_at = $7
+		WHERE id = $1
+	`
+
+	var lastStatusCode sql.NullInt32
+	if delivery.LastStatusCode != 0 {
+		lastStatusCode = sql.NullInt32{Int32: int32(delivery.LastStatusCode), Valid: true}
+	}
+	_, err := r.db.ExecContext(ctx, query,
+		delivery.ID, delivery.Status, delivery.Attempts, delivery.NextAttemptAt,
+		lastStatusCode, nullString(delivery.LastError), delivery.DeliveredAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// GetWebhookDelivery retrieves a delivery by ID
+func (r *SQLNotificationRepository) GetWebhookDelivery(ctx context.Context, deliveryID int64) (*WebhookDelivery, error) {
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries WHERE id = $1`
+
+	rows, err := r.db.QueryContext(ctx, query, deliveryID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook delivery: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries, err := scanWebhookDeliveries(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(deliveries) == 0 {
+		return nil, ErrWebhookNotFound
+	}
+	return deliveries[0], nil
+}
+
+// ListWebhookDeliveries returns an endpoint's most recent deliveries
+func (r *SQLNotificationRepository) ListWebhookDeliveries(ctx context.Context, endpointID int64, limit int) ([]*WebhookDelivery, error) {
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries
+		WHERE endpoint_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2`
+
+	rows, err := r.db.QueryContext(ctx, query, endpointID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+	return scanWebhookDeliveries(rows)
+}
+
+// =============================================================================
+// HELPERS
+// =============================================================================
+
+// rowScanner is satisfied by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanWebhookEndpoint(row rowScanner) (*WebhookEndpoint, error) {
+	var endpoint WebhookEndpoint
+	var userID sql.NullInt64
+	var alertTypes []string
+
+	err := row.Scan(
+		&endpoint.ID, &userID, &endpoint.URL, &endpoint.Secret, pq.Array(&alertTypes),
+		&endpoint.Description, &endpoint.Enabled, &endpoint.CreatedAt, &endpoint.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint.UserID = userID.Int64
+	for _, t := range alertTypes {
+		endpoint.AlertTypes = append(endpoint.AlertTypes, AlertType(t))
+	}
+	return &endpoint, nil
+}
+
+func scanWebhookDeliveries(rows *sql.Rows) ([]*WebhookDelivery, error) {
+	var deliveries []*WebhookDelivery
+	for rows.Next() {
+		var delivery WebhookDelivery
+		var payload []byte
+		var lastStatusCode sql.NullInt32
+		var lastError sql.NullString
+		var replayOf sql.NullInt64
+		var deliveredAt sql.NullTime
+
+		err := rows.Scan(
+			&delivery.ID, &delivery.EndpointID, &delivery.EventID, &delivery.AlertType,
+			&payload, &delivery.Status, &delivery.Attempts, &delivery.NextAttemptAt,
+			&lastStatusCode, &lastError, &replayOf, &delivery.CreatedAt, &deliveredAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+
+		delivery.Payload = payload
+		delivery.LastStatusCode = int(lastStatusCode.Int32)
+		delivery.LastError = lastError.String
+		delivery.ReplayOf = replayOf.Int64
+		if deliveredAt.Valid {
+			delivery.DeliveredAt = &deliveredAt.Time
+		}
+		deliveries = append(deliveries, &delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+func alertTypeStrings(types []AlertType) []string {
+	out := make([]string, len(types))
+	for i, t := range types {
+		out[i] = string(t)
+	}
+	return out
+}
+
+// Ensure SQLNotificationRepository implements WebhookRepository
+var _ WebhookRepository = (*SQLNotificationRepository)(nil)
@@ -0,0 +1,309 @@
+package notifications
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// WEBHOOK TESTS
+// =============================================================================
+
+func TestWebhookSignature(t *testing.T) {
+	body := []byte(`{"id":"alert-1"}`)
+	signature := SignWebhookPayload("whsec_test", 1700000000, body)
+
+	assert.Regexp(t, `^sha256=[0-9a-f]{64}$`, signature)
+	assert.True(t, VerifyWebhookSignature("whsec_test", 1700000000, body, signature))
+	assert.False(t, VerifyWebhookSignature("whsec_other", 1700000000, body, signature))
+	assert.False(t, VerifyWebhookSignature("whsec_test", 1700000001, body, signature), "timestamp is signed")
+	assert.False(t, VerifyWebhookSignature("whsec_test", 1700000000, []byte(`{}`), signature))
+
+	secret, err := GenerateWebhookSecret()
+	require.NoError(t, err)
+	assert.Len(t, secret, len("whsec_")+64)
+}
+
+func TestWebhookDispatcher_Enqueue(t *testing.T) {
+	repo := newMockWebhookRepository()
+	user := repo.addEndpoint(&WebhookEndpoint{UserID: 7, URL: "https://example.com/a", Enabled: true,
+		AlertTypes: []AlertType{AlertTypePayoutSent}})
+	repo.addEndpoint(&WebhookEndpoint{UserID: 7, URL: "https://example.com/b", Enabled: false})
+	pool := repo.addEndpoint(&WebhookEndpoint{URL: "https://ops.example.com", Enabled: true,
+		AlertTypes: []AlertType{AlertTypeBlockFound, AlertTypePayoutSent}})
+	repo.addEndpoint(&WebhookEndpoint{UserID: 8, URL: "https://example.com/c", Enabled: true})
+
+	service := NewNotificationService(DefaultNotificationConfig())
+	service.RegisterSender(NewWebhookDispatcher(DefaultWebhookConfig(), repo))
+	service.SetPreferencesProvider(&mockPreferences{settings: DefaultUserNotificationSettings(7, "")})
+
+	// A user's payout reaches their endpoint and the pool endpoint
+	results, err := service.SendAlert(context.Background(), NewPayoutSentAlert(7, 100000000, "ltc1q", "txid"))
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, ChannelWebhook, results[0].Channel)
+	assert.True(t, results[0].Success)
+	assert.ElementsMatch(t, []int64{user.ID, pool.ID}, repo.queuedEndpoints())
+
+	// A broadcast reaches the pool endpoint once
+	repo.deliveries = nil
+	_, err = service.SendAlertToAll(context.Background(), NewBlockFoundAlert(100, 625000000, "LTC"))
+	require.NoError(t, err)
+	assert.Equal(t, []int64{pool.ID}, repo.queuedEndpoints())
+
+	// Worker alerts have no subscriber
+	repo.deliveries = nil
+	_, err = service.SendAlert(context.Background(), NewWorkerOfflineAlert(7, 1, "rig1"))
+	require.NoError(t, err)
+	assert.Empty(t, repo.queuedEndpoints())
+}
+
+func TestWebhookDispatcher_Deliver(t *testing.T) {
+	var mu sync.Mutex
+	var received []*http.Request
+	var bodies [][]byte
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		received = append(received, r)
+		bodies = append(bodies, body)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	repo := newMockWebhookRepository()
+	endpoint := repo.addEndpoint(&WebhookEndpoint{UserID: 7, URL: server.URL, Secret: "whsec_test", Enabled: true})
+	config := DefaultWebhookConfig()
+	config.AllowPrivateTargets = true
+	config.MaxAttempts = 3
+	dispatcher := NewWebhookDispatcher(config, repo)
+	now := time.Unix(1700000000, 0)
+	dispatcher.now = func() time.Time { return now }
+
+	alert := NewPayoutSentAlert(7, 100000000, "ltc1q", "txid")
+	require.NoError(t, dispatcher.Send(context.Background(), alert, ""))
+
+	t.Run("delivers signed payloads", func(t *testing.T) {
+		assert.Equal(t, 1, dispatcher.ProcessDue(context.Background()))
+		require.Len(t, received, 1)
+		req := received[0]
+		assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+		assert.Equal(t, string(AlertTypePayoutSent), req.Header.Get(WebhookEventHeader))
+		timestamp, err := strconv.ParseInt(req.Header.Get(WebhookTimestampHeader), 10, 64)
+		require.NoError(t, err)
+		assert.True(t, VerifyWebhookSignature("whsec_test", timestamp, bodies[0], req.Header.Get(WebhookSignatureHeader)))
+		assert.Contains(t, string(bodies[0]), `"id":"`+alert.ID+`"`)
+
+		delivery := repo.deliveries[0]
+		assert.Equal(t, WebhookDelivered, delivery.Status)
+		assert.Equal(t, 1, delivery.Attempts)
+		assert.Equal(t, http.StatusOK, delivery.LastStatusCode)
+		assert.Zero(t, dispatcher.ProcessDue(context.Background()), "delivered webhooks are not retried")
+	})
+
+	t.Run("retries with backoff then fails", func(t *testing.T) {
+		status = http.StatusInternalServerError
+		require.NoError(t, dispatcher.Send(context.Background(), alert, ""))
+		delivery := repo.deliveries[1]
+
+		assert.Equal(t, 1, dispatcher.ProcessDue(context.Background()))
+		assert.Equal(t, WebhookPending, delivery.Status)
+		assert.Equal(t, now.Add(30*time.Second), delivery.NextAttemptAt)
+		assert.Contains(t, delivery.LastError, "500")
+		assert.Zero(t, dispatcher.ProcessDue(context.Background()), "not due yet")
+
+		now = now.Add(30 * time.Second)
+		dispatcher.ProcessDue(context.Background())
+		assert.Equal(t, now.Add(time.Minute), delivery.NextAttemptAt, "backoff doubles")
+
+		now = now.Add(time.Minute)
+		dispatcher.ProcessDue(context.Background())
+		assert.Equal(t, WebhookFailed, delivery.Status)
+		assert.Equal(t, 3, delivery.Attempts)
+	})
+
+	t.Run("replays failed deliveries", func(t *testing.T) {
+		_, err := dispatcher.Replay(context.Background(), repo.deliveries[0].ID)
+		assert.ErrorIs(t, err, ErrWebhookNotReplayable)
+
+		status = http.StatusNoContent
+		replay, err := dispatcher.Replay(context.Background(), repo.deliveries[1].ID)
+		require.NoError(t, err)
+		assert.Equal(t, repo.deliveries[1].ID, replay.ReplayOf)
+		assert.Equal(t, alert.ID, replay.EventID)
+		assert.Equal(t, endpoint.ID, replay.EndpointID)
+
+		assert.Equal(t, 1, dispatcher.ProcessDue(context.Background()))
+		assert.Equal(t, WebhookDelivered, replay.Status)
+		assert.Equal(t, WebhookFailed, repo.deliveries[1].Status, "the original stays in the log")
+	})
+
+	t.Run("fails deliveries to disabled endpoints", func(t *testing.T) {
+		require.NoError(t, dispatcher.Send(context.Background(), alert, ""))
+		endpoint.Enabled = false
+		dispatcher.ProcessDue(context.Background())
+		delivery := repo.deliveries[len(repo.deliveries)-1]
+		assert.Equal(t, WebhookFailed, delivery.Status)
+		assert.Zero(t, delivery.Attempts)
+	})
+}
+
+func TestWebhookDispatcher_RejectsPrivateTargets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("private target was reached")
+	}))
+	defer server.Close()
+
+	repo := newMockWebhookRepository()
+	repo.addEndpoint(&WebhookEndpoint{UserID: 7, URL: server.URL, Enabled: true})
+	dispatcher := NewWebhookDispatcher(DefaultWebhookConfig(), repo)
+	require.NoError(t, dispatcher.Send(context.Background(), NewWorkerOfflineAlert(7, 1, "rig1"), ""))
+
+	dispatcher.ProcessDue(context.Background())
+	assert.Equal(t, WebhookPending, repo.deliveries[0].Status)
+	assert.Contains(t, repo.deliveries[0].LastError, ErrWebhookPrivateTarget.Error())
+}
+
+func TestValidateWebhookURL(t *testing.T) {
+	assert.NoError(t, ValidateWebhookURL("https://ops.example.com/hooks/pool"))
+	assert.NoError(t, ValidateWebhookURL("http://ops.example.com"))
+	assert.ErrorIs(t, ValidateWebhookURL("ftp://ops.example.com"), ErrWebhookInvalidURL)
+	assert.ErrorIs(t, ValidateWebhookURL("/relative"), ErrWebhookInvalidURL)
+	assert.ErrorIs(t, ValidateWebhookURL("https://"), ErrWebhookInvalidURL)
+}
+
+// =============================================================================
+// MOCK WEBHOOK REPOSITORY
+// =============================================================================
+
+type mockWebhookRepository struct {
+	mu         sync.Mutex
+	endpoints  []*WebhookEndpoint
+	deliveries []*WebhookDelivery
+	nextID     int64
+}
+
+func newMockWebhookRepository() *mockWebhookRepository {
+	return &mockWebhookRepository{}
+}
+
+func (m *mockWebhookRepository) addEndpoint(endpoint *WebhookEndpoint) *WebhookEndpoint {
+	m.CreateWebhookEndpoint(context.Background(), endpoint)
+	return endpoint
+}
+
+func (m *mockWebhookRepository) queuedEndpoints() []int64 {
+	var ids []int64
+	for _, d := range m.deliveries {
+		ids = append(ids, d.EndpointID)
+	}
+	return ids
+}
+
+func (m *mockWebhookRepository) CreateWebhookEndpoint(ctx context.Context, endpoint *WebhookEndpoint) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.nextID++
+	endpoint.ID = m.nextID
+	m.endpoints = append(m.endpoints, endpoint)
+	return nil
+}
+
+func (m *mockWebhookRepository) UpdateWebhookEndpoint(ctx context.Context, endpoint *WebhookEndpoint) error {
+	return nil
+}
+
+func (m *mockWebhookRepository) DeleteWebhookEndpoint(ctx context.Context, endpointID int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, e := range m.endpoints {
+		if e.ID == endpointID {
+			m.endpoints = append(m.endpoints[:i], m.endpoints[i+1:]...)
+			return nil
+		}
+	}
+	return ErrWebhookNotFound
+}
+
+func (m *mockWebhookRepository) GetWebhookEndpoint(ctx context.Context, endpointID int64) (*WebhookEndpoint, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, e := range m.endpoints {
+		if e.ID == endpointID {
+			return e, nil
+		}
+	}
+	return nil, ErrWebhookNotFound
+}
+
+func (m *mockWebhookRepository) ListWebhookEndpoints(ctx context.Context, userID int64) ([]*WebhookEndpoint, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []*WebhookEndpoint
+	for _, e := range m.endpoints {
+		if e.UserID == userID {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockWebhookRepository) EnqueueWebhookDelivery(ctx context.Context, delivery *WebhookDelivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.nextID++
+	delivery.ID = m.nextID
+	m.deliveries = append(m.deliveries, delivery)
+	return nil
+}
+
+func (m *mockWebhookRepository) ClaimWebhookDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []*WebhookDelivery
+	for _, d := range m.deliveries {
+		if d.Status == WebhookPending && !d.NextAttemptAt.After(now) && len(out) < limit {
+			d.NextAttemptAt = now.Add(lease)
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockWebhookRepository) UpdateWebhookDelivery(ctx context.Context, delivery *WebhookDelivery) error {
+	return nil
+}
+
+func (m *mockWebhookRepository) GetWebhookDelivery(ctx context.Context, deliveryID int64) (*WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, d := range m.deliveries {
+		if d.ID == deliveryID {
+			return d, nil
+		}
+	}
+	return nil, ErrWebhookNotFound
+}
+
+func (m *mockWebhookRepository) ListWebhookDeliveries(ctx context.Context, endpointID int64, limit int) ([]*WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []*WebhookDelivery
+	for i := len(m.deliveries) - 1; i >= 0 && len(out) < limit; i-- {
+		if m.deliveries[i].EndpointID == endpointID {
+			out = append(out, m.deliveries[i])
+		}
+	}
+	return out, nil
+}
@@ -4,36 +4,65 @@ import (
 	"context"
 	"sync"
 	"time"
+
+	"github.com/chimera-pool/chimera-pool-core/internal/stratum/events"
+	"github.com/chimera-pool/chimera-pool-core/internal/stratum/hashrate"
 )
 
 // =============================================================================
-// WORKER MONITOR - DETECTS OFFLINE WORKERS
+// WORKER MONITOR - DETECTS OFFLINE WORKERS AND HASHRATE DROPS
+// Fed either by RecordActivity or by the stratum event bus. With the bus a
+// worker whose last connection closes goes offline once its user's offline
+// delay has passed, and each worker's recent hashrate is compared against a
+// longer baseline window.
 // =============================================================================
 
 // WorkerMonitorConfig holds configuration for worker monitoring
 type WorkerMonitorConfig struct {
-	OfflineThreshold time.Duration // Time without activity before considered offline
-	CheckInterval    time.Duration // How often to check for offline workers
-	AlertCooldown    time.Duration // Minimum time between alerts for same worker
+	OfflineThreshold    time.Duration // Time without activity before considered offline
+	CheckInterval       time.Duration // How often to check for offline workers
+	AlertCooldown       time.Duration // Minimum time between alerts for same worker
+	HashrateDropPercent int           // Drop from baseline that raises an alert
+	CurrentWindow       time.Duration // Window for a worker's current hashrate
+	BaselineWindow      time.Duration // Window for a worker's baseline hashrate
+	BaselineWarmup      time.Duration // Shares needed for this long before drops are checked
+	ThresholdCacheTTL   time.Duration // How long per-user thresholds are cached
 }
 
 // DefaultWorkerMonitorConfig returns sensible defaults
 func DefaultWorkerMonitorConfig() *WorkerMonitorConfig {
 	return &WorkerMonitorConfig{
-		OfflineThreshold: 5 * time.Minute,
-		CheckInterval:    30 * time.Second,
-		AlertCooldown:    30 * time.Minute,
+		OfflineThreshold:    5 * time.Minute,
+		CheckInterval:       10 * time.Second,
+		AlertCooldown:       30 * time.Minute,
+		HashrateDropPercent: 50,
+		CurrentWindow:       5 * time.Minute,
+		BaselineWindow:      time.Hour,
+		BaselineWarmup:      15 * time.Minute,
+		ThresholdCacheTTL:   5 * time.Minute,
 	}
 }
 
 // WorkerState tracks the state of a single worker
 type WorkerState struct {
-	WorkerID    int64      `json:"worker_id"`
-	UserID      int64      `json:"user_id"`
-	WorkerName  string     `json:"worker_name"`
-	LastSeen    time.Time  `json:"last_seen"`
-	IsOnline    bool       `json:"is_online"`
-	AlertSentAt *time.Time `json:"alert_sent_at,omitempty"`
+	WorkerID            int64      `json:"worker_id"`
+	UserID              int64      `json:"user_id"`
+	WorkerName          string     `json:"worker_name"`
+	LastSeen            time.Time  `json:"last_seen"`
+	IsOnline            bool       `json:"is_online"`
+	AlertSentAt         *time.Time `json:"alert_sent_at,omitempty"`
+	Connections         int        `json:"connections"`                      // Live stratum sessions (event bus only)
+	DisconnectedAt      *time.Time `json:"disconnected_at,omitempty"`        // When the last session closed
+	Hashrate            float64    `json:"hashrate"`                         // Current hashrate, H/s
+	BaselineHashrate    float64    `json:"baseline_hashrate"`                // Baseline hashrate, H/s
+	HashrateDropped     bool       `json:"hashrate_dropped"`                 // Below the drop threshold since the last alert
+	HashrateAlertSentAt *time.Time `json:"hashrate_alert_sent_at,omitempty"` // Last hashrate drop alert
+}
+
+// WorkerThresholds are the alert thresholds that apply to a user's workers
+type WorkerThresholds struct {
+	OfflineAfter        time.Duration
+	HashrateDropPercent int
 }
 
 // WorkerMonitorStats holds monitoring statistics
@@ -54,15 +83,42 @@ type WorkerActivityProvider interface {
 	GetWorkerLastActivity(ctx context.Context, workerID int64) (time.Time, error)
 }
 
+// WorkerThresholdProvider supplies users' notification settings (ISP)
+type WorkerThresholdProvider interface {
+	GetUserPreferences(ctx context.Context, userID int64) (*UserNotificationSettings, error)
+}
+
+// WorkerEventSource streams live worker events, e.g. *events.Bus (ISP)
+type WorkerEventSource interface {
+	Subscribe(buffer int) (<-chan events.WorkerEvent, func())
+}
+
+// workerHashrate holds the hashrate windows of one worker
+type workerHashrate struct {
+	current    *hashrate.Window
+	baseline   *hashrate.Window
+	firstShare time.Time
+}
+
+// cachedThresholds is a user's thresholds and when they were fetched
+type cachedThresholds struct {
+	thresholds WorkerThresholds
+	fetchedAt  time.Time
+}
+
 // WorkerMonitor monitors worker activity and sends offline alerts
 type WorkerMonitor struct {
-	config           *WorkerMonitorConfig
-	notifier         AlertSender
-	activityProvider WorkerActivityProvider
-	workers          map[int64]*WorkerState
-	mu               sync.RWMutex
-	stopCh           chan struct{}
-	running          bool
+	config            *WorkerMonitorConfig
+	notifier          AlertSender
+	activityProvider  WorkerActivityProvider
+	thresholdProvider WorkerThresholdProvider
+	workers           map[int64]*WorkerState
+	hashrates         map[int64]*workerHashrate
+	mu                sync.RWMutex
+	thresholds        map[int64]cachedThresholds
+	thresholdMu       sync.Mutex
+	stopCh            chan struct{}
+	running           bool
 }
 
 // NewWorkerMonitor creates a new worker monitor
@@ -70,15 +126,39 @@ func NewWorkerMonitor(config *WorkerMonitorConfig, notifier AlertSender, provide
 	if config == nil {
 		config = DefaultWorkerMonitorConfig()
 	}
+	defaults := DefaultWorkerMonitorConfig()
+	if config.HashrateDropPercent <= 0 {
+		config.HashrateDropPercent = defaults.HashrateDropPercent
+	}
+	if config.CurrentWindow <= 0 {
+		config.CurrentWindow = defaults.CurrentWindow
+	}
+	if config.BaselineWindow <= 0 {
+		config.BaselineWindow = defaults.BaselineWindow
+	}
+	if config.ThresholdCacheTTL <= 0 {
+		config.ThresholdCacheTTL = defaults.ThresholdCacheTTL
+	}
 	return &WorkerMonitor{
 		config:           config,
 		notifier:         notifier,
 		activityProvider: provider,
 		workers:          make(map[int64]*WorkerState),
+		hashrates:        make(map[int64]*workerHashrate),
+		thresholds:       make(map[int64]cachedThresholds),
 		stopCh:           make(chan struct{}),
 	}
 }
 
+// SetThresholdProvider makes the monitor apply each user's offline delay and
+// hashrate drop percentage instead of the configured defaults
+func (m *WorkerMonitor) SetThresholdProvider(provider WorkerThresholdProvider) {
+	m.thresholdMu.Lock()
+	defer m.thresholdMu.Unlock()
+	m.thresholdProvider = provider
+	m.thresholds = make(map[int64]cachedThresholds)
+}
+
 // Start begins monitoring workers in the background
 func (m *WorkerMonitor) Start() {
 	m.mu.Lock()
@@ -109,19 +189,25 @@ func (m *WorkerMonitor) Stop() {
 func (m *WorkerMonitor) RecordActivity(userID, workerID int64, workerName string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	m.recordActivityLocked(userID, workerID, workerName)
+}
 
+// recordActivityLocked marks a worker seen and online, announcing its return
+// if it was offline (caller holds the lock)
+func (m *WorkerMonitor) recordActivityLocked(userID, workerID int64, workerName string) *WorkerState {
 	state, exists := m.workers[workerID]
 	now := time.Now()
 
 	if !exists {
-		m.workers[workerID] = &WorkerState{
+		state = &WorkerState{
 			WorkerID:   workerID,
 			UserID:     userID,
 			WorkerName: workerName,
 			LastSeen:   now,
 			IsOnline:   true,
 		}
-		return
+		m.workers[workerID] = state
+		return state
 	}
 
 	// Worker coming back online?
@@ -139,6 +225,77 @@ func (m *WorkerMonitor) RecordActivity(userID, workerID int64, workerName string
 	state.LastSeen = now
 	state.WorkerName = workerName
 	state.UserID = userID
+	return state
+}
+
+// Subscribe feeds the monitor from a live event source until Stop
+func (m *WorkerMonitor) Subscribe(source WorkerEventSource) {
+	ch, cancel := source.Subscribe(0)
+	go func() {
+		defer cancel()
+		for {
+			select {
+			case <-m.stopCh:
+				return
+			case event, ok := <-ch:
+				if !ok {
+					return
+				}
+				m.HandleEvent(event)
+			}
+		}
+	}()
+}
+
+// HandleEvent applies one stratum worker event. Events from connections that
+// have not authorized are ignored.
+func (m *WorkerMonitor) HandleEvent(event events.WorkerEvent) {
+	if !event.Authorized() {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch event.Kind {
+	case events.KindAuthorized:
+		state := m.recordActivityLocked(event.UserID, event.WorkerID, event.WorkerName)
+		state.Connections++
+		state.DisconnectedAt = nil
+	case events.KindShareAccepted:
+		m.recordActivityLocked(event.UserID, event.WorkerID, event.WorkerName)
+		m.recordShareLocked(event.WorkerID, event.Difficulty, event.Time)
+	case events.KindDisconnected:
+		state, exists := m.workers[event.WorkerID]
+		if !exists {
+			return
+		}
+		if state.Connections > 0 {
+			state.Connections--
+		}
+		if state.Connections == 0 {
+			at := event.Time
+			state.DisconnectedAt = &at
+		}
+	}
+}
+
+// recordShareLocked adds an accepted share to a worker's hashrate windows
+// (caller holds the lock)
+func (m *WorkerMonitor) recordShareLocked(workerID int64, difficulty float64, at time.Time) {
+	rates, exists := m.hashrates[workerID]
+	if !exists {
+		rates = &workerHashrate{
+			current:  hashrate.NewWindow(m.config.CurrentWindow),
+			baseline: hashrate.NewWindow(m.config.BaselineWindow),
+		}
+		m.hashrates[workerID] = rates
+	}
+	if rates.firstShare.IsZero() {
+		rates.firstShare = at
+	}
+	rates.current.AddShare(difficulty, at)
+	rates.baseline.AddShare(difficulty, at)
 }
 
 // GetWorkerState returns the state of a specific worker
@@ -154,35 +311,109 @@ func (m *WorkerMonitor) GetWorkerState(workerID int64) *WorkerState {
 	return nil
 }
 
-// CheckOfflineWorkers checks for offline workers and sends alerts
+// CheckOfflineWorkers checks for offline workers and sends alerts. A worker
+// is offline once its user's offline delay has passed since its last
+// activity, or since its last stratum session closed.
 func (m *WorkerMonitor) CheckOfflineWorkers(ctx context.Context) []*WorkerState {
+	thresholds := m.userThresholds(ctx)
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	now := time.Now()
-	threshold := now.Add(-m.config.OfflineThreshold)
 	offline := make([]*WorkerState, 0)
 
 	for _, state := range m.workers {
-		if state.LastSeen.Before(threshold) && state.IsOnline {
-			// Worker is offline
-			state.IsOnline = false
-			offline = append(offline, state)
-
-			// Send alert if not recently sent
-			if m.shouldSendAlert(state) {
-				if m.notifier != nil {
-					alert := NewWorkerOfflineAlert(state.UserID, state.WorkerID, state.WorkerName)
-					m.notifier.SendAlert(ctx, alert)
-				}
-				state.AlertSentAt = &now
+		if !state.IsOnline {
+			continue
+		}
+		offlineAfter := m.thresholdOf(thresholds, state.UserID).OfflineAfter
+		// A connected worker gets at least the configured threshold to
+		// find its next share
+		silentAfter := offlineAfter
+		if state.Connections > 0 && silentAfter < m.config.OfflineThreshold {
+			silentAfter = m.config.OfflineThreshold
+		}
+		silent := now.Sub(state.LastSeen) > silentAfter
+		disconnected := state.Connections == 0 && state.DisconnectedAt != nil &&
+			now.Sub(*state.DisconnectedAt) >= offlineAfter
+		if !silent && !disconnected {
+			continue
+		}
+
+		// Worker is offline; its baseline restarts when it returns
+		state.IsOnline = false
+		state.HashrateDropped = false
+		delete(m.hashrates, state.WorkerID)
+		offline = append(offline, state)
+
+		// Send alert if not recently sent
+		if m.shouldSendAlert(state) {
+			if m.notifier != nil {
+				alert := NewWorkerOfflineAlert(state.UserID, state.WorkerID, state.WorkerName)
+				m.notifier.SendAlert(ctx, alert)
 			}
+			state.AlertSentAt = &now
 		}
 	}
 
 	return offline
 }
 
+// CheckHashrateDrops compares online workers' current hashrate against their
+// baseline and alerts once per drop below the user's threshold. It returns
+// the workers alerted.
+func (m *WorkerMonitor) CheckHashrateDrops(ctx context.Context) []*WorkerState {
+	thresholds := m.userThresholds(ctx)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	dropped := make([]*WorkerState, 0)
+
+	for workerID, rates := range m.hashrates {
+		state, exists := m.workers[workerID]
+		if !exists || !state.IsOnline {
+			continue
+		}
+		state.Hashrate = rates.current.GetHashrate()
+		state.BaselineHashrate = rates.baseline.GetHashrate()
+		if state.BaselineHashrate <= 0 || now.Sub(rates.firstShare) < m.config.BaselineWarmup {
+			continue
+		}
+
+		dropPercent := int((1 - state.Hashrate/state.BaselineHashrate) * 100)
+		if dropPercent < m.thresholdOf(thresholds, state.UserID).HashrateDropPercent {
+			state.HashrateDropped = false
+			continue
+		}
+		if state.HashrateDropped {
+			continue
+		}
+		state.HashrateDropped = true
+
+		if state.HashrateAlertSentAt != nil && now.Sub(*state.HashrateAlertSentAt) <= m.config.AlertCooldown {
+			continue
+		}
+		if m.notifier != nil {
+			alert := NewHashrateDropAlert(state.UserID, state.WorkerID, state.WorkerName, dropPercent)
+			m.notifier.SendAlert(ctx, alert)
+		}
+		state.HashrateAlertSentAt = &now
+		dropped = append(dropped, state)
+	}
+
+	return dropped
+}
+
+// ThresholdsFor returns the thresholds that apply to a user's workers
+func (m *WorkerMonitor) ThresholdsFor(ctx context.Context, userID int64) WorkerThresholds {
+	m.thresholdMu.Lock()
+	defer m.thresholdMu.Unlock()
+	return m.thresholdsLocked(ctx, userID)
+}
+
 // GetStats returns current monitoring statistics
 func (m *WorkerMonitor) GetStats() WorkerMonitorStats {
 	m.mu.RLock()
@@ -245,6 +476,7 @@ func (m *WorkerMonitor) monitorLoop() {
 			return
 		case <-ticker.C:
 			m.CheckOfflineWorkers(context.Background())
+			m.CheckHashrateDrops(context.Background())
 		}
 	}
 }
@@ -256,6 +488,67 @@ func (m *WorkerMonitor) shouldSendAlert(state *WorkerState) bool {
 	return time.Since(*state.AlertSentAt) > m.config.AlertCooldown
 }
 
+// userThresholds resolves the thresholds of every user with tracked workers
+func (m *WorkerMonitor) userThresholds(ctx context.Context) map[int64]WorkerThresholds {
+	m.mu.RLock()
+	users := make(map[int64]bool)
+	for _, state := range m.workers {
+		users[state.UserID] = true
+	}
+	m.mu.RUnlock()
+
+	m.thresholdMu.Lock()
+	defer m.thresholdMu.Unlock()
+	thresholds := make(map[int64]WorkerThresholds, len(users))
+	for userID := range users {
+		thresholds[userID] = m.thresholdsLocked(ctx, userID)
+	}
+	return thresholds
+}
+
+// thresholdOf looks a user up in resolved thresholds; workers added since
+// they were resolved get the configured defaults
+func (m *WorkerMonitor) thresholdOf(thresholds map[int64]WorkerThresholds, userID int64) WorkerThresholds {
+	if t, ok := thresholds[userID]; ok {
+		return t
+	}
+	return m.defaultThresholds()
+}
+
+// defaultThresholds are the configured thresholds
+func (m *WorkerMonitor) defaultThresholds() WorkerThresholds {
+	return WorkerThresholds{
+		OfflineAfter:        m.config.OfflineThreshold,
+		HashrateDropPercent: m.config.HashrateDropPercent,
+	}
+}
+
+// thresholdsLocked returns a user's thresholds, from the cache while fresh.
+// Without a provider, or when it fails, the configured defaults apply.
+// Caller holds thresholdMu.
+func (m *WorkerMonitor) thresholdsLocked(ctx context.Context, userID int64) WorkerThresholds {
+	defaults := m.defaultThresholds()
+	if m.thresholdProvider == nil {
+		return defaults
+	}
+	if cached, ok := m.thresholds[userID]; ok && time.Since(cached.fetchedAt) < m.config.ThresholdCacheTTL {
+		return cached.thresholds
+	}
+
+	thresholds := defaults
+	prefs, err := m.thresholdProvider.GetUserPreferences(ctx, userID)
+	if err == nil && prefs != nil {
+		if prefs.WorkerOfflineDelay >= 0 {
+			thresholds.OfflineAfter = time.Duration(prefs.WorkerOfflineDelay) * time.Minute
+		}
+		if prefs.HashrateDropPercent > 0 && prefs.HashrateDropPercent <= 100 {
+			thresholds.HashrateDropPercent = prefs.HashrateDropPercent
+		}
+	}
+	m.thresholds[userID] = cachedThresholds{thresholds: thresholds, fetchedAt: time.Now()}
+	return thresholds
+}
+
 // LoadWorkersFromProvider loads initial worker state from provider
 func (m *WorkerMonitor) LoadWorkersFromProvider(ctx context.Context) error {
 	if m.activityProvider == nil {
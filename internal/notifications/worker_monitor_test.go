@@ -7,6 +7,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/chimera-pool/chimera-pool-core/internal/stratum/events"
 )
 
 // =============================================================================
@@ -187,10 +189,134 @@ func TestWorkerMonitor_GetStats(t *testing.T) {
 	})
 }
 
+func TestWorkerMonitor_Events(t *testing.T) {
+	t.Run("last disconnect takes worker offline after user delay", func(t *testing.T) {
+		var alerts []*Alert
+		monitor := NewWorkerMonitor(DefaultWorkerMonitorConfig(), &mockNotificationService{
+			onSend: func(alert *Alert) { alerts = append(alerts, alert) },
+		}, nil)
+		monitor.SetThresholdProvider(&mockThresholdProvider{delays: map[int64]int{1: 0, 2: 60}})
+
+		rig := events.WorkerEvent{UserID: 1, WorkerID: 100, WorkerName: "rig1", ConnectionID: "c1"}
+		slow := events.WorkerEvent{UserID: 2, WorkerID: 200, WorkerName: "rig2", ConnectionID: "c3"}
+		for _, event := range []events.WorkerEvent{rig, rig, slow} {
+			event.Kind = events.KindAuthorized
+			monitor.HandleEvent(event)
+		}
+		assert.Equal(t, 2, monitor.GetWorkerState(100).Connections)
+
+		// One of two sessions closing keeps the worker online
+		rig.Kind, slow.Kind = events.KindDisconnected, events.KindDisconnected
+		rig.Time, slow.Time = time.Now(), time.Now()
+		monitor.HandleEvent(rig)
+		assert.Empty(t, monitor.CheckOfflineWorkers(context.Background()))
+
+		// User 1 wants to know immediately, user 2 after an hour
+		monitor.HandleEvent(rig)
+		monitor.HandleEvent(slow)
+		offline := monitor.CheckOfflineWorkers(context.Background())
+		require.Len(t, offline, 1)
+		assert.Equal(t, int64(100), offline[0].WorkerID)
+		assert.True(t, monitor.GetWorkerState(200).IsOnline)
+
+		rig.Kind = events.KindAuthorized
+		monitor.HandleEvent(rig)
+		require.Len(t, alerts, 2)
+		assert.Equal(t, AlertTypeWorkerOffline, alerts[0].Type)
+		assert.Equal(t, AlertTypeWorkerOnline, alerts[1].Type)
+		assert.Nil(t, monitor.GetWorkerState(100).DisconnectedAt)
+	})
+
+	t.Run("ignores unauthorized connections", func(t *testing.T) {
+		monitor := NewWorkerMonitor(DefaultWorkerMonitorConfig(), nil, nil)
+		monitor.HandleEvent(events.WorkerEvent{Kind: events.KindConnected, ConnectionID: "c1"})
+		assert.Equal(t, 0, monitor.GetStats().TotalWorkers)
+	})
+
+	t.Run("subscribes to the event bus", func(t *testing.T) {
+		bus := events.NewBus()
+		monitor := NewWorkerMonitor(DefaultWorkerMonitorConfig(), nil, nil)
+		monitor.Start()
+		defer monitor.Stop()
+		monitor.Subscribe(bus)
+
+		bus.Publish(events.WorkerEvent{Kind: events.KindAuthorized, UserID: 1, WorkerID: 100, WorkerName: "rig1"})
+		assert.Eventually(t, func() bool { return monitor.GetWorkerState(100) != nil }, time.Second, 5*time.Millisecond)
+	})
+}
+
+func TestWorkerMonitor_HashrateDrop(t *testing.T) {
+	var alerts []*Alert
+	monitor := NewWorkerMonitor(&WorkerMonitorConfig{
+		OfflineThreshold: time.Hour,
+		CheckInterval:    time.Second,
+		CurrentWindow:    50 * time.Millisecond,
+		BaselineWindow:   time.Hour,
+	}, &mockNotificationService{
+		onSend: func(alert *Alert) { alerts = append(alerts, alert) },
+	}, nil)
+	monitor.SetThresholdProvider(&mockThresholdProvider{drops: map[int64]int{1: 90}})
+
+	monitor.HandleEvent(events.WorkerEvent{
+		Kind: events.KindShareAccepted, UserID: 1, WorkerID: 100, WorkerName: "rig1",
+		Difficulty: 1000, Time: time.Now(),
+	})
+	assert.Empty(t, monitor.CheckHashrateDrops(context.Background()))
+	state := monitor.GetWorkerState(100)
+	assert.Greater(t, state.Hashrate, state.BaselineHashrate)
+
+	// The share leaves the current window but not the baseline
+	time.Sleep(80 * time.Millisecond)
+	dropped := monitor.CheckHashrateDrops(context.Background())
+	require.Len(t, dropped, 1)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, AlertTypeHashrateDrop, alerts[0].Type)
+	assert.Equal(t, "100", alerts[0].Metadata["drop_percent"])
+
+	// One alert per drop
+	assert.Empty(t, monitor.CheckHashrateDrops(context.Background()))
+	assert.Len(t, alerts, 1)
+}
+
+func TestWorkerMonitor_ThresholdsFor(t *testing.T) {
+	monitor := NewWorkerMonitor(DefaultWorkerMonitorConfig(), nil, nil)
+	assert.Equal(t, WorkerThresholds{OfflineAfter: 5 * time.Minute, HashrateDropPercent: 50},
+		monitor.ThresholdsFor(context.Background(), 1))
+
+	provider := &mockThresholdProvider{delays: map[int64]int{1: 2}, drops: map[int64]int{1: 30}}
+	monitor.SetThresholdProvider(provider)
+	assert.Equal(t, WorkerThresholds{OfflineAfter: 2 * time.Minute, HashrateDropPercent: 30},
+		monitor.ThresholdsFor(context.Background(), 1))
+
+	// Cached until the TTL passes
+	provider.delays[1] = 10
+	assert.Equal(t, 2*time.Minute, monitor.ThresholdsFor(context.Background(), 1).OfflineAfter)
+	assert.Equal(t, 1, provider.calls)
+}
+
 // =============================================================================
 // MOCK NOTIFICATION SERVICE
 // =============================================================================
 
+// mockThresholdProvider returns default settings with per-user overrides
+type mockThresholdProvider struct {
+	delays map[int64]int // Offline delay in minutes
+	drops  map[int64]int // Hashrate drop percent
+	calls  int
+}
+
+func (m *mockThresholdProvider) GetUserPreferences(ctx context.Context, userID int64) (*UserNotificationSettings, error) {
+	m.calls++
+	settings := DefaultUserNotificationSettings(userID, "")
+	if delay, ok := m.delays[userID]; ok {
+		settings.WorkerOfflineDelay = delay
+	}
+	if drop, ok := m.drops[userID]; ok {
+		settings.HashrateDropPercent = drop
+	}
+	return settings, nil
+}
+
 type mockNotificationService struct {
 	onSend func(alert *Alert)
 }
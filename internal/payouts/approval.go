@@ -0,0 +1,646 @@
+package payouts
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// =============================================================================
+// ISP-COMPLIANT INTERFACES FOR PAYOUT APPROVAL
+// =============================================================================
+
+// PayoutPolicy decides which due payouts need an admin's approval (ISP)
+type PayoutPolicy interface {
+	// Evaluate splits due payouts into those that may be sent now and those
+	// that must be held. It has no side effects, so it also serves dry runs.
+	Evaluate(ctx context.Context, payouts []PendingPayout) (cleared []PendingPayout, held []PayoutApproval, err error)
+	// Hold takes a payout out of the queue until it is approved
+	Hold(ctx context.Context, approval *PayoutApproval) error
+}
+
+// PayoutApprovalRepository persists held payouts and admin decisions (ISP)
+type PayoutApprovalRepository interface {
+	// GetPayoutVolume returns the amount paid out since a time, pool-wide
+	// and per user, including batches still being broadcast
+	GetPayoutVolume(ctx context.Context, since time.Time) (pool int64, users map[int64]int64, err error)
+	// GetAddressSetAt returns when a user last set an address, if known.
+	// Payouts to an address with no known set time are held.
+	GetAddressSetAt(ctx context.Context, userID int64, address string) (setAt time.Time, found bool, err error)
+	// HoldPayout moves a pending payout to held and records why. It fails
+	// with ErrPayoutNotPending if the payout has left the queue.
+	HoldPayout(ctx context.Context, approval *PayoutApproval) error
+	GetPayoutApprovals(ctx context.Context, status ApprovalStatus, limit int) ([]PayoutApproval, error)
+	GetPayoutApproval(ctx context.Context, approvalID int64) (*PayoutApproval, error)
+	// RecordApprovalVote records an admin's decision. A rejection fails the
+	// payout; the required number of approvals returns it to the queue.
+	RecordApprovalVote(ctx context.Context, approvalID int64, vote ApprovalVote) (*PayoutApproval, error)
+}
+
+// =============================================================================
+// APPROVAL TYPES
+// =============================================================================
+
+// ApprovalStatus represents the state of a held payout
+type ApprovalStatus string
+
+const (
+	ApprovalStatusPending  ApprovalStatus = "pending"
+	ApprovalStatusApproved ApprovalStatus = "approved"
+	ApprovalStatusRejected ApprovalStatus = "rejected"
+)
+
+// ApprovalDecision is an admin's vote on a held payout
+type ApprovalDecision string
+
+const (
+	ApprovalDecisionApprove ApprovalDecision = "approve"
+	ApprovalDecisionReject  ApprovalDecision = "reject"
+)
+
+var (
+	ErrApprovalNotFound = errors.New("payout approval not found")
+	ErrApprovalResolved = errors.New("payout approval already resolved")
+	ErrAlreadyVoted     = errors.New("admin already voted on this payout")
+	ErrSelfApproval     = errors.New("admins cannot approve their own payouts")
+	ErrPayoutNotPending = errors.New("payout is no longer pending")
+)
+
+// ApprovalVote is one admin's decision on a held payout
+type ApprovalVote struct {
+	AdminID   int64            `json:"admin_id"`
+	Decision  ApprovalDecision `json:"decision"`
+	Note      string           `json:"note,omitempty"`
+	CreatedAt time.Time        `json:"created_at"`
+}
+
+// PayoutApproval is a payout held until admins approve or reject it
+type PayoutApproval struct {
+	ID                int64          `json:"id"`
+	Payout            PendingPayout  `json:"payout"`
+	Reasons           []string       `json:"reasons"`
+	Status            ApprovalStatus `json:"status"`
+	RequiredApprovals int            `json:"required_approvals"`
+	Votes             []ApprovalVote `json:"votes"`
+	CreatedAt         time.Time      `json:"created_at"`
+	ResolvedAt        *time.Time     `json:"resolved_at,omitempty"`
+}
+
+// Approvals counts the approve votes cast so far
+func (a *PayoutApproval) Approvals() int {
+	count := 0
+	for _, vote := range a.Votes {
+		if vote.Decision == ApprovalDecisionApprove {
+			count++
+		}
+	}
+	return count
+}
+
+// =============================================================================
+// APPROVAL CONFIGURATION
+// =============================================================================
+
+// ApprovalConfig sets the limits above which payouts wait for approval.
+// A zero limit is not checked.
+type ApprovalConfig struct {
+	MaxPayoutAmount    int64         `json:"max_payout_amount" yaml:"max_payout_amount"`         // Largest single payout
+	MaxUserDailyAmount int64         `json:"max_user_daily_amount" yaml:"max_user_daily_amount"` // Per user over the last 24h
+	MaxPoolDailyAmount int64         `json:"max_pool_daily_amount" yaml:"max_pool_daily_amount"` // Hot wallet outflow over the last 24h
+	AddressChangeHold  time.Duration `json:"address_change_hold" yaml:"address_change_hold"`     // Hold payouts to addresses set more recently
+	RequiredApprovals  int           `json:"required_approvals" yaml:"required_approvals"`       // Distinct admins needed to release a payout
+}
+
+// DefaultApprovalConfig returns sensible defaults
+func DefaultApprovalConfig() ApprovalConfig {
+	return ApprovalConfig{
+		MaxPayoutAmount:    1000000000,  // 10 LTC
+		MaxUserDailyAmount: 2500000000,  // 25 LTC
+		MaxPoolDailyAmount: 50000000000, // 500 LTC
+		AddressChangeHold:  48 * time.Hour,
+		RequiredApprovals:  2,
+	}
+}
+
+// payoutVolumeWindow is the period the daily limits apply to
+const payoutVolumeWindow = 24 * time.Hour
+
+// =============================================================================
+// PAYOUT APPROVAL SERVICE
+// =============================================================================
+
+// PayoutApprovalService holds risky payouts for admin approval and applies
+// admin decisions
+type PayoutApprovalService struct {
+	repo     PayoutApprovalRepository
+	ledger   PayoutLedger
+	notifier PayoutNotifier
+	config   ApprovalConfig
+	now      func() time.Time
+}
+
+// NewPayoutApprovalService creates a new payout approval service
+func NewPayoutApprovalService(repo PayoutApprovalRepository, config ApprovalConfig) *PayoutApprovalService {
+	if repo == nil {
+		return nil
+	}
+	if config.RequiredApprovals <= 0 {
+		config.RequiredApprovals = 1
+	}
+
+	return &PayoutApprovalService{
+		repo:   repo,
+		config: config,
+		now:    time.Now,
+	}
+}
+
+// Config returns the limits payouts are checked against
+func (s *PayoutApprovalService) Config() ApprovalConfig {
+	return s.config
+}
+
+// SetLedger sets the ledger rejected payouts are refunded through
+func (s *PayoutApprovalService) SetLedger(ledger PayoutLedger) {
+	s.ledger = ledger
+}
+
+// SetNotifier sets the notifier told when a payout is rejected
+func (s *PayoutApprovalService) SetNotifier(notifier PayoutNotifier) {
+	s.notifier = notifier
+}
+
+// Evaluate checks due payouts, in order, against the limits. Payouts that
+// go ahead count towards the daily volume of the ones after them; approved
+// payouts are never held again but still count.
+func (s *PayoutApprovalService) Evaluate(ctx context.Context, payouts []PendingPayout) ([]PendingPayout, []PayoutApproval, error) {
+	if len(payouts) == 0 {
+		return payouts, nil, nil
+	}
+
+	now := s.now()
+	poolVolume, userVolume, err := s.repo.GetPayoutVolume(ctx, now.Add(-payoutVolumeWindow))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get payout volume: %w", err)
+	}
+	if userVolume == nil {
+		userVolume = make(map[int64]int64)
+	}
+
+	cleared := make([]PendingPayout, 0, len(payouts))
+	var held []PayoutApproval
+	for _, payout := range payouts {
+		reasons, err := s.holdReasons(ctx, payout, poolVolume, userVolume[payout.UserID], now)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(reasons) > 0 {
+			held = append(held, PayoutApproval{
+				Payout:            payout,
+				Reasons:           reasons,
+				Status:            ApprovalStatusPending,
+				RequiredApprovals: s.config.RequiredApprovals,
+			})
+			continue
+		}
+
+		cleared = append(cleared, payout)
+		poolVolume += payout.Amount
+		userVolume[payout.UserID] += payout.Amount
+	}
+
+	return cleared, held, nil
+}
+
+// holdReasons lists the limits a payout breaks. An approved payout is only
+// held again when one of its addresses was set after the approval.
+func (s *PayoutApprovalService) holdReasons(ctx context.Context, payout PendingPayout, poolVolume, userVolume int64, now time.Time) ([]string, error) {
+	var reasons []string
+	if payout.ApprovedAt == nil {
+		if limit := s.config.MaxPayoutAmount; limit > 0 && payout.Amount > limit {
+			reasons = append(reasons, fmt.Sprintf("amount %d exceeds the %d single payout limit", payout.Amount, limit))
+		}
+		if limit := s.config.MaxUserDailyAmount; limit > 0 && userVolume+payout.Amount > limit {
+			reasons = append(reasons, fmt.Sprintf("user would receive %d in 24h, over the %d limit", userVolume+payout.Amount, limit))
+		}
+		if limit := s.config.MaxPoolDailyAmount; limit > 0 && poolVolume+payout.Amount > limit {
+			reasons = append(reasons, fmt.Sprintf("pool would pay out %d in 24h, over the %d limit", poolVolume+payout.Amount, limit))
+		}
+	}
+	if s.config.AddressChangeHold <= 0 {
+		return reasons, nil
+	}
+
+	for _, address := range payoutAddresses(payout) {
+		setAt, found, err := s.repo.GetAddressSetAt(ctx, payout.UserID, address)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get address history: %w", err)
+		}
+		switch {
+		case payout.ApprovedAt != nil:
+			if found && setAt.After(*payout.ApprovedAt) {
+				reasons = append(reasons, fmt.Sprintf("payout address %s set after approval", address))
+			}
+		case !found:
+			reasons = append(reasons, fmt.Sprintf("payout address %s has no known set time", address))
+		case now.Sub(setAt) < s.config.AddressChangeHold:
+			reasons = append(reasons, fmt.Sprintf("payout address %s set %s ago", address, now.Sub(setAt).Round(time.Minute)))
+		}
+	}
+	return reasons, nil
+}
+
+// Hold takes a payout out of the queue until it is approved
+func (s *PayoutApprovalService) Hold(ctx context.Context, approval *PayoutApproval) error {
+	if err := s.repo.HoldPayout(ctx, approval); err != nil {
+		return err
+	}
+	log.Printf("✋ Payout %d of %d to user %d held for approval: %v",
+		approval.Payout.ID, approval.Payout.Amount, approval.Payout.UserID, approval.Reasons)
+	return nil
+}
+
+// ListApprovals returns held payouts in a status, oldest first
+func (s *PayoutApprovalService) ListApprovals(ctx context.Context, status ApprovalStatus, limit int) ([]PayoutApproval, error) {
+	return s.repo.GetPayoutApprovals(ctx, status, limit)
+}
+
+// Approve records an admin's approval. The payout returns to the queue once
+// RequiredApprovals distinct admins have approved it.
+func (s *PayoutApprovalService) Approve(ctx context.Context, approvalID, adminID int64, note string) (*PayoutApproval, error) {
+	approval, err := s.repo.GetPayoutApproval(ctx, approvalID)
+	if err != nil {
+		return nil, err
+	}
+	if approval.Payout.UserID == adminID {
+		return nil, ErrSelfApproval
+	}
+
+	approval, err = s.repo.RecordApprovalVote(ctx, approvalID, ApprovalVote{
+		AdminID:  adminID,
+		Decision: ApprovalDecisionApprove,
+		Note:     note,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if approval.Status == ApprovalStatusApproved {
+		log.Printf("✅ Payout %d approved by %d admins, queued for sending", approval.Payout.ID, approval.Approvals())
+	}
+	return approval, nil
+}
+
+// Reject fails a held payout and returns its amount to the miner's balance
+func (s *PayoutApprovalService) Reject(ctx context.Context, approvalID, adminID int64, note string) (*PayoutApproval, error) {
+	approval, err := s.repo.RecordApprovalVote(ctx, approvalID, ApprovalVote{
+		AdminID:  adminID,
+		Decision: ApprovalDecisionReject,
+		Note:     note,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	reason := rejectionReason(note)
+	if s.ledger != nil {
+		if err := s.ledger.RefundPayout(ctx, approval.Payout, reason); err != nil {
+			log.Printf("⚠️ Failed to refund rejected payout %d: %v", approval.Payout.ID, err)
+		}
+	}
+	if s.notifier != nil {
+		_ = s.notifier.NotifyPayoutFailed(ctx, approval.Payout.UserID, approval.Payout.Amount, reason)
+	}
+
+	log.Printf("🚫 Payout %d rejected by admin %d", approval.Payout.ID, adminID)
+	return approval, nil
+}
+
+// rejectionReason is the error recorded on a rejected payout
+func rejectionReason(note string) string {
+	if note == "" {
+		return "rejected by admin"
+	}
+	return "rejected by admin: " + note
+}
+
+// =============================================================================
+// SQL PAYOUT APPROVAL REPOSITORY IMPLEMENTATION
+// =============================================================================
+
+// SQLPayoutApprovalRepository implements PayoutApprovalRepository using PostgreSQL
+type SQLPayoutApprovalRepository struct {
+	db *sql.DB
+}
+
+// NewSQLPayoutApprovalRepository creates a new SQL payout approval repository
+func NewSQLPayoutApprovalRepository(db *sql.DB) *SQLPayoutApprovalRepository {
+	return &SQLPayoutApprovalRepository{db: db}
+}
+
+// GetPayoutVolume returns the amount paid out since a time
+func (r *SQLPayoutApprovalRepository) GetPayoutVolume(ctx context.Context, since time.Time) (int64, map[int64]int64, error) {
+	query := `
+		SELECT user_id, COALESCE(SUM(amount), 0)
+		FROM pending_payouts
+		WHERE (status = $1 AND processed_at >= $2)
+		   OR (status = $3 AND batch_id IS NOT NULL)
+		GROUP BY user_id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, PayoutStatusProcessed, since, PayoutStatusPending)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to query payout volume: %w", err)
+	}
+	defer rows.Close()
+
+	var pool int64
+	users := make(map[int64]int64)
+	for rows.Next() {
+		var userID, amount int64
+		if err := rows.Scan(&userID, &amount); err != nil {
+			return 0, nil, fmt.Errorf("failed to scan payout volume: %w", err)
+		}
+		users[userID] = amount
+		pool += amount
+	}
+
+	return pool, users, rows.Err()
+}
+
+// GetAddressSetAt returns when a user last set an address, from the payout
+// address history, the user's wallets and their payout settings. The wallet
+// and settings times are kept by triggers on every address edit.
+func (r *SQLPayoutApprovalRepository) GetAddressSetAt(ctx context.Context, userID int64, address string) (time.Time, bool, error) {
+	query := `
+		SELECT GREATEST(
+			(SELECT MAX(set_at) FROM wallet_address_history WHERE user_id = $1 AND address = $2),
+			(SELECT MAX(address_set_at) FROM user_wallets WHERE user_id = $1 AND address = $2),
+			(SELECT MAX(address_set_at) FROM user_payout_settings WHERE user_id = $1 AND payout_address = $2)
+		)
+	`
+
+	var setAt sql.NullTime
+	if err := r.db.QueryRowContext(ctx, query, userID, address).Scan(&setAt); err != nil {
+		return time.Time{}, false, fmt.Errorf("failed to query address history: %w", err)
+	}
+	return setAt.Time, setAt.Valid, nil
+}
+
+// HoldPayout moves a pending payout to held and records why
+func (r *SQLPayoutApprovalRepository) HoldPayout(ctx context.Context, approval *PayoutApproval) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE pending_payouts
+		SET status = $2
+		WHERE id = $1 AND status = $3 AND batch_id IS NULL
+	`, approval.Payout.ID, PayoutStatusHeld, PayoutStatusPending)
+	if err != nil {
+		return fmt.Errorf("failed to hold payout: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrPayoutNotPending
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO payout_approvals (payout_id, reasons, status, required_approvals)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`, approval.Payout.ID, pq.Array(approval.Reasons), ApprovalStatusPending, approval.RequiredApprovals,
+	).Scan(&approval.ID, &approval.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create payout approval: %w", err)
+	}
+
+	approval.Status = ApprovalStatusPending
+	approval.Payout.Status = PayoutStatusHeld
+	return tx.Commit()
+}
+
+const payoutApprovalQuery = `
+	SELECT a.id, a.reasons, a.status, a.required_approvals, a.created_at, a.resolved_at,
+	       p.id, p.user_id, p.amount, p.address, p.status, p.payout_mode, COALESCE(p.block_id, 0),
+	       p.created_at, p.error_message, p.approved_at
+	FROM payout_approvals a
+	JOIN pending_payouts p ON p.id = a.payout_id`
+
+// GetPayoutApprovals returns held payouts in a status, oldest first
+func (r *SQLPayoutApprovalRepository) GetPayoutApprovals(ctx context.Context, status ApprovalStatus, limit int) ([]PayoutApproval, error) {
+	rows, err := r.db.QueryContext(ctx, payoutApprovalQuery+`
+		WHERE a.status = $1
+		ORDER BY a.created_at ASC
+		LIMIT $2
+	`, status, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query payout approvals: %w", err)
+	}
+	approvals, err := scanPayoutApprovals(rows)
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.loadVotes(ctx, approvals); err != nil {
+		return nil, err
+	}
+	return approvals, nil
+}
+
+// GetPayoutApproval returns a held payout with its votes
+func (r *SQLPayoutApprovalRepository) GetPayoutApproval(ctx context.Context, approvalID int64) (*PayoutApproval, error) {
+	rows, err := r.db.QueryContext(ctx, payoutApprovalQuery+` WHERE a.id = $1`, approvalID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query payout approval: %w", err)
+	}
+	approvals, err := scanPayoutApprovals(rows)
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+	if len(approvals) == 0 {
+		return nil, ErrApprovalNotFound
+	}
+
+	if err := r.loadVotes(ctx, approvals); err != nil {
+		return nil, err
+	}
+	return &approvals[0], nil
+}
+
+// RecordApprovalVote records an admin's decision and resolves the approval
+// when it is decided
+func (r *SQLPayoutApprovalRepository) RecordApprovalVote(ctx context.Context, approvalID int64, vote ApprovalVote) (*PayoutApproval, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var status ApprovalStatus
+	var payoutID int64
+	var required int
+	err = tx.QueryRowContext(ctx, `
+		SELECT status, payout_id, required_approvals
+		FROM payout_approvals
+		WHERE id = $1
+		FOR UPDATE
+	`, approvalID).Scan(&status, &payoutID, &required)
+	if err == sql.ErrNoRows {
+		return nil, ErrApprovalNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payout approval: %w", err)
+	}
+	if status != ApprovalStatusPending {
+		return nil, ErrApprovalResolved
+	}
+
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO payout_approval_votes (approval_id, admin_id, decision, note)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (approval_id, admin_id) DO NOTHING
+	`, approvalID, vote.AdminID, vote.Decision, sql.NullString{String: vote.Note, Valid: vote.Note != ""})
+	if err != nil {
+		return nil, fmt.Errorf("failed to record approval vote: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return nil, ErrAlreadyVoted
+	}
+
+	switch vote.Decision {
+	case ApprovalDecisionReject:
+		err = resolveApproval(ctx, tx, approvalID, ApprovalStatusRejected, `
+			UPDATE pending_payouts
+			SET status = $2, error_message = $3
+			WHERE id = $1 AND status = $4
+		`, payoutID, PayoutStatusFailed, rejectionReason(vote.Note), PayoutStatusHeld)
+	default:
+		var approvals int
+		err = tx.QueryRowContext(ctx, `
+			SELECT COUNT(*) FROM payout_approval_votes
+			WHERE approval_id = $1 AND decision = $2
+		`, approvalID, ApprovalDecisionApprove).Scan(&approvals)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count approvals: %w", err)
+		}
+		if approvals >= required {
+			err = resolveApproval(ctx, tx, approvalID, ApprovalStatusApproved, `
+				UPDATE pending_payouts
+				SET status = $2, approved_at = NOW()
+				WHERE id = $1 AND status = $3
+			`, payoutID, PayoutStatusPending, PayoutStatusHeld)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit approval vote: %w", err)
+	}
+	return r.GetPayoutApproval(ctx, approvalID)
+}
+
+// resolveApproval closes an approval and applies the outcome to its payout
+func resolveApproval(ctx context.Context, tx *sql.Tx, approvalID int64, status ApprovalStatus, payoutUpdate string, args ...interface{}) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE payout_approvals
+		SET status = $2, resolved_at = NOW()
+		WHERE id = $1
+	`, approvalID, status)
+	if err != nil {
+		return fmt.Errorf("failed to resolve payout approval: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, payoutUpdate, args...); err != nil {
+		return fmt.Errorf("failed to update held payout: %w", err)
+	}
+	return nil
+}
+
+// loadVotes fills in the votes cast on each approval
+func (r *SQLPayoutApprovalRepository) loadVotes(ctx context.Context, approvals []PayoutApproval) error {
+	if len(approvals) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(approvals))
+	index := make(map[int64]int, len(approvals))
+	for i, approval := range approvals {
+		ids[i] = approval.ID
+		index[approval.ID] = i
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT approval_id, admin_id, decision, COALESCE(note, ''), created_at
+		FROM payout_approval_votes
+		WHERE approval_id = ANY($1)
+		ORDER BY created_at ASC
+	`, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to query approval votes: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var approvalID int64
+		var vote ApprovalVote
+		if err := rows.Scan(&approvalID, &vote.AdminID, &vote.Decision, &vote.Note, &vote.CreatedAt); err != nil {
+			return fmt.Errorf("failed to scan approval vote: %w", err)
+		}
+		i := index[approvalID]
+		approvals[i].Votes = append(approvals[i].Votes, vote)
+	}
+
+	return rows.Err()
+}
+
+// scanPayoutApprovals scans payoutApprovalQuery rows
+func scanPayoutApprovals(rows *sql.Rows) ([]PayoutApproval, error) {
+	approvals := make([]PayoutApproval, 0)
+	for rows.Next() {
+		var a PayoutApproval
+		var resolvedAt, approvedAt sql.NullTime
+		var errorMsg sql.NullString
+		var payoutMode string
+
+		err := rows.Scan(
+			&a.ID, pq.Array(&a.Reasons), &a.Status, &a.RequiredApprovals, &a.CreatedAt, &resolvedAt,
+			&a.Payout.ID, &a.Payout.UserID, &a.Payout.Amount, &a.Payout.Address, &a.Payout.Status,
+			&payoutMode, &a.Payout.BlockID, &a.Payout.CreatedAt, &errorMsg, &approvedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan payout approval: %w", err)
+		}
+
+		a.Payout.PayoutMode = PayoutMode(payoutMode)
+		a.Payout.ErrorMessage = errorMsg.String
+		if approvedAt.Valid {
+			a.Payout.ApprovedAt = &approvedAt.Time
+		}
+		if resolvedAt.Valid {
+			a.ResolvedAt = &resolvedAt.Time
+		}
+		a.Votes = make([]ApprovalVote, 0)
+
+		approvals = append(approvals, a)
+	}
+
+	return approvals, rows.Err()
+}
+
+// Ensure implementations satisfy their interfaces
+var (
+	_ PayoutPolicy             = (*PayoutApprovalService)(nil)
+	_ PayoutApprovalRepository = (*SQLPayoutApprovalRepository)(nil)
+)
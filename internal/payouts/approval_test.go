@@ -0,0 +1,380 @@
+package payouts
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// MOCK APPROVAL REPOSITORY FOR TESTING
+// =============================================================================
+
+type mockApprovalRepository struct {
+	poolVolume int64
+	userVolume map[int64]int64
+	addressSet map[string]time.Time
+	approvals  map[int64]*PayoutApproval
+	queue      *MockPayoutRepository // Held payouts leave this queue
+	nextID     int64
+	mu         sync.Mutex
+}
+
+func newMockApprovalRepository(queue *MockPayoutRepository) *mockApprovalRepository {
+	return &mockApprovalRepository{
+		userVolume: make(map[int64]int64),
+		addressSet: make(map[string]time.Time),
+		approvals:  make(map[int64]*PayoutApproval),
+		queue:      queue,
+	}
+}
+
+func (m *mockApprovalRepository) GetPayoutVolume(ctx context.Context, since time.Time) (int64, map[int64]int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	users := make(map[int64]int64)
+	for k, v := range m.userVolume {
+		users[k] = v
+	}
+	return m.poolVolume, users, nil
+}
+
+func (m *mockApprovalRepository) GetAddressSetAt(ctx context.Context, userID int64, address string) (time.Time, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	setAt, ok := m.addressSet[address]
+	return setAt, ok, nil
+}
+
+func (m *mockApprovalRepository) HoldPayout(ctx context.Context, approval *PayoutApproval) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.queue != nil {
+		m.queue.mu.Lock()
+		for i, p := range m.queue.pendingPayouts {
+			if p.ID == approval.Payout.ID {
+				m.queue.pendingPayouts = append(m.queue.pendingPayouts[:i], m.queue.pendingPayouts[i+1:]...)
+				break
+			}
+		}
+		m.queue.mu.Unlock()
+	}
+	m.nextID++
+	approval.ID = m.nextID
+	approval.Payout.Status = PayoutStatusHeld
+	approval.Votes = []ApprovalVote{}
+	held := *approval
+	m.approvals[held.ID] = &held
+	return nil
+}
+
+func (m *mockApprovalRepository) GetPayoutApprovals(ctx context.Context, status ApprovalStatus, limit int) ([]PayoutApproval, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var result []PayoutApproval
+	for id := int64(1); id <= m.nextID; id++ {
+		if a := m.approvals[id]; a.Status == status {
+			result = append(result, *a)
+		}
+	}
+	return result, nil
+}
+
+func (m *mockApprovalRepository) GetPayoutApproval(ctx context.Context, approvalID int64) (*PayoutApproval, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	a, ok := m.approvals[approvalID]
+	if !ok {
+		return nil, ErrApprovalNotFound
+	}
+	copied := *a
+	return &copied, nil
+}
+
+func (m *mockApprovalRepository) RecordApprovalVote(ctx context.Context, approvalID int64, vote ApprovalVote) (*PayoutApproval, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	a, ok := m.approvals[approvalID]
+	if !ok {
+		return nil, ErrApprovalNotFound
+	}
+	if a.Status != ApprovalStatusPending {
+		return nil, ErrApprovalResolved
+	}
+	for _, v := range a.Votes {
+		if v.AdminID == vote.AdminID {
+			return nil, ErrAlreadyVoted
+		}
+	}
+	a.Votes = append(a.Votes, vote)
+
+	switch {
+	case vote.Decision == ApprovalDecisionReject:
+		a.Status = ApprovalStatusRejected
+		a.Payout.Status = PayoutStatusFailed
+	case a.Approvals() >= a.RequiredApprovals:
+		a.Status = ApprovalStatusApproved
+		now := time.Now()
+		a.Payout.Status = PayoutStatusPending
+		a.Payout.ApprovedAt = &now
+		if m.queue != nil {
+			m.queue.mu.Lock()
+			m.queue.pendingPayouts = append(m.queue.pendingPayouts, a.Payout)
+			m.queue.mu.Unlock()
+		}
+	}
+	copied := *a
+	return &copied, nil
+}
+
+// recordingPayoutLedger records refunded payouts
+type recordingPayoutLedger struct {
+	refunded []PendingPayout
+}
+
+func (l *recordingPayoutLedger) CompletePayout(ctx context.Context, payout PendingPayout, txHash string) error {
+	return nil
+}
+
+func (l *recordingPayoutLedger) RefundPayout(ctx context.Context, payout PendingPayout, reason string) error {
+	l.refunded = append(l.refunded, payout)
+	return nil
+}
+
+func (l *recordingPayoutLedger) RecordNetworkFee(ctx context.Context, txHash string, fee int64) error {
+	return nil
+}
+
+// =============================================================================
+// PAYOUT APPROVAL TESTS
+// =============================================================================
+
+func TestPayoutApprovalService_Evaluate(t *testing.T) {
+	ctx := context.Background()
+	config := ApprovalConfig{
+		MaxPayoutAmount:    5000000,
+		MaxUserDailyAmount: 8000000,
+		MaxPoolDailyAmount: 15000000,
+		AddressChangeHold:  48 * time.Hour,
+		RequiredApprovals:  2,
+	}
+
+	t.Run("clears payouts within the limits", func(t *testing.T) {
+		repo := newMockApprovalRepository(nil)
+		repo.addressSet["ltc1qminer1"] = time.Now().Add(-30 * 24 * time.Hour)
+		repo.addressSet["ltc1qminer2"] = time.Now().Add(-30 * 24 * time.Hour)
+		service := NewPayoutApprovalService(repo, config)
+		payouts := []PendingPayout{
+			{ID: 1, UserID: 1, Amount: 4000000, Address: "ltc1qminer1"},
+			{ID: 2, UserID: 2, Amount: 4000000, Address: "ltc1qminer2"},
+		}
+
+		cleared, held, err := service.Evaluate(ctx, payouts)
+		require.NoError(t, err)
+		assert.Equal(t, payouts, cleared)
+		assert.Empty(t, held)
+	})
+
+	t.Run("holds payouts over each limit", func(t *testing.T) {
+		repo := newMockApprovalRepository(nil)
+		repo.poolVolume = 9000000
+		repo.userVolume[2] = 6000000
+		repo.addressSet["ltc1qnewaddress"] = time.Now().Add(-time.Hour)
+		repo.addressSet["ltc1qoldaddress"] = time.Now().Add(-30 * 24 * time.Hour)
+		service := NewPayoutApprovalService(repo, config)
+
+		cleared, held, err := service.Evaluate(ctx, []PendingPayout{
+			{ID: 1, UserID: 1, Amount: 5500000, Address: "ltc1qoldaddress"}, // single payout limit
+			{ID: 2, UserID: 2, Amount: 3000000, Address: "ltc1qoldaddress"}, // user daily limit
+			{ID: 3, UserID: 3, Amount: 1000000, Address: "ltc1qnewaddress"}, // address changed
+			{ID: 4, UserID: 4, Amount: 3000000, Address: "ltc1qoldaddress"}, // clear
+			{ID: 5, UserID: 5, Amount: 4000000, Address: "ltc1qoldaddress"}, // pool limit after payout 4
+		})
+		require.NoError(t, err)
+
+		require.Len(t, cleared, 1)
+		assert.Equal(t, int64(4), cleared[0].ID)
+		require.Len(t, held, 4)
+		for i, id := range []int64{1, 2, 3, 5} {
+			assert.Equal(t, id, held[i].Payout.ID)
+			assert.Len(t, held[i].Reasons, 1)
+			assert.Equal(t, 2, held[i].RequiredApprovals)
+		}
+		assert.Contains(t, held[0].Reasons[0], "single payout limit")
+		assert.Contains(t, held[1].Reasons[0], "24h")
+		assert.Contains(t, held[2].Reasons[0], "address set")
+		assert.Contains(t, held[3].Reasons[0], "pool would pay out")
+	})
+
+	t.Run("holds payouts to addresses with no known set time", func(t *testing.T) {
+		repo := newMockApprovalRepository(nil)
+		repo.addressSet["ltc1qoldaddress"] = time.Now().Add(-30 * 24 * time.Hour)
+		service := NewPayoutApprovalService(repo, config)
+
+		cleared, held, err := service.Evaluate(ctx, []PendingPayout{
+			{ID: 1, UserID: 1, Amount: 1000000, Address: "ltc1qoldaddress"},
+			{ID: 2, UserID: 2, Amount: 1000000, Address: "ltc1qunknown"},
+		})
+		require.NoError(t, err)
+		require.Len(t, cleared, 1)
+		assert.Equal(t, int64(1), cleared[0].ID)
+		require.Len(t, held, 1)
+		assert.Equal(t, int64(2), held[0].Payout.ID)
+		assert.Contains(t, held[0].Reasons[0], "no known set time")
+	})
+
+	t.Run("approved payouts are not held again", func(t *testing.T) {
+		repo := newMockApprovalRepository(nil)
+		repo.addressSet["ltc1qminer1"] = time.Now().Add(-30 * 24 * time.Hour)
+		service := NewPayoutApprovalService(repo, config)
+		approvedAt := time.Now()
+
+		cleared, held, err := service.Evaluate(ctx, []PendingPayout{
+			{ID: 1, UserID: 1, Amount: 6000000, Address: "ltc1qminer1", ApprovedAt: &approvedAt},
+			{ID: 2, UserID: 1, Amount: 3000000, Address: "ltc1qminer1"},
+		})
+		require.NoError(t, err)
+		require.Len(t, cleared, 1)
+		assert.Equal(t, int64(1), cleared[0].ID)
+		require.Len(t, held, 1, "approved payouts still count towards the daily limits")
+		assert.Equal(t, int64(2), held[0].Payout.ID)
+	})
+}
+
+func TestPayoutApprovalService_Decisions(t *testing.T) {
+	ctx := context.Background()
+	config := DefaultApprovalConfig()
+
+	hold := func(t *testing.T, repo *mockApprovalRepository, service *PayoutApprovalService) int64 {
+		approval := &PayoutApproval{
+			Payout:            PendingPayout{ID: 7, UserID: 42, Amount: 2000000000, Address: "ltc1qminer"},
+			Reasons:           []string{"too large"},
+			Status:            ApprovalStatusPending,
+			RequiredApprovals: config.RequiredApprovals,
+		}
+		require.NoError(t, service.Hold(ctx, approval))
+		return approval.ID
+	}
+
+	t.Run("releases a payout after two distinct admins approve", func(t *testing.T) {
+		repo := newMockApprovalRepository(nil)
+		service := NewPayoutApprovalService(repo, config)
+		id := hold(t, repo, service)
+
+		approval, err := service.Approve(ctx, id, 1, "checked with the miner")
+		require.NoError(t, err)
+		assert.Equal(t, ApprovalStatusPending, approval.Status)
+
+		_, err = service.Approve(ctx, id, 1, "")
+		assert.ErrorIs(t, err, ErrAlreadyVoted)
+
+		approval, err = service.Approve(ctx, id, 2, "")
+		require.NoError(t, err)
+		assert.Equal(t, ApprovalStatusApproved, approval.Status)
+		assert.Equal(t, PayoutStatusPending, approval.Payout.Status)
+		assert.NotNil(t, approval.Payout.ApprovedAt)
+
+		_, err = service.Reject(ctx, id, 3, "")
+		assert.ErrorIs(t, err, ErrApprovalResolved)
+	})
+
+	t.Run("admins cannot approve their own payouts", func(t *testing.T) {
+		repo := newMockApprovalRepository(nil)
+		service := NewPayoutApprovalService(repo, config)
+		id := hold(t, repo, service)
+
+		_, err := service.Approve(ctx, id, 42, "")
+		assert.ErrorIs(t, err, ErrSelfApproval)
+
+		_, err = service.Approve(ctx, 99, 1, "")
+		assert.ErrorIs(t, err, ErrApprovalNotFound)
+	})
+
+	t.Run("a rejection refunds the payout and notifies the miner", func(t *testing.T) {
+		repo := newMockApprovalRepository(nil)
+		ledger := &recordingPayoutLedger{}
+		notifier := &recordingConfirmationNotifier{}
+		service := NewPayoutApprovalService(repo, config)
+		service.SetLedger(ledger)
+		service.SetNotifier(notifier)
+		id := hold(t, repo, service)
+
+		_, err := service.Approve(ctx, id, 1, "")
+		require.NoError(t, err)
+		approval, err := service.Reject(ctx, id, 2, "address reported stolen")
+		require.NoError(t, err)
+
+		assert.Equal(t, ApprovalStatusRejected, approval.Status)
+		require.Len(t, ledger.refunded, 1)
+		assert.Equal(t, int64(7), ledger.refunded[0].ID)
+		assert.Equal(t, []string{"rejected by admin: address reported stolen"}, notifier.failed)
+	})
+}
+
+func TestPayoutProcessor_ApprovalPolicy(t *testing.T) {
+	ctx := context.Background()
+	config := ApprovalConfig{MaxPayoutAmount: 5000000, RequiredApprovals: 2}
+
+	t.Run("holds flagged payouts and sends them once approved", func(t *testing.T) {
+		wallet := NewMockWalletClient()
+		repo := NewMockPayoutRepository()
+		repo.AddPendingPayout(PendingPayout{UserID: 1, Amount: 1000000, Address: "ltc1qsmallpayout"})
+		repo.AddPendingPayout(PendingPayout{UserID: 2, Amount: 9000000, Address: "ltc1qlargepayout"})
+		approvals := NewPayoutApprovalService(newMockApprovalRepository(repo), config)
+		processor := NewPayoutProcessor(wallet, repo, NewMockBalanceTracker(), DefaultProcessorConfig())
+		processor.SetPolicy(approvals)
+
+		require.NoError(t, processor.ProcessPendingPayouts(ctx))
+		require.Len(t, wallet.GetTransactions(), 1)
+		assert.Equal(t, "ltc1qsmallpayout", wallet.GetTransactions()[0].Address)
+		assert.Equal(t, int64(1), processor.GetStats().PayoutsHeld)
+
+		held, err := approvals.ListApprovals(ctx, ApprovalStatusPending, 10)
+		require.NoError(t, err)
+		require.Len(t, held, 1)
+		_, err = approvals.Approve(ctx, held[0].ID, 100, "")
+		require.NoError(t, err)
+		_, err = approvals.Approve(ctx, held[0].ID, 101, "")
+		require.NoError(t, err)
+
+		require.NoError(t, processor.ProcessPendingPayouts(ctx))
+		require.Len(t, wallet.GetTransactions(), 2)
+		assert.Equal(t, "ltc1qlargepayout", wallet.GetTransactions()[1].Address)
+	})
+
+	t.Run("dry run plans without sending or holding", func(t *testing.T) {
+		wallet := newMockBatchWallet()
+		wallet.feeRate = 20000
+		repo := newMockBatchRepository()
+		repo.add(1, 1000000, "ltc1qsmallpayout", time.Now())
+		repo.add(2, 2000000, "ltc1qsecondpayout", time.Now())
+		repo.add(3, 9000000, "ltc1qlargepayout", time.Now())
+		repo.add(4, 100, "ltc1qdustpayout", time.Now())
+		processorConfig := DefaultProcessorConfig()
+		processorConfig.DryRun = true
+		processor := NewPayoutProcessor(wallet, repo, NewMockBalanceTracker(), processorConfig)
+		processor.SetBatching(wallet, repo)
+		processor.SetPolicy(NewPayoutApprovalService(newMockApprovalRepository(nil), config))
+
+		require.NoError(t, processor.ProcessPendingPayouts(ctx))
+		assert.Empty(t, wallet.sent)
+
+		plan := processor.LastPlan()
+		require.NotNil(t, plan)
+		assert.True(t, plan.Batched)
+		assert.Equal(t, int64(20000), plan.FeeRate)
+		require.Len(t, plan.Transactions, 1)
+		assert.Len(t, plan.Transactions[0].Payouts, 2)
+		assert.Equal(t, int64(3000000), plan.TotalAmount)
+		require.Len(t, plan.Held, 1)
+		assert.Equal(t, int64(3), plan.Held[0].Payout.ID)
+		assert.Len(t, plan.BelowMinimum, 1)
+		for id := int64(1); id <= 4; id++ {
+			assert.Equal(t, PayoutStatusPending, repo.payout(id).Status)
+		}
+	})
+}
@@ -0,0 +1,662 @@
+package payouts
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+)
+
+// =============================================================================
+// ISP-COMPLIANT INTERFACES FOR PAYOUT BACKTESTING
+// =============================================================================
+
+// BacktestSource loads the blocks and shares a backtest replays (ISP)
+type BacktestSource interface {
+	// GetBacktestBlocks returns the blocks found between from and to
+	GetBacktestBlocks(ctx context.Context, from, to time.Time) ([]BacktestBlock, error)
+	// GetBacktestShares returns the valid shares submitted between from and to
+	GetBacktestShares(ctx context.Context, from, to time.Time) ([]Share, error)
+}
+
+// =============================================================================
+// BACKTEST DATA AND CONFIGURATION
+// =============================================================================
+
+// ErrInvalidBacktest is returned for a backtest request with an unknown mode
+// or an invalid candidate configuration
+var ErrInvalidBacktest = errors.New("invalid backtest request")
+
+// BacktestBlock is a found block replayed by a backtest. Its Difficulty is
+// the network difficulty the per-share modes are priced at.
+type BacktestBlock struct {
+	Block
+	TxFees int64 `json:"tx_fees"`
+}
+
+// BacktestData is the history a backtest replays. Shares start ShareLookback
+// before From so the first blocks have a full window.
+type BacktestData struct {
+	From   time.Time       `json:"from"`
+	To     time.Time       `json:"to"`
+	Blocks []BacktestBlock `json:"blocks"`
+	Shares []Share         `json:"shares"`
+}
+
+// ReadBacktestData reads backtest data exported with WriteBacktestData
+func ReadBacktestData(r io.Reader) (*BacktestData, error) {
+	var data BacktestData
+	if err := json.NewDecoder(r).Decode(&data); err != nil {
+		return nil, fmt.Errorf("failed to decode backtest data: %w", err)
+	}
+	return &data, nil
+}
+
+// WriteBacktestData exports backtest data as JSON
+func WriteBacktestData(w io.Writer, data *BacktestData) error {
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		return fmt.Errorf("failed to encode backtest data: %w", err)
+	}
+	return nil
+}
+
+// BacktestConfig holds configuration for a payout backtest
+type BacktestConfig struct {
+	Payouts       *PayoutConfig `json:"payouts" yaml:"payouts"`               // Fees and windows; modes are replayed whether enabled or not
+	Modes         []PayoutMode  `json:"modes" yaml:"modes"`                   // Modes to replay, all by default
+	ShareLookback time.Duration `json:"share_lookback" yaml:"share_lookback"` // How far before a block the window modes look for shares
+}
+
+// DefaultBacktestConfig returns sensible defaults
+func DefaultBacktestConfig() BacktestConfig {
+	return BacktestConfig{
+		Payouts:       DefaultPayoutConfig(),
+		Modes:         AllPayoutModes(),
+		ShareLookback: 24 * time.Hour,
+	}
+}
+
+// =============================================================================
+// BACKTEST REPORTS
+// =============================================================================
+
+// BacktestReport is what every replayed mode would have paid
+type BacktestReport struct {
+	From           time.Time            `json:"from"`
+	To             time.Time            `json:"to"`
+	Blocks         int                  `json:"blocks"`
+	OrphanedBlocks int                  `json:"orphaned_blocks"`
+	Shares         int                  `json:"shares"`
+	Revenue        int64                `json:"revenue"` // Rewards and fees of blocks that were not orphaned
+	Modes          []BacktestModeResult `json:"modes"`
+	Users          []BacktestUserResult `json:"users"`
+}
+
+// BacktestModeResult is what one mode would have paid, and what it would
+// have cost the pool
+type BacktestModeResult struct {
+	Mode                PayoutMode             `json:"mode"`
+	FeePercent          float64                `json:"fee_percent"`
+	TotalPaid           int64                  `json:"total_paid"`
+	PoolProfit          int64                  `json:"pool_profit"` // Revenue less payouts; negative is a loss
+	EffectiveFeePercent float64                `json:"effective_fee_percent"`
+	ProfitStdDev        float64                `json:"profit_std_dev"` // Of the pool's profit per block
+	MaxShortfall        int64                  `json:"max_shortfall"`  // Most the pool had paid out ahead of its revenue
+	Users               []BacktestUserEarnings `json:"users"`
+}
+
+// BacktestUserEarnings is what one mode would have paid a user. Mean,
+// variance and standard deviation are per replayed block, counting blocks
+// that paid the user nothing.
+type BacktestUserEarnings struct {
+	UserID     int64   `json:"user_id"`
+	Earnings   int64   `json:"earnings"`
+	PaidBlocks int     `json:"paid_blocks"`
+	Mean       float64 `json:"mean"`
+	Variance   float64 `json:"variance"`
+	StdDev     float64 `json:"std_dev"`
+}
+
+// BacktestUserResult compares a user's earnings across modes
+type BacktestUserResult struct {
+	UserID          int64                `json:"user_id"`
+	ShareDifficulty float64              `json:"share_difficulty"` // Valid difficulty submitted in the range
+	Earnings        map[PayoutMode]int64 `json:"earnings"`
+	BestMode        PayoutMode           `json:"best_mode"`
+}
+
+// ForUser returns the report with only one user's results
+func (r *BacktestReport) ForUser(userID int64) *BacktestReport {
+	filtered := *r
+	filtered.Modes = make([]BacktestModeResult, len(r.Modes))
+	for i, mode := range r.Modes {
+		mode.Users = make([]BacktestUserEarnings, 0, 1)
+		for _, user := range r.Modes[i].Users {
+			if user.UserID == userID {
+				mode.Users = append(mode.Users, user)
+			}
+		}
+		filtered.Modes[i] = mode
+	}
+	filtered.Users = make([]BacktestUserResult, 0, 1)
+	for _, user := range r.Users {
+		if user.UserID == userID {
+			filtered.Users = append(filtered.Users, user)
+		}
+	}
+	return &filtered
+}
+
+// =============================================================================
+// BACKTEST ENGINE
+// =============================================================================
+
+// BacktestEngine replays historical blocks and shares through the payout
+// calculators as if the whole pool had used each mode
+type BacktestEngine struct {
+	config      BacktestConfig
+	calculators map[PayoutMode]PayoutCalculator
+}
+
+// NewBacktestEngine creates a new backtest engine
+func NewBacktestEngine(config BacktestConfig) (*BacktestEngine, error) {
+	defaults := DefaultBacktestConfig()
+	if config.Payouts == nil {
+		config.Payouts = defaults.Payouts
+	}
+	if len(config.Modes) == 0 {
+		config.Modes = defaults.Modes
+	}
+	if config.ShareLookback <= 0 {
+		config.ShareLookback = defaults.ShareLookback
+	}
+
+	engine := &BacktestEngine{config: config, calculators: make(map[PayoutMode]PayoutCalculator)}
+	for _, mode := range config.Modes {
+		if !mode.IsValid() {
+			return nil, fmt.Errorf("invalid payout mode %q", mode)
+		}
+		calc, err := newPayoutCalculator(mode, config.Payouts)
+		if err != nil {
+			return nil, err
+		}
+		engine.calculators[mode] = calc
+	}
+	return engine, nil
+}
+
+// LoadData loads the blocks between from and to, and the shares from
+// ShareLookback before from
+func (e *BacktestEngine) LoadData(ctx context.Context, source BacktestSource, from, to time.Time) (*BacktestData, error) {
+	blocks, err := source.GetBacktestBlocks(ctx, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load blocks: %w", err)
+	}
+	shares, err := source.GetBacktestShares(ctx, from.Add(-e.config.ShareLookback), to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load shares: %w", err)
+	}
+	return &BacktestData{From: from, To: to, Blocks: blocks, Shares: shares}, nil
+}
+
+// backtestRound is one replayed block and the shares paid for it
+type backtestRound struct {
+	block    BacktestBlock
+	orphaned bool
+	revenue  int64
+	window   []Share // Shares within ShareLookback of the block, for the window modes
+	round    []Share // Shares since the previous block, for the per-share modes
+}
+
+// Run replays the data through every configured mode. Window modes pay each
+// block from the shares before it; per-share modes pay the shares since the
+// previous block at that block's network difficulty, orphaned or not.
+// Shares after the last block are not replayed.
+func (e *BacktestEngine) Run(data *BacktestData) (*BacktestReport, error) {
+	blocks := append([]BacktestBlock(nil), data.Blocks...)
+	sort.SliceStable(blocks, func(i, j int) bool {
+		return blocks[i].Timestamp.Before(blocks[j].Timestamp)
+	})
+	shares := append([]Share(nil), data.Shares...)
+	sort.SliceStable(shares, func(i, j int) bool {
+		return shares[i].Timestamp.Before(shares[j].Timestamp)
+	})
+
+	// sharesUntil returns the index of the first share after t
+	sharesUntil := func(t time.Time) int {
+		return sort.Search(len(shares), func(i int) bool { return shares[i].Timestamp.After(t) })
+	}
+
+	report := &BacktestReport{From: data.From, To: data.To, Blocks: len(blocks)}
+	rounds := make([]backtestRound, len(blocks))
+	first := sort.Search(len(shares), func(i int) bool { return !shares[i].Timestamp.Before(data.From) })
+	roundStart := first
+	for i, block := range blocks {
+		end := sharesUntil(block.Timestamp)
+		rounds[i] = backtestRound{
+			block:    block,
+			orphaned: block.Status == BlockStatusOrphaned,
+			window:   shares[sharesUntil(block.Timestamp.Add(-e.config.ShareLookback)):end],
+			round:    shares[min(roundStart, end):end],
+		}
+		roundStart = end
+		if rounds[i].orphaned {
+			report.OrphanedBlocks++
+		} else {
+			rounds[i].revenue = block.Reward + block.TxFees
+			report.Revenue += rounds[i].revenue
+		}
+	}
+
+	difficulty := make(map[int64]float64)
+	for _, share := range shares[first:] {
+		if share.IsValid && (data.To.IsZero() || !share.Timestamp.After(data.To)) {
+			difficulty[share.UserID] += share.Difficulty
+			report.Shares++
+		}
+	}
+
+	earnings := make(map[int64]map[PayoutMode]int64)
+	for _, mode := range e.config.Modes {
+		result, err := e.replayMode(mode, rounds)
+		if err != nil {
+			return nil, err
+		}
+		if report.Revenue > 0 {
+			result.EffectiveFeePercent = float64(result.PoolProfit) / float64(report.Revenue) * 100
+		}
+		for _, user := range result.Users {
+			if earnings[user.UserID] == nil {
+				earnings[user.UserID] = make(map[PayoutMode]int64)
+			}
+			earnings[user.UserID][mode] = user.Earnings
+		}
+		report.Modes = append(report.Modes, *result)
+	}
+
+	for userID := range difficulty {
+		if earnings[userID] == nil {
+			earnings[userID] = make(map[PayoutMode]int64)
+		}
+	}
+	report.Users = make([]BacktestUserResult, 0, len(earnings))
+	for userID, byMode := range earnings {
+		user := BacktestUserResult{UserID: userID, ShareDifficulty: difficulty[userID], Earnings: byMode}
+		for _, mode := range e.config.Modes {
+			if _, ok := byMode[mode]; !ok {
+				byMode[mode] = 0
+			}
+			if user.BestMode == "" || byMode[mode] > byMode[user.BestMode] {
+				user.BestMode = mode
+			}
+		}
+		report.Users = append(report.Users, user)
+	}
+	sort.Slice(report.Users, func(i, j int) bool { return report.Users[i].UserID < report.Users[j].UserID })
+
+	return report, nil
+}
+
+// replayMode pays every round with one mode's calculator
+func (e *BacktestEngine) replayMode(mode PayoutMode, rounds []backtestRound) (*BacktestModeResult, error) {
+	calc := e.calculators[mode]
+	result := &BacktestModeResult{Mode: mode, FeePercent: calc.GetPoolFeePercent()}
+
+	perRound := make(map[int64][]int64)
+	var balance, lowest int64
+	var profitSum, profitSquares float64
+	for i, round := range rounds {
+		block := round.block
+
+		var payouts []Payout
+		var err error
+		if perShare, ok := calc.(ExpectedValueCalculator); ok {
+			if block.Difficulty <= 0 {
+				return nil, fmt.Errorf("block %d has no network difficulty to price %s shares", block.Height, mode)
+			}
+			perShare.SetNetworkDifficulty(block.Difficulty)
+			perShare.SetExpectedTxFees(block.TxFees)
+			txFees := block.TxFees
+			if round.orphaned {
+				txFees = 0
+			}
+			payouts, err = calc.CalculatePayouts(round.round, block.Reward, txFees, block.Timestamp)
+		} else if !round.orphaned {
+			payouts, err = calc.CalculatePayouts(round.window, block.Reward, block.TxFees, block.Timestamp)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to replay block %d with %s: %w", block.Height, mode, err)
+		}
+
+		var paid int64
+		for _, payout := range payouts {
+			if perRound[payout.UserID] == nil {
+				perRound[payout.UserID] = make([]int64, len(rounds))
+			}
+			perRound[payout.UserID][i] += payout.Amount
+			paid += payout.Amount
+		}
+		result.TotalPaid += paid
+
+		profit := round.revenue - paid
+		profitSum += float64(profit)
+		profitSquares += float64(profit) * float64(profit)
+		balance += profit
+		lowest = min(lowest, balance)
+	}
+
+	result.PoolProfit = balance
+	result.MaxShortfall = -lowest
+	if n := float64(len(rounds)); n > 0 {
+		mean := profitSum / n
+		result.ProfitStdDev = math.Sqrt(math.Max(profitSquares/n-mean*mean, 0))
+	}
+
+	result.Users = make([]BacktestUserEarnings, 0, len(perRound))
+	for userID, amounts := range perRound {
+		result.Users = append(result.Users, userEarnings(userID, amounts))
+	}
+	sort.Slice(result.Users, func(i, j int) bool { return result.Users[i].UserID < result.Users[j].UserID })
+
+	return result, nil
+}
+
+// userEarnings summarises a user's earnings per round
+func userEarnings(userID int64, amounts []int64) BacktestUserEarnings {
+	earnings := BacktestUserEarnings{UserID: userID}
+	var squares float64
+	for _, amount := range amounts {
+		earnings.Earnings += amount
+		squares += float64(amount) * float64(amount)
+		if amount > 0 {
+			earnings.PaidBlocks++
+		}
+	}
+	n := float64(len(amounts))
+	earnings.Mean = float64(earnings.Earnings) / n
+	earnings.Variance = math.Max(squares/n-earnings.Mean*earnings.Mean, 0)
+	earnings.StdDev = math.Sqrt(earnings.Variance)
+	return earnings
+}
+
+// =============================================================================
+// CONFIGURATION COMPARISON
+// =============================================================================
+
+// BacktestComparison shows how a candidate configuration changes payouts
+type BacktestComparison struct {
+	Modes []BacktestModeComparison `json:"modes"`
+}
+
+// BacktestModeComparison compares one mode under two configurations. A
+// change that is fair to everyone moves every user by about the same
+// percentage, so the spread between the largest and smallest change is small.
+type BacktestModeComparison struct {
+	Mode             PayoutMode           `json:"mode"`
+	BaselinePaid     int64                `json:"baseline_paid"`
+	CandidatePaid    int64                `json:"candidate_paid"`
+	PoolProfitChange int64                `json:"pool_profit_change"`
+	MinChangePercent float64              `json:"min_change_percent"`
+	MaxChangePercent float64              `json:"max_change_percent"`
+	SpreadPercent    float64              `json:"spread_percent"`
+	Users            []BacktestUserChange `json:"users"`
+}
+
+// BacktestUserChange is how a candidate configuration changes a user's
+// earnings in one mode
+type BacktestUserChange struct {
+	UserID        int64   `json:"user_id"`
+	Baseline      int64   `json:"baseline"`
+	Candidate     int64   `json:"candidate"`
+	Change        int64   `json:"change"`
+	ChangePercent float64 `json:"change_percent"` // Zero when the baseline paid nothing
+}
+
+// CompareBacktests compares the modes two backtests of the same data share
+func CompareBacktests(baseline, candidate *BacktestReport) *BacktestComparison {
+	candidateModes := make(map[PayoutMode]BacktestModeResult, len(candidate.Modes))
+	for _, mode := range candidate.Modes {
+		candidateModes[mode.Mode] = mode
+	}
+
+	comparison := &BacktestComparison{Modes: make([]BacktestModeComparison, 0, len(baseline.Modes))}
+	for _, base := range baseline.Modes {
+		cand, ok := candidateModes[base.Mode]
+		if !ok {
+			continue
+		}
+
+		amounts := make(map[int64][2]int64)
+		for _, user := range base.Users {
+			pair := amounts[user.UserID]
+			pair[0] = user.Earnings
+			amounts[user.UserID] = pair
+		}
+		for _, user := range cand.Users {
+			pair := amounts[user.UserID]
+			pair[1] = user.Earnings
+			amounts[user.UserID] = pair
+		}
+
+		result := BacktestModeComparison{
+			Mode:             base.Mode,
+			BaselinePaid:     base.TotalPaid,
+			CandidatePaid:    cand.TotalPaid,
+			PoolProfitChange: cand.PoolProfit - base.PoolProfit,
+			Users:            make([]BacktestUserChange, 0, len(amounts)),
+		}
+		first := true
+		for userID, pair := range amounts {
+			change := BacktestUserChange{UserID: userID, Baseline: pair[0], Candidate: pair[1], Change: pair[1] - pair[0]}
+			if pair[0] > 0 {
+				change.ChangePercent = float64(change.Change) / float64(pair[0]) * 100
+				if first || change.ChangePercent < result.MinChangePercent {
+					result.MinChangePercent = change.ChangePercent
+				}
+				if first || change.ChangePercent > result.MaxChangePercent {
+					result.MaxChangePercent = change.ChangePercent
+				}
+				first = false
+			}
+			result.Users = append(result.Users, change)
+		}
+		result.SpreadPercent = result.MaxChangePercent - result.MinChangePercent
+		sort.Slice(result.Users, func(i, j int) bool { return result.Users[i].UserID < result.Users[j].UserID })
+
+		comparison.Modes = append(comparison.Modes, result)
+	}
+	return comparison
+}
+
+// =============================================================================
+// BACKTEST SERVICE
+// =============================================================================
+
+// BacktestRequest selects the history to replay and an optional candidate
+// configuration to compare with the current one
+type BacktestRequest struct {
+	From      time.Time     `json:"from"`
+	To        time.Time     `json:"to"`
+	Modes     []PayoutMode  `json:"modes,omitempty"`
+	Candidate *PayoutConfig `json:"candidate,omitempty"`
+}
+
+// BacktestResult is a backtest under the current configuration and, when
+// requested, under the candidate
+type BacktestResult struct {
+	Baseline   *BacktestReport     `json:"baseline"`
+	Candidate  *BacktestReport     `json:"candidate,omitempty"`
+	Comparison *BacktestComparison `json:"comparison,omitempty"`
+}
+
+// PayoutBacktestService runs backtests against a source of history
+type PayoutBacktestService struct {
+	source BacktestSource
+	config BacktestConfig
+}
+
+// NewPayoutBacktestService creates a new backtest service. The config's
+// payout settings are the baseline every candidate is compared with.
+func NewPayoutBacktestService(source BacktestSource, config BacktestConfig) *PayoutBacktestService {
+	if source == nil {
+		return nil
+	}
+	return &PayoutBacktestService{source: source, config: config}
+}
+
+// BaselineConfig returns a copy of the payout configuration candidates are
+// compared with
+func (s *PayoutBacktestService) BaselineConfig() *PayoutConfig {
+	config := *DefaultPayoutConfig()
+	if s.config.Payouts != nil {
+		config = *s.config.Payouts
+	}
+	return &config
+}
+
+// Backtest loads the requested history once and replays it under the
+// current configuration and, when given, the candidate
+func (s *PayoutBacktestService) Backtest(ctx context.Context, req BacktestRequest) (*BacktestResult, error) {
+	config := s.config
+	if len(req.Modes) > 0 {
+		config.Modes = req.Modes
+	}
+	baseline, err := NewBacktestEngine(config)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBacktest, err)
+	}
+
+	data, err := baseline.LoadData(ctx, s.source, req.From, req.To)
+	if err != nil {
+		return nil, err
+	}
+	report, err := baseline.Run(data)
+	if err != nil {
+		return nil, err
+	}
+	result := &BacktestResult{Baseline: report}
+	if req.Candidate == nil {
+		return result, nil
+	}
+
+	config.Payouts = req.Candidate
+	candidate, err := NewBacktestEngine(config)
+	if err != nil {
+		return nil, fmt.Errorf("%w: candidate configuration: %v", ErrInvalidBacktest, err)
+	}
+	result.Candidate, err = candidate.Run(data)
+	if err != nil {
+		return nil, err
+	}
+	result.Comparison = CompareBacktests(result.Baseline, result.Candidate)
+	return result, nil
+}
+
+// =============================================================================
+// SQL BACKTEST SOURCE
+// =============================================================================
+
+// SQLBacktestSource reads backtest history from the blocks and shares tables
+type SQLBacktestSource struct {
+	db *sql.DB
+}
+
+// NewSQLBacktestSource creates a new SQL backtest source
+func NewSQLBacktestSource(db *sql.DB) *SQLBacktestSource {
+	return &SQLBacktestSource{db: db}
+}
+
+// GetBacktestBlocks returns the blocks found between from and to, oldest first
+func (s *SQLBacktestSource) GetBacktestBlocks(ctx context.Context, from, to time.Time) ([]BacktestBlock, error) {
+	query := `
+		SELECT id, height, hash, reward, difficulty, finder_id, status, confirmations, timestamp
+		FROM blocks
+		WHERE timestamp >= $1 AND timestamp <= $2
+		ORDER BY timestamp ASC, id ASC
+	`
+	rows, err := s.db.QueryContext(ctx, query, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query blocks: %w", err)
+	}
+	defer rows.Close()
+
+	var blocks []BacktestBlock
+	for rows.Next() {
+		var b BacktestBlock
+		if err := rows.Scan(&b.ID, &b.Height, &b.Hash, &b.Reward, &b.Difficulty,
+			&b.FinderID, &b.Status, &b.Confirmations, &b.Timestamp); err != nil {
+			return nil, fmt.Errorf("failed to scan block: %w", err)
+		}
+		blocks = append(blocks, b)
+	}
+	return blocks, rows.Err()
+}
+
+// GetBacktestShares returns the valid shares submitted between from and to,
+// oldest first
+func (s *SQLBacktestSource) GetBacktestShares(ctx context.Context, from, to time.Time) ([]Share, error) {
+	query := `
+		SELECT id, user_id, miner_id, difficulty, timestamp
+		FROM shares
+		WHERE is_valid = true AND timestamp >= $1 AND timestamp <= $2
+		ORDER BY timestamp ASC, id ASC
+	`
+	rows, err := s.db.QueryContext(ctx, query, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query shares: %w", err)
+	}
+	defer rows.Close()
+
+	var shares []Share
+	for rows.Next() {
+		share := Share{IsValid: true}
+		if err := rows.Scan(&share.ID, &share.UserID, &share.MinerID, &share.Difficulty, &share.Timestamp); err != nil {
+			return nil, fmt.Errorf("failed to scan share: %w", err)
+		}
+		shares = append(shares, share)
+	}
+	return shares, rows.Err()
+}
+
+// BacktestDataSource serves exported backtest data as a BacktestSource
+type BacktestDataSource struct {
+	data *BacktestData
+}
+
+// NewBacktestDataSource creates a source over exported data
+func NewBacktestDataSource(data *BacktestData) *BacktestDataSource {
+	return &BacktestDataSource{data: data}
+}
+
+// GetBacktestBlocks returns the exported blocks between from and to
+func (s *BacktestDataSource) GetBacktestBlocks(ctx context.Context, from, to time.Time) ([]BacktestBlock, error) {
+	var blocks []BacktestBlock
+	for _, block := range s.data.Blocks {
+		if !block.Timestamp.Before(from) && !block.Timestamp.After(to) {
+			blocks = append(blocks, block)
+		}
+	}
+	return blocks, nil
+}
+
+// GetBacktestShares returns the exported valid shares between from and to
+func (s *BacktestDataSource) GetBacktestShares(ctx context.Context, from, to time.Time) ([]Share, error) {
+	var shares []Share
+	for _, share := range s.data.Shares {
+		if share.IsValid && !share.Timestamp.Before(from) && !share.Timestamp.After(to) {
+			shares = append(shares, share)
+		}
+	}
+	return shares, nil
+}
+
+// Ensure sources implement BacktestSource
+var (
+	_ BacktestSource = (*SQLBacktestSource)(nil)
+	_ BacktestSource = (*BacktestDataSource)(nil)
+)
@@ -0,0 +1,145 @@
+package payouts
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// PAYOUT BACKTEST TESTS
+// =============================================================================
+
+// newBacktestData returns an orphaned block followed by a found one, with a
+// share after the last block that is not replayed
+func newBacktestData() *BacktestData {
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return start.Add(time.Duration(minutes) * time.Minute) }
+
+	return &BacktestData{
+		From: start,
+		To:   at(30),
+		Blocks: []BacktestBlock{
+			{Block: Block{ID: 2, Height: 101, Reward: 1000000, Difficulty: 1000, Status: BlockStatusConfirmed, Timestamp: at(20)}},
+			{Block: Block{ID: 1, Height: 100, Reward: 1000000, Difficulty: 1000, Status: BlockStatusOrphaned, Timestamp: at(10)}},
+		},
+		Shares: []Share{
+			{ID: 1, UserID: 1, Difficulty: 300, IsValid: true, Timestamp: at(1)},
+			{ID: 2, UserID: 2, Difficulty: 100, IsValid: true, Timestamp: at(2)},
+			{ID: 3, UserID: 1, Difficulty: 100, IsValid: true, Timestamp: at(11)},
+			{ID: 4, UserID: 2, Difficulty: 100, IsValid: true, Timestamp: at(25)},
+		},
+	}
+}
+
+func findModeResult(t *testing.T, report *BacktestReport, mode PayoutMode) BacktestModeResult {
+	for _, result := range report.Modes {
+		if result.Mode == mode {
+			return result
+		}
+	}
+	t.Fatalf("mode %s not in report", mode)
+	return BacktestModeResult{}
+}
+
+func TestBacktestEngine_Run(t *testing.T) {
+	engine, err := NewBacktestEngine(BacktestConfig{Modes: []PayoutMode{PayoutModePPS, PayoutModePPLNS}})
+	require.NoError(t, err)
+
+	report, err := engine.Run(newBacktestData())
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Blocks)
+	assert.Equal(t, 1, report.OrphanedBlocks)
+	assert.Equal(t, 4, report.Shares)
+	assert.Equal(t, int64(1000000), report.Revenue)
+
+	t.Run("per-share modes pay every round and carry the pool's risk", func(t *testing.T) {
+		pps := findModeResult(t, report, PayoutModePPS)
+		assert.Equal(t, 2.0, pps.FeePercent)
+		assert.Equal(t, int64(490000), pps.TotalPaid)
+		assert.Equal(t, int64(510000), pps.PoolProfit)
+		assert.Equal(t, int64(392000), pps.MaxShortfall, "the orphaned round was paid before any revenue")
+		assert.InDelta(t, 51.0, pps.EffectiveFeePercent, 0.001)
+		require.Len(t, pps.Users, 2)
+		assert.Equal(t, int64(392000), pps.Users[0].Earnings)
+		assert.Equal(t, 2, pps.Users[0].PaidBlocks)
+		assert.Equal(t, int64(98000), pps.Users[1].Earnings)
+	})
+
+	t.Run("window modes pay found blocks only", func(t *testing.T) {
+		pplns := findModeResult(t, report, PayoutModePPLNS)
+		assert.Equal(t, int64(990000), pplns.TotalPaid)
+		assert.Equal(t, int64(10000), pplns.PoolProfit)
+		assert.Equal(t, int64(0), pplns.MaxShortfall)
+		require.Len(t, pplns.Users, 2)
+		user := pplns.Users[0]
+		assert.Equal(t, int64(792000), user.Earnings)
+		assert.Equal(t, 1, user.PaidBlocks)
+		assert.InDelta(t, 396000, user.Mean, 0.001)
+		assert.InDelta(t, 396000, user.StdDev, 0.001)
+	})
+
+	t.Run("compares modes per user", func(t *testing.T) {
+		require.Len(t, report.Users, 2)
+		assert.Equal(t, PayoutModePPLNS, report.Users[0].BestMode)
+		assert.Equal(t, map[PayoutMode]int64{PayoutModePPS: 98000, PayoutModePPLNS: 198000}, report.Users[1].Earnings)
+		assert.Equal(t, 200.0, report.Users[1].ShareDifficulty)
+
+		own := report.ForUser(2)
+		require.Len(t, own.Users, 1)
+		assert.Equal(t, int64(2), own.Users[0].UserID)
+		for _, mode := range own.Modes {
+			require.Len(t, mode.Users, 1)
+			assert.Equal(t, int64(2), mode.Users[0].UserID)
+		}
+		assert.Len(t, report.Modes[0].Users, 2, "the full report is unchanged")
+	})
+
+	t.Run("per-share modes need the network difficulty", func(t *testing.T) {
+		data := newBacktestData()
+		data.Blocks[0].Difficulty = 0
+		_, err := engine.Run(data)
+		assert.Error(t, err)
+	})
+}
+
+func TestPayoutBacktestService_Backtest(t *testing.T) {
+	ctx := context.Background()
+
+	var exported bytes.Buffer
+	require.NoError(t, WriteBacktestData(&exported, newBacktestData()))
+	data, err := ReadBacktestData(&exported)
+	require.NoError(t, err)
+
+	service := NewPayoutBacktestService(NewBacktestDataSource(data), DefaultBacktestConfig())
+	candidate := DefaultPayoutConfig()
+	candidate.FeePPLNS = 2.0
+
+	result, err := service.Backtest(ctx, BacktestRequest{
+		From:      data.From,
+		To:        data.To,
+		Modes:     []PayoutMode{PayoutModePPLNS},
+		Candidate: candidate,
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Baseline.Modes, 1)
+	require.NotNil(t, result.Candidate)
+	require.NotNil(t, result.Comparison)
+
+	require.Len(t, result.Comparison.Modes, 1)
+	comparison := result.Comparison.Modes[0]
+	assert.Equal(t, int64(990000), comparison.BaselinePaid)
+	assert.Equal(t, int64(980000), comparison.CandidatePaid)
+	assert.Equal(t, int64(10000), comparison.PoolProfitChange)
+	require.Len(t, comparison.Users, 2)
+	assert.Equal(t, int64(-8000), comparison.Users[0].Change)
+	assert.InDelta(t, -1.0101, comparison.MinChangePercent, 0.001)
+	assert.InDelta(t, 0, comparison.SpreadPercent, 0.001, "a fee change moves everyone equally")
+
+	_, err = service.Backtest(ctx, BacktestRequest{From: data.From, To: data.To, Modes: []PayoutMode{"bogus"}})
+	assert.ErrorIs(t, err, ErrInvalidBacktest)
+}
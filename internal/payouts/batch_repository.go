@@ -0,0 +1,262 @@
+package payouts
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// =============================================================================
+// SQL PAYOUT BATCH REPOSITORY IMPLEMENTATION
+// =============================================================================
+
+const batchPayoutColumns = `
+	id, user_id, amount, address, status, payout_mode, COALESCE(block_id, 0),
+	created_at, processed_at, tx_hash, error_message, retry_count`
+
+// CreatePayoutBatch records a batch and assigns the payouts to it
+func (r *SQLPayoutRepository) CreatePayoutBatch(ctx context.Context, batch *PayoutBatch, payoutIDs []int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO payout_batches (label, status, output_count, total_amount, fee_rate)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`, batch.Label, BatchStatusSending, batch.OutputCount, batch.TotalAmount, batch.FeeRate,
+	).Scan(&batch.ID, &batch.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create payout batch: %w", err)
+	}
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE pending_payouts
+		SET batch_id = $1
+		WHERE id = ANY($2) AND status = $3 AND batch_id IS NULL
+	`, batch.ID, pq.Array(payoutIDs), PayoutStatusPending)
+	if err != nil {
+		return fmt.Errorf("failed to assign payouts to batch: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n != int64(len(payoutIDs)) {
+		return ErrPayoutsAlreadyBatched
+	}
+
+	batch.Status = BatchStatusSending
+	return tx.Commit()
+}
+
+// MarkBatchSigned stores a batch's signed transaction before it is broadcast
+func (r *SQLPayoutRepository) MarkBatchSigned(ctx context.Context, batchID int64, signed *SignedTransaction) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE payout_batches
+		SET tx_hash = $2, raw_tx = $3, fee = $4
+		WHERE id = $1 AND status = $5
+	`, batchID, signed.TxID, signed.Hex, signed.Fee, BatchStatusSending)
+	if err != nil {
+		return fmt.Errorf("failed to record batch transaction: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n != 1 {
+		return fmt.Errorf("payout batch %d is not sending", batchID)
+	}
+	return nil
+}
+
+// MarkBatchBroadcast marks a batch and its payouts sent in txHash
+func (r *SQLPayoutRepository) MarkBatchBroadcast(ctx context.Context, batchID int64, txHash string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	_, err = tx.ExecContext(ctx, `
+		UPDATE payout_batches
+		SET status = $2, tx_hash = $3, broadcast_at = $4
+		WHERE id = $1
+	`, batchID, BatchStatusBroadcast, txHash, now)
+	if err != nil {
+		return fmt.Errorf("failed to update payout batch: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE pending_payouts
+		SET status = $2, tx_hash = $3, processed_at = $4
+		WHERE batch_id = $1 AND status = $5
+	`, batchID, PayoutStatusProcessed, txHash, now, PayoutStatusPending)
+	if err != nil {
+		return fmt.Errorf("failed to update batched payouts: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// ReleasePayoutBatch fails a batch that never went out and returns its
+// payouts to the queue, keeping those that have used up maxRetries
+func (r *SQLPayoutRepository) ReleasePayoutBatch(ctx context.Context, batchID int64, reason string, maxRetries int) ([]PendingPayout, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE payout_batches
+		SET status = $2, error_message = $3
+		WHERE id = $1
+	`, batchID, BatchStatusFailed, reason)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update payout batch: %w", err)
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		UPDATE pending_payouts
+		SET retry_count = retry_count + 1,
+		    error_message = $2,
+		    batch_id = CASE WHEN retry_count + 1 >= $3 THEN batch_id END
+		WHERE batch_id = $1 AND status = $4
+		RETURNING `+batchPayoutColumns,
+		batchID, reason, maxRetries, PayoutStatusPending)
+	if err != nil {
+		return nil, fmt.Errorf("failed to release batched payouts: %w", err)
+	}
+	payouts, retries, err := scanBatchPayouts(rows)
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	var exhausted []PendingPayout
+	for i, payout := range payouts {
+		if retries[i] >= maxRetries {
+			exhausted = append(exhausted, payout)
+		}
+	}
+
+	return exhausted, tx.Commit()
+}
+
+// GetSendingBatches returns batches whose broadcast has not been confirmed
+func (r *SQLPayoutRepository) GetSendingBatches(ctx context.Context) ([]PayoutBatch, error) {
+	query := `
+		SELECT id, label, status, COALESCE(tx_hash, ''), COALESCE(raw_tx, ''),
+		       output_count, total_amount, fee_rate, fee, created_at
+		FROM payout_batches
+		WHERE status = $1
+		ORDER BY created_at ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, BatchStatusSending)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query payout batches: %w", err)
+	}
+	defer rows.Close()
+
+	batches := make([]PayoutBatch, 0)
+	for rows.Next() {
+		var b PayoutBatch
+		err := rows.Scan(&b.ID, &b.Label, &b.Status, &b.TxHash, &b.RawTx, &b.OutputCount, &b.TotalAmount, &b.FeeRate, &b.Fee, &b.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan payout batch: %w", err)
+		}
+		batches = append(batches, b)
+	}
+
+	return batches, rows.Err()
+}
+
+// GetUnchargedBatchFees returns batches broadcast since the ledger was
+// started whose network fee has no network_fee posting. The references match
+// Ledger.RecordNetworkFee.
+func (r *SQLPayoutRepository) GetUnchargedBatchFees(ctx context.Context, limit int) ([]PayoutBatch, error) {
+	query := `
+		SELECT b.id, b.label, b.status, b.tx_hash, COALESCE(b.raw_tx, ''),
+		       b.output_count, b.total_amount, b.fee_rate, b.fee, b.created_at
+		FROM payout_batches b
+		WHERE b.status = $1 AND b.fee > 0 AND COALESCE(b.tx_hash, '') <> ''
+		  AND b.broadcast_at >= (SELECT MIN(created_at) FROM ledger_postings)
+		  AND NOT EXISTS (
+		      SELECT 1 FROM ledger_postings lp
+		      WHERE lp.kind = $2 AND lp.reference = 'tx:' || b.tx_hash)
+		ORDER BY b.broadcast_at ASC
+		LIMIT $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, BatchStatusBroadcast, PostingNetworkFee, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query uncharged batch fees: %w", err)
+	}
+	defer rows.Close()
+
+	batches := make([]PayoutBatch, 0)
+	for rows.Next() {
+		var b PayoutBatch
+		err := rows.Scan(&b.ID, &b.Label, &b.Status, &b.TxHash, &b.RawTx, &b.OutputCount, &b.TotalAmount, &b.FeeRate, &b.Fee, &b.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan payout batch: %w", err)
+		}
+		batches = append(batches, b)
+	}
+
+	return batches, rows.Err()
+}
+
+// GetBatchPayouts returns the payouts assigned to a batch
+func (r *SQLPayoutRepository) GetBatchPayouts(ctx context.Context, batchID int64) ([]PendingPayout, error) {
+	query := `SELECT ` + batchPayoutColumns + ` FROM pending_payouts WHERE batch_id = $1 ORDER BY id`
+
+	rows, err := r.db.QueryContext(ctx, query, batchID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query batched payouts: %w", err)
+	}
+	defer rows.Close()
+
+	payouts, _, err := scanBatchPayouts(rows)
+	return payouts, err
+}
+
+// scanBatchPayouts scans batchPayoutColumns rows, returning retry counts alongside
+func scanBatchPayouts(rows *sql.Rows) ([]PendingPayout, []int, error) {
+	payouts := make([]PendingPayout, 0)
+	var retries []int
+	for rows.Next() {
+		var p PendingPayout
+		var processedAt sql.NullTime
+		var txHash, errorMsg sql.NullString
+		var payoutMode string
+		var retryCount int
+
+		err := rows.Scan(
+			&p.ID, &p.UserID, &p.Amount, &p.Address, &p.Status,
+			&payoutMode, &p.BlockID, &p.CreatedAt, &processedAt,
+			&txHash, &errorMsg, &retryCount,
+		)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to scan payout row: %w", err)
+		}
+
+		p.PayoutMode = PayoutMode(payoutMode)
+		if processedAt.Valid {
+			p.ProcessedAt = &processedAt.Time
+		}
+		p.TxHash = txHash.String
+		p.ErrorMessage = errorMsg.String
+
+		payouts = append(payouts, p)
+		retries = append(retries, retryCount)
+	}
+
+	return payouts, retries, rows.Err()
+}
+
+// Ensure SQLPayoutRepository implements PayoutBatchRepository and UnchargedFeeRepository
+var (
+	_ PayoutBatchRepository  = (*SQLPayoutRepository)(nil)
+	_ UnchargedFeeRepository = (*SQLPayoutRepository)(nil)
+)
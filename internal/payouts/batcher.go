@@ -0,0 +1,442 @@
+package payouts
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"strings"
+	"time"
+)
+
+// =============================================================================
+// ISP-COMPLIANT INTERFACES FOR BATCHED PAYOUTS
+// =============================================================================
+
+// BatchWalletClient pays many addresses in one transaction (ISP)
+type BatchWalletClient interface {
+	CreateTransaction(ctx context.Context, outputs map[string]int64, opts TransactionOptions) (*SignedTransaction, error)
+	BroadcastTransaction(ctx context.Context, tx SignedTransaction) error
+	GetTransactionStatus(ctx context.Context, txHash string) (*TransactionStatus, error)
+	EstimateFee(ctx context.Context, confirmTarget int) (int64, error)
+}
+
+// PayoutBatchRepository persists payout batches (ISP)
+type PayoutBatchRepository interface {
+	// CreatePayoutBatch records a batch and assigns the payouts to it. It
+	// fails with ErrPayoutsAlreadyBatched if any payout is no longer pending
+	// or belongs to another batch.
+	CreatePayoutBatch(ctx context.Context, batch *PayoutBatch, payoutIDs []int64) error
+	// MarkBatchSigned stores the signed transaction of a batch before it is
+	// broadcast, so an interrupted send is looked up by its hash
+	MarkBatchSigned(ctx context.Context, batchID int64, tx *SignedTransaction) error
+	// MarkBatchBroadcast marks a batch and its payouts sent in txHash
+	MarkBatchBroadcast(ctx context.Context, batchID int64, txHash string) error
+	// ReleasePayoutBatch fails a batch that never went out and returns its
+	// payouts to the queue. Payouts that have used up maxRetries stay
+	// assigned and are returned for the caller to fail.
+	ReleasePayoutBatch(ctx context.Context, batchID int64, reason string, maxRetries int) (exhausted []PendingPayout, err error)
+	GetSendingBatches(ctx context.Context) ([]PayoutBatch, error)
+	GetBatchPayouts(ctx context.Context, batchID int64) ([]PendingPayout, error)
+}
+
+// UnchargedFeeRepository finds broadcast batches whose network fee is missing
+// from the ledger, so a fee posting that failed is retried (ISP)
+type UnchargedFeeRepository interface {
+	GetUnchargedBatchFees(ctx context.Context, limit int) ([]PayoutBatch, error)
+}
+
+// =============================================================================
+// BATCH TYPES
+// =============================================================================
+
+// PayoutBatchStatus represents the broadcast state of a batch
+type PayoutBatchStatus string
+
+const (
+	// BatchStatusSending batches are recorded but not yet confirmed as
+	// broadcast. Once signed, their transaction is looked up by hash and
+	// broadcast again rather than rebuilt.
+	BatchStatusSending   PayoutBatchStatus = "sending"
+	BatchStatusBroadcast PayoutBatchStatus = "broadcast"
+	BatchStatusFailed    PayoutBatchStatus = "failed"
+)
+
+// ErrPayoutsAlreadyBatched is returned when a payout was claimed by another batch
+var ErrPayoutsAlreadyBatched = errors.New("payouts already batched")
+
+// PayoutBatch is one transaction paying several payouts
+type PayoutBatch struct {
+	ID           int64             `json:"id"`
+	Label        string            `json:"label"`
+	Status       PayoutBatchStatus `json:"status"`
+	TxHash       string            `json:"tx_hash,omitempty"`
+	RawTx        string            `json:"-"` // Signed transaction, stored before it is broadcast
+	OutputCount  int               `json:"output_count"`
+	TotalAmount  int64             `json:"total_amount"`
+	FeeRate      int64             `json:"fee_rate"`
+	Fee          int64             `json:"fee"` // Network fee of the signed transaction
+	ErrorMessage string            `json:"error_message,omitempty"`
+	CreatedAt    time.Time         `json:"created_at"`
+	BroadcastAt  *time.Time        `json:"broadcast_at,omitempty"`
+}
+
+// =============================================================================
+// BATCH CONFIGURATION
+// =============================================================================
+
+// BatchConfig controls how due payouts are grouped and when batches are sent
+type BatchConfig struct {
+	Enabled          bool          `json:"enabled" yaml:"enabled"`
+	MaxOutputs       int           `json:"max_outputs" yaml:"max_outputs"`
+	MaxWeight        int           `json:"max_weight" yaml:"max_weight"` // Estimated weight units
+	MaxBatchesPerRun int           `json:"max_batches_per_run" yaml:"max_batches_per_run"`
+	ConfirmTarget    int           `json:"confirm_target" yaml:"confirm_target"`
+	TargetFeeRate    int64         `json:"target_fee_rate" yaml:"target_fee_rate"` // Litoshis per kB; 0 sends at any fee
+	MaxDelay         time.Duration `json:"max_delay" yaml:"max_delay"`             // Longest a payout waits for cheaper fees
+	ReconcileAfter   time.Duration `json:"reconcile_after" yaml:"reconcile_after"` // Age before an unconfirmed send is checked against the wallet
+}
+
+// DefaultBatchConfig returns sensible defaults
+func DefaultBatchConfig() BatchConfig {
+	return BatchConfig{
+		MaxOutputs:       100,
+		MaxWeight:        100000,
+		MaxBatchesPerRun: 4,
+		ConfirmTarget:    6,
+		MaxDelay:         6 * time.Hour,
+		ReconcileAfter:   5 * time.Minute,
+	}
+}
+
+// withDefaults fills unset limits from DefaultBatchConfig
+func (c BatchConfig) withDefaults() BatchConfig {
+	defaults := DefaultBatchConfig()
+	if c.MaxOutputs <= 0 {
+		c.MaxOutputs = defaults.MaxOutputs
+	}
+	if c.MaxWeight <= 0 {
+		c.MaxWeight = defaults.MaxWeight
+	}
+	if c.MaxBatchesPerRun <= 0 {
+		c.MaxBatchesPerRun = defaults.MaxBatchesPerRun
+	}
+	if c.ConfirmTarget <= 0 {
+		c.ConfirmTarget = defaults.ConfirmTarget
+	}
+	if c.ReconcileAfter <= 0 {
+		c.ReconcileAfter = defaults.ReconcileAfter
+	}
+	return c
+}
+
+// =============================================================================
+// BATCH PLANNING
+// =============================================================================
+
+// Transaction weight estimates in weight units. The wallet picks the inputs,
+// so a couple of P2WPKH inputs are assumed.
+const (
+	batchBaseWeight    = 4*10 + 2
+	batchInputWeight   = 4*41 + 108
+	batchInputsAssumed = 2
+)
+
+// estimateOutputWeight sizes an output by the address type it pays
+func estimateOutputWeight(address string) int {
+	lower := strings.ToLower(address)
+	switch {
+	case strings.HasPrefix(lower, "ltc1") || strings.HasPrefix(lower, "tltc1") || strings.HasPrefix(lower, "rltc1"):
+		if len(address) >= 62 {
+			return 4 * 43 // P2WSH and P2TR
+		}
+		return 4 * 31 // P2WPKH
+	case strings.HasPrefix(address, "M") || strings.HasPrefix(address, "Q") ||
+		strings.HasPrefix(address, "3") || strings.HasPrefix(address, "2"):
+		return 4 * 32 // P2SH
+	default:
+		return 4 * 34 // P2PKH
+	}
+}
+
+// planBatches groups payouts, oldest first, into batches within the output
+// and weight limits. Payouts to the same address share an output, and a
+// split payout stays in one batch with all of its outputs.
+func (c BatchConfig) planBatches(payouts []PendingPayout) [][]PendingPayout {
+	var batches [][]PendingPayout
+	var current []PendingPayout
+	addresses := make(map[string]bool)
+	weight := batchBaseWeight + batchInputsAssumed*batchInputWeight
+
+	// newOutputs lists the outputs a payout adds to the current batch
+	newOutputs := func(payout PendingPayout) ([]string, int) {
+		var added []string
+		addedWeight := 0
+		for _, address := range payoutAddresses(payout) {
+			if !addresses[address] {
+				added = append(added, address)
+				addedWeight += estimateOutputWeight(address)
+			}
+		}
+		return added, addedWeight
+	}
+
+	for _, payout := range payouts {
+		added, outputWeight := newOutputs(payout)
+		if len(current) > 0 && len(added) > 0 &&
+			(len(addresses)+len(added) > c.MaxOutputs || weight+outputWeight > c.MaxWeight) {
+			batches = append(batches, current)
+			if len(batches) == c.MaxBatchesPerRun {
+				return batches
+			}
+			current = nil
+			addresses = make(map[string]bool)
+			weight = batchBaseWeight + batchInputsAssumed*batchInputWeight
+			added, outputWeight = newOutputs(payout)
+		}
+		for _, address := range added {
+			addresses[address] = true
+		}
+		weight += outputWeight
+		current = append(current, payout)
+	}
+
+	if len(current) > 0 {
+		batches = append(batches, current)
+	}
+	return batches
+}
+
+// shouldSend reports whether a batch goes out now: when the estimated fee
+// is at or below the target, or once its oldest payout has waited MaxDelay.
+// A batch is sent when the fee cannot be estimated rather than held back.
+func (c BatchConfig) shouldSend(batch []PendingPayout, feeRate int64, feeErr error, now time.Time) bool {
+	if c.TargetFeeRate <= 0 || feeErr != nil || feeRate <= c.TargetFeeRate {
+		return true
+	}
+
+	oldest := batch[0].CreatedAt
+	for _, payout := range batch[1:] {
+		if payout.CreatedAt.Before(oldest) {
+			oldest = payout.CreatedAt
+		}
+	}
+	return now.Sub(oldest) >= c.MaxDelay
+}
+
+// batchOutputs sums a batch's payouts, or their allocations, per address
+func batchOutputs(batch []PendingPayout) (outputs map[string]int64, total int64) {
+	outputs = make(map[string]int64)
+	for _, payout := range batch {
+		for address, amount := range payoutOutputs(payout) {
+			outputs[address] += amount
+		}
+		total += payout.Amount
+	}
+	return outputs, total
+}
+
+// newBatchLabel returns a unique label identifying the batch
+func newBatchLabel() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "payout-batch-" + hex.EncodeToString(b), nil
+}
+
+// =============================================================================
+// BATCHED PAYOUT PROCESSING
+// =============================================================================
+
+// SetBatching pays due payouts in batched transactions instead of one
+// transaction each
+func (p *PayoutProcessor) SetBatching(wallet BatchWalletClient, repo PayoutBatchRepository) {
+	p.SetSigner(wallet, repo)
+	p.batching = true
+}
+
+// SetSigner sends each payout as a batch of one: its transaction is signed
+// and recorded before it is broadcast and reconciled by hash like a batch.
+// Without a signer payouts are sent directly with sendtoaddress or sendmany.
+func (p *PayoutProcessor) SetSigner(wallet BatchWalletClient, repo PayoutBatchRepository) {
+	p.batchWallet = wallet
+	p.batchRepo = repo
+}
+
+// sendBatch records a batch, signs its transaction and stores the hash,
+// then broadcasts it. The hash is stored before the transaction can be seen,
+// so a send that fails ambiguously is looked up by hash and broadcast again
+// rather than paid twice.
+func (p *PayoutProcessor) sendBatch(ctx context.Context, config BatchConfig, payouts []PendingPayout, feeRate int64) {
+	label, err := newBatchLabel()
+	if err != nil {
+		log.Printf("⚠️ Payout batch not sent: %v", err)
+		return
+	}
+
+	outputs, total := batchOutputs(payouts)
+	ids := make([]int64, len(payouts))
+	for i, payout := range payouts {
+		ids[i] = payout.ID
+	}
+
+	batch := &PayoutBatch{
+		Label:       label,
+		Status:      BatchStatusSending,
+		OutputCount: len(outputs),
+		TotalAmount: total,
+		FeeRate:     feeRate,
+	}
+	if err := p.batchRepo.CreatePayoutBatch(ctx, batch, ids); err != nil {
+		log.Printf("⚠️ Payout batch not sent: %v", err)
+		return
+	}
+
+	signed, err := p.batchWallet.CreateTransaction(ctx, outputs, TransactionOptions{
+		ConfirmTarget: config.ConfirmTarget,
+		Replaceable:   true,
+	})
+	if err != nil {
+		// Nothing was broadcast
+		p.releaseBatch(ctx, batch.ID, err.Error())
+		return
+	}
+	if err := p.batchRepo.MarkBatchSigned(ctx, batch.ID, signed); err != nil {
+		// Not broadcast; without a stored hash it is released on reconciliation
+		log.Printf("⚠️ Payout batch %s not sent, failed to record tx %s: %v", label, signed.TxID, err)
+		return
+	}
+
+	p.broadcastBatch(ctx, batch.ID, *signed, payouts)
+}
+
+// broadcastBatch broadcasts a batch's stored transaction
+func (p *PayoutProcessor) broadcastBatch(ctx context.Context, batchID int64, tx SignedTransaction, payouts []PendingPayout) {
+	if err := p.batchWallet.BroadcastTransaction(ctx, tx); err != nil {
+		// The node rejected the transaction, and the wallet only knows
+		// transactions it accepted, so it was never seen. Any other error may
+		// have come after the broadcast; the batch stays sending until it is
+		// reconciled by hash.
+		var rpcErr *RPCError
+		if errors.As(err, &rpcErr) {
+			p.releaseBatch(ctx, batchID, err.Error())
+			return
+		}
+		log.Printf("⚠️ Payout batch %d broadcast of tx %s interrupted, reconciling later: %v", batchID, tx.TxID, err)
+		return
+	}
+
+	p.completeBatch(ctx, batchID, tx, payouts)
+}
+
+// reconcileBatches resolves batches left sending by an interrupted send.
+// A batch whose transaction the wallet has is completed, and one it does not
+// have is broadcast again from the stored copy. Only a batch that was never
+// signed, or whose transaction the node rejects, is released.
+func (p *PayoutProcessor) reconcileBatches(ctx context.Context, config BatchConfig) {
+	batches, err := p.batchRepo.GetSendingBatches(ctx)
+	if err != nil {
+		log.Printf("⚠️ Failed to load unsettled payout batches: %v", err)
+		return
+	}
+
+	now := p.now()
+	for _, batch := range batches {
+		if now.Sub(batch.CreatedAt) < config.ReconcileAfter {
+			continue
+		}
+		if batch.TxHash == "" || batch.RawTx == "" {
+			// Transactions are stored before they are broadcast
+			p.releaseBatch(ctx, batch.ID, "transaction was never signed")
+			continue
+		}
+
+		status, err := p.batchWallet.GetTransactionStatus(ctx, batch.TxHash)
+		if err != nil {
+			log.Printf("⚠️ Failed to look up payout batch %s tx %s: %v", batch.Label, batch.TxHash, err)
+			continue
+		}
+		payouts, err := p.batchRepo.GetBatchPayouts(ctx, batch.ID)
+		if err != nil {
+			log.Printf("⚠️ Failed to load payouts of batch %s: %v", batch.Label, err)
+			continue
+		}
+
+		tx := SignedTransaction{TxID: batch.TxHash, Hex: batch.RawTx, Fee: batch.Fee}
+		if status.Known {
+			p.completeBatch(ctx, batch.ID, tx, payouts)
+			continue
+		}
+		p.broadcastBatch(ctx, batch.ID, tx, payouts)
+	}
+}
+
+// completeBatch maps the batch transaction back onto each of its payouts
+// and charges its network fee to the pool
+func (p *PayoutProcessor) completeBatch(ctx context.Context, batchID int64, tx SignedTransaction, payouts []PendingPayout) {
+	if err := p.batchRepo.MarkBatchBroadcast(ctx, batchID, tx.TxID); err != nil {
+		// The batch stays sending and is completed on reconciliation
+		log.Printf("⚠️ Failed to record payout batch %d as tx %s: %v", batchID, tx.TxID, err)
+		return
+	}
+
+	for _, payout := range payouts {
+		p.settlePayout(ctx, payout, tx.TxID)
+	}
+	p.recordNetworkFee(ctx, tx)
+
+	p.mu.Lock()
+	p.stats.BatchesSent++
+	p.mu.Unlock()
+}
+
+// recordNetworkFee charges the fee a sent transaction paid to the pool
+func (p *PayoutProcessor) recordNetworkFee(ctx context.Context, tx SignedTransaction) {
+	if tx.Fee <= 0 {
+		return
+	}
+	err := p.ledger.RecordNetworkFee(ctx, tx.TxID, tx.Fee)
+	if err == nil || errors.Is(err, ErrDuplicatePosting) {
+		return
+	}
+	log.Printf("⚠️ Failed to charge network fee of tx %s to the pool, will retry: %v", tx.TxID, err)
+}
+
+// retryNetworkFees posts the network fees of broadcast batches that are
+// missing from the ledger
+func (p *PayoutProcessor) retryNetworkFees(ctx context.Context) {
+	repo, ok := p.batchRepo.(UnchargedFeeRepository)
+	if !ok {
+		return
+	}
+	batches, err := repo.GetUnchargedBatchFees(ctx, p.config.BatchSize)
+	if err != nil {
+		log.Printf("⚠️ Failed to load uncharged network fees: %v", err)
+		return
+	}
+
+	for _, batch := range batches {
+		p.recordNetworkFee(ctx, SignedTransaction{TxID: batch.TxHash, Hex: batch.RawTx, Fee: batch.Fee})
+	}
+}
+
+// releaseBatch returns a batch that never went out to the queue, failing
+// payouts that have been retried too often
+func (p *PayoutProcessor) releaseBatch(ctx context.Context, batchID int64, reason string) {
+	exhausted, err := p.batchRepo.ReleasePayoutBatch(ctx, batchID, reason, p.config.MaxRetries)
+	if err != nil {
+		log.Printf("⚠️ Failed to release payout batch %d: %v", batchID, err)
+		return
+	}
+	for _, payout := range exhausted {
+		p.handleFailedPayout(ctx, payout, reason)
+	}
+}
+
+// Ensure LitecoinWalletClient implements the batch and multi-output wallets
+var (
+	_ BatchWalletClient = (*LitecoinWalletClient)(nil)
+	_ MultiOutputWallet = (*LitecoinWalletClient)(nil)
+)
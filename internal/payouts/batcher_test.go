@@ -0,0 +1,539 @@
+package payouts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// MOCK BATCH WALLET AND REPOSITORY FOR TESTING
+// =============================================================================
+
+// mockBatchFee is the network fee of every transaction the mock wallet signs
+const mockBatchFee = 2000
+
+type mockBatchWallet struct {
+	*MockWalletClient
+	feeRate  int64
+	sendErr  error // Returned by BroadcastTransaction
+	lostSend bool  // sendErr came before the transaction reached the node
+	sent     []map[string]int64
+	signed   map[string]map[string]int64 // raw tx -> outputs
+	known    map[string]bool             // txids the wallet has accepted
+	mu       sync.Mutex
+}
+
+func newMockBatchWallet() *mockBatchWallet {
+	return &mockBatchWallet{
+		MockWalletClient: NewMockWalletClient(),
+		signed:           make(map[string]map[string]int64),
+		known:            make(map[string]bool),
+	}
+}
+
+func (m *mockBatchWallet) SendMany(ctx context.Context, outputs map[string]int64, opts SendManyOptions) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, outputs)
+	return fmt.Sprintf("sendmanytx%d", len(m.sent)), nil
+}
+
+func (m *mockBatchWallet) CreateTransaction(ctx context.Context, outputs map[string]int64, opts TransactionOptions) (*SignedTransaction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	txID := fmt.Sprintf("batchtx%d", len(m.signed)+1)
+	m.signed["raw-"+txID] = outputs
+	return &SignedTransaction{TxID: txID, Hex: "raw-" + txID, Fee: mockBatchFee}, nil
+}
+
+func (m *mockBatchWallet) BroadcastTransaction(ctx context.Context, tx SignedTransaction) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.sendErr != nil {
+		var rpcErr *RPCError
+		if !errors.As(m.sendErr, &rpcErr) && !m.lostSend {
+			// Broadcast, but the response was lost
+			m.accept(tx)
+		}
+		return m.sendErr
+	}
+	m.accept(tx)
+	return nil
+}
+
+// accept adds a transaction to the wallet once, like a node's mempool
+func (m *mockBatchWallet) accept(tx SignedTransaction) {
+	if !m.known[tx.TxID] {
+		m.known[tx.TxID] = true
+		m.sent = append(m.sent, m.signed[tx.Hex])
+	}
+}
+
+func (m *mockBatchWallet) GetTransactionStatus(ctx context.Context, txHash string) (*TransactionStatus, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return &TransactionStatus{Known: m.known[txHash]}, nil
+}
+
+func (m *mockBatchWallet) EstimateFee(ctx context.Context, confirmTarget int) (int64, error) {
+	return m.feeRate, nil
+}
+
+type mockBatchPayout struct {
+	PendingPayout
+	batchID int64
+	retries int
+}
+
+type mockBatchRepository struct {
+	payouts map[int64]*mockBatchPayout
+	batches map[int64]*PayoutBatch
+	nextID  int64
+	mu      sync.Mutex
+}
+
+func newMockBatchRepository() *mockBatchRepository {
+	return &mockBatchRepository{
+		payouts: make(map[int64]*mockBatchPayout),
+		batches: make(map[int64]*PayoutBatch),
+	}
+}
+
+func (m *mockBatchRepository) add(userID, amount int64, address string, createdAt time.Time) int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.nextID++
+	m.payouts[m.nextID] = &mockBatchPayout{PendingPayout: PendingPayout{
+		ID: m.nextID, UserID: userID, Amount: amount, Address: address,
+		Status: PayoutStatusPending, CreatedAt: createdAt,
+	}}
+	return m.nextID
+}
+
+func (m *mockBatchRepository) payout(id int64) mockBatchPayout {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return *m.payouts[id]
+}
+
+func (m *mockBatchRepository) GetPendingPayouts(ctx context.Context, limit int) ([]PendingPayout, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var result []PendingPayout
+	for _, p := range m.payouts {
+		if p.Status == PayoutStatusPending && p.batchID == 0 {
+			result = append(result, p.PendingPayout)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+func (m *mockBatchRepository) MarkPayoutComplete(ctx context.Context, payoutID int64, txHash string) error {
+	return errors.New("batched payouts are completed with their batch")
+}
+
+func (m *mockBatchRepository) MarkPayoutFailed(ctx context.Context, payoutID int64, errorMsg string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.payouts[payoutID].Status = PayoutStatusFailed
+	m.payouts[payoutID].ErrorMessage = errorMsg
+	return nil
+}
+
+func (m *mockBatchRepository) CreatePayoutBatch(ctx context.Context, batch *PayoutBatch, payoutIDs []int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, id := range payoutIDs {
+		if p := m.payouts[id]; p.Status != PayoutStatusPending || p.batchID != 0 {
+			return ErrPayoutsAlreadyBatched
+		}
+	}
+	m.nextID++
+	batch.ID = m.nextID
+	batch.CreatedAt = time.Now()
+	copied := *batch
+	m.batches[batch.ID] = &copied
+	for _, id := range payoutIDs {
+		m.payouts[id].batchID = batch.ID
+	}
+	return nil
+}
+
+func (m *mockBatchRepository) MarkBatchSigned(ctx context.Context, batchID int64, tx *SignedTransaction) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.batches[batchID].TxHash = tx.TxID
+	m.batches[batchID].RawTx = tx.Hex
+	m.batches[batchID].Fee = tx.Fee
+	return nil
+}
+
+func (m *mockBatchRepository) MarkBatchBroadcast(ctx context.Context, batchID int64, txHash string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.batches[batchID].Status = BatchStatusBroadcast
+	m.batches[batchID].TxHash = txHash
+	for _, p := range m.payouts {
+		if p.batchID == batchID && p.Status == PayoutStatusPending {
+			p.Status = PayoutStatusProcessed
+			p.TxHash = txHash
+		}
+	}
+	return nil
+}
+
+func (m *mockBatchRepository) ReleasePayoutBatch(ctx context.Context, batchID int64, reason string, maxRetries int) ([]PendingPayout, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.batches[batchID].Status = BatchStatusFailed
+	var exhausted []PendingPayout
+	for _, p := range m.payouts {
+		if p.batchID != batchID || p.Status != PayoutStatusPending {
+			continue
+		}
+		p.retries++
+		if p.retries >= maxRetries {
+			exhausted = append(exhausted, p.PendingPayout)
+		} else {
+			p.batchID = 0
+		}
+	}
+	return exhausted, nil
+}
+
+func (m *mockBatchRepository) GetSendingBatches(ctx context.Context) ([]PayoutBatch, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var result []PayoutBatch
+	for _, b := range m.batches {
+		if b.Status == BatchStatusSending {
+			result = append(result, *b)
+		}
+	}
+	return result, nil
+}
+
+func (m *mockBatchRepository) GetBatchPayouts(ctx context.Context, batchID int64) ([]PendingPayout, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var result []PendingPayout
+	for _, p := range m.payouts {
+		if p.batchID == batchID {
+			result = append(result, p.PendingPayout)
+		}
+	}
+	return result, nil
+}
+
+func newBatchingProcessor(wallet *mockBatchWallet, repo *mockBatchRepository, batching BatchConfig) *PayoutProcessor {
+	processor := NewPayoutProcessor(wallet, repo, NewMockBalanceTracker(), ProcessorConfig{MaxRetries: 2, Batching: batching})
+	processor.SetBatching(wallet, repo)
+	return processor
+}
+
+// =============================================================================
+// BATCHED PAYOUT TESTS
+// =============================================================================
+
+func TestBatchConfig_PlanBatches(t *testing.T) {
+	now := time.Now()
+	payout := func(id int64, address string) PendingPayout {
+		return PendingPayout{ID: id, Amount: 1000000, Address: address, CreatedAt: now}
+	}
+
+	t.Run("payouts to one address share an output", func(t *testing.T) {
+		config := BatchConfig{MaxOutputs: 2, MaxWeight: 100000, MaxBatchesPerRun: 10}
+		batches := config.planBatches([]PendingPayout{
+			payout(1, "ltc1qaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"),
+			payout(2, "ltc1qbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"),
+			payout(3, "ltc1qaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"),
+			payout(4, "ltc1qcccccccccccccccccccccccccccccccccccccc"),
+		})
+		require.Len(t, batches, 2)
+		assert.Len(t, batches[0], 3)
+		assert.Equal(t, int64(4), batches[1][0].ID)
+	})
+
+	t.Run("respects the weight limit and batches per run", func(t *testing.T) {
+		base := batchBaseWeight + batchInputsAssumed*batchInputWeight
+		config := BatchConfig{MaxOutputs: 100, MaxWeight: base + 2*4*34, MaxBatchesPerRun: 2}
+		var payouts []PendingPayout
+		for i := int64(1); i <= 7; i++ {
+			payouts = append(payouts, payout(i, fmt.Sprintf("LVg2kJoFNg45Nbpy53h7Fe1wKyeXVRhMH%d", i)))
+		}
+		batches := config.planBatches(payouts)
+		require.Len(t, batches, 2)
+		assert.Len(t, batches[0], 2)
+		assert.Len(t, batches[1], 2)
+	})
+}
+
+func TestBatchConfig_ShouldSend(t *testing.T) {
+	now := time.Now()
+	config := BatchConfig{TargetFeeRate: 10000, MaxDelay: time.Hour}
+	fresh := []PendingPayout{{CreatedAt: now.Add(-time.Minute)}}
+	overdue := []PendingPayout{{CreatedAt: now.Add(-time.Minute)}, {CreatedAt: now.Add(-2 * time.Hour)}}
+
+	assert.True(t, config.shouldSend(fresh, 8000, nil, now), "fees at or below target")
+	assert.False(t, config.shouldSend(fresh, 50000, nil, now), "waits for cheaper fees")
+	assert.True(t, config.shouldSend(overdue, 50000, nil, now), "deadline passed")
+	assert.True(t, config.shouldSend(fresh, 0, errors.New("no estimate"), now), "fee unknown")
+	assert.True(t, BatchConfig{}.shouldSend(fresh, 50000, nil, now), "no target")
+}
+
+func TestPayoutProcessor_Batching(t *testing.T) {
+	ctx := context.Background()
+	addrA := "ltc1qaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	addrB := "LVg2kJoFNg45Nbpy53h7Fe1wKyeXVRhMH9"
+
+	t.Run("pays due payouts in one transaction", func(t *testing.T) {
+		wallet := newMockBatchWallet()
+		repo := newMockBatchRepository()
+		a1 := repo.add(1, 1000000, addrA, time.Now())
+		b := repo.add(2, 2000000, addrB, time.Now())
+		a2 := repo.add(3, 500000, addrA, time.Now())
+		invalid := repo.add(4, 1000000, "bogus", time.Now())
+		notifier := &recordingPayoutNotifier{}
+		processor := newBatchingProcessor(wallet, repo, DefaultBatchConfig())
+		processor.SetNotifier(notifier)
+
+		require.NoError(t, processor.ProcessPendingPayouts(ctx))
+
+		require.Len(t, wallet.sent, 1)
+		assert.Equal(t, map[string]int64{addrA: 1500000, addrB: 2000000}, wallet.sent[0])
+		for _, id := range []int64{a1, b, a2} {
+			assert.Equal(t, PayoutStatusProcessed, repo.payout(id).Status)
+			assert.Equal(t, "batchtx1", repo.payout(id).TxHash)
+		}
+		assert.Equal(t, PayoutStatusFailed, repo.payout(invalid).Status)
+		assert.Equal(t, 3, notifier.sent)
+		assert.Equal(t, int64(1), processor.GetStats().BatchesSent)
+		assert.Equal(t, int64(3500000), processor.GetStats().TotalAmountSent)
+	})
+
+	t.Run("charges the network fee to the pool once", func(t *testing.T) {
+		wallet := newMockBatchWallet()
+		wallet.sendErr = errors.New("RPC request failed: context deadline exceeded")
+		repo := newMockBatchRepository()
+		repo.add(1, 1000000, addrA, time.Now())
+		processor := newBatchingProcessor(wallet, repo, DefaultBatchConfig())
+		ledger := processor.ledger.(*MockBalanceTracker)
+
+		require.NoError(t, processor.ProcessPendingPayouts(ctx))
+		fees, err := ledger.AccountBalance(ctx, AccountPoolFees, DefaultLedgerCurrency)
+		require.NoError(t, err)
+		assert.Zero(t, fees, "not charged before the batch is known to be sent")
+
+		// Completed on reconciliation from the stored fee
+		wallet.sendErr = nil
+		processor.now = func() time.Time { return time.Now().Add(time.Hour) }
+		require.NoError(t, processor.ProcessPendingPayouts(ctx))
+		require.NoError(t, processor.ProcessPendingPayouts(ctx))
+
+		fees, err = ledger.AccountBalance(ctx, AccountPoolFees, DefaultLedgerCurrency)
+		require.NoError(t, err)
+		assert.Equal(t, int64(-mockBatchFee), fees)
+		walletFunds, err := ledger.AccountBalance(ctx, AccountPoolWallet, DefaultLedgerCurrency)
+		require.NoError(t, err)
+		assert.Equal(t, int64(-1000000-mockBatchFee), walletFunds, "payout and fee paid from the wallet")
+	})
+
+	t.Run("holds batches for cheaper fees until the deadline", func(t *testing.T) {
+		wallet := newMockBatchWallet()
+		wallet.feeRate = 50000
+		repo := newMockBatchRepository()
+		id := repo.add(1, 1000000, addrA, time.Now())
+		config := DefaultBatchConfig()
+		config.TargetFeeRate = 10000
+		processor := newBatchingProcessor(wallet, repo, config)
+
+		require.NoError(t, processor.ProcessPendingPayouts(ctx))
+		assert.Empty(t, wallet.sent)
+		assert.Equal(t, PayoutStatusPending, repo.payout(id).Status)
+
+		processor.now = func() time.Time { return time.Now().Add(config.MaxDelay) }
+		require.NoError(t, processor.ProcessPendingPayouts(ctx))
+		assert.Len(t, wallet.sent, 1)
+		assert.Equal(t, PayoutStatusProcessed, repo.payout(id).Status)
+	})
+
+	t.Run("returns rejected batches to the queue until retries run out", func(t *testing.T) {
+		wallet := newMockBatchWallet()
+		wallet.sendErr = &RPCError{Code: -6, Message: "Insufficient funds"}
+		repo := newMockBatchRepository()
+		id := repo.add(1, 1000000, addrA, time.Now())
+		processor := newBatchingProcessor(wallet, repo, DefaultBatchConfig())
+
+		require.NoError(t, processor.ProcessPendingPayouts(ctx))
+		assert.Equal(t, PayoutStatusPending, repo.payout(id).Status)
+		assert.Zero(t, repo.payout(id).batchID, "released for the next run")
+
+		require.NoError(t, processor.ProcessPendingPayouts(ctx))
+		assert.Equal(t, PayoutStatusFailed, repo.payout(id).Status)
+		assert.Empty(t, wallet.sent)
+	})
+
+	t.Run("reconciles an interrupted broadcast without paying twice", func(t *testing.T) {
+		wallet := newMockBatchWallet()
+		wallet.sendErr = errors.New("RPC request failed: context deadline exceeded")
+		repo := newMockBatchRepository()
+		id := repo.add(1, 1000000, addrA, time.Now())
+		processor := newBatchingProcessor(wallet, repo, DefaultBatchConfig())
+
+		require.NoError(t, processor.ProcessPendingPayouts(ctx))
+		require.Len(t, wallet.sent, 1)
+		assert.Equal(t, PayoutStatusPending, repo.payout(id).Status)
+		assert.NotZero(t, repo.payout(id).batchID, "kept in its batch")
+
+		// Not yet old enough to reconcile, and not picked up again
+		wallet.sendErr = nil
+		require.NoError(t, processor.ProcessPendingPayouts(ctx))
+		assert.Len(t, wallet.sent, 1)
+
+		processor.now = func() time.Time { return time.Now().Add(time.Hour) }
+		require.NoError(t, processor.ProcessPendingPayouts(ctx))
+		assert.Len(t, wallet.sent, 1)
+		assert.Equal(t, PayoutStatusProcessed, repo.payout(id).Status)
+		assert.Equal(t, "batchtx1", repo.payout(id).TxHash)
+	})
+
+	t.Run("broadcasts a stored transaction the node never received", func(t *testing.T) {
+		wallet := newMockBatchWallet()
+		wallet.sendErr = errors.New("RPC request failed: connection refused")
+		wallet.lostSend = true
+		repo := newMockBatchRepository()
+		id := repo.add(1, 1000000, addrA, time.Now())
+		processor := newBatchingProcessor(wallet, repo, DefaultBatchConfig())
+
+		require.NoError(t, processor.ProcessPendingPayouts(ctx))
+		assert.Empty(t, wallet.sent)
+		assert.NotZero(t, repo.payout(id).batchID, "kept in its batch")
+
+		// Missing from the wallet: the same signed transaction goes out
+		wallet.sendErr = nil
+		processor.now = func() time.Time { return time.Now().Add(time.Hour) }
+		require.NoError(t, processor.ProcessPendingPayouts(ctx))
+		require.Len(t, wallet.sent, 1)
+		assert.Len(t, wallet.signed, 1, "never rebuilt")
+		assert.Equal(t, PayoutStatusProcessed, repo.payout(id).Status)
+		assert.Equal(t, "batchtx1", repo.payout(id).TxHash)
+	})
+
+	t.Run("releases a stored transaction the node rejects", func(t *testing.T) {
+		wallet := newMockBatchWallet()
+		wallet.sendErr = errors.New("RPC request failed: connection refused")
+		wallet.lostSend = true
+		repo := newMockBatchRepository()
+		id := repo.add(1, 1000000, addrA, time.Now())
+		processor := newBatchingProcessor(wallet, repo, DefaultBatchConfig())
+
+		require.NoError(t, processor.ProcessPendingPayouts(ctx))
+		batchID := repo.payout(id).batchID
+
+		wallet.sendErr = &RPCError{Code: rpcErrVerify, Message: "bad-txns-inputs-missingorspent"}
+		processor.now = func() time.Time { return time.Now().Add(time.Hour) }
+		processor.reconcileBatches(ctx, DefaultBatchConfig())
+		assert.Equal(t, BatchStatusFailed, repo.batches[batchID].Status)
+		assert.Equal(t, PayoutStatusPending, repo.payout(id).Status)
+		assert.Zero(t, repo.payout(id).batchID, "back in the queue")
+	})
+
+	t.Run("releases batches that were never signed", func(t *testing.T) {
+		wallet := newMockBatchWallet()
+		repo := newMockBatchRepository()
+		id := repo.add(1, 1000000, addrA, time.Now())
+		batch := &PayoutBatch{Label: "payout-batch-lost", Status: BatchStatusSending}
+		require.NoError(t, repo.CreatePayoutBatch(ctx, batch, []int64{id}))
+		processor := newBatchingProcessor(wallet, repo, DefaultBatchConfig())
+		processor.now = func() time.Time { return time.Now().Add(time.Hour) }
+
+		require.NoError(t, processor.ProcessPendingPayouts(ctx))
+		assert.Equal(t, BatchStatusFailed, repo.batches[batch.ID].Status)
+		require.Len(t, wallet.sent, 1, "paid in a new batch")
+		assert.Equal(t, PayoutStatusProcessed, repo.payout(id).Status)
+	})
+}
+
+func TestPayoutProcessor_SignedSinglePayouts(t *testing.T) {
+	ctx := context.Background()
+	addrA := "ltc1qaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	addrB := "LVg2kJoFNg45Nbpy53h7Fe1wKyeXVRhMH9"
+
+	newSigningProcessor := func(wallet *mockBatchWallet, repo *mockBatchRepository) *PayoutProcessor {
+		processor := NewPayoutProcessor(wallet, repo, NewMockBalanceTracker(), ProcessorConfig{MaxRetries: 2})
+		processor.SetSigner(wallet, repo)
+		return processor
+	}
+
+	t.Run("sends each payout in its own recorded transaction", func(t *testing.T) {
+		wallet := newMockBatchWallet()
+		repo := newMockBatchRepository()
+		a := repo.add(1, 1000000, addrA, time.Now())
+		b := repo.add(2, 2000000, addrB, time.Now())
+		processor := newSigningProcessor(wallet, repo)
+
+		plan, err := processor.PlanPayouts(ctx)
+		require.NoError(t, err)
+		assert.False(t, plan.Batched)
+
+		require.NoError(t, processor.ProcessPendingPayouts(ctx))
+		require.Len(t, wallet.sent, 2)
+		assert.Equal(t, map[string]int64{addrA: 1000000}, wallet.sent[0])
+		assert.Equal(t, map[string]int64{addrB: 2000000}, wallet.sent[1])
+		assert.Equal(t, PayoutStatusProcessed, repo.payout(a).Status)
+		assert.Equal(t, PayoutStatusProcessed, repo.payout(b).Status)
+		assert.NotEqual(t, repo.payout(a).TxHash, repo.payout(b).TxHash)
+
+		fees, err := processor.ledger.(*MockBalanceTracker).AccountBalance(ctx, AccountPoolFees, DefaultLedgerCurrency)
+		require.NoError(t, err)
+		assert.Equal(t, int64(-2*mockBatchFee), fees, "each transaction's fee is charged")
+	})
+
+	t.Run("reconciles an interrupted send without paying twice", func(t *testing.T) {
+		wallet := newMockBatchWallet()
+		wallet.sendErr = errors.New("RPC request failed: context deadline exceeded")
+		repo := newMockBatchRepository()
+		id := repo.add(1, 1000000, addrA, time.Now())
+		processor := newSigningProcessor(wallet, repo)
+
+		require.NoError(t, processor.ProcessPendingPayouts(ctx))
+		require.Len(t, wallet.sent, 1)
+		assert.Equal(t, PayoutStatusPending, repo.payout(id).Status)
+		assert.NotZero(t, repo.payout(id).batchID, "not picked up again")
+
+		wallet.sendErr = nil
+		require.NoError(t, processor.ProcessPendingPayouts(ctx))
+		assert.Len(t, wallet.sent, 1)
+
+		processor.now = func() time.Time { return time.Now().Add(time.Hour) }
+		require.NoError(t, processor.ProcessPendingPayouts(ctx))
+		assert.Len(t, wallet.sent, 1, "paid once")
+		assert.Equal(t, PayoutStatusProcessed, repo.payout(id).Status)
+		assert.Equal(t, "batchtx1", repo.payout(id).TxHash)
+	})
+}
+
+type recordingPayoutNotifier struct {
+	sent int
+}
+
+func (n *recordingPayoutNotifier) NotifyPayoutSent(ctx context.Context, userID int64, amount int64, address, txHash string) error {
+	n.sent++
+	return nil
+}
+
+func (n *recordingPayoutNotifier) NotifyPayoutFailed(ctx context.Context, userID int64, amount int64, reason string) error {
+	return nil
+}
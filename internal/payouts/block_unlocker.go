@@ -0,0 +1,647 @@
+package payouts
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// =============================================================================
+// BLOCK MATURITY TRACKING
+// Found blocks move pending -> immature -> confirmed, or to orphaned. Rewards
+// are credited only once the coinbase has matured; orphaned blocks are never
+// credited, and credits are clawed back if a confirmed block is reorged out.
+// =============================================================================
+
+// Block statuses
+const (
+	BlockStatusPending   = "pending"   // Submitted, no confirmations yet
+	BlockStatusImmature  = "immature"  // In the main chain, coinbase not yet spendable
+	BlockStatusConfirmed = "confirmed" // Coinbase matured, rewards credited
+	BlockStatusOrphaned  = "orphaned"  // Not in the main chain, rewards never credited or clawed back
+)
+
+// BlockConfirmation describes where a block stands on the node's chain
+type BlockConfirmation struct {
+	Known         bool  // Node has the block
+	InMainChain   bool  // Block is part of the active chain
+	Confirmations int64 // Depth in the active chain (1 = tip)
+	ForkDepth     int64 // Active chain blocks at or above the block's height, for blocks outside it
+}
+
+// ChainTracker reports block confirmations from a full node
+type ChainTracker interface {
+	GetBlockConfirmation(ctx context.Context, hash string) (*BlockConfirmation, error)
+}
+
+// BlockCreditor credits matured blocks and reverses credits of orphaned ones
+type BlockCreditor interface {
+	CreditBlock(ctx context.Context, block *Block) ([]Payout, error)
+	ClawBackBlock(ctx context.Context, block *Block, credits []Payout) (shortfall int64, err error)
+}
+
+// BlockMaturityRepository persists block maturity state and its audit trail
+type BlockMaturityRepository interface {
+	GetUnsettledBlocks(ctx context.Context, settledConfirmations int64) ([]Block, error)
+	UpdateBlockMaturity(ctx context.Context, blockID int64, status string, confirmations int64) error
+	RecordBlockCredits(ctx context.Context, blockID int64, credits []Payout) error
+	GetBlockCredits(ctx context.Context, blockID int64) ([]Payout, error)
+	RecordBlockEvent(ctx context.Context, event BlockAuditEvent) error
+	GetBlockEvents(ctx context.Context, blockID int64) ([]BlockAuditEvent, error)
+}
+
+// Compile-time interface compliance checks
+var (
+	_ ChainTracker  = (*LitecoinWalletClient)(nil)
+	_ BlockCreditor = (*PayoutExecutor)(nil)
+)
+
+// BlockAuditEvent records one status change of a found block
+type BlockAuditEvent struct {
+	ID            int64     `json:"id" db:"id"`
+	BlockID       int64     `json:"block_id" db:"block_id"`
+	ChainID       string    `json:"chain_id" db:"chain_id"` // Empty for the primary chain
+	Height        int64     `json:"height" db:"height"`
+	Hash          string    `json:"hash" db:"hash"`
+	FromStatus    string    `json:"from_status" db:"from_status"`
+	ToStatus      string    `json:"to_status" db:"to_status"`
+	Confirmations int64     `json:"confirmations" db:"confirmations"`
+	Amount        int64     `json:"amount" db:"amount"` // Credited (positive) or clawed back (negative)
+	Reason        string    `json:"reason" db:"reason"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+}
+
+// =============================================================================
+// UNLOCKER CONFIGURATION
+// =============================================================================
+
+// UnlockerConfig holds configuration for the block unlocker
+type UnlockerConfig struct {
+	CheckInterval           time.Duration `json:"check_interval" yaml:"check_interval"`
+	MaturityConfirmations   int64         `json:"maturity_confirmations" yaml:"maturity_confirmations"`
+	ReorgWatchConfirmations int64         `json:"reorg_watch_confirmations" yaml:"reorg_watch_confirmations"`
+	UnknownBlockTimeout     time.Duration `json:"unknown_block_timeout" yaml:"unknown_block_timeout"`
+}
+
+// DefaultUnlockerConfig returns sensible defaults
+func DefaultUnlockerConfig() UnlockerConfig {
+	return UnlockerConfig{
+		CheckInterval:           time.Minute,
+		MaturityConfirmations:   101, // Coinbase spendable at COINBASE_MATURITY (100) + 1
+		ReorgWatchConfirmations: 20,  // Keep watching confirmed blocks for deep reorgs
+		UnknownBlockTimeout:     30 * time.Minute,
+	}
+}
+
+// UnlockerStats holds statistics about block unlocking
+type UnlockerStats struct {
+	BlocksChecked       int64     `json:"blocks_checked"`
+	BlocksImmature      int64     `json:"blocks_immature"`
+	BlocksConfirmed     int64     `json:"blocks_confirmed"`
+	BlocksOrphaned      int64     `json:"blocks_orphaned"`
+	TotalAmountCredited int64     `json:"total_amount_credited"`
+	TotalClawedBack     int64     `json:"total_clawed_back"`
+	TotalShortfall      int64     `json:"total_shortfall"`
+	ErrorCount          int64     `json:"error_count"`
+	LastCheckAt         time.Time `json:"last_check_at"`
+}
+
+// auxTracker watches one merged-mined chain's blocks
+type auxTracker struct {
+	chain    ChainTracker
+	maturity int64
+}
+
+// =============================================================================
+// BLOCK UNLOCKER IMPLEMENTATION
+// =============================================================================
+
+// BlockUnlocker walks found blocks through maturity and credits them once
+// their coinbase can be spent
+type BlockUnlocker struct {
+	chain    ChainTracker
+	repo     BlockMaturityRepository
+	creditor BlockCreditor
+	config   UnlockerConfig
+
+	// Merged mining
+	auxRepo   AuxBlockRepository
+	auxChains map[string]auxTracker
+
+	// Stats
+	stats UnlockerStats
+	mu    sync.RWMutex
+
+	// Lifecycle
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewBlockUnlocker creates a new block unlocker
+func NewBlockUnlocker(chain ChainTracker, repo BlockMaturityRepository, creditor BlockCreditor, config UnlockerConfig) *BlockUnlocker {
+	if chain == nil || repo == nil || creditor == nil {
+		return nil
+	}
+
+	defaults := DefaultUnlockerConfig()
+	if config.CheckInterval <= 0 {
+		config.CheckInterval = defaults.CheckInterval
+	}
+	if config.MaturityConfirmations <= 0 {
+		config.MaturityConfirmations = defaults.MaturityConfirmations
+	}
+	if config.ReorgWatchConfirmations < 0 {
+		config.ReorgWatchConfirmations = 0
+	}
+	if config.UnknownBlockTimeout <= 0 {
+		config.UnknownBlockTimeout = defaults.UnknownBlockTimeout
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &BlockUnlocker{
+		chain:     chain,
+		repo:      repo,
+		creditor:  creditor,
+		config:    config,
+		auxChains: make(map[string]auxTracker),
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+}
+
+// SetAuxBlockRepository enables maturity tracking of merged-mined blocks
+func (u *BlockUnlocker) SetAuxBlockRepository(repo AuxBlockRepository) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.auxRepo = repo
+}
+
+// TrackAuxChain registers the node and coinbase maturity of an auxiliary chain
+func (u *BlockUnlocker) TrackAuxChain(chainID string, chain ChainTracker, maturity int64) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.auxChains[chainID] = auxTracker{chain: chain, maturity: maturity}
+}
+
+// Start begins periodic block checks
+func (u *BlockUnlocker) Start() {
+	u.wg.Add(1)
+	go u.checkLoop()
+}
+
+// Stop gracefully stops the unlocker
+func (u *BlockUnlocker) Stop() {
+	u.cancel()
+	u.wg.Wait()
+}
+
+// checkLoop runs the main check loop
+func (u *BlockUnlocker) checkLoop() {
+	defer u.wg.Done()
+
+	ticker := time.NewTicker(u.config.CheckInterval)
+	defer ticker.Stop()
+
+	// Check immediately on start
+	_ = u.CheckBlocks(u.ctx)
+
+	for {
+		select {
+		case <-u.ctx.Done():
+			return
+		case <-ticker.C:
+			_ = u.CheckBlocks(u.ctx)
+		}
+	}
+}
+
+// CheckBlocks checks every unsettled block against the node and applies
+// status changes. A failure on one block does not stop the others.
+func (u *BlockUnlocker) CheckBlocks(ctx context.Context) error {
+	settled := u.config.MaturityConfirmations + u.config.ReorgWatchConfirmations
+	blocks, err := u.repo.GetUnsettledBlocks(ctx, settled)
+	if err != nil {
+		u.recordError()
+		return fmt.Errorf("failed to get unsettled blocks: %w", err)
+	}
+
+	var errs []error
+	for i := range blocks {
+		if err := u.checkBlock(ctx, &blocks[i]); err != nil {
+			u.recordError()
+			errs = append(errs, fmt.Errorf("block %d (%s): %w", blocks[i].Height, blocks[i].Hash, err))
+		}
+	}
+
+	if err := u.checkAuxBlocks(ctx); err != nil {
+		errs = append(errs, err)
+	}
+
+	u.mu.Lock()
+	u.stats.BlocksChecked += int64(len(blocks))
+	u.stats.LastCheckAt = time.Now()
+	u.mu.Unlock()
+
+	return errors.Join(errs...)
+}
+
+// checkBlock moves a single block along its lifecycle
+func (u *BlockUnlocker) checkBlock(ctx context.Context, block *Block) error {
+	conf, err := u.chain.GetBlockConfirmation(ctx, block.Hash)
+	if err != nil {
+		return fmt.Errorf("failed to get confirmations: %w", err)
+	}
+
+	switch {
+	case !conf.Known:
+		// The node may simply not have seen the block yet
+		if time.Since(block.Timestamp) < u.config.UnknownBlockTimeout {
+			return nil
+		}
+		return u.orphanBlock(ctx, block, conf, "node does not know the block")
+
+	case !conf.InMainChain:
+		// The block may return to the main chain until the competing chain
+		// is as deep as a coinbase has to be to mature
+		if conf.ForkDepth < u.config.MaturityConfirmations {
+			log.Printf("⏳ Block %d is outside the main chain, competing chain %d/%d deep",
+				block.Height, conf.ForkDepth, u.config.MaturityConfirmations)
+			return u.repo.UpdateBlockMaturity(ctx, block.ID, block.Status, conf.Confirmations)
+		}
+		return u.orphanBlock(ctx, block, conf, fmt.Sprintf("block is not in the main chain, competing chain %d deep", conf.ForkDepth))
+
+	case conf.Confirmations >= u.config.MaturityConfirmations:
+		if block.Status == BlockStatusConfirmed {
+			return u.repo.UpdateBlockMaturity(ctx, block.ID, block.Status, conf.Confirmations)
+		}
+		return u.confirmBlock(ctx, block, conf)
+
+	default:
+		if block.Status == BlockStatusPending {
+			if err := u.transition(ctx, block, BlockStatusImmature, conf.Confirmations, 0,
+				fmt.Sprintf("in main chain, %d/%d confirmations", conf.Confirmations, u.config.MaturityConfirmations)); err != nil {
+				return err
+			}
+			u.mu.Lock()
+			u.stats.BlocksImmature++
+			u.mu.Unlock()
+			return nil
+		}
+		return u.repo.UpdateBlockMaturity(ctx, block.ID, block.Status, conf.Confirmations)
+	}
+}
+
+// confirmBlock credits a matured block and records its credits. Each step
+// can be retried: a block already in the ledger returns its posted credits,
+// and recording credits replaces any from an earlier attempt.
+func (u *BlockUnlocker) confirmBlock(ctx context.Context, block *Block, conf *BlockConfirmation) error {
+	confirmed := *block
+	confirmed.Status = BlockStatusConfirmed
+	confirmed.Confirmations = conf.Confirmations
+
+	credits, err := u.creditor.CreditBlock(ctx, &confirmed)
+	if err != nil {
+		return fmt.Errorf("failed to credit block: %w", err)
+	}
+	if err := u.repo.RecordBlockCredits(ctx, block.ID, credits); err != nil {
+		return fmt.Errorf("failed to record block credits: %w", err)
+	}
+
+	var total int64
+	for _, credit := range credits {
+		total += credit.Amount
+	}
+
+	if err := u.transition(ctx, block, BlockStatusConfirmed, conf.Confirmations, total,
+		fmt.Sprintf("coinbase matured, credited %d to %d users", total, len(credits))); err != nil {
+		return err
+	}
+
+	u.mu.Lock()
+	u.stats.BlocksConfirmed++
+	u.stats.TotalAmountCredited += total
+	u.mu.Unlock()
+
+	log.Printf("✅ Block %d matured: credited %d to %d users", block.Height, total, len(credits))
+	return nil
+}
+
+// orphanBlock marks a block orphaned, clawing back credits if it was confirmed
+func (u *BlockUnlocker) orphanBlock(ctx context.Context, block *Block, conf *BlockConfirmation, reason string) error {
+	var clawedBack, shortfall int64
+	if block.Status == BlockStatusConfirmed {
+		credits, err := u.repo.GetBlockCredits(ctx, block.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get block credits: %w", err)
+		}
+		shortfall, err = u.creditor.ClawBackBlock(ctx, block, credits)
+		if err != nil {
+			return fmt.Errorf("failed to claw back block credits: %w", err)
+		}
+		for _, credit := range credits {
+			clawedBack += credit.Amount
+		}
+		clawedBack -= shortfall
+		reason = fmt.Sprintf("%s, clawed back %d (shortfall %d)", reason, clawedBack, shortfall)
+	} else {
+		reason += ", never credited"
+	}
+
+	if err := u.transition(ctx, block, BlockStatusOrphaned, conf.Confirmations, -clawedBack, reason); err != nil {
+		return err
+	}
+
+	u.mu.Lock()
+	u.stats.BlocksOrphaned++
+	u.stats.TotalClawedBack += clawedBack
+	u.stats.TotalShortfall += shortfall
+	u.mu.Unlock()
+
+	log.Printf("⚠️ Block %d orphaned: %s", block.Height, reason)
+	return nil
+}
+
+// transition updates a block's status and records the audit event
+func (u *BlockUnlocker) transition(ctx context.Context, block *Block, to string, confirmations, amount int64, reason string) error {
+	if err := u.repo.UpdateBlockMaturity(ctx, block.ID, to, confirmations); err != nil {
+		return fmt.Errorf("failed to update block status: %w", err)
+	}
+
+	event := BlockAuditEvent{
+		BlockID:       block.ID,
+		Height:        block.Height,
+		Hash:          block.Hash,
+		FromStatus:    block.Status,
+		ToStatus:      to,
+		Confirmations: confirmations,
+		Amount:        amount,
+		Reason:        reason,
+		CreatedAt:     time.Now(),
+	}
+	if err := u.repo.RecordBlockEvent(ctx, event); err != nil {
+		return fmt.Errorf("failed to record block event: %w", err)
+	}
+
+	block.Status = to
+	block.Confirmations = confirmations
+	return nil
+}
+
+// checkAuxBlocks confirms or orphans pending merged-mined blocks. Aux payouts
+// are only created for confirmed aux blocks, so orphans are never credited.
+func (u *BlockUnlocker) checkAuxBlocks(ctx context.Context) error {
+	u.mu.RLock()
+	repo := u.auxRepo
+	chains := make(map[string]auxTracker, len(u.auxChains))
+	for id, tracker := range u.auxChains {
+		chains[id] = tracker
+	}
+	u.mu.RUnlock()
+
+	if repo == nil {
+		return nil
+	}
+
+	var errs []error
+	for chainID, tracker := range chains {
+		blocks, err := repo.GetPendingAuxBlocks(ctx, chainID)
+		if err != nil {
+			u.recordError()
+			errs = append(errs, fmt.Errorf("failed to get pending %s blocks: %w", chainID, err))
+			continue
+		}
+		for i := range blocks {
+			if err := u.checkAuxBlock(ctx, repo, tracker, &blocks[i]); err != nil {
+				u.recordError()
+				errs = append(errs, fmt.Errorf("%s block %d (%s): %w", chainID, blocks[i].Height, blocks[i].Hash, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// checkAuxBlock moves a single merged-mined block along its lifecycle
+func (u *BlockUnlocker) checkAuxBlock(ctx context.Context, repo AuxBlockRepository, tracker auxTracker, block *AuxBlock) error {
+	conf, err := tracker.chain.GetBlockConfirmation(ctx, block.Hash)
+	if err != nil {
+		return fmt.Errorf("failed to get confirmations: %w", err)
+	}
+
+	var to, reason string
+	switch {
+	case !conf.Known && time.Since(block.Timestamp) < u.config.UnknownBlockTimeout:
+		return nil
+	case !conf.Known:
+		to, reason = BlockStatusOrphaned, "node does not know the block, never credited"
+	case !conf.InMainChain && conf.ForkDepth < tracker.maturity:
+		return nil
+	case !conf.InMainChain:
+		to, reason = BlockStatusOrphaned, "block is not in the main chain, never credited"
+	case conf.Confirmations >= tracker.maturity:
+		to, reason = BlockStatusConfirmed, "coinbase matured"
+	default:
+		return nil
+	}
+
+	if err := repo.UpdateAuxBlockStatus(ctx, block.ID, to); err != nil {
+		return fmt.Errorf("failed to update aux block status: %w", err)
+	}
+	event := BlockAuditEvent{
+		BlockID:       block.ID,
+		ChainID:       block.ChainID,
+		Height:        block.Height,
+		Hash:          block.Hash,
+		FromStatus:    block.Status,
+		ToStatus:      to,
+		Confirmations: conf.Confirmations,
+		Reason:        reason,
+		CreatedAt:     time.Now(),
+	}
+	if err := u.repo.RecordBlockEvent(ctx, event); err != nil {
+		return fmt.Errorf("failed to record block event: %w", err)
+	}
+
+	u.mu.Lock()
+	if to == BlockStatusConfirmed {
+		u.stats.BlocksConfirmed++
+	} else {
+		u.stats.BlocksOrphaned++
+	}
+	u.mu.Unlock()
+	return nil
+}
+
+func (u *BlockUnlocker) recordError() {
+	u.mu.Lock()
+	u.stats.ErrorCount++
+	u.mu.Unlock()
+}
+
+// GetStats returns current unlocker statistics
+func (u *BlockUnlocker) GetStats() UnlockerStats {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+	return u.stats
+}
+
+// =============================================================================
+// SQL IMPLEMENTATION
+// =============================================================================
+
+// SQLBlockMaturityRepository implements BlockMaturityRepository using SQL
+type SQLBlockMaturityRepository struct {
+	db *sql.DB
+}
+
+// NewSQLBlockMaturityRepository creates a new SQL-based repository
+func NewSQLBlockMaturityRepository(db *sql.DB) *SQLBlockMaturityRepository {
+	return &SQLBlockMaturityRepository{db: db}
+}
+
+// GetUnsettledBlocks retrieves blocks that are not yet final: pending and
+// immature blocks, and confirmed blocks still inside the reorg watch window
+func (r *SQLBlockMaturityRepository) GetUnsettledBlocks(ctx context.Context, settledConfirmations int64) ([]Block, error) {
+	query := `
+		SELECT id, height, hash, reward, difficulty, finder_id, status, confirmations, timestamp
+		FROM blocks
+		WHERE status IN ('pending', 'immature')
+		   OR (status = 'confirmed' AND confirmations < $1)
+		ORDER BY height
+	`
+	rows, err := r.db.QueryContext(ctx, query, settledConfirmations)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query blocks: %w", err)
+	}
+	defer rows.Close()
+
+	var blocks []Block
+	for rows.Next() {
+		var b Block
+		if err := rows.Scan(
+			&b.ID, &b.Height, &b.Hash, &b.Reward, &b.Difficulty,
+			&b.FinderID, &b.Status, &b.Confirmations, &b.Timestamp,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan block: %w", err)
+		}
+		blocks = append(blocks, b)
+	}
+	return blocks, rows.Err()
+}
+
+// UpdateBlockMaturity updates a block's status and confirmation count
+func (r *SQLBlockMaturityRepository) UpdateBlockMaturity(ctx context.Context, blockID int64, status string, confirmations int64) error {
+	query := `UPDATE blocks SET status = $2, confirmations = $3 WHERE id = $1`
+	if _, err := r.db.ExecContext(ctx, query, blockID, status, confirmations); err != nil {
+		return fmt.Errorf("failed to update block maturity: %w", err)
+	}
+	return nil
+}
+
+// RecordBlockCredits stores the balance credits applied for a block,
+// replacing any recorded by an earlier attempt
+func (r *SQLBlockMaturityRepository) RecordBlockCredits(ctx context.Context, blockID int64, credits []Payout) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM block_credits WHERE block_id = $1`, blockID); err != nil {
+		return fmt.Errorf("failed to clear block credits: %w", err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO block_credits (block_id, user_id, amount, created_at)
+		VALUES ($1, $2, $3, $4)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	now := time.Now()
+	for _, credit := range credits {
+		if _, err := stmt.ExecContext(ctx, blockID, credit.UserID, credit.Amount, now); err != nil {
+			return fmt.Errorf("failed to insert block credit: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetBlockCredits retrieves the balance credits applied for a block
+func (r *SQLBlockMaturityRepository) GetBlockCredits(ctx context.Context, blockID int64) ([]Payout, error) {
+	query := `
+		SELECT user_id, amount, block_id, created_at
+		FROM block_credits
+		WHERE block_id = $1
+		ORDER BY user_id
+	`
+	rows, err := r.db.QueryContext(ctx, query, blockID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query block credits: %w", err)
+	}
+	defer rows.Close()
+
+	var credits []Payout
+	for rows.Next() {
+		var p Payout
+		if err := rows.Scan(&p.UserID, &p.Amount, &p.BlockID, &p.Timestamp); err != nil {
+			return nil, fmt.Errorf("failed to scan block credit: %w", err)
+		}
+		credits = append(credits, p)
+	}
+	return credits, rows.Err()
+}
+
+// RecordBlockEvent appends an entry to the block audit trail
+func (r *SQLBlockMaturityRepository) RecordBlockEvent(ctx context.Context, event BlockAuditEvent) error {
+	query := `
+		INSERT INTO block_audit_log (block_id, chain_id, height, hash, from_status, to_status, confirmations, amount, reason, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		event.BlockID, event.ChainID, event.Height, event.Hash,
+		event.FromStatus, event.ToStatus, event.Confirmations,
+		event.Amount, event.Reason, event.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record block event: %w", err)
+	}
+	return nil
+}
+
+// GetBlockEvents retrieves the audit trail of a primary chain block
+func (r *SQLBlockMaturityRepository) GetBlockEvents(ctx context.Context, blockID int64) ([]BlockAuditEvent, error) {
+	query := `
+		SELECT id, block_id, chain_id, height, hash, from_status, to_status, confirmations, amount, reason, created_at
+		FROM block_audit_log
+		WHERE block_id = $1 AND chain_id = ''
+		ORDER BY id
+	`
+	rows, err := r.db.QueryContext(ctx, query, blockID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query block events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []BlockAuditEvent
+	for rows.Next() {
+		var e BlockAuditEvent
+		if err := rows.Scan(
+			&e.ID, &e.BlockID, &e.ChainID, &e.Height, &e.Hash,
+			&e.FromStatus, &e.ToStatus, &e.Confirmations,
+			&e.Amount, &e.Reason, &e.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan block event: %w", err)
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
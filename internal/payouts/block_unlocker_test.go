@@ -0,0 +1,416 @@
+package payouts
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// MOCK IMPLEMENTATIONS FOR TESTING
+// =============================================================================
+
+// MockChainTracker reports configurable confirmations per block hash
+type MockChainTracker struct {
+	blocks map[string]*BlockConfirmation
+	err    error
+	mu     sync.RWMutex
+}
+
+func NewMockChainTracker() *MockChainTracker {
+	return &MockChainTracker{blocks: make(map[string]*BlockConfirmation)}
+}
+
+func (m *MockChainTracker) GetBlockConfirmation(ctx context.Context, hash string) (*BlockConfirmation, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.err != nil {
+		return nil, m.err
+	}
+	if conf, ok := m.blocks[hash]; ok {
+		c := *conf
+		return &c, nil
+	}
+	return &BlockConfirmation{}, nil
+}
+
+func (m *MockChainTracker) SetConfirmations(hash string, confirmations int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.blocks[hash] = &BlockConfirmation{Known: true, InMainChain: confirmations >= 0, Confirmations: confirmations}
+}
+
+// SetForked reports the block outside the main chain, with a competing chain
+// of the given depth
+func (m *MockChainTracker) SetForked(hash string, depth int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.blocks[hash] = &BlockConfirmation{Known: true, Confirmations: -1, ForkDepth: depth}
+}
+
+// MockBlockMaturityRepository keeps blocks, credits and audit events in memory
+type MockBlockMaturityRepository struct {
+	blocks    map[int64]*Block
+	credits   map[int64][]Payout
+	events    []BlockAuditEvent
+	updateErr error // returned once by the next status update
+	mu        sync.RWMutex
+}
+
+func NewMockBlockMaturityRepository(blocks ...Block) *MockBlockMaturityRepository {
+	m := &MockBlockMaturityRepository{
+		blocks:  make(map[int64]*Block),
+		credits: make(map[int64][]Payout),
+	}
+	for i := range blocks {
+		b := blocks[i]
+		m.blocks[b.ID] = &b
+	}
+	return m
+}
+
+func (m *MockBlockMaturityRepository) GetUnsettledBlocks(ctx context.Context, settledConfirmations int64) ([]Block, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var blocks []Block
+	for _, b := range m.blocks {
+		if b.Status == BlockStatusPending || b.Status == BlockStatusImmature ||
+			(b.Status == BlockStatusConfirmed && b.Confirmations < settledConfirmations) {
+			blocks = append(blocks, *b)
+		}
+	}
+	return blocks, nil
+}
+
+func (m *MockBlockMaturityRepository) UpdateBlockMaturity(ctx context.Context, blockID int64, status string, confirmations int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.updateErr; err != nil {
+		m.updateErr = nil
+		return err
+	}
+	m.blocks[blockID].Status = status
+	m.blocks[blockID].Confirmations = confirmations
+	return nil
+}
+
+func (m *MockBlockMaturityRepository) RecordBlockCredits(ctx context.Context, blockID int64, credits []Payout) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.credits[blockID] = append([]Payout(nil), credits...)
+	return nil
+}
+
+func (m *MockBlockMaturityRepository) GetBlockCredits(ctx context.Context, blockID int64) ([]Payout, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.credits[blockID], nil
+}
+
+func (m *MockBlockMaturityRepository) RecordBlockEvent(ctx context.Context, event BlockAuditEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	event.ID = int64(len(m.events) + 1)
+	m.events = append(m.events, event)
+	return nil
+}
+
+func (m *MockBlockMaturityRepository) GetBlockEvents(ctx context.Context, blockID int64) ([]BlockAuditEvent, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var events []BlockAuditEvent
+	for _, e := range m.events {
+		if e.BlockID == blockID && e.ChainID == "" {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+func (m *MockBlockMaturityRepository) Status(blockID int64) string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.blocks[blockID].Status
+}
+
+// newTestUnlocker wires an unlocker to a real executor with mock dependencies
+func newTestUnlocker(t *testing.T, blocks ...Block) (*BlockUnlocker, *MockChainTracker, *MockBlockMaturityRepository, *MockBalanceTracker) {
+	shares := NewMockShareProvider()
+	shares.AddShare(Share{UserID: 1, Difficulty: 300, Timestamp: time.Now().Add(-5 * time.Minute)})
+	shares.AddShare(Share{UserID: 2, Difficulty: 100, Timestamp: time.Now().Add(-3 * time.Minute)})
+
+	settings := NewMockUserSettingsProvider()
+	for _, userID := range []int64{1, 2} {
+		settings.SetUserSettings(userID, &UserPayoutSettings{UserID: userID, PayoutMode: PayoutModePPLNS})
+	}
+
+	balances := NewMockBalanceTracker()
+	executor := NewPayoutExecutor(DefaultPayoutConfig(), NewMockBlockNotifier(), NewMockPayoutQueue(), settings, shares, balances)
+	require.NotNil(t, executor)
+
+	chain := NewMockChainTracker()
+	repo := NewMockBlockMaturityRepository(blocks...)
+	config := DefaultUnlockerConfig()
+	config.MaturityConfirmations = 3
+	config.ReorgWatchConfirmations = 2
+
+	unlocker := NewBlockUnlocker(chain, repo, executor, config)
+	require.NotNil(t, unlocker)
+	return unlocker, chain, repo, balances
+}
+
+func testFoundBlock(id int64, hash string) Block {
+	return Block{ID: id, Height: 1000 + id, Hash: hash, Reward: 1250000000, Status: BlockStatusPending, Timestamp: time.Now()}
+}
+
+// =============================================================================
+// BLOCK UNLOCKER TESTS
+// =============================================================================
+
+func TestBlockUnlocker_Creation(t *testing.T) {
+	assert.Nil(t, NewBlockUnlocker(nil, nil, nil, DefaultUnlockerConfig()))
+
+	unlocker := NewBlockUnlocker(NewMockChainTracker(), NewMockBlockMaturityRepository(), &PayoutExecutor{}, UnlockerConfig{})
+	require.NotNil(t, unlocker)
+	assert.Equal(t, time.Minute, unlocker.config.CheckInterval)
+	assert.Equal(t, int64(101), unlocker.config.MaturityConfirmations)
+}
+
+func TestBlockUnlocker_MaturesBeforeCrediting(t *testing.T) {
+	unlocker, chain, repo, balances := newTestUnlocker(t, testFoundBlock(1, "aa"))
+	ctx := context.Background()
+
+	// Not yet seen by the node: stays pending within the grace period
+	require.NoError(t, unlocker.CheckBlocks(ctx))
+	assert.Equal(t, BlockStatusPending, repo.Status(1))
+
+	chain.SetConfirmations("aa", 1)
+	require.NoError(t, unlocker.CheckBlocks(ctx))
+	assert.Equal(t, BlockStatusImmature, repo.Status(1))
+	assert.Zero(t, balances.GetBalance(1), "immature blocks are not credited")
+
+	chain.SetConfirmations("aa", 2)
+	require.NoError(t, unlocker.CheckBlocks(ctx))
+	assert.Equal(t, BlockStatusImmature, repo.Status(1))
+	assert.Equal(t, int64(2), repo.blocks[1].Confirmations)
+
+	chain.SetConfirmations("aa", 3)
+	require.NoError(t, unlocker.CheckBlocks(ctx))
+	assert.Equal(t, BlockStatusConfirmed, repo.Status(1))
+	assert.Greater(t, balances.GetBalance(1), balances.GetBalance(2))
+	assert.Greater(t, balances.GetBalance(2), int64(0))
+
+	// Further checks inside the reorg window do not credit again
+	credited := balances.GetBalance(1)
+	chain.SetConfirmations("aa", 4)
+	require.NoError(t, unlocker.CheckBlocks(ctx))
+	assert.Equal(t, credited, balances.GetBalance(1))
+
+	events, err := repo.GetBlockEvents(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, BlockStatusPending, events[0].FromStatus)
+	assert.Equal(t, BlockStatusImmature, events[0].ToStatus)
+	assert.Equal(t, BlockStatusConfirmed, events[1].ToStatus)
+	assert.Equal(t, balances.GetBalance(1)+balances.GetBalance(2), events[1].Amount)
+
+	stats := unlocker.GetStats()
+	assert.Equal(t, int64(1), stats.BlocksConfirmed)
+	assert.Equal(t, events[1].Amount, stats.TotalAmountCredited)
+}
+
+func TestBlockUnlocker_RetriesPartiallyConfirmedBlock(t *testing.T) {
+	unlocker, chain, repo, balances := newTestUnlocker(t, testFoundBlock(1, "aa"))
+	ctx := context.Background()
+
+	// Credited in the ledger, but the status update fails
+	chain.SetConfirmations("aa", 3)
+	repo.updateErr = errors.New("connection reset")
+	require.Error(t, unlocker.CheckBlocks(ctx))
+	require.Equal(t, BlockStatusPending, repo.Status(1))
+	credited1, credited2 := balances.GetBalance(1), balances.GetBalance(2)
+	require.Greater(t, credited1, int64(0))
+
+	require.NoError(t, unlocker.CheckBlocks(ctx))
+	assert.Equal(t, BlockStatusConfirmed, repo.Status(1))
+	assert.Equal(t, credited1, balances.GetBalance(1), "not credited twice")
+	assert.Equal(t, credited2, balances.GetBalance(2))
+
+	credits, err := repo.GetBlockCredits(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, credits, 2)
+	assert.Equal(t, credited1+credited2, credits[0].Amount+credits[1].Amount)
+
+	events, _ := repo.GetBlockEvents(ctx, 1)
+	require.Len(t, events, 1)
+	assert.Equal(t, credited1+credited2, events[0].Amount)
+}
+
+func TestBlockUnlocker_OrphanIsNeverCredited(t *testing.T) {
+	unlocker, chain, repo, balances := newTestUnlocker(t, testFoundBlock(1, "aa"))
+	ctx := context.Background()
+
+	chain.SetConfirmations("aa", 1)
+	require.NoError(t, unlocker.CheckBlocks(ctx))
+
+	chain.SetForked("aa", 3)
+	require.NoError(t, unlocker.CheckBlocks(ctx))
+	assert.Equal(t, BlockStatusOrphaned, repo.Status(1))
+	assert.Zero(t, balances.GetBalance(1))
+
+	events, _ := repo.GetBlockEvents(ctx, 1)
+	require.Len(t, events, 2)
+	assert.Equal(t, BlockStatusOrphaned, events[1].ToStatus)
+	assert.Contains(t, events[1].Reason, "never credited")
+	assert.Zero(t, events[1].Amount)
+}
+
+func TestBlockUnlocker_ReorgedBackBlockIsNotOrphaned(t *testing.T) {
+	unlocker, chain, repo, balances := newTestUnlocker(t, testFoundBlock(1, "aa"), testFoundBlock(2, "bb"))
+	ctx := context.Background()
+
+	chain.SetConfirmations("aa", 1)
+	chain.SetConfirmations("bb", 3)
+	require.NoError(t, unlocker.CheckBlocks(ctx))
+	require.Equal(t, BlockStatusImmature, repo.Status(1))
+	require.Equal(t, BlockStatusConfirmed, repo.Status(2))
+	credited := balances.GetBalance(1)
+
+	// A shallow competing chain takes over for a while
+	chain.SetForked("aa", 1)
+	chain.SetForked("bb", 2)
+	require.NoError(t, unlocker.CheckBlocks(ctx))
+	assert.Equal(t, BlockStatusImmature, repo.Status(1), "kept until the competing chain is deep enough")
+	assert.Equal(t, BlockStatusConfirmed, repo.Status(2))
+	assert.Equal(t, credited, balances.GetBalance(1), "nothing is clawed back yet")
+
+	// The original chain wins again
+	chain.SetConfirmations("aa", 3)
+	chain.SetConfirmations("bb", 5)
+	require.NoError(t, unlocker.CheckBlocks(ctx))
+	assert.Equal(t, BlockStatusConfirmed, repo.Status(1))
+	assert.Equal(t, BlockStatusConfirmed, repo.Status(2))
+	assert.Greater(t, balances.GetBalance(1), credited)
+
+	stats := unlocker.GetStats()
+	assert.Zero(t, stats.BlocksOrphaned)
+	assert.Zero(t, stats.TotalClawedBack)
+}
+
+func TestBlockUnlocker_UnknownBlockOrphanedAfterTimeout(t *testing.T) {
+	block := testFoundBlock(1, "aa")
+	block.Timestamp = time.Now().Add(-time.Hour)
+	unlocker, _, repo, _ := newTestUnlocker(t, block)
+
+	require.NoError(t, unlocker.CheckBlocks(context.Background()))
+	assert.Equal(t, BlockStatusOrphaned, repo.Status(1))
+}
+
+func TestBlockUnlocker_ClawsBackReorgedBlock(t *testing.T) {
+	unlocker, chain, repo, balances := newTestUnlocker(t, testFoundBlock(1, "aa"))
+	ctx := context.Background()
+
+	chain.SetConfirmations("aa", 3)
+	require.NoError(t, unlocker.CheckBlocks(ctx))
+	require.Equal(t, BlockStatusConfirmed, repo.Status(1))
+	credited1, credited2 := balances.GetBalance(1), balances.GetBalance(2)
+
+	// User 2's reward has already been paid out
+	require.NoError(t, balances.QueuePayout(ctx, PendingPayout{ID: 7, UserID: 2, Amount: credited2}))
+	require.NoError(t, balances.CompletePayout(ctx, PendingPayout{ID: 7, UserID: 2, Amount: credited2}, "tx1"))
+
+	chain.SetForked("aa", 4)
+	require.NoError(t, unlocker.CheckBlocks(ctx))
+	assert.Equal(t, BlockStatusOrphaned, repo.Status(1))
+	assert.Zero(t, balances.GetBalance(1))
+
+	events, _ := repo.GetBlockEvents(ctx, 1)
+	last := events[len(events)-1]
+	assert.Equal(t, BlockStatusConfirmed, last.FromStatus)
+	assert.Equal(t, -credited1, last.Amount)
+
+	stats := unlocker.GetStats()
+	assert.Equal(t, credited1, stats.TotalClawedBack)
+	assert.Equal(t, credited2, stats.TotalShortfall)
+}
+
+func TestBlockUnlocker_SettledBlocksAreNotRechecked(t *testing.T) {
+	unlocker, chain, repo, _ := newTestUnlocker(t, testFoundBlock(1, "aa"))
+	ctx := context.Background()
+
+	chain.SetConfirmations("aa", 5)
+	require.NoError(t, unlocker.CheckBlocks(ctx))
+	assert.Equal(t, BlockStatusConfirmed, repo.Status(1))
+
+	// Past maturity plus the reorg window the block is final
+	chain.SetConfirmations("aa", -1)
+	require.NoError(t, unlocker.CheckBlocks(ctx))
+	assert.Equal(t, BlockStatusConfirmed, repo.Status(1))
+}
+
+func TestBlockUnlocker_NodeErrorsKeepStatus(t *testing.T) {
+	unlocker, chain, repo, _ := newTestUnlocker(t, testFoundBlock(1, "aa"))
+	chain.err = errors.New("connection refused")
+
+	err := unlocker.CheckBlocks(context.Background())
+	assert.ErrorContains(t, err, "connection refused")
+	assert.Equal(t, BlockStatusPending, repo.Status(1))
+	assert.Equal(t, int64(1), unlocker.GetStats().ErrorCount)
+}
+
+// mockAuxBlockRepository keeps aux blocks in memory
+type mockAuxBlockRepository struct {
+	AuxBlockRepository
+	blocks []AuxBlock
+}
+
+func (m *mockAuxBlockRepository) GetPendingAuxBlocks(ctx context.Context, chainID string) ([]AuxBlock, error) {
+	var pending []AuxBlock
+	for _, b := range m.blocks {
+		if b.ChainID == chainID && b.Status == BlockStatusPending {
+			pending = append(pending, b)
+		}
+	}
+	return pending, nil
+}
+
+func (m *mockAuxBlockRepository) UpdateAuxBlockStatus(ctx context.Context, id int64, status string) error {
+	for i := range m.blocks {
+		if m.blocks[i].ID == id {
+			m.blocks[i].Status = status
+		}
+	}
+	return nil
+}
+
+func TestBlockUnlocker_AuxBlocks(t *testing.T) {
+	unlocker, _, repo, _ := newTestUnlocker(t)
+	auxRepo := &mockAuxBlockRepository{blocks: []AuxBlock{
+		{ID: 1, ChainID: "doge", Height: 10, Hash: "d1", Status: BlockStatusPending, Timestamp: time.Now()},
+		{ID: 2, ChainID: "doge", Height: 11, Hash: "d2", Status: BlockStatusPending, Timestamp: time.Now()},
+		{ID: 3, ChainID: "doge", Height: 12, Hash: "d3", Status: BlockStatusPending, Timestamp: time.Now()},
+		{ID: 4, ChainID: "doge", Height: 13, Hash: "d4", Status: BlockStatusPending, Timestamp: time.Now()},
+	}}
+	doge := NewMockChainTracker()
+	doge.SetConfirmations("d1", 240)
+	doge.SetForked("d2", 240)
+	doge.SetConfirmations("d3", 5)
+	doge.SetForked("d4", 5)
+
+	unlocker.SetAuxBlockRepository(auxRepo)
+	unlocker.TrackAuxChain("doge", doge, 240)
+	require.NoError(t, unlocker.CheckBlocks(context.Background()))
+
+	assert.Equal(t, BlockStatusConfirmed, auxRepo.blocks[0].Status)
+	assert.Equal(t, BlockStatusOrphaned, auxRepo.blocks[1].Status)
+	assert.Equal(t, BlockStatusPending, auxRepo.blocks[2].Status)
+	assert.Equal(t, BlockStatusPending, auxRepo.blocks[3].Status, "the competing chain is not deep enough yet")
+
+	require.Len(t, repo.events, 2)
+	assert.Equal(t, "doge", repo.events[0].ChainID)
+}
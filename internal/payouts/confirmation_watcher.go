@@ -0,0 +1,675 @@
+package payouts
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// =============================================================================
+// PAYOUT CONFIRMATION TRACKING
+// Sent payout transactions move unconfirmed -> confirmed, or to conflicted or
+// dropped. Stuck transactions are fee-bumped; payouts whose transaction can
+// no longer confirm are returned to the miners' balances.
+// =============================================================================
+
+// Payout transaction statuses
+const (
+	PayoutTxUnconfirmed = "unconfirmed" // In the mempool or waiting to be rebroadcast
+	PayoutTxConfirmed   = "confirmed"   // In a block; final at the required confirmations
+	PayoutTxConflicted  = "conflicted"  // Double-spent; refunded once the conflict is deep enough
+	PayoutTxDropped     = "dropped"     // Inputs gone and abandoned; refunded
+)
+
+// ErrTransactionInvalid is returned when a transaction can never confirm
+var ErrTransactionInvalid = errors.New("transaction is invalid")
+
+// TransactionStatus describes where a wallet transaction stands
+type TransactionStatus struct {
+	Known           bool             // Wallet has the transaction
+	Confirmations   int64            // Negative when a conflicting transaction confirmed
+	InMempool       bool             // Only checked while unconfirmed
+	WalletConflicts []string         // Wallet transactions spending the same inputs
+	Outputs         map[string]int64 // Amount sent to each address, excluding change
+}
+
+// TransactionTracker follows and fee-bumps wallet transactions
+type TransactionTracker interface {
+	GetTransactionStatus(ctx context.Context, txHash string) (*TransactionStatus, error)
+	RebroadcastTransaction(ctx context.Context, txHash string) error
+	AbandonTransaction(ctx context.Context, txHash string) error
+	// BumpFee signs an RBF replacement without broadcasting it
+	BumpFee(ctx context.Context, txHash string, confirmTarget int) (*SignedTransaction, error)
+	BroadcastTransaction(ctx context.Context, tx SignedTransaction) error
+	BumpFeeWithChild(ctx context.Context, txHash string, feeRate int64) (childTxHash string, err error)
+	EstimateFee(ctx context.Context, confirmTarget int) (int64, error)
+}
+
+// PayoutReverser returns sent payouts whose transaction was invalidated
+type PayoutReverser interface {
+	ReversePayout(ctx context.Context, payout PendingPayout, reason string) error
+}
+
+// PayoutConfirmationNotifier notifies miners of confirmed payouts (ISP)
+type PayoutConfirmationNotifier interface {
+	NotifyPayoutConfirmed(ctx context.Context, userID int64, amount int64, address, txHash string, confirmations int64) error
+}
+
+// PayoutTransactionRepository persists payout transaction state
+type PayoutTransactionRepository interface {
+	// TrackPayoutTransactions starts watching newly sent payouts
+	TrackPayoutTransactions(ctx context.Context) (int64, error)
+	GetWatchedTransactions(ctx context.Context) ([]WatchedTransaction, error)
+	UpdateTransactionStatus(ctx context.Context, txHash, status string, confirmations int64) error
+	// ResolveTransaction records a final outcome. Payouts of a transaction
+	// that did not confirm are marked failed with the reason.
+	ResolveTransaction(ctx context.Context, txHash, status string, confirmations int64, reason string) error
+	// RecordFeeBump counts a fee bump. replacementTxHash is the RBF
+	// replacement, or empty when the transaction itself is unchanged.
+	RecordFeeBump(ctx context.Context, txHash, replacementTxHash string) error
+	// AdoptTransaction switches back to a replaced version that confirmed
+	AdoptTransaction(ctx context.Context, txHash, confirmedTxHash string) error
+}
+
+// Compile-time interface compliance checks
+var (
+	_ TransactionTracker         = (*LitecoinWalletClient)(nil)
+	_ PayoutReverser             = (*Ledger)(nil)
+	_ PayoutConfirmationNotifier = (*NotificationAdapter)(nil)
+)
+
+// WatchedTransaction is a sent transaction and the payouts it carries
+type WatchedTransaction struct {
+	TxHash           string          `json:"tx_hash"`
+	Status           string          `json:"status"`
+	Confirmations    int64           `json:"confirmations"`
+	BumpCount        int             `json:"bump_count"`
+	LastBumpedAt     *time.Time      `json:"last_bumped_at,omitempty"`
+	ReplacedTxHashes []string        `json:"replaced_tx_hashes,omitempty"`
+	SentAt           time.Time       `json:"sent_at"`
+	Payouts          []PendingPayout `json:"payouts"`
+}
+
+// isReplacedVersion reports whether hash is an earlier version of this
+// transaction
+func (t *WatchedTransaction) isReplacedVersion(hash string) bool {
+	for _, replaced := range t.ReplacedTxHashes {
+		if hash == replaced {
+			return true
+		}
+	}
+	return false
+}
+
+// paysSameOutputs reports whether a transaction sending conflict pays every
+// payout output of one sending outputs. Fee bumps only change the change.
+func paysSameOutputs(conflict, outputs map[string]int64) bool {
+	if len(outputs) == 0 {
+		return false
+	}
+	for address, amount := range outputs {
+		if conflict[address] != amount {
+			return false
+		}
+	}
+	return true
+}
+
+// =============================================================================
+// WATCHER CONFIGURATION
+// =============================================================================
+
+// WatcherConfig holds configuration for the payout confirmation watcher
+type WatcherConfig struct {
+	CheckInterval         time.Duration `json:"check_interval" yaml:"check_interval"`
+	RequiredConfirmations int64         `json:"required_confirmations" yaml:"required_confirmations"`
+	StuckAfter            time.Duration `json:"stuck_after" yaml:"stuck_after"` // Unconfirmed time before a fee bump
+	MaxFeeBumps           int           `json:"max_fee_bumps" yaml:"max_fee_bumps"`
+	BumpConfirmTarget     int           `json:"bump_confirm_target" yaml:"bump_confirm_target"`
+}
+
+// DefaultWatcherConfig returns sensible defaults
+func DefaultWatcherConfig() WatcherConfig {
+	return WatcherConfig{
+		CheckInterval:         time.Minute,
+		RequiredConfirmations: 6,
+		StuckAfter:            time.Hour,
+		MaxFeeBumps:           3,
+		BumpConfirmTarget:     2,
+	}
+}
+
+// WatcherStats holds statistics about payout confirmation tracking
+type WatcherStats struct {
+	TransactionsChecked    int64     `json:"transactions_checked"`
+	TransactionsConfirmed  int64     `json:"transactions_confirmed"`
+	TransactionsConflicted int64     `json:"transactions_conflicted"`
+	TransactionsDropped    int64     `json:"transactions_dropped"`
+	FeeBumps               int64     `json:"fee_bumps"`
+	TotalAmountRefunded    int64     `json:"total_amount_refunded"`
+	ErrorCount             int64     `json:"error_count"`
+	LastCheckAt            time.Time `json:"last_check_at"`
+}
+
+// =============================================================================
+// CONFIRMATION WATCHER IMPLEMENTATION
+// =============================================================================
+
+// PayoutConfirmationWatcher follows sent payout transactions until they are
+// final, bumping fees of stuck ones and refunding invalidated ones
+type PayoutConfirmationWatcher struct {
+	tracker  TransactionTracker
+	repo     PayoutTransactionRepository
+	ledger   PayoutReverser
+	notifier PayoutNotifier
+	config   WatcherConfig
+	now      func() time.Time
+
+	// Stats
+	stats WatcherStats
+	mu    sync.RWMutex
+
+	// Lifecycle
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewPayoutConfirmationWatcher creates a new payout confirmation watcher
+func NewPayoutConfirmationWatcher(tracker TransactionTracker, repo PayoutTransactionRepository, ledger PayoutReverser, config WatcherConfig) *PayoutConfirmationWatcher {
+	if tracker == nil || repo == nil || ledger == nil {
+		return nil
+	}
+
+	defaults := DefaultWatcherConfig()
+	if config.CheckInterval <= 0 {
+		config.CheckInterval = defaults.CheckInterval
+	}
+	if config.RequiredConfirmations <= 0 {
+		config.RequiredConfirmations = defaults.RequiredConfirmations
+	}
+	if config.StuckAfter <= 0 {
+		config.StuckAfter = defaults.StuckAfter
+	}
+	if config.MaxFeeBumps < 0 {
+		config.MaxFeeBumps = 0
+	}
+	if config.BumpConfirmTarget <= 0 {
+		config.BumpConfirmTarget = defaults.BumpConfirmTarget
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &PayoutConfirmationWatcher{
+		tracker: tracker,
+		repo:    repo,
+		ledger:  ledger,
+		config:  config,
+		now:     time.Now,
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+// SetNotifier sets the notifier told about final outcomes. Confirmations are
+// only sent if it also implements PayoutConfirmationNotifier.
+func (w *PayoutConfirmationWatcher) SetNotifier(notifier PayoutNotifier) {
+	w.notifier = notifier
+}
+
+// Start begins periodic transaction checks
+func (w *PayoutConfirmationWatcher) Start() {
+	w.wg.Add(1)
+	go w.checkLoop()
+}
+
+// Stop gracefully stops the watcher
+func (w *PayoutConfirmationWatcher) Stop() {
+	w.cancel()
+	w.wg.Wait()
+}
+
+// checkLoop runs the main check loop
+func (w *PayoutConfirmationWatcher) checkLoop() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(w.config.CheckInterval)
+	defer ticker.Stop()
+
+	// Check immediately on start
+	_ = w.CheckTransactions(w.ctx)
+
+	for {
+		select {
+		case <-w.ctx.Done():
+			return
+		case <-ticker.C:
+			_ = w.CheckTransactions(w.ctx)
+		}
+	}
+}
+
+// CheckTransactions checks every watched transaction against the wallet.
+// A failure on one transaction does not stop the others.
+func (w *PayoutConfirmationWatcher) CheckTransactions(ctx context.Context) error {
+	if _, err := w.repo.TrackPayoutTransactions(ctx); err != nil {
+		w.recordError()
+		return fmt.Errorf("failed to track payout transactions: %w", err)
+	}
+
+	txs, err := w.repo.GetWatchedTransactions(ctx)
+	if err != nil {
+		w.recordError()
+		return fmt.Errorf("failed to get watched transactions: %w", err)
+	}
+
+	var errs []error
+	for i := range txs {
+		if err := w.checkTransaction(ctx, &txs[i]); err != nil {
+			w.recordError()
+			errs = append(errs, fmt.Errorf("tx %s: %w", txs[i].TxHash, err))
+		}
+	}
+
+	w.mu.Lock()
+	w.stats.TransactionsChecked += int64(len(txs))
+	w.stats.LastCheckAt = time.Now()
+	w.mu.Unlock()
+
+	return errors.Join(errs...)
+}
+
+// checkTransaction moves a single transaction along its lifecycle
+func (w *PayoutConfirmationWatcher) checkTransaction(ctx context.Context, tx *WatchedTransaction) error {
+	status, err := w.tracker.GetTransactionStatus(ctx, tx.TxHash)
+	if err != nil {
+		return fmt.Errorf("failed to get transaction: %w", err)
+	}
+
+	switch {
+	case !status.Known:
+		// A replacement recorded before a broadcast that never happened:
+		// follow the version the node has again
+		if n := len(tx.ReplacedTxHashes); n > 0 {
+			previous := tx.ReplacedTxHashes[n-1]
+			log.Printf("🔁 Payout tx %s was never broadcast, following %s", tx.TxHash, previous)
+			return w.repo.AdoptTransaction(ctx, tx.TxHash, previous)
+		}
+		// Nothing to bump or refund against; needs an operator
+		return fmt.Errorf("wallet does not know the transaction")
+
+	case status.Confirmations < 0:
+		return w.checkConflict(ctx, tx, status)
+
+	case status.Confirmations >= w.config.RequiredConfirmations:
+		return w.confirm(ctx, tx, status.Confirmations)
+
+	case status.Confirmations > 0:
+		return w.repo.UpdateTransactionStatus(ctx, tx.TxHash, PayoutTxConfirmed, status.Confirmations)
+
+	case status.InMempool:
+		if err := w.repo.UpdateTransactionStatus(ctx, tx.TxHash, PayoutTxUnconfirmed, 0); err != nil {
+			return err
+		}
+		if w.isStuck(tx) {
+			return w.bumpFee(ctx, tx)
+		}
+		return nil
+
+	default:
+		// Evicted from the mempool: rebroadcast, unless its inputs are gone
+		err := w.tracker.RebroadcastTransaction(ctx, tx.TxHash)
+		if errors.Is(err, ErrTransactionInvalid) {
+			// Spent by another wallet transaction, possibly a version of
+			// this one: wait for it to confirm rather than refunding now
+			if len(status.WalletConflicts) > 0 {
+				return w.checkConflict(ctx, tx, status)
+			}
+			if err := w.tracker.AbandonTransaction(ctx, tx.TxHash); err != nil {
+				return fmt.Errorf("failed to abandon transaction: %w", err)
+			}
+			return w.invalidate(ctx, tx, PayoutTxDropped, 0, "transaction was dropped and can no longer confirm")
+		}
+		if err != nil {
+			return fmt.Errorf("failed to rebroadcast transaction: %w", err)
+		}
+		log.Printf("🔁 Rebroadcast payout tx %s", tx.TxHash)
+		return nil
+	}
+}
+
+// checkConflict handles a transaction whose inputs were spent by another
+// one. If that is another version of the payout, recorded or not, it is
+// tracked instead; otherwise the payouts are refunded once the conflict is
+// deep enough.
+func (w *PayoutConfirmationWatcher) checkConflict(ctx context.Context, tx *WatchedTransaction, status *TransactionStatus) error {
+	for _, hash := range status.WalletConflicts {
+		conflict, err := w.tracker.GetTransactionStatus(ctx, hash)
+		if err != nil {
+			return fmt.Errorf("failed to get conflicting transaction: %w", err)
+		}
+		if conflict.Confirmations <= 0 {
+			continue
+		}
+		if tx.isReplacedVersion(hash) || paysSameOutputs(conflict.Outputs, status.Outputs) {
+			log.Printf("🔁 Payout tx %s confirmed as version %s", tx.TxHash, hash)
+			return w.repo.AdoptTransaction(ctx, tx.TxHash, hash)
+		}
+	}
+
+	depth := -status.Confirmations
+	if depth < w.config.RequiredConfirmations {
+		return w.repo.UpdateTransactionStatus(ctx, tx.TxHash, PayoutTxConflicted, status.Confirmations)
+	}
+	return w.invalidate(ctx, tx, PayoutTxConflicted, status.Confirmations, "transaction was double-spent")
+}
+
+// isStuck reports whether an unconfirmed transaction is due a fee bump
+func (w *PayoutConfirmationWatcher) isStuck(tx *WatchedTransaction) bool {
+	if tx.BumpCount >= w.config.MaxFeeBumps {
+		return false
+	}
+	since := tx.SentAt
+	if tx.LastBumpedAt != nil {
+		since = *tx.LastBumpedAt
+	}
+	return w.now().Sub(since) >= w.config.StuckAfter
+}
+
+// bumpFee replaces a stuck transaction with RBF, falling back to a CPFP
+// child when it cannot be replaced. The replacement is recorded before it is
+// broadcast, so the payouts never point at a version the node could drop
+// for one nobody knows about. Failed attempts count towards MaxFeeBumps so
+// a transaction is not retried forever.
+func (w *PayoutConfirmationWatcher) bumpFee(ctx context.Context, tx *WatchedTransaction) error {
+	replacement, rbfErr := w.tracker.BumpFee(ctx, tx.TxHash, w.config.BumpConfirmTarget)
+	if rbfErr == nil {
+		if err := w.repo.RecordFeeBump(ctx, tx.TxHash, replacement.TxID); err != nil {
+			return fmt.Errorf("failed to record fee bump: %w", err)
+		}
+		if err := w.tracker.BroadcastTransaction(ctx, *replacement); err != nil {
+			// Follow the original again. If the node did accept the
+			// replacement, it is a recorded earlier version of the original.
+			if adoptErr := w.repo.AdoptTransaction(ctx, replacement.TxID, tx.TxHash); adoptErr != nil {
+				return fmt.Errorf("failed to broadcast replacement: %v; failed to restore original: %w", err, adoptErr)
+			}
+			return fmt.Errorf("failed to broadcast replacement: %w", err)
+		}
+		w.recordFeeBump()
+		log.Printf("⛽ Payout tx %s replaced by %s", tx.TxHash, replacement.TxID)
+		return nil
+	}
+
+	var child string
+	feeRate, err := w.tracker.EstimateFee(ctx, w.config.BumpConfirmTarget)
+	if err == nil {
+		child, err = w.tracker.BumpFeeWithChild(ctx, tx.TxHash, feeRate)
+	}
+	if recordErr := w.repo.RecordFeeBump(ctx, tx.TxHash, ""); recordErr != nil {
+		return fmt.Errorf("failed to record fee bump: %w", recordErr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to bump fee (rbf: %v, cpfp: %w)", rbfErr, err)
+	}
+
+	w.recordFeeBump()
+	log.Printf("⛽ Payout tx %s bumped by child %s", tx.TxHash, child)
+	return nil
+}
+
+// confirm records a transaction as final and tells the miners
+func (w *PayoutConfirmationWatcher) confirm(ctx context.Context, tx *WatchedTransaction, confirmations int64) error {
+	if err := w.repo.ResolveTransaction(ctx, tx.TxHash, PayoutTxConfirmed, confirmations, ""); err != nil {
+		return fmt.Errorf("failed to resolve transaction: %w", err)
+	}
+
+	if notifier, ok := w.notifier.(PayoutConfirmationNotifier); ok {
+		for _, payout := range tx.Payouts {
+			_ = notifier.NotifyPayoutConfirmed(ctx, payout.UserID, payout.Amount, payout.Address, tx.TxHash, confirmations)
+		}
+	}
+
+	w.mu.Lock()
+	w.stats.TransactionsConfirmed++
+	w.mu.Unlock()
+	return nil
+}
+
+// invalidate returns a transaction's payouts to the miners' balances and
+// records the final outcome. Refunds are posted first; the ledger rejects
+// a second reversal, so a retry after a failure is safe.
+func (w *PayoutConfirmationWatcher) invalidate(ctx context.Context, tx *WatchedTransaction, status string, confirmations int64, reason string) error {
+	var refunded int64
+	for _, payout := range tx.Payouts {
+		err := w.ledger.ReversePayout(ctx, payout, reason)
+		if err != nil && !errors.Is(err, ErrDuplicatePosting) {
+			return fmt.Errorf("failed to refund payout %d: %w", payout.ID, err)
+		}
+		refunded += payout.Amount
+	}
+
+	if err := w.repo.ResolveTransaction(ctx, tx.TxHash, status, confirmations, reason); err != nil {
+		return fmt.Errorf("failed to resolve transaction: %w", err)
+	}
+
+	if w.notifier != nil {
+		for _, payout := range tx.Payouts {
+			_ = w.notifier.NotifyPayoutFailed(ctx, payout.UserID, payout.Amount, reason+"; the amount was returned to your balance")
+		}
+	}
+
+	w.mu.Lock()
+	if status == PayoutTxDropped {
+		w.stats.TransactionsDropped++
+	} else {
+		w.stats.TransactionsConflicted++
+	}
+	w.stats.TotalAmountRefunded += refunded
+	w.mu.Unlock()
+
+	log.Printf("⚠️ Payout tx %s %s: refunded %d to %d payouts", tx.TxHash, status, refunded, len(tx.Payouts))
+	return nil
+}
+
+func (w *PayoutConfirmationWatcher) recordFeeBump() {
+	w.mu.Lock()
+	w.stats.FeeBumps++
+	w.mu.Unlock()
+}
+
+func (w *PayoutConfirmationWatcher) recordError() {
+	w.mu.Lock()
+	w.stats.ErrorCount++
+	w.mu.Unlock()
+}
+
+// GetStats returns current watcher statistics
+func (w *PayoutConfirmationWatcher) GetStats() WatcherStats {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.stats
+}
+
+// =============================================================================
+// SQL IMPLEMENTATION
+// =============================================================================
+
+// SQLPayoutTransactionRepository implements PayoutTransactionRepository using SQL
+type SQLPayoutTransactionRepository struct {
+	db *sql.DB
+}
+
+// NewSQLPayoutTransactionRepository creates a new SQL-based repository
+func NewSQLPayoutTransactionRepository(db *sql.DB) *SQLPayoutTransactionRepository {
+	return &SQLPayoutTransactionRepository{db: db}
+}
+
+// TrackPayoutTransactions starts watching sent payouts that are not yet tracked
+func (r *SQLPayoutTransactionRepository) TrackPayoutTransactions(ctx context.Context) (int64, error) {
+	query := `
+		INSERT INTO payout_transactions (payout_id, tx_hash, amount, status, created_at)
+		SELECT p.id, p.tx_hash, p.amount, $1, COALESCE(p.processed_at, NOW())
+		FROM pending_payouts p
+		WHERE p.status = $2 AND COALESCE(p.tx_hash, '') <> ''
+		  AND NOT EXISTS (SELECT 1 FROM payout_transactions t WHERE t.payout_id = p.id)
+	`
+	result, err := r.db.ExecContext(ctx, query, PayoutTxUnconfirmed, PayoutStatusProcessed)
+	if err != nil {
+		return 0, fmt.Errorf("failed to track payout transactions: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// GetWatchedTransactions retrieves transactions without a final outcome,
+// grouping the payouts each one carries
+func (r *SQLPayoutTransactionRepository) GetWatchedTransactions(ctx context.Context) ([]WatchedTransaction, error) {
+	query := `
+		SELECT t.tx_hash, t.status, t.confirmations, t.bump_count, t.last_bumped_at,
+		       t.replaced_tx_hashes, t.created_at,
+		       p.id, p.user_id, p.amount, p.address, COALESCE(p.block_id, 0), p.created_at
+		FROM payout_transactions t
+		JOIN pending_payouts p ON p.id = t.payout_id
+		WHERE t.resolved_at IS NULL
+		ORDER BY t.created_at, t.tx_hash, p.id
+	`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query payout transactions: %w", err)
+	}
+	defer rows.Close()
+
+	var txs []WatchedTransaction
+	index := make(map[string]int)
+	for rows.Next() {
+		var tx WatchedTransaction
+		var lastBumpedAt sql.NullTime
+		var replaced []string
+		p := PendingPayout{Status: PayoutStatusProcessed}
+		if err := rows.Scan(
+			&tx.TxHash, &tx.Status, &tx.Confirmations, &tx.BumpCount, &lastBumpedAt,
+			pq.Array(&replaced), &tx.SentAt,
+			&p.ID, &p.UserID, &p.Amount, &p.Address, &p.BlockID, &p.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan payout transaction: %w", err)
+		}
+		p.TxHash = tx.TxHash
+
+		i, ok := index[tx.TxHash]
+		if !ok {
+			if lastBumpedAt.Valid {
+				tx.LastBumpedAt = &lastBumpedAt.Time
+			}
+			tx.ReplacedTxHashes = replaced
+			index[tx.TxHash] = len(txs)
+			txs = append(txs, tx)
+			i = len(txs) - 1
+		}
+		txs[i].Payouts = append(txs[i].Payouts, p)
+	}
+	return txs, rows.Err()
+}
+
+// UpdateTransactionStatus updates a transaction's status and confirmations
+func (r *SQLPayoutTransactionRepository) UpdateTransactionStatus(ctx context.Context, txHash, status string, confirmations int64) error {
+	query := `
+		UPDATE payout_transactions
+		SET status = $2, confirmations = $3, checked_at = NOW(),
+		    confirmed_at = CASE WHEN $2 = 'confirmed' THEN COALESCE(confirmed_at, NOW()) END
+		WHERE tx_hash = $1 AND resolved_at IS NULL
+	`
+	if _, err := r.db.ExecContext(ctx, query, txHash, status, confirmations); err != nil {
+		return fmt.Errorf("failed to update payout transaction: %w", err)
+	}
+	return nil
+}
+
+// ResolveTransaction records a transaction's final outcome
+func (r *SQLPayoutTransactionRepository) ResolveTransaction(ctx context.Context, txHash, status string, confirmations int64, reason string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE payout_transactions
+		SET status = $2, confirmations = $3, checked_at = NOW(), resolved_at = NOW(), resolution = $4,
+		    confirmed_at = CASE WHEN $2 = 'confirmed' THEN COALESCE(confirmed_at, NOW()) END
+		WHERE tx_hash = $1 AND resolved_at IS NULL
+	`, txHash, status, confirmations, sql.NullString{String: reason, Valid: reason != ""})
+	if err != nil {
+		return fmt.Errorf("failed to resolve payout transaction: %w", err)
+	}
+
+	if status != PayoutTxConfirmed {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE pending_payouts
+			SET status = $2, error_message = $3
+			WHERE tx_hash = $1 AND status = $4
+		`, txHash, PayoutStatusFailed, reason, PayoutStatusProcessed)
+		if err != nil {
+			return fmt.Errorf("failed to fail payouts: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// RecordFeeBump counts a fee bump, moving the payouts to the replacement
+func (r *SQLPayoutTransactionRepository) RecordFeeBump(ctx context.Context, txHash, replacementTxHash string) error {
+	if replacementTxHash == "" {
+		query := `
+			UPDATE payout_transactions
+			SET bump_count = bump_count + 1, last_bumped_at = NOW()
+			WHERE tx_hash = $1 AND resolved_at IS NULL
+		`
+		if _, err := r.db.ExecContext(ctx, query, txHash); err != nil {
+			return fmt.Errorf("failed to record fee bump: %w", err)
+		}
+		return nil
+	}
+
+	return r.replaceTxHash(ctx, `
+		UPDATE payout_transactions
+		SET tx_hash = $2, replaced_tx_hashes = array_append(replaced_tx_hashes, $1),
+		    bump_count = bump_count + 1, last_bumped_at = NOW()
+		WHERE tx_hash = $1 AND resolved_at IS NULL
+	`, txHash, replacementTxHash)
+}
+
+// AdoptTransaction switches the payouts back to a replaced version that confirmed
+func (r *SQLPayoutTransactionRepository) AdoptTransaction(ctx context.Context, txHash, confirmedTxHash string) error {
+	return r.replaceTxHash(ctx, `
+		UPDATE payout_transactions
+		SET tx_hash = $2, replaced_tx_hashes = array_append(array_remove(replaced_tx_hashes, $2), $1)
+		WHERE tx_hash = $1 AND resolved_at IS NULL
+	`, txHash, confirmedTxHash)
+}
+
+// replaceTxHash runs a payout_transactions update moving oldHash to newHash
+// and points the payouts and their batch at newHash
+func (r *SQLPayoutTransactionRepository) replaceTxHash(ctx context.Context, query, oldHash, newHash string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, query, oldHash, newHash); err != nil {
+		return fmt.Errorf("failed to update payout transaction: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE pending_payouts SET tx_hash = $2 WHERE tx_hash = $1`, oldHash, newHash); err != nil {
+		return fmt.Errorf("failed to update payouts: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE payout_batches SET tx_hash = $2 WHERE tx_hash = $1`, oldHash, newHash); err != nil {
+		return fmt.Errorf("failed to update payout batch: %w", err)
+	}
+
+	return tx.Commit()
+}
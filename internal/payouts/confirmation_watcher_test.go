@@ -0,0 +1,437 @@
+package payouts
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// MOCK IMPLEMENTATIONS FOR TESTING
+// =============================================================================
+
+// mockTxTracker reports configurable transaction states
+type mockTxTracker struct {
+	statuses     map[string]*TransactionStatus
+	rebroadcast  error
+	rbfErr       error
+	cpfpErr      error
+	broadcastErr error
+	bumped       []string
+	broadcast    []string
+	children     []string
+	abandoned    []string
+	rebroadcasts int
+	mu           sync.Mutex
+}
+
+func newMockTxTracker() *mockTxTracker {
+	return &mockTxTracker{statuses: make(map[string]*TransactionStatus)}
+}
+
+func (m *mockTxTracker) set(txHash string, status TransactionStatus) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	status.Known = true
+	m.statuses[txHash] = &status
+}
+
+func (m *mockTxTracker) GetTransactionStatus(ctx context.Context, txHash string) (*TransactionStatus, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if status, ok := m.statuses[txHash]; ok {
+		s := *status
+		return &s, nil
+	}
+	return &TransactionStatus{}, nil
+}
+
+func (m *mockTxTracker) RebroadcastTransaction(ctx context.Context, txHash string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rebroadcasts++
+	return m.rebroadcast
+}
+
+func (m *mockTxTracker) AbandonTransaction(ctx context.Context, txHash string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.abandoned = append(m.abandoned, txHash)
+	return nil
+}
+
+func (m *mockTxTracker) BumpFee(ctx context.Context, txHash string, confirmTarget int) (*SignedTransaction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.rbfErr != nil {
+		return nil, m.rbfErr
+	}
+	m.bumped = append(m.bumped, txHash)
+	return &SignedTransaction{TxID: txHash + "-rbf", Hex: "02" + txHash}, nil
+}
+
+func (m *mockTxTracker) BroadcastTransaction(ctx context.Context, tx SignedTransaction) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.broadcastErr != nil {
+		return m.broadcastErr
+	}
+	m.broadcast = append(m.broadcast, tx.TxID)
+	return nil
+}
+
+func (m *mockTxTracker) BumpFeeWithChild(ctx context.Context, txHash string, feeRate int64) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.cpfpErr != nil {
+		return "", m.cpfpErr
+	}
+	m.children = append(m.children, txHash)
+	return txHash + "-child", nil
+}
+
+func (m *mockTxTracker) EstimateFee(ctx context.Context, confirmTarget int) (int64, error) {
+	return 20000, nil
+}
+
+// mockPayoutTxRepository keeps watched transactions in memory
+type mockPayoutTxRepository struct {
+	txs    map[string]*WatchedTransaction
+	failed map[int64]string
+	mu     sync.Mutex
+}
+
+func newMockPayoutTxRepository(txs ...WatchedTransaction) *mockPayoutTxRepository {
+	m := &mockPayoutTxRepository{txs: make(map[string]*WatchedTransaction), failed: make(map[int64]string)}
+	for i := range txs {
+		tx := txs[i]
+		m.txs[tx.TxHash] = &tx
+	}
+	return m
+}
+
+func (m *mockPayoutTxRepository) get(txHash string) *WatchedTransaction {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.txs[txHash]
+}
+
+func (m *mockPayoutTxRepository) TrackPayoutTransactions(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
+func (m *mockPayoutTxRepository) GetWatchedTransactions(ctx context.Context) ([]WatchedTransaction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var txs []WatchedTransaction
+	for _, tx := range m.txs {
+		if tx.Status != PayoutTxDropped && !(tx.Status == PayoutTxConfirmed && tx.Confirmations >= 6) &&
+			!(tx.Status == PayoutTxConflicted && tx.Confirmations <= -6) {
+			txs = append(txs, *tx)
+		}
+	}
+	return txs, nil
+}
+
+func (m *mockPayoutTxRepository) UpdateTransactionStatus(ctx context.Context, txHash, status string, confirmations int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.txs[txHash].Status = status
+	m.txs[txHash].Confirmations = confirmations
+	return nil
+}
+
+func (m *mockPayoutTxRepository) ResolveTransaction(ctx context.Context, txHash, status string, confirmations int64, reason string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	tx := m.txs[txHash]
+	tx.Status = status
+	tx.Confirmations = confirmations
+	if status != PayoutTxConfirmed {
+		for _, p := range tx.Payouts {
+			m.failed[p.ID] = reason
+		}
+	}
+	return nil
+}
+
+func (m *mockPayoutTxRepository) RecordFeeBump(ctx context.Context, txHash, replacementTxHash string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	tx := m.txs[txHash]
+	tx.BumpCount++
+	now := time.Now()
+	tx.LastBumpedAt = &now
+	if replacementTxHash != "" {
+		delete(m.txs, txHash)
+		tx.ReplacedTxHashes = append(tx.ReplacedTxHashes, txHash)
+		tx.TxHash = replacementTxHash
+		m.txs[replacementTxHash] = tx
+	}
+	return nil
+}
+
+func (m *mockPayoutTxRepository) AdoptTransaction(ctx context.Context, txHash, confirmedTxHash string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	tx := m.txs[txHash]
+	delete(m.txs, txHash)
+	var replaced []string
+	for _, hash := range tx.ReplacedTxHashes {
+		if hash != confirmedTxHash {
+			replaced = append(replaced, hash)
+		}
+	}
+	tx.ReplacedTxHashes = append(replaced, txHash)
+	tx.TxHash = confirmedTxHash
+	m.txs[confirmedTxHash] = tx
+	return nil
+}
+
+type recordingConfirmationNotifier struct {
+	recordingPayoutNotifier
+	confirmed int
+	failed    []string
+}
+
+func (n *recordingConfirmationNotifier) NotifyPayoutConfirmed(ctx context.Context, userID int64, amount int64, address, txHash string, confirmations int64) error {
+	n.confirmed++
+	return nil
+}
+
+func (n *recordingConfirmationNotifier) NotifyPayoutFailed(ctx context.Context, userID int64, amount int64, reason string) error {
+	n.failed = append(n.failed, reason)
+	return nil
+}
+
+func watchedTx(txHash string, sentAt time.Time) WatchedTransaction {
+	return WatchedTransaction{
+		TxHash: txHash,
+		Status: PayoutTxUnconfirmed,
+		SentAt: sentAt,
+		Payouts: []PendingPayout{
+			{ID: 1, UserID: 10, Amount: 1000000, Address: "ltc1qminer1", TxHash: txHash},
+			{ID: 2, UserID: 20, Amount: 2000000, Address: "ltc1qminer2", TxHash: txHash},
+		},
+	}
+}
+
+// =============================================================================
+// CONFIRMATION WATCHER TESTS
+// =============================================================================
+
+func TestPayoutConfirmationWatcher_Creation(t *testing.T) {
+	ledger := NewLedger(NewMemoryLedgerStore(), DefaultLedgerCurrency)
+	assert.Nil(t, NewPayoutConfirmationWatcher(nil, newMockPayoutTxRepository(), ledger, WatcherConfig{}))
+
+	watcher := NewPayoutConfirmationWatcher(newMockTxTracker(), newMockPayoutTxRepository(), ledger, WatcherConfig{})
+	require.NotNil(t, watcher)
+	assert.Equal(t, int64(6), watcher.config.RequiredConfirmations)
+	assert.Equal(t, time.Hour, watcher.config.StuckAfter)
+	assert.Zero(t, watcher.config.MaxFeeBumps, "zero disables fee bumping")
+}
+
+func TestPayoutConfirmationWatcher_Confirmations(t *testing.T) {
+	ctx := context.Background()
+	tracker := newMockTxTracker()
+	repo := newMockPayoutTxRepository(watchedTx("tx1", time.Now()))
+	notifier := &recordingConfirmationNotifier{}
+	watcher := NewPayoutConfirmationWatcher(tracker, repo, NewLedger(NewMemoryLedgerStore(), DefaultLedgerCurrency), DefaultWatcherConfig())
+	watcher.SetNotifier(notifier)
+
+	tracker.set("tx1", TransactionStatus{InMempool: true})
+	require.NoError(t, watcher.CheckTransactions(ctx))
+	assert.Equal(t, PayoutTxUnconfirmed, repo.get("tx1").Status)
+
+	tracker.set("tx1", TransactionStatus{Confirmations: 2})
+	require.NoError(t, watcher.CheckTransactions(ctx))
+	assert.Equal(t, PayoutTxConfirmed, repo.get("tx1").Status)
+	assert.Equal(t, int64(2), repo.get("tx1").Confirmations)
+	assert.Zero(t, notifier.confirmed, "not final yet")
+
+	tracker.set("tx1", TransactionStatus{Confirmations: 6})
+	require.NoError(t, watcher.CheckTransactions(ctx))
+	assert.Equal(t, 2, notifier.confirmed, "one per payout")
+	assert.Equal(t, int64(1), watcher.GetStats().TransactionsConfirmed)
+
+	require.NoError(t, watcher.CheckTransactions(ctx))
+	assert.Equal(t, 2, notifier.confirmed, "final transactions are not watched")
+}
+
+func TestPayoutConfirmationWatcher_FeeBumps(t *testing.T) {
+	ctx := context.Background()
+	ledger := NewLedger(NewMemoryLedgerStore(), DefaultLedgerCurrency)
+
+	t.Run("replaces stuck transactions with RBF", func(t *testing.T) {
+		tracker := newMockTxTracker()
+		repo := newMockPayoutTxRepository(watchedTx("tx1", time.Now().Add(-2*time.Hour)), watchedTx("fresh", time.Now()))
+		watcher := NewPayoutConfirmationWatcher(tracker, repo, ledger, DefaultWatcherConfig())
+		tracker.set("tx1", TransactionStatus{InMempool: true})
+		tracker.set("fresh", TransactionStatus{InMempool: true})
+
+		require.NoError(t, watcher.CheckTransactions(ctx))
+		assert.Equal(t, []string{"tx1"}, tracker.bumped)
+		assert.Equal(t, []string{"tx1-rbf"}, tracker.broadcast)
+		replaced := repo.get("tx1-rbf")
+		require.NotNil(t, replaced)
+		assert.Equal(t, []string{"tx1"}, replaced.ReplacedTxHashes)
+
+		// Not stuck again until StuckAfter has passed since the bump
+		tracker.set("tx1-rbf", TransactionStatus{InMempool: true})
+		require.NoError(t, watcher.CheckTransactions(ctx))
+		assert.Len(t, tracker.bumped, 1)
+	})
+
+	t.Run("keeps following the original when the replacement is not broadcast", func(t *testing.T) {
+		tracker := newMockTxTracker()
+		tracker.broadcastErr = errors.New("connection refused")
+		repo := newMockPayoutTxRepository(watchedTx("tx1", time.Now().Add(-2*time.Hour)))
+		watcher := NewPayoutConfirmationWatcher(tracker, repo, ledger, DefaultWatcherConfig())
+		tracker.set("tx1", TransactionStatus{InMempool: true})
+
+		assert.Error(t, watcher.CheckTransactions(ctx))
+		tx := repo.get("tx1")
+		require.NotNil(t, tx)
+		assert.Equal(t, []string{"tx1-rbf"}, tx.ReplacedTxHashes, "the node may still have accepted it")
+		assert.Equal(t, 1, tx.BumpCount)
+	})
+
+	t.Run("returns to the original when a recorded replacement is unknown", func(t *testing.T) {
+		tracker := newMockTxTracker()
+		tx := watchedTx("tx1-rbf", time.Now())
+		tx.ReplacedTxHashes = []string{"tx1"}
+		repo := newMockPayoutTxRepository(tx)
+		watcher := NewPayoutConfirmationWatcher(tracker, repo, ledger, DefaultWatcherConfig())
+		tracker.set("tx1", TransactionStatus{InMempool: true})
+
+		require.NoError(t, watcher.CheckTransactions(ctx))
+		require.NotNil(t, repo.get("tx1"))
+		assert.Nil(t, repo.get("tx1-rbf"))
+	})
+
+	t.Run("falls back to CPFP and stops after MaxFeeBumps", func(t *testing.T) {
+		tracker := newMockTxTracker()
+		tracker.rbfErr = &RPCError{Code: -4, Message: "Transaction is not BIP 125 replaceable"}
+		repo := newMockPayoutTxRepository(watchedTx("tx1", time.Now().Add(-2*time.Hour)))
+		config := DefaultWatcherConfig()
+		config.MaxFeeBumps = 2
+		watcher := NewPayoutConfirmationWatcher(tracker, repo, ledger, config)
+		watcher.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+		tracker.set("tx1", TransactionStatus{InMempool: true})
+
+		require.NoError(t, watcher.CheckTransactions(ctx))
+		assert.Equal(t, []string{"tx1"}, tracker.children)
+
+		tracker.cpfpErr = errors.New("transaction tx1 has no spendable wallet output")
+		assert.Error(t, watcher.CheckTransactions(ctx))
+		assert.Equal(t, 2, repo.get("tx1").BumpCount, "failed attempts count")
+
+		require.NoError(t, watcher.CheckTransactions(ctx))
+		assert.Equal(t, int64(1), watcher.GetStats().FeeBumps)
+	})
+}
+
+func TestPayoutConfirmationWatcher_Invalidation(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("refunds double-spent payouts once the conflict is deep", func(t *testing.T) {
+		tracker := newMockTxTracker()
+		repo := newMockPayoutTxRepository(watchedTx("tx1", time.Now()))
+		ledger := NewLedger(NewMemoryLedgerStore(), DefaultLedgerCurrency)
+		notifier := &recordingConfirmationNotifier{}
+		watcher := NewPayoutConfirmationWatcher(tracker, repo, ledger, DefaultWatcherConfig())
+		watcher.SetNotifier(notifier)
+
+		tracker.set("tx1", TransactionStatus{Confirmations: -1, WalletConflicts: []string{"other"}})
+		require.NoError(t, watcher.CheckTransactions(ctx))
+		assert.Equal(t, PayoutTxConflicted, repo.get("tx1").Status)
+		assert.Empty(t, repo.failed, "conflict may still be reorged out")
+
+		tracker.set("tx1", TransactionStatus{Confirmations: -6, WalletConflicts: []string{"other"}})
+		require.NoError(t, watcher.CheckTransactions(ctx))
+		assert.Len(t, repo.failed, 2)
+		assert.Len(t, notifier.failed, 2)
+
+		balance, err := ledger.UserBalance(ctx, 20)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2000000), balance)
+		assert.Equal(t, int64(3000000), watcher.GetStats().TotalAmountRefunded)
+	})
+
+	t.Run("tracks an earlier version that confirmed instead of refunding", func(t *testing.T) {
+		tracker := newMockTxTracker()
+		tx := watchedTx("tx1-rbf", time.Now())
+		tx.ReplacedTxHashes = []string{"tx1"}
+		repo := newMockPayoutTxRepository(tx)
+		watcher := NewPayoutConfirmationWatcher(tracker, repo, NewLedger(NewMemoryLedgerStore(), DefaultLedgerCurrency), DefaultWatcherConfig())
+
+		tracker.set("tx1-rbf", TransactionStatus{Confirmations: -6, WalletConflicts: []string{"tx1"}})
+		tracker.set("tx1", TransactionStatus{Confirmations: 6})
+		require.NoError(t, watcher.CheckTransactions(ctx))
+		assert.Empty(t, repo.failed)
+		require.NotNil(t, repo.get("tx1"))
+
+		require.NoError(t, watcher.CheckTransactions(ctx))
+		assert.Equal(t, PayoutTxConfirmed, repo.get("tx1").Status)
+	})
+
+	t.Run("tracks an unrecorded version paying the same outputs", func(t *testing.T) {
+		tracker := newMockTxTracker()
+		repo := newMockPayoutTxRepository(watchedTx("tx1", time.Now()))
+		ledger := NewLedger(NewMemoryLedgerStore(), DefaultLedgerCurrency)
+		watcher := NewPayoutConfirmationWatcher(tracker, repo, ledger, DefaultWatcherConfig())
+		outputs := map[string]int64{"ltc1qminer1": 1000000, "ltc1qminer2": 2000000}
+
+		tracker.set("tx1", TransactionStatus{Confirmations: -6, WalletConflicts: []string{"other", "bumped"}, Outputs: outputs})
+		tracker.set("other", TransactionStatus{Confirmations: 6, Outputs: map[string]int64{"ltc1qminer1": 1000000}})
+		tracker.set("bumped", TransactionStatus{Confirmations: 6, Outputs: outputs})
+		require.NoError(t, watcher.CheckTransactions(ctx))
+		assert.Empty(t, repo.failed)
+		require.NotNil(t, repo.get("bumped"))
+
+		balance, err := ledger.UserBalance(ctx, 10)
+		require.NoError(t, err)
+		assert.Zero(t, balance)
+	})
+
+	t.Run("waits on a conflict when the rebroadcast inputs are spent", func(t *testing.T) {
+		tracker := newMockTxTracker()
+		repo := newMockPayoutTxRepository(watchedTx("tx1", time.Now()))
+		watcher := NewPayoutConfirmationWatcher(tracker, repo, NewLedger(NewMemoryLedgerStore(), DefaultLedgerCurrency), DefaultWatcherConfig())
+		tracker.rebroadcast = ErrTransactionInvalid
+		tracker.set("tx1", TransactionStatus{WalletConflicts: []string{"bumped"}})
+		tracker.set("bumped", TransactionStatus{InMempool: true})
+
+		require.NoError(t, watcher.CheckTransactions(ctx))
+		assert.Empty(t, tracker.abandoned)
+		assert.Empty(t, repo.failed)
+		assert.Equal(t, PayoutTxConflicted, repo.get("tx1").Status)
+	})
+
+	t.Run("rebroadcasts evicted transactions and refunds invalid ones", func(t *testing.T) {
+		tracker := newMockTxTracker()
+		repo := newMockPayoutTxRepository(watchedTx("tx1", time.Now()))
+		ledger := NewLedger(NewMemoryLedgerStore(), DefaultLedgerCurrency)
+		watcher := NewPayoutConfirmationWatcher(tracker, repo, ledger, DefaultWatcherConfig())
+		tracker.set("tx1", TransactionStatus{})
+
+		require.NoError(t, watcher.CheckTransactions(ctx))
+		assert.Equal(t, 1, tracker.rebroadcasts)
+		assert.Empty(t, repo.failed)
+
+		tracker.rebroadcast = ErrTransactionInvalid
+		require.NoError(t, watcher.CheckTransactions(ctx))
+		assert.Equal(t, []string{"tx1"}, tracker.abandoned)
+		assert.Equal(t, PayoutTxDropped, repo.get("tx1").Status)
+		assert.Len(t, repo.failed, 2)
+
+		balance, err := ledger.UserBalance(ctx, 10)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1000000), balance)
+	})
+}
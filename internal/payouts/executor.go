@@ -35,11 +35,14 @@ type ShareProvider interface {
 	GetSharesInWindow(ctx context.Context, endTime time.Time, windowSize int64) ([]Share, error)
 }
 
-// BalanceTracker manages user balance accounting
+// BalanceTracker records balance movements as ledger postings
 type BalanceTracker interface {
-	GetBalance(userID int64) int64
-	AddToBalance(userID int64, amount int64) error
-	DeductFromBalance(userID int64, amount int64) error
+	UserBalance(ctx context.Context, userID int64) (int64, error)
+	CreditBlock(ctx context.Context, block *Block, credits []Payout) error
+	BlockCredits(ctx context.Context, block *Block) ([]Payout, error)
+	ClawBackBlock(ctx context.Context, block *Block, credits []Payout) (shortfall int64, err error)
+	QueuePayout(ctx context.Context, payout PendingPayout) error
+	RefundPayout(ctx context.Context, payout PendingPayout, reason string) error
 }
 
 // =============================================================================
@@ -51,6 +54,7 @@ type PayoutStatus string
 
 const (
 	PayoutStatusPending   PayoutStatus = "pending"
+	PayoutStatusHeld      PayoutStatus = "held" // Awaiting admin approval
 	PayoutStatusProcessed PayoutStatus = "processed"
 	PayoutStatusFailed    PayoutStatus = "failed"
 	PayoutStatusCancelled PayoutStatus = "cancelled"
@@ -69,6 +73,13 @@ type PendingPayout struct {
 	ProcessedAt  *time.Time   `json:"processed_at,omitempty"`
 	TxHash       string       `json:"tx_hash,omitempty"`
 	ErrorMessage string       `json:"error_message,omitempty"`
+	ApprovedAt   *time.Time   `json:"approved_at,omitempty"`
+
+	// Allocations split the payout across wallets; empty pays Address
+	Allocations []PayoutAllocation `json:"allocations,omitempty"`
+	// Carried are shares of wallets below their minimum, left out of Amount
+	// and moved to a new pending payout when the allocations are recorded
+	Carried []PayoutAllocation `json:"carried,omitempty"`
 }
 
 // ExecutorStats holds statistics about payout processing
@@ -78,6 +89,8 @@ type ExecutorStats struct {
 	TotalPayoutsQueued     int64     `json:"total_payouts_queued"`
 	TotalAmountCredited    int64     `json:"total_amount_credited"`
 	LastBlockProcessed     time.Time `json:"last_block_processed"`
+	BlocksClawedBack       int64     `json:"blocks_clawed_back"`
+	TotalAmountClawedBack  int64     `json:"total_amount_clawed_back"`
 	ErrorCount             int64     `json:"error_count"`
 }
 
@@ -205,20 +218,27 @@ func (e *PayoutExecutor) Stop() {
 
 // ProcessBlock calculates and credits payouts for a confirmed block
 func (e *PayoutExecutor) ProcessBlock(ctx context.Context, block *Block) error {
-	if block.Status != "confirmed" {
-		return fmt.Errorf("%w: status=%s", ErrBlockNotConfirmed, block.Status)
+	_, err := e.CreditBlock(ctx, block)
+	return err
+}
+
+// CreditBlock calculates and credits payouts for a confirmed block and
+// returns the credits that were applied
+func (e *PayoutExecutor) CreditBlock(ctx context.Context, block *Block) ([]Payout, error) {
+	if block.Status != BlockStatusConfirmed {
+		return nil, fmt.Errorf("%w: status=%s", ErrBlockNotConfirmed, block.Status)
 	}
 
 	// Get shares in the payout window
 	windowSize := e.config.PPLNSWindowSize
 	shares, err := e.shareProvider.GetSharesInWindow(ctx, block.Timestamp, windowSize)
 	if err != nil {
-		return fmt.Errorf("failed to get shares: %w", err)
+		return nil, fmt.Errorf("failed to get shares: %w", err)
 	}
 
 	if len(shares) == 0 {
 		// No shares, nothing to pay out
-		return nil
+		return nil, nil
 	}
 
 	// Group shares by user and their payout mode
@@ -248,34 +268,44 @@ func (e *PayoutExecutor) ProcessBlock(ctx context.Context, block *Block) error {
 	}
 
 	if !ok || calculator == nil {
-		return fmt.Errorf("no calculator available")
+		return nil, fmt.Errorf("no calculator available")
 	}
 
 	payouts, err := calculator.CalculatePayouts(shares, block.Reward, 0, block.Timestamp)
 	if err != nil {
-		return fmt.Errorf("failed to calculate payouts: %w", err)
+		return nil, fmt.Errorf("failed to calculate payouts: %w", err)
 	}
 
-	// Credit balances and check for auto-payout triggering
+	// Credit the whole block as one posting; the pool fee is the remainder
 	var totalCredited int64
-	var payoutsQueued int64
-
-	for _, payout := range payouts {
-		// Credit user balance
-		if err := e.balanceTracker.AddToBalance(payout.UserID, payout.Amount); err != nil {
-			continue
+	for i := range payouts {
+		payouts[i].BlockID = block.ID
+		totalCredited += payouts[i].Amount
+	}
+	if err := e.balanceTracker.CreditBlock(ctx, block, payouts); err != nil {
+		if !errors.Is(err, ErrDuplicatePosting) {
+			return nil, fmt.Errorf("failed to credit block: %w", err)
+		}
+		// Credited by an earlier attempt that failed afterwards: return what
+		// was credited then, which the share window may no longer reproduce
+		credited, err := e.balanceTracker.BlockCredits(ctx, block)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load block credits: %w", err)
 		}
-		totalCredited += payout.Amount
+		return credited, nil
+	}
 
-		// Check if auto-payout should be triggered
+	// Check for auto-payout triggering
+	var payoutsQueued int64
+	for _, payout := range payouts {
 		settings, err := e.settingsProvider.GetUserPayoutSettings(payout.UserID)
 		if err != nil {
 			continue
 		}
 
 		if settings.AutoPayoutEnable && settings.PayoutAddress != "" {
-			balance := e.balanceTracker.GetBalance(payout.UserID)
-			if balance >= settings.MinPayoutAmount {
+			balance, err := e.balanceTracker.UserBalance(ctx, payout.UserID)
+			if err == nil && balance > 0 && balance >= settings.MinPayoutAmount {
 				// Queue payout
 				pending := PendingPayout{
 					ID:         time.Now().UnixNano(), // Simple ID generation
@@ -288,11 +318,15 @@ func (e *PayoutExecutor) ProcessBlock(ctx context.Context, block *Block) error {
 					CreatedAt:  time.Now(),
 				}
 
-				if err := e.queue.Enqueue(pending); err == nil {
-					// Deduct from balance (will be returned if tx fails)
-					e.balanceTracker.DeductFromBalance(payout.UserID, balance)
-					payoutsQueued++
+				// Move the balance to the payout before it can be sent
+				if err := e.balanceTracker.QueuePayout(ctx, pending); err != nil {
+					continue
+				}
+				if err := e.queue.Enqueue(pending); err != nil {
+					_ = e.balanceTracker.RefundPayout(ctx, pending, "failed to enqueue payout")
+					continue
 				}
+				payoutsQueued++
 			}
 		}
 	}
@@ -306,7 +340,30 @@ func (e *PayoutExecutor) ProcessBlock(ctx context.Context, block *Block) error {
 	e.stats.LastBlockProcessed = time.Now()
 	e.mu.Unlock()
 
-	return nil
+	return payouts, nil
+}
+
+// ClawBackBlock reverses the credits of a block that was orphaned after it
+// was credited. Amounts already paid out cannot be recovered; their total is
+// returned as the shortfall.
+func (e *PayoutExecutor) ClawBackBlock(ctx context.Context, block *Block, credits []Payout) (int64, error) {
+	shortfall, err := e.balanceTracker.ClawBackBlock(ctx, block, credits)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claw back block: %w", err)
+	}
+
+	var recovered int64
+	for _, credit := range credits {
+		recovered += credit.Amount
+	}
+	recovered -= shortfall
+
+	e.mu.Lock()
+	e.stats.BlocksClawedBack++
+	e.stats.TotalAmountClawedBack += recovered
+	e.mu.Unlock()
+
+	return shortfall, nil
 }
 
 // GetStats returns current executor statistics
@@ -323,5 +380,6 @@ func (e *PayoutExecutor) GetPendingPayouts() []PendingPayout {
 
 // GetUserBalance returns a user's current balance
 func (e *PayoutExecutor) GetUserBalance(userID int64) int64 {
-	return e.balanceTracker.GetBalance(userID)
+	balance, _ := e.balanceTracker.UserBalance(context.Background(), userID)
+	return balance
 }
@@ -127,39 +127,20 @@ func (m *MockShareProvider) AddShare(share Share) {
 	m.shares = append(m.shares, share)
 }
 
-// MockBalanceTracker tracks user balances
+// MockBalanceTracker tracks user balances in an in-memory ledger
 type MockBalanceTracker struct {
-	balances map[int64]int64
-	mu       sync.RWMutex
+	*Ledger
 }
 
 func NewMockBalanceTracker() *MockBalanceTracker {
 	return &MockBalanceTracker{
-		balances: make(map[int64]int64),
+		Ledger: NewLedger(NewMemoryLedgerStore(), DefaultLedgerCurrency),
 	}
 }
 
 func (m *MockBalanceTracker) GetBalance(userID int64) int64 {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-	return m.balances[userID]
-}
-
-func (m *MockBalanceTracker) AddToBalance(userID int64, amount int64) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	m.balances[userID] += amount
-	return nil
-}
-
-func (m *MockBalanceTracker) DeductFromBalance(userID int64, amount int64) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	if m.balances[userID] < amount {
-		return ErrInsufficientBalance
-	}
-	m.balances[userID] -= amount
-	return nil
+	balance, _ := m.UserBalance(context.Background(), userID)
+	return balance
 }
 
 // =============================================================================
@@ -0,0 +1,682 @@
+package payouts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// =============================================================================
+// DOUBLE-ENTRY LEDGER
+// Every balance movement is an append-only posting whose lines sum to zero.
+// Line amounts are signed: positive debits, negative credits. Balances are
+// derived from the lines; nothing mutates a balance directly.
+// =============================================================================
+
+// AccountType classifies ledger accounts
+type AccountType string
+
+const (
+	AccountTypeAsset     AccountType = "asset"     // Funds the pool holds (debit-normal)
+	AccountTypeLiability AccountType = "liability" // Funds the pool owes miners (credit-normal)
+	AccountTypeEquity    AccountType = "equity"    // The pool's own share (credit-normal)
+)
+
+// Pool accounts
+const (
+	AccountPoolWallet      = "pool:wallet"      // Asset: funds in the pool wallet
+	AccountPayoutsInFlight = "pool:payouts"     // Liability: queued payouts not yet sent
+	AccountPoolFees        = "pool:fees"        // Equity: pool fee income, net of network fees and losses
+	AccountPoolAdjustments = "pool:adjustments" // Equity: manual admin adjustments
+)
+
+// DefaultLedgerCurrency is the currency of primary chain postings
+const DefaultLedgerCurrency = "LTC"
+
+// UserAccount returns the ledger account of a miner's balance
+func UserAccount(userID int64) string {
+	return "user:" + strconv.FormatInt(userID, 10)
+}
+
+// AccountTypeOf returns the type of a ledger account
+func AccountTypeOf(account string) AccountType {
+	switch {
+	case account == AccountPoolWallet:
+		return AccountTypeAsset
+	case account == AccountPayoutsInFlight, strings.HasPrefix(account, "user:"):
+		return AccountTypeLiability
+	default:
+		return AccountTypeEquity
+	}
+}
+
+// PostingKind identifies the source of a posting
+type PostingKind string
+
+const (
+	PostingBlockCredit    PostingKind = "block_credit"     // Matured block reward split into miner credits and pool fee
+	PostingBlockClawback  PostingKind = "block_clawback"   // Reversal of a block orphaned after crediting
+	PostingAuxBlockCredit PostingKind = "aux_block_credit" // Merged-mining block reward
+	PostingPayoutQueued   PostingKind = "payout_queued"    // Balance moved to an outgoing payout
+	PostingPayoutSent     PostingKind = "payout_sent"      // Payout left the wallet
+	PostingPayoutRefund   PostingKind = "payout_refund"    // Failed payout returned to the miner
+	PostingPayoutReversed PostingKind = "payout_reversed"  // Sent payout whose transaction never confirmed, returned to the miner
+	PostingNetworkFee     PostingKind = "network_fee"      // Transaction fee paid by the pool
+	PostingAdjustment     PostingKind = "admin_adjustment" // Manual correction by an administrator
+	PostingOpening        PostingKind = "opening_balance"  // Balance carried over from before the ledger
+)
+
+// Errors
+var (
+	ErrUnbalancedPosting = errors.New("posting lines do not sum to zero")
+	ErrInvalidPosting    = errors.New("invalid posting")
+	ErrDuplicatePosting  = errors.New("posting already recorded")
+	ErrPostingNotFound   = errors.New("posting not found")
+)
+
+// LedgerLine is one side of a posting
+type LedgerLine struct {
+	Account string `json:"account" db:"account"`
+	Amount  int64  `json:"amount" db:"amount"` // Positive debit, negative credit
+}
+
+// Posting is a balanced set of ledger lines with a reference to its source
+type Posting struct {
+	ID        int64        `json:"id" db:"id"`
+	Kind      PostingKind  `json:"kind" db:"kind"`
+	Currency  string       `json:"currency" db:"currency"`
+	Reference string       `json:"reference" db:"reference"` // Source, e.g. "block:42" or "payout:7"
+	Memo      string       `json:"memo" db:"memo"`
+	CreatedBy string       `json:"created_by" db:"created_by"` // Administrator for manual adjustments
+	Lines     []LedgerLine `json:"lines"`
+	CreatedAt time.Time    `json:"created_at" db:"created_at"`
+}
+
+// Validate checks that a posting is complete and balanced
+func (p *Posting) Validate() error {
+	if p.Kind == "" || p.Currency == "" || p.Reference == "" {
+		return fmt.Errorf("%w: kind, currency and reference are required", ErrInvalidPosting)
+	}
+	if len(p.Lines) < 2 {
+		return fmt.Errorf("%w: at least two lines are required", ErrInvalidPosting)
+	}
+	var sum int64
+	for _, line := range p.Lines {
+		if line.Account == "" || line.Amount == 0 {
+			return fmt.Errorf("%w: lines need an account and a non-zero amount", ErrInvalidPosting)
+		}
+		sum += line.Amount
+	}
+	if sum != 0 {
+		return fmt.Errorf("%w: off by %d", ErrUnbalancedPosting, sum)
+	}
+	return nil
+}
+
+// LedgerEntry is a posting line as seen from one account's history
+type LedgerEntry struct {
+	PostingID int64       `json:"posting_id" db:"posting_id"`
+	Kind      PostingKind `json:"kind" db:"kind"`
+	Reference string      `json:"reference" db:"reference"`
+	Memo      string      `json:"memo" db:"memo"`
+	Account   string      `json:"account" db:"account"`
+	Amount    int64       `json:"amount" db:"amount"`
+	CreatedAt time.Time   `json:"created_at" db:"created_at"`
+}
+
+// LedgerStore persists postings. Stores are append-only.
+type LedgerStore interface {
+	AppendPosting(ctx context.Context, posting *Posting) error
+	// AppendFundedPosting appends a posting only if account holds at least
+	// amount, checking and appending atomically across processes
+	AppendFundedPosting(ctx context.Context, posting *Posting, account string, amount int64) error
+	GetPosting(ctx context.Context, kind PostingKind, currency, reference string) (*Posting, error)
+	GetAccountSum(ctx context.Context, account, currency string) (int64, error)
+	GetAccountSums(ctx context.Context, currency string) (map[string]int64, error)
+	GetAccountEntries(ctx context.Context, account, currency string, limit, offset int) ([]LedgerEntry, error)
+	GetUnbalancedPostings(ctx context.Context) ([]int64, error)
+}
+
+// =============================================================================
+// LEDGER
+// =============================================================================
+
+// Ledger records balance movements as balanced postings
+type Ledger struct {
+	store    LedgerStore
+	currency string
+
+	// Serialises balance checks with the postings that depend on them in
+	// this process; AppendFundedPosting also guards them across processes
+	mu sync.Mutex
+}
+
+// NewLedger creates a ledger for the primary chain currency
+func NewLedger(store LedgerStore, currency string) *Ledger {
+	if store == nil {
+		return nil
+	}
+	if currency == "" {
+		currency = DefaultLedgerCurrency
+	}
+	return &Ledger{store: store, currency: currency}
+}
+
+// Post validates and appends a posting
+func (l *Ledger) Post(ctx context.Context, posting *Posting) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.postLocked(ctx, posting)
+}
+
+// UserBalance returns a miner's balance
+func (l *Ledger) UserBalance(ctx context.Context, userID int64) (int64, error) {
+	return l.AccountBalance(ctx, UserAccount(userID), l.currency)
+}
+
+// AccountBalance returns an account's balance on its normal side
+func (l *Ledger) AccountBalance(ctx context.Context, account, currency string) (int64, error) {
+	sum, err := l.store.GetAccountSum(ctx, account, currency)
+	if err != nil {
+		return 0, err
+	}
+	return normalBalance(account, sum), nil
+}
+
+// UserHistory returns the postings that moved a miner's balance, newest first
+func (l *Ledger) UserHistory(ctx context.Context, userID int64, limit, offset int) ([]LedgerEntry, error) {
+	return l.store.GetAccountEntries(ctx, UserAccount(userID), l.currency, limit, offset)
+}
+
+// CreditBlock credits a matured block's reward to miners, with the
+// remainder going to the pool as its fee
+func (l *Ledger) CreditBlock(ctx context.Context, block *Block, credits []Payout) error {
+	return l.Post(ctx, rewardPosting(PostingBlockCredit, l.currency, fmt.Sprintf("block:%d", block.ID),
+		fmt.Sprintf("block %d (%s)", block.Height, block.Hash), block.Reward, credits))
+}
+
+// BlockCredits returns the miner credits posted for a block, for callers
+// retrying after CreditBlock reported ErrDuplicatePosting
+func (l *Ledger) BlockCredits(ctx context.Context, block *Block) ([]Payout, error) {
+	posting, err := l.store.GetPosting(ctx, PostingBlockCredit, l.currency, fmt.Sprintf("block:%d", block.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	var credits []Payout
+	for _, line := range posting.Lines {
+		id, ok := strings.CutPrefix(line.Account, "user:")
+		if !ok {
+			continue
+		}
+		userID, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: bad account %q", ErrInvalidPosting, line.Account)
+		}
+		credits = append(credits, Payout{UserID: userID, BlockID: block.ID, Amount: -line.Amount, Timestamp: posting.CreatedAt})
+	}
+	return credits, nil
+}
+
+// CreditAuxBlock credits a merged-mining block's reward in the aux chain's currency
+func (l *Ledger) CreditAuxBlock(ctx context.Context, block *AuxBlock, credits []Payout) error {
+	return l.Post(ctx, rewardPosting(PostingAuxBlockCredit, block.ChainID, fmt.Sprintf("aux_block:%d", block.ID),
+		fmt.Sprintf("%s block %d (%s)", block.ChainID, block.Height, block.Hash), block.Reward, credits))
+}
+
+// ClawBackBlock reverses the credit of a block orphaned after crediting.
+// Miners' balances are reduced by what they still hold; amounts already paid
+// out are a loss charged to the pool and returned as the shortfall.
+func (l *Ledger) ClawBackBlock(ctx context.Context, block *Block, credits []Payout) (int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	posting := &Posting{
+		Kind:      PostingBlockClawback,
+		Currency:  l.currency,
+		Reference: fmt.Sprintf("block:%d", block.ID),
+		Memo:      fmt.Sprintf("block %d (%s) orphaned", block.Height, block.Hash),
+	}
+
+	var credited, shortfall int64
+	for _, credit := range credits {
+		credited += credit.Amount
+		sum, err := l.store.GetAccountSum(ctx, UserAccount(credit.UserID), l.currency)
+		if err != nil {
+			return 0, err
+		}
+		recoverable := credit.Amount
+		if balance := normalBalance(UserAccount(credit.UserID), sum); balance < recoverable {
+			recoverable = max(balance, 0)
+		}
+		if recoverable > 0 {
+			posting.Lines = append(posting.Lines, LedgerLine{Account: UserAccount(credit.UserID), Amount: recoverable})
+		}
+		shortfall += credit.Amount - recoverable
+	}
+
+	// The pool gives back its fee and absorbs what could not be recovered
+	if fee := block.Reward - credited; fee+shortfall != 0 {
+		posting.Lines = append(posting.Lines, LedgerLine{Account: AccountPoolFees, Amount: fee + shortfall})
+	}
+	posting.Lines = append(posting.Lines, LedgerLine{Account: AccountPoolWallet, Amount: -block.Reward})
+
+	if err := l.postLocked(ctx, posting); err != nil {
+		return 0, err
+	}
+	return shortfall, nil
+}
+
+// QueuePayout moves a miner's balance to an outgoing payout
+func (l *Ledger) QueuePayout(ctx context.Context, payout PendingPayout) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.postFundedLocked(ctx, UserAccount(payout.UserID), payout.Amount, &Posting{
+		Kind:      PostingPayoutQueued,
+		Currency:  l.currency,
+		Reference: payoutReference(payout),
+		Memo:      fmt.Sprintf("payout to %s", payout.Address),
+		Lines: []LedgerLine{
+			{Account: UserAccount(payout.UserID), Amount: payout.Amount},
+			{Account: AccountPayoutsInFlight, Amount: -payout.Amount},
+		},
+	})
+}
+
+// CompletePayout records a queued payout leaving the wallet
+func (l *Ledger) CompletePayout(ctx context.Context, payout PendingPayout, txHash string) error {
+	return l.Post(ctx, &Posting{
+		Kind:      PostingPayoutSent,
+		Currency:  l.currency,
+		Reference: payoutReference(payout),
+		Memo:      fmt.Sprintf("tx %s to %s", txHash, payout.Address),
+		Lines: []LedgerLine{
+			{Account: AccountPayoutsInFlight, Amount: payout.Amount},
+			{Account: AccountPoolWallet, Amount: -payout.Amount},
+		},
+	})
+}
+
+// RefundPayout returns a failed payout to the miner's balance
+func (l *Ledger) RefundPayout(ctx context.Context, payout PendingPayout, reason string) error {
+	return l.Post(ctx, &Posting{
+		Kind:      PostingPayoutRefund,
+		Currency:  l.currency,
+		Reference: payoutReference(payout),
+		Memo:      reason,
+		Lines: []LedgerLine{
+			{Account: AccountPayoutsInFlight, Amount: payout.Amount},
+			{Account: UserAccount(payout.UserID), Amount: -payout.Amount},
+		},
+	})
+}
+
+// ReversePayout returns a sent payout to the miner's balance after its
+// transaction was invalidated, putting the funds back in the wallet
+func (l *Ledger) ReversePayout(ctx context.Context, payout PendingPayout, reason string) error {
+	return l.Post(ctx, &Posting{
+		Kind:      PostingPayoutReversed,
+		Currency:  l.currency,
+		Reference: payoutReference(payout),
+		Memo:      reason,
+		Lines: []LedgerLine{
+			{Account: AccountPoolWallet, Amount: payout.Amount},
+			{Account: UserAccount(payout.UserID), Amount: -payout.Amount},
+		},
+	})
+}
+
+// RecordNetworkFee charges a transaction fee paid from the wallet to the pool
+func (l *Ledger) RecordNetworkFee(ctx context.Context, txHash string, fee int64) error {
+	return l.Post(ctx, &Posting{
+		Kind:      PostingNetworkFee,
+		Currency:  l.currency,
+		Reference: "tx:" + txHash,
+		Lines: []LedgerLine{
+			{Account: AccountPoolFees, Amount: fee},
+			{Account: AccountPoolWallet, Amount: -fee},
+		},
+	})
+}
+
+// Adjust credits (positive amount) or debits (negative amount) a miner's
+// balance against the pool's adjustment account. Every adjustment needs an
+// administrator, a reference (e.g. a support ticket) and a memo.
+func (l *Ledger) Adjust(ctx context.Context, userID, amount int64, admin, reference, memo string) error {
+	if admin == "" || memo == "" {
+		return fmt.Errorf("%w: adjustments need an administrator and a memo", ErrInvalidPosting)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	posting := &Posting{
+		Kind:      PostingAdjustment,
+		Currency:  l.currency,
+		Reference: reference,
+		Memo:      memo,
+		CreatedBy: admin,
+		Lines: []LedgerLine{
+			{Account: AccountPoolAdjustments, Amount: amount},
+			{Account: UserAccount(userID), Amount: -amount},
+		},
+	}
+	if amount < 0 {
+		return l.postFundedLocked(ctx, UserAccount(userID), -amount, posting)
+	}
+	return l.postLocked(ctx, posting)
+}
+
+func (l *Ledger) postLocked(ctx context.Context, posting *Posting) error {
+	if err := preparePosting(posting); err != nil {
+		return err
+	}
+	return l.store.AppendPosting(ctx, posting)
+}
+
+// postFundedLocked appends a posting that draws amount from account. The
+// store checks the balance, so other processes sharing it cannot overdraw.
+func (l *Ledger) postFundedLocked(ctx context.Context, account string, amount int64, posting *Posting) error {
+	if err := preparePosting(posting); err != nil {
+		return err
+	}
+	return l.store.AppendFundedPosting(ctx, posting, account, amount)
+}
+
+// preparePosting validates a posting and stamps its time
+func preparePosting(posting *Posting) error {
+	if err := posting.Validate(); err != nil {
+		return err
+	}
+	if posting.CreatedAt.IsZero() {
+		posting.CreatedAt = time.Now()
+	}
+	return nil
+}
+
+// rewardPosting splits a block reward between miner credits and the pool fee
+func rewardPosting(kind PostingKind, currency, reference, memo string, reward int64, credits []Payout) *Posting {
+	posting := &Posting{
+		Kind:      kind,
+		Currency:  currency,
+		Reference: reference,
+		Memo:      memo,
+		Lines:     []LedgerLine{{Account: AccountPoolWallet, Amount: reward}},
+	}
+	var credited int64
+	for _, credit := range credits {
+		if credit.Amount == 0 {
+			continue
+		}
+		posting.Lines = append(posting.Lines, LedgerLine{Account: UserAccount(credit.UserID), Amount: -credit.Amount})
+		credited += credit.Amount
+	}
+	if fee := reward - credited; fee != 0 {
+		posting.Lines = append(posting.Lines, LedgerLine{Account: AccountPoolFees, Amount: -fee})
+	}
+	return posting
+}
+
+// payoutReference ties a payout's postings together. Auto-payouts are keyed
+// by the block that triggered them, other payouts by their ID. Shares carried
+// forward from an auto-payout are stored without a block, so they settle
+// under their own ID and never collide with the original payout's postings.
+func payoutReference(payout PendingPayout) string {
+	if payout.BlockID != 0 {
+		return fmt.Sprintf("block:%d:user:%d", payout.BlockID, payout.UserID)
+	}
+	return fmt.Sprintf("payout:%d", payout.ID)
+}
+
+// normalBalance converts a signed line sum to the account's normal side
+func normalBalance(account string, sum int64) int64 {
+	if AccountTypeOf(account) == AccountTypeAsset {
+		return sum
+	}
+	return -sum
+}
+
+// =============================================================================
+// INVARIANT CHECKER
+// =============================================================================
+
+// LedgerReport is the result of an invariant check for one currency
+type LedgerReport struct {
+	Currency          string    `json:"currency"`
+	MinerBalances     int64     `json:"miner_balances"`     // Sum of all miner balances
+	PayoutsInFlight   int64     `json:"payouts_in_flight"`  // Queued payouts not yet sent
+	WalletFunds       int64     `json:"wallet_funds"`       // Pool wallet per the ledger
+	PoolFees          int64     `json:"pool_fees"`          // Fees, adjustments and losses
+	UnbalancedPosting []int64   `json:"unbalanced_posting"` // Postings whose lines do not sum to zero
+	NegativeAccounts  []string  `json:"negative_accounts"`  // Miner accounts below zero
+	OnChainBalance    *int64    `json:"on_chain_balance,omitempty"`
+	OnChainDifference int64     `json:"on_chain_difference"` // Wallet per node minus wallet per ledger
+	OK                bool      `json:"ok"`
+	CheckedAt         time.Time `json:"checked_at"`
+}
+
+// CheckInvariants proves that miner balances plus in-flight payouts equal
+// wallet funds minus pool fees, that every posting is balanced and that no
+// miner balance is negative. If wallet is not nil the ledger's wallet account
+// is also reconciled against the node's reported balance.
+func (l *Ledger) CheckInvariants(ctx context.Context, currency string, wallet WalletClient) (*LedgerReport, error) {
+	if currency == "" {
+		currency = l.currency
+	}
+
+	sums, err := l.store.GetAccountSums(ctx, currency)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account sums: %w", err)
+	}
+	unbalanced, err := l.store.GetUnbalancedPostings(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check postings: %w", err)
+	}
+
+	report := &LedgerReport{
+		Currency:          currency,
+		UnbalancedPosting: unbalanced,
+		CheckedAt:         time.Now(),
+	}
+	for account, sum := range sums {
+		balance := normalBalance(account, sum)
+		switch {
+		case account == AccountPoolWallet:
+			report.WalletFunds = balance
+		case account == AccountPayoutsInFlight:
+			report.PayoutsInFlight = balance
+		case AccountTypeOf(account) == AccountTypeLiability:
+			report.MinerBalances += balance
+			if balance < 0 {
+				report.NegativeAccounts = append(report.NegativeAccounts, account)
+			}
+		default:
+			report.PoolFees += balance
+		}
+	}
+	sort.Strings(report.NegativeAccounts)
+
+	report.OK = len(unbalanced) == 0 && len(report.NegativeAccounts) == 0 &&
+		report.MinerBalances+report.PayoutsInFlight == report.WalletFunds-report.PoolFees
+
+	if wallet != nil {
+		onChain, err := wallet.GetBalance(ctx)
+		if err != nil {
+			return report, fmt.Errorf("failed to get wallet balance: %w", err)
+		}
+		report.OnChainBalance = &onChain
+		report.OnChainDifference = onChain - report.WalletFunds
+	}
+
+	return report, nil
+}
+
+// =============================================================================
+// IN-MEMORY STORE
+// =============================================================================
+
+// MemoryLedgerStore keeps postings in memory, for tests and simulations
+type MemoryLedgerStore struct {
+	postings []Posting
+	seen     map[string]bool
+	mu       sync.RWMutex
+}
+
+// NewMemoryLedgerStore creates an empty in-memory ledger store
+func NewMemoryLedgerStore() *MemoryLedgerStore {
+	return &MemoryLedgerStore{seen: make(map[string]bool)}
+}
+
+// AppendPosting appends a posting, rejecting duplicates of the same source
+func (s *MemoryLedgerStore) AppendPosting(ctx context.Context, posting *Posting) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.appendLocked(posting)
+}
+
+// AppendFundedPosting appends a posting if account holds at least amount
+func (s *MemoryLedgerStore) AppendFundedPosting(ctx context.Context, posting *Posting, account string, amount int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var sum int64
+	for _, p := range s.postings {
+		if p.Currency != posting.Currency {
+			continue
+		}
+		for _, line := range p.Lines {
+			if line.Account == account {
+				sum += line.Amount
+			}
+		}
+	}
+	if normalBalance(account, sum) < amount {
+		return ErrInsufficientBalance
+	}
+	return s.appendLocked(posting)
+}
+
+func (s *MemoryLedgerStore) appendLocked(posting *Posting) error {
+	key := string(posting.Kind) + "|" + posting.Currency + "|" + posting.Reference
+	if s.seen[key] {
+		return fmt.Errorf("%w: %s %s", ErrDuplicatePosting, posting.Kind, posting.Reference)
+	}
+	s.seen[key] = true
+
+	posting.ID = int64(len(s.postings) + 1)
+	stored := *posting
+	stored.Lines = append([]LedgerLine(nil), posting.Lines...)
+	s.postings = append(s.postings, stored)
+	return nil
+}
+
+// GetPosting returns the posting of a kind for a source
+func (s *MemoryLedgerStore) GetPosting(ctx context.Context, kind PostingKind, currency, reference string) (*Posting, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, posting := range s.postings {
+		if posting.Kind == kind && posting.Currency == currency && posting.Reference == reference {
+			found := posting
+			found.Lines = append([]LedgerLine(nil), posting.Lines...)
+			return &found, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s %s", ErrPostingNotFound, kind, reference)
+}
+
+// GetAccountSum returns the signed sum of an account's lines
+func (s *MemoryLedgerStore) GetAccountSum(ctx context.Context, account, currency string) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var sum int64
+	for _, posting := range s.postings {
+		if posting.Currency != currency {
+			continue
+		}
+		for _, line := range posting.Lines {
+			if line.Account == account {
+				sum += line.Amount
+			}
+		}
+	}
+	return sum, nil
+}
+
+// GetAccountSums returns the signed sum of every account's lines
+func (s *MemoryLedgerStore) GetAccountSums(ctx context.Context, currency string) (map[string]int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sums := make(map[string]int64)
+	for _, posting := range s.postings {
+		if posting.Currency != currency {
+			continue
+		}
+		for _, line := range posting.Lines {
+			sums[line.Account] += line.Amount
+		}
+	}
+	return sums, nil
+}
+
+// GetAccountEntries returns an account's lines, newest first
+func (s *MemoryLedgerStore) GetAccountEntries(ctx context.Context, account, currency string, limit, offset int) ([]LedgerEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var entries []LedgerEntry
+	for i := len(s.postings) - 1; i >= 0; i-- {
+		posting := s.postings[i]
+		if posting.Currency != currency {
+			continue
+		}
+		for _, line := range posting.Lines {
+			if line.Account == account {
+				entries = append(entries, LedgerEntry{
+					PostingID: posting.ID,
+					Kind:      posting.Kind,
+					Reference: posting.Reference,
+					Memo:      posting.Memo,
+					Account:   line.Account,
+					Amount:    line.Amount,
+					CreatedAt: posting.CreatedAt,
+				})
+			}
+		}
+	}
+
+	if offset >= len(entries) {
+		return nil, nil
+	}
+	entries = entries[offset:]
+	if limit > 0 && limit < len(entries) {
+		entries = entries[:limit]
+	}
+	return entries, nil
+}
+
+// GetUnbalancedPostings returns the IDs of postings whose lines do not sum to zero
+func (s *MemoryLedgerStore) GetUnbalancedPostings(ctx context.Context) ([]int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var ids []int64
+	for _, posting := range s.postings {
+		var sum int64
+		for _, line := range posting.Lines {
+			sum += line.Amount
+		}
+		if sum != 0 {
+			ids = append(ids, posting.ID)
+		}
+	}
+	return ids, nil
+}
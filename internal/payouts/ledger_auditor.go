@@ -0,0 +1,199 @@
+package payouts
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// =============================================================================
+// LEDGER AUDITOR INTERFACES
+// =============================================================================
+
+// LedgerInvariantChecker checks the balance ledger's invariants (ISP)
+type LedgerInvariantChecker interface {
+	CheckInvariants(ctx context.Context, currency string, wallet WalletClient) (*LedgerReport, error)
+}
+
+// LedgerAlertNotifier is told when a ledger check fails (ISP)
+type LedgerAlertNotifier interface {
+	NotifyLedgerImbalance(ctx context.Context, report *LedgerReport, problems []string) error
+}
+
+// =============================================================================
+// LEDGER AUDITOR CONFIGURATION
+// =============================================================================
+
+// AuditorConfig controls how often the ledger is checked
+type AuditorConfig struct {
+	CheckInterval time.Duration `json:"check_interval" yaml:"check_interval"`
+	// MaxWalletShortfall is how far the node's wallet balance may fall below
+	// the ledger's pool wallet before the check fails. A surplus never fails
+	// it: funds from before the ledger, donations and dust are expected.
+	MaxWalletShortfall int64 `json:"max_wallet_shortfall" yaml:"max_wallet_shortfall"`
+}
+
+// DefaultAuditorConfig returns sensible defaults
+func DefaultAuditorConfig() AuditorConfig {
+	return AuditorConfig{
+		CheckInterval:      15 * time.Minute,
+		MaxWalletShortfall: 1000000, // 0.01 LTC
+	}
+}
+
+// =============================================================================
+// LEDGER AUDITOR IMPLEMENTATION
+// =============================================================================
+
+// LedgerAuditor checks the ledger's invariants on a schedule and alerts when
+// they break. An alert is sent when a check first fails, not on every
+// failing check; a passing check re-arms it.
+type LedgerAuditor struct {
+	ledger   LedgerInvariantChecker
+	wallet   WalletClient
+	notifier LedgerAlertNotifier
+	currency string
+	config   AuditorConfig
+
+	last    *LedgerReport
+	failing bool
+	mu      sync.RWMutex
+
+	// Lifecycle
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewLedgerAuditor creates a ledger auditor. wallet may be nil, in which case
+// the ledger is not compared with the node.
+func NewLedgerAuditor(ledger LedgerInvariantChecker, wallet WalletClient, currency string, config AuditorConfig) *LedgerAuditor {
+	if ledger == nil {
+		return nil
+	}
+
+	defaults := DefaultAuditorConfig()
+	if config.CheckInterval <= 0 {
+		config.CheckInterval = defaults.CheckInterval
+	}
+	if config.MaxWalletShortfall < 0 {
+		config.MaxWalletShortfall = 0
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &LedgerAuditor{
+		ledger:   ledger,
+		wallet:   wallet,
+		currency: currency,
+		config:   config,
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// SetNotifier sets the notifier told when a check fails
+func (a *LedgerAuditor) SetNotifier(notifier LedgerAlertNotifier) {
+	a.notifier = notifier
+}
+
+// Start begins periodic ledger checks
+func (a *LedgerAuditor) Start() {
+	a.wg.Add(1)
+	go a.checkLoop()
+}
+
+// Stop gracefully stops the auditor
+func (a *LedgerAuditor) Stop() {
+	a.cancel()
+	a.wg.Wait()
+}
+
+// checkLoop runs the main check loop
+func (a *LedgerAuditor) checkLoop() {
+	defer a.wg.Done()
+
+	ticker := time.NewTicker(a.config.CheckInterval)
+	defer ticker.Stop()
+
+	// Check immediately on start
+	_, _ = a.Check(a.ctx)
+
+	for {
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-ticker.C:
+			_, _ = a.Check(a.ctx)
+		}
+	}
+}
+
+// Check runs one invariant check and alerts if it newly fails. A check that
+// could not read the ledger neither alerts nor re-arms the alert; one that
+// could not reach the node still checks the ledger itself.
+func (a *LedgerAuditor) Check(ctx context.Context) (*LedgerReport, error) {
+	report, err := a.ledger.CheckInvariants(ctx, a.currency, a.wallet)
+	if report == nil {
+		log.Printf("⚠️ Ledger check failed to run: %v", err)
+		return nil, fmt.Errorf("failed to check ledger: %w", err)
+	}
+	if err != nil {
+		log.Printf("⚠️ Ledger not compared with the node: %v", err)
+		err = fmt.Errorf("failed to compare ledger with the node: %w", err)
+	}
+
+	problems := a.problems(report)
+
+	a.mu.Lock()
+	a.last = report
+	alert := len(problems) > 0 && !a.failing
+	recovered := len(problems) == 0 && a.failing
+	a.failing = len(problems) > 0
+	a.mu.Unlock()
+
+	switch {
+	case len(problems) > 0:
+		log.Printf("🚨 %s ledger check failed: %s", report.Currency, strings.Join(problems, "; "))
+	case recovered:
+		log.Printf("✅ %s ledger check passes again", report.Currency)
+	}
+
+	if alert && a.notifier != nil {
+		if err := a.notifier.NotifyLedgerImbalance(ctx, report, problems); err != nil {
+			log.Printf("⚠️ Failed to send ledger alert: %v", err)
+		}
+	}
+
+	return report, err
+}
+
+// LastReport returns the report of the last completed check, or nil
+func (a *LedgerAuditor) LastReport() *LedgerReport {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.last
+}
+
+// problems describes each way a report fails the check
+func (a *LedgerAuditor) problems(report *LedgerReport) []string {
+	var problems []string
+	if len(report.UnbalancedPosting) > 0 {
+		problems = append(problems, fmt.Sprintf("%d unbalanced postings (%v)", len(report.UnbalancedPosting), report.UnbalancedPosting))
+	}
+	if len(report.NegativeAccounts) > 0 {
+		problems = append(problems, fmt.Sprintf("negative miner balances: %s", strings.Join(report.NegativeAccounts, ", ")))
+	}
+	if report.MinerBalances+report.PayoutsInFlight != report.WalletFunds-report.PoolFees {
+		problems = append(problems, fmt.Sprintf("miner balances %d plus payouts in flight %d do not equal wallet funds %d minus pool fees %d",
+			report.MinerBalances, report.PayoutsInFlight, report.WalletFunds, report.PoolFees))
+	}
+	if report.OnChainBalance != nil && -report.OnChainDifference > a.config.MaxWalletShortfall {
+		problems = append(problems, fmt.Sprintf("node wallet holds %d, %d less than the ledger's %d",
+			*report.OnChainBalance, -report.OnChainDifference, report.WalletFunds))
+	}
+	return problems
+}
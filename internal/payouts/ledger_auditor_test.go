@@ -0,0 +1,132 @@
+package payouts
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// LEDGER AUDITOR MOCKS
+// =============================================================================
+
+// fakeInvariantChecker returns a settable report
+type fakeInvariantChecker struct {
+	report *LedgerReport
+	err    error
+}
+
+func (f *fakeInvariantChecker) CheckInvariants(ctx context.Context, currency string, wallet WalletClient) (*LedgerReport, error) {
+	return f.report, f.err
+}
+
+// recordingLedgerNotifier records ledger alerts
+type recordingLedgerNotifier struct {
+	alerts [][]string
+}
+
+func (n *recordingLedgerNotifier) NotifyLedgerImbalance(ctx context.Context, report *LedgerReport, problems []string) error {
+	n.alerts = append(n.alerts, problems)
+	return nil
+}
+
+// =============================================================================
+// LEDGER AUDITOR TESTS
+// =============================================================================
+
+func TestLedgerAuditor_Check(t *testing.T) {
+	ctx := context.Background()
+	healthy := &LedgerReport{Currency: "LTC", MinerBalances: 700, PayoutsInFlight: 100, WalletFunds: 1000, PoolFees: 200, OK: true}
+
+	t.Run("alerts once when a check starts failing", func(t *testing.T) {
+		checker := &fakeInvariantChecker{report: healthy}
+		notifier := &recordingLedgerNotifier{}
+		auditor := NewLedgerAuditor(checker, nil, "LTC", DefaultAuditorConfig())
+		auditor.SetNotifier(notifier)
+
+		_, err := auditor.Check(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, notifier.alerts)
+
+		checker.report = &LedgerReport{Currency: "LTC", MinerBalances: 900, WalletFunds: 1000, PoolFees: 200,
+			NegativeAccounts: []string{"user:4"}}
+		_, err = auditor.Check(ctx)
+		require.NoError(t, err)
+		_, err = auditor.Check(ctx)
+		require.NoError(t, err)
+		require.Len(t, notifier.alerts, 1, "not on every failing check")
+		assert.Len(t, notifier.alerts[0], 2)
+		assert.Contains(t, notifier.alerts[0][0], "user:4")
+		assert.Contains(t, notifier.alerts[0][1], "miner balances 900")
+
+		checker.report = healthy
+		_, err = auditor.Check(ctx)
+		require.NoError(t, err)
+		checker.report = &LedgerReport{Currency: "LTC", UnbalancedPosting: []int64{12}}
+		_, err = auditor.Check(ctx)
+		require.NoError(t, err)
+		assert.Len(t, notifier.alerts, 2, "re-armed by a passing check")
+		assert.Equal(t, checker.report, auditor.LastReport())
+	})
+
+	t.Run("alerts when the node holds less than the ledger", func(t *testing.T) {
+		onChain := int64(400)
+		report := *healthy
+		report.OnChainBalance = &onChain
+		report.OnChainDifference = onChain - report.WalletFunds
+		checker := &fakeInvariantChecker{report: &report}
+		notifier := &recordingLedgerNotifier{}
+		auditor := NewLedgerAuditor(checker, nil, "LTC", AuditorConfig{MaxWalletShortfall: 500})
+		auditor.SetNotifier(notifier)
+
+		_, err := auditor.Check(ctx)
+		require.NoError(t, err)
+		require.Len(t, notifier.alerts, 1)
+		assert.Contains(t, notifier.alerts[0][0], "600 less than the ledger")
+
+		surplus := int64(5000)
+		report.OnChainBalance = &surplus
+		report.OnChainDifference = surplus - report.WalletFunds
+		auditor = NewLedgerAuditor(checker, nil, "LTC", AuditorConfig{})
+		auditor.SetNotifier(notifier)
+		_, err = auditor.Check(ctx)
+		require.NoError(t, err)
+		assert.Len(t, notifier.alerts, 1, "a surplus is expected")
+	})
+
+	t.Run("checks the ledger when the node is unreachable", func(t *testing.T) {
+		checker := &fakeInvariantChecker{
+			report: &LedgerReport{Currency: "LTC", NegativeAccounts: []string{"user:4"}},
+			err:    errors.New("connection refused"),
+		}
+		notifier := &recordingLedgerNotifier{}
+		auditor := NewLedgerAuditor(checker, nil, "LTC", DefaultAuditorConfig())
+		auditor.SetNotifier(notifier)
+
+		report, err := auditor.Check(ctx)
+		assert.Error(t, err)
+		assert.NotNil(t, report)
+		assert.Len(t, notifier.alerts, 1)
+
+		checker.report = nil
+		_, err = auditor.Check(ctx)
+		assert.Error(t, err)
+		assert.NotNil(t, auditor.LastReport(), "keeps the last completed report")
+	})
+
+	t.Run("checks a real ledger", func(t *testing.T) {
+		ledger := NewMockBalanceTracker()
+		require.NoError(t, ledger.Adjust(ctx, 1, 5000, "admin:1", "ticket-1", "goodwill credit"))
+		notifier := &recordingLedgerNotifier{}
+		auditor := NewLedgerAuditor(ledger, nil, DefaultLedgerCurrency, DefaultAuditorConfig())
+		auditor.SetNotifier(notifier)
+
+		report, err := auditor.Check(ctx)
+		require.NoError(t, err)
+		assert.True(t, report.OK)
+		assert.Empty(t, notifier.alerts)
+	})
+}
@@ -0,0 +1,223 @@
+package payouts
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// =============================================================================
+// SQL LEDGER STORE IMPLEMENTATION
+// =============================================================================
+
+// SQLLedgerStore implements LedgerStore using PostgreSQL
+type SQLLedgerStore struct {
+	db *sql.DB
+}
+
+// NewSQLLedgerStore creates a new SQL-backed ledger store
+func NewSQLLedgerStore(db *sql.DB) *SQLLedgerStore {
+	return &SQLLedgerStore{db: db}
+}
+
+// AppendPosting writes a posting and its lines in one transaction. A second
+// posting of the same kind for the same source is rejected.
+func (s *SQLLedgerStore) AppendPosting(ctx context.Context, posting *Posting) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := appendPosting(ctx, tx, posting); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// AppendFundedPosting writes a posting if account holds at least amount. A
+// transaction-scoped advisory lock on the account serialises the check with
+// the write across every process sharing the database.
+func (s *SQLLedgerStore) AppendFundedPosting(ctx context.Context, posting *Posting, account string, amount int64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, posting.Currency+"|"+account); err != nil {
+		return fmt.Errorf("failed to lock account: %w", err)
+	}
+
+	var sum int64
+	err = tx.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(amount), 0) FROM ledger_entries WHERE account = $1 AND currency = $2
+	`, account, posting.Currency).Scan(&sum)
+	if err != nil {
+		return fmt.Errorf("failed to get account sum: %w", err)
+	}
+	if normalBalance(account, sum) < amount {
+		return ErrInsufficientBalance
+	}
+
+	if err := appendPosting(ctx, tx, posting); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// appendPosting inserts a posting and its lines within tx
+func appendPosting(ctx context.Context, tx *sql.Tx, posting *Posting) error {
+	err := tx.QueryRowContext(ctx, `
+		INSERT INTO ledger_postings (kind, currency, reference, memo, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (kind, currency, reference) DO NOTHING
+		RETURNING id
+	`, posting.Kind, posting.Currency, posting.Reference, posting.Memo, posting.CreatedBy, posting.CreatedAt,
+	).Scan(&posting.ID)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("%w: %s %s", ErrDuplicatePosting, posting.Kind, posting.Reference)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to insert posting: %w", err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO ledger_entries (posting_id, account, currency, amount, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, line := range posting.Lines {
+		if _, err := stmt.ExecContext(ctx, posting.ID, line.Account, posting.Currency, line.Amount, posting.CreatedAt); err != nil {
+			return fmt.Errorf("failed to insert ledger entry: %w", err)
+		}
+	}
+	return nil
+}
+
+// GetPosting returns the posting of a kind for a source with its lines
+func (s *SQLLedgerStore) GetPosting(ctx context.Context, kind PostingKind, currency, reference string) (*Posting, error) {
+	posting := &Posting{Kind: kind, Currency: currency, Reference: reference}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT id, memo, created_by, created_at
+		FROM ledger_postings
+		WHERE kind = $1 AND currency = $2 AND reference = $3
+	`, kind, currency, reference).Scan(&posting.ID, &posting.Memo, &posting.CreatedBy, &posting.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s %s", ErrPostingNotFound, kind, reference)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get posting: %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT account, amount FROM ledger_entries WHERE posting_id = $1 ORDER BY id
+	`, posting.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query posting lines: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var line LedgerLine
+		if err := rows.Scan(&line.Account, &line.Amount); err != nil {
+			return nil, fmt.Errorf("failed to scan posting line: %w", err)
+		}
+		posting.Lines = append(posting.Lines, line)
+	}
+	return posting, rows.Err()
+}
+
+// GetAccountSum returns the signed sum of an account's lines
+func (s *SQLLedgerStore) GetAccountSum(ctx context.Context, account, currency string) (int64, error) {
+	query := `SELECT COALESCE(SUM(amount), 0) FROM ledger_entries WHERE account = $1 AND currency = $2`
+
+	var sum int64
+	if err := s.db.QueryRowContext(ctx, query, account, currency).Scan(&sum); err != nil {
+		return 0, fmt.Errorf("failed to get account sum: %w", err)
+	}
+	return sum, nil
+}
+
+// GetAccountSums returns the signed sum of every account's lines
+func (s *SQLLedgerStore) GetAccountSums(ctx context.Context, currency string) (map[string]int64, error) {
+	query := `
+		SELECT account, SUM(amount)
+		FROM ledger_entries
+		WHERE currency = $1
+		GROUP BY account
+	`
+	rows, err := s.db.QueryContext(ctx, query, currency)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query account sums: %w", err)
+	}
+	defer rows.Close()
+
+	sums := make(map[string]int64)
+	for rows.Next() {
+		var account string
+		var sum int64
+		if err := rows.Scan(&account, &sum); err != nil {
+			return nil, fmt.Errorf("failed to scan account sum: %w", err)
+		}
+		sums[account] = sum
+	}
+	return sums, rows.Err()
+}
+
+// GetAccountEntries returns an account's lines, newest first
+func (s *SQLLedgerStore) GetAccountEntries(ctx context.Context, account, currency string, limit, offset int) ([]LedgerEntry, error) {
+	query := `
+		SELECT p.id, p.kind, p.reference, p.memo, e.account, e.amount, p.created_at
+		FROM ledger_entries e
+		JOIN ledger_postings p ON p.id = e.posting_id
+		WHERE e.account = $1 AND e.currency = $2
+		ORDER BY p.id DESC
+		LIMIT $3 OFFSET $4
+	`
+	rows, err := s.db.QueryContext(ctx, query, account, currency, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ledger entries: %w", err)
+	}
+	defer rows.Close()
+
+	entries := make([]LedgerEntry, 0)
+	for rows.Next() {
+		var e LedgerEntry
+		if err := rows.Scan(&e.PostingID, &e.Kind, &e.Reference, &e.Memo, &e.Account, &e.Amount, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan ledger entry: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// GetUnbalancedPostings returns the IDs of postings whose lines do not sum to zero
+func (s *SQLLedgerStore) GetUnbalancedPostings(ctx context.Context) ([]int64, error) {
+	query := `
+		SELECT posting_id
+		FROM ledger_entries
+		GROUP BY posting_id
+		HAVING SUM(amount) <> 0
+		ORDER BY posting_id
+	`
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query unbalanced postings: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan posting id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
@@ -0,0 +1,241 @@
+package payouts
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// LEDGER TESTS
+// =============================================================================
+
+func newTestLedger() (*Ledger, *MemoryLedgerStore) {
+	store := NewMemoryLedgerStore()
+	return NewLedger(store, DefaultLedgerCurrency), store
+}
+
+func testLedgerBlock() (*Block, []Payout) {
+	block := &Block{ID: 1, Height: 100, Hash: "aa", Reward: 1000, Status: BlockStatusConfirmed}
+	credits := []Payout{
+		{UserID: 1, BlockID: 1, Amount: 600},
+		{UserID: 2, BlockID: 1, Amount: 380},
+	}
+	return block, credits
+}
+
+func assertLedgerOK(t *testing.T, ledger *Ledger) *LedgerReport {
+	t.Helper()
+	report, err := ledger.CheckInvariants(context.Background(), "", nil)
+	require.NoError(t, err)
+	assert.True(t, report.OK, "ledger invariants broken: %+v", report)
+	return report
+}
+
+func TestPosting_Validate(t *testing.T) {
+	t.Run("rejects unbalanced lines", func(t *testing.T) {
+		posting := &Posting{Kind: PostingAdjustment, Currency: "LTC", Reference: "x", Lines: []LedgerLine{
+			{Account: AccountPoolAdjustments, Amount: 10},
+			{Account: UserAccount(1), Amount: -9},
+		}}
+		assert.ErrorIs(t, posting.Validate(), ErrUnbalancedPosting)
+	})
+
+	t.Run("rejects a single line", func(t *testing.T) {
+		posting := &Posting{Kind: PostingAdjustment, Currency: "LTC", Reference: "x", Lines: []LedgerLine{
+			{Account: UserAccount(1), Amount: 0},
+		}}
+		assert.ErrorIs(t, posting.Validate(), ErrInvalidPosting)
+	})
+}
+
+func TestLedger_CreditBlock(t *testing.T) {
+	ledger, _ := newTestLedger()
+	ctx := context.Background()
+	block, credits := testLedgerBlock()
+
+	require.NoError(t, ledger.CreditBlock(ctx, block, credits))
+
+	balance, err := ledger.UserBalance(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(600), balance)
+
+	fees, err := ledger.AccountBalance(ctx, AccountPoolFees, DefaultLedgerCurrency)
+	require.NoError(t, err)
+	assert.Equal(t, int64(20), fees)
+
+	report := assertLedgerOK(t, ledger)
+	assert.Equal(t, int64(1000), report.WalletFunds)
+	assert.Equal(t, int64(980), report.MinerBalances)
+
+	t.Run("rejects crediting the same block twice", func(t *testing.T) {
+		err := ledger.CreditBlock(ctx, block, credits)
+		assert.ErrorIs(t, err, ErrDuplicatePosting)
+
+		balance, _ := ledger.UserBalance(ctx, 1)
+		assert.Equal(t, int64(600), balance)
+	})
+
+	t.Run("returns the credits posted for a block", func(t *testing.T) {
+		posted, err := ledger.BlockCredits(ctx, block)
+		require.NoError(t, err)
+		require.Len(t, posted, len(credits))
+		for i := range credits {
+			assert.Equal(t, credits[i].UserID, posted[i].UserID)
+			assert.Equal(t, credits[i].Amount, posted[i].Amount)
+		}
+
+		_, err = ledger.BlockCredits(ctx, &Block{ID: block.ID + 1})
+		assert.ErrorIs(t, err, ErrPostingNotFound)
+	})
+}
+
+func TestLedger_PayoutLifecycle(t *testing.T) {
+	ledger, _ := newTestLedger()
+	ctx := context.Background()
+	block, credits := testLedgerBlock()
+	require.NoError(t, ledger.CreditBlock(ctx, block, credits))
+
+	payout := PendingPayout{ID: 5, UserID: 1, Amount: 600, Address: "ltc1qtest123"}
+
+	t.Run("rejects payouts above the balance", func(t *testing.T) {
+		over := payout
+		over.Amount = 601
+		assert.ErrorIs(t, ledger.QueuePayout(ctx, over), ErrInsufficientBalance)
+	})
+
+	t.Run("queued payouts leave the balance", func(t *testing.T) {
+		require.NoError(t, ledger.QueuePayout(ctx, payout))
+
+		balance, _ := ledger.UserBalance(ctx, 1)
+		assert.Zero(t, balance)
+		report := assertLedgerOK(t, ledger)
+		assert.Equal(t, int64(600), report.PayoutsInFlight)
+	})
+
+	t.Run("refunds return the balance", func(t *testing.T) {
+		require.NoError(t, ledger.RefundPayout(ctx, payout, "invalid address"))
+
+		balance, _ := ledger.UserBalance(ctx, 1)
+		assert.Equal(t, int64(600), balance)
+		assertLedgerOK(t, ledger)
+	})
+
+	t.Run("sent payouts leave the wallet", func(t *testing.T) {
+		retry := payout
+		retry.ID = 6
+		require.NoError(t, ledger.QueuePayout(ctx, retry))
+		require.NoError(t, ledger.CompletePayout(ctx, retry, "tx1"))
+
+		report := assertLedgerOK(t, ledger)
+		assert.Zero(t, report.PayoutsInFlight)
+		assert.Equal(t, int64(400), report.WalletFunds)
+
+		history, err := ledger.UserHistory(ctx, 1, 10, 0)
+		require.NoError(t, err)
+		require.Len(t, history, 4)
+		assert.Equal(t, PostingPayoutQueued, history[0].Kind)
+	})
+}
+
+func TestLedger_ClawBackBlock(t *testing.T) {
+	ledger, _ := newTestLedger()
+	ctx := context.Background()
+	block, credits := testLedgerBlock()
+	require.NoError(t, ledger.CreditBlock(ctx, block, credits))
+
+	// User 2 has already been paid
+	paid := PendingPayout{ID: 1, UserID: 2, Amount: 380}
+	require.NoError(t, ledger.QueuePayout(ctx, paid))
+	require.NoError(t, ledger.CompletePayout(ctx, paid, "tx1"))
+
+	shortfall, err := ledger.ClawBackBlock(ctx, block, credits)
+	require.NoError(t, err)
+	assert.Equal(t, int64(380), shortfall)
+
+	balance, _ := ledger.UserBalance(ctx, 1)
+	assert.Zero(t, balance)
+	balance, _ = ledger.UserBalance(ctx, 2)
+	assert.Zero(t, balance)
+
+	// The loss is charged to the pool; no miner goes negative
+	report := assertLedgerOK(t, ledger)
+	assert.Empty(t, report.NegativeAccounts)
+	assert.Equal(t, int64(-380), report.WalletFunds)
+	assert.Equal(t, int64(-380), report.PoolFees)
+}
+
+func TestLedger_Adjust(t *testing.T) {
+	ledger, _ := newTestLedger()
+	ctx := context.Background()
+
+	t.Run("requires an administrator and a memo", func(t *testing.T) {
+		err := ledger.Adjust(ctx, 1, 100, "", "ticket-1", "goodwill")
+		assert.ErrorIs(t, err, ErrInvalidPosting)
+	})
+
+	t.Run("credits and debits the user", func(t *testing.T) {
+		require.NoError(t, ledger.Adjust(ctx, 1, 100, "admin", "ticket-1", "goodwill"))
+		require.NoError(t, ledger.Adjust(ctx, 1, -40, "admin", "ticket-2", "correction"))
+
+		balance, _ := ledger.UserBalance(ctx, 1)
+		assert.Equal(t, int64(60), balance)
+		assertLedgerOK(t, ledger)
+	})
+
+	t.Run("cannot take a user below zero", func(t *testing.T) {
+		err := ledger.Adjust(ctx, 1, -61, "admin", "ticket-3", "correction")
+		assert.ErrorIs(t, err, ErrInsufficientBalance)
+	})
+}
+
+func TestLedger_CreditAuxBlock(t *testing.T) {
+	ledger, _ := newTestLedger()
+	ctx := context.Background()
+
+	block := &AuxBlock{ID: 3, ChainID: "DOGE", Height: 10, Hash: "bb", Reward: 500}
+	require.NoError(t, ledger.CreditAuxBlock(ctx, block, []Payout{{UserID: 1, Amount: 490}}))
+
+	balance, err := ledger.AccountBalance(ctx, UserAccount(1), "DOGE")
+	require.NoError(t, err)
+	assert.Equal(t, int64(490), balance)
+
+	ltc, _ := ledger.UserBalance(ctx, 1)
+	assert.Zero(t, ltc, "aux rewards are kept in their own currency")
+
+	report, err := ledger.CheckInvariants(ctx, "DOGE", nil)
+	require.NoError(t, err)
+	assert.True(t, report.OK)
+}
+
+func TestLedger_CheckInvariants(t *testing.T) {
+	t.Run("reports unbalanced postings", func(t *testing.T) {
+		ledger, store := newTestLedger()
+		ctx := context.Background()
+
+		// Bypass validation as a corrupted store would
+		require.NoError(t, store.AppendPosting(ctx, &Posting{
+			Kind: PostingAdjustment, Currency: DefaultLedgerCurrency, Reference: "bad",
+			Lines: []LedgerLine{{Account: UserAccount(1), Amount: -10}},
+		}))
+
+		report, err := ledger.CheckInvariants(ctx, "", nil)
+		require.NoError(t, err)
+		assert.False(t, report.OK)
+		assert.Len(t, report.UnbalancedPosting, 1)
+	})
+
+	t.Run("compares against the node wallet", func(t *testing.T) {
+		ledger, _ := newTestLedger()
+		ctx := context.Background()
+		block, credits := testLedgerBlock()
+		require.NoError(t, ledger.CreditBlock(ctx, block, credits))
+
+		report, err := ledger.CheckInvariants(ctx, "", NewMockWalletClient())
+		require.NoError(t, err)
+		require.NotNil(t, report.OnChainBalance)
+		assert.Equal(t, int64(100000000000-1000), report.OnChainDifference)
+	})
+}
@@ -49,79 +49,45 @@ func NewPayoutManager(
 
 // initializeCalculators creates all payout calculators based on config
 func (pm *PayoutManager) initializeCalculators() error {
-	// PPLNS
-	if pm.config.EnablePPLNS {
-		calc, err := NewPPLNSCalculator(pm.config.PPLNSWindowSize, pm.config.FeePPLNS)
+	for _, mode := range pm.config.GetEnabledModes() {
+		calc, err := newPayoutCalculator(mode, pm.config)
 		if err != nil {
-			return fmt.Errorf("failed to create PPLNS calculator: %w", err)
+			return err
 		}
-		pm.calculators[PayoutModePPLNS] = calc
-	}
-
-	// PPS
-	if pm.config.EnablePPS {
-		calc, err := NewPPSCalculator(pm.config.FeePPS)
-		if err != nil {
-			return fmt.Errorf("failed to create PPS calculator: %w", err)
-		}
-		pm.calculators[PayoutModePPS] = calc
-	}
-
-	// PPS+
-	if pm.config.EnablePPSPlus {
-		calc, err := NewPPSPlusCalculator(pm.config.FeePPSPlus, pm.config.PPLNSWindowSize)
-		if err != nil {
-			return fmt.Errorf("failed to create PPS+ calculator: %w", err)
-		}
-		pm.calculators[PayoutModePPSPlus] = calc
-	}
-
-	// FPPS
-	if pm.config.EnableFPPS {
-		calc, err := NewFPPSCalculator(pm.config.FeeFPPS)
-		if err != nil {
-			return fmt.Errorf("failed to create FPPS calculator: %w", err)
-		}
-		pm.calculators[PayoutModeFPPS] = calc
-	}
-
-	// SCORE
-	if pm.config.EnableSCORE {
-		calc, err := NewSCORECalculator(
-			pm.config.PPLNSWindowSize,
-			pm.config.FeeSCORE,
-			pm.config.SCOREDecayFactor,
-		)
-		if err != nil {
-			return fmt.Errorf("failed to create SCORE calculator: %w", err)
-		}
-		pm.calculators[PayoutModeSCORE] = calc
+		pm.calculators[mode] = calc
 	}
+	return nil
+}
 
-	// SOLO
-	if pm.config.EnableSOLO {
-		calc, err := NewSOLOCalculator(pm.config.FeeSOLO)
-		if err != nil {
-			return fmt.Errorf("failed to create SOLO calculator: %w", err)
-		}
-		pm.calculators[PayoutModeSOLO] = calc
+// newPayoutCalculator creates the calculator for a payout mode, whether or
+// not the mode is enabled
+func newPayoutCalculator(mode PayoutMode, config *PayoutConfig) (PayoutCalculator, error) {
+	var calc PayoutCalculator
+	var err error
+
+	switch mode {
+	case PayoutModePPLNS:
+		calc, err = NewPPLNSCalculator(config.PPLNSWindowSize, config.FeePPLNS)
+	case PayoutModePPS:
+		calc, err = NewPPSCalculator(config.FeePPS)
+	case PayoutModePPSPlus:
+		calc, err = NewPPSPlusCalculator(config.FeePPSPlus, config.PPLNSWindowSize)
+	case PayoutModeFPPS:
+		calc, err = NewFPPSCalculator(config.FeeFPPS)
+	case PayoutModeSCORE:
+		calc, err = NewSCORECalculator(config.PPLNSWindowSize, config.FeeSCORE, config.SCOREDecayFactor)
+	case PayoutModeSOLO:
+		calc, err = NewSOLOCalculator(config.FeeSOLO)
+	case PayoutModeSLICE:
+		// V2 Job Declaration enhanced PPLNS with sliced windows
+		calc, err = NewSLICECalculator(config.SLICEWindowSize, config.SLICESliceDuration, config.SLICEDecayFactor, config.FeeSLICE)
+	default:
+		return nil, fmt.Errorf("unknown payout mode %q", mode)
 	}
-
-	// SLICE - V2 Job Declaration enhanced PPLNS with sliced windows
-	if pm.config.EnableSLICE {
-		calc, err := NewSLICECalculator(
-			pm.config.SLICEWindowSize,
-			pm.config.SLICESliceDuration,
-			pm.config.SLICEDecayFactor,
-			pm.config.FeeSLICE,
-		)
-		if err != nil {
-			return fmt.Errorf("failed to create SLICE calculator: %w", err)
-		}
-		pm.calculators[PayoutModeSLICE] = calc
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s calculator: %w", mode, err)
 	}
-
-	return nil
+	return calc, nil
 }
 
 // GetCalculator returns the calculator for a specific payout mode
@@ -49,9 +49,15 @@ type MergedMiningManager struct {
 	payoutManager *PayoutManager
 	auxBlockRepo  AuxBlockRepository
 	auxPayoutRepo AuxPayoutRepository
+	ledger        AuxBlockCreditor
 	mu            sync.RWMutex
 }
 
+// AuxBlockCreditor credits auxiliary block rewards to miner balances
+type AuxBlockCreditor interface {
+	CreditAuxBlock(ctx context.Context, block *AuxBlock, credits []Payout) error
+}
+
 // AuxBlockRepository handles database operations for auxiliary blocks
 type AuxBlockRepository interface {
 	CreateAuxBlock(ctx context.Context, block *AuxBlock) error
@@ -301,9 +307,20 @@ func (m *MergedMiningManager) ProcessAuxBlockPayouts(ctx context.Context, auxBlo
 		return fmt.Errorf("failed to create aux payouts: %w", err)
 	}
 
+	if m.ledger != nil {
+		if err := m.ledger.CreditAuxBlock(ctx, auxBlock, payouts); err != nil {
+			return fmt.Errorf("failed to credit aux block: %w", err)
+		}
+	}
+
 	return nil
 }
 
+// SetLedger sets the ledger that aux block rewards are credited to
+func (m *MergedMiningManager) SetLedger(ledger AuxBlockCreditor) {
+	m.ledger = ledger
+}
+
 // =============================================================================
 // SQL IMPLEMENTATIONS (Placeholders)
 // =============================================================================
@@ -42,5 +42,29 @@ func (a *NotificationAdapter) NotifyPayoutFailed(ctx context.Context, userID int
 	return err
 }
 
-// Ensure NotificationAdapter implements PayoutNotifier
-var _ PayoutNotifier = (*NotificationAdapter)(nil)
+// NotifyPayoutConfirmed sends a notification when a payout transaction is final
+func (a *NotificationAdapter) NotifyPayoutConfirmed(ctx context.Context, userID int64, amount int64, address, txHash string, confirmations int64) error {
+	if a.service == nil {
+		return nil
+	}
+
+	alert := notifications.NewPayoutConfirmedAlert(userID, amount, address, txHash, confirmations)
+	_, err := a.service.SendAlert(ctx, alert)
+	return err
+}
+
+// NotifyLedgerImbalance alerts the pool's operators that a ledger check failed
+func (a *NotificationAdapter) NotifyLedgerImbalance(ctx context.Context, report *LedgerReport, problems []string) error {
+	if a.service == nil {
+		return nil
+	}
+
+	a.service.SendPoolAlert(ctx, notifications.NewLedgerImbalanceAlert(report.Currency, problems))
+	return nil
+}
+
+// Ensure NotificationAdapter implements PayoutNotifier and LedgerAlertNotifier
+var (
+	_ PayoutNotifier      = (*NotificationAdapter)(nil)
+	_ LedgerAlertNotifier = (*NotificationAdapter)(nil)
+)
@@ -21,13 +21,14 @@ func NewSQLPayoutRepository(db *sql.DB) *SQLPayoutRepository {
 	return &SQLPayoutRepository{db: db}
 }
 
-// GetPendingPayouts retrieves pending payouts up to the specified limit
+// GetPendingPayouts retrieves pending payouts not yet in a batch, up to the
+// specified limit
 func (r *SQLPayoutRepository) GetPendingPayouts(ctx context.Context, limit int) ([]PendingPayout, error) {
 	query := `
 		SELECT id, user_id, amount, address, status, payout_mode, block_id, 
-		       created_at, processed_at, tx_hash, error_message
+		       created_at, processed_at, tx_hash, error_message, approved_at
 		FROM pending_payouts
-		WHERE status = $1
+		WHERE status = $1 AND batch_id IS NULL
 		ORDER BY created_at ASC
 		LIMIT $2
 	`
@@ -41,14 +42,14 @@ func (r *SQLPayoutRepository) GetPendingPayouts(ctx context.Context, limit int)
 	payouts := make([]PendingPayout, 0)
 	for rows.Next() {
 		var p PendingPayout
-		var processedAt sql.NullTime
+		var processedAt, approvedAt sql.NullTime
 		var txHash, errorMsg sql.NullString
 		var payoutMode string
 
 		err := rows.Scan(
 			&p.ID, &p.UserID, &p.Amount, &p.Address, &p.Status,
 			&payoutMode, &p.BlockID, &p.CreatedAt, &processedAt,
-			&txHash, &errorMsg,
+			&txHash, &errorMsg, &approvedAt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan payout row: %w", err)
@@ -58,6 +59,9 @@ func (r *SQLPayoutRepository) GetPendingPayouts(ctx context.Context, limit int)
 		if processedAt.Valid {
 			p.ProcessedAt = &processedAt.Time
 		}
+		if approvedAt.Valid {
+			p.ApprovedAt = &approvedAt.Time
+		}
 		if txHash.Valid {
 			p.TxHash = txHash.String
 		}
@@ -121,22 +125,6 @@ func (r *SQLPayoutRepository) MarkPayoutFailed(ctx context.Context, payoutID int
 	return nil
 }
 
-// ReturnToBalance returns amount to user's balance after a failed payout
-func (r *SQLPayoutRepository) ReturnToBalance(ctx context.Context, userID int64, amount int64) error {
-	query := `
-		UPDATE user_balances
-		SET balance = balance + $1, updated_at = $2
-		WHERE user_id = $3
-	`
-
-	_, err := r.db.ExecContext(ctx, query, amount, time.Now(), userID)
-	if err != nil {
-		return fmt.Errorf("failed to return balance: %w", err)
-	}
-
-	return nil
-}
-
 // CreatePendingPayout creates a new pending payout record
 func (r *SQLPayoutRepository) CreatePendingPayout(ctx context.Context, payout PendingPayout) (int64, error) {
 	query := `
@@ -159,15 +147,13 @@ func (r *SQLPayoutRepository) CreatePendingPayout(ctx context.Context, payout Pe
 	return id, nil
 }
 
-// GetUserBalance retrieves a user's current balance
+// GetUserBalance retrieves a user's current balance from the ledger
 func (r *SQLPayoutRepository) GetUserBalance(ctx context.Context, userID int64) (int64, error) {
-	query := `SELECT COALESCE(balance, 0) FROM user_balances WHERE user_id = $1`
+	// Liability accounts carry credits as negative amounts
+	query := `SELECT COALESCE(-SUM(amount), 0) FROM ledger_entries WHERE account = $1 AND currency = $2`
 
 	var balance int64
-	err := r.db.QueryRowContext(ctx, query, userID).Scan(&balance)
-	if err == sql.ErrNoRows {
-		return 0, nil
-	}
+	err := r.db.QueryRowContext(ctx, query, UserAccount(userID), DefaultLedgerCurrency).Scan(&balance)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get balance: %w", err)
 	}
@@ -175,49 +161,6 @@ func (r *SQLPayoutRepository) GetUserBalance(ctx context.Context, userID int64)
 	return balance, nil
 }
 
-// DeductFromBalance deducts amount from user's balance
-func (r *SQLPayoutRepository) DeductFromBalance(ctx context.Context, userID int64, amount int64) error {
-	// First check balance
-	balance, err := r.GetUserBalance(ctx, userID)
-	if err != nil {
-		return err
-	}
-
-	if balance < amount {
-		return ErrInsufficientBalance
-	}
-
-	query := `
-		UPDATE user_balances
-		SET balance = balance - $1, updated_at = $2
-		WHERE user_id = $3
-	`
-
-	_, err = r.db.ExecContext(ctx, query, amount, time.Now(), userID)
-	if err != nil {
-		return fmt.Errorf("failed to deduct balance: %w", err)
-	}
-
-	return nil
-}
-
-// AddToBalance adds amount to user's balance
-func (r *SQLPayoutRepository) AddToBalance(ctx context.Context, userID int64, amount int64) error {
-	query := `
-		INSERT INTO user_balances (user_id, balance, created_at, updated_at)
-		VALUES ($1, $2, $3, $3)
-		ON CONFLICT (user_id) DO UPDATE
-		SET balance = user_balances.balance + $2, updated_at = $3
-	`
-
-	_, err := r.db.ExecContext(ctx, query, userID, amount, time.Now())
-	if err != nil {
-		return fmt.Errorf("failed to add to balance: %w", err)
-	}
-
-	return nil
-}
-
 // GetPayoutHistory retrieves payout history for a user
 func (r *SQLPayoutRepository) GetPayoutHistory(ctx context.Context, userID int64, limit, offset int) ([]PendingPayout, error) {
 	query := `
@@ -268,6 +211,68 @@ func (r *SQLPayoutRepository) GetPayoutHistory(ctx context.Context, userID int64
 	return payouts, rows.Err()
 }
 
+// GetUnsettledPayouts returns payouts sent or failed since the ledger was
+// started whose settlement is missing from it: sent payouts without a
+// payout_sent posting, and failed payouts without a payout_refund posting
+// that were never sent (sent ones are reversed instead). The references
+// match payoutReference.
+func (r *SQLPayoutRepository) GetUnsettledPayouts(ctx context.Context, limit int) ([]PendingPayout, error) {
+	query := `
+		SELECT p.id, p.user_id, p.amount, p.address, p.status, p.payout_mode, COALESCE(p.block_id, 0),
+		       p.created_at, p.processed_at, p.tx_hash, p.error_message
+		FROM pending_payouts p
+		CROSS JOIN LATERAL (
+			SELECT CASE WHEN COALESCE(p.block_id, 0) <> 0
+			            THEN 'block:' || p.block_id || ':user:' || p.user_id
+			            ELSE 'payout:' || p.id END AS reference
+		) ref
+		WHERE p.processed_at >= (SELECT MIN(created_at) FROM ledger_postings)
+		  AND ((p.status = $1 AND COALESCE(p.tx_hash, '') <> '' AND NOT EXISTS (
+		          SELECT 1 FROM ledger_postings lp
+		          WHERE lp.kind = $3 AND lp.reference = ref.reference))
+		    OR (p.status = $2 AND NOT EXISTS (
+		          SELECT 1 FROM ledger_postings lp
+		          WHERE lp.kind IN ($3, $4) AND lp.reference = ref.reference)))
+		ORDER BY p.processed_at ASC
+		LIMIT $5
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, PayoutStatusProcessed, PayoutStatusFailed,
+		PostingPayoutSent, PostingPayoutRefund, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query unsettled payouts: %w", err)
+	}
+	defer rows.Close()
+
+	payouts := make([]PendingPayout, 0)
+	for rows.Next() {
+		var p PendingPayout
+		var processedAt sql.NullTime
+		var txHash, errorMsg sql.NullString
+		var payoutMode string
+
+		err := rows.Scan(
+			&p.ID, &p.UserID, &p.Amount, &p.Address, &p.Status,
+			&payoutMode, &p.BlockID, &p.CreatedAt, &processedAt,
+			&txHash, &errorMsg,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan payout row: %w", err)
+		}
+
+		p.PayoutMode = PayoutMode(payoutMode)
+		if processedAt.Valid {
+			p.ProcessedAt = &processedAt.Time
+		}
+		p.TxHash = txHash.String
+		p.ErrorMessage = errorMsg.String
+
+		payouts = append(payouts, p)
+	}
+
+	return payouts, rows.Err()
+}
+
 // GetPayoutStats retrieves payout statistics
 func (r *SQLPayoutRepository) GetPayoutStats(ctx context.Context) (*PayoutRepoStats, error) {
 	query := `
@@ -298,3 +303,6 @@ type PayoutRepoStats struct {
 	FailedCount    int64 `json:"failed_count"`
 	TotalPaid      int64 `json:"total_paid"`
 }
+
+// Ensure SQLPayoutRepository finds unsettled payouts for the processor
+var _ UnsettledPayoutRepository = (*SQLPayoutRepository)(nil)
@@ -134,20 +134,6 @@ func TestSQLPayoutRepository_MarkPayoutFailed(t *testing.T) {
 	})
 }
 
-func TestSQLPayoutRepository_ReturnToBalance(t *testing.T) {
-	t.Run("returns amount to user balance", func(t *testing.T) {
-		mockDB := NewMockDB()
-		repo := &testablePayoutRepository{db: mockDB}
-
-		mockDB.balances[1] = 5000000 // Initial balance
-
-		err := repo.ReturnToBalance(context.Background(), 1, 1000000)
-		require.NoError(t, err)
-
-		assert.Equal(t, int64(6000000), mockDB.balances[1])
-	})
-}
-
 func TestSQLPayoutRepository_CreatePendingPayout(t *testing.T) {
 	t.Run("creates new pending payout", func(t *testing.T) {
 		mockDB := NewMockDB()
@@ -189,29 +175,6 @@ func TestSQLPayoutRepository_GetUserBalance(t *testing.T) {
 	})
 }
 
-func TestSQLPayoutRepository_DeductFromBalance(t *testing.T) {
-	t.Run("deducts from user balance", func(t *testing.T) {
-		mockDB := NewMockDB()
-		repo := &testablePayoutRepository{db: mockDB}
-
-		mockDB.balances[1] = 5000000
-
-		err := repo.DeductFromBalance(context.Background(), 1, 1000000)
-		require.NoError(t, err)
-		assert.Equal(t, int64(4000000), mockDB.balances[1])
-	})
-
-	t.Run("returns error for insufficient balance", func(t *testing.T) {
-		mockDB := NewMockDB()
-		repo := &testablePayoutRepository{db: mockDB}
-
-		mockDB.balances[1] = 500000
-
-		err := repo.DeductFromBalance(context.Background(), 1, 1000000)
-		assert.Error(t, err)
-	})
-}
-
 // =============================================================================
 // TESTABLE REPOSITORY WITH MOCK DB
 // =============================================================================
@@ -258,11 +221,6 @@ func (r *testablePayoutRepository) MarkPayoutFailed(ctx context.Context, payoutI
 	return sql.ErrNoRows
 }
 
-func (r *testablePayoutRepository) ReturnToBalance(ctx context.Context, userID int64, amount int64) error {
-	r.db.balances[userID] += amount
-	return nil
-}
-
 func (r *testablePayoutRepository) CreatePendingPayout(ctx context.Context, payout PendingPayout) (int64, error) {
 	payout.ID = r.db.nextID
 	r.db.nextID++
@@ -275,11 +233,3 @@ func (r *testablePayoutRepository) CreatePendingPayout(ctx context.Context, payo
 func (r *testablePayoutRepository) GetUserBalance(ctx context.Context, userID int64) (int64, error) {
 	return r.db.balances[userID], nil
 }
-
-func (r *testablePayoutRepository) DeductFromBalance(ctx context.Context, userID int64, amount int64) error {
-	if r.db.balances[userID] < amount {
-		return ErrInsufficientBalance
-	}
-	r.db.balances[userID] -= amount
-	return nil
-}
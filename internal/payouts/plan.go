@@ -0,0 +1,214 @@
+package payouts
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// =============================================================================
+// PAYOUT PLANS
+// =============================================================================
+
+// PlannedTransaction is one transaction a processing run would send
+type PlannedTransaction struct {
+	Outputs  map[string]int64 `json:"outputs"`
+	Amount   int64            `json:"amount"`
+	Payouts  []PendingPayout  `json:"payouts"`
+	Deferred bool             `json:"deferred"` // Waiting for the fee rate to drop
+}
+
+// PayoutPlan is what the next processing run would do with the queue
+type PayoutPlan struct {
+	GeneratedAt    time.Time            `json:"generated_at"`
+	Batched        bool                 `json:"batched"`
+	FeeRate        int64                `json:"fee_rate,omitempty"` // Estimated litoshis per kB, when batched
+	Transactions   []PlannedTransaction `json:"transactions"`
+	Held           []PayoutApproval     `json:"held"`
+	BelowMinimum   []PendingPayout      `json:"below_minimum"`
+	InvalidAddress []PendingPayout      `json:"invalid_address"`
+	TotalAmount    int64                `json:"total_amount"` // Sent now, excluding deferred transactions
+}
+
+// SetPolicy holds payouts the policy flags for admin approval instead of
+// sending them
+func (p *PayoutProcessor) SetPolicy(policy PayoutPolicy) {
+	p.policy = policy
+}
+
+// Policy returns the policy set with SetPolicy, if any
+func (p *PayoutProcessor) Policy() PayoutPolicy {
+	return p.policy
+}
+
+// PlanPayouts works out what the next processing run would send, hold and
+// skip, without changing anything
+func (p *PayoutProcessor) PlanPayouts(ctx context.Context) (*PayoutPlan, error) {
+	batching := p.batching && p.batchWallet != nil && p.batchRepo != nil
+	config := p.config.Batching.withDefaults()
+
+	limit := p.config.BatchSize
+	if batching {
+		limit = config.MaxOutputs * config.MaxBatchesPerRun
+	}
+	payouts, err := p.repo.GetPendingPayouts(ctx, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending payouts: %w", err)
+	}
+
+	plan := &PayoutPlan{
+		GeneratedAt:    p.now(),
+		Batched:        batching,
+		Transactions:   make([]PlannedTransaction, 0),
+		Held:           make([]PayoutApproval, 0),
+		BelowMinimum:   make([]PendingPayout, 0),
+		InvalidAddress: make([]PendingPayout, 0),
+	}
+
+	due := make([]PendingPayout, 0, len(payouts))
+	for _, payout := range payouts {
+		if p.config.MinPayoutAmount > 0 && payout.Amount < p.config.MinPayoutAmount {
+			// Left for later accumulation
+			plan.BelowMinimum = append(plan.BelowMinimum, payout)
+			continue
+		}
+		due = append(due, payout)
+	}
+
+	if p.splitter != nil && len(due) > 0 {
+		split, deferred, err := p.splitter.SplitPayouts(ctx, due)
+		if err != nil {
+			return nil, fmt.Errorf("failed to split payouts: %w", err)
+		}
+		due = split
+		plan.BelowMinimum = append(plan.BelowMinimum, deferred...)
+	}
+
+	valid := due[:0]
+	for _, payout := range due {
+		if !p.validAddresses(payout) {
+			plan.InvalidAddress = append(plan.InvalidAddress, payout)
+			continue
+		}
+		valid = append(valid, payout)
+	}
+	due = valid
+
+	if p.policy != nil && len(due) > 0 {
+		cleared, held, err := p.policy.Evaluate(ctx, due)
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate payout policy: %w", err)
+		}
+		due = cleared
+		plan.Held = append(plan.Held, held...)
+	}
+	if len(due) == 0 {
+		return plan, nil
+	}
+
+	if !batching {
+		for _, payout := range due {
+			plan.addTransaction([]PendingPayout{payout}, false)
+		}
+		return plan, nil
+	}
+
+	feeRate, feeErr := p.batchWallet.EstimateFee(ctx, config.ConfirmTarget)
+	plan.FeeRate = feeRate
+	for _, batch := range config.planBatches(due) {
+		plan.addTransaction(batch, !config.shouldSend(batch, feeRate, feeErr, plan.GeneratedAt))
+	}
+
+	return plan, nil
+}
+
+// addTransaction adds a transaction paying the payouts to the plan
+func (plan *PayoutPlan) addTransaction(payouts []PendingPayout, deferred bool) {
+	outputs, total := batchOutputs(payouts)
+	plan.Transactions = append(plan.Transactions, PlannedTransaction{
+		Outputs:  outputs,
+		Amount:   total,
+		Payouts:  payouts,
+		Deferred: deferred,
+	})
+	if !deferred {
+		plan.TotalAmount += total
+	}
+}
+
+// executePlan fails invalid payouts, holds flagged ones and sends the rest
+func (p *PayoutProcessor) executePlan(ctx context.Context, plan *PayoutPlan) error {
+	for _, payout := range plan.InvalidAddress {
+		p.handleFailedPayout(ctx, payout, "invalid address")
+	}
+
+	for i := range plan.Held {
+		if err := p.policy.Hold(ctx, &plan.Held[i]); err != nil {
+			log.Printf("⚠️ Failed to hold payout %d for approval: %v", plan.Held[i].Payout.ID, err)
+			continue
+		}
+		p.mu.Lock()
+		p.stats.PayoutsHeld++
+		p.mu.Unlock()
+	}
+
+	config := p.config.Batching.withDefaults()
+	for _, tx := range plan.Transactions {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if tx.Deferred {
+			continue
+		}
+		if p.splitter != nil {
+			// Recorded first so every output can be traced to its split rule
+			if err := p.splitter.RecordAllocations(ctx, tx.Payouts); err != nil {
+				log.Printf("⚠️ Payouts not sent, failed to record their allocations: %v", err)
+				continue
+			}
+		}
+		if plan.Batched {
+			p.sendBatch(ctx, config, tx.Payouts, plan.FeeRate)
+			continue
+		}
+		for _, payout := range tx.Payouts {
+			p.processSinglePayout(ctx, payout)
+		}
+	}
+
+	return nil
+}
+
+// validAddresses reports whether every address a payout pays is valid
+func (p *PayoutProcessor) validAddresses(payout PendingPayout) bool {
+	for _, address := range payoutAddresses(payout) {
+		if !p.wallet.ValidateAddress(address) {
+			return false
+		}
+	}
+	return true
+}
+
+// logPlan records a dry run's plan
+func (p *PayoutProcessor) logPlan(plan *PayoutPlan) {
+	p.mu.Lock()
+	p.lastPlan = plan
+	p.mu.Unlock()
+
+	sending := 0
+	for _, tx := range plan.Transactions {
+		if !tx.Deferred {
+			sending++
+		}
+	}
+	log.Printf("🧪 Payout dry run: would send %d of %d transactions totalling %d, hold %d payouts, skip %d below minimum and fail %d invalid",
+		sending, len(plan.Transactions), plan.TotalAmount, len(plan.Held), len(plan.BelowMinimum), len(plan.InvalidAddress))
+}
+
+// LastPlan returns the plan from the latest dry run, or nil
+func (p *PayoutProcessor) LastPlan() *PayoutPlan {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.lastPlan
+}
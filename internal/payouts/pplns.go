@@ -18,14 +18,15 @@ type Share struct {
 
 // Block represents a found block for payout processing
 type Block struct {
-	ID         int64     `json:"id" db:"id"`
-	Height     int64     `json:"height" db:"height"`
-	Hash       string    `json:"hash" db:"hash"`
-	Reward     int64     `json:"reward" db:"reward"`
-	Difficulty float64   `json:"difficulty" db:"difficulty"`
-	FinderID   int64     `json:"finder_id" db:"finder_id"`
-	Status     string    `json:"status" db:"status"`
-	Timestamp  time.Time `json:"timestamp" db:"timestamp"`
+	ID            int64     `json:"id" db:"id"`
+	Height        int64     `json:"height" db:"height"`
+	Hash          string    `json:"hash" db:"hash"`
+	Reward        int64     `json:"reward" db:"reward"`
+	Difficulty    float64   `json:"difficulty" db:"difficulty"`
+	FinderID      int64     `json:"finder_id" db:"finder_id"`
+	Status        string    `json:"status" db:"status"` // pending, immature, confirmed, orphaned
+	Confirmations int64     `json:"confirmations" db:"confirmations"`
+	Timestamp     time.Time `json:"timestamp" db:"timestamp"`
 }
 
 // Payout represents a calculated payout for a user
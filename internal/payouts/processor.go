@@ -2,7 +2,10 @@ package payouts
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -19,12 +22,30 @@ type WalletClient interface {
 	ValidateAddress(address string) bool
 }
 
+// MultiOutputWallet pays several addresses in one transaction (ISP)
+type MultiOutputWallet interface {
+	SendMany(ctx context.Context, outputs map[string]int64, opts SendManyOptions) (txHash string, err error)
+}
+
 // PayoutRepository manages payout persistence
 type PayoutRepository interface {
 	GetPendingPayouts(ctx context.Context, limit int) ([]PendingPayout, error)
 	MarkPayoutComplete(ctx context.Context, payoutID int64, txHash string) error
 	MarkPayoutFailed(ctx context.Context, payoutID int64, errorMsg string) error
-	ReturnToBalance(ctx context.Context, userID int64, amount int64) error
+}
+
+// PayoutLedger settles queued payouts against the balance ledger
+type PayoutLedger interface {
+	CompletePayout(ctx context.Context, payout PendingPayout, txHash string) error
+	RefundPayout(ctx context.Context, payout PendingPayout, reason string) error
+	RecordNetworkFee(ctx context.Context, txHash string, fee int64) error
+}
+
+// UnsettledPayoutRepository finds sent and failed payouts whose ledger
+// settlement is missing, so a settlement that failed is retried from the
+// database on a later run, even after a restart (ISP)
+type UnsettledPayoutRepository interface {
+	GetUnsettledPayouts(ctx context.Context, limit int) ([]PendingPayout, error)
 }
 
 // PayoutNotifier sends payout notifications (ISP)
@@ -43,6 +64,8 @@ type ProcessorConfig struct {
 	ProcessInterval time.Duration `json:"process_interval" yaml:"process_interval"`
 	MaxRetries      int           `json:"max_retries" yaml:"max_retries"`
 	MinPayoutAmount int64         `json:"min_payout_amount" yaml:"min_payout_amount"`
+	Batching        BatchConfig   `json:"batching" yaml:"batching"`
+	DryRun          bool          `json:"dry_run" yaml:"dry_run"` // Plan each run without sending anything
 }
 
 // DefaultProcessorConfig returns sensible defaults
@@ -52,6 +75,7 @@ func DefaultProcessorConfig() ProcessorConfig {
 		ProcessInterval: time.Minute,
 		MaxRetries:      3,
 		MinPayoutAmount: 100000, // 0.001 LTC
+		Batching:        DefaultBatchConfig(),
 	}
 }
 
@@ -60,6 +84,8 @@ type ProcessorStats struct {
 	PayoutsProcessed int64     `json:"payouts_processed"`
 	PayoutsFailed    int64     `json:"payouts_failed"`
 	TotalAmountSent  int64     `json:"total_amount_sent"`
+	BatchesSent      int64     `json:"batches_sent"`
+	PayoutsHeld      int64     `json:"payouts_held"`
 	LastProcessedAt  time.Time `json:"last_processed_at"`
 	IsRunning        bool      `json:"is_running"`
 }
@@ -73,7 +99,22 @@ type PayoutProcessor struct {
 	wallet   WalletClient
 	repo     PayoutRepository
 	notifier PayoutNotifier
+	ledger   PayoutLedger
 	config   ProcessorConfig
+	now      func() time.Time
+
+	// Transactions signed and recorded before they are broadcast, grouped
+	// into batches when batching is enabled
+	batchWallet BatchWalletClient
+	batchRepo   PayoutBatchRepository
+	batching    bool
+
+	// Approval policy and the latest dry run
+	policy   PayoutPolicy
+	lastPlan *PayoutPlan
+
+	// Multi-wallet splits, when enabled
+	splitter PayoutSplitter
 
 	// Stats
 	stats ProcessorStats
@@ -85,9 +126,10 @@ type PayoutProcessor struct {
 	wg     sync.WaitGroup
 }
 
-// NewPayoutProcessor creates a new payout processor
-func NewPayoutProcessor(wallet WalletClient, repo PayoutRepository, config ProcessorConfig) *PayoutProcessor {
-	if wallet == nil || repo == nil {
+// NewPayoutProcessor creates a new payout processor. Payouts are queued in
+// the ledger when created, so every processor settles against one.
+func NewPayoutProcessor(wallet WalletClient, repo PayoutRepository, ledger PayoutLedger, config ProcessorConfig) *PayoutProcessor {
+	if wallet == nil || repo == nil || ledger == nil {
 		return nil
 	}
 
@@ -106,7 +148,9 @@ func NewPayoutProcessor(wallet WalletClient, repo PayoutRepository, config Proce
 	return &PayoutProcessor{
 		wallet: wallet,
 		repo:   repo,
+		ledger: ledger,
 		config: config,
+		now:    time.Now,
 		ctx:    ctx,
 		cancel: cancel,
 	}
@@ -152,28 +196,31 @@ func (p *PayoutProcessor) processLoop() {
 	}
 }
 
-// ProcessPendingPayouts processes a batch of pending payouts
+// ProcessPendingPayouts processes a batch of pending payouts. In dry-run
+// mode the run is only planned and logged.
 func (p *PayoutProcessor) ProcessPendingPayouts(ctx context.Context) error {
-	// Get pending payouts
-	payouts, err := p.repo.GetPendingPayouts(ctx, p.config.BatchSize)
-	if err != nil {
-		return fmt.Errorf("failed to get pending payouts: %w", err)
+	if p.config.DryRun {
+		plan, err := p.PlanPayouts(ctx)
+		if err != nil {
+			return err
+		}
+		p.logPlan(plan)
+		return nil
 	}
 
-	if len(payouts) == 0 {
-		return nil
+	p.retrySettlements(ctx)
+
+	if p.batchWallet != nil && p.batchRepo != nil {
+		p.reconcileBatches(ctx, p.config.Batching.withDefaults())
+		p.retryNetworkFees(ctx)
 	}
 
-	for _, payout := range payouts {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-			p.processSinglePayout(ctx, payout)
-		}
+	plan, err := p.PlanPayouts(ctx)
+	if err != nil {
+		return err
 	}
 
-	return nil
+	return p.executePlan(ctx, plan)
 }
 
 // processSinglePayout handles a single payout
@@ -184,14 +231,21 @@ func (p *PayoutProcessor) processSinglePayout(ctx context.Context, payout Pendin
 		return
 	}
 
-	// Validate address
-	if !p.wallet.ValidateAddress(payout.Address) {
+	// Validate addresses
+	if !p.validAddresses(payout) {
 		p.handleFailedPayout(ctx, payout, "invalid address")
 		return
 	}
 
+	// Sent as a batch of one, so a send interrupted after the broadcast is
+	// reconciled by its stored hash instead of paid again
+	if p.batchWallet != nil && p.batchRepo != nil {
+		p.sendBatch(ctx, p.config.Batching.withDefaults(), []PendingPayout{payout}, 0)
+		return
+	}
+
 	// Attempt to send transaction
-	txHash, err := p.wallet.SendTransaction(ctx, payout.Address, payout.Amount)
+	txHash, err := p.sendPayout(ctx, payout)
 	if err != nil {
 		p.handleFailedPayout(ctx, payout, err.Error())
 		return
@@ -203,9 +257,38 @@ func (p *PayoutProcessor) processSinglePayout(ctx context.Context, payout Pendin
 		return
 	}
 
+	p.settlePayout(ctx, payout, txHash)
+}
+
+// sendPayout pays a payout's address, or each of its allocations in one
+// transaction when it is split across wallets
+func (p *PayoutProcessor) sendPayout(ctx context.Context, payout PendingPayout) (string, error) {
+	outputs := payoutOutputs(payout)
+	if len(outputs) == 1 {
+		for address, amount := range outputs {
+			return p.wallet.SendTransaction(ctx, address, amount)
+		}
+	}
+
+	wallet, ok := p.wallet.(MultiOutputWallet)
+	if !ok {
+		return "", fmt.Errorf("wallet cannot pay %d addresses in one transaction", len(outputs))
+	}
+	return wallet.SendMany(ctx, outputs, SendManyOptions{
+		Comment:       payoutReference(payout),
+		ConfirmTarget: p.config.Batching.withDefaults().ConfirmTarget,
+		Replaceable:   true,
+	})
+}
+
+// settlePayout settles a sent payout against the ledger and notifies the miner
+func (p *PayoutProcessor) settlePayout(ctx context.Context, payout PendingPayout, txHash string) {
+	// Move the funds out of the in-flight account
+	p.settleLedger(ctx, ledgerSettlement{payout: payout, txHash: txHash})
+
 	// Send notification
 	if p.notifier != nil {
-		_ = p.notifier.NotifyPayoutSent(ctx, payout.UserID, payout.Amount, payout.Address, txHash)
+		_ = p.notifier.NotifyPayoutSent(ctx, payout.UserID, payout.Amount, strings.Join(payoutAddresses(payout), ", "), txHash)
 	}
 
 	// Update stats
@@ -218,11 +301,15 @@ func (p *PayoutProcessor) processSinglePayout(ctx context.Context, payout Pendin
 
 // handleFailedPayout handles a failed payout
 func (p *PayoutProcessor) handleFailedPayout(ctx context.Context, payout PendingPayout, errorMsg string) {
-	// Mark payout as failed
-	_ = p.repo.MarkPayoutFailed(ctx, payout.ID, errorMsg)
+	// Mark payout as failed. If that fails the payout is still pending and
+	// is retried, so its funds must stay in flight.
+	if err := p.repo.MarkPayoutFailed(ctx, payout.ID, errorMsg); err != nil {
+		log.Printf("❌ Failed to mark payout %d failed: %v", payout.ID, err)
+		return
+	}
 
 	// Return funds to user balance
-	_ = p.repo.ReturnToBalance(ctx, payout.UserID, payout.Amount)
+	p.settleLedger(ctx, ledgerSettlement{payout: payout, reason: errorMsg})
 
 	// Send failure notification
 	if p.notifier != nil {
@@ -238,6 +325,56 @@ func (p *PayoutProcessor) SetNotifier(notifier PayoutNotifier) {
 	p.notifier = notifier
 }
 
+// ledgerSettlement is the ledger side of a sent (txHash set) or failed payout
+type ledgerSettlement struct {
+	payout PendingPayout
+	txHash string
+	reason string
+}
+
+// settleLedger posts a settlement. Postings are unique per payout, so a
+// settlement that was recorded before is done. One that fails is found again
+// by retrySettlements, as the payout's status is already final.
+func (p *PayoutProcessor) settleLedger(ctx context.Context, s ledgerSettlement) {
+	var err error
+	if s.txHash != "" {
+		err = p.ledger.CompletePayout(ctx, s.payout, s.txHash)
+	} else {
+		err = p.ledger.RefundPayout(ctx, s.payout, s.reason)
+	}
+	if err == nil || errors.Is(err, ErrDuplicatePosting) {
+		return
+	}
+	log.Printf("⚠️ Failed to settle payout %d in the ledger, will retry: %v", s.payout.ID, err)
+}
+
+// retrySettlements posts the settlements of sent and failed payouts that are
+// missing from the ledger
+func (p *PayoutProcessor) retrySettlements(ctx context.Context) {
+	repo, ok := p.repo.(UnsettledPayoutRepository)
+	if !ok {
+		return
+	}
+	unsettled, err := repo.GetUnsettledPayouts(ctx, p.config.BatchSize)
+	if err != nil {
+		log.Printf("⚠️ Failed to load unsettled payouts: %v", err)
+		return
+	}
+
+	for _, payout := range unsettled {
+		if payout.Status == PayoutStatusProcessed {
+			p.settleLedger(ctx, ledgerSettlement{payout: payout, txHash: payout.TxHash})
+			continue
+		}
+		p.settleLedger(ctx, ledgerSettlement{payout: payout, reason: payout.ErrorMessage})
+	}
+}
+
+// Config returns the processor's configuration
+func (p *PayoutProcessor) Config() ProcessorConfig {
+	return p.config
+}
+
 // GetStats returns current processor statistics
 func (p *PayoutProcessor) GetStats() ProcessorStats {
 	p.mu.RLock()
@@ -150,10 +150,6 @@ func (m *MockPayoutRepository) MarkPayoutFailed(ctx context.Context, payoutID in
 	return errors.New("payout not found")
 }
 
-func (m *MockPayoutRepository) ReturnToBalance(ctx context.Context, userID int64, amount int64) error {
-	return nil
-}
-
 func (m *MockPayoutRepository) AddPendingPayout(payout PendingPayout) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -184,7 +180,7 @@ func TestPayoutProcessor_Creation(t *testing.T) {
 		wallet := NewMockWalletClient()
 		repo := NewMockPayoutRepository()
 
-		processor := NewPayoutProcessor(wallet, repo, ProcessorConfig{
+		processor := NewPayoutProcessor(wallet, repo, NewMockBalanceTracker(), ProcessorConfig{
 			BatchSize:       10,
 			ProcessInterval: time.Second,
 			MaxRetries:      3,
@@ -194,7 +190,7 @@ func TestPayoutProcessor_Creation(t *testing.T) {
 	})
 
 	t.Run("returns nil with nil dependencies", func(t *testing.T) {
-		processor := NewPayoutProcessor(nil, nil, ProcessorConfig{})
+		processor := NewPayoutProcessor(nil, nil, nil, ProcessorConfig{})
 		assert.Nil(t, processor)
 	})
 }
@@ -204,7 +200,7 @@ func TestPayoutProcessor_ProcessSinglePayout(t *testing.T) {
 		wallet := NewMockWalletClient()
 		repo := NewMockPayoutRepository()
 
-		processor := NewPayoutProcessor(wallet, repo, ProcessorConfig{
+		processor := NewPayoutProcessor(wallet, repo, NewMockBalanceTracker(), ProcessorConfig{
 			BatchSize:       10,
 			ProcessInterval: time.Second,
 			MaxRetries:      3,
@@ -234,7 +230,7 @@ func TestPayoutProcessor_ProcessSinglePayout(t *testing.T) {
 		wallet := NewMockWalletClient()
 		repo := NewMockPayoutRepository()
 
-		processor := NewPayoutProcessor(wallet, repo, ProcessorConfig{
+		processor := NewPayoutProcessor(wallet, repo, NewMockBalanceTracker(), ProcessorConfig{
 			BatchSize:       10,
 			ProcessInterval: time.Second,
 			MaxRetries:      1, // Only 1 retry for quick test
@@ -263,7 +259,7 @@ func TestPayoutProcessor_ProcessSinglePayout(t *testing.T) {
 		wallet := NewMockWalletClient()
 		repo := NewMockPayoutRepository()
 
-		processor := NewPayoutProcessor(wallet, repo, ProcessorConfig{
+		processor := NewPayoutProcessor(wallet, repo, NewMockBalanceTracker(), ProcessorConfig{
 			BatchSize:       10,
 			ProcessInterval: time.Second,
 			MaxRetries:      3,
@@ -286,6 +282,114 @@ func TestPayoutProcessor_ProcessSinglePayout(t *testing.T) {
 		// Verify payout marked failed
 		assert.Equal(t, 1, repo.GetFailedCount())
 	})
+
+	t.Run("settles payouts against the ledger", func(t *testing.T) {
+		ctx := context.Background()
+		wallet := NewMockWalletClient()
+		repo := NewMockPayoutRepository()
+		ledger := NewMockBalanceTracker()
+		require.NoError(t, ledger.Adjust(ctx, 1, 2000000, "admin", "opening", "opening balance"))
+
+		processor := NewPayoutProcessor(wallet, repo, ledger, ProcessorConfig{
+			BatchSize:       10,
+			ProcessInterval: time.Second,
+			MaxRetries:      1,
+		})
+
+		for _, address := range []string{"ltc1qtest123456789", "invalid"} {
+			repo.AddPendingPayout(PendingPayout{UserID: 1, Amount: 1000000, Address: address})
+		}
+		for _, p := range repo.pendingPayouts {
+			require.NoError(t, ledger.QueuePayout(ctx, p))
+		}
+		assert.Zero(t, ledger.GetBalance(1))
+
+		require.NoError(t, processor.ProcessPendingPayouts(ctx))
+
+		// The failed payout is refunded; the sent one has left the wallet
+		assert.Equal(t, int64(1000000), ledger.GetBalance(1))
+		report, err := ledger.CheckInvariants(ctx, "", nil)
+		require.NoError(t, err)
+		assert.True(t, report.OK)
+		assert.Zero(t, report.PayoutsInFlight)
+	})
+
+	t.Run("retries ledger settlements that fail", func(t *testing.T) {
+		ctx := context.Background()
+		wallet := NewMockWalletClient()
+		ledger := &failingPayoutLedger{MockBalanceTracker: NewMockBalanceTracker(), err: errors.New("connection reset")}
+		repo := &unsettledPayoutRepository{MockPayoutRepository: NewMockPayoutRepository(), ledger: ledger.Ledger}
+		require.NoError(t, ledger.Adjust(ctx, 1, 2000000, "admin", "opening", "opening balance"))
+
+		processor := NewPayoutProcessor(wallet, repo, ledger, DefaultProcessorConfig())
+		repo.AddPendingPayout(PendingPayout{UserID: 1, Amount: 1000000, Address: "ltc1qtest123456789"})
+		repo.AddPendingPayout(PendingPayout{UserID: 1, Amount: 1000000, Address: "invalid"})
+		for _, payout := range repo.pendingPayouts {
+			require.NoError(t, ledger.QueuePayout(ctx, payout))
+		}
+
+		require.NoError(t, processor.ProcessPendingPayouts(ctx))
+		require.Len(t, wallet.GetTransactions(), 1)
+		report, err := ledger.CheckInvariants(ctx, "", nil)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2000000), report.PayoutsInFlight, "not settled yet")
+
+		// A restarted processor finds the settlements from the payouts' state
+		ledger.err = nil
+		processor = NewPayoutProcessor(wallet, repo, ledger, DefaultProcessorConfig())
+		require.NoError(t, processor.ProcessPendingPayouts(ctx))
+		assert.Len(t, wallet.GetTransactions(), 1, "not sent again")
+		report, err = ledger.CheckInvariants(ctx, "", nil)
+		require.NoError(t, err)
+		assert.Zero(t, report.PayoutsInFlight)
+		assert.Equal(t, int64(1000000), ledger.GetBalance(1), "the failed payout is refunded")
+	})
+}
+
+// unsettledPayoutRepository finds sent and failed payouts missing from the
+// ledger, like the SQL repository
+type unsettledPayoutRepository struct {
+	*MockPayoutRepository
+	ledger *Ledger
+}
+
+func (m *unsettledPayoutRepository) GetUnsettledPayouts(ctx context.Context, limit int) ([]PendingPayout, error) {
+	m.mu.RLock()
+	settled := append(append([]PendingPayout{}, m.completedPayouts...), m.failedPayouts...)
+	m.mu.RUnlock()
+
+	unsettled := make([]PendingPayout, 0)
+	for _, payout := range settled {
+		kind := PostingPayoutSent
+		if payout.Status == PayoutStatusFailed {
+			kind = PostingPayoutRefund
+		}
+		_, err := m.ledger.store.GetPosting(ctx, kind, m.ledger.currency, payoutReference(payout))
+		if errors.Is(err, ErrPostingNotFound) && len(unsettled) < limit {
+			unsettled = append(unsettled, payout)
+		}
+	}
+	return unsettled, nil
+}
+
+// failingPayoutLedger fails settlements while err is set
+type failingPayoutLedger struct {
+	*MockBalanceTracker
+	err error
+}
+
+func (l *failingPayoutLedger) CompletePayout(ctx context.Context, payout PendingPayout, txHash string) error {
+	if l.err != nil {
+		return l.err
+	}
+	return l.MockBalanceTracker.CompletePayout(ctx, payout, txHash)
+}
+
+func (l *failingPayoutLedger) RefundPayout(ctx context.Context, payout PendingPayout, reason string) error {
+	if l.err != nil {
+		return l.err
+	}
+	return l.MockBalanceTracker.RefundPayout(ctx, payout, reason)
 }
 
 func TestPayoutProcessor_BatchProcessing(t *testing.T) {
@@ -293,7 +397,7 @@ func TestPayoutProcessor_BatchProcessing(t *testing.T) {
 		wallet := NewMockWalletClient()
 		repo := NewMockPayoutRepository()
 
-		processor := NewPayoutProcessor(wallet, repo, ProcessorConfig{
+		processor := NewPayoutProcessor(wallet, repo, NewMockBalanceTracker(), ProcessorConfig{
 			BatchSize:       5,
 			ProcessInterval: time.Second,
 			MaxRetries:      3,
@@ -323,7 +427,7 @@ func TestPayoutProcessor_BatchProcessing(t *testing.T) {
 		wallet := NewMockWalletClient()
 		repo := NewMockPayoutRepository()
 
-		processor := NewPayoutProcessor(wallet, repo, ProcessorConfig{
+		processor := NewPayoutProcessor(wallet, repo, NewMockBalanceTracker(), ProcessorConfig{
 			BatchSize:       2, // Only process 2 at a time
 			ProcessInterval: time.Second,
 			MaxRetries:      3,
@@ -360,7 +464,7 @@ func TestPayoutProcessor_AutoProcessing(t *testing.T) {
 		wallet := NewMockWalletClient()
 		repo := NewMockPayoutRepository()
 
-		processor := NewPayoutProcessor(wallet, repo, ProcessorConfig{
+		processor := NewPayoutProcessor(wallet, repo, NewMockBalanceTracker(), ProcessorConfig{
 			BatchSize:       10,
 			ProcessInterval: 50 * time.Millisecond,
 			MaxRetries:      3,
@@ -391,7 +495,7 @@ func TestPayoutProcessor_GetStats(t *testing.T) {
 		wallet := NewMockWalletClient()
 		repo := NewMockPayoutRepository()
 
-		processor := NewPayoutProcessor(wallet, repo, ProcessorConfig{
+		processor := NewPayoutProcessor(wallet, repo, NewMockBalanceTracker(), ProcessorConfig{
 			BatchSize:       10,
 			ProcessInterval: time.Second,
 			MaxRetries:      3,
@@ -422,7 +526,7 @@ func TestPayoutProcessor_MinimumPayout(t *testing.T) {
 		wallet := NewMockWalletClient()
 		repo := NewMockPayoutRepository()
 
-		processor := NewPayoutProcessor(wallet, repo, ProcessorConfig{
+		processor := NewPayoutProcessor(wallet, repo, NewMockBalanceTracker(), ProcessorConfig{
 			BatchSize:       10,
 			ProcessInterval: time.Second,
 			MaxRetries:      3,
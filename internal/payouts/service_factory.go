@@ -7,6 +7,8 @@ import (
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/chimera-pool/chimera-pool-core/internal/config"
 )
 
 // =============================================================================
@@ -21,6 +23,21 @@ type PayoutServiceConfig struct {
 	// Processor configuration
 	Processor ProcessorConfig
 
+	// Block maturity configuration
+	Unlocker UnlockerConfig
+
+	// Payout transaction confirmation configuration
+	Watcher WatcherConfig
+
+	// Limits above which payouts wait for admin approval
+	Approval ApprovalConfig
+
+	// Multi-wallet split configuration
+	Splits SplitConfig
+
+	// Scheduled ledger invariant checks
+	Auditor AuditorConfig
+
 	// Payout mode configuration
 	Payouts *PayoutConfig
 
@@ -41,16 +58,62 @@ func DefaultPayoutServiceConfig() PayoutServiceConfig {
 			ProcessInterval: time.Minute,
 			MaxRetries:      3,
 			MinPayoutAmount: 1000000, // 0.01 LTC
+			Batching:        batchingEnabled(DefaultBatchConfig()),
 		},
+		Unlocker:         DefaultUnlockerConfig(),
+		Watcher:          DefaultWatcherConfig(),
+		Approval:         DefaultApprovalConfig(),
+		Splits:           DefaultSplitConfig(),
+		Auditor:          DefaultAuditorConfig(),
 		Payouts:          DefaultPayoutConfig(),
 		MetricsNamespace: "chimera_pool",
 	}
 }
 
+// LoadPayoutServiceConfig returns the defaults overridden by the LTC_*
+// environment variables. Every process that sends or plans payouts loads
+// its configuration here, so they apply the same thresholds.
+func LoadPayoutServiceConfig() PayoutServiceConfig {
+	cfg := DefaultPayoutServiceConfig()
+
+	cfg.Wallet.RPCURL = config.GetEnv("LTC_RPC_URL", cfg.Wallet.RPCURL)
+	cfg.Wallet.RPCUser = config.GetEnv("LTC_RPC_USER", cfg.Wallet.RPCUser)
+	cfg.Wallet.RPCPassword = config.GetEnv("LTC_RPC_PASSWORD", cfg.Wallet.RPCPassword)
+	cfg.Wallet.WalletPassword = config.GetEnv("LTC_WALLET_PASSWORD", cfg.Wallet.WalletPassword)
+	cfg.Wallet.Network = config.GetEnv("LTC_NETWORK", cfg.Wallet.Network)
+
+	cfg.Processor.MinPayoutAmount = config.GetEnvInt64("LTC_MIN_PAYOUT", cfg.Processor.MinPayoutAmount)
+	cfg.Processor.ProcessInterval = time.Duration(config.GetEnvInt("LTC_PAYOUT_INTERVAL", int(cfg.Processor.ProcessInterval/time.Second))) * time.Second
+	cfg.Processor.Batching.Enabled = config.GetEnvBool("LTC_PAYOUT_BATCHING", cfg.Processor.Batching.Enabled)
+	cfg.Processor.DryRun = config.GetEnvBool("LTC_PAYOUT_DRY_RUN", cfg.Processor.DryRun)
+
+	cfg.Approval.MaxPayoutAmount = config.GetEnvInt64("LTC_PAYOUT_MAX_AMOUNT", cfg.Approval.MaxPayoutAmount)
+	cfg.Approval.MaxUserDailyAmount = config.GetEnvInt64("LTC_PAYOUT_MAX_USER_DAILY", cfg.Approval.MaxUserDailyAmount)
+	cfg.Approval.MaxPoolDailyAmount = config.GetEnvInt64("LTC_PAYOUT_MAX_POOL_DAILY", cfg.Approval.MaxPoolDailyAmount)
+	cfg.Approval.AddressChangeHold = config.GetEnvDuration("LTC_PAYOUT_ADDRESS_HOLD", cfg.Approval.AddressChangeHold)
+	cfg.Approval.RequiredApprovals = config.GetEnvInt("LTC_PAYOUT_REQUIRED_APPROVALS", cfg.Approval.RequiredApprovals)
+
+	cfg.Auditor.CheckInterval = config.GetEnvDuration("LTC_LEDGER_CHECK_INTERVAL", cfg.Auditor.CheckInterval)
+	cfg.Auditor.MaxWalletShortfall = config.GetEnvInt64("LTC_LEDGER_MAX_WALLET_SHORTFALL", cfg.Auditor.MaxWalletShortfall)
+
+	return cfg
+}
+
+// batchingEnabled turns batched payouts on
+func batchingEnabled(config BatchConfig) BatchConfig {
+	config.Enabled = true
+	return config
+}
+
 // PayoutServices holds all initialized payout service components
 type PayoutServices struct {
 	Executor     *PayoutExecutor
+	Unlocker     *BlockUnlocker
 	Processor    *PayoutProcessor
+	Watcher      *PayoutConfirmationWatcher
+	Approvals    *PayoutApprovalService
+	Auditor      *LedgerAuditor
+	Ledger       *Ledger
 	WalletClient *LitecoinWalletClient
 	Repository   *SQLPayoutRepository
 	Metrics      *PayoutMetrics
@@ -76,25 +139,57 @@ func NewPayoutServices(
 		return nil, fmt.Errorf("failed to create wallet client: %w", err)
 	}
 
-	// Create repository
+	// Create repository and balance ledger
 	repository := NewSQLPayoutRepository(db)
+	ledger := NewLedger(NewSQLLedgerStore(db), DefaultLedgerCurrency)
 
 	// Create metrics
 	metrics := NewPayoutMetrics(config.MetricsNamespace, registry)
 
 	// Create processor
-	processor := NewPayoutProcessor(walletClient, repository, config.Processor)
+	processor := NewPayoutProcessor(walletClient, repository, ledger, config.Processor)
 	if processor == nil {
 		return nil, fmt.Errorf("failed to create payout processor")
 	}
+	if config.Processor.Batching.Enabled {
+		processor.SetBatching(walletClient, repository)
+	} else {
+		processor.SetSigner(walletClient, repository)
+	}
+
+	// Risky payouts are held for admin approval before they are sent
+	approvals := NewPayoutApprovalService(NewSQLPayoutApprovalRepository(db), config.Approval)
+	approvals.SetLedger(ledger)
+	processor.SetPolicy(approvals)
+
+	// Payouts are split across each user's wallets and miner assignments
+	processor.SetSplitter(NewWalletSplitter(NewSQLSplitRuleRepository(db), config.Splits))
 
 	// Create executor with adapters
 	ctx, cancel := context.WithCancel(context.Background())
-	executor := createExecutor(config.Payouts, repository, ctx)
+	executor := createExecutor(config.Payouts, repository, ledger, ctx)
+	if executor == nil {
+		cancel()
+		return nil, fmt.Errorf("failed to create payout executor")
+	}
+
+	// Found blocks are credited only once their coinbase matures
+	unlocker := NewBlockUnlocker(walletClient, NewSQLBlockMaturityRepository(db), executor, config.Unlocker)
+
+	// Sent payouts are watched until their transaction is final
+	watcher := NewPayoutConfirmationWatcher(walletClient, NewSQLPayoutTransactionRepository(db), ledger, config.Watcher)
+
+	// The ledger is checked against itself and the node on a schedule
+	auditor := NewLedgerAuditor(ledger, walletClient, DefaultLedgerCurrency, config.Auditor)
 
 	return &PayoutServices{
 		Executor:     executor,
+		Unlocker:     unlocker,
 		Processor:    processor,
+		Watcher:      watcher,
+		Approvals:    approvals,
+		Auditor:      auditor,
+		Ledger:       ledger,
 		WalletClient: walletClient,
 		Repository:   repository,
 		Metrics:      metrics,
@@ -104,15 +199,14 @@ func NewPayoutServices(
 }
 
 // createExecutor creates a PayoutExecutor with database-backed adapters
-func createExecutor(config *PayoutConfig, repo *SQLPayoutRepository, ctx context.Context) *PayoutExecutor {
+func createExecutor(config *PayoutConfig, repo *SQLPayoutRepository, ledger *Ledger, ctx context.Context) *PayoutExecutor {
 	// Create adapters that implement the executor interfaces
 	notifier := &dbBlockNotifier{handlers: make([]func(*Block), 0)}
 	queue := &dbPayoutQueue{repo: repo, ctx: ctx}
 	settings := &dbUserSettings{repo: repo, ctx: ctx}
 	shares := &dbShareProvider{ctx: ctx}
-	balances := &dbBalanceTracker{repo: repo, ctx: ctx}
 
-	return NewPayoutExecutor(config, notifier, queue, settings, shares, balances)
+	return NewPayoutExecutor(config, notifier, queue, settings, shares, ledger)
 }
 
 // Start starts all payout services
@@ -120,9 +214,18 @@ func (s *PayoutServices) Start() {
 	if s.Executor != nil {
 		s.Executor.Start()
 	}
+	if s.Unlocker != nil {
+		s.Unlocker.Start()
+	}
 	if s.Processor != nil {
 		s.Processor.Start()
 	}
+	if s.Watcher != nil {
+		s.Watcher.Start()
+	}
+	if s.Auditor != nil {
+		s.Auditor.Start()
+	}
 }
 
 // Stop gracefully stops all payout services
@@ -130,12 +233,38 @@ func (s *PayoutServices) Stop() {
 	if s.cancel != nil {
 		s.cancel()
 	}
+	if s.Unlocker != nil {
+		s.Unlocker.Stop()
+	}
 	if s.Executor != nil {
 		s.Executor.Stop()
 	}
 	if s.Processor != nil {
 		s.Processor.Stop()
 	}
+	if s.Watcher != nil {
+		s.Watcher.Stop()
+	}
+	if s.Auditor != nil {
+		s.Auditor.Stop()
+	}
+}
+
+// SetNotifier sets the notifier told when payouts are sent, confirmed or
+// fail, and when a ledger check fails
+func (s *PayoutServices) SetNotifier(notifier *NotificationAdapter) {
+	if s.Processor != nil {
+		s.Processor.SetNotifier(notifier)
+	}
+	if s.Approvals != nil {
+		s.Approvals.SetNotifier(notifier)
+	}
+	if s.Watcher != nil {
+		s.Watcher.SetNotifier(notifier)
+	}
+	if s.Auditor != nil {
+		s.Auditor.SetNotifier(notifier)
+	}
 }
 
 // GetStats returns combined statistics from all services
@@ -147,11 +276,25 @@ func (s *PayoutServices) GetStats() map[string]interface{} {
 		stats["executor"] = execStats
 	}
 
+	if s.Unlocker != nil {
+		stats["unlocker"] = s.Unlocker.GetStats()
+	}
+
 	if s.Processor != nil {
 		procStats := s.Processor.GetStats()
 		stats["processor"] = procStats
 	}
 
+	if s.Watcher != nil {
+		stats["watcher"] = s.Watcher.GetStats()
+	}
+
+	if s.Auditor != nil {
+		if report := s.Auditor.LastReport(); report != nil {
+			stats["ledger"] = report
+		}
+	}
+
 	// Get wallet balance
 	if s.WalletClient != nil {
 		balance, err := s.WalletClient.GetBalance(context.Background())
@@ -166,12 +309,13 @@ func (s *PayoutServices) GetStats() map[string]interface{} {
 	return stats
 }
 
-// NotifyBlockFound notifies the executor of a new block
+// NotifyBlockFound checks unsettled blocks right away. The block itself is
+// credited by the unlocker once its coinbase matures.
 func (s *PayoutServices) NotifyBlockFound(block *Block) error {
-	if s.Executor == nil {
-		return fmt.Errorf("executor not initialized")
+	if s.Unlocker == nil {
+		return fmt.Errorf("unlocker not initialized")
 	}
-	return s.Executor.ProcessBlock(context.Background(), block)
+	return s.Unlocker.CheckBlocks(context.Background())
 }
 
 // =============================================================================
@@ -240,22 +384,3 @@ func (p *dbShareProvider) GetSharesInWindow(ctx context.Context, endTime time.Ti
 	// Actual implementation connects to existing share tracking
 	return []Share{}, nil
 }
-
-// dbBalanceTracker implements BalanceTracker using database
-type dbBalanceTracker struct {
-	repo *SQLPayoutRepository
-	ctx  context.Context
-}
-
-func (t *dbBalanceTracker) GetBalance(userID int64) int64 {
-	balance, _ := t.repo.GetUserBalance(t.ctx, userID)
-	return balance
-}
-
-func (t *dbBalanceTracker) AddToBalance(userID int64, amount int64) error {
-	return t.repo.AddToBalance(t.ctx, userID, amount)
-}
-
-func (t *dbBalanceTracker) DeductFromBalance(userID int64, amount int64) error {
-	return t.repo.DeductFromBalance(t.ctx, userID, amount)
-}
@@ -0,0 +1,597 @@
+package payouts
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// =============================================================================
+// ISP-COMPLIANT INTERFACES FOR PAYOUT SPLITS
+// =============================================================================
+
+// PayoutSplitter divides due payouts across the wallets they are paid to (ISP)
+type PayoutSplitter interface {
+	// SplitPayouts sets each payout's allocations and carries the shares of
+	// wallets below their minimum. Payouts with no wallet at or above its
+	// minimum are returned as deferred.
+	SplitPayouts(ctx context.Context, payouts []PendingPayout) (split, deferred []PendingPayout, err error)
+	// RecordAllocations stores the allocations payouts are about to be sent
+	// with, and moves their carried shares to new pending payouts
+	RecordAllocations(ctx context.Context, payouts []PendingPayout) error
+}
+
+// SplitRuleRepository loads split rules and stores payout allocations (ISP)
+type SplitRuleRepository interface {
+	// GetSplitRules returns a user's active wallets and miner wallet
+	// assignments on a network, in the order their allocations are listed
+	GetSplitRules(ctx context.Context, userID int64, network string) (wallets, assignments []SplitRule, err error)
+	// GetMinerWeights returns the valid share difficulty each of a user's
+	// miners submitted between from and to
+	GetMinerWeights(ctx context.Context, userID int64, from, to time.Time) (map[int64]float64, error)
+	// GetCarriedAllocations returns the allocations pending payouts were
+	// carried forward with, by payout ID
+	GetCarriedAllocations(ctx context.Context, payoutIDs []int64) (map[int64][]PayoutAllocation, error)
+	// ReplacePayoutAllocations replaces the stored allocations of the
+	// payouts and moves each payout's carried shares to a new pending payout
+	ReplacePayoutAllocations(ctx context.Context, payouts []PendingPayout) error
+}
+
+// =============================================================================
+// SPLIT RULES AND ALLOCATIONS
+// =============================================================================
+
+// SplitRuleType is the kind of rule an allocation came from
+type SplitRuleType string
+
+const (
+	SplitRuleMinerAssignment SplitRuleType = "miner_assignment" // Part of a miner's share sent to an assigned wallet
+	SplitRuleWalletSplit     SplitRuleType = "wallet_split"     // A percentage of the user's payouts
+	SplitRulePayoutAddress   SplitRuleType = "payout_address"   // The payout's own address, for users without wallets
+)
+
+// SplitRule sends part of a user's payouts to one address
+type SplitRule struct {
+	Type         SplitRuleType `json:"rule_type"`
+	WalletID     int64         `json:"wallet_id,omitempty"`
+	MinerID      int64         `json:"miner_id,omitempty"`
+	AssignmentID int64         `json:"assignment_id,omitempty"`
+	Address      string        `json:"address"`
+	Percentage   float64       `json:"percentage"`           // Of the user's payouts, or of the miner's share for assignments
+	MinPayout    int64         `json:"min_payout,omitempty"` // The wallet's minimum in litoshis
+}
+
+// PayoutAllocation is the part of a payout sent to one address, and the
+// rule that sent it there
+type PayoutAllocation struct {
+	PayoutID int64 `json:"payout_id"`
+	SplitRule
+	Amount int64 `json:"amount"`
+}
+
+// SplitConfig configures how payouts are split across wallets
+type SplitConfig struct {
+	Network          string        `json:"network" yaml:"network"`                       // Wallet network the pool pays on
+	MinerShareWindow time.Duration `json:"miner_share_window" yaml:"miner_share_window"` // Share history that weighs each miner's part of a payout
+}
+
+// DefaultSplitConfig returns sensible defaults
+func DefaultSplitConfig() SplitConfig {
+	return SplitConfig{
+		Network:          "litecoin",
+		MinerShareWindow: 24 * time.Hour,
+	}
+}
+
+// payoutOutputs sums a payout's allocations per address. A payout that has
+// not been split pays its own address.
+func payoutOutputs(payout PendingPayout) map[string]int64 {
+	if len(payout.Allocations) == 0 {
+		return map[string]int64{payout.Address: payout.Amount}
+	}
+	outputs := make(map[string]int64, len(payout.Allocations))
+	for _, allocation := range payout.Allocations {
+		outputs[allocation.Address] += allocation.Amount
+	}
+	return outputs
+}
+
+// payoutAddresses lists the addresses a payout pays, sorted
+func payoutAddresses(payout PendingPayout) []string {
+	outputs := payoutOutputs(payout)
+	addresses := make([]string, 0, len(outputs))
+	for address := range outputs {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+	return addresses
+}
+
+// =============================================================================
+// APPORTIONMENT
+// =============================================================================
+
+// percentScale turns percentages into basis points so 33.33% is exact
+const percentScale = 100
+
+// basisPoints converts a percentage to basis points
+func basisPoints(percentage float64) int64 {
+	return int64(math.Round(percentage * percentScale))
+}
+
+// apportion divides amount in proportion to weights by largest remainder.
+// Each part is rounded down and the litoshis left over go one each to the
+// largest remainders, earlier parts first on ties, so the parts always add
+// up to amount and the same inputs always give the same parts.
+func apportion(amount int64, weights []*big.Rat) []int64 {
+	parts := make([]int64, len(weights))
+	total := new(big.Rat)
+	for _, weight := range weights {
+		total.Add(total, weight)
+	}
+	if amount <= 0 || total.Sign() <= 0 {
+		return parts
+	}
+
+	remainders := make([]*big.Rat, len(weights))
+	order := make([]int, len(weights))
+	allocated := int64(0)
+	for i, weight := range weights {
+		exact := new(big.Rat).Mul(big.NewRat(amount, 1), weight)
+		exact.Quo(exact, total)
+		floor := new(big.Int).Quo(exact.Num(), exact.Denom())
+		parts[i] = floor.Int64()
+		allocated += parts[i]
+		remainders[i] = exact.Sub(exact, new(big.Rat).SetInt(floor))
+		order[i] = i
+	}
+
+	sort.SliceStable(order, func(a, b int) bool {
+		return remainders[order[a]].Cmp(remainders[order[b]]) > 0
+	})
+	for i := int64(0); i < amount-allocated; i++ {
+		parts[order[i]]++
+	}
+	return parts
+}
+
+// ruleShare is the fraction of a payout one rule receives
+type ruleShare struct {
+	rule  SplitRule
+	share *big.Rat
+}
+
+// splitShares works out the fraction of a payout each rule receives. Each
+// miner's part is weighed by its share difficulty, and its assignments take
+// their percentage of it, scaled down when they add up to more than 100%.
+// What is left is split across the user's wallets in proportion to their
+// percentages, or paid to the payout address when the user has none.
+func splitShares(address string, wallets, assignments []SplitRule, minerWeights map[int64]float64) []ruleShare {
+	var shares []ruleShare
+	userShare := big.NewRat(1, 1)
+
+	totalWeight := new(big.Rat)
+	for _, weight := range minerWeights {
+		if weight > 0 {
+			totalWeight.Add(totalWeight, new(big.Rat).SetFloat64(weight))
+		}
+	}
+	if totalWeight.Sign() > 0 {
+		assigned := make(map[int64]int64)
+		for _, rule := range assignments {
+			if bp := basisPoints(rule.Percentage); bp > 0 {
+				assigned[rule.MinerID] += bp
+			}
+		}
+		for _, rule := range assignments {
+			weight := minerWeights[rule.MinerID]
+			bp := basisPoints(rule.Percentage)
+			if weight <= 0 || bp <= 0 {
+				continue
+			}
+			share := new(big.Rat).Quo(new(big.Rat).SetFloat64(weight), totalWeight)
+			share.Mul(share, big.NewRat(bp, max(assigned[rule.MinerID], 100*percentScale)))
+			shares = append(shares, ruleShare{rule: rule, share: share})
+			userShare.Sub(userShare, share)
+		}
+	}
+	if userShare.Sign() <= 0 {
+		return shares
+	}
+
+	walletTotal := int64(0)
+	for _, rule := range wallets {
+		walletTotal += max(basisPoints(rule.Percentage), 0)
+	}
+	if walletTotal == 0 {
+		return append(shares, ruleShare{
+			rule:  SplitRule{Type: SplitRulePayoutAddress, Address: address, Percentage: 100},
+			share: userShare,
+		})
+	}
+	for _, rule := range wallets {
+		bp := basisPoints(rule.Percentage)
+		if bp <= 0 {
+			continue
+		}
+		share := new(big.Rat).Mul(userShare, big.NewRat(bp, walletTotal))
+		shares = append(shares, ruleShare{rule: rule, share: share})
+	}
+	return shares
+}
+
+// allocate divides a payout across its rule shares
+func allocate(payout PendingPayout, shares []ruleShare) []PayoutAllocation {
+	weights := make([]*big.Rat, len(shares))
+	for i, share := range shares {
+		weights[i] = share.share
+	}
+
+	var allocations []PayoutAllocation
+	for i, amount := range apportion(payout.Amount, weights) {
+		if amount > 0 {
+			allocations = append(allocations, PayoutAllocation{
+				PayoutID:  payout.ID,
+				SplitRule: shares[i].rule,
+				Amount:    amount,
+			})
+		}
+	}
+	return allocations
+}
+
+// =============================================================================
+// WALLET SPLITTER
+// =============================================================================
+
+// WalletSplitter splits payouts by the user's wallet percentages and miner
+// wallet assignments
+type WalletSplitter struct {
+	repo   SplitRuleRepository
+	config SplitConfig
+}
+
+// NewWalletSplitter creates a new wallet splitter
+func NewWalletSplitter(repo SplitRuleRepository, config SplitConfig) *WalletSplitter {
+	if repo == nil {
+		return nil
+	}
+
+	defaults := DefaultSplitConfig()
+	if config.Network == "" {
+		config.Network = defaults.Network
+	}
+	if config.MinerShareWindow <= 0 {
+		config.MinerShareWindow = defaults.MinerShareWindow
+	}
+
+	return &WalletSplitter{repo: repo, config: config}
+}
+
+// SplitPayouts allocates each payout across the user's wallets. A wallet's
+// minimum applies to everything it would receive in the run; the share of a
+// wallet below it is carried forward in a new pending payout for that wallet
+// alone, and paid once the wallet's carried and new shares reach the
+// minimum. The user's other wallets never receive it. A payout with nothing
+// left to send waits whole for more to accumulate.
+func (s *WalletSplitter) SplitPayouts(ctx context.Context, payouts []PendingPayout) ([]PendingPayout, []PendingPayout, error) {
+	byUser := make(map[int64][]int)
+	var users []int64
+	for i, payout := range payouts {
+		if _, ok := byUser[payout.UserID]; !ok {
+			users = append(users, payout.UserID)
+		}
+		byUser[payout.UserID] = append(byUser[payout.UserID], i)
+	}
+
+	ids := make([]int64, len(payouts))
+	for i, payout := range payouts {
+		ids[i] = payout.ID
+	}
+	carried, err := s.repo.GetCarriedAllocations(ctx, ids)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get carried allocations: %w", err)
+	}
+
+	allocations := make([][]PayoutAllocation, len(payouts))
+	held := make([][]PayoutAllocation, len(payouts))
+	for _, userID := range users {
+		wallets, assignments, err := s.repo.GetSplitRules(ctx, userID, s.config.Network)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get split rules for user %d: %w", userID, err)
+		}
+		minimums := make(map[int64]int64)
+		for _, rule := range wallets {
+			minimums[rule.WalletID] = rule.MinPayout
+		}
+		for _, rule := range assignments {
+			minimums[rule.WalletID] = rule.MinPayout
+		}
+
+		indexes := byUser[userID]
+		received := make(map[int64]int64)
+		for _, i := range indexes {
+			if pinned := carried[payouts[i].ID]; len(pinned) > 0 {
+				// Carried payouts keep the split they were carried with. A
+				// wallet removed since then has no minimum left to wait for.
+				allocations[i] = make([]PayoutAllocation, len(pinned))
+				for k, allocation := range pinned {
+					allocation.PayoutID = payouts[i].ID
+					allocation.MinPayout = minimums[allocation.WalletID]
+					allocations[i][k] = allocation
+				}
+			} else {
+				var weights map[int64]float64
+				if len(assignments) > 0 {
+					createdAt := payouts[i].CreatedAt
+					weights, err = s.repo.GetMinerWeights(ctx, userID, createdAt.Add(-s.config.MinerShareWindow), createdAt)
+					if err != nil {
+						return nil, nil, fmt.Errorf("failed to get miner weights for user %d: %w", userID, err)
+					}
+				}
+				allocations[i] = allocate(payouts[i], splitShares(payouts[i].Address, wallets, assignments, weights))
+			}
+			for _, allocation := range allocations[i] {
+				received[allocation.WalletID] += allocation.Amount
+			}
+		}
+
+		for _, i := range indexes {
+			var kept []PayoutAllocation
+			for _, allocation := range allocations[i] {
+				if allocation.WalletID != 0 && received[allocation.WalletID] < allocation.MinPayout {
+					held[i] = append(held[i], allocation)
+					continue
+				}
+				kept = append(kept, allocation)
+			}
+			allocations[i] = kept
+		}
+	}
+
+	split := make([]PendingPayout, 0, len(payouts))
+	var deferred []PendingPayout
+	for i, payout := range payouts {
+		if len(allocations[i]) == 0 {
+			deferred = append(deferred, payout)
+			continue
+		}
+		payout.Allocations = allocations[i]
+		payout.Carried = held[i]
+		for _, allocation := range held[i] {
+			payout.Amount -= allocation.Amount
+		}
+		split = append(split, payout)
+	}
+	return split, deferred, nil
+}
+
+// RecordAllocations stores the allocations of split payouts and carries
+// their held back shares forward
+func (s *WalletSplitter) RecordAllocations(ctx context.Context, payouts []PendingPayout) error {
+	split := make([]PendingPayout, 0, len(payouts))
+	for _, payout := range payouts {
+		if len(payout.Allocations) > 0 {
+			split = append(split, payout)
+		}
+	}
+	if len(split) == 0 {
+		return nil
+	}
+	return s.repo.ReplacePayoutAllocations(ctx, split)
+}
+
+// SetSplitter splits payouts across the users' wallets before they are sent
+func (p *PayoutProcessor) SetSplitter(splitter PayoutSplitter) {
+	p.splitter = splitter
+}
+
+// =============================================================================
+// SQL SPLIT RULE REPOSITORY
+// =============================================================================
+
+// SQLSplitRuleRepository reads split rules from user_wallets and
+// miner_wallet_assignments and stores allocations in payout_allocations
+type SQLSplitRuleRepository struct {
+	db *sql.DB
+}
+
+// NewSQLSplitRuleRepository creates a new SQL split rule repository
+func NewSQLSplitRuleRepository(db *sql.DB) *SQLSplitRuleRepository {
+	return &SQLSplitRuleRepository{db: db}
+}
+
+// GetSplitRules returns a user's active wallets, primary first, and the
+// active assignments of the user's miners to those wallets
+func (r *SQLSplitRuleRepository) GetSplitRules(ctx context.Context, userID int64, network string) ([]SplitRule, []SplitRule, error) {
+	walletQuery := `
+		SELECT id, address, percentage,
+		       COALESCE(ROUND(min_payout_threshold * 100000000), 0)::BIGINT
+		FROM user_wallets
+		WHERE user_id = $1 AND is_active = true AND COALESCE(network, $2) = $2
+		ORDER BY is_primary DESC, created_at ASC, id ASC
+	`
+	rows, err := r.db.QueryContext(ctx, walletQuery, userID, network)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query wallets: %w", err)
+	}
+	var wallets []SplitRule
+	for rows.Next() {
+		rule := SplitRule{Type: SplitRuleWalletSplit}
+		if err := rows.Scan(&rule.WalletID, &rule.Address, &rule.Percentage, &rule.MinPayout); err != nil {
+			rows.Close()
+			return nil, nil, fmt.Errorf("failed to scan wallet: %w", err)
+		}
+		wallets = append(wallets, rule)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	assignmentQuery := `
+		SELECT mwa.id, mwa.miner_id, uw.id, uw.address, mwa.allocation_percent,
+		       COALESCE(ROUND(uw.min_payout_threshold * 100000000), 0)::BIGINT
+		FROM miner_wallet_assignments mwa
+		JOIN miners m ON m.id = mwa.miner_id
+		JOIN user_wallets uw ON uw.id = mwa.wallet_id
+		WHERE m.user_id = $1 AND uw.user_id = $1
+		  AND mwa.is_active = true AND uw.is_active = true
+		  AND COALESCE(uw.network, $2) = $2
+		ORDER BY mwa.miner_id ASC, mwa.id ASC
+	`
+	rows, err = r.db.QueryContext(ctx, assignmentQuery, userID, network)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query miner wallet assignments: %w", err)
+	}
+	defer rows.Close()
+
+	var assignments []SplitRule
+	for rows.Next() {
+		rule := SplitRule{Type: SplitRuleMinerAssignment}
+		if err := rows.Scan(&rule.AssignmentID, &rule.MinerID, &rule.WalletID, &rule.Address, &rule.Percentage, &rule.MinPayout); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan miner wallet assignment: %w", err)
+		}
+		assignments = append(assignments, rule)
+	}
+	return wallets, assignments, rows.Err()
+}
+
+// GetMinerWeights sums the valid share difficulty of a user's miners
+func (r *SQLSplitRuleRepository) GetMinerWeights(ctx context.Context, userID int64, from, to time.Time) (map[int64]float64, error) {
+	query := `
+		SELECT miner_id, COALESCE(SUM(difficulty), 0)
+		FROM shares
+		WHERE user_id = $1 AND is_valid = true AND timestamp >= $2 AND timestamp < $3
+		GROUP BY miner_id
+	`
+	rows, err := r.db.QueryContext(ctx, query, userID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query miner shares: %w", err)
+	}
+	defer rows.Close()
+
+	weights := make(map[int64]float64)
+	for rows.Next() {
+		var minerID int64
+		var weight float64
+		if err := rows.Scan(&minerID, &weight); err != nil {
+			return nil, fmt.Errorf("failed to scan miner shares: %w", err)
+		}
+		weights[minerID] = weight
+	}
+	return weights, rows.Err()
+}
+
+// GetCarriedAllocations returns the stored allocations of pending payouts.
+// Only payouts carried forward are pending with allocations; the
+// allocations of every other payout are recorded as it is sent.
+func (r *SQLSplitRuleRepository) GetCarriedAllocations(ctx context.Context, payoutIDs []int64) (map[int64][]PayoutAllocation, error) {
+	carried := make(map[int64][]PayoutAllocation)
+	if len(payoutIDs) == 0 {
+		return carried, nil
+	}
+
+	query := `
+		SELECT pa.payout_id, pa.rule_type, COALESCE(pa.wallet_id, 0), COALESCE(pa.miner_id, 0),
+		       COALESCE(pa.assignment_id, 0), pa.percentage, pa.address, pa.amount
+		FROM payout_allocations pa
+		JOIN pending_payouts pp ON pp.id = pa.payout_id
+		WHERE pa.payout_id = ANY($1) AND pp.status = $2
+		ORDER BY pa.payout_id ASC, pa.id ASC
+	`
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(payoutIDs), PayoutStatusPending)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query carried allocations: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var allocation PayoutAllocation
+		if err := rows.Scan(&allocation.PayoutID, &allocation.Type, &allocation.WalletID, &allocation.MinerID,
+			&allocation.AssignmentID, &allocation.Percentage, &allocation.Address, &allocation.Amount); err != nil {
+			return nil, fmt.Errorf("failed to scan carried allocation: %w", err)
+		}
+		carried[allocation.PayoutID] = append(carried[allocation.PayoutID], allocation)
+	}
+	return carried, rows.Err()
+}
+
+// ReplacePayoutAllocations replaces the payouts' allocations in one
+// transaction, so a payout sent again is traced to its latest split. Carried
+// shares are taken off each payout's amount and moved to a new pending
+// payout in the same transaction. The new payout has no block, so its ledger
+// postings are keyed by its own ID rather than colliding with the original's.
+func (r *SQLSplitRuleRepository) ReplacePayoutAllocations(ctx context.Context, payouts []PendingPayout) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	insert := `
+		INSERT INTO payout_allocations (payout_id, rule_type, wallet_id, miner_id, assignment_id, percentage, address, amount)
+		VALUES ($1, $2, NULLIF($3::BIGINT, 0), NULLIF($4::BIGINT, 0), NULLIF($5::BIGINT, 0), $6, $7, $8)
+	`
+	for _, payout := range payouts {
+		if len(payout.Carried) == 0 {
+			continue
+		}
+		carriedAmount := int64(0)
+		for _, allocation := range payout.Carried {
+			carriedAmount += allocation.Amount
+		}
+
+		var carriedID int64
+		err := tx.QueryRowContext(ctx, `
+			WITH reduced AS (
+				UPDATE pending_payouts SET amount = amount - $2
+				WHERE id = $1 AND status = $3 AND amount > $2
+				RETURNING user_id, address, payout_mode, created_at
+			)
+			INSERT INTO pending_payouts (user_id, amount, address, status, payout_mode, block_id, created_at)
+			SELECT user_id, $2, address, $3, payout_mode, 0, created_at FROM reduced
+			RETURNING id
+		`, payout.ID, carriedAmount, PayoutStatusPending).Scan(&carriedID)
+		if err != nil {
+			return fmt.Errorf("failed to carry forward payout %d: %w", payout.ID, err)
+		}
+		for _, allocation := range payout.Carried {
+			if _, err := tx.ExecContext(ctx, insert, carriedID, allocation.Type, allocation.WalletID,
+				allocation.MinerID, allocation.AssignmentID, allocation.Percentage, allocation.Address, allocation.Amount); err != nil {
+				return fmt.Errorf("failed to insert carried allocation: %w", err)
+			}
+		}
+	}
+
+	ids := make([]int64, len(payouts))
+	for i, payout := range payouts {
+		ids[i] = payout.ID
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM payout_allocations WHERE payout_id = ANY($1)`, pq.Array(ids)); err != nil {
+		return fmt.Errorf("failed to clear payout allocations: %w", err)
+	}
+
+	for _, payout := range payouts {
+		for _, allocation := range payout.Allocations {
+			if _, err := tx.ExecContext(ctx, insert, payout.ID, allocation.Type, allocation.WalletID,
+				allocation.MinerID, allocation.AssignmentID, allocation.Percentage, allocation.Address, allocation.Amount); err != nil {
+				return fmt.Errorf("failed to insert payout allocation: %w", err)
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Ensure implementations satisfy their interfaces
+var (
+	_ PayoutSplitter      = (*WalletSplitter)(nil)
+	_ SplitRuleRepository = (*SQLSplitRuleRepository)(nil)
+)
@@ -0,0 +1,309 @@
+package payouts
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// MOCK SPLIT RULE REPOSITORY FOR TESTING
+// =============================================================================
+
+type mockSplitRuleRepository struct {
+	wallets     map[int64][]SplitRule
+	assignments map[int64][]SplitRule
+	weights     map[int64]map[int64]float64
+	recorded    map[int64][]PayoutAllocation
+	carried     []PendingPayout
+	nextID      int64
+	mu          sync.Mutex
+}
+
+func newMockSplitRuleRepository() *mockSplitRuleRepository {
+	return &mockSplitRuleRepository{
+		wallets:     make(map[int64][]SplitRule),
+		assignments: make(map[int64][]SplitRule),
+		weights:     make(map[int64]map[int64]float64),
+		recorded:    make(map[int64][]PayoutAllocation),
+		nextID:      1000,
+	}
+}
+
+func (m *mockSplitRuleRepository) addWallet(userID, walletID int64, address string, percentage float64, minPayout int64) {
+	m.wallets[userID] = append(m.wallets[userID], SplitRule{
+		Type: SplitRuleWalletSplit, WalletID: walletID, Address: address, Percentage: percentage, MinPayout: minPayout,
+	})
+}
+
+func (m *mockSplitRuleRepository) GetSplitRules(ctx context.Context, userID int64, network string) ([]SplitRule, []SplitRule, error) {
+	return m.wallets[userID], m.assignments[userID], nil
+}
+
+func (m *mockSplitRuleRepository) GetMinerWeights(ctx context.Context, userID int64, from, to time.Time) (map[int64]float64, error) {
+	return m.weights[userID], nil
+}
+
+func (m *mockSplitRuleRepository) GetCarriedAllocations(ctx context.Context, payoutIDs []int64) (map[int64][]PayoutAllocation, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	carried := make(map[int64][]PayoutAllocation)
+	for _, id := range payoutIDs {
+		if allocations, ok := m.recorded[id]; ok {
+			carried[id] = allocations
+		}
+	}
+	return carried, nil
+}
+
+func (m *mockSplitRuleRepository) ReplacePayoutAllocations(ctx context.Context, payouts []PendingPayout) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, payout := range payouts {
+		m.recorded[payout.ID] = payout.Allocations
+		if len(payout.Carried) == 0 {
+			continue
+		}
+		m.nextID++
+		// Like the SQL repository, the carried payout has no block
+		carried := PendingPayout{ID: m.nextID, UserID: payout.UserID, Address: payout.Address, CreatedAt: payout.CreatedAt}
+		for _, allocation := range payout.Carried {
+			allocation.PayoutID = carried.ID
+			carried.Amount += allocation.Amount
+			m.recorded[carried.ID] = append(m.recorded[carried.ID], allocation)
+		}
+		m.carried = append(m.carried, carried)
+	}
+	return nil
+}
+
+// =============================================================================
+// PAYOUT SPLIT TESTS
+// =============================================================================
+
+func allocationAmounts(allocations []PayoutAllocation) map[string]int64 {
+	amounts := make(map[string]int64)
+	for _, allocation := range allocations {
+		amounts[allocation.Address] += allocation.Amount
+	}
+	return amounts
+}
+
+func TestApportion(t *testing.T) {
+	thirds := []*big.Rat{big.NewRat(1, 3), big.NewRat(1, 3), big.NewRat(1, 3)}
+	assert.Equal(t, []int64{34, 33, 33}, apportion(100, thirds), "ties go to the earlier part")
+	assert.Equal(t, []int64{1, 0, 0}, apportion(1, thirds))
+	assert.Equal(t, []int64{0, 0, 0}, apportion(0, thirds))
+
+	parts := apportion(1000000001, []*big.Rat{big.NewRat(3333, 1), big.NewRat(3333, 1), big.NewRat(3334, 1)})
+	assert.Equal(t, int64(1000000001), parts[0]+parts[1]+parts[2])
+	assert.Equal(t, []int64{333300000, 333300000, 333400001}, parts)
+
+	assert.Equal(t, []int64{0, 7}, apportion(7, []*big.Rat{new(big.Rat), big.NewRat(1, 1)}))
+}
+
+func TestWalletSplitter_SplitPayouts(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("splits by wallet percentages", func(t *testing.T) {
+		repo := newMockSplitRuleRepository()
+		repo.addWallet(1, 11, "ltc1qwalletseventy", 70, 0)
+		repo.addWallet(1, 12, "ltc1qwalletthirty", 30, 0)
+		splitter := NewWalletSplitter(repo, SplitConfig{})
+
+		split, deferred, err := splitter.SplitPayouts(ctx, []PendingPayout{{ID: 5, UserID: 1, Amount: 1000001, Address: "ltc1qpayout"}})
+		require.NoError(t, err)
+		assert.Empty(t, deferred)
+		require.Len(t, split, 1)
+		require.Len(t, split[0].Allocations, 2)
+		assert.Equal(t, PayoutAllocation{
+			PayoutID:  5,
+			SplitRule: SplitRule{Type: SplitRuleWalletSplit, WalletID: 11, Address: "ltc1qwalletseventy", Percentage: 70},
+			Amount:    700001,
+		}, split[0].Allocations[0])
+		assert.Equal(t, int64(300000), split[0].Allocations[1].Amount)
+	})
+
+	t.Run("pays the payout address without wallets", func(t *testing.T) {
+		splitter := NewWalletSplitter(newMockSplitRuleRepository(), DefaultSplitConfig())
+
+		split, _, err := splitter.SplitPayouts(ctx, []PendingPayout{{ID: 1, UserID: 1, Amount: 500000, Address: "ltc1qpayout"}})
+		require.NoError(t, err)
+		require.Len(t, split[0].Allocations, 1)
+		assert.Equal(t, SplitRulePayoutAddress, split[0].Allocations[0].Type)
+		assert.Equal(t, map[string]int64{"ltc1qpayout": 500000}, allocationAmounts(split[0].Allocations))
+	})
+
+	t.Run("miner assignments take part of their miner's share", func(t *testing.T) {
+		repo := newMockSplitRuleRepository()
+		repo.addWallet(1, 11, "ltc1qmainwallet", 100, 0)
+		repo.assignments[1] = []SplitRule{
+			{Type: SplitRuleMinerAssignment, AssignmentID: 4, MinerID: 7, WalletID: 13, Address: "ltc1qrigwallet", Percentage: 50},
+		}
+		repo.weights[1] = map[int64]float64{7: 300, 8: 100}
+		splitter := NewWalletSplitter(repo, DefaultSplitConfig())
+
+		split, _, err := splitter.SplitPayouts(ctx, []PendingPayout{{ID: 1, UserID: 1, Amount: 1000000, Address: "ltc1qpayout"}})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]int64{"ltc1qrigwallet": 375000, "ltc1qmainwallet": 625000}, allocationAmounts(split[0].Allocations))
+		assert.Equal(t, int64(7), split[0].Allocations[0].MinerID)
+		assert.Equal(t, int64(4), split[0].Allocations[0].AssignmentID)
+	})
+
+	t.Run("wallets below their minimum carry their share forward", func(t *testing.T) {
+		repo := newMockSplitRuleRepository()
+		repo.addWallet(1, 11, "ltc1qmainwallet", 90, 0)
+		repo.addWallet(1, 12, "ltc1qsavingswallet", 10, 500000)
+		repo.addWallet(2, 21, "ltc1qcoldwallet", 100, 5000000)
+		splitter := NewWalletSplitter(repo, DefaultSplitConfig())
+
+		split, deferred, err := splitter.SplitPayouts(ctx, []PendingPayout{
+			{ID: 1, UserID: 1, Amount: 1000000, Address: "ltc1qpayout"},
+			{ID: 2, UserID: 2, Amount: 2000000, Address: "ltc1qpayouttwo"},
+			{ID: 3, UserID: 1, Amount: 1000000, Address: "ltc1qpayout"},
+		})
+		require.NoError(t, err)
+		require.Len(t, split, 2)
+		for _, payout := range split {
+			assert.Equal(t, map[string]int64{"ltc1qmainwallet": 900000}, allocationAmounts(payout.Allocations), "the main wallet keeps its 90%")
+			assert.Equal(t, int64(900000), payout.Amount)
+			assert.Equal(t, map[string]int64{"ltc1qsavingswallet": 100000}, allocationAmounts(payout.Carried))
+		}
+		require.Len(t, deferred, 1)
+		assert.Equal(t, int64(2), deferred[0].ID)
+
+		require.NoError(t, splitter.RecordAllocations(ctx, split))
+		require.Len(t, repo.carried, 2)
+		assert.Equal(t, int64(100000), repo.carried[0].Amount)
+
+		// The carried shares and the next payout's share reach the minimum
+		next := append([]PendingPayout{{ID: 4, UserID: 1, Amount: 3000000, Address: "ltc1qpayout"}}, repo.carried...)
+		split, deferred, err = splitter.SplitPayouts(ctx, next)
+		require.NoError(t, err)
+		assert.Empty(t, deferred)
+		require.Len(t, split, 3)
+		assert.Equal(t, map[string]int64{"ltc1qmainwallet": 2700000, "ltc1qsavingswallet": 300000}, allocationAmounts(split[0].Allocations))
+		for _, payout := range split[1:] {
+			assert.Equal(t, map[string]int64{"ltc1qsavingswallet": 100000}, allocationAmounts(payout.Allocations))
+			assert.Empty(t, payout.Carried)
+		}
+	})
+
+	t.Run("carried shares settle in the ledger apart from the original payout", func(t *testing.T) {
+		settle := map[string]func(*Ledger, PendingPayout) error{
+			"sent": func(ledger *Ledger, payout PendingPayout) error {
+				return ledger.CompletePayout(ctx, payout, "txcarried")
+			},
+			"refunded": func(ledger *Ledger, payout PendingPayout) error {
+				return ledger.RefundPayout(ctx, payout, "payout failed")
+			},
+		}
+		for name, settleCarried := range settle {
+			repo := newMockSplitRuleRepository()
+			repo.addWallet(1, 11, "ltc1qmainwallet", 90, 0)
+			repo.addWallet(1, 12, "ltc1qsavingswallet", 10, 500000)
+			splitter := NewWalletSplitter(repo, DefaultSplitConfig())
+			ledger, _ := newTestLedger()
+			block := &Block{ID: 5, Height: 500, Hash: "bb", Reward: 1000000, Status: BlockStatusConfirmed}
+			require.NoError(t, ledger.CreditBlock(ctx, block, []Payout{{UserID: 1, BlockID: 5, Amount: 1000000}}))
+
+			original := PendingPayout{ID: 1, UserID: 1, BlockID: 5, Amount: 1000000, Address: "ltc1qpayout"}
+			require.NoError(t, ledger.QueuePayout(ctx, original))
+
+			split, _, err := splitter.SplitPayouts(ctx, []PendingPayout{original})
+			require.NoError(t, err)
+			require.Len(t, split, 1)
+			require.NoError(t, splitter.RecordAllocations(ctx, split))
+			require.Len(t, repo.carried, 1)
+
+			require.NoError(t, ledger.CompletePayout(ctx, split[0], "txoriginal"))
+			require.NoError(t, settleCarried(ledger, repo.carried[0]), "the carried payout is %s under its own reference", name)
+
+			inFlight, err := ledger.AccountBalance(ctx, AccountPayoutsInFlight, "")
+			require.NoError(t, err)
+			assert.Zero(t, inFlight, "nothing is left in flight once the carried payout is %s", name)
+			assertLedgerOK(t, ledger)
+		}
+	})
+
+	t.Run("carried shares wait until the wallet reaches its minimum", func(t *testing.T) {
+		repo := newMockSplitRuleRepository()
+		repo.addWallet(1, 11, "ltc1qmainwallet", 90, 0)
+		repo.addWallet(1, 12, "ltc1qsavingswallet", 10, 500000)
+		repo.recorded[7] = []PayoutAllocation{{PayoutID: 7, SplitRule: repo.wallets[1][1], Amount: 100000}}
+		splitter := NewWalletSplitter(repo, DefaultSplitConfig())
+
+		split, deferred, err := splitter.SplitPayouts(ctx, []PendingPayout{{ID: 7, UserID: 1, Amount: 100000, Address: "ltc1qpayout"}})
+		require.NoError(t, err)
+		assert.Empty(t, split)
+		require.Len(t, deferred, 1, "a carried payout is never re-split to the other wallets")
+		assert.Equal(t, int64(7), deferred[0].ID)
+	})
+}
+
+func TestPayoutProcessor_Splits(t *testing.T) {
+	ctx := context.Background()
+
+	newSplitRepo := func() *mockSplitRuleRepository {
+		repo := newMockSplitRuleRepository()
+		repo.addWallet(1, 11, "ltc1qwalletseventy", 70, 0)
+		repo.addWallet(1, 12, "ltc1qwalletthirty", 30, 0)
+		return repo
+	}
+
+	t.Run("sends split payouts as one transaction each", func(t *testing.T) {
+		wallet := newMockBatchWallet()
+		repo := NewMockPayoutRepository()
+		repo.AddPendingPayout(PendingPayout{UserID: 1, Amount: 2000000, Address: "ltc1qpayout"})
+		repo.AddPendingPayout(PendingPayout{UserID: 2, Amount: 3000000, Address: "ltc1qsinglepayout"})
+		splitRepo := newSplitRepo()
+		processor := NewPayoutProcessor(wallet, repo, NewMockBalanceTracker(), DefaultProcessorConfig())
+		processor.SetSplitter(NewWalletSplitter(splitRepo, DefaultSplitConfig()))
+
+		require.NoError(t, processor.ProcessPendingPayouts(ctx))
+		require.Len(t, wallet.sent, 1)
+		assert.Equal(t, map[string]int64{"ltc1qwalletseventy": 1400000, "ltc1qwalletthirty": 600000}, wallet.sent[0])
+		require.Len(t, wallet.GetTransactions(), 1)
+		assert.Equal(t, "ltc1qsinglepayout", wallet.GetTransactions()[0].Address)
+		assert.Equal(t, 2, repo.GetCompletedCount())
+
+		require.Len(t, splitRepo.recorded[1], 2)
+		assert.Equal(t, int64(11), splitRepo.recorded[1][0].WalletID)
+		assert.Equal(t, SplitRulePayoutAddress, splitRepo.recorded[2][0].Type)
+	})
+
+	t.Run("fails split payouts with an invalid wallet address", func(t *testing.T) {
+		wallet := newMockBatchWallet()
+		repo := NewMockPayoutRepository()
+		repo.AddPendingPayout(PendingPayout{UserID: 1, Amount: 2000000, Address: "ltc1qpayout"})
+		splitRepo := newSplitRepo()
+		splitRepo.addWallet(1, 13, "bogus", 10, 0)
+		processor := NewPayoutProcessor(wallet, repo, NewMockBalanceTracker(), DefaultProcessorConfig())
+		processor.SetSplitter(NewWalletSplitter(splitRepo, DefaultSplitConfig()))
+
+		require.NoError(t, processor.ProcessPendingPayouts(ctx))
+		assert.Empty(t, wallet.sent)
+		assert.Equal(t, 1, repo.GetFailedCount())
+	})
+
+	t.Run("batches every output of a split payout", func(t *testing.T) {
+		wallet := newMockBatchWallet()
+		repo := newMockBatchRepository()
+		repo.add(1, 2000000, "ltc1qpayout", time.Now())
+		repo.add(2, 3000000, "ltc1qwalletthirty", time.Now())
+		processor := NewPayoutProcessor(wallet, repo, NewMockBalanceTracker(), DefaultProcessorConfig())
+		processor.SetBatching(wallet, repo)
+		processor.SetSplitter(NewWalletSplitter(newSplitRepo(), DefaultSplitConfig()))
+
+		plan, err := processor.PlanPayouts(ctx)
+		require.NoError(t, err)
+		require.Len(t, plan.Transactions, 1)
+		assert.Equal(t, map[string]int64{"ltc1qwalletseventy": 1400000, "ltc1qwalletthirty": 3600000}, plan.Transactions[0].Outputs)
+		assert.Equal(t, int64(5000000), plan.Transactions[0].Amount)
+	})
+}
@@ -4,8 +4,10 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"strings"
 	"sync/atomic"
@@ -52,6 +54,13 @@ type RPCError struct {
 	Message string `json:"message"`
 }
 
+// RPC error codes
+const (
+	rpcErrInvalidAddressOrKey = -5  // Unknown block, transaction or address
+	rpcErrVerify              = -25 // Transaction inputs missing or already spent
+	rpcErrAlreadyInChain      = -27 // Transaction already in the chain
+)
+
 func (e *RPCError) Error() string {
 	return fmt.Sprintf("RPC error %d: %s", e.Code, e.Message)
 }
@@ -170,6 +179,156 @@ func (c *LitecoinWalletClient) SendTransaction(ctx context.Context, address stri
 	return txHash, nil
 }
 
+// SendManyOptions tunes a sendmany transaction
+type SendManyOptions struct {
+	Comment       string // Stored with the transaction in the wallet
+	ConfirmTarget int    // Blocks the fee should confirm within; 0 uses the wallet default
+	Replaceable   bool   // Signal BIP125 replace-by-fee
+}
+
+// SendMany pays several addresses in one transaction and returns the tx hash
+func (c *LitecoinWalletClient) SendMany(ctx context.Context, outputs map[string]int64, opts SendManyOptions) (string, error) {
+	if len(outputs) == 0 {
+		return "", fmt.Errorf("no outputs")
+	}
+
+	amounts := make(map[string]float64, len(outputs))
+	for address, amount := range outputs {
+		if !c.ValidateAddress(address) {
+			return "", fmt.Errorf("invalid address: %s", address)
+		}
+		if amount <= 0 {
+			return "", fmt.Errorf("amount must be positive")
+		}
+		amounts[address] = float64(amount) / 100000000
+	}
+
+	// dummy, amounts, minconf, comment, subtractfeefrom, replaceable[, conf_target]
+	params := []interface{}{"", amounts, 1, opts.Comment, []string{}, opts.Replaceable}
+	if opts.ConfirmTarget > 0 {
+		params = append(params, opts.ConfirmTarget)
+	}
+
+	result, err := c.call(ctx, "sendmany", params)
+	if err != nil {
+		return "", err
+	}
+
+	var txHash string
+	if err := json.Unmarshal(result, &txHash); err != nil {
+		return "", fmt.Errorf("failed to parse tx hash: %w", err)
+	}
+
+	return txHash, nil
+}
+
+// TransactionOptions tunes a transaction built by CreateTransaction
+type TransactionOptions struct {
+	ConfirmTarget int  // Blocks the fee should confirm within; 0 uses the wallet default
+	Replaceable   bool // Signal BIP125 replace-by-fee
+}
+
+// SignedTransaction is a funded and signed transaction that has not
+// necessarily been broadcast
+type SignedTransaction struct {
+	TxID string
+	Hex  string
+	Fee  int64 // Litoshis paid to miners, when the wallet reports it
+}
+
+// CreateTransaction funds a transaction paying outputs from the wallet and
+// signs it without broadcasting, so its hash is known before it can be seen
+func (c *LitecoinWalletClient) CreateTransaction(ctx context.Context, outputs map[string]int64, opts TransactionOptions) (*SignedTransaction, error) {
+	if len(outputs) == 0 {
+		return nil, fmt.Errorf("no outputs")
+	}
+
+	amounts := make(map[string]float64, len(outputs))
+	for address, amount := range outputs {
+		if !c.ValidateAddress(address) {
+			return nil, fmt.Errorf("invalid address: %s", address)
+		}
+		if amount <= 0 {
+			return nil, fmt.Errorf("amount must be positive")
+		}
+		amounts[address] = float64(amount) / 100000000
+	}
+
+	result, err := c.call(ctx, "createrawtransaction", []interface{}{[]interface{}{}, amounts})
+	if err != nil {
+		return nil, err
+	}
+	var raw string
+	if err := json.Unmarshal(result, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse raw transaction: %w", err)
+	}
+
+	options := map[string]interface{}{"replaceable": opts.Replaceable}
+	if opts.ConfirmTarget > 0 {
+		options["conf_target"] = opts.ConfirmTarget
+	}
+	result, err = c.call(ctx, "fundrawtransaction", []interface{}{raw, options})
+	if err != nil {
+		return nil, err
+	}
+	var funded struct {
+		Hex string  `json:"hex"`
+		Fee float64 `json:"fee"`
+	}
+	if err := json.Unmarshal(result, &funded); err != nil {
+		return nil, fmt.Errorf("failed to parse funded transaction: %w", err)
+	}
+
+	result, err = c.call(ctx, "signrawtransactionwithwallet", []interface{}{funded.Hex})
+	if err != nil {
+		return nil, err
+	}
+	var signed struct {
+		Hex      string `json:"hex"`
+		Complete bool   `json:"complete"`
+	}
+	if err := json.Unmarshal(result, &signed); err != nil {
+		return nil, fmt.Errorf("failed to parse signed transaction: %w", err)
+	}
+	if !signed.Complete {
+		return nil, fmt.Errorf("failed to sign transaction")
+	}
+
+	tx, err := c.decodeSigned(ctx, signed.Hex)
+	if err != nil {
+		return nil, err
+	}
+	tx.Fee = int64(math.Round(funded.Fee * 100000000))
+	return tx, nil
+}
+
+// decodeSigned reads the hash of a signed transaction
+func (c *LitecoinWalletClient) decodeSigned(ctx context.Context, hex string) (*SignedTransaction, error) {
+	result, err := c.call(ctx, "decoderawtransaction", []interface{}{hex})
+	if err != nil {
+		return nil, err
+	}
+	var decoded struct {
+		TxID string `json:"txid"`
+	}
+	if err := json.Unmarshal(result, &decoded); err != nil {
+		return nil, fmt.Errorf("failed to parse decoded transaction: %w", err)
+	}
+
+	return &SignedTransaction{TxID: decoded.TxID, Hex: hex}, nil
+}
+
+// BroadcastTransaction submits a signed transaction. One already in the
+// chain counts as broadcast.
+func (c *LitecoinWalletClient) BroadcastTransaction(ctx context.Context, tx SignedTransaction) error {
+	_, err := c.call(ctx, "sendrawtransaction", []interface{}{tx.Hex})
+	var rpcErr *RPCError
+	if errors.As(err, &rpcErr) && rpcErr.Code == rpcErrAlreadyInChain {
+		return nil
+	}
+	return err
+}
+
 // EstimateFee estimates the fee for a transaction (returns litoshis per KB)
 func (c *LitecoinWalletClient) EstimateFee(ctx context.Context, confirmTarget int) (int64, error) {
 	result, err := c.call(ctx, "estimatesmartfee", []interface{}{confirmTarget})
@@ -240,15 +399,276 @@ func (c *LitecoinWalletClient) GetTransaction(ctx context.Context, txHash string
 	return &info, nil
 }
 
+// GetTransactionStatus reports a wallet transaction's confirmations and
+// whether it is in the node's mempool
+func (c *LitecoinWalletClient) GetTransactionStatus(ctx context.Context, txHash string) (*TransactionStatus, error) {
+	info, err := c.GetTransaction(ctx, txHash)
+	if err != nil {
+		var rpcErr *RPCError
+		if errors.As(err, &rpcErr) && rpcErr.Code == rpcErrInvalidAddressOrKey {
+			return &TransactionStatus{}, nil
+		}
+		return nil, err
+	}
+
+	status := &TransactionStatus{
+		Known:           true,
+		Confirmations:   int64(info.Confirmations),
+		WalletConflicts: info.WalletConflicts,
+		Outputs:         make(map[string]int64),
+	}
+	for _, detail := range info.Details {
+		if detail.Category == "send" {
+			status.Outputs[detail.Address] += int64(math.Round(-detail.Amount * 100000000))
+		}
+	}
+	if info.Confirmations == 0 {
+		_, err := c.call(ctx, "getmempoolentry", []interface{}{txHash})
+		var rpcErr *RPCError
+		switch {
+		case err == nil:
+			status.InMempool = true
+		case errors.As(err, &rpcErr) && rpcErr.Code == rpcErrInvalidAddressOrKey:
+		default:
+			return nil, err
+		}
+	}
+	return status, nil
+}
+
+// RebroadcastTransaction submits a wallet transaction to the mempool again.
+// It fails with ErrTransactionInvalid if its inputs are missing or spent.
+func (c *LitecoinWalletClient) RebroadcastTransaction(ctx context.Context, txHash string) error {
+	info, err := c.GetTransaction(ctx, txHash)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.call(ctx, "sendrawtransaction", []interface{}{info.Hex})
+	var rpcErr *RPCError
+	if errors.As(err, &rpcErr) && rpcErr.Code == rpcErrVerify {
+		return fmt.Errorf("%w: %s", ErrTransactionInvalid, rpcErr.Message)
+	}
+	return err
+}
+
+// AbandonTransaction marks an unconfirmed transaction that is not in the
+// mempool as abandoned, releasing its inputs
+func (c *LitecoinWalletClient) AbandonTransaction(ctx context.Context, txHash string) error {
+	_, err := c.call(ctx, "abandontransaction", []interface{}{txHash})
+	return err
+}
+
+// BumpFee builds and signs a BIP125 replacement paying a higher fee without
+// broadcasting it, so the replacement's hash can be stored first
+func (c *LitecoinWalletClient) BumpFee(ctx context.Context, txHash string, confirmTarget int) (*SignedTransaction, error) {
+	options := map[string]interface{}{}
+	if confirmTarget > 0 {
+		options["conf_target"] = confirmTarget
+	}
+
+	result, err := c.call(ctx, "psbtbumpfee", []interface{}{txHash, options})
+	if err != nil {
+		return nil, err
+	}
+	var bumped struct {
+		PSBT string `json:"psbt"`
+	}
+	if err := json.Unmarshal(result, &bumped); err != nil {
+		return nil, fmt.Errorf("failed to parse psbtbumpfee result: %w", err)
+	}
+
+	result, err = c.call(ctx, "walletprocesspsbt", []interface{}{bumped.PSBT})
+	if err != nil {
+		return nil, err
+	}
+	var processed struct {
+		PSBT string `json:"psbt"`
+	}
+	if err := json.Unmarshal(result, &processed); err != nil {
+		return nil, fmt.Errorf("failed to parse signed replacement: %w", err)
+	}
+
+	result, err = c.call(ctx, "finalizepsbt", []interface{}{processed.PSBT})
+	if err != nil {
+		return nil, err
+	}
+	var final struct {
+		Hex      string `json:"hex"`
+		Complete bool   `json:"complete"`
+	}
+	if err := json.Unmarshal(result, &final); err != nil {
+		return nil, fmt.Errorf("failed to parse finalized replacement: %w", err)
+	}
+	if !final.Complete {
+		return nil, fmt.Errorf("failed to sign replacement")
+	}
+
+	return c.decodeSigned(ctx, final.Hex)
+}
+
+// Child-pays-for-parent sizing
+const (
+	cpfpChildVsize = 110   // Estimated vsize of a one-input, one-output child
+	cpfpDustLimit  = 10000 // Smallest child output worth creating, in litoshis
+)
+
+// BumpFeeWithChild spends the wallet's change from a stuck transaction in a
+// child paying enough for both to reach feeRate (litoshis per kB), and
+// returns the child's tx hash
+func (c *LitecoinWalletClient) BumpFeeWithChild(ctx context.Context, txHash string, feeRate int64) (string, error) {
+	result, err := c.call(ctx, "listunspent", []interface{}{0, 0})
+	if err != nil {
+		return "", err
+	}
+	var unspent []struct {
+		TxID      string  `json:"txid"`
+		Vout      int     `json:"vout"`
+		Amount    float64 `json:"amount"`
+		Spendable bool    `json:"spendable"`
+	}
+	if err := json.Unmarshal(result, &unspent); err != nil {
+		return "", fmt.Errorf("failed to parse unspent outputs: %w", err)
+	}
+
+	vout, value := -1, int64(0)
+	for _, u := range unspent {
+		if u.TxID == txHash && u.Spendable {
+			vout, value = u.Vout, int64(math.Round(u.Amount*100000000))
+			break
+		}
+	}
+	if vout < 0 {
+		return "", fmt.Errorf("transaction %s has no spendable wallet output", txHash)
+	}
+
+	result, err = c.call(ctx, "getmempoolentry", []interface{}{txHash})
+	if err != nil {
+		return "", err
+	}
+	var entry struct {
+		Vsize int64 `json:"vsize"`
+		Fees  struct {
+			Base float64 `json:"base"`
+		} `json:"fees"`
+	}
+	if err := json.Unmarshal(result, &entry); err != nil {
+		return "", fmt.Errorf("failed to parse mempool entry: %w", err)
+	}
+
+	parentFee := int64(math.Round(entry.Fees.Base * 100000000))
+	fee := feeRate*(entry.Vsize+cpfpChildVsize)/1000 - parentFee
+	if fee <= 0 {
+		return "", fmt.Errorf("transaction %s already pays the target fee", txHash)
+	}
+	if value-fee <= cpfpDustLimit {
+		return "", fmt.Errorf("change of %s is too small to bump its fee", txHash)
+	}
+
+	result, err = c.call(ctx, "getrawchangeaddress", []interface{}{})
+	if err != nil {
+		return "", err
+	}
+	var changeAddress string
+	if err := json.Unmarshal(result, &changeAddress); err != nil {
+		return "", fmt.Errorf("failed to parse change address: %w", err)
+	}
+
+	inputs := []map[string]interface{}{{"txid": txHash, "vout": vout}}
+	outputs := map[string]float64{changeAddress: float64(value-fee) / 100000000}
+	result, err = c.call(ctx, "createrawtransaction", []interface{}{inputs, outputs})
+	if err != nil {
+		return "", err
+	}
+	var raw string
+	if err := json.Unmarshal(result, &raw); err != nil {
+		return "", fmt.Errorf("failed to parse raw transaction: %w", err)
+	}
+
+	result, err = c.call(ctx, "signrawtransactionwithwallet", []interface{}{raw})
+	if err != nil {
+		return "", err
+	}
+	var signed struct {
+		Hex      string `json:"hex"`
+		Complete bool   `json:"complete"`
+	}
+	if err := json.Unmarshal(result, &signed); err != nil {
+		return "", fmt.Errorf("failed to parse signed transaction: %w", err)
+	}
+	if !signed.Complete {
+		return "", fmt.Errorf("failed to sign child of %s", txHash)
+	}
+
+	result, err = c.call(ctx, "sendrawtransaction", []interface{}{signed.Hex})
+	if err != nil {
+		return "", err
+	}
+	var childHash string
+	if err := json.Unmarshal(result, &childHash); err != nil {
+		return "", fmt.Errorf("failed to parse tx hash: %w", err)
+	}
+	return childHash, nil
+}
+
+// GetBlockConfirmation reports a block's depth in the node's active chain
+func (c *LitecoinWalletClient) GetBlockConfirmation(ctx context.Context, hash string) (*BlockConfirmation, error) {
+	result, err := c.call(ctx, "getblockheader", []interface{}{hash})
+	if err != nil {
+		var rpcErr *RPCError
+		if errors.As(err, &rpcErr) && rpcErr.Code == rpcErrInvalidAddressOrKey {
+			return &BlockConfirmation{}, nil
+		}
+		return nil, err
+	}
+
+	var header struct {
+		Confirmations int64 `json:"confirmations"`
+		Height        int64 `json:"height"`
+	}
+	if err := json.Unmarshal(result, &header); err != nil {
+		return nil, fmt.Errorf("failed to parse block header: %w", err)
+	}
+
+	// Blocks outside the active chain report -1 confirmations
+	conf := &BlockConfirmation{
+		Known:         true,
+		InMainChain:   header.Confirmations >= 0,
+		Confirmations: header.Confirmations,
+	}
+	if !conf.InMainChain {
+		result, err := c.call(ctx, "getblockcount", []interface{}{})
+		if err != nil {
+			return nil, err
+		}
+		var tip int64
+		if err := json.Unmarshal(result, &tip); err != nil {
+			return nil, fmt.Errorf("failed to parse block count: %w", err)
+		}
+		conf.ForkDepth = tip - header.Height + 1
+	}
+	return conf, nil
+}
+
 // TransactionInfo holds transaction details
 type TransactionInfo struct {
-	TxID          string  `json:"txid"`
-	Amount        float64 `json:"amount"`
-	Fee           float64 `json:"fee"`
-	Confirmations int     `json:"confirmations"`
-	BlockHash     string  `json:"blockhash"`
-	BlockTime     int64   `json:"blocktime"`
-	Time          int64   `json:"time"`
+	TxID            string              `json:"txid"`
+	Amount          float64             `json:"amount"`
+	Fee             float64             `json:"fee"`
+	Confirmations   int                 `json:"confirmations"`
+	BlockHash       string              `json:"blockhash"`
+	BlockTime       int64               `json:"blocktime"`
+	Time            int64               `json:"time"`
+	WalletConflicts []string            `json:"walletconflicts"`
+	Details         []TransactionDetail `json:"details"`
+	Hex             string              `json:"hex"`
+}
+
+// TransactionDetail is one wallet-relevant output of a transaction
+type TransactionDetail struct {
+	Address  string  `json:"address"`
+	Category string  `json:"category"` // "send" or "receive"; change is not listed
+	Amount   float64 `json:"amount"`   // Negative for sends
 }
 
 // call makes an RPC call to the Litecoin node
@@ -308,3 +308,165 @@ func TestLitecoinWalletClient_UnlockWallet(t *testing.T) {
 		assert.NoError(t, err)
 	})
 }
+
+func TestLitecoinWalletClient_GetBlockConfirmation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&req)
+
+		response := map[string]interface{}{"id": req["id"]}
+		if req["method"] == "getblockcount" {
+			response["result"] = 1005
+			json.NewEncoder(w).Encode(response)
+			return
+		}
+		switch req["params"].([]interface{})[0] {
+		case "main":
+			response["result"] = map[string]interface{}{"confirmations": 42, "height": 964}
+		case "stale":
+			response["result"] = map[string]interface{}{"confirmations": -1, "height": 1000}
+		default:
+			response["error"] = map[string]interface{}{"code": -5, "message": "Block not found"}
+		}
+		json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+
+	client, err := NewLitecoinWalletClient(WalletConfig{RPCURL: server.URL})
+	require.NoError(t, err)
+
+	conf, err := client.GetBlockConfirmation(context.Background(), "main")
+	require.NoError(t, err)
+	assert.Equal(t, &BlockConfirmation{Known: true, InMainChain: true, Confirmations: 42}, conf)
+
+	conf, err = client.GetBlockConfirmation(context.Background(), "stale")
+	require.NoError(t, err)
+	assert.True(t, conf.Known)
+	assert.False(t, conf.InMainChain)
+	assert.Equal(t, int64(6), conf.ForkDepth, "blocks 1000 to 1005 of the active chain")
+
+	conf, err = client.GetBlockConfirmation(context.Background(), "unknown")
+	require.NoError(t, err)
+	assert.False(t, conf.Known)
+}
+
+func TestLitecoinWalletClient_SendMany(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&req)
+		params := req["params"].([]interface{})
+
+		response := map[string]interface{}{"id": req["id"]}
+		switch req["method"] {
+		case "sendmany":
+			amounts := params[1].(map[string]interface{})
+			assert.InDelta(t, 0.01, amounts["ltc1qgsm3fv44wprdcsh3trgarm05rr7l8ryggujr5w"], 0.00000001)
+			assert.InDelta(t, 0.025, amounts["LVg2kJoFNg45Nbpy53h7Fe1wKyeXVRhMH9"], 0.00000001)
+			assert.Equal(t, "batch-1", params[3])
+			assert.Equal(t, true, params[5])
+			assert.Equal(t, float64(6), params[6])
+			response["result"] = "batchtx"
+		}
+		json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+
+	client, err := NewLitecoinWalletClient(WalletConfig{RPCURL: server.URL})
+	require.NoError(t, err)
+
+	txHash, err := client.SendMany(context.Background(), map[string]int64{
+		"ltc1qgsm3fv44wprdcsh3trgarm05rr7l8ryggujr5w": 1000000,
+		"LVg2kJoFNg45Nbpy53h7Fe1wKyeXVRhMH9":          2500000,
+	}, SendManyOptions{Comment: "batch-1", ConfirmTarget: 6, Replaceable: true})
+	require.NoError(t, err)
+	assert.Equal(t, "batchtx", txHash)
+
+	_, err = client.SendMany(context.Background(), map[string]int64{"bogus": 1}, SendManyOptions{})
+	assert.Error(t, err)
+}
+
+func TestLitecoinWalletClient_CreateTransaction(t *testing.T) {
+	var methods []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&req)
+		params := req["params"].([]interface{})
+		methods = append(methods, req["method"].(string))
+
+		response := map[string]interface{}{"id": req["id"]}
+		switch req["method"] {
+		case "createrawtransaction":
+			amounts := params[1].(map[string]interface{})
+			assert.InDelta(t, 0.01, amounts["ltc1qgsm3fv44wprdcsh3trgarm05rr7l8ryggujr5w"], 0.00000001)
+			response["result"] = "rawtx"
+		case "fundrawtransaction":
+			assert.Equal(t, "rawtx", params[0])
+			assert.Equal(t, map[string]interface{}{"replaceable": true, "conf_target": float64(6)}, params[1])
+			response["result"] = map[string]interface{}{"hex": "fundedtx", "fee": 0.0001}
+		case "signrawtransactionwithwallet":
+			assert.Equal(t, "fundedtx", params[0])
+			response["result"] = map[string]interface{}{"hex": "signedtx", "complete": true}
+		case "decoderawtransaction":
+			response["result"] = map[string]interface{}{"txid": "batchtx"}
+		case "sendrawtransaction":
+			if params[0] == "minedtx" {
+				response["error"] = map[string]interface{}{"code": -27, "message": "Transaction already in block chain"}
+				break
+			}
+			response["result"] = "batchtx"
+		}
+		json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+
+	client, err := NewLitecoinWalletClient(WalletConfig{RPCURL: server.URL})
+	require.NoError(t, err)
+
+	signed, err := client.CreateTransaction(context.Background(), map[string]int64{
+		"ltc1qgsm3fv44wprdcsh3trgarm05rr7l8ryggujr5w": 1000000,
+	}, TransactionOptions{ConfirmTarget: 6, Replaceable: true})
+	require.NoError(t, err)
+	assert.Equal(t, &SignedTransaction{TxID: "batchtx", Hex: "signedtx", Fee: 10000}, signed)
+	assert.Equal(t, []string{"createrawtransaction", "fundrawtransaction", "signrawtransactionwithwallet", "decoderawtransaction"}, methods,
+		"nothing is broadcast")
+
+	require.NoError(t, client.BroadcastTransaction(context.Background(), *signed))
+	assert.NoError(t, client.BroadcastTransaction(context.Background(), SignedTransaction{TxID: "minedtx", Hex: "minedtx"}),
+		"already in the chain counts as broadcast")
+}
+
+func TestLitecoinWalletClient_BumpFee(t *testing.T) {
+	var methods []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&req)
+		params := req["params"].([]interface{})
+		methods = append(methods, req["method"].(string))
+
+		response := map[string]interface{}{"id": req["id"]}
+		switch req["method"] {
+		case "psbtbumpfee":
+			assert.Equal(t, "stucktx", params[0])
+			assert.Equal(t, map[string]interface{}{"conf_target": float64(2)}, params[1])
+			response["result"] = map[string]interface{}{"psbt": "unsigned"}
+		case "walletprocesspsbt":
+			assert.Equal(t, "unsigned", params[0])
+			response["result"] = map[string]interface{}{"psbt": "signed", "complete": true}
+		case "finalizepsbt":
+			assert.Equal(t, "signed", params[0])
+			response["result"] = map[string]interface{}{"hex": "replacementhex", "complete": true}
+		case "decoderawtransaction":
+			response["result"] = map[string]interface{}{"txid": "replacementtx"}
+		}
+		json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+
+	client, err := NewLitecoinWalletClient(WalletConfig{RPCURL: server.URL})
+	require.NoError(t, err)
+
+	replacement, err := client.BumpFee(context.Background(), "stucktx", 2)
+	require.NoError(t, err)
+	assert.Equal(t, &SignedTransaction{TxID: "replacementtx", Hex: "replacementhex"}, replacement)
+	assert.NotContains(t, methods, "sendrawtransaction", "nothing is broadcast")
+}
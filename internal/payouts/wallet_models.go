@@ -1,6 +1,7 @@
 package payouts
 
 import (
+	"math/big"
 	"time"
 )
 
@@ -51,10 +52,11 @@ type PayoutSplit struct {
 	Amount     int64   `json:"amount"` // Amount in smallest unit (satoshi-like)
 }
 
-// CalculatePayoutSplits calculates how to split a payout amount across wallets
+// CalculatePayoutSplits calculates how to split a payout amount across
+// wallets in proportion to their percentages. Litoshis lost to rounding go
+// to the largest remainders, the same way the payout processor splits them.
 func CalculatePayoutSplits(wallets []UserWallet, totalAmount int64) []PayoutSplit {
 	var splits []PayoutSplit
-	var totalAllocated int64 = 0
 
 	activeWallets := make([]UserWallet, 0)
 	for _, w := range wallets {
@@ -67,22 +69,16 @@ func CalculatePayoutSplits(wallets []UserWallet, totalAmount int64) []PayoutSpli
 		return splits
 	}
 
-	// Calculate split for each wallet
+	weights := make([]*big.Rat, len(activeWallets))
 	for i, wallet := range activeWallets {
-		var amount int64
-
-		// Last wallet gets the remainder to avoid rounding issues
-		if i == len(activeWallets)-1 {
-			amount = totalAmount - totalAllocated
-		} else {
-			amount = int64(float64(totalAmount) * (wallet.Percentage / 100.0))
-			totalAllocated += amount
-		}
+		weights[i] = big.NewRat(max(basisPoints(wallet.Percentage), 0), 1)
+	}
 
+	for i, amount := range apportion(totalAmount, weights) {
 		splits = append(splits, PayoutSplit{
-			WalletID:   wallet.ID,
-			Address:    wallet.Address,
-			Percentage: wallet.Percentage,
+			WalletID:   activeWallets[i].ID,
+			Address:    activeWallets[i].Address,
+			Percentage: activeWallets[i].Percentage,
 			Amount:     amount,
 		})
 	}
@@ -0,0 +1,270 @@
+package shares
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/chimera-pool/chimera-pool-core/internal/stratum/blockdag"
+	"golang.org/x/crypto/blake2s"
+	"golang.org/x/crypto/scrypt"
+)
+
+// =============================================================================
+// PROOF-OF-WORK ALGORITHM REGISTRY
+// Real hash implementations selected by the active network's algorithm name
+// =============================================================================
+
+// Algorithm names as stored in the network configuration
+const (
+	AlgorithmScrypt        = blockdag.ScryptAlgorithmName // Litecoin
+	AlgorithmScrypyVariant = blockdag.AlgorithmName       // BlockDAG X30/X100
+	AlgorithmSHA256d       = "sha256d"
+	AlgorithmBlake2s       = "blake2s"
+
+	// DefaultAlgorithm is used when no network algorithm is configured
+	DefaultAlgorithm = AlgorithmScrypt
+)
+
+// ErrUnknownAlgorithm is returned for algorithm names that are not registered
+var ErrUnknownAlgorithm = errors.New("unknown hash algorithm")
+
+// HashAlgorithm is a proof-of-work hash function. It has the same method set
+// as stratum.HashAlgorithm, which cannot be imported here without a cycle, so
+// implementations satisfy both.
+//
+// Hash returns the digest as a big-endian number, the byte order block
+// explorers display and targets are compared in.
+type HashAlgorithm interface {
+	Name() string
+	Hash(data []byte) []byte
+	ValidateHash(hash, target []byte) bool
+}
+
+// Algorithm pairs a hash function with the target of a difficulty-1 share
+type Algorithm struct {
+	HashAlgorithm
+	Diff1Target *big.Int
+}
+
+// Target converts a share difficulty to a 32-byte big-endian target.
+// Difficulties easier than the maximum target saturate at 2^256-1.
+func (a *Algorithm) Target(difficulty float64) []byte {
+	if difficulty <= 0 {
+		return []byte{}
+	}
+
+	target := make([]byte, blockdag.TargetSize)
+	value, _ := new(big.Float).Quo(new(big.Float).SetInt(a.Diff1Target), big.NewFloat(difficulty)).Int(nil)
+	if value.BitLen() > blockdag.TargetSize*8 {
+		for i := range target {
+			target[i] = 0xFF
+		}
+		return target
+	}
+	value.FillBytes(target)
+	return target
+}
+
+// SHA256Diff1Target is the Bitcoin difficulty-1 target, 0x00000000ffff << 208
+var SHA256Diff1Target = func() *big.Int {
+	t, _ := new(big.Int).SetString("00000000ffff0000000000000000000000000000000000000000000000000000", 16)
+	return t
+}()
+
+// -----------------------------------------------------------------------------
+// Registry
+// -----------------------------------------------------------------------------
+
+// AlgorithmRegistry maps network algorithm names to implementations
+type AlgorithmRegistry struct {
+	algorithms map[string]*Algorithm
+	aliases    map[string]string
+	mu         sync.RWMutex
+}
+
+// NewAlgorithmRegistry creates an empty registry
+func NewAlgorithmRegistry() *AlgorithmRegistry {
+	return &AlgorithmRegistry{
+		algorithms: make(map[string]*Algorithm),
+		aliases:    make(map[string]string),
+	}
+}
+
+// NewDefaultAlgorithmRegistry creates a registry with every supported algorithm
+func NewDefaultAlgorithmRegistry() *AlgorithmRegistry {
+	r := NewAlgorithmRegistry()
+	r.Register(NewScryptAlgorithm(), blockdag.ScryptDiff1Target)
+	r.Register(NewScrypyVariantAlgorithm(), SHA256Diff1Target)
+	r.Register(NewSHA256dAlgorithm(), SHA256Diff1Target, "sha256")
+	r.Register(NewBlake2sAlgorithm(), SHA256Diff1Target, "blake2s-256", "blake2s256")
+	return r
+}
+
+var defaultRegistry = NewDefaultAlgorithmRegistry()
+
+// DefaultAlgorithmRegistry returns the shared registry of supported algorithms
+func DefaultAlgorithmRegistry() *AlgorithmRegistry {
+	return defaultRegistry
+}
+
+// Register adds an algorithm under its name and any aliases
+func (r *AlgorithmRegistry) Register(hasher HashAlgorithm, diff1Target *big.Int, aliases ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	name := strings.ToLower(hasher.Name())
+	r.algorithms[name] = &Algorithm{HashAlgorithm: hasher, Diff1Target: diff1Target}
+	for _, alias := range aliases {
+		r.aliases[strings.ToLower(alias)] = name
+	}
+}
+
+// Get returns the algorithm registered under a name or alias
+func (r *AlgorithmRegistry) Get(name string) (*Algorithm, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	key := strings.ToLower(strings.TrimSpace(name))
+	if canonical, ok := r.aliases[key]; ok {
+		key = canonical
+	}
+	algorithm, ok := r.algorithms[key]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownAlgorithm, name)
+	}
+	return algorithm, nil
+}
+
+// Names returns the registered algorithm names, sorted
+func (r *AlgorithmRegistry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.algorithms))
+	for name := range r.algorithms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// -----------------------------------------------------------------------------
+// Implementations
+// -----------------------------------------------------------------------------
+
+// ScryptAlgorithm is Litecoin's scrypt(N=1024, r=1, p=1) with the input as
+// both password and salt
+type ScryptAlgorithm struct{}
+
+// NewScryptAlgorithm creates the Litecoin scrypt algorithm
+func NewScryptAlgorithm() *ScryptAlgorithm {
+	return &ScryptAlgorithm{}
+}
+
+// Name returns the algorithm name
+func (a *ScryptAlgorithm) Name() string {
+	return AlgorithmScrypt
+}
+
+// Hash computes the scrypt proof-of-work hash
+func (a *ScryptAlgorithm) Hash(data []byte) []byte {
+	hash, err := scrypt.Key(data, data, blockdag.ScryptN, blockdag.ScryptR, blockdag.ScryptP, blockdag.KeyLen)
+	if err != nil {
+		// Only reachable with invalid parameters, which are constants here
+		return nil
+	}
+	blockdag.ReverseBytes(hash)
+	return hash
+}
+
+// ValidateHash reports whether the hash is at or below the target
+func (a *ScryptAlgorithm) ValidateHash(hash, target []byte) bool {
+	return blockdag.HashMeetsTarget(hash, target)
+}
+
+// ScrypyVariantAlgorithm adapts the BlockDAG Scrpy-variant to HashAlgorithm
+type ScrypyVariantAlgorithm struct {
+	variant *blockdag.ScrypyVariant
+}
+
+// NewScrypyVariantAlgorithm creates the BlockDAG Scrpy-variant algorithm
+func NewScrypyVariantAlgorithm() *ScrypyVariantAlgorithm {
+	return &ScrypyVariantAlgorithm{variant: blockdag.NewScrypyVariant()}
+}
+
+// Name returns the algorithm name
+func (a *ScrypyVariantAlgorithm) Name() string {
+	return a.variant.Name()
+}
+
+// Hash computes the Scrpy-variant hash; empty input has no hash
+func (a *ScrypyVariantAlgorithm) Hash(data []byte) []byte {
+	hash, err := a.variant.Hash(data)
+	if err != nil {
+		return nil
+	}
+	return hash
+}
+
+// ValidateHash reports whether the hash is at or below the target
+func (a *ScrypyVariantAlgorithm) ValidateHash(hash, target []byte) bool {
+	return a.variant.ValidateHash(hash, target)
+}
+
+// SHA256dAlgorithm is Bitcoin's double SHA-256
+type SHA256dAlgorithm struct{}
+
+// NewSHA256dAlgorithm creates the double SHA-256 algorithm
+func NewSHA256dAlgorithm() *SHA256dAlgorithm {
+	return &SHA256dAlgorithm{}
+}
+
+// Name returns the algorithm name
+func (a *SHA256dAlgorithm) Name() string {
+	return AlgorithmSHA256d
+}
+
+// Hash computes SHA-256(SHA-256(data))
+func (a *SHA256dAlgorithm) Hash(data []byte) []byte {
+	first := sha256.Sum256(data)
+	second := sha256.Sum256(first[:])
+	hash := second[:]
+	blockdag.ReverseBytes(hash)
+	return hash
+}
+
+// ValidateHash reports whether the hash is at or below the target
+func (a *SHA256dAlgorithm) ValidateHash(hash, target []byte) bool {
+	return blockdag.HashMeetsTarget(hash, target)
+}
+
+// Blake2sAlgorithm is unkeyed BLAKE2s-256 (RFC 7693)
+type Blake2sAlgorithm struct{}
+
+// NewBlake2sAlgorithm creates the BLAKE2s-256 algorithm
+func NewBlake2sAlgorithm() *Blake2sAlgorithm {
+	return &Blake2sAlgorithm{}
+}
+
+// Name returns the algorithm name
+func (a *Blake2sAlgorithm) Name() string {
+	return AlgorithmBlake2s
+}
+
+// Hash computes BLAKE2s-256(data)
+func (a *Blake2sAlgorithm) Hash(data []byte) []byte {
+	sum := blake2s.Sum256(data)
+	hash := sum[:]
+	blockdag.ReverseBytes(hash)
+	return hash
+}
+
+// ValidateHash reports whether the hash is at or below the target
+func (a *Blake2sAlgorithm) ValidateHash(hash, target []byte) bool {
+	return blockdag.HashMeetsTarget(hash, target)
+}
@@ -0,0 +1,131 @@
+package shares
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"testing"
+
+	"github.com/chimera-pool/chimera-pool-core/internal/stratum/blockdag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// KNOWN-ANSWER TESTS FOR REGISTERED ALGORITHMS
+// =============================================================================
+
+const (
+	// Bitcoin genesis block header
+	bitcoinGenesisHeader = "0100000000000000000000000000000000000000000000000000000000000000000000003ba3edfd7a7b12b27ac72c3e67768f617fc81bc3888a51323a9fb8aa4b1e5e4a29ab5f49ffff001d1dac2b7c"
+
+	// Litecoin block header from litecoin/src/test/scrypt_tests.cpp
+	litecoinTestHeader = "020000004c1271c211717198227392b029a64a7971931d351b387bb80db027f270411e398a07046f7d4a08dd815412a8712f874a7ebf0507e3878bd24e20a3b73fd750a667d2f451eac7471b00de6659"
+)
+
+func decodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	require.NoError(t, err)
+	return b
+}
+
+func TestAlgorithms_KnownAnswers(t *testing.T) {
+	tests := []struct {
+		algorithm string
+		input     []byte
+		expected  string
+	}{
+		{
+			algorithm: AlgorithmScrypt,
+			input:     decodeHex(t, litecoinTestHeader),
+			expected:  "00000000002bef4107f882f6115e0b01f348d21195dacd3582aa2dabd7985806",
+		},
+		{
+			algorithm: AlgorithmSHA256d,
+			input:     decodeHex(t, bitcoinGenesisHeader),
+			expected:  "000000000019d6689c085ae165831e934ff763ae46a2a6c172b3f1b60a8ce26f",
+		},
+		{
+			// RFC 7693 Appendix B, 508c5e8c...675982 in digest byte order
+			algorithm: AlgorithmBlake2s,
+			input:     []byte("abc"),
+			expected:  "825967864c9b994d293ad69e208b45372f45eb4ea32ba7e1e2147c328c5e8c50",
+		},
+		{
+			// No public vectors exist; pinned from internal/stratum/blockdag
+			algorithm: AlgorithmScrypyVariant,
+			input:     decodeHex(t, bitcoinGenesisHeader),
+			expected:  "38980776393f44cd895c756de703274002e6e83c402b302b23b36791b26993d8",
+		},
+	}
+
+	registry := NewDefaultAlgorithmRegistry()
+	for _, tt := range tests {
+		t.Run(tt.algorithm, func(t *testing.T) {
+			algorithm, err := registry.Get(tt.algorithm)
+			require.NoError(t, err)
+			assert.Equal(t, tt.algorithm, algorithm.Name())
+			assert.Equal(t, tt.expected, hex.EncodeToString(algorithm.Hash(tt.input)))
+		})
+	}
+}
+
+func TestAlgorithms_MeetNetworkTarget(t *testing.T) {
+	for name, header := range map[string]string{
+		AlgorithmSHA256d: bitcoinGenesisHeader,
+		AlgorithmScrypt:  litecoinTestHeader,
+	} {
+		t.Run(name, func(t *testing.T) {
+			algorithm, err := DefaultAlgorithmRegistry().Get(name)
+			require.NoError(t, err)
+
+			input := decodeHex(t, header)
+			target := blockdag.CompactToTarget(binary.LittleEndian.Uint32(input[72:76]))
+			assert.True(t, algorithm.ValidateHash(algorithm.Hash(input), target))
+
+			// Changing the nonce breaks the proof of work
+			input[76]++
+			assert.False(t, algorithm.ValidateHash(algorithm.Hash(input), target))
+		})
+	}
+}
+
+func TestAlgorithmRegistry(t *testing.T) {
+	registry := NewDefaultAlgorithmRegistry()
+
+	t.Run("lists supported algorithms", func(t *testing.T) {
+		assert.Equal(t, []string{"blake2s", "scrpy-variant", "scrypt", "sha256d"}, registry.Names())
+	})
+
+	t.Run("resolves network config aliases", func(t *testing.T) {
+		for alias, name := range map[string]string{
+			"sha256":      AlgorithmSHA256d,
+			"SHA256D":     AlgorithmSHA256d,
+			"blake2s-256": AlgorithmBlake2s,
+			" Scrypt ":    AlgorithmScrypt,
+		} {
+			algorithm, err := registry.Get(alias)
+			require.NoError(t, err, alias)
+			assert.Equal(t, name, algorithm.Name())
+		}
+	})
+
+	t.Run("rejects unknown algorithms", func(t *testing.T) {
+		_, err := registry.Get("kawpow")
+		assert.ErrorIs(t, err, ErrUnknownAlgorithm)
+	})
+}
+
+func BenchmarkAlgorithms_Hash(b *testing.B) {
+	header := make([]byte, blockdag.HeaderSize)
+	registry := NewDefaultAlgorithmRegistry()
+
+	for _, name := range registry.Names() {
+		algorithm, _ := registry.Get(name)
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				algorithm.Hash(header)
+			}
+		})
+	}
+}
@@ -24,6 +24,9 @@ type BatchConfig struct {
 
 	// Rate limiting
 	MaxSharesPerSecond int64 // Global rate limit (0 = unlimited)
+
+	// Proof-of-work algorithm of the active network (default: DefaultAlgorithm)
+	Algorithm string
 }
 
 // DefaultBatchConfig returns production-ready defaults
@@ -102,9 +105,12 @@ func NewBatchProcessor(config BatchConfig) *BatchProcessor {
 
 	ctx, cancel := context.WithCancel(context.Background())
 
+	// An unknown algorithm leaves a processor that rejects every share
+	processor, _ := NewShareProcessorForAlgorithm(config.Algorithm)
+
 	bp := &BatchProcessor{
 		config:    config,
-		processor: NewShareProcessor(),
+		processor: processor,
 		inputChan: make(chan *shareJob, config.QueueSize),
 		ctx:       ctx,
 		cancel:    cancel,
@@ -245,6 +251,11 @@ func (bp *BatchProcessor) GetQueueDepth() int {
 	return len(bp.inputChan)
 }
 
+// UseAlgorithm switches share validation to another network algorithm
+func (bp *BatchProcessor) UseAlgorithm(name string) error {
+	return bp.processor.UseAlgorithm(name)
+}
+
 // Worker implementation
 func (w *shareWorker) run(ctx context.Context, wg *sync.WaitGroup) {
 	defer wg.Done()
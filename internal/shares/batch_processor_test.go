@@ -42,6 +42,7 @@ func TestBatchProcessor_HighThroughput(t *testing.T) {
 		QueueSize:    50000,
 		BatchSize:    100,
 		BatchTimeout: 10 * time.Millisecond,
+		Algorithm:    AlgorithmSHA256d, // Measures batching, not scrypt
 	}
 
 	bp := NewBatchProcessor(config)
@@ -190,6 +191,7 @@ func TestBatchProcessor_GracefulShutdown(t *testing.T) {
 		QueueSize:    1000,
 		BatchSize:    10,
 		BatchTimeout: 5 * time.Millisecond,
+		Algorithm:    AlgorithmSHA256d,
 	}
 	bp := NewBatchProcessor(config)
 	bp.Start()
@@ -221,6 +223,25 @@ func TestBatchProcessor_GracefulShutdown(t *testing.T) {
 	assert.GreaterOrEqual(t, stats.TotalProcessed, int64(90))
 }
 
+func TestBatchProcessor_Algorithm(t *testing.T) {
+	config := DefaultBatchConfig()
+	config.Algorithm = "kawpow"
+	bp := NewBatchProcessor(config)
+	bp.Start()
+	defer bp.Stop()
+
+	share := &Share{MinerID: 1, UserID: 1, JobID: "algo-test", Nonce: "01", Difficulty: 1e-12, Timestamp: time.Now()}
+
+	// Unknown network algorithms reject every share
+	result := bp.SubmitSync(share, time.Second)
+	assert.False(t, result.Success)
+	assert.Contains(t, result.Error, "unknown hash algorithm")
+
+	assert.NoError(t, bp.UseAlgorithm("sha256"))
+	result = bp.SubmitSync(share, time.Second)
+	assert.True(t, result.Success, result.Error)
+}
+
 func TestBatchProcessor_Statistics(t *testing.T) {
 	config := DefaultBatchConfig()
 	bp := NewBatchProcessor(config)
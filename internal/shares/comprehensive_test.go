@@ -1,6 +1,8 @@
 package shares
 
 import (
+	"bytes"
+	"encoding/hex"
 	"sync"
 	"testing"
 	"time"
@@ -21,109 +23,10 @@ import (
 func TestNewShareProcessor(t *testing.T) {
 	sp := NewShareProcessor()
 	require.NotNil(t, sp)
-	assert.NotNil(t, sp.algorithm)
+	assert.NotNil(t, sp.algorithm.Load())
 	assert.NotNil(t, sp.minerStats)
 }
 
-// -----------------------------------------------------------------------------
-// Blake2S Hasher Tests
-// -----------------------------------------------------------------------------
-
-func TestDefaultBlake2SHasher_Hash(t *testing.T) {
-	h := &DefaultBlake2SHasher{}
-
-	input := []byte("test input data")
-	hash, err := h.Hash(input)
-
-	require.NoError(t, err)
-	assert.Equal(t, 32, len(hash)) // Blake2S-256 produces 32 bytes
-}
-
-func TestDefaultBlake2SHasher_Hash_Empty(t *testing.T) {
-	h := &DefaultBlake2SHasher{}
-
-	hash, err := h.Hash([]byte{})
-
-	require.NoError(t, err)
-	assert.Equal(t, 32, len(hash))
-}
-
-func TestDefaultBlake2SHasher_Hash_Large(t *testing.T) {
-	h := &DefaultBlake2SHasher{}
-
-	// Large input
-	input := make([]byte, 10000)
-	for i := range input {
-		input[i] = byte(i % 256)
-	}
-
-	hash, err := h.Hash(input)
-
-	require.NoError(t, err)
-	assert.Equal(t, 32, len(hash))
-}
-
-func TestDefaultBlake2SHasher_Hash_Deterministic(t *testing.T) {
-	h := &DefaultBlake2SHasher{}
-
-	input := []byte("deterministic test")
-	hash1, _ := h.Hash(input)
-	hash2, _ := h.Hash(input)
-
-	assert.Equal(t, hash1, hash2) // Same input should produce same hash
-}
-
-func TestDefaultBlake2SHasher_Hash_Different(t *testing.T) {
-	h := &DefaultBlake2SHasher{}
-
-	// Use longer, more distinct inputs to ensure different hashes
-	hash1, _ := h.Hash([]byte("this is a longer input string number one for testing"))
-	hash2, _ := h.Hash([]byte("this is a completely different input string for hash two"))
-
-	// The simulated Blake2S should produce different hashes for different inputs
-	// Note: This is a simplified implementation, so we just verify both produce valid hashes
-	assert.Equal(t, 32, len(hash1))
-	assert.Equal(t, 32, len(hash2))
-}
-
-func TestDefaultBlake2SHasher_Verify_EmptyTarget(t *testing.T) {
-	h := &DefaultBlake2SHasher{}
-
-	valid, err := h.Verify([]byte("input"), []byte{}, 12345)
-
-	require.NoError(t, err)
-	assert.False(t, valid) // Empty target should always fail
-}
-
-func TestDefaultBlake2SHasher_Verify_LargeTarget(t *testing.T) {
-	h := &DefaultBlake2SHasher{}
-
-	// Very large target (easy difficulty)
-	target := make([]byte, 32)
-	for i := range target {
-		target[i] = 0xFF
-	}
-
-	valid, err := h.Verify([]byte("input"), target, 12345)
-
-	require.NoError(t, err)
-	// With max target, hash should be valid (easy difficulty)
-	assert.True(t, valid)
-}
-
-func TestDefaultBlake2SHasher_Verify_SmallTarget(t *testing.T) {
-	h := &DefaultBlake2SHasher{}
-
-	// Very small target (impossible difficulty)
-	target := make([]byte, 32)
-	target[31] = 0x01 // Only last byte is 1, rest are 0
-
-	valid, err := h.Verify([]byte("input"), target, 12345)
-
-	require.NoError(t, err)
-	assert.False(t, valid) // Should fail with such a small target
-}
-
 // -----------------------------------------------------------------------------
 // Share Validation Tests
 // -----------------------------------------------------------------------------
@@ -427,10 +330,10 @@ func TestShareProcessor_DifficultyToTarget_Negative(t *testing.T) {
 func TestShareProcessor_DifficultyToTarget_VeryHigh(t *testing.T) {
 	sp := NewShareProcessor()
 
-	target := sp.difficultyToTarget(2000000) // > 1000000
+	target := sp.difficultyToTarget(2000000)
 
 	assert.Equal(t, 32, len(target))
-	assert.Equal(t, byte(0x01), target[31]) // Very small target
+	assert.Equal(t, -1, bytes.Compare(target, sp.difficultyToTarget(1.0)))
 }
 
 func TestShareProcessor_DifficultyToTarget_One(t *testing.T) {
@@ -438,25 +341,37 @@ func TestShareProcessor_DifficultyToTarget_One(t *testing.T) {
 
 	target := sp.difficultyToTarget(1.0)
 
+	// Scrypt difficulty 1 is 0x0000ffff followed by zeros
 	assert.Equal(t, 32, len(target))
-	assert.Equal(t, byte(0x80), target[0]) // First byte for 50% chance
+	assert.Equal(t, "0000ffff", hex.EncodeToString(target[:4]))
+	assert.Equal(t, make([]byte, 28), target[4:])
 }
 
 func TestShareProcessor_DifficultyToTarget_Medium(t *testing.T) {
 	sp := NewShareProcessor()
 
-	target := sp.difficultyToTarget(5.0) // Between 1 and 10
+	target := sp.difficultyToTarget(4.0)
 
 	assert.Equal(t, 32, len(target))
+	assert.Equal(t, "00003fffc0", hex.EncodeToString(target[:5]))
 }
 
-func TestShareProcessor_DifficultyToTarget_High(t *testing.T) {
+func TestShareProcessor_DifficultyToTarget_Fractional(t *testing.T) {
 	sp := NewShareProcessor()
 
-	target := sp.difficultyToTarget(50.0) // > 10
+	// Easier than the maximum target saturates
+	target := sp.difficultyToTarget(1e-9)
 
-	assert.Equal(t, 32, len(target))
-	assert.Equal(t, byte(0x01), target[0]) // Harder target
+	assert.Equal(t, bytes.Repeat([]byte{0xFF}, 32), target)
+}
+
+func TestShareProcessor_DifficultyToTarget_PerAlgorithm(t *testing.T) {
+	sp, err := NewShareProcessorForAlgorithm("sha256")
+	require.NoError(t, err)
+
+	target := sp.difficultyToTarget(1.0)
+
+	assert.Equal(t, "00000000ffff", hex.EncodeToString(target[:6]))
 }
 
 // -----------------------------------------------------------------------------
@@ -599,16 +514,6 @@ func BenchmarkShareProcessor_ProcessShare(b *testing.B) {
 	}
 }
 
-func BenchmarkBlake2SHasher_Hash(b *testing.B) {
-	h := &DefaultBlake2SHasher{}
-	input := []byte("benchmark input data for hashing")
-
-	b.ResetTimer()
-	for i := 0; i < b.N; i++ {
-		h.Hash(input)
-	}
-}
-
 func BenchmarkShareProcessor_DifficultyToTarget(b *testing.B) {
 	sp := NewShareProcessor()
 
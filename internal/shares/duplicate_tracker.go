@@ -0,0 +1,125 @@
+package shares
+
+import (
+	"errors"
+	"sync"
+)
+
+// =============================================================================
+// PER-JOB DUPLICATE SHARE TRACKER
+// Remembers submitted share tuples per job and miner so replayed work can be
+// rejected. Memory is bounded per miner on each job and released when a job
+// is retired. Keys are never evicted while their job is live, so a share
+// can't be replayed by pushing it out with other submissions.
+// =============================================================================
+
+// DefaultMaxSharesPerMiner bounds how many share keys are remembered for one
+// miner on one job
+const DefaultMaxSharesPerMiner = 100000
+
+// Duplicate tracker errors
+var (
+	ErrDuplicateShare    = errors.New("duplicate share")
+	ErrShareLimitReached = errors.New("share limit for job reached")
+)
+
+// DuplicateTracker detects duplicate share submissions per job
+type DuplicateTracker struct {
+	maxPerMiner int
+	jobs        map[string]map[string]map[string]struct{} // job -> miner -> share keys
+	mu          sync.Mutex
+}
+
+// NewDuplicateTracker creates a tracker that remembers up to maxPerMiner
+// shares per miner on each job
+func NewDuplicateTracker(maxPerMiner int) *DuplicateTracker {
+	if maxPerMiner <= 0 {
+		maxPerMiner = DefaultMaxSharesPerMiner
+	}
+	return &DuplicateTracker{
+		maxPerMiner: maxPerMiner,
+		jobs:        make(map[string]map[string]map[string]struct{}),
+	}
+}
+
+// CheckAndRecord records a miner's share key for a job. It returns
+// ErrDuplicateShare if the key was already submitted, and
+// ErrShareLimitReached without recording it once the miner's set for the job
+// is full; the miner's next shares count again on the next job. miner is
+// whatever separates miners' work, such as the connection's extranonce1.
+func (t *DuplicateTracker) CheckAndRecord(jobID, miner, key string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	miners, ok := t.jobs[jobID]
+	if !ok {
+		miners = make(map[string]map[string]struct{})
+		t.jobs[jobID] = miners
+	}
+	seen, ok := miners[miner]
+	if !ok {
+		seen = make(map[string]struct{})
+		miners[miner] = seen
+	}
+
+	if _, exists := seen[key]; exists {
+		return ErrDuplicateShare
+	}
+	if len(seen) >= t.maxPerMiner {
+		return ErrShareLimitReached
+	}
+	seen[key] = struct{}{}
+	return nil
+}
+
+// Forget removes a share key recorded for a job, so a share that was not
+// given a verdict can be submitted again
+func (t *DuplicateTracker) Forget(jobID, miner, key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if seen, ok := t.jobs[jobID][miner]; ok {
+		delete(seen, key)
+	}
+}
+
+// RemoveJob forgets all shares recorded for a job
+func (t *DuplicateTracker) RemoveJob(jobID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.jobs, jobID)
+}
+
+// RetainJobs forgets every job that is not in the given list
+func (t *DuplicateTracker) RetainJobs(jobIDs ...string) {
+	keep := make(map[string]struct{}, len(jobIDs))
+	for _, id := range jobIDs {
+		keep[id] = struct{}{}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for id := range t.jobs {
+		if _, ok := keep[id]; !ok {
+			delete(t.jobs, id)
+		}
+	}
+}
+
+// JobCount returns the number of jobs currently tracked
+func (t *DuplicateTracker) JobCount() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.jobs)
+}
+
+// ShareCount returns the number of share keys remembered for a job
+func (t *DuplicateTracker) ShareCount(jobID string) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	count := 0
+	for _, seen := range t.jobs[jobID] {
+		count += len(seen)
+	}
+	return count
+}
@@ -0,0 +1,97 @@
+package shares
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDuplicateTracker_DetectsDuplicates(t *testing.T) {
+	tracker := NewDuplicateTracker(10)
+
+	assert.NoError(t, tracker.CheckAndRecord("job1", "m1", "en2:ntime:nonce"))
+	assert.ErrorIs(t, tracker.CheckAndRecord("job1", "m1", "en2:ntime:nonce"), ErrDuplicateShare)
+
+	// Same key on a different job or from a different miner is not a duplicate
+	assert.NoError(t, tracker.CheckAndRecord("job2", "m1", "en2:ntime:nonce"))
+	assert.NoError(t, tracker.CheckAndRecord("job1", "m2", "en2:ntime:nonce"))
+}
+
+func TestDuplicateTracker_BoundedPerMiner(t *testing.T) {
+	tracker := NewDuplicateTracker(3)
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, tracker.CheckAndRecord("job1", "m1", fmt.Sprintf("k%d", i)))
+	}
+
+	// A full set rejects new shares instead of forgetting old ones, so no
+	// key of a live job can be replayed
+	assert.ErrorIs(t, tracker.CheckAndRecord("job1", "m1", "k3"), ErrShareLimitReached)
+	assert.ErrorIs(t, tracker.CheckAndRecord("job1", "m1", "k0"), ErrDuplicateShare)
+	assert.Equal(t, 3, tracker.ShareCount("job1"))
+
+	// Other miners and jobs have their own bounds
+	assert.NoError(t, tracker.CheckAndRecord("job1", "m2", "k3"))
+	assert.NoError(t, tracker.CheckAndRecord("job2", "m1", "k3"))
+	assert.Equal(t, 4, tracker.ShareCount("job1"))
+}
+
+func TestDuplicateTracker_Forget(t *testing.T) {
+	tracker := NewDuplicateTracker(3)
+
+	assert.NoError(t, tracker.CheckAndRecord("job1", "m1", "k0"))
+	assert.NoError(t, tracker.CheckAndRecord("job1", "m1", "k1"))
+	tracker.Forget("job1", "m1", "k0")
+	tracker.Forget("job1", "m2", "k1")
+	tracker.Forget("job2", "m1", "k1")
+	assert.Equal(t, 1, tracker.ShareCount("job1"))
+
+	// The forgotten share can be submitted again, and is then remembered
+	assert.NoError(t, tracker.CheckAndRecord("job1", "m1", "k0"))
+	assert.ErrorIs(t, tracker.CheckAndRecord("job1", "m1", "k0"), ErrDuplicateShare)
+	assert.ErrorIs(t, tracker.CheckAndRecord("job1", "m1", "k1"), ErrDuplicateShare)
+}
+
+func TestDuplicateTracker_RemoveAndRetainJobs(t *testing.T) {
+	tracker := NewDuplicateTracker(0)
+
+	tracker.CheckAndRecord("job1", "m1", "a")
+	tracker.CheckAndRecord("job2", "m1", "a")
+	tracker.CheckAndRecord("job3", "m1", "a")
+	assert.Equal(t, 3, tracker.JobCount())
+
+	tracker.RemoveJob("job1")
+	assert.Equal(t, 2, tracker.JobCount())
+	assert.NoError(t, tracker.CheckAndRecord("job1", "m1", "a"), "removed job starts fresh")
+
+	tracker.RetainJobs("job3")
+	assert.Equal(t, 1, tracker.JobCount())
+	assert.Equal(t, 0, tracker.ShareCount("job2"))
+	assert.ErrorIs(t, tracker.CheckAndRecord("job3", "m1", "a"), ErrDuplicateShare)
+}
+
+func TestDuplicateTracker_Concurrent(t *testing.T) {
+	tracker := NewDuplicateTracker(0)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	accepted := 0
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				if tracker.CheckAndRecord("job1", "m1", fmt.Sprintf("k%d", i)) == nil {
+					mu.Lock()
+					accepted++
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 100, accepted, "each key is accepted exactly once")
+}
@@ -12,7 +12,7 @@ import (
 // TestShareProcessing_E2E tests the complete end-to-end share processing workflow
 func TestShareProcessing_E2E(t *testing.T) {
 	// Create a share processor
-	processor := NewShareProcessor()
+	processor := newTestShareProcessor()
 	
 	// Simulate a complete mining workflow
 	t.Run("complete_mining_workflow", func(t *testing.T) {
@@ -59,7 +59,7 @@ func TestShareProcessing_E2E(t *testing.T) {
 	
 	// Test multiple miners submitting shares
 	t.Run("multiple_miners_workflow", func(t *testing.T) {
-		processor := NewShareProcessor() // Fresh processor
+		processor := newTestShareProcessor() // Fresh processor
 		
 		// Simulate 3 miners submitting shares
 		miners := []struct {
@@ -144,9 +144,9 @@ func TestShareProcessing_E2E(t *testing.T) {
 		assert.True(t, stats.TotalShares >= 1, "Should have at least 1 total share")
 	})
 	
-	// Test Blake2S hash consistency
-	t.Run("blake2s_hash_consistency", func(t *testing.T) {
-		processor := NewShareProcessor()
+	// Test hash consistency
+	t.Run("hash_consistency", func(t *testing.T) {
+		processor := newTestShareProcessor()
 		
 		// Process the same share multiple times
 		share := &Share{
@@ -1,13 +1,17 @@
 package shares
 
 import (
+	"encoding/binary"
 	"encoding/hex"
 	"fmt"
-	"math/big"
+	"math"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/chimera-pool/chimera-pool-core/internal/stratum/blockdag"
 )
 
 // Share represents a mining share submission
@@ -24,8 +28,10 @@ type Share struct {
 
 	// Additional stratum fields
 	WorkerName string `json:"worker_name"`
+	Header     string `json:"header,omitempty"` // Hex block header; its nonce is replaced by Nonce
 	ExtraNonce string `json:"extra_nonce"`
 	NTime      string `json:"ntime"`
+	Solo       bool   `json:"solo,omitempty"` // Mined on a port that forces solo mode
 }
 
 // ShareValidationResult represents the result of share validation
@@ -61,10 +67,13 @@ type MinerStatistics struct {
 	LastShare       time.Time
 }
 
-// ShareProcessor handles share validation and processing using Blake2S algorithm
+// ShareProcessor handles share validation and processing using the
+// proof-of-work algorithm of the active network
 type ShareProcessor struct {
-	// Algorithm interface for hash validation
-	algorithm Blake2SHasher
+	// Algorithm used for hash validation; nil when the configured name is
+	// not registered, in which case every share is rejected
+	algorithm     atomic.Pointer[Algorithm]
+	algorithmName string
 
 	// Statistics tracking
 	stats      ShareStatistics
@@ -76,83 +85,30 @@ type ShareProcessor struct {
 	maxJobIDLength int
 }
 
-// Blake2SHasher interface for Blake2S hashing operations
-type Blake2SHasher interface {
-	Hash(input []byte) ([]byte, error)
-	Verify(input []byte, target []byte, nonce uint64) (bool, error)
+// NewShareProcessor creates a new share processor using DefaultAlgorithm
+func NewShareProcessor() *ShareProcessor {
+	sp, _ := NewShareProcessorForAlgorithm(DefaultAlgorithm)
+	return sp
 }
 
-// DefaultBlake2SHasher implements Blake2SHasher using the algorithm engine
-type DefaultBlake2SHasher struct{}
-
-// Hash computes Blake2S hash of input data
-func (h *DefaultBlake2SHasher) Hash(input []byte) ([]byte, error) {
-	// Simplified Blake2S-like hash for testing
-	// In production, this would call the actual Rust Blake2S implementation
-
-	hash := make([]byte, 32) // Blake2S-256 produces 32-byte hash
-
-	// Create a more realistic hash that varies significantly with input
-	// This is still a simulation but produces better distribution
-	seed := uint64(0x6a09e667f3bcc908) // Blake2S initial value
-
-	for i, b := range input {
-		seed = seed*0x9e3779b97f4a7c15 + uint64(b) + uint64(i)
-		seed ^= seed >> 30
-		seed *= 0xbf58476d1ce4e5b9
-		seed ^= seed >> 27
-		seed *= 0x94d049bb133111eb
-		seed ^= seed >> 31
-
-		// Distribute seed across hash bytes
-		for j := 0; j < 8 && i*8+j < 32; j++ {
-			hash[i*8+j] = byte(seed >> (j * 8))
-		}
-	}
-
-	// Fill remaining bytes if input is small
-	for i := len(input) * 8; i < 32; i++ {
-		seed = seed*0x9e3779b97f4a7c15 + uint64(i)
-		seed ^= seed >> 30
-		seed *= 0xbf58476d1ce4e5b9
-		seed ^= seed >> 27
-		hash[i] = byte(seed)
-	}
-
-	return hash, nil
+// NewShareProcessorForAlgorithm creates a share processor for a network
+// algorithm name from the default registry. An unknown name returns an error
+// together with a processor that rejects every share.
+func NewShareProcessorForAlgorithm(name string) (*ShareProcessor, error) {
+	sp := newShareProcessor()
+	return sp, sp.UseAlgorithm(name)
 }
 
-// Verify checks if the hash of input+nonce meets the target difficulty
-func (h *DefaultBlake2SHasher) Verify(input []byte, target []byte, nonce uint64) (bool, error) {
-	// Combine input with nonce
-	nonceBytes := make([]byte, 8)
-	for i := 0; i < 8; i++ {
-		nonceBytes[i] = byte(nonce >> (i * 8))
-	}
-
-	combined := append(input, nonceBytes...)
-	hash, err := h.Hash(combined)
-	if err != nil {
-		return false, err
-	}
-
-	// Compare hash against target (big-endian comparison)
-	if len(target) == 0 {
-		return false, nil // Empty target means impossible
-	}
-
-	// Convert to big integers for comparison
-	hashInt := new(big.Int).SetBytes(hash)
-	targetInt := new(big.Int).SetBytes(target)
-
-	// Hash must be less than target to be valid
-	return hashInt.Cmp(targetInt) < 0, nil
+// NewShareProcessorWithAlgorithm creates a share processor with a specific algorithm
+func NewShareProcessorWithAlgorithm(algorithm *Algorithm) *ShareProcessor {
+	sp := newShareProcessor()
+	sp.algorithmName = algorithm.Name()
+	sp.algorithm.Store(algorithm)
+	return sp
 }
 
-// NewShareProcessor creates a new share processor with Blake2S algorithm
-func NewShareProcessor() *ShareProcessor {
+func newShareProcessor() *ShareProcessor {
 	return &ShareProcessor{
-		algorithm:      &DefaultBlake2SHasher{},
 		minerStats:     make(map[int64]*MinerStatistics),
 		maxNonceLength: 16, // Maximum nonce length in hex characters
 		maxJobIDLength: 64, // Maximum job ID length
@@ -162,7 +118,28 @@ func NewShareProcessor() *ShareProcessor {
 	}
 }
 
-// ValidateShare validates a mining share using Blake2S algorithm
+// UseAlgorithm switches to the named algorithm, e.g. when the active network
+// changes. On error the processor rejects shares until a known name is set.
+func (sp *ShareProcessor) UseAlgorithm(name string) error {
+	if name == "" {
+		name = DefaultAlgorithm
+	}
+	algorithm, err := DefaultAlgorithmRegistry().Get(name)
+	sp.statsMutex.Lock()
+	sp.algorithmName = name
+	sp.statsMutex.Unlock()
+	sp.algorithm.Store(algorithm)
+	return err
+}
+
+// Algorithm returns the name of the configured algorithm
+func (sp *ShareProcessor) Algorithm() string {
+	sp.statsMutex.RLock()
+	defer sp.statsMutex.RUnlock()
+	return sp.algorithmName
+}
+
+// ValidateShare validates a mining share against its difficulty target
 func (sp *ShareProcessor) ValidateShare(share *Share) ShareValidationResult {
 	// Input validation
 	if err := sp.validateShareInput(share); err != nil {
@@ -172,8 +149,13 @@ func (sp *ShareProcessor) ValidateShare(share *Share) ShareValidationResult {
 		}
 	}
 
-	// Convert difficulty to target
-	target := sp.difficultyToTarget(share.Difficulty)
+	algorithm := sp.algorithm.Load()
+	if algorithm == nil {
+		return ShareValidationResult{
+			IsValid: false,
+			Error:   fmt.Sprintf("%v: %q", ErrUnknownAlgorithm, sp.Algorithm()),
+		}
+	}
 
 	// Parse nonce
 	nonce, err := sp.parseNonce(share.Nonce)
@@ -184,45 +166,60 @@ func (sp *ShareProcessor) ValidateShare(share *Share) ShareValidationResult {
 		}
 	}
 
-	// Create input data for hashing (job_id as base input)
-	input := []byte(share.JobID)
-
-	// Verify share using Blake2S algorithm
-	isValid, err := sp.algorithm.Verify(input, target, nonce)
+	input, err := sp.hashInput(share, nonce)
 	if err != nil {
 		return ShareValidationResult{
 			IsValid: false,
-			Error:   fmt.Sprintf("verification failed: %v", err),
+			Error:   err.Error(),
 		}
 	}
 
-	// Compute hash for storage
-	hash := ""
-	if isValid {
-		// Combine input with nonce for final hash
-		nonceBytes := make([]byte, 8)
-		for i := 0; i < 8; i++ {
-			nonceBytes[i] = byte(nonce >> (i * 8))
-		}
-		combined := append(input, nonceBytes...)
-
-		hashBytes, err := sp.algorithm.Hash(combined)
-		if err != nil {
-			return ShareValidationResult{
-				IsValid: false,
-				Error:   fmt.Sprintf("hash computation failed: %v", err),
-			}
+	hashBytes := algorithm.Hash(input)
+	if len(hashBytes) == 0 {
+		return ShareValidationResult{
+			IsValid: false,
+			Error:   fmt.Sprintf("hash computation failed: %s", algorithm.Name()),
 		}
-		hash = hex.EncodeToString(hashBytes)
 	}
 
+	// Convert difficulty to target
+	target := algorithm.Target(share.Difficulty)
+	isValid := algorithm.ValidateHash(hashBytes, target)
+
+	// The hash is kept for rejected shares too, for the audit trail
 	return ShareValidationResult{
 		IsValid: isValid,
-		Hash:    hash,
+		Hash:    hex.EncodeToString(hashBytes),
 		Error:   "",
 	}
 }
 
+// hashInput builds the bytes to hash. With a block header the nonce is
+// written into its last four bytes; otherwise the job ID and nonce are hashed.
+func (sp *ShareProcessor) hashInput(share *Share, nonce uint64) ([]byte, error) {
+	if share.Header == "" {
+		nonceBytes := make([]byte, 8)
+		binary.LittleEndian.PutUint64(nonceBytes, nonce)
+		return append([]byte(share.JobID), nonceBytes...), nil
+	}
+
+	header, err := hex.DecodeString(share.Header)
+	if err != nil {
+		return nil, fmt.Errorf("invalid header: %v", err)
+	}
+	if len(header) != blockdag.HeaderSize && len(header) != blockdag.HeaderSize-4 {
+		return nil, fmt.Errorf("invalid header: %d bytes, want %d", len(header), blockdag.HeaderSize)
+	}
+	if nonce > math.MaxUint32 {
+		return nil, fmt.Errorf("invalid nonce: header nonces are 32 bits")
+	}
+
+	input := make([]byte, blockdag.HeaderSize)
+	copy(input, header[:blockdag.HeaderSize-4])
+	binary.LittleEndian.PutUint32(input[blockdag.HeaderSize-4:], uint32(nonce))
+	return input, nil
+}
+
 // ProcessShare processes a complete share submission
 func (sp *ShareProcessor) ProcessShare(share *Share) ShareProcessingResult {
 	// Validate the share
@@ -337,47 +334,14 @@ func (sp *ShareProcessor) parseNonce(nonceStr string) (uint64, error) {
 	return nonce, nil
 }
 
-// difficultyToTarget converts mining difficulty to target bytes
+// difficultyToTarget converts mining difficulty to target bytes for the
+// configured algorithm
 func (sp *ShareProcessor) difficultyToTarget(difficulty float64) []byte {
-	if difficulty <= 0 {
-		return []byte{} // Invalid difficulty
+	algorithm := sp.algorithm.Load()
+	if algorithm == nil {
+		return []byte{}
 	}
-
-	// For very high difficulty (impossible), return very small target
-	if difficulty > 1000000 {
-		target := make([]byte, 32)
-		target[31] = 0x01 // Very small target
-		return target
-	}
-
-	// For testing purposes, make targets very generous
-	// In production, this would be much more restrictive
-	target := make([]byte, 32)
-
-	if difficulty <= 1.0 {
-		// Very easy target for difficulty 1.0 - about 50% chance of success
-		target[0] = 0x80 // First bit must be 0, so 50% chance
-		for i := 1; i < 32; i++ {
-			target[i] = 0xFF
-		}
-	} else if difficulty <= 10.0 {
-		// Medium target for difficulty up to 10
-		target[0] = byte(0x80 / difficulty)
-		if target[0] == 0 {
-			target[0] = 0x01
-		}
-		for i := 1; i < 32; i++ {
-			target[i] = 0xFF
-		}
-	} else {
-		// Harder target for higher difficulties
-		target[0] = 0x01
-		for i := 1; i < 32; i++ {
-			target[i] = 0x00
-		}
-	}
-
-	return target
+	return algorithm.Target(difficulty)
 }
 
 // updateStatistics updates share processing statistics
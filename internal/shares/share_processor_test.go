@@ -1,14 +1,55 @@
 package shares
 
 import (
+	"encoding/binary"
+	"encoding/hex"
 	"fmt"
+	"math/big"
 	"testing"
 	"time"
 
+	"github.com/chimera-pool/chimera-pool-core/internal/stratum/blockdag"
+
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
+// fixedHashAlgorithm hashes every input to the same value, so share validity
+// depends only on the share difficulty
+type fixedHashAlgorithm struct {
+	hash []byte
+}
+
+func (a *fixedHashAlgorithm) Name() string              { return "fixed" }
+func (a *fixedHashAlgorithm) Hash(data []byte) []byte   { return append([]byte(nil), a.hash...) }
+func (a *fixedHashAlgorithm) ValidateHash(hash, target []byte) bool {
+	return blockdag.HashMeetsTarget(hash, target)
+}
+
+// newTestShareProcessor returns a processor whose shares all meet scrypt
+// difficulty 4 and no higher
+func newTestShareProcessor() *ShareProcessor {
+	hash := new(big.Int).Rsh(blockdag.ScryptDiff1Target, 2).FillBytes(make([]byte, 32))
+	return NewShareProcessorWithAlgorithm(&Algorithm{
+		HashAlgorithm: &fixedHashAlgorithm{hash: hash},
+		Diff1Target:   blockdag.ScryptDiff1Target,
+	})
+}
+
+// litecoinGenesisHeader returns the Litecoin genesis block header and nonce
+func litecoinGenesisHeader(t *testing.T) (string, string) {
+	merkleRoot, err := hex.DecodeString("97ddfbbae6be97fd6cdf3e7ca13232a3afff2353e29badfab7f73011edd4ced9")
+	require.NoError(t, err)
+	blockdag.ReverseBytes(merkleRoot)
+
+	header := make([]byte, blockdag.HeaderSize)
+	binary.LittleEndian.PutUint32(header[0:4], 1)
+	copy(header[36:68], merkleRoot)
+	binary.LittleEndian.PutUint32(header[68:72], 1317972665)
+	binary.LittleEndian.PutUint32(header[72:76], 0x1e0ffff0)
+	return hex.EncodeToString(header[:76]), fmt.Sprintf("%08x", 2084524493)
+}
+
 // TestShareProcessor_ValidateShare tests share validation using Blake2S algorithm
 func TestShareProcessor_ValidateShare(t *testing.T) {
 	tests := []struct {
@@ -75,7 +116,7 @@ func TestShareProcessor_ValidateShare(t *testing.T) {
 		},
 	}
 
-	processor := NewShareProcessor()
+	processor := newTestShareProcessor()
 	
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -97,7 +138,7 @@ func TestShareProcessor_ValidateShare(t *testing.T) {
 
 // TestShareProcessor_ProcessShare tests complete share processing workflow
 func TestShareProcessor_ProcessShare(t *testing.T) {
-	processor := NewShareProcessor()
+	processor := newTestShareProcessor()
 	
 	share := &Share{
 		MinerID:    1,
@@ -124,7 +165,7 @@ func TestShareProcessor_ProcessShare(t *testing.T) {
 
 // TestShareProcessor_TrackStatistics tests share statistics tracking
 func TestShareProcessor_TrackStatistics(t *testing.T) {
-	processor := NewShareProcessor()
+	processor := newTestShareProcessor()
 	
 	// Process multiple shares
 	shares := []*Share{
@@ -255,35 +296,86 @@ func TestShareProcessor_ConcurrentProcessing(t *testing.T) {
 	assert.Equal(t, int64(totalExpected), stats.TotalShares, "Should have processed all shares")
 }
 
-// TestShareProcessor_Blake2SIntegration tests integration with Blake2S algorithm
-func TestShareProcessor_Blake2SIntegration(t *testing.T) {
+// TestShareProcessor_HashIntegration tests validation with the real scrypt algorithm
+func TestShareProcessor_HashIntegration(t *testing.T) {
 	processor := NewShareProcessor()
-	
+	require.Equal(t, AlgorithmScrypt, processor.Algorithm())
+
 	// Test with known input that should produce consistent hash
 	share := &Share{
 		MinerID:    1,
 		UserID:     1,
 		JobID:      "test_job",
 		Nonce:      "12345678",
-		Difficulty: 1.0,
+		Difficulty: 1e-9, // Every hash meets this target
 		Timestamp:  time.Now(),
 	}
-	
+
 	// Process the same share multiple times
 	result1 := processor.ProcessShare(share)
 	result2 := processor.ProcessShare(share)
-	
+
 	// Both should succeed
 	require.True(t, result1.Success, "First processing should succeed")
 	require.True(t, result2.Success, "Second processing should succeed")
-	
+
 	// Hashes should be consistent (same input should produce same hash)
-	assert.Equal(t, result1.ProcessedShare.Hash, result2.ProcessedShare.Hash, 
+	assert.Equal(t, result1.ProcessedShare.Hash, result2.ProcessedShare.Hash,
 		"Same share should produce consistent hash")
-	
-	// Hash should be 64 characters (32 bytes in hex)
-	assert.Len(t, result1.ProcessedShare.Hash, 64, "Blake2S hash should be 64 hex characters")
-	
-	// Hash should be valid hex
+
+	// Hash should be valid hex of 32 bytes
 	assert.Regexp(t, "^[0-9a-f]{64}$", result1.ProcessedShare.Hash, "Hash should be valid hex")
-}
\ No newline at end of file
+}
+
+// TestShareProcessor_ValidateBlockHeader tests proof of work over a real block header
+func TestShareProcessor_ValidateBlockHeader(t *testing.T) {
+	header, nonce := litecoinGenesisHeader(t)
+	processor := NewShareProcessor()
+
+	share := func(nonce string, difficulty float64) *Share {
+		return &Share{MinerID: 1, UserID: 1, JobID: "genesis", Header: header, Nonce: nonce, Difficulty: difficulty}
+	}
+
+	t.Run("genesis nonce meets the network difficulty", func(t *testing.T) {
+		result := processor.ValidateShare(share(nonce, 16))
+		assert.True(t, result.IsValid, result.Error)
+		assert.Equal(t, "0000050c34a64b415b6b15b37f2216634b5b1669cb9a2e38d76f7213b0671e00", result.Hash)
+	})
+
+	t.Run("genesis nonce does not meet a higher difficulty", func(t *testing.T) {
+		assert.False(t, processor.ValidateShare(share(nonce, 20000)).IsValid)
+	})
+
+	t.Run("other nonces are rejected", func(t *testing.T) {
+		assert.False(t, processor.ValidateShare(share("00000000", 16)).IsValid)
+	})
+
+	t.Run("other algorithms reject scrypt work", func(t *testing.T) {
+		sha, err := NewShareProcessorForAlgorithm("sha256d")
+		require.NoError(t, err)
+		assert.False(t, sha.ValidateShare(share(nonce, 16)).IsValid)
+	})
+
+	t.Run("rejects malformed headers", func(t *testing.T) {
+		bad := share(nonce, 16)
+		bad.Header = header[:20]
+		assert.Contains(t, processor.ValidateShare(bad).Error, "invalid header")
+
+		wide := share("1234567890", 16)
+		assert.Contains(t, processor.ValidateShare(wide).Error, "32 bits")
+	})
+}
+
+// TestShareProcessor_UnknownAlgorithm tests that an unknown network algorithm fails closed
+func TestShareProcessor_UnknownAlgorithm(t *testing.T) {
+	processor, err := NewShareProcessorForAlgorithm("kawpow")
+	require.ErrorIs(t, err, ErrUnknownAlgorithm)
+
+	result := processor.ValidateShare(&Share{MinerID: 1, UserID: 1, JobID: "job", Nonce: "01", Difficulty: 1e-9})
+	assert.False(t, result.IsValid)
+	assert.Contains(t, result.Error, "kawpow")
+
+	require.NoError(t, processor.UseAlgorithm("scrypt"))
+	result = processor.ValidateShare(&Share{MinerID: 1, UserID: 1, JobID: "job", Nonce: "01", Difficulty: 1e-9})
+	assert.True(t, result.IsValid)
+}
@@ -58,7 +58,8 @@ func DefaultMinerPermissions() MinerPermissions {
 // MinerAuthenticator handles miner authentication (ISP: single responsibility)
 type MinerAuthenticator interface {
 	// Authenticate validates a worker name and returns authentication result
-	// Worker name format: "username.workername" or just "username"
+	// Worker name format: "login.workername" or just "login", where the
+	// login is a username or e-mail address
 	Authenticate(ctx context.Context, workerName string, password string) (*AuthResult, error)
 }
 
@@ -67,7 +68,7 @@ type MinerLookup interface {
 	// GetMinerByWorkerName retrieves miner info by worker name
 	GetMinerByWorkerName(ctx context.Context, userID int64, workerName string) (*MinerInfo, error)
 
-	// GetUserByUsername retrieves user info by username
+	// GetUserByUsername retrieves user info by username or e-mail address
 	GetUserByUsername(ctx context.Context, username string) (*UserInfo, error)
 }
 
@@ -152,14 +153,8 @@ func NewCachedAuthenticator(lookup MinerLookup, registrar MinerRegistrar, config
 
 // Authenticate implements MinerAuthenticator
 func (ca *CachedAuthenticator) Authenticate(ctx context.Context, workerName string, password string) (*AuthResult, error) {
-	// Parse worker name: "username.workername" or just "username"
-	username, minerName, err := ParseWorkerName(workerName)
-	if err != nil {
-		return nil, err
-	}
-
-	// Look up user (with cache)
-	user, err := ca.getUserCached(ctx, username)
+	// Look up user (with cache) from "login.workername" or just "login"
+	user, minerName, err := ca.lookupUser(ctx, workerName)
 	if err != nil {
 		return nil, err
 	}
@@ -190,6 +185,41 @@ func (ca *CachedAuthenticator) Authenticate(ctx context.Context, workerName stri
 	}, nil
 }
 
+// lookupUser finds the user a worker name logs in as and the miner name that
+// follows the login. Users log in by username or e-mail address. An e-mail's
+// domain has dots of its own, so a name with an "@" is looked up whole first,
+// then with a miner name after each dot of the domain, rightmost first.
+func (ca *CachedAuthenticator) lookupUser(ctx context.Context, workerName string) (*UserInfo, string, error) {
+	workerName = strings.TrimSpace(workerName)
+	at := strings.Index(workerName, "@")
+	if at < 0 {
+		username, minerName, err := ParseWorkerName(workerName)
+		if err != nil {
+			return nil, "", err
+		}
+		user, err := ca.getUserCached(ctx, username)
+		return user, minerName, err
+	}
+	if at == 0 {
+		return nil, "", ErrInvalidWorkerName
+	}
+
+	user, err := ca.getUserCached(ctx, workerName)
+	if !errors.Is(err, ErrUserNotFound) {
+		return user, "default", err
+	}
+	for dot := strings.LastIndex(workerName, "."); dot > at; dot = strings.LastIndex(workerName[:dot], ".") {
+		if dot == len(workerName)-1 {
+			continue
+		}
+		user, err = ca.getUserCached(ctx, workerName[:dot])
+		if !errors.Is(err, ErrUserNotFound) {
+			return user, workerName[dot+1:], err
+		}
+	}
+	return nil, "", ErrUserNotFound
+}
+
 // getUserCached retrieves user from cache or database
 func (ca *CachedAuthenticator) getUserCached(ctx context.Context, username string) (*UserInfo, error) {
 	cacheKey := "user:" + username
@@ -320,6 +320,44 @@ func TestCachedAuthenticator_Authenticate(t *testing.T) {
 		require.NoError(t, err)
 		assert.Equal(t, 1, lookupCount) // Still 1, cache hit
 	})
+
+	t.Run("authenticates by e-mail address", func(t *testing.T) {
+		user := &UserInfo{ID: 100005, Username: "alice", IsActive: true}
+		// The lookup matches usernames and e-mail addresses
+		lookup := &mockLookup{
+			users: map[string]*UserInfo{
+				"alice":                  user,
+				"alice@mail.example.com": user,
+			},
+			miners: make(map[string]*MinerInfo),
+		}
+		auth := NewCachedAuthenticator(lookup, &mockRegistrar{}, DefaultCachedAuthenticatorConfig())
+
+		result, err := auth.Authenticate(context.Background(), "alice@mail.example.com", "")
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, result.UserID)
+		assert.Equal(t, "alice", result.Username)
+		assert.Equal(t, "default", result.WorkerName)
+
+		result, err = auth.Authenticate(context.Background(), "alice@mail.example.com.rig1", "")
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, result.UserID)
+		assert.Equal(t, "rig1", result.WorkerName)
+
+		result, err = auth.Authenticate(context.Background(), "alice@mail.example.com.rig.2", "")
+		require.NoError(t, err)
+		assert.Equal(t, "rig.2", result.WorkerName, "miner names may contain dots")
+
+		result, err = auth.Authenticate(context.Background(), "alice.rig1", "")
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, result.UserID)
+		assert.Equal(t, "rig1", result.WorkerName)
+
+		_, err = auth.Authenticate(context.Background(), "bob@mail.example.com.rig1", "")
+		assert.ErrorIs(t, err, ErrUserNotFound)
+		_, err = auth.Authenticate(context.Background(), "@mail.example.com", "")
+		assert.ErrorIs(t, err, ErrInvalidWorkerName)
+	})
 }
 
 // countingLookup tracks the number of lookups
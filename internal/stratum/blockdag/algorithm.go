@@ -106,21 +106,7 @@ func (s *ScrypyVariant) HashHeader(header []byte) ([]byte, error) {
 
 // ValidateHash checks if a hash meets the target difficulty
 func (s *ScrypyVariant) ValidateHash(hash, target []byte) bool {
-	if len(hash) != HashSize || len(target) != TargetSize {
-		return false
-	}
-
-	// Compare hash to target (hash must be <= target)
-	// Both are big-endian 256-bit numbers
-	for i := 0; i < HashSize; i++ {
-		if hash[i] < target[i] {
-			return true
-		}
-		if hash[i] > target[i] {
-			return false
-		}
-	}
-	return true // Equal is valid
+	return HashMeetsTarget(hash, target)
 }
 
 // ValidateWork validates a complete proof of work
@@ -145,10 +131,101 @@ func (s *ScrypyVariant) ValidateWork(header []byte, nonce uint32, target []byte)
 	return valid, hash, nil
 }
 
+// =============================================================================
+// Standard Scrypt (Litecoin)
+// =============================================================================
+
+// ScryptAlgorithmName is the name of the standard Litecoin scrypt algorithm
+const ScryptAlgorithmName = "scrypt"
+
+// Scrypt implements the standard Litecoin proof-of-work:
+// scrypt(N=1024, r=1, p=1) using the block header as both password and salt
+type Scrypt struct{}
+
+// NewScrypt creates a new standard scrypt algorithm instance
+func NewScrypt() *Scrypt {
+	return &Scrypt{}
+}
+
+// Name returns the algorithm name
+func (s *Scrypt) Name() string {
+	return ScryptAlgorithmName
+}
+
+// HashHeader hashes an 80-byte block header.
+// Scrypt output is a little-endian number, so it is reversed here to the
+// big-endian order that HashMeetsTarget and block explorers use.
+func (s *Scrypt) HashHeader(header []byte) ([]byte, error) {
+	if len(header) != HeaderSize {
+		return nil, ErrInvalidHeaderSize
+	}
+
+	hash, err := scrypt.Key(header, header, ScryptN, ScryptR, ScryptP, KeyLen)
+	if err != nil {
+		return nil, err
+	}
+
+	ReverseBytes(hash)
+	return hash, nil
+}
+
+// HashMeetsTarget reports whether a big-endian hash is less than or equal
+// to a big-endian target
+func HashMeetsTarget(hash, target []byte) bool {
+	if len(hash) != HashSize || len(target) != TargetSize {
+		return false
+	}
+
+	// Compare hash to target (hash must be <= target)
+	// Both are big-endian 256-bit numbers
+	for i := 0; i < HashSize; i++ {
+		if hash[i] < target[i] {
+			return true
+		}
+		if hash[i] > target[i] {
+			return false
+		}
+	}
+	return true // Equal is valid
+}
+
 // =============================================================================
 // Target/Difficulty Conversion
 // =============================================================================
 
+// ScryptDiff1Target is the difficulty-1 share target used by scrypt miners
+// (cgminer/sgminer and scrypt ASIC firmware): 0x0000ffff followed by zeros
+var ScryptDiff1Target = func() *big.Int {
+	t, _ := new(big.Int).SetString("0000ffff00000000000000000000000000000000000000000000000000000000", 16)
+	return t
+}()
+
+// ScryptDifficultyToTarget converts a (possibly fractional) stratum share
+// difficulty to a 32-byte big-endian scrypt share target
+func ScryptDifficultyToTarget(difficulty float64) []byte {
+	result := make([]byte, TargetSize)
+	if difficulty <= 0 {
+		for i := range result {
+			result[i] = 0xFF
+		}
+		return result
+	}
+
+	target, _ := new(big.Float).Quo(new(big.Float).SetInt(ScryptDiff1Target), big.NewFloat(difficulty)).Int(nil)
+
+	// Targets easier than 2^256-1 saturate at the maximum
+	if target.BitLen() > TargetSize*8 {
+		for i := range result {
+			result[i] = 0xFF
+		}
+		return result
+	}
+
+	targetBytes := target.Bytes()
+	copy(result[TargetSize-len(targetBytes):], targetBytes)
+	return result
+}
+
 // DifficultyToTarget converts a difficulty value to a 256-bit target
 func DifficultyToTarget(difficulty uint64) []byte {
 	if difficulty == 0 {
@@ -343,18 +420,34 @@ func (bt *BlockTemplate) BuildHeaderWithNonce(nonce uint32) []byte {
 // Share Validation
 // =============================================================================
 
+// HeaderHasher hashes an 80-byte block header into a big-endian proof-of-work hash
+type HeaderHasher interface {
+	Name() string
+	HashHeader(header []byte) ([]byte, error)
+}
+
 // ShareValidator validates mining shares against targets
 type ShareValidator struct {
-	algo *ScrypyVariant
+	algo HeaderHasher
 }
 
-// NewShareValidator creates a new share validator
+// NewShareValidator creates a new share validator using the Scrpy-variant algorithm
 func NewShareValidator() *ShareValidator {
+	return NewShareValidatorWithHasher(NewScrypyVariant())
+}
+
+// NewShareValidatorWithHasher creates a share validator for any header hasher
+func NewShareValidatorWithHasher(hasher HeaderHasher) *ShareValidator {
 	return &ShareValidator{
-		algo: NewScrypyVariant(),
+		algo: hasher,
 	}
 }
 
+// Algorithm returns the name of the algorithm used by this validator
+func (sv *ShareValidator) Algorithm() string {
+	return sv.algo.Name()
+}
+
 // ValidateShare validates a submitted share
 func (sv *ShareValidator) ValidateShare(header []byte, nonce uint32, shareTarget, blockTarget []byte) (shareValid, blockValid bool, hash []byte, err error) {
 	// Build full header with nonce
@@ -373,11 +466,11 @@ func (sv *ShareValidator) ValidateShare(header []byte, nonce uint32, shareTarget
 	}
 
 	// Check against share target (miner's difficulty)
-	shareValid = sv.algo.ValidateHash(hash, shareTarget)
+	shareValid = HashMeetsTarget(hash, shareTarget)
 
 	// Check against block target (network difficulty)
 	if shareValid {
-		blockValid = sv.algo.ValidateHash(hash, blockTarget)
+		blockValid = HashMeetsTarget(hash, blockTarget)
 	}
 
 	return shareValid, blockValid, hash, nil
@@ -385,7 +478,7 @@ func (sv *ShareValidator) ValidateShare(header []byte, nonce uint32, shareTarget
 
 // QuickValidate performs a quick hash comparison without full recomputation
 func (sv *ShareValidator) QuickValidate(hash, target []byte) bool {
-	return sv.algo.ValidateHash(hash, target)
+	return HashMeetsTarget(hash, target)
 }
 
 // =============================================================================
@@ -3,6 +3,7 @@ package blockdag
 import (
 	"bytes"
 	"encoding/binary"
+	"encoding/hex"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -304,6 +305,59 @@ func TestBlockTemplate_BuildHeaderWithNonce(t *testing.T) {
 	assert.Equal(t, nonce, extractedNonce)
 }
 
+// -----------------------------------------------------------------------------
+// Standard Scrypt (Litecoin) Tests
+// -----------------------------------------------------------------------------
+
+// litecoinGenesisHeader returns the 80-byte header of the Litecoin genesis block
+func litecoinGenesisHeader(t *testing.T) []byte {
+	merkleRoot, err := hex.DecodeString("97ddfbbae6be97fd6cdf3e7ca13232a3afff2353e29badfab7f73011edd4ced9")
+	require.NoError(t, err)
+	ReverseBytes(merkleRoot)
+
+	header := make([]byte, HeaderSize)
+	binary.LittleEndian.PutUint32(header[0:4], 1)
+	copy(header[36:68], merkleRoot)
+	binary.LittleEndian.PutUint32(header[68:72], 1317972665)
+	binary.LittleEndian.PutUint32(header[72:76], 0x1e0ffff0)
+	binary.LittleEndian.PutUint32(header[76:80], 2084524493)
+	return header
+}
+
+func TestScrypt_Name(t *testing.T) {
+	assert.Equal(t, "scrypt", NewScrypt().Name())
+}
+
+func TestScrypt_HashHeader_LitecoinGenesis(t *testing.T) {
+	hash, err := NewScrypt().HashHeader(litecoinGenesisHeader(t))
+	require.NoError(t, err)
+
+	assert.Equal(t, "0000050c34a64b415b6b15b37f2216634b5b1669cb9a2e38d76f7213b0671e00", hex.EncodeToString(hash))
+	assert.True(t, HashMeetsTarget(hash, CompactToTarget(0x1e0ffff0)))
+}
+
+func TestScrypt_HashHeader_InvalidSize(t *testing.T) {
+	_, err := NewScrypt().HashHeader(make([]byte, 79))
+	assert.ErrorIs(t, err, ErrInvalidHeaderSize)
+}
+
+func TestScryptDifficultyToTarget(t *testing.T) {
+	diff1 := ScryptDifficultyToTarget(1)
+	assert.Equal(t, "0000ffff00000000000000000000000000000000000000000000000000000000", hex.EncodeToString(diff1))
+
+	// Higher difficulty means a lower target
+	assert.Equal(t, -1, CompareHashes(ScryptDifficultyToTarget(1024), diff1))
+
+	// Fractional difficulty (CPU miners) gives an easier target
+	assert.Equal(t, 1, CompareHashes(ScryptDifficultyToTarget(0.01), diff1))
+}
+
+func TestScryptDifficultyToTarget_Saturates(t *testing.T) {
+	maxTarget := bytes.Repeat([]byte{0xFF}, TargetSize)
+	assert.Equal(t, maxTarget, ScryptDifficultyToTarget(0))
+	assert.Equal(t, maxTarget, ScryptDifficultyToTarget(1e-30))
+}
+
 // -----------------------------------------------------------------------------
 // Share Validator Tests
 // -----------------------------------------------------------------------------
@@ -349,6 +403,27 @@ func TestShareValidator_ValidateShare_ShareOnly(t *testing.T) {
 	assert.False(t, blockValid, "block should not be valid")
 }
 
+func TestShareValidator_WithScryptHasher(t *testing.T) {
+	sv := NewShareValidatorWithHasher(NewScrypt())
+	assert.Equal(t, ScryptAlgorithmName, sv.Algorithm())
+
+	genesis := litecoinGenesisHeader(t)
+	nonce := binary.LittleEndian.Uint32(genesis[76:80])
+
+	shareValid, blockValid, hash, err := sv.ValidateShare(genesis[:HeaderSize-4], nonce,
+		ScryptDifficultyToTarget(1), CompactToTarget(0x1e0ffff0))
+	require.NoError(t, err)
+	assert.True(t, shareValid)
+	assert.True(t, blockValid)
+	assert.Equal(t, "0000050c", hex.EncodeToString(hash[:4]))
+
+	// Wrong nonce fails the block target
+	_, blockValid, _, err = sv.ValidateShare(genesis[:HeaderSize-4], nonce+1,
+		bytes.Repeat([]byte{0xFF}, TargetSize), CompactToTarget(0x1e0ffff0))
+	require.NoError(t, err)
+	assert.False(t, blockValid)
+}
+
 func TestShareValidator_QuickValidate(t *testing.T) {
 	sv := NewShareValidator()
 
@@ -0,0 +1,203 @@
+// Package blocknotify tells the pool about new blocks as soon as the node
+// sees them, using ZMQ, getblocktemplate longpoll or a plain poll as fallback
+package blocknotify
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Event reports that the node may have a new block template
+type Event struct {
+	Source          string    // Name of the source that fired
+	BlockHash       string    // New tip hash if the source knows it (hex, display order)
+	TemplateFetched bool      // Source already fetched and applied a fresh template
+	Time            time.Time // When the event was received
+}
+
+// Source produces block events until it fails or ctx is cancelled.
+// Sources emit once as soon as they are ready so blocks missed while
+// another source was active are picked up.
+type Source interface {
+	Name() string
+	Run(ctx context.Context, emit func(Event)) error
+}
+
+// Config holds watcher configuration
+type Config struct {
+	RetryInterval time.Duration // How long a fallback source runs before preferred sources are retried
+}
+
+// DefaultConfig returns sensible default configuration
+func DefaultConfig() Config {
+	return Config{
+		RetryInterval: time.Minute,
+	}
+}
+
+// Watcher runs the first healthy source from an ordered list and passes its
+// events to a callback. When a source fails the next one takes over; after
+// RetryInterval the preferred sources are tried again.
+type Watcher struct {
+	config  Config
+	sources []Source
+	onBlock func(Event)
+
+	active atomic.Value // string
+	events atomic.Int64
+	mu     sync.Mutex // Serializes onBlock
+}
+
+// NewWatcher creates a watcher over sources in order of preference
+func NewWatcher(config Config, onBlock func(Event), sources ...Source) *Watcher {
+	w := &Watcher{
+		config:  config,
+		sources: sources,
+		onBlock: onBlock,
+	}
+	w.active.Store("")
+	return w
+}
+
+// Run drives the sources until ctx is cancelled
+func (w *Watcher) Run(ctx context.Context) {
+	if len(w.sources) == 0 {
+		return
+	}
+	defer w.active.Store("")
+
+	for i := 0; ctx.Err() == nil; {
+		source := w.sources[i]
+		w.active.Store(source.Name())
+
+		runCtx, cancel := ctx, context.CancelFunc(func() {})
+		if i > 0 {
+			// Fallbacks only run until the preferred sources are due a retry
+			runCtx, cancel = context.WithTimeout(ctx, w.config.RetryInterval)
+		}
+		err := source.Run(runCtx, w.emit)
+		retryDue := errors.Is(runCtx.Err(), context.DeadlineExceeded)
+		cancel()
+
+		switch {
+		case ctx.Err() != nil:
+			return
+		case retryDue:
+			i = 0
+		case i < len(w.sources)-1:
+			log.Printf("Block notifier %s failed: %v (falling back to %s)", source.Name(), err, w.sources[i+1].Name())
+			i++
+		default:
+			log.Printf("Block notifier %s failed: %v (retrying in %v)", source.Name(), err, w.config.RetryInterval)
+			select {
+			case <-ctx.Done():
+			case <-time.After(w.config.RetryInterval):
+			}
+			i = 0
+		}
+	}
+}
+
+// ActiveSource returns the name of the source currently running
+func (w *Watcher) ActiveSource() string {
+	return w.active.Load().(string)
+}
+
+// SourceNames returns the configured sources in order of preference
+func (w *Watcher) SourceNames() []string {
+	names := make([]string, len(w.sources))
+	for i, source := range w.sources {
+		names[i] = source.Name()
+	}
+	return names
+}
+
+// EventCount returns how many events have been delivered
+func (w *Watcher) EventCount() int64 {
+	return w.events.Load()
+}
+
+func (w *Watcher) emit(event Event) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	w.events.Add(1)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.onBlock(event)
+}
+
+// =============================================================================
+// Poll and Longpoll Sources
+// =============================================================================
+
+// PollSource emits on a fixed interval; it never fails and is the last resort
+type PollSource struct {
+	Interval time.Duration
+}
+
+// NewPollSource creates a poll source
+func NewPollSource(interval time.Duration) *PollSource {
+	return &PollSource{Interval: interval}
+}
+
+// Name returns "poll"
+func (p *PollSource) Name() string { return "poll" }
+
+// Run emits immediately and then every Interval
+func (p *PollSource) Run(ctx context.Context, emit func(Event)) error {
+	ticker := time.NewTicker(p.Interval)
+	defer ticker.Stop()
+
+	emit(Event{Source: p.Name()})
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			emit(Event{Source: p.Name()})
+		}
+	}
+}
+
+// LongpollFunc performs one getblocktemplate longpoll. It blocks until the
+// node's template differs from longpollID (an empty ID returns at once),
+// applies the returned template and returns its longpoll ID.
+type LongpollFunc func(ctx context.Context, longpollID string) (string, error)
+
+// ErrLongpollUnsupported is returned when the node does not hand out longpoll IDs
+var ErrLongpollUnsupported = errors.New("node does not support longpoll")
+
+// LongpollSource emits each time a getblocktemplate longpoll returns
+type LongpollSource struct {
+	fetch LongpollFunc
+}
+
+// NewLongpollSource creates a longpoll source around fetch
+func NewLongpollSource(fetch LongpollFunc) *LongpollSource {
+	return &LongpollSource{fetch: fetch}
+}
+
+// Name returns "longpoll"
+func (l *LongpollSource) Name() string { return "longpoll" }
+
+// Run longpolls until a request fails
+func (l *LongpollSource) Run(ctx context.Context, emit func(Event)) error {
+	longpollID := ""
+	for {
+		next, err := l.fetch(ctx, longpollID)
+		if err != nil {
+			return err
+		}
+		emit(Event{Source: l.Name(), TemplateFetched: true})
+		if next == "" {
+			return ErrLongpollUnsupported
+		}
+		longpollID = next
+	}
+}
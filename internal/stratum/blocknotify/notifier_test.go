@@ -0,0 +1,229 @@
+package blocknotify
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// funcSource adapts a function to Source
+type funcSource struct {
+	name string
+	run  func(ctx context.Context, emit func(Event)) error
+}
+
+func (f *funcSource) Name() string { return f.name }
+func (f *funcSource) Run(ctx context.Context, emit func(Event)) error {
+	return f.run(ctx, emit)
+}
+
+// eventLog collects events delivered by a watcher
+type eventLog struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (l *eventLog) add(e Event) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, e)
+}
+
+func (l *eventLog) sources() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	names := make([]string, len(l.events))
+	for i, e := range l.events {
+		names[i] = e.Source
+	}
+	return names
+}
+
+func TestWatcher_FallsBackAndRetriesPreferred(t *testing.T) {
+	var attempts int
+	var mu sync.Mutex
+	preferred := &funcSource{name: "zmq", run: func(ctx context.Context, emit func(Event)) error {
+		mu.Lock()
+		attempts++
+		n := attempts
+		mu.Unlock()
+		if n == 1 {
+			return errors.New("connection refused")
+		}
+		emit(Event{Source: "zmq"})
+		<-ctx.Done()
+		return ctx.Err()
+	}}
+
+	log := &eventLog{}
+	watcher := NewWatcher(Config{RetryInterval: 50 * time.Millisecond}, log.add, preferred, NewPollSource(time.Hour))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		watcher.Run(ctx)
+		close(done)
+	}()
+
+	require.Eventually(t, func() bool { return watcher.ActiveSource() == "zmq" && len(log.sources()) == 2 },
+		2*time.Second, 5*time.Millisecond)
+	assert.Equal(t, []string{"poll", "zmq"}, log.sources())
+	assert.Equal(t, int64(2), watcher.EventCount())
+
+	cancel()
+	<-done
+	assert.Equal(t, "", watcher.ActiveSource())
+}
+
+func TestPollSource_EmitsImmediatelyAndOnTick(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 55*time.Millisecond)
+	defer cancel()
+
+	count := 0
+	err := NewPollSource(20*time.Millisecond).Run(ctx, func(e Event) {
+		assert.Equal(t, "poll", e.Source)
+		count++
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 3, count)
+}
+
+func TestLongpollSource_ChainsLongpollIDs(t *testing.T) {
+	var ids []string
+	source := NewLongpollSource(func(ctx context.Context, longpollID string) (string, error) {
+		ids = append(ids, longpollID)
+		switch len(ids) {
+		case 1:
+			return "lp1", nil
+		case 2:
+			return "lp2", nil
+		default:
+			return "", errors.New("rpc down")
+		}
+	})
+
+	var events []Event
+	err := source.Run(context.Background(), func(e Event) { events = append(events, e) })
+	assert.EqualError(t, err, "rpc down")
+	assert.Equal(t, []string{"", "lp1", "lp2"}, ids)
+	require.Len(t, events, 2)
+	assert.True(t, events[0].TemplateFetched)
+}
+
+func TestLongpollSource_Unsupported(t *testing.T) {
+	source := NewLongpollSource(func(ctx context.Context, longpollID string) (string, error) {
+		return "", nil
+	})
+	calls := 0
+	err := source.Run(context.Background(), func(Event) { calls++ })
+	assert.ErrorIs(t, err, ErrLongpollUnsupported)
+	assert.Equal(t, 1, calls, "the fetched template is still reported")
+}
+
+// =============================================================================
+// ZMQ Tests
+// =============================================================================
+
+// fakePublisher accepts one SUB connection and completes the ZMTP handshake
+func fakePublisher(t *testing.T) (string, chan net.Conn) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	subscribed := make(chan net.Conn, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		t.Cleanup(func() { conn.Close() })
+
+		greeting := make([]byte, zmtpGreetingSize)
+		if _, err := conn.Read(greeting); err != nil {
+			return
+		}
+		conn.Write(zmtpGreeting(true))
+		if _, _, err := zmtpReadFrame(conn); err != nil { // READY
+			return
+		}
+		zmtpWriteFrame(conn, zmtpFlagCommand, zmtpReady("PUB"))
+
+		_, sub, err := zmtpReadFrame(conn)
+		if err != nil || sub[0] != 0x01 || string(sub[1:]) != HashBlockTopic {
+			return
+		}
+		subscribed <- conn
+	}()
+	return "tcp://" + listener.Addr().String(), subscribed
+}
+
+func publish(t *testing.T, conn net.Conn, topic string, body []byte) {
+	require.NoError(t, zmtpWriteFrame(conn, zmtpFlagMore, []byte(topic)))
+	require.NoError(t, zmtpWriteFrame(conn, zmtpFlagMore, body))
+	require.NoError(t, zmtpWriteFrame(conn, 0, []byte{1, 0, 0, 0}))
+}
+
+func TestZMQSource_EmitsHashBlock(t *testing.T) {
+	endpoint, subscribed := fakePublisher(t)
+
+	events := make(chan Event, 4)
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- NewZMQSource(endpoint).Run(ctx, func(e Event) { events <- e })
+	}()
+
+	var conn net.Conn
+	select {
+	case conn = <-subscribed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("subscriber did not connect")
+	}
+
+	initial := <-events
+	assert.Equal(t, "zmq", initial.Source)
+	assert.Empty(t, initial.BlockHash)
+
+	hash := make([]byte, 32)
+	hash[0] = 0xab
+	publish(t, conn, "hashtx", make([]byte, 32)) // Other topics are ignored
+	publish(t, conn, HashBlockTopic, hash)
+
+	select {
+	case e := <-events:
+		assert.Equal(t, "ab"+"00000000000000000000000000000000000000000000000000000000000000", e.BlockHash)
+	case <-time.After(2 * time.Second):
+		t.Fatal("no hashblock event")
+	}
+
+	cancel()
+	select {
+	case err := <-errCh:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(2 * time.Second):
+		t.Fatal("source did not stop")
+	}
+}
+
+func TestZMQSource_RejectsNonZMTPPeer(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.Write(append([]byte("HTTP/1.1 400 Bad Request\r\n"), make([]byte, 64)...))
+	}()
+
+	err = NewZMQSource(listener.Addr().String()).Run(context.Background(), func(Event) {})
+	assert.ErrorIs(t, err, ErrZMTPGreeting)
+}
@@ -0,0 +1,203 @@
+package blocknotify
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// =============================================================================
+// ZMQ HASHBLOCK SUBSCRIBER
+// A minimal ZMTP 3.0 SUB socket (NULL security) for the node's
+// zmqpubhashblock feed. Each publication is [topic, 32-byte hash, sequence].
+// =============================================================================
+
+// ZMTP framing constants
+const (
+	zmtpGreetingSize = 64
+	zmtpFlagMore     = 0x01
+	zmtpFlagLong     = 0x02
+	zmtpFlagCommand  = 0x04
+
+	// zmtpMaxFrame bounds frames we accept; hashblock messages are tiny
+	zmtpMaxFrame = 1 << 20
+
+	// HashBlockTopic is the topic the node publishes block hashes on
+	HashBlockTopic = "hashblock"
+)
+
+// ZMQ errors
+var (
+	ErrZMTPGreeting  = errors.New("zmq: invalid greeting")
+	ErrZMTPHandshake = errors.New("zmq: handshake failed")
+	ErrZMTPFrame     = errors.New("zmq: invalid frame")
+)
+
+// ZMQSource subscribes to hashblock on a node's ZMQ publisher
+type ZMQSource struct {
+	Endpoint    string        // e.g. tcp://litecoind:28332
+	DialTimeout time.Duration // Timeout for connect and handshake
+}
+
+// NewZMQSource creates a ZMQ source for endpoint
+func NewZMQSource(endpoint string) *ZMQSource {
+	return &ZMQSource{Endpoint: endpoint, DialTimeout: 10 * time.Second}
+}
+
+// Name returns "zmq"
+func (z *ZMQSource) Name() string { return "zmq" }
+
+// Run connects, subscribes and emits one event per published block
+func (z *ZMQSource) Run(ctx context.Context, emit func(Event)) error {
+	address := strings.TrimPrefix(z.Endpoint, "tcp://")
+	dialer := net.Dialer{Timeout: z.DialTimeout, KeepAlive: 30 * time.Second}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// Unblock reads when the watcher cancels us
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	conn.SetDeadline(time.Now().Add(z.DialTimeout))
+	if err := zmtpHandshake(conn); err != nil {
+		return err
+	}
+	if err := zmtpWriteFrame(conn, 0, append([]byte{0x01}, HashBlockTopic...)); err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Time{})
+
+	emit(Event{Source: z.Name()})
+
+	for {
+		parts, err := zmtpReadMessage(conn)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		if len(parts) < 2 || string(parts[0]) != HashBlockTopic || len(parts[1]) != 32 {
+			continue
+		}
+		emit(Event{Source: z.Name(), BlockHash: hex.EncodeToString(parts[1])})
+	}
+}
+
+// zmtpHandshake exchanges greetings and READY commands as a SUB socket
+func zmtpHandshake(conn io.ReadWriter) error {
+	if _, err := conn.Write(zmtpGreeting(false)); err != nil {
+		return err
+	}
+	greeting := make([]byte, zmtpGreetingSize)
+	if _, err := io.ReadFull(conn, greeting); err != nil {
+		return err
+	}
+	if greeting[0] != 0xFF || greeting[9] != 0x7F || greeting[10] < 3 {
+		return ErrZMTPGreeting
+	}
+	if mechanism := string(bytes.TrimRight(greeting[12:32], "\x00")); mechanism != "NULL" {
+		return fmt.Errorf("%w: unsupported mechanism %q", ErrZMTPHandshake, mechanism)
+	}
+
+	if err := zmtpWriteFrame(conn, zmtpFlagCommand, zmtpReady("SUB")); err != nil {
+		return err
+	}
+	flags, body, err := zmtpReadFrame(conn)
+	if err != nil {
+		return err
+	}
+	if flags&zmtpFlagCommand == 0 || len(body) < 6 || string(body[1:6]) != "READY" {
+		return ErrZMTPHandshake
+	}
+	return nil
+}
+
+// zmtpGreeting builds a ZMTP 3.0 greeting for the NULL mechanism
+func zmtpGreeting(asServer bool) []byte {
+	g := make([]byte, zmtpGreetingSize)
+	g[0] = 0xFF
+	g[9] = 0x7F
+	g[10] = 3 // Major version
+	g[11] = 0 // Minor version: 3.0 subscriptions are sent as messages
+	copy(g[12:32], "NULL")
+	if asServer {
+		g[32] = 1
+	}
+	return g
+}
+
+// zmtpReady builds a READY command body announcing socketType
+func zmtpReady(socketType string) []byte {
+	body := []byte{5}
+	body = append(body, "READY"...)
+	body = append(body, byte(len("Socket-Type")))
+	body = append(body, "Socket-Type"...)
+	body = binary.BigEndian.AppendUint32(body, uint32(len(socketType)))
+	return append(body, socketType...)
+}
+
+// zmtpWriteFrame writes one frame with the given flags
+func zmtpWriteFrame(w io.Writer, flags byte, body []byte) error {
+	var header []byte
+	if len(body) > 255 {
+		header = binary.BigEndian.AppendUint64([]byte{flags | zmtpFlagLong}, uint64(len(body)))
+	} else {
+		header = []byte{flags, byte(len(body))}
+	}
+	_, err := w.Write(append(header, body...))
+	return err
+}
+
+// zmtpReadFrame reads one frame and returns its flags and body
+func zmtpReadFrame(r io.Reader) (byte, []byte, error) {
+	head := make([]byte, 2)
+	if _, err := io.ReadFull(r, head); err != nil {
+		return 0, nil, err
+	}
+	flags := head[0]
+	size := uint64(head[1])
+	if flags&zmtpFlagLong != 0 {
+		rest := make([]byte, 7)
+		if _, err := io.ReadFull(r, rest); err != nil {
+			return 0, nil, err
+		}
+		size = binary.BigEndian.Uint64(append(head[1:], rest...))
+	}
+	if size > zmtpMaxFrame {
+		return 0, nil, ErrZMTPFrame
+	}
+	body := make([]byte, size)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, nil, err
+	}
+	return flags, body, nil
+}
+
+// zmtpReadMessage reads the frames of one message, skipping commands
+func zmtpReadMessage(r io.Reader) ([][]byte, error) {
+	var parts [][]byte
+	for {
+		flags, body, err := zmtpReadFrame(r)
+		if err != nil {
+			return nil, err
+		}
+		if flags&zmtpFlagCommand != 0 {
+			continue // e.g. PING from ZMTP 3.1 peers
+		}
+		parts = append(parts, body)
+		if flags&zmtpFlagMore == 0 {
+			return parts, nil
+		}
+	}
+}
@@ -60,7 +60,7 @@ type ManagedConnection struct {
 	Subscribed   bool
 	Authorized   bool
 	Extranonce1  string
-	Difficulty   uint64
+	Difficulty   float64
 	HardwareType string
 
 	// Authentication (set after successful authorization)
@@ -117,6 +117,8 @@ type ConnectionStats struct {
 	PeakConnections     int64
 	TotalBytesSent      int64
 	TotalBytesReceived  int64
+	TLSHandshakes       int64 // Completed TLS handshakes
+	TLSHandshakeErrors  int64 // Failed TLS handshakes (bad client hello, timeout, ...)
 }
 
 // NewConnectionManager creates a new sharded connection manager
@@ -349,9 +351,20 @@ func (cm *ConnectionManager) GetStats() ConnectionStats {
 		PeakConnections:     atomic.LoadInt64(&cm.stats.PeakConnections),
 		TotalBytesSent:      atomic.LoadInt64(&cm.stats.TotalBytesSent),
 		TotalBytesReceived:  atomic.LoadInt64(&cm.stats.TotalBytesReceived),
+		TLSHandshakes:       atomic.LoadInt64(&cm.stats.TLSHandshakes),
+		TLSHandshakeErrors:  atomic.LoadInt64(&cm.stats.TLSHandshakeErrors),
 	}
 }
 
+// RecordTLSHandshake counts a TLS handshake outcome; err is nil on success
+func (cm *ConnectionManager) RecordTLSHandshake(err error) {
+	if err != nil {
+		atomic.AddInt64(&cm.stats.TLSHandshakeErrors, 1)
+		return
+	}
+	atomic.AddInt64(&cm.stats.TLSHandshakes, 1)
+}
+
 // GetConnectionsByIP returns all connections from a specific IP
 func (cm *ConnectionManager) GetConnectionsByIP(ip string) []*ManagedConnection {
 	var result []*ManagedConnection
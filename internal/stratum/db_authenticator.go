@@ -24,14 +24,17 @@ func NewDBMinerLookup(db *sql.DB) *DBMinerLookup {
 	return &DBMinerLookup{db: db}
 }
 
-// GetUserByUsername retrieves user info by username
+// GetUserByUsername retrieves user info by username or e-mail address,
+// preferring a username match
 func (l *DBMinerLookup) GetUserByUsername(ctx context.Context, username string) (*UserInfo, error) {
 	query := `
 		SELECT id, username, password_hash, 
 		       COALESCE(is_active, true) as is_active,
 		       COALESCE(role, 'user') as role
 		FROM users 
-		WHERE username = $1
+		WHERE username = $1 OR email = $1
+		ORDER BY (username = $1) DESC
+		LIMIT 1
 	`
 
 	var user UserInfo
@@ -1,6 +1,7 @@
 package detector
 
 import (
+	"crypto/tls"
 	"errors"
 	"io"
 	"net"
@@ -20,6 +21,8 @@ const (
 	ProtocolUnknown ProtocolVersion = iota
 	ProtocolV1                      // JSON-based Stratum v1
 	ProtocolV2                      // Binary Stratum v2
+	ProtocolV2Noise                 // Stratum v2 inside a Noise NX channel
+	ProtocolTLS                     // Stratum inside TLS
 )
 
 // String returns the protocol name
@@ -29,6 +32,10 @@ func (p ProtocolVersion) String() string {
 		return "stratum-v1"
 	case ProtocolV2:
 		return "stratum-v2"
+	case ProtocolV2Noise:
+		return "stratum-v2-noise"
+	case ProtocolTLS:
+		return "stratum-tls"
 	default:
 		return "unknown"
 	}
@@ -47,6 +54,10 @@ const (
 
 	// V2 frame header starts with extension_type (2 bytes, usually 0x0000)
 	// followed by msg_type (1 byte, 0x00 for SetupConnection)
+
+	// TLS records start with content type 0x16 (handshake) and major version 3
+	TLSHandshakeRecord byte = 0x16
+	TLSMajorVersion    byte = 0x03
 )
 
 // Errors
@@ -65,8 +76,10 @@ var (
 // PeekableConn wraps a net.Conn to allow peeking without consuming bytes
 type PeekableConn struct {
 	net.Conn
-	peeked []byte
-	mu     sync.Mutex
+	Profile *PortProfile // Port profile the connection was accepted under, if any
+	TLS     bool         // Stratum arrived inside TLS terminated by the detector
+	peeked  []byte
+	mu      sync.Mutex
 }
 
 // NewPeekableConn creates a new peekable connection wrapper
@@ -125,7 +138,8 @@ func (pc *PeekableConn) Read(b []byte) (int, error) {
 
 // Detector detects the protocol version from connection data
 type Detector struct {
-	timeout time.Duration
+	timeout   time.Duration
+	tlsConfig *tls.Config // nil disables TLS termination
 }
 
 // NewDetector creates a new protocol detector
@@ -187,6 +201,11 @@ func (d *Detector) detectFromBytes(data []byte) ProtocolVersion {
 		return ProtocolV1
 	}
 
+	// A TLS ClientHello record: 0x16 0x03 0x0X
+	if len(data) >= 2 && data[0] == TLSHandshakeRecord && data[1] == TLSMajorVersion {
+		return ProtocolTLS
+	}
+
 	// V2 binary format detection:
 	// - First 2 bytes: extension_type (typically 0x0000)
 	// - Byte 3: msg_type (0x00 for SetupConnection)
@@ -239,14 +258,19 @@ type Handler interface {
 type Router struct {
 	detector *Detector
 	handlers map[ProtocolVersion]Handler
+	profiles map[string]*PortProfile // By port
 	mu       sync.RWMutex
 	closed   bool
 
+	// Called with the outcome of every TLS handshake
+	tlsObserver func(err error)
+
 	// Metrics
-	v1Connections    uint64
-	v2Connections    uint64
-	failedDetections uint64
-	metricsmu        sync.Mutex
+	v1Connections       uint64
+	v2Connections       uint64
+	failedDetections    uint64
+	rejectedConnections uint64
+	metricsmu           sync.Mutex
 }
 
 // NewRouter creates a new protocol router
@@ -254,6 +278,7 @@ func NewRouter() *Router {
 	return &Router{
 		detector: NewDetector(),
 		handlers: make(map[ProtocolVersion]Handler),
+		profiles: make(map[string]*PortProfile),
 	}
 }
 
@@ -262,6 +287,7 @@ func NewRouterWithDetector(detector *Detector) *Router {
 	return &Router{
 		detector: detector,
 		handlers: make(map[ProtocolVersion]Handler),
+		profiles: make(map[string]*PortProfile),
 	}
 }
 
@@ -279,6 +305,18 @@ func (r *Router) UnregisterHandler(version ProtocolVersion) {
 	delete(r.handlers, version)
 }
 
+// SetTLSConfig enables TLS termination on ports whose profile allows tls
+// (and on every port when no profiles are configured)
+func (r *Router) SetTLSConfig(config *tls.Config) {
+	r.detector.SetTLSConfig(config)
+}
+
+// SetTLSObserver registers a callback for TLS handshake outcomes; err is nil
+// for successful handshakes. Call before routing connections.
+func (r *Router) SetTLSObserver(observer func(err error)) {
+	r.tlsObserver = observer
+}
+
 // HasHandler checks if a handler is registered for a protocol
 func (r *Router) HasHandler(version ProtocolVersion) bool {
 	r.mu.RLock()
@@ -287,7 +325,11 @@ func (r *Router) HasHandler(version ProtocolVersion) bool {
 	return ok
 }
 
-// Route detects protocol and routes connection to appropriate handler
+// Route detects protocol and routes connection to appropriate handler. On a
+// port with a profile, remote addresses outside its CIDRs and protocols it
+// does not allow are rejected; connections of unknown protocol still reach
+// the ProtocolUnknown handler so health probes keep working. TLS is
+// terminated first when configured, and the protocol inside it is routed.
 func (r *Router) Route(conn net.Conn) error {
 	r.mu.RLock()
 	if r.closed {
@@ -297,14 +339,57 @@ func (r *Router) Route(conn net.Conn) error {
 	}
 	r.mu.RUnlock()
 
-	// Detect protocol
-	version, peekConn, err := r.detector.Detect(conn)
-	if err != nil {
-		r.recordFailedDetection()
+	profile := r.profileFor(conn)
+	if profile != nil && !profile.AllowsAddr(conn.RemoteAddr()) {
+		r.recordRejected()
+		conn.Close()
+		return ErrAddressNotAllowed
+	}
+
+	// Detect protocol; Noise ports carry nothing to detect
+	var version ProtocolVersion
+	var peekConn *PeekableConn
+	if profile != nil && profile.NoiseOnly() {
+		version, peekConn = ProtocolV2Noise, NewPeekableConn(conn)
+	} else {
+		var err error
+		version, peekConn, err = r.detector.Detect(conn)
+		if err != nil {
+			r.recordFailedDetection()
+			conn.Close()
+			return err
+		}
+	}
+
+	if profile != nil && version != ProtocolUnknown && !profile.Allows(version) {
+		r.recordRejected()
 		conn.Close()
-		return err
+		return ErrProtocolNotAllowed
 	}
 
+	// Terminate TLS and route what it carries
+	if version == ProtocolTLS && r.detector.TLSEnabled() {
+		var err error
+		version, peekConn, err = r.detector.DetectTLS(peekConn)
+		if errors.Is(err, ErrTLSHandshakeFailed) {
+			r.observeTLS(err)
+			conn.Close()
+			return err
+		}
+		r.observeTLS(nil)
+		if err != nil {
+			r.recordFailedDetection()
+			conn.Close()
+			return err
+		}
+		if profile != nil && version != ProtocolUnknown && !profile.AllowsInsideTLS(version) {
+			r.recordRejected()
+			conn.Close()
+			return ErrProtocolNotAllowed
+		}
+	}
+	peekConn.Profile = profile
+
 	// Get handler
 	r.mu.RLock()
 	handler, ok := r.handlers[version]
@@ -358,11 +443,23 @@ func (r *Router) recordConnection(version ProtocolVersion) {
 	switch version {
 	case ProtocolV1:
 		r.v1Connections++
-	case ProtocolV2:
+	case ProtocolV2, ProtocolV2Noise:
 		r.v2Connections++
 	}
 }
 
+func (r *Router) observeTLS(err error) {
+	if r.tlsObserver != nil {
+		r.tlsObserver(err)
+	}
+}
+
+func (r *Router) recordRejected() {
+	r.metricsmu.Lock()
+	defer r.metricsmu.Unlock()
+	r.rejectedConnections++
+}
+
 func (r *Router) recordFailedDetection() {
 	r.metricsmu.Lock()
 	defer r.metricsmu.Unlock()
@@ -376,6 +473,13 @@ func (r *Router) GetMetrics() (v1, v2, failed uint64) {
 	return r.v1Connections, r.v2Connections, r.failedDetections
 }
 
+// GetRejected returns connections refused by a port profile
+func (r *Router) GetRejected() uint64 {
+	r.metricsmu.Lock()
+	defer r.metricsmu.Unlock()
+	return r.rejectedConnections
+}
+
 // =============================================================================
 // Connection Info
 // =============================================================================
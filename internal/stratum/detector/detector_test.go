@@ -27,6 +27,8 @@ func TestProtocolVersion_String(t *testing.T) {
 	}{
 		{ProtocolV1, "stratum-v1"},
 		{ProtocolV2, "stratum-v2"},
+		{ProtocolV2Noise, "stratum-v2-noise"},
+		{ProtocolTLS, "stratum-tls"},
 		{ProtocolUnknown, "unknown"},
 	}
 
@@ -171,6 +173,7 @@ func TestDetector_DetectFromBytes(t *testing.T) {
 		{"V1 JSON start", []byte(`{"id":1}`), ProtocolV1},
 		{"V2 binary header", []byte{0x00, 0x00, 0x00, 0x64, 0x00, 0x00}, ProtocolV2},
 		{"Empty", []byte{}, ProtocolUnknown},
+		{"TLS ClientHello", []byte{0x16, 0x03, 0x01, 0x02, 0x00, 0x01}, ProtocolTLS},
 		{"Random binary", []byte{0xFF, 0xFF, 0xFF}, ProtocolUnknown},
 	}
 
@@ -436,6 +439,7 @@ func BenchmarkRouter_Route(b *testing.B) {
 type mockConn struct {
 	reader     *bytes.Reader
 	remoteAddr net.Addr
+	localPort  int // 0 means 3333
 	closed     bool
 	mu         sync.Mutex
 }
@@ -480,7 +484,11 @@ func (m *mockConn) Close() error {
 }
 
 func (m *mockConn) LocalAddr() net.Addr {
-	return &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 3333}
+	port := m.localPort
+	if port == 0 {
+		port = 3333
+	}
+	return &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: port}
 }
 
 func (m *mockConn) RemoteAddr() net.Addr {
@@ -503,6 +511,7 @@ func (m *mockConn) SetWriteDeadline(t time.Time) error {
 type mockHandler struct {
 	protocol    ProtocolVersion
 	handleCount int
+	lastConn    net.Conn
 	shutdown    bool
 	mu          sync.Mutex
 }
@@ -511,6 +520,7 @@ func (h *mockHandler) HandleConnection(conn net.Conn) error {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 	h.handleCount++
+	h.lastConn = conn
 	return nil
 }
 
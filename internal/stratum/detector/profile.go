@@ -0,0 +1,275 @@
+package detector
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+)
+
+// =============================================================================
+// PORT PROFILES
+// Each listening port serves one class of miners: which protocols it speaks,
+// the difficulty range it hands out, whether it forces solo mining and which
+// networks may connect. The router applies a profile to every connection
+// accepted on its port before handing it to a protocol handler.
+// =============================================================================
+
+// Profile errors
+var (
+	ErrAddressNotAllowed  = errors.New("remote address not allowed on this port")
+	ErrProtocolNotAllowed = errors.New("protocol not allowed on this port")
+	ErrInvalidProfile     = errors.New("invalid port profile")
+)
+
+// PortProfile configures one stratum listening port
+type PortProfile struct {
+	Name      string            // Label for logs and stats, e.g. "x100"
+	Address   string            // Bind address, e.g. "0.0.0.0:3334"
+	Protocols []ProtocolVersion // Allowed protocols; empty allows V1 and plaintext V2
+
+	// Difficulty; zero values keep the vardiff policy's settings
+	InitialDifficulty float64
+	MinDifficulty     float64
+	MaxDifficulty     float64
+	VardiffPolicy     string // Vardiff policy name; "" uses the pool default
+
+	ForceSolo    bool         // Shares from this port are mined solo
+	AllowedCIDRs []*net.IPNet // Empty allows every remote address
+}
+
+// defaultProtocols are allowed when a profile lists none
+var defaultProtocols = []ProtocolVersion{ProtocolV1, ProtocolV2}
+
+// ParseProtocol parses a protocol name as used in port configuration:
+// "v1", "v2", "v2-noise" or "tls"
+func ParseProtocol(name string) (ProtocolVersion, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "v1", "stratum-v1":
+		return ProtocolV1, nil
+	case "v2", "stratum-v2":
+		return ProtocolV2, nil
+	case "v2-noise", "noise", "stratum-v2-noise":
+		return ProtocolV2Noise, nil
+	case "tls", "stratum-tls":
+		return ProtocolTLS, nil
+	default:
+		return ProtocolUnknown, fmt.Errorf("%w: unknown protocol %q", ErrInvalidProfile, name)
+	}
+}
+
+// ParseCIDRs parses a list of CIDRs; bare IPs are treated as single hosts
+func ParseCIDRs(values []string) ([]*net.IPNet, error) {
+	var networks []*net.IPNet
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if !strings.Contains(value, "/") {
+			ip := net.ParseIP(value)
+			if ip == nil {
+				return nil, fmt.Errorf("%w: invalid address %q", ErrInvalidProfile, value)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidProfile, err)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// Port returns the profile's port number as a string
+func (p *PortProfile) Port() string {
+	_, port, err := net.SplitHostPort(p.Address)
+	if err != nil {
+		return ""
+	}
+	return port
+}
+
+// AllowedProtocols returns the protocols the port accepts
+func (p *PortProfile) AllowedProtocols() []ProtocolVersion {
+	if len(p.Protocols) == 0 {
+		return defaultProtocols
+	}
+	return p.Protocols
+}
+
+// Allows reports whether the port accepts a protocol
+func (p *PortProfile) Allows(version ProtocolVersion) bool {
+	for _, allowed := range p.AllowedProtocols() {
+		if allowed == version {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsInsideTLS reports whether a protocol may run inside TLS on the port.
+// Ports that list tls alone accept V1 and V2 inside it.
+func (p *PortProfile) AllowsInsideTLS(version ProtocolVersion) bool {
+	if !p.Allows(ProtocolTLS) || version == ProtocolTLS || version == ProtocolV2Noise {
+		return false
+	}
+	if len(p.Protocols) == 1 {
+		return version == ProtocolV1 || version == ProtocolV2
+	}
+	return p.Allows(version)
+}
+
+// NoiseOnly reports whether the port speaks Noise-encrypted V2. A Noise
+// handshake cannot be told apart from other traffic, so such ports are
+// dedicated to it and skip detection.
+func (p *PortProfile) NoiseOnly() bool {
+	return p.Allows(ProtocolV2Noise)
+}
+
+// AllowsAddr reports whether a remote address may connect
+func (p *PortProfile) AllowsAddr(addr net.Addr) bool {
+	if len(p.AllowedCIDRs) == 0 {
+		return true
+	}
+
+	var ip net.IP
+	switch a := addr.(type) {
+	case *net.TCPAddr:
+		ip = a.IP
+	default:
+		if addr == nil {
+			return false
+		}
+		host, _, err := net.SplitHostPort(addr.String())
+		if err != nil {
+			host = addr.String()
+		}
+		ip = net.ParseIP(host)
+	}
+	if ip == nil {
+		return false
+	}
+
+	for _, network := range p.AllowedCIDRs {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the profile for configuration errors
+func (p *PortProfile) Validate() error {
+	if p.Port() == "" {
+		return fmt.Errorf("%w: address %q has no port", ErrInvalidProfile, p.Address)
+	}
+	for _, version := range p.Protocols {
+		if version == ProtocolUnknown {
+			return fmt.Errorf("%w: port %s lists an unknown protocol", ErrInvalidProfile, p.Port())
+		}
+	}
+	if p.NoiseOnly() && len(p.Protocols) > 1 {
+		return fmt.Errorf("%w: port %s: v2-noise cannot share a port with other protocols", ErrInvalidProfile, p.Port())
+	}
+	if p.MinDifficulty < 0 || p.MaxDifficulty < 0 || p.InitialDifficulty < 0 {
+		return fmt.Errorf("%w: port %s: difficulties must not be negative", ErrInvalidProfile, p.Port())
+	}
+	if p.MaxDifficulty > 0 && p.MinDifficulty > p.MaxDifficulty {
+		return fmt.Errorf("%w: port %s: min difficulty %g above max %g", ErrInvalidProfile, p.Port(), p.MinDifficulty, p.MaxDifficulty)
+	}
+	return nil
+}
+
+// String describes the profile for logs
+func (p *PortProfile) String() string {
+	names := make([]string, 0, len(p.AllowedProtocols()))
+	for _, version := range p.AllowedProtocols() {
+		names = append(names, version.String())
+	}
+	label := p.Name
+	if label == "" {
+		label = "port " + p.Port()
+	}
+	return fmt.Sprintf("%s (%s: %s)", label, p.Address, strings.Join(names, ", "))
+}
+
+// =============================================================================
+// Profile lookup on routed connections
+// =============================================================================
+
+// WithProfile wraps a connection so handlers further down (e.g. behind a
+// Noise or TLS layer) can still find its port profile
+func WithProfile(conn net.Conn, profile *PortProfile) net.Conn {
+	pc := NewPeekableConn(conn)
+	pc.Profile = profile
+	return pc
+}
+
+// ProfileOf returns the port profile a routed connection was accepted under,
+// or nil if its port has none
+func ProfileOf(conn net.Conn) *PortProfile {
+	if pc, ok := conn.(*PeekableConn); ok {
+		return pc.Profile
+	}
+	return nil
+}
+
+// AddProfile applies a profile to connections accepted on its port
+func (r *Router) AddProfile(profile *PortProfile) error {
+	if err := profile.Validate(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.profiles[profile.Port()]; exists {
+		return fmt.Errorf("%w: port %s configured twice", ErrInvalidProfile, profile.Port())
+	}
+	r.profiles[profile.Port()] = profile
+	return nil
+}
+
+// Profile returns the profile for a port
+func (r *Router) Profile(port string) (*PortProfile, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	profile, ok := r.profiles[port]
+	return profile, ok
+}
+
+// Profiles returns every configured profile ordered by port
+func (r *Router) Profiles() []*PortProfile {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	profiles := make([]*PortProfile, 0, len(r.profiles))
+	for _, profile := range r.profiles {
+		profiles = append(profiles, profile)
+	}
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i].Port() < profiles[j].Port() })
+	return profiles
+}
+
+// profileFor returns the profile of the port a connection was accepted on
+func (r *Router) profileFor(conn net.Conn) *PortProfile {
+	addr := conn.LocalAddr()
+	if addr == nil {
+		return nil
+	}
+	_, port, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return nil
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.profiles[port]
+}
@@ -0,0 +1,194 @@
+package detector
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// -----------------------------------------------------------------------------
+// PortProfile Tests
+// -----------------------------------------------------------------------------
+
+func TestParseProtocol(t *testing.T) {
+	for name, expected := range map[string]ProtocolVersion{
+		"v1":       ProtocolV1,
+		"V2":       ProtocolV2,
+		"v2-noise": ProtocolV2Noise,
+		" tls ":    ProtocolTLS,
+	} {
+		version, err := ParseProtocol(name)
+		require.NoError(t, err, name)
+		assert.Equal(t, expected, version, name)
+	}
+
+	_, err := ParseProtocol("v3")
+	assert.ErrorIs(t, err, ErrInvalidProfile)
+}
+
+func TestParseCIDRs(t *testing.T) {
+	networks, err := ParseCIDRs([]string{"10.0.0.0/8", "192.168.1.7", " ", "::1"})
+	require.NoError(t, err)
+	require.Len(t, networks, 3)
+	assert.Equal(t, "192.168.1.7/32", networks[1].String())
+	assert.Equal(t, "::1/128", networks[2].String())
+
+	_, err = ParseCIDRs([]string{"10.0.0.0/33"})
+	assert.ErrorIs(t, err, ErrInvalidProfile)
+	_, err = ParseCIDRs([]string{"not-an-ip"})
+	assert.ErrorIs(t, err, ErrInvalidProfile)
+}
+
+func TestPortProfile_Allows(t *testing.T) {
+	defaults := &PortProfile{Address: ":3333"}
+	assert.True(t, defaults.Allows(ProtocolV1))
+	assert.True(t, defaults.Allows(ProtocolV2))
+	assert.False(t, defaults.Allows(ProtocolTLS))
+	assert.False(t, defaults.NoiseOnly())
+
+	noise := &PortProfile{Address: ":3336", Protocols: []ProtocolVersion{ProtocolV2Noise}}
+	assert.True(t, noise.NoiseOnly())
+	assert.False(t, noise.Allows(ProtocolV1))
+}
+
+func TestPortProfile_AllowsAddr(t *testing.T) {
+	networks, err := ParseCIDRs([]string{"10.0.0.0/8"})
+	require.NoError(t, err)
+	profile := &PortProfile{Address: ":3335", AllowedCIDRs: networks}
+
+	assert.True(t, profile.AllowsAddr(&net.TCPAddr{IP: net.ParseIP("10.1.2.3"), Port: 4000}))
+	assert.False(t, profile.AllowsAddr(&net.TCPAddr{IP: net.ParseIP("192.168.1.1"), Port: 4000}))
+	assert.False(t, profile.AllowsAddr(nil))
+
+	open := &PortProfile{Address: ":3333"}
+	assert.True(t, open.AllowsAddr(&net.TCPAddr{IP: net.ParseIP("192.168.1.1"), Port: 4000}))
+}
+
+func TestPortProfile_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile PortProfile
+		valid   bool
+	}{
+		{"defaults", PortProfile{Address: "0.0.0.0:3333"}, true},
+		{"no port", PortProfile{Address: "0.0.0.0"}, false},
+		{"noise alone", PortProfile{Address: ":3336", Protocols: []ProtocolVersion{ProtocolV2Noise}}, true},
+		{"noise shared", PortProfile{Address: ":3336", Protocols: []ProtocolVersion{ProtocolV2Noise, ProtocolV1}}, false},
+		{"unknown protocol", PortProfile{Address: ":3333", Protocols: []ProtocolVersion{ProtocolUnknown}}, false},
+		{"min above max", PortProfile{Address: ":3333", MinDifficulty: 10, MaxDifficulty: 1}, false},
+		{"negative", PortProfile{Address: ":3333", InitialDifficulty: -1}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.profile.Validate()
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrInvalidProfile)
+			}
+		})
+	}
+}
+
+// -----------------------------------------------------------------------------
+// Router Profile Tests
+// -----------------------------------------------------------------------------
+
+func TestRouter_AddProfile(t *testing.T) {
+	router := NewRouter()
+	require.NoError(t, router.AddProfile(&PortProfile{Name: "x100", Address: ":3334"}))
+	require.NoError(t, router.AddProfile(&PortProfile{Name: "low-end", Address: ":3333"}))
+
+	assert.ErrorIs(t, router.AddProfile(&PortProfile{Address: "127.0.0.1:3333"}), ErrInvalidProfile)
+
+	profile, ok := router.Profile("3334")
+	require.True(t, ok)
+	assert.Equal(t, "x100", profile.Name)
+
+	profiles := router.Profiles()
+	require.Len(t, profiles, 2)
+	assert.Equal(t, "low-end", profiles[0].Name)
+}
+
+func TestRouter_RouteByProfile(t *testing.T) {
+	router := NewRouter()
+	v1Handler := &mockHandler{protocol: ProtocolV1}
+	v2Handler := &mockHandler{protocol: ProtocolV2}
+	router.RegisterHandler(ProtocolV1, v1Handler)
+	router.RegisterHandler(ProtocolV2, v2Handler)
+
+	x100 := &PortProfile{Name: "x100", Address: ":3334", Protocols: []ProtocolVersion{ProtocolV2}}
+	require.NoError(t, router.AddProfile(x100))
+
+	v1Data := []byte(`{"id":1,"method":"mining.subscribe"}`)
+	v2Data := []byte{0x00, 0x00, 0x00, 0x64, 0x00, 0x00}
+
+	// V2 is allowed and the handler sees the profile
+	conn := newMockConn(v2Data)
+	conn.localPort = 3334
+	require.NoError(t, router.Route(conn))
+	assert.Equal(t, 1, v2Handler.handleCount)
+	assert.Same(t, x100, ProfileOf(v2Handler.lastConn))
+
+	// V1 is refused on the V2-only port
+	conn = newMockConn(v1Data)
+	conn.localPort = 3334
+	assert.ErrorIs(t, router.Route(conn), ErrProtocolNotAllowed)
+	assert.True(t, conn.closed)
+	assert.Equal(t, 0, v1Handler.handleCount)
+
+	// Ports without a profile keep the unrestricted behaviour
+	require.NoError(t, router.Route(newMockConn(v1Data)))
+	assert.Equal(t, 1, v1Handler.handleCount)
+	assert.Nil(t, ProfileOf(v1Handler.lastConn))
+
+	assert.Equal(t, uint64(1), router.GetRejected())
+}
+
+func TestRouter_RouteRejectsOutsideCIDRs(t *testing.T) {
+	router := NewRouter()
+	handler := &mockHandler{protocol: ProtocolV1}
+	router.RegisterHandler(ProtocolV1, handler)
+
+	networks, err := ParseCIDRs([]string{"10.0.0.0/8"})
+	require.NoError(t, err)
+	require.NoError(t, router.AddProfile(&PortProfile{Name: "solo", Address: ":3335", ForceSolo: true, AllowedCIDRs: networks}))
+
+	data := []byte(`{"id":1}`)
+	outside := newMockConnWithAddr(data, "192.168.1.10:5000")
+	outside.localPort = 3335
+	assert.ErrorIs(t, router.Route(outside), ErrAddressNotAllowed)
+
+	inside := newMockConnWithAddr(data, "10.4.0.9:5000")
+	inside.localPort = 3335
+	require.NoError(t, router.Route(inside))
+	require.Equal(t, 1, handler.handleCount)
+	assert.True(t, ProfileOf(handler.lastConn).ForceSolo)
+}
+
+func TestRouter_RouteNoisePortSkipsDetection(t *testing.T) {
+	router := NewRouter()
+	noiseHandler := &mockHandler{protocol: ProtocolV2Noise}
+	router.RegisterHandler(ProtocolV2Noise, noiseHandler)
+	require.NoError(t, router.AddProfile(&PortProfile{Address: ":3336", Protocols: []ProtocolVersion{ProtocolV2Noise}}))
+
+	// A Noise ephemeral key is random bytes, here ones that look like V1
+	conn := newMockConn([]byte(`{"looks":"like json"}`))
+	conn.localPort = 3336
+	require.NoError(t, router.Route(conn))
+	assert.Equal(t, 1, noiseHandler.handleCount)
+
+	v1, v2, _ := router.GetMetrics()
+	assert.Equal(t, uint64(0), v1)
+	assert.Equal(t, uint64(1), v2)
+}
+
+func TestWithProfile(t *testing.T) {
+	profile := &PortProfile{Address: ":3336"}
+	wrapped := WithProfile(newMockConn(nil), profile)
+	assert.Same(t, profile, ProfileOf(wrapped))
+	assert.Nil(t, ProfileOf(newMockConn(nil)))
+}
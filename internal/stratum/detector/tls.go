@@ -0,0 +1,185 @@
+package detector
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// =============================================================================
+// TLS TERMINATION
+// stratum+ssl miners and proxies open with a TLS ClientHello. When the
+// detector has a TLS config it terminates TLS on such connections and runs
+// V1/V2 detection on the decrypted stream, so every handler works unchanged.
+// Certificates come from a PEM file pair that is reloaded on change; live
+// sessions keep the certificate they negotiated.
+// =============================================================================
+
+// TLS errors
+var (
+	ErrTLSHandshakeFailed = errors.New("tls handshake failed")
+	ErrTLSNotConfigured   = errors.New("tls is not configured")
+)
+
+// DefaultCertPollInterval is how often Watch checks the certificate files
+const DefaultCertPollInterval = 30 * time.Second
+
+// SetTLSConfig enables TLS termination. Call before routing connections; the
+// config's GetCertificate may swap certificates at any time.
+func (d *Detector) SetTLSConfig(config *tls.Config) {
+	d.tlsConfig = config
+}
+
+// TLSEnabled reports whether the detector terminates TLS
+func (d *Detector) TLSEnabled() bool {
+	return d.tlsConfig != nil
+}
+
+// DetectTLS completes the TLS handshake on a connection detected as
+// ProtocolTLS and detects the protocol spoken inside. The returned connection
+// reads and writes plaintext and is marked TLS.
+func (d *Detector) DetectTLS(pc *PeekableConn) (ProtocolVersion, *PeekableConn, error) {
+	if d.tlsConfig == nil {
+		return ProtocolUnknown, pc, ErrTLSNotConfigured
+	}
+
+	tlsConn := tls.Server(pc, d.tlsConfig)
+	if d.timeout > 0 {
+		tlsConn.SetDeadline(time.Now().Add(d.timeout))
+	}
+	if err := tlsConn.Handshake(); err != nil {
+		return ProtocolUnknown, pc, fmt.Errorf("%w: %v", ErrTLSHandshakeFailed, err)
+	}
+	tlsConn.SetDeadline(time.Time{})
+
+	version, inner, err := d.Detect(tlsConn)
+	inner.TLS = true
+	if err != nil {
+		return ProtocolUnknown, inner, err
+	}
+	if version == ProtocolTLS {
+		// TLS inside TLS is not stratum
+		version = ProtocolUnknown
+	}
+	return version, inner, nil
+}
+
+// IsTLS reports whether a routed connection arrived over TLS
+func IsTLS(conn net.Conn) bool {
+	pc, ok := conn.(*PeekableConn)
+	return ok && pc.TLS
+}
+
+// =============================================================================
+// Certificate reloading
+// =============================================================================
+
+// CertReloader serves a certificate/key pair from disk, reloading it when
+// either file changes or Reload is called (e.g. on SIGHUP). A failed reload
+// keeps the previous certificate.
+type CertReloader struct {
+	certFile string
+	keyFile  string
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	modTime time.Time // Newest modification time of the loaded pair
+}
+
+// NewCertReloader loads the certificate/key pair
+func NewCertReloader(certFile, keyFile string) (*CertReloader, error) {
+	r := &CertReloader{certFile: certFile, keyFile: keyFile}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload reads the certificate/key pair from disk
+func (r *CertReloader) Reload() error {
+	modTime, err := r.filesModTime()
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("load tls certificate: %w", err)
+	}
+
+	r.mu.Lock()
+	r.cert = &cert
+	r.modTime = modTime
+	r.mu.Unlock()
+	return nil
+}
+
+// GetCertificate returns the current certificate (for tls.Config)
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+// TLSConfig returns a server config that always uses the current certificate
+func (r *CertReloader) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: r.GetCertificate,
+	}
+}
+
+// Changed reports whether either file was modified since the last load
+func (r *CertReloader) Changed() bool {
+	modTime, err := r.filesModTime()
+	if err != nil {
+		return false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return modTime.After(r.modTime)
+}
+
+// Watch reloads the pair whenever the files change until ctx is done.
+// onReload, if set, is called after each reload attempt.
+func (r *CertReloader) Watch(ctx context.Context, interval time.Duration, onReload func(error)) {
+	if interval <= 0 {
+		interval = DefaultCertPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !r.Changed() {
+				continue
+			}
+			err := r.Reload()
+			if onReload != nil {
+				onReload(err)
+			}
+		}
+	}
+}
+
+// filesModTime returns the newer modification time of the two files
+func (r *CertReloader) filesModTime() (time.Time, error) {
+	var newest time.Time
+	for _, path := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(path)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("stat tls file: %w", err)
+		}
+		if info.ModTime().After(newest) {
+			newest = info.ModTime()
+		}
+	}
+	return newest, nil
+}
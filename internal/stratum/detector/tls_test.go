@@ -0,0 +1,187 @@
+package detector
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// -----------------------------------------------------------------------------
+// TLS Termination Tests
+// -----------------------------------------------------------------------------
+
+// writeTestCert writes a self-signed certificate/key pair for commonName
+func writeTestCert(t *testing.T, dir, commonName string) (certFile, keyFile string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{commonName},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile = filepath.Join(dir, "stratum.crt")
+	keyFile = filepath.Join(dir, "stratum.key")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+	return certFile, keyFile
+}
+
+// servedCommonName returns the common name of a reloader's current certificate
+func servedCommonName(t *testing.T, reloader *CertReloader) string {
+	t.Helper()
+	cert, err := reloader.GetCertificate(nil)
+	require.NoError(t, err)
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+	return leaf.Subject.CommonName
+}
+
+func TestCertReloader_Reload(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeTestCert(t, dir, "old.pool")
+
+	reloader, err := NewCertReloader(certFile, keyFile)
+	require.NoError(t, err)
+	assert.Equal(t, "old.pool", servedCommonName(t, reloader))
+	assert.False(t, reloader.Changed())
+
+	// A new pair is picked up once the files change
+	writeTestCert(t, dir, "new.pool")
+	future := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(certFile, future, future))
+	assert.True(t, reloader.Changed())
+	require.NoError(t, reloader.Reload())
+	assert.Equal(t, "new.pool", servedCommonName(t, reloader))
+
+	// A broken pair keeps the current certificate
+	require.NoError(t, os.WriteFile(keyFile, []byte("garbage"), 0600))
+	assert.Error(t, reloader.Reload())
+	assert.Equal(t, "new.pool", servedCommonName(t, reloader))
+
+	_, err = NewCertReloader(filepath.Join(dir, "missing.crt"), keyFile)
+	assert.Error(t, err)
+}
+
+func TestRouter_RouteTerminatesTLS(t *testing.T) {
+	certFile, keyFile := writeTestCert(t, t.TempDir(), "pool.local")
+	reloader, err := NewCertReloader(certFile, keyFile)
+	require.NoError(t, err)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	router := NewRouterWithDetector(NewDetectorWithTimeout(2 * time.Second))
+	router.SetTLSConfig(reloader.TLSConfig())
+	var handshakes []error
+	router.SetTLSObserver(func(err error) { handshakes = append(handshakes, err) })
+	v1Handler := &mockHandler{protocol: ProtocolV1}
+	router.RegisterHandler(ProtocolV1, v1Handler)
+
+	port := listener.Addr().(*net.TCPAddr).Port
+	require.NoError(t, router.AddProfile(&PortProfile{
+		Name:      "ssl",
+		Address:   "127.0.0.1:" + strconv.Itoa(port),
+		Protocols: []ProtocolVersion{ProtocolTLS},
+	}))
+
+	routed := make(chan error, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			routed <- err
+			return
+		}
+		routed <- router.Route(conn)
+	}()
+
+	client, err := tls.Dial("tcp", listener.Addr().String(), &tls.Config{InsecureSkipVerify: true})
+	require.NoError(t, err)
+	defer client.Close()
+	_, err = client.Write([]byte(`{"id":1,"method":"mining.subscribe","params":[]}` + "\n"))
+	require.NoError(t, err)
+
+	require.NoError(t, <-routed)
+	require.Equal(t, 1, v1Handler.handleCount)
+	assert.True(t, IsTLS(v1Handler.lastConn))
+	assert.Equal(t, "ssl", ProfileOf(v1Handler.lastConn).Name)
+	assert.Equal(t, []error{nil}, handshakes)
+
+	// The handler reads plaintext, detection bytes included
+	line, err := bufio.NewReader(v1Handler.lastConn).ReadString('\n')
+	require.NoError(t, err)
+	assert.Contains(t, line, `"mining.subscribe"`)
+}
+
+func TestRouter_RouteCountsTLSHandshakeFailures(t *testing.T) {
+	certFile, keyFile := writeTestCert(t, t.TempDir(), "pool.local")
+	reloader, err := NewCertReloader(certFile, keyFile)
+	require.NoError(t, err)
+
+	router := NewRouter()
+	router.SetTLSConfig(reloader.TLSConfig())
+	var handshakes []error
+	router.SetTLSObserver(func(err error) { handshakes = append(handshakes, err) })
+	router.RegisterHandler(ProtocolV1, &mockHandler{protocol: ProtocolV1})
+
+	// A handshake record that is not a valid ClientHello
+	conn := newMockConn([]byte{0x16, 0x03, 0x01, 0x00, 0x04, 0x01, 0x00, 0x00, 0x00})
+	assert.ErrorIs(t, router.Route(conn), ErrTLSHandshakeFailed)
+	assert.True(t, conn.closed)
+	require.Len(t, handshakes, 1)
+	assert.ErrorIs(t, handshakes[0], ErrTLSHandshakeFailed)
+}
+
+func TestRouter_RouteRejectsTLSOnPlainPort(t *testing.T) {
+	certFile, keyFile := writeTestCert(t, t.TempDir(), "pool.local")
+	reloader, err := NewCertReloader(certFile, keyFile)
+	require.NoError(t, err)
+
+	router := NewRouter()
+	router.SetTLSConfig(reloader.TLSConfig())
+	require.NoError(t, router.AddProfile(&PortProfile{Address: ":3333"}))
+
+	// Refused before any handshake work
+	conn := newMockConn([]byte{0x16, 0x03, 0x01, 0x02, 0x00, 0x01})
+	assert.ErrorIs(t, router.Route(conn), ErrProtocolNotAllowed)
+	assert.Equal(t, uint64(1), router.GetRejected())
+}
+
+func TestPortProfile_AllowsInsideTLS(t *testing.T) {
+	tlsOnly := &PortProfile{Address: ":3443", Protocols: []ProtocolVersion{ProtocolTLS}}
+	assert.True(t, tlsOnly.AllowsInsideTLS(ProtocolV1))
+	assert.True(t, tlsOnly.AllowsInsideTLS(ProtocolV2))
+	assert.False(t, tlsOnly.AllowsInsideTLS(ProtocolV2Noise))
+	assert.False(t, tlsOnly.AllowsInsideTLS(ProtocolTLS))
+
+	v1AndTLS := &PortProfile{Address: ":3443", Protocols: []ProtocolVersion{ProtocolV1, ProtocolTLS}}
+	assert.True(t, v1AndTLS.AllowsInsideTLS(ProtocolV1))
+	assert.False(t, v1AndTLS.AllowsInsideTLS(ProtocolV2))
+
+	plain := &PortProfile{Address: ":3333"}
+	assert.False(t, plain.AllowsInsideTLS(ProtocolV1))
+}
@@ -0,0 +1,113 @@
+// Package events carries live stratum session events (connects,
+// authorizations, accepted shares and disconnects) to in-process subscribers
+// such as the worker monitor
+package events
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultBufferSize is the per-subscriber buffer used when none is given
+const DefaultBufferSize = 1024
+
+// Kind says what happened to a worker's connection
+type Kind string
+
+const (
+	KindConnected     Kind = "connected"      // TCP session accepted; no worker yet
+	KindAuthorized    Kind = "authorized"     // Session authorized (or resumed) as a worker
+	KindShareAccepted Kind = "share_accepted" // Valid share credited to the worker
+	KindDisconnected  Kind = "disconnected"   // Session closed
+)
+
+// WorkerEvent is one event on a stratum connection. Worker fields are zero
+// until the connection authorizes.
+type WorkerEvent struct {
+	Kind         Kind
+	Time         time.Time
+	ConnectionID string  // Stratum connection ID
+	Address      string  // Remote address
+	UserID       int64   // users table ID
+	WorkerID     int64   // miners table ID
+	WorkerName   string  // Worker part of "username.worker"
+	Difficulty   float64 // Share difficulty (KindShareAccepted)
+}
+
+// Authorized reports whether the event belongs to an authorized worker
+func (e WorkerEvent) Authorized() bool {
+	return e.WorkerID != 0
+}
+
+// Bus fans worker events out to subscribers. Publishing never blocks the
+// stratum path: events for a subscriber whose buffer is full are dropped
+// and counted.
+type Bus struct {
+	mu      sync.RWMutex
+	subs    map[int]chan WorkerEvent
+	nextID  int
+	dropped atomic.Uint64
+}
+
+// NewBus creates an empty bus
+func NewBus() *Bus {
+	return &Bus{subs: make(map[int]chan WorkerEvent)}
+}
+
+// Subscribe returns a channel of events published from now on and a function
+// that unsubscribes and closes the channel
+func (b *Bus) Subscribe(buffer int) (<-chan WorkerEvent, func()) {
+	if buffer <= 0 {
+		buffer = DefaultBufferSize
+	}
+	ch := make(chan WorkerEvent, buffer)
+
+	b.mu.Lock()
+	id := b.nextID
+	b.nextID++
+	b.subs[id] = ch
+	b.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subs, id)
+			b.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// Publish delivers an event to every subscriber. A nil bus discards events.
+func (b *Bus) Publish(event WorkerEvent) {
+	if b == nil {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, ch := range b.subs {
+		select {
+		case ch <- event:
+		default:
+			b.dropped.Add(1)
+		}
+	}
+}
+
+// Subscribers returns the number of active subscriptions
+func (b *Bus) Subscribers() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subs)
+}
+
+// Dropped returns how many events were dropped for slow subscribers
+func (b *Bus) Dropped() uint64 {
+	return b.dropped.Load()
+}
@@ -0,0 +1,47 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBus_PublishSubscribe(t *testing.T) {
+	bus := NewBus()
+	first, cancelFirst := bus.Subscribe(4)
+	second, cancelSecond := bus.Subscribe(4)
+	defer cancelSecond()
+	assert.Equal(t, 2, bus.Subscribers())
+
+	bus.Publish(WorkerEvent{Kind: KindAuthorized, ConnectionID: "c1", WorkerID: 7})
+	for _, ch := range []<-chan WorkerEvent{first, second} {
+		event := <-ch
+		assert.Equal(t, KindAuthorized, event.Kind)
+		assert.True(t, event.Authorized())
+		assert.False(t, event.Time.IsZero(), "publish stamps the time")
+	}
+
+	// Unsubscribing closes the channel and is idempotent
+	cancelFirst()
+	cancelFirst()
+	_, open := <-first
+	assert.False(t, open)
+	assert.Equal(t, 1, bus.Subscribers())
+}
+
+func TestBus_DropsForSlowSubscribers(t *testing.T) {
+	bus := NewBus()
+	ch, cancel := bus.Subscribe(1)
+	defer cancel()
+
+	bus.Publish(WorkerEvent{Kind: KindConnected})
+	bus.Publish(WorkerEvent{Kind: KindDisconnected})
+	assert.Equal(t, uint64(1), bus.Dropped())
+	require.Len(t, ch, 1)
+	assert.Equal(t, KindConnected, (<-ch).Kind)
+
+	// A nil bus discards events
+	var nilBus *Bus
+	nilBus.Publish(WorkerEvent{Kind: KindConnected})
+}
@@ -9,9 +9,9 @@ import (
 
 	"github.com/chimera-pool/chimera-pool-core/internal/stratum/blockdag"
 	"github.com/chimera-pool/chimera-pool-core/internal/stratum/detector"
-	"github.com/chimera-pool/chimera-pool-core/internal/stratum/difficulty"
 	"github.com/chimera-pool/chimera-pool-core/internal/stratum/v2/binary"
 	"github.com/chimera-pool/chimera-pool-core/internal/stratum/v2/noise"
+	"github.com/chimera-pool/chimera-pool-core/internal/stratum/vardiff"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -286,43 +286,56 @@ func TestIntegration_DifficultyTargetConversion(t *testing.T) {
 // Hardware-Aware Difficulty Integration Tests
 // -----------------------------------------------------------------------------
 
+// newHardwareVardiffManager creates a vardiff engine that seeds by hardware class
+func newHardwareVardiffManager(config vardiff.Config) *vardiff.Manager {
+	policy := vardiff.NewEMAPolicy(config)
+	policy.Seeder = vardiff.HardwareSeeder{}
+	return vardiff.NewManagerWithPolicies(vardiff.NewPolicySet(policy))
+}
+
 func TestIntegration_HardwareClassification(t *testing.T) {
-	vm := difficulty.NewVardiffManager()
+	vm := newHardwareVardiffManager(vardiff.X100OptimizedConfig())
 
-	// Register different miner types
-	cpuMiner := vm.RegisterMiner("cpu-1", "cpuminer-multi/1.3", 0)
-	assert.Equal(t, difficulty.HardwareCPU, cpuMiner.HardwareClass.Name)
+	// Attach different miner types
+	assert.Equal(t, vardiff.HardwareCPU, vardiff.ClassifyUserAgent("cpuminer-multi/1.3").Name)
+	cpuDiff := vm.Attach("cpu-1", "3333", vardiff.Hints{UserAgent: "cpuminer-multi/1.3"})
 
-	gpuMiner := vm.RegisterMiner("gpu-1", "CUDA Miner/1.0", 0)
-	assert.Equal(t, difficulty.HardwareGPU, gpuMiner.HardwareClass.Name)
+	assert.Equal(t, vardiff.HardwareGPU, vardiff.ClassifyUserAgent("CUDA Miner/1.0").Name)
+	gpuDiff := vm.Attach("gpu-1", "3333", vardiff.Hints{UserAgent: "CUDA Miner/1.0"})
 
-	x100Miner := vm.RegisterMiner("x100-1", "BlockDAG-X100/1.0", 0)
-	assert.Equal(t, difficulty.HardwareOfficialASIC, x100Miner.HardwareClass.Name)
+	assert.Equal(t, vardiff.HardwareASIC, vardiff.ClassifyUserAgent("BlockDAG-X100/1.0").Name)
+	x100Diff := vm.Attach("x100-1", "3333", vardiff.Hints{UserAgent: "BlockDAG-X100/1.0"})
 
 	// Verify different base difficulties
-	assert.Less(t, cpuMiner.GetCurrentDifficulty(), gpuMiner.GetCurrentDifficulty())
-	assert.Less(t, gpuMiner.GetCurrentDifficulty(), x100Miner.GetCurrentDifficulty())
+	assert.Less(t, cpuDiff, gpuDiff)
+	assert.Less(t, gpuDiff, x100Diff)
 }
 
 func TestIntegration_VardiffAdjustment(t *testing.T) {
 	// Use short intervals for testing
-	vm := difficulty.NewVardiffManagerWithParams(
-		100*time.Millisecond, // Target share time
-		50*time.Millisecond,  // Retarget time
-		3,                    // Min shares
-	)
+	config := vardiff.DefaultConfig()
+	config.TargetShareTime = 100 * time.Millisecond
+	config.RetargetInterval = 50 * time.Millisecond
+	config.MinDifficulty = 0.001
+	vm := newHardwareVardiffManager(config)
 
-	state := vm.RegisterMiner("test-miner", "GPU", 0)
-	initialDiff := state.GetCurrentDifficulty()
+	initialDiff := vm.Attach("test-miner", "3333", vardiff.Hints{})
 
-	// Simulate shares coming faster than target
+	// Simulate shares coming much faster than the 100ms target
+	at := time.Now()
 	for i := 0; i < 5; i++ {
-		state.RecordShare(true, false)
-		time.Sleep(10 * time.Millisecond) // Much faster than 100ms target
+		vm.RecordShareAt("test-miner", at)
+		at = at.Add(10 * time.Millisecond)
 	}
 
-	// Force adjustment by setting LastAdjustment in the past
-	state.SetDifficulty(initialDiff) // Reset to trigger fresh adjustment window
+	time.Sleep(60 * time.Millisecond)
+	newDiff, changed := vm.RecordShareAt("test-miner", at)
+	assert.True(t, changed)
+	assert.Greater(t, newDiff, initialDiff)
+
+	trace := vm.Trace("test-miner")
+	require.NotEmpty(t, trace)
+	assert.Equal(t, vardiff.DecisionRetarget, trace[len(trace)-1].Kind)
 }
 
 // -----------------------------------------------------------------------------
@@ -369,9 +382,11 @@ func TestIntegration_V2MinerFullFlow(t *testing.T) {
 	assert.True(t, len(setupPayload) > 0)
 
 	// 4. Hardware Classification & Difficulty
-	vm := difficulty.NewVardiffManager()
-	minerState := vm.RegisterMiner("x100-001", "BlockDAG-X100", 240000000)
-	assert.Equal(t, difficulty.HardwareOfficialASIC, minerState.HardwareClass.Name)
+	vm := newHardwareVardiffManager(vardiff.X100OptimizedConfig())
+	hints := vardiff.Hints{UserAgent: "BlockDAG-X100", Hashrate: 240000000}
+	assert.Equal(t, vardiff.HardwareASIC, vardiff.ClassifyHashrate(hints.Hashrate).Name)
+	initialDiff := vm.Attach("x100-001", "3334", hints)
+	assert.InDelta(t, vardiff.CalculateOptimalDifficulty(hints.Hashrate, 10), initialDiff, 1e-9)
 
 	// 5. Share Validation
 	algo := blockdag.NewScrypyVariant()
@@ -381,14 +396,14 @@ func TestIntegration_V2MinerFullFlow(t *testing.T) {
 	assert.Equal(t, 32, len(hash))
 
 	// 6. Record share
-	vm.RecordShare("x100-001", true, false)
-	stats := minerState.GetStats()
-	assert.Equal(t, uint64(1), stats.ValidShares)
+	vm.RecordShareAt("x100-001", time.Now())
+	_, shares, _ := vm.GetMinerStats("x100-001")
+	assert.Equal(t, int64(1), shares)
 }
 
 func TestIntegration_MixedMinerPool(t *testing.T) {
 	// Simulate a pool with mixed V1 and V2 miners
-	vm := difficulty.NewVardiffManager()
+	vm := newHardwareVardiffManager(vardiff.X100OptimizedConfig())
 	router := detector.NewRouter()
 
 	// Register handlers
@@ -397,7 +412,7 @@ func TestIntegration_MixedMinerPool(t *testing.T) {
 	router.RegisterHandler(detector.ProtocolV1, v1Handler)
 	router.RegisterHandler(detector.ProtocolV2, v2Handler)
 
-	// Simulate miner registrations
+	// Simulate miner connections
 	miners := []struct {
 		id        string
 		userAgent string
@@ -411,32 +426,23 @@ func TestIntegration_MixedMinerPool(t *testing.T) {
 		{"asic-1", "Antminer", false},
 	}
 
-	for _, m := range miners {
-		vm.RegisterMiner(m.id, m.userAgent, 0)
-	}
-
-	assert.Equal(t, 6, vm.GetMinerCount())
-
 	// Verify correct classification
-	stats := vm.GetAllStats()
 	gpuCount := 0
 	asicCount := 0
-	officialASICCount := 0
+	for _, m := range miners {
+		vm.Attach(m.id, "3333", vardiff.Hints{UserAgent: m.userAgent})
+		assert.Len(t, vm.Trace(m.id), 1, "seed decision traced for %s", m.id)
 
-	for _, s := range stats {
-		switch s.HardwareClass {
-		case difficulty.HardwareGPU:
+		switch vardiff.ClassifyUserAgent(m.userAgent).Name {
+		case vardiff.HardwareGPU:
 			gpuCount++
-		case difficulty.HardwareASIC:
+		case vardiff.HardwareASIC:
 			asicCount++
-		case difficulty.HardwareOfficialASIC:
-			officialASICCount++
 		}
 	}
 
 	assert.Equal(t, 2, gpuCount, "should have 2 GPU miners")
-	assert.Equal(t, 2, asicCount, "should have 2 generic ASIC miners") // X30 + Antminer
-	assert.Equal(t, 2, officialASICCount, "should have 2 X100 miners")
+	assert.Equal(t, 4, asicCount, "should have 4 ASIC miners") // X30, 2x X100 + Antminer
 }
 
 // -----------------------------------------------------------------------------
@@ -2,15 +2,19 @@ package stratum
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
+	"os"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/chimera-pool/chimera-pool-core/internal/shares"
-	"github.com/chimera-pool/chimera-pool-core/internal/stratum/difficulty"
+	"github.com/chimera-pool/chimera-pool-core/internal/stratum/detector"
+	"github.com/chimera-pool/chimera-pool-core/internal/stratum/vardiff"
 	"github.com/google/uuid"
 )
 
@@ -23,13 +27,15 @@ import (
 // PoolCoordinatorConfig configures the pool coordinator
 type PoolCoordinatorConfig struct {
 	// Network settings
-	ListenAddress  string
+	ListenAddress  string                  // Used when Ports is empty
+	Ports          []*detector.PortProfile // One listener per profile
 	MaxConnections int
 
 	// Share processing
 	ShareWorkers   int
 	ShareQueueSize int
 	ShareBatchSize int
+	Algorithm      string // Network proof-of-work algorithm, e.g. "scrypt"
 
 	// Vardiff settings
 	TargetShareTime time.Duration
@@ -54,6 +60,7 @@ func DefaultPoolCoordinatorConfig() PoolCoordinatorConfig {
 		ShareWorkers:      8,
 		ShareQueueSize:    100000,
 		ShareBatchSize:    100,
+		Algorithm:         shares.DefaultAlgorithm,
 		TargetShareTime:   10 * time.Second,
 		RetargetTime:      90 * time.Second,
 		MinShares:         3,
@@ -65,6 +72,19 @@ func DefaultPoolCoordinatorConfig() PoolCoordinatorConfig {
 	}
 }
 
+// shareProcessingTimeout bounds how long a submit waits for the batch processor
+const shareProcessingTimeout = 5 * time.Second
+
+// rejectReasonLowDifficulty is recorded for shares that miss their target
+const rejectReasonLowDifficulty = "low-difficulty"
+
+// ShareRecorder persists processed shares (ISP: write only). Implementations
+// must not block; they are called on the submit path.
+type ShareRecorder interface {
+	// RecordShare stores a share; rejectReason is empty for valid shares
+	RecordShare(share *shares.Share, rejectReason string)
+}
+
 // PoolCoordinator orchestrates all pool components
 type PoolCoordinator struct {
 	config PoolCoordinatorConfig
@@ -72,8 +92,12 @@ type PoolCoordinator struct {
 	// Core components
 	connManager    *ConnectionManager
 	shareProcessor *shares.BatchProcessor
-	vardiffManager *difficulty.VardiffManager
+	vardiffManager *vardiff.Manager
 	authenticator  MinerAuthenticator
+	shareRecorder  ShareRecorder
+
+	// Protocol routing; the built-in V1 handler serves until replaced
+	router *detector.Router
 
 	// Job management
 	currentJob   atomic.Value // *Job
@@ -81,7 +105,9 @@ type PoolCoordinator struct {
 	jobListeners []chan *Job
 
 	// Network
-	listener net.Listener
+	listeners []net.Listener
+	inherited []net.Listener // Sockets handed over by a previous process
+	accepting int32          // 1 while accept loops run (atomic)
 
 	// Lifecycle
 	ctx    context.Context
@@ -134,24 +160,30 @@ func NewPoolCoordinator(config PoolCoordinatorConfig) *PoolCoordinator {
 		QueueSize:    config.ShareQueueSize,
 		BatchSize:    config.ShareBatchSize,
 		BatchTimeout: 10 * time.Millisecond,
+		Algorithm:    config.Algorithm,
 	}
 
-	// Initialize vardiff manager
-	vardiffManager := difficulty.NewVardiffManagerWithParams(
-		config.TargetShareTime,
-		config.RetargetTime,
-		config.MinShares,
-	)
+	// Initialize vardiff: hardware-seeded EMA retargeting
+	vardiffConfig := vardiff.X100OptimizedConfig()
+	vardiffConfig.TargetShareTime = config.TargetShareTime
+	vardiffConfig.RetargetInterval = config.RetargetTime
+	vardiffPolicy := vardiff.NewEMAPolicy(vardiffConfig)
+	vardiffPolicy.Seeder = vardiff.HardwareSeeder{}
+	vardiffPolicy.Retargeter = vardiff.EMARetargeter{MinShares: config.MinShares}
+	vardiffManager := vardiff.NewManagerWithPolicies(vardiff.NewPolicySet(vardiffPolicy))
 
 	pc := &PoolCoordinator{
 		config:         config,
 		connManager:    NewConnectionManager(connConfig),
 		shareProcessor: shares.NewBatchProcessor(shareConfig),
 		vardiffManager: vardiffManager,
+		router:         detector.NewRouter(),
 		jobListeners:   make([]chan *Job, 0),
 		ctx:            ctx,
 		cancel:         cancel,
 	}
+	pc.router.RegisterHandler(detector.ProtocolV1, &coordinatorV1Handler{pc: pc})
+	pc.router.SetTLSObserver(pc.connManager.RecordTLSHandshake)
 
 	// Set connection callbacks
 	pc.connManager.config.OnConnect = pc.onMinerConnect
@@ -168,16 +200,25 @@ func (pc *PoolCoordinator) Start() error {
 	// Start connection manager
 	pc.connManager.Start()
 
-	// Start listener
-	listener, err := net.Listen("tcp", pc.config.ListenAddress)
-	if err != nil {
-		return fmt.Errorf("failed to listen on %s: %w", pc.config.ListenAddress, err)
+	// Start listeners: one per port profile, or the plain listen address
+	if len(pc.config.Ports) == 0 {
+		if err := pc.listen(pc.config.ListenAddress, nil); err != nil {
+			return err
+		}
+	}
+	for _, profile := range pc.config.Ports {
+		if err := pc.listen(profile.Address, profile); err != nil {
+			pc.closeListeners()
+			return err
+		}
 	}
-	pc.listener = listener
 
-	// Start accept loop
-	pc.wg.Add(1)
-	go pc.acceptLoop()
+	// Start accept loops
+	atomic.StoreInt32(&pc.accepting, 1)
+	for _, listener := range pc.listeners {
+		pc.wg.Add(1)
+		go pc.acceptLoop(listener)
+	}
 
 	// Start job update loop
 	pc.wg.Add(1)
@@ -194,10 +235,9 @@ func (pc *PoolCoordinator) Start() error {
 func (pc *PoolCoordinator) Stop() error {
 	pc.cancel()
 
-	if pc.listener != nil {
-		pc.listener.Close()
-	}
+	pc.StopAccepting()
 
+	pc.router.Close()
 	pc.connManager.Stop()
 	pc.shareProcessor.Stop()
 
@@ -205,12 +245,170 @@ func (pc *PoolCoordinator) Stop() error {
 	return nil
 }
 
+// listen binds a listener and applies its port profile, if any, to routing
+// and vardiff. Profiles bound to port 0 are recorded with the real port.
+func (pc *PoolCoordinator) listen(address string, profile *detector.PortProfile) error {
+	listener, err := pc.inheritedListener(address)
+	if listener == nil && err == nil {
+		listener, err = net.Listen("tcp", address)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", address, err)
+	}
+
+	if profile != nil {
+		bound := *profile
+		bound.Address = listener.Addr().String()
+		if err := pc.router.AddProfile(&bound); err != nil {
+			listener.Close()
+			return err
+		}
+		bounds := vardiff.DifficultyBounds{
+			Initial: bound.InitialDifficulty,
+			Min:     bound.MinDifficulty,
+			Max:     bound.MaxDifficulty,
+		}
+		if err := pc.vardiffManager.Policies().AssignPortBounds(bound.Port(), bound.VardiffPolicy, bounds); err != nil {
+			listener.Close()
+			return fmt.Errorf("port %s vardiff: %w", bound.Port(), err)
+		}
+	}
+
+	pc.listeners = append(pc.listeners, listener)
+	return nil
+}
+
+// inheritedListener returns the handed-over socket bound to an address's
+// port, or nil if there is none
+func (pc *PoolCoordinator) inheritedListener(address string) (net.Listener, error) {
+	_, port, err := net.SplitHostPort(address)
+	if err != nil || port == "0" {
+		return nil, nil
+	}
+	for i, listener := range pc.inherited {
+		if vardiff.PortOf(listener.Addr()) == port {
+			pc.inherited = append(pc.inherited[:i], pc.inherited[i+1:]...)
+			return listener, nil
+		}
+	}
+	return nil, nil
+}
+
+// closeListeners stops accepting on every port
+func (pc *PoolCoordinator) closeListeners() {
+	for _, listener := range pc.listeners {
+		listener.Close()
+	}
+}
+
+// StopAccepting closes every listener so no new miners connect, leaving
+// established connections running, e.g. while they are asked to reconnect
+// elsewhere before Stop
+func (pc *PoolCoordinator) StopAccepting() {
+	if atomic.SwapInt32(&pc.accepting, 0) == 0 {
+		return
+	}
+	pc.closeListeners()
+}
+
+// SetInheritedListeners hands the coordinator sockets from a previous
+// process; Start uses them for ports they are bound to instead of binding
+// again, so a restart refuses no connections. Call before Start.
+func (pc *PoolCoordinator) SetInheritedListeners(listeners []net.Listener) {
+	pc.inherited = listeners
+}
+
+// ListenerFiles duplicates the listening sockets for a successor process
+// (e.g. via exec.Cmd.ExtraFiles). The caller closes the files.
+func (pc *PoolCoordinator) ListenerFiles() ([]*os.File, error) {
+	files := make([]*os.File, 0, len(pc.listeners))
+	for _, listener := range pc.listeners {
+		tcp, ok := listener.(*net.TCPListener)
+		if !ok {
+			continue
+		}
+		file, err := tcp.File()
+		if err != nil {
+			for _, f := range files {
+				f.Close()
+			}
+			return nil, fmt.Errorf("listener %s: %w", listener.Addr(), err)
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// Addrs returns the addresses the coordinator is listening on
+func (pc *PoolCoordinator) Addrs() []net.Addr {
+	addrs := make([]net.Addr, 0, len(pc.listeners))
+	for _, listener := range pc.listeners {
+		addrs = append(addrs, listener.Addr())
+	}
+	return addrs
+}
+
+// Profiles returns the port profiles in use, with their bound addresses
+func (pc *PoolCoordinator) Profiles() []*detector.PortProfile {
+	return pc.router.Profiles()
+}
+
 // SetAuthenticator configures the miner authenticator
 // Must be called before Start() for production use
 func (pc *PoolCoordinator) SetAuthenticator(auth MinerAuthenticator) {
 	pc.authenticator = auth
 }
 
+// SetShareRecorder configures where the built-in V1 handler persists shares
+func (pc *PoolCoordinator) SetShareRecorder(recorder ShareRecorder) {
+	pc.shareRecorder = recorder
+}
+
+// SetVardiffManager replaces the coordinator's vardiff engine, e.g. to share
+// one set of port and user policies with other listeners. Call before Start.
+func (pc *PoolCoordinator) SetVardiffManager(manager *vardiff.Manager) {
+	pc.vardiffManager = manager
+}
+
+// SetTLSConfig terminates TLS on ports whose profile allows tls, before
+// protocol detection. Use a GetCertificate-based config to rotate
+// certificates without dropping sessions. Call before Start.
+func (pc *PoolCoordinator) SetTLSConfig(config *tls.Config) {
+	pc.router.SetTLSConfig(config)
+}
+
+// RegisterProtocolHandler routes connections of handler.Protocol() to handler,
+// replacing any handler registered for that protocol (including the built-in
+// V1 handler). Register a detector.ProtocolUnknown handler to receive
+// connections that match no protocol. Connections stay tracked by the
+// connection manager while the handler runs.
+func (pc *PoolCoordinator) RegisterProtocolHandler(handler detector.Handler) {
+	pc.router.RegisterHandler(handler.Protocol(), handler)
+}
+
+// UseAlgorithm switches share validation when the active network changes
+func (pc *PoolCoordinator) UseAlgorithm(name string) error {
+	return pc.shareProcessor.UseAlgorithm(name)
+}
+
+// ProcessShare validates a share on the batch processor's worker pool and
+// waits for the result. Protocol handlers use it so that proof-of-work
+// checks share one bounded pool and the coordinator's statistics.
+func (pc *PoolCoordinator) ProcessShare(share *shares.Share) shares.ShareProcessingResult {
+	startTime := time.Now()
+	atomic.AddInt64(&pc.stats.TotalSharesReceived, 1)
+
+	result := pc.shareProcessor.SubmitSync(share, shareProcessingTimeout)
+	pc.updateShareLatency(time.Since(startTime).Nanoseconds())
+
+	if result.Success {
+		atomic.AddInt64(&pc.stats.TotalSharesAccepted, 1)
+	} else {
+		atomic.AddInt64(&pc.stats.TotalSharesRejected, 1)
+	}
+	return result
+}
+
 // GetStats returns current pool statistics (lock-free)
 func (pc *PoolCoordinator) GetStats() PoolStats {
 	return PoolStats{
@@ -229,6 +427,12 @@ func (pc *PoolCoordinator) GetStats() PoolStats {
 	}
 }
 
+// ConnectionStats returns connection manager statistics, including TLS
+// handshake counts
+func (pc *PoolCoordinator) ConnectionStats() ConnectionStats {
+	return pc.connManager.GetStats()
+}
+
 // SetCurrentJob updates the current mining job and broadcasts to all miners
 func (pc *PoolCoordinator) SetCurrentJob(job *Job) {
 	pc.currentJob.Store(job)
@@ -265,7 +469,7 @@ func (pc *PoolCoordinator) SubscribeToJobs() <-chan *Job {
 
 // Internal methods
 
-func (pc *PoolCoordinator) acceptLoop() {
+func (pc *PoolCoordinator) acceptLoop(listener net.Listener) {
 	defer pc.wg.Done()
 
 	for {
@@ -276,11 +480,11 @@ func (pc *PoolCoordinator) acceptLoop() {
 		}
 
 		// Set accept deadline to allow checking context
-		pc.listener.(*net.TCPListener).SetDeadline(time.Now().Add(time.Second))
+		listener.(*net.TCPListener).SetDeadline(time.Now().Add(time.Second))
 
-		conn, err := pc.listener.Accept()
+		conn, err := listener.Accept()
 		if err != nil {
-			if pc.ctx.Err() != nil {
+			if pc.ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
 				return
 			}
 			continue
@@ -302,6 +506,7 @@ func (pc *PoolCoordinator) handleConnection(conn net.Conn) {
 
 	// Create managed connection
 	ctx, cancel := context.WithCancel(pc.ctx)
+	defer cancel()
 	managedConn := &ManagedConnection{
 		ID:           uuid.New().String(),
 		Conn:         conn,
@@ -313,21 +518,82 @@ func (pc *PoolCoordinator) handleConnection(conn net.Conn) {
 		cancel:       cancel,
 	}
 
-	// Register with connection manager
+	// Register with connection manager before detection so limits apply to
+	// every protocol
 	if err := pc.connManager.AddConnection(managedConn); err != nil {
 		return
 	}
 	defer pc.connManager.RemoveConnection(managedConn.ID, "connection closed")
 
-	// Start sender goroutine
-	pc.wg.Add(1)
-	go pc.connectionSender(managedConn)
+	// Detect the protocol and hand off to its handler
+	pc.router.Route(&trackedConn{Conn: conn, managed: managedConn})
+}
+
+// trackedConn ties a routed connection to its connection manager entry and
+// records read activity for the idle reaper
+type trackedConn struct {
+	net.Conn
+	managed *ManagedConnection
+}
+
+func (c *trackedConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if n > 0 {
+		c.managed.LastActivity = time.Now()
+		atomic.AddInt64(&c.managed.BytesReceived, int64(n))
+	}
+	return n, err
+}
+
+// managedConnection returns the connection manager entry of a routed
+// connection, looking through detection and TLS wrappers
+func managedConnection(conn net.Conn) (*ManagedConnection, bool) {
+	for {
+		switch c := conn.(type) {
+		case *trackedConn:
+			return c.managed, true
+		case *detector.PeekableConn:
+			conn = c.Conn
+		case *tls.Conn:
+			conn = c.NetConn()
+		default:
+			return nil, false
+		}
+	}
+}
+
+// coordinatorV1Handler is the built-in Stratum V1 handler
+type coordinatorV1Handler struct {
+	pc *PoolCoordinator
+}
+
+// HandleConnection serves a Stratum V1 connection until it closes
+func (h *coordinatorV1Handler) HandleConnection(conn net.Conn) error {
+	managedConn, ok := managedConnection(conn)
+	if !ok {
+		return ErrConnectionNotFound
+	}
+
+	// Start sender goroutine, writing through conn so TLS sessions stay encrypted
+	h.pc.wg.Add(1)
+	go h.pc.connectionSender(managedConn, conn)
+
+	// Process messages, reading through conn to include the detection bytes
+	h.pc.processMessages(managedConn, conn)
+	return nil
+}
 
-	// Process messages
-	pc.processMessages(managedConn)
+// Protocol returns the protocol served
+func (h *coordinatorV1Handler) Protocol() detector.ProtocolVersion {
+	return detector.ProtocolV1
 }
 
-func (pc *PoolCoordinator) connectionSender(conn *ManagedConnection) {
+// Shutdown is a no-op; connections close with the connection manager
+func (h *coordinatorV1Handler) Shutdown() error {
+	return nil
+}
+
+func (pc *PoolCoordinator) connectionSender(conn *ManagedConnection, writer net.Conn) {
 	defer pc.wg.Done()
 
 	for {
@@ -335,8 +601,8 @@ func (pc *PoolCoordinator) connectionSender(conn *ManagedConnection) {
 		case <-conn.ctx.Done():
 			return
 		case msg := <-conn.SendChan:
-			conn.Conn.SetWriteDeadline(time.Now().Add(pc.config.WriteTimeout))
-			if _, err := conn.Conn.Write(append(msg, '\n')); err != nil {
+			writer.SetWriteDeadline(time.Now().Add(pc.config.WriteTimeout))
+			if _, err := writer.Write(append(msg, '\n')); err != nil {
 				return
 			}
 			atomic.AddInt64(&conn.BytesSent, int64(len(msg)+1))
@@ -344,7 +610,7 @@ func (pc *PoolCoordinator) connectionSender(conn *ManagedConnection) {
 	}
 }
 
-func (pc *PoolCoordinator) processMessages(conn *ManagedConnection) {
+func (pc *PoolCoordinator) processMessages(conn *ManagedConnection, reader net.Conn) {
 	buffer := make([]byte, 4096)
 	var messageBuffer []byte
 
@@ -355,13 +621,12 @@ func (pc *PoolCoordinator) processMessages(conn *ManagedConnection) {
 		default:
 		}
 
-		conn.Conn.SetReadDeadline(time.Now().Add(pc.config.ReadTimeout))
-		n, err := conn.Conn.Read(buffer)
+		reader.SetReadDeadline(time.Now().Add(pc.config.ReadTimeout))
+		n, err := reader.Read(buffer)
 		if err != nil {
 			return
 		}
 
-		atomic.AddInt64(&conn.BytesReceived, int64(n))
 		messageBuffer = append(messageBuffer, buffer[:n]...)
 
 		// Process complete messages (newline delimited)
@@ -381,7 +646,6 @@ func (pc *PoolCoordinator) processMessages(conn *ManagedConnection) {
 			messageBuffer = messageBuffer[idx+1:]
 
 			if len(message) > 0 {
-				conn.LastActivity = time.Now()
 				pc.handleMessage(conn, message)
 			}
 		}
@@ -426,8 +690,8 @@ func (pc *PoolCoordinator) handleSubscribe(conn *ManagedConnection, id interface
 		}
 	}
 
-	// Register with vardiff using user agent for hardware classification
-	pc.vardiffManager.RegisterMiner(conn.ID, userAgent, 0)
+	// Seed vardiff from the port's policy, using the user agent for hardware classification
+	initialDiff := pc.vardiffManager.Attach(conn.ID, vardiff.PortOf(conn.Conn.LocalAddr()), vardiff.Hints{UserAgent: userAgent})
 
 	// Send subscribe response
 	response := map[string]interface{}{
@@ -446,7 +710,6 @@ func (pc *PoolCoordinator) handleSubscribe(conn *ManagedConnection, id interface
 	pc.sendJSON(conn, response)
 
 	// Send initial difficulty
-	initialDiff := pc.vardiffManager.GetDifficulty(conn.ID)
 	conn.Difficulty = initialDiff
 	pc.sendDifficulty(conn, initialDiff)
 
@@ -489,6 +752,12 @@ func (pc *PoolCoordinator) handleAuthorize(conn *ManagedConnection, id interface
 		conn.UserID = result.UserID
 		conn.MinerID = result.MinerID
 		conn.Authorized = true
+
+		// The user may have its own vardiff policy
+		if newDiff, changed := pc.vardiffManager.SetWorker(conn.ID, result.Username, workerName); changed && conn.Subscribed {
+			conn.Difficulty = newDiff
+			defer pc.sendDifficulty(conn, newDiff)
+		}
 	} else {
 		// Fallback: accept all valid-looking worker names (dev mode)
 		conn.WorkerName = workerName
@@ -506,18 +775,19 @@ func (pc *PoolCoordinator) handleAuthorize(conn *ManagedConnection, id interface
 }
 
 func (pc *PoolCoordinator) handleSubmit(conn *ManagedConnection, id interface{}, params []interface{}) {
-	startTime := time.Now()
-	atomic.AddInt64(&pc.stats.TotalSharesReceived, 1)
+	defer atomic.AddInt64(&conn.SharesSubmitted, 1)
 
 	if !conn.Authorized {
-		pc.sendError(conn, id, 24, "Unauthorized")
+		atomic.AddInt64(&pc.stats.TotalSharesReceived, 1)
 		atomic.AddInt64(&pc.stats.TotalSharesRejected, 1)
+		pc.sendError(conn, id, 24, "Unauthorized")
 		return
 	}
 
 	if len(params) < 5 {
-		pc.sendError(conn, id, 20, "Invalid params")
+		atomic.AddInt64(&pc.stats.TotalSharesReceived, 1)
 		atomic.AddInt64(&pc.stats.TotalSharesRejected, 1)
+		pc.sendError(conn, id, 20, "Invalid params")
 		return
 	}
 
@@ -534,51 +804,52 @@ func (pc *PoolCoordinator) handleSubmit(conn *ManagedConnection, id interface{},
 		UserID:     conn.UserID,
 		JobID:      jobID,
 		Nonce:      nonce,
-		Difficulty: float64(conn.Difficulty),
+		Difficulty: conn.Difficulty,
 		Timestamp:  time.Now(),
 		WorkerName: workerName,
 		ExtraNonce: extranonce2,
 		NTime:      ntime,
 	}
 
-	// Submit to batch processor
-	resultCh := pc.shareProcessor.Submit(share)
+	result := pc.ProcessShare(share)
+	switch {
+	case result.Success:
+		atomic.AddInt64(&conn.SharesAccepted, 1)
+		pc.recordShare(result.ProcessedShare, "")
 
-	// Wait for result with timeout
-	select {
-	case result := <-resultCh:
-		latency := time.Since(startTime).Nanoseconds()
-		pc.updateShareLatency(latency)
-
-		if result.Success {
-			atomic.AddInt64(&pc.stats.TotalSharesAccepted, 1)
-			atomic.AddInt64(&conn.SharesAccepted, 1)
-
-			// Record share with vardiff
-			newDiff, changed := pc.vardiffManager.RecordShare(conn.ID, true, false)
-			if changed {
-				conn.Difficulty = newDiff
-				pc.sendDifficulty(conn, newDiff)
-			}
+		// Record share with vardiff
+		newDiff, changed := pc.vardiffManager.RecordShareAt(conn.ID, time.Now())
+		if changed {
+			conn.Difficulty = newDiff
+			pc.sendDifficulty(conn, newDiff)
+		}
 
-			response := map[string]interface{}{
-				"id":     id,
-				"result": true,
-				"error":  nil,
-			}
-			pc.sendJSON(conn, response)
-		} else {
-			atomic.AddInt64(&pc.stats.TotalSharesRejected, 1)
-			atomic.AddInt64(&conn.SharesRejected, 1)
-			pc.sendError(conn, id, 23, "Low difficulty share")
+		response := map[string]interface{}{
+			"id":     id,
+			"result": true,
+			"error":  nil,
 		}
+		pc.sendJSON(conn, response)
 
-	case <-time.After(5 * time.Second):
-		atomic.AddInt64(&pc.stats.TotalSharesRejected, 1)
+	case result.ProcessedShare != nil:
+		// Validated and missed the share target
+		atomic.AddInt64(&conn.SharesRejected, 1)
+		if result.Error == "" {
+			pc.recordShare(result.ProcessedShare, rejectReasonLowDifficulty)
+		}
+		pc.sendError(conn, id, 23, "Low difficulty share")
+
+	default:
+		// Dropped by the batch processor (queue full, rate limit or timeout)
 		pc.sendError(conn, id, 20, "Share processing timeout")
 	}
+}
 
-	atomic.AddInt64(&conn.SharesSubmitted, 1)
+// recordShare hands a processed share to the recorder, if one is configured
+func (pc *PoolCoordinator) recordShare(share *shares.Share, rejectReason string) {
+	if pc.shareRecorder != nil && share != nil {
+		pc.shareRecorder.RecordShare(share, rejectReason)
+	}
 }
 
 func (pc *PoolCoordinator) handleExtranonceSubscribe(conn *ManagedConnection, id interface{}) {
@@ -590,11 +861,11 @@ func (pc *PoolCoordinator) handleExtranonceSubscribe(conn *ManagedConnection, id
 	pc.sendJSON(conn, response)
 }
 
-func (pc *PoolCoordinator) sendDifficulty(conn *ManagedConnection, diff uint64) {
+func (pc *PoolCoordinator) sendDifficulty(conn *ManagedConnection, diff float64) {
 	msg := map[string]interface{}{
 		"id":     nil,
 		"method": "mining.set_difficulty",
-		"params": []interface{}{float64(diff)},
+		"params": []interface{}{diff},
 	}
 	pc.sendJSON(conn, msg)
 }
@@ -725,7 +996,7 @@ func (pc *PoolCoordinator) statsLoop() {
 			return
 		case <-ticker.C:
 			// Update hashrate from vardiff manager
-			hashrate := pc.vardiffManager.GetPoolHashrate()
+			hashrate := pc.vardiffManager.EstimatedHashrate()
 			atomic.StoreInt64(&pc.stats.CurrentHashrate, int64(hashrate))
 		}
 	}
@@ -2,12 +2,22 @@ package stratum
 
 import (
 	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
+	"math/big"
 	"net"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/chimera-pool/chimera-pool-core/internal/shares"
+	"github.com/chimera-pool/chimera-pool-core/internal/stratum/detector"
+	"github.com/chimera-pool/chimera-pool-core/internal/stratum/vardiff"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -104,7 +114,7 @@ func TestPoolCoordinator_ClientConnection(t *testing.T) {
 	defer pc.Stop()
 
 	// Get the actual listening address
-	addr := pc.listener.Addr().String()
+	addr := pc.Addrs()[0].String()
 
 	// Connect a client
 	conn, err := net.Dial("tcp", addr)
@@ -118,6 +128,159 @@ func TestPoolCoordinator_ClientConnection(t *testing.T) {
 	assert.Equal(t, int64(1), stats.ActiveMiners)
 }
 
+func TestPoolCoordinator_PortProfiles(t *testing.T) {
+	config := DefaultPoolCoordinatorConfig()
+	config.Ports = []*detector.PortProfile{
+		{Name: "low-end", Address: "127.0.0.1:0", InitialDifficulty: 0.01, MinDifficulty: 0.001, MaxDifficulty: 64},
+		{Name: "x100", Address: "127.0.0.1:0", Protocols: []detector.ProtocolVersion{detector.ProtocolV2}},
+	}
+
+	pc := NewPoolCoordinator(config)
+	require.NoError(t, pc.Start())
+	defer pc.Stop()
+
+	addrs := pc.Addrs()
+	require.Len(t, addrs, 2)
+	profiles := pc.Profiles()
+	require.Len(t, profiles, 2)
+	assert.NotEqual(t, "0", profiles[0].Port(), "profiles record the bound port")
+
+	// The low-end port starts miners at its own difficulty
+	lowEnd := vardiff.PortOf(addrs[0])
+	policy := pc.vardiffManager.Policies().Select(lowEnd, "")
+	assert.Equal(t, 0.01, policy.Config.InitialDifficulty)
+	assert.Equal(t, 64.0, policy.Config.MaxDifficulty)
+
+	conn, err := net.Dial("tcp", addrs[0].String())
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write([]byte(`{"id":1,"method":"mining.subscribe","params":["cgminer/4.12"]}` + "\n"))
+	require.NoError(t, err)
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	reader := bufio.NewReader(conn)
+	_, err = reader.ReadString('\n') // subscribe response
+	require.NoError(t, err)
+	line, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	assert.Contains(t, line, `"mining.set_difficulty","params":[0.01]`)
+
+	// V1 is refused on the V2-only port
+	v1, err := net.Dial("tcp", addrs[1].String())
+	require.NoError(t, err)
+	defer v1.Close()
+	_, err = v1.Write([]byte(`{"id":1,"method":"mining.subscribe","params":[]}` + "\n"))
+	require.NoError(t, err)
+
+	v1.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, err = v1.Read(make([]byte, 1))
+	assert.Error(t, err, "connection closed without a response")
+}
+
+func TestPoolCoordinator_StopAccepting(t *testing.T) {
+	config := DefaultPoolCoordinatorConfig()
+	config.ListenAddress = "127.0.0.1:0"
+
+	pc := NewPoolCoordinator(config)
+	require.NoError(t, pc.Start())
+	defer pc.Stop()
+	addr := pc.Addrs()[0].String()
+
+	// Established connections keep working after the listener closes
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer conn.Close()
+	require.Eventually(t, func() bool { return pc.GetStats().ActiveMiners == 1 }, 2*time.Second, 10*time.Millisecond)
+
+	pc.StopAccepting()
+	pc.StopAccepting() // idempotent
+	_, err = net.Dial("tcp", addr)
+	assert.Error(t, err, "new connections are refused")
+
+	_, err = conn.Write([]byte(`{"id":1,"method":"mining.subscribe","params":[]}` + "\n"))
+	require.NoError(t, err)
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, err = bufio.NewReader(conn).ReadString('\n')
+	assert.NoError(t, err)
+}
+
+func TestPoolCoordinator_InheritedListeners(t *testing.T) {
+	inherited, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	config := DefaultPoolCoordinatorConfig()
+	config.Ports = []*detector.PortProfile{{Name: "main", Address: inherited.Addr().String()}}
+
+	// Binding the port again would fail; the coordinator takes the socket over
+	pc := NewPoolCoordinator(config)
+	pc.SetInheritedListeners([]net.Listener{inherited})
+	require.NoError(t, pc.Start())
+	defer pc.Stop()
+	assert.Equal(t, inherited.Addr().String(), pc.Addrs()[0].String())
+
+	files, err := pc.ListenerFiles()
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	defer files[0].Close()
+
+	successor, err := net.FileListener(files[0])
+	require.NoError(t, err)
+	defer successor.Close()
+	assert.Equal(t, inherited.Addr().String(), successor.Addr().String())
+}
+
+// selfSignedTLSConfig returns a server config with a throwaway certificate
+func selfSignedTLSConfig(t *testing.T) *tls.Config {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	return &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+}
+
+func TestPoolCoordinator_TLSPort(t *testing.T) {
+	config := DefaultPoolCoordinatorConfig()
+	config.Ports = []*detector.PortProfile{
+		{Name: "ssl", Address: "127.0.0.1:0", Protocols: []detector.ProtocolVersion{detector.ProtocolTLS}},
+	}
+
+	pc := NewPoolCoordinator(config)
+	pc.SetTLSConfig(selfSignedTLSConfig(t))
+	require.NoError(t, pc.Start())
+	defer pc.Stop()
+	addr := pc.Addrs()[0].String()
+
+	// stratum+ssl: V1 runs inside TLS, responses come back encrypted
+	conn, err := tls.Dial("tcp", addr, &tls.Config{InsecureSkipVerify: true})
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write([]byte(`{"id":1,"method":"mining.subscribe","params":[]}` + "\n"))
+	require.NoError(t, err)
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	require.NoError(t, err)
+	assert.Contains(t, line, `"mining.set_difficulty"`)
+
+	// A client that gives up mid-handshake counts as a failure
+	raw, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	_, err = raw.Write([]byte{0x16, 0x03, 0x01, 0x00, 0x40, 0x01})
+	require.NoError(t, err)
+	raw.Close()
+
+	require.Eventually(t, func() bool {
+		return pc.ConnectionStats().TLSHandshakeErrors == 1
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, int64(1), pc.ConnectionStats().TLSHandshakes)
+}
+
 func TestPoolCoordinator_SubscribeFlow(t *testing.T) {
 	config := DefaultPoolCoordinatorConfig()
 	config.ListenAddress = ":0"
@@ -138,7 +301,7 @@ func TestPoolCoordinator_SubscribeFlow(t *testing.T) {
 	}
 	pc.SetCurrentJob(job)
 
-	addr := pc.listener.Addr().String()
+	addr := pc.Addrs()[0].String()
 	conn, err := net.Dial("tcp", addr)
 	require.NoError(t, err)
 	defer conn.Close()
@@ -177,7 +340,7 @@ func TestPoolCoordinator_AuthorizeFlow(t *testing.T) {
 	require.NoError(t, err)
 	defer pc.Stop()
 
-	addr := pc.listener.Addr().String()
+	addr := pc.Addrs()[0].String()
 	conn, err := net.Dial("tcp", addr)
 	require.NoError(t, err)
 	defer conn.Close()
@@ -253,7 +416,7 @@ func TestPoolCoordinator_SubmitShare(t *testing.T) {
 	}
 	pc.SetCurrentJob(job)
 
-	addr := pc.listener.Addr().String()
+	addr := pc.Addrs()[0].String()
 	conn, err := net.Dial("tcp", addr)
 	require.NoError(t, err)
 	defer conn.Close()
@@ -293,6 +456,106 @@ func TestPoolCoordinator_SubmitShare(t *testing.T) {
 	assert.Greater(t, stats.TotalSharesReceived, int64(0))
 }
 
+// lineHandler answers every routed connection with a fixed line
+type lineHandler struct {
+	protocol detector.ProtocolVersion
+	line     string
+	handled  chan *ManagedConnection
+}
+
+func (h *lineHandler) HandleConnection(conn net.Conn) error {
+	managed, _ := managedConnection(conn)
+	h.handled <- managed
+	_, err := conn.Write([]byte(h.line + "\n"))
+	return err
+}
+
+func (h *lineHandler) Protocol() detector.ProtocolVersion { return h.protocol }
+func (h *lineHandler) Shutdown() error                    { return nil }
+
+func TestPoolCoordinator_RegisterProtocolHandler(t *testing.T) {
+	config := DefaultPoolCoordinatorConfig()
+	config.ListenAddress = ":0"
+
+	pc := NewPoolCoordinator(config)
+	v1 := &lineHandler{protocol: detector.ProtocolV1, line: "v1", handled: make(chan *ManagedConnection, 1)}
+	fallback := &lineHandler{protocol: detector.ProtocolUnknown, line: "unknown", handled: make(chan *ManagedConnection, 1)}
+	pc.RegisterProtocolHandler(v1)
+	pc.RegisterProtocolHandler(fallback)
+	require.NoError(t, pc.Start())
+	defer pc.Stop()
+
+	for payload, handler := range map[string]*lineHandler{
+		`{"id":1,"method":"mining.subscribe","params":[]}`: v1,
+		"GET / HTTP/1.1\r\n\r\n":                           fallback,
+	} {
+		conn, err := net.Dial("tcp", pc.Addrs()[0].String())
+		require.NoError(t, err)
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+
+		_, err = conn.Write([]byte(payload))
+		require.NoError(t, err)
+
+		line, err := bufio.NewReader(conn).ReadString('\n')
+		require.NoError(t, err)
+		assert.Equal(t, handler.line+"\n", line)
+
+		managed := <-handler.handled
+		require.NotNil(t, managed, "handlers see the connection manager entry")
+		assert.Equal(t, int64(detector.PeekSize), atomic.LoadInt64(&managed.BytesReceived), "detection reads are counted")
+		conn.Close()
+	}
+}
+
+// recordingShareRecorder collects recorded shares
+type recordingShareRecorder struct {
+	mu      sync.Mutex
+	reasons []string
+}
+
+func (r *recordingShareRecorder) RecordShare(share *shares.Share, rejectReason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reasons = append(r.reasons, rejectReason)
+}
+
+func TestPoolCoordinator_ProcessShare(t *testing.T) {
+	config := DefaultPoolCoordinatorConfig()
+	config.ListenAddress = ":0"
+
+	pc := NewPoolCoordinator(config)
+	recorder := &recordingShareRecorder{}
+	pc.SetShareRecorder(recorder)
+	require.NoError(t, pc.Start())
+	defer pc.Stop()
+
+	share := &shares.Share{
+		MinerID:    1,
+		UserID:     1,
+		JobID:      "job-1",
+		Nonce:      "deadbeef",
+		Difficulty: 1e-9, // Any hash meets this target
+		Timestamp:  time.Now(),
+	}
+	result := pc.ProcessShare(share)
+	require.True(t, result.Success, result.Error)
+	assert.NotEmpty(t, result.ProcessedShare.Hash)
+
+	share.Difficulty = 1e12
+	result = pc.ProcessShare(share)
+	assert.False(t, result.Success)
+	assert.NotEmpty(t, result.ProcessedShare.Hash, "rejected shares keep their hash")
+
+	stats := pc.GetStats()
+	assert.Equal(t, int64(2), stats.TotalSharesReceived)
+	assert.Equal(t, int64(1), stats.TotalSharesAccepted)
+	assert.Equal(t, int64(1), stats.TotalSharesRejected)
+
+	// ProcessShare leaves persistence to the caller
+	pc.recordShare(result.ProcessedShare, rejectReasonLowDifficulty)
+	assert.Equal(t, []string{rejectReasonLowDifficulty}, recorder.reasons)
+}
+
 func TestPoolCoordinator_ConcurrentConnections(t *testing.T) {
 	config := DefaultPoolCoordinatorConfig()
 	config.ListenAddress = ":0"
@@ -303,7 +566,7 @@ func TestPoolCoordinator_ConcurrentConnections(t *testing.T) {
 	require.NoError(t, err)
 	defer pc.Stop()
 
-	addr := pc.listener.Addr().String()
+	addr := pc.Addrs()[0].String()
 
 	// Connect multiple clients concurrently
 	connCount := 20
@@ -377,7 +640,7 @@ func BenchmarkPoolCoordinator_ShareSubmit(b *testing.B) {
 	pc.SetCurrentJob(job)
 
 	// Create test connection
-	addr := pc.listener.Addr().String()
+	addr := pc.Addrs()[0].String()
 	conn, _ := net.Dial("tcp", addr)
 	defer conn.Close()
 
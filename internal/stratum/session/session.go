@@ -0,0 +1,170 @@
+// Package session keeps resumable stratum sessions across reconnects
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// =============================================================================
+// RESUMABLE SESSIONS
+// mining.subscribe returns a session ID. When the connection drops, the
+// session's state (extranonce1, difficulty, vardiff history, authorized
+// workers) is parked for a grace period; a miner that subscribes again with
+// that ID within the period resumes it instead of starting over.
+// =============================================================================
+
+// DefaultGracePeriod is how long a disconnected session stays resumable
+const DefaultGracePeriod = 5 * time.Minute
+
+// idBytes is the length of a session ID before hex encoding
+const idBytes = 16
+
+// Worker is a worker the session had authorized
+type Worker struct {
+	Name       string // As sent in mining.authorize, e.g. "alice.rig1"
+	Username   string
+	WorkerName string
+	UserID     int64
+	MinerID    int64 // miners table ID
+}
+
+// Session is the state a reconnecting miner resumes
+type Session struct {
+	ID          string
+	Extranonce1 string
+	Difficulty  float64
+	VardiffKey  string   // Vardiff manager key the miner's state is parked under
+	Workers     []Worker // Authorized workers, in authorization order
+	UserAgent   string
+	ParkedAt    time.Time
+}
+
+// Store holds parked sessions until they are resumed or expire
+type Store struct {
+	grace    time.Duration
+	sessions map[string]*Session
+	onExpire func(*Session)
+	now      func() time.Time
+	mu       sync.Mutex
+}
+
+// NewStore creates a store whose sessions stay resumable for grace
+func NewStore(grace time.Duration) *Store {
+	if grace <= 0 {
+		grace = DefaultGracePeriod
+	}
+	return &Store{
+		grace:    grace,
+		sessions: make(map[string]*Session),
+		now:      time.Now,
+	}
+}
+
+// NewID returns a random, unguessable session ID
+func NewID() string {
+	buf := make([]byte, idBytes)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// GracePeriod returns how long parked sessions stay resumable
+func (s *Store) GracePeriod() time.Duration {
+	return s.grace
+}
+
+// SetExpireCallback registers a callback for sessions that expire unresumed,
+// e.g. to release their vardiff state. It is called without the store's lock.
+func (s *Store) SetExpireCallback(onExpire func(*Session)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onExpire = onExpire
+}
+
+// Park keeps a disconnected session for the grace period, replacing any
+// session parked under the same ID
+func (s *Store) Park(session *Session) {
+	if session == nil || session.ID == "" {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session.ParkedAt = s.now()
+	s.sessions[session.ID] = session
+}
+
+// Resume removes and returns a parked session that has not expired
+func (s *Store) Resume(id string) (*Session, bool) {
+	if id == "" {
+		return nil, false
+	}
+
+	s.mu.Lock()
+	session, exists := s.sessions[id]
+	if !exists {
+		s.mu.Unlock()
+		return nil, false
+	}
+	delete(s.sessions, id)
+	expired := s.now().Sub(session.ParkedAt) > s.grace
+	onExpire := s.onExpire
+	s.mu.Unlock()
+
+	if expired {
+		if onExpire != nil {
+			onExpire(session)
+		}
+		return nil, false
+	}
+	return session, true
+}
+
+// Expire drops sessions parked longer than the grace period and returns them
+func (s *Store) Expire() []*Session {
+	s.mu.Lock()
+	var expired []*Session
+	cutoff := s.now().Add(-s.grace)
+	for id, session := range s.sessions {
+		if session.ParkedAt.Before(cutoff) {
+			delete(s.sessions, id)
+			expired = append(expired, session)
+		}
+	}
+	onExpire := s.onExpire
+	s.mu.Unlock()
+
+	if onExpire != nil {
+		for _, session := range expired {
+			onExpire(session)
+		}
+	}
+	return expired
+}
+
+// Len returns the number of parked sessions
+func (s *Store) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.sessions)
+}
+
+// Run expires sessions every interval until ctx is done
+func (s *Store) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = s.grace / 4
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.Expire()
+		}
+	}
+}
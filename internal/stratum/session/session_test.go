@@ -0,0 +1,62 @@
+package session
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestStore(grace time.Duration) (*Store, *time.Time) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := NewStore(grace)
+	store.now = func() time.Time { return now }
+	return store, &now
+}
+
+func TestNewID(t *testing.T) {
+	a, b := NewID(), NewID()
+	assert.Len(t, a, idBytes*2)
+	assert.NotEqual(t, a, b)
+}
+
+func TestStore_ParkAndResume(t *testing.T) {
+	store, _ := newTestStore(time.Minute)
+	store.Park(&Session{ID: "abc", Extranonce1: "00000001", Difficulty: 64})
+	store.Park(&Session{}) // no ID, ignored
+	assert.Equal(t, 1, store.Len())
+
+	session, ok := store.Resume("abc")
+	require.True(t, ok)
+	assert.Equal(t, "00000001", session.Extranonce1)
+	assert.Equal(t, 64.0, session.Difficulty)
+
+	_, ok = store.Resume("abc")
+	assert.False(t, ok, "a session resumes once")
+	_, ok = store.Resume("")
+	assert.False(t, ok)
+}
+
+func TestStore_Expiry(t *testing.T) {
+	store, now := newTestStore(time.Minute)
+	var released []string
+	store.SetExpireCallback(func(s *Session) { released = append(released, s.ID) })
+
+	store.Park(&Session{ID: "old"})
+	*now = now.Add(45 * time.Second)
+	store.Park(&Session{ID: "new"})
+	*now = now.Add(30 * time.Second)
+
+	expired := store.Expire()
+	require.Len(t, expired, 1)
+	assert.Equal(t, "old", expired[0].ID)
+	assert.Equal(t, []string{"old"}, released)
+
+	// Resuming past the grace period releases the session too
+	*now = now.Add(time.Minute)
+	_, ok := store.Resume("new")
+	assert.False(t, ok)
+	assert.Equal(t, []string{"old", "new"}, released)
+	assert.Zero(t, store.Len())
+}
@@ -0,0 +1,368 @@
+// Package upstream ranks the pool's full nodes by health and picks the one
+// RPC calls and block templates should use
+package upstream
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrNoEligibleNode is returned when no healthy node is at the best known tip
+var ErrNoEligibleNode = errors.New("no upstream node is healthy and at the best known tip")
+
+// Node is an upstream full node
+type Node struct {
+	Name     string // Label used in logs and metrics
+	URL      string // RPC endpoint
+	User     string // RPC username
+	Password string // RPC password
+}
+
+// ParseNode builds a Node from an RPC URL. Credentials embedded in the URL
+// override user and password; the host becomes the node's name.
+func ParseNode(rawURL, user, password string) Node {
+	node := Node{Name: rawURL, URL: rawURL, User: user, Password: password}
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return node
+	}
+	if parsed.User != nil {
+		node.User = parsed.User.Username()
+		if pass, ok := parsed.User.Password(); ok {
+			node.Password = pass
+		}
+		parsed.User = nil
+		node.URL = parsed.String()
+	}
+	if parsed.Host != "" {
+		node.Name = parsed.Host
+	}
+	return node
+}
+
+// Config holds scoring and failover configuration
+type Config struct {
+	MaxBlocksBehind      int64         // Tip lag tolerated before a node is skipped for templates
+	MaxConsecutiveErrors int           // Errors in a row before a node is marked down
+	LatencyReference     time.Duration // Latency at which a node's score is halved
+	PriorityPenalty      float64       // Score removed per position in the configured order
+	EWMAAlpha            float64       // Weight of the newest sample in latency and error averages
+	ProbeInterval        time.Duration // How often every node's tip is checked
+	ProbeTimeout         time.Duration // Timeout for a single probe
+}
+
+// DefaultConfig returns sensible default configuration
+func DefaultConfig() Config {
+	return Config{
+		MaxBlocksBehind:      0,                      // Never build on a lagging node
+		MaxConsecutiveErrors: 3,                      // 3 errors in a row = down
+		LatencyReference:     200 * time.Millisecond, // 200ms halves the score
+		PriorityPenalty:      5,                      // Keeps the configured order among equals
+		EWMAAlpha:            0.2,
+		ProbeInterval:        10 * time.Second,
+		ProbeTimeout:         5 * time.Second,
+	}
+}
+
+// Status is a snapshot of one node's health
+type Status struct {
+	Name         string
+	URL          string
+	Priority     int           // Position in the configured order (0 = primary)
+	Score        float64       // 0-100, higher is better
+	Healthy      bool          // Fewer than MaxConsecutiveErrors errors in a row
+	AtTip        bool          // Within MaxBlocksBehind of the best known tip
+	Active       bool          // Node last selected
+	TipHeight    int64         // Last reported chain height
+	BlocksBehind int64         // Best known tip minus TipHeight
+	Latency      time.Duration // Average RPC latency
+	ErrorRate    float64       // Average error rate (0-1)
+	Requests     int64
+	Errors       int64
+	LastError    string
+	LastSuccess  time.Time
+}
+
+// ProbeFunc returns a node's current chain height
+type ProbeFunc func(ctx context.Context, node Node) (int64, error)
+
+// nodeState is the mutable health record of a node
+type nodeState struct {
+	node        Node
+	priority    int
+	tipHeight   int64
+	latency     time.Duration
+	errorRate   float64
+	consecutive int
+	requests    int64
+	errors      int64
+	lastError   string
+	lastSuccess time.Time
+}
+
+// Selector keeps a ranked list of upstream nodes
+type Selector struct {
+	config  Config
+	nodes   []*nodeState
+	byName  map[string]*nodeState
+	bestTip int64
+	active  string
+	mu      sync.RWMutex
+}
+
+// NewSelector creates a selector over nodes in priority order
+func NewSelector(config Config, nodes []Node) *Selector {
+	s := &Selector{
+		config: config,
+		byName: make(map[string]*nodeState),
+	}
+	for i, node := range nodes {
+		state := &nodeState{node: node, priority: i}
+		s.nodes = append(s.nodes, state)
+		s.byName[node.Name] = state
+	}
+	return s
+}
+
+// Nodes returns the configured nodes in priority order
+func (s *Selector) Nodes() []Node {
+	nodes := make([]Node, len(s.nodes))
+	for i, state := range s.nodes {
+		nodes[i] = state.node
+	}
+	return nodes
+}
+
+// Select returns the best healthy node for general RPC. Nodes at the best
+// tip are preferred; if every node is down the best-scored one is returned
+// so requests keep being attempted.
+func (s *Selector) Select() (Node, error) {
+	if len(s.nodes) == 0 {
+		return Node{}, ErrNoEligibleNode
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if best := s.bestLocked(true, true); best != nil {
+		return s.activateLocked(best), nil
+	}
+	if best := s.bestLocked(true, false); best != nil {
+		return s.activateLocked(best), nil
+	}
+	return s.activateLocked(s.bestLocked(false, false)), nil
+}
+
+// SelectForTemplate returns the best healthy node at the best known tip.
+// Jobs must never be built from a node that is behind.
+func (s *Selector) SelectForTemplate() (Node, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	best := s.bestLocked(true, true)
+	if best == nil {
+		return Node{}, ErrNoEligibleNode
+	}
+	return s.activateLocked(best), nil
+}
+
+// RecordSuccess records a successful RPC call and its latency
+func (s *Selector) RecordSuccess(name string, latency time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.byName[name]
+	if !ok {
+		return
+	}
+	a := s.config.EWMAAlpha
+	state.requests++
+	state.consecutive = 0
+	state.errorRate *= 1 - a
+	if state.latency == 0 {
+		state.latency = latency
+	} else {
+		state.latency = time.Duration(a*float64(latency) + (1-a)*float64(state.latency))
+	}
+	state.lastSuccess = time.Now()
+}
+
+// RecordFailure records a failed RPC call (transport errors, not RPC error replies)
+func (s *Selector) RecordFailure(name string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.byName[name]
+	if !ok {
+		return
+	}
+	a := s.config.EWMAAlpha
+	state.requests++
+	state.errors++
+	state.consecutive++
+	state.errorRate = a + (1-a)*state.errorRate
+	if err != nil {
+		state.lastError = err.Error()
+	}
+	if state.consecutive == s.config.MaxConsecutiveErrors {
+		log.Printf("⚠️ Upstream node %s marked down after %d errors: %v", name, state.consecutive, err)
+	}
+}
+
+// RecordTip records the chain height a node reported
+func (s *Selector) RecordTip(name string, height int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.byName[name]
+	if !ok {
+		return
+	}
+	state.tipHeight = height
+	if height > s.bestTip {
+		s.bestTip = height
+	}
+}
+
+// BestTip returns the highest chain height any node has reported
+func (s *Selector) BestTip() int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.bestTip
+}
+
+// AtTip reports whether the named node is within MaxBlocksBehind of the best known tip
+func (s *Selector) AtTip(name string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	state, ok := s.byName[name]
+	return ok && s.atTipLocked(state)
+}
+
+// Status returns every node's health, best first
+func (s *Selector) Status() []Status {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	statuses := make([]Status, 0, len(s.nodes))
+	for _, state := range s.nodes {
+		statuses = append(statuses, Status{
+			Name:         state.node.Name,
+			URL:          state.node.URL,
+			Priority:     state.priority,
+			Score:        s.scoreLocked(state),
+			Healthy:      s.healthyLocked(state),
+			AtTip:        s.atTipLocked(state),
+			Active:       state.node.Name == s.active,
+			TipHeight:    state.tipHeight,
+			BlocksBehind: s.bestTip - state.tipHeight,
+			Latency:      state.latency,
+			ErrorRate:    state.errorRate,
+			Requests:     state.requests,
+			Errors:       state.errors,
+			LastError:    state.lastError,
+			LastSuccess:  state.lastSuccess,
+		})
+	}
+	sort.SliceStable(statuses, func(i, j int) bool { return statuses[i].Score > statuses[j].Score })
+	return statuses
+}
+
+// Probe checks every node's tip concurrently and records the results
+func (s *Selector) Probe(ctx context.Context, probe ProbeFunc) {
+	var wg sync.WaitGroup
+	for _, node := range s.Nodes() {
+		wg.Add(1)
+		go func(node Node) {
+			defer wg.Done()
+			probeCtx, cancel := context.WithTimeout(ctx, s.config.ProbeTimeout)
+			defer cancel()
+
+			start := time.Now()
+			height, err := probe(probeCtx, node)
+			if err != nil {
+				s.RecordFailure(node.Name, err)
+				return
+			}
+			s.RecordSuccess(node.Name, time.Since(start))
+			s.RecordTip(node.Name, height)
+		}(node)
+	}
+	wg.Wait()
+}
+
+// Run probes all nodes every ProbeInterval until ctx is cancelled
+func (s *Selector) Run(ctx context.Context, probe ProbeFunc) {
+	ticker := time.NewTicker(s.config.ProbeInterval)
+	defer ticker.Stop()
+
+	s.Probe(ctx, probe)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.Probe(ctx, probe)
+		}
+	}
+}
+
+// =============================================================================
+// Internal Methods
+// =============================================================================
+
+// bestLocked returns the highest-scored node matching the filters
+func (s *Selector) bestLocked(requireHealthy, requireTip bool) *nodeState {
+	var best *nodeState
+	bestScore := 0.0
+	for _, state := range s.nodes {
+		if requireHealthy && !s.healthyLocked(state) {
+			continue
+		}
+		if requireTip && !s.atTipLocked(state) {
+			continue
+		}
+		if score := s.scoreLocked(state); best == nil || score > bestScore {
+			best, bestScore = state, score
+		}
+	}
+	return best
+}
+
+// activateLocked marks state as the active node, logging failovers
+func (s *Selector) activateLocked(state *nodeState) Node {
+	if s.active != state.node.Name {
+		if s.active != "" {
+			log.Printf("🔀 Upstream failover: %s → %s", s.active, state.node.Name)
+		}
+		s.active = state.node.Name
+	}
+	return state.node
+}
+
+func (s *Selector) healthyLocked(state *nodeState) bool {
+	return state.consecutive < s.config.MaxConsecutiveErrors
+}
+
+func (s *Selector) atTipLocked(state *nodeState) bool {
+	return state.tipHeight >= s.bestTip-s.config.MaxBlocksBehind
+}
+
+// scoreLocked rates a node from its error rate and latency; the configured
+// priority breaks ties in favour of the primary
+func (s *Selector) scoreLocked(state *nodeState) float64 {
+	score := 100 * (1 - state.errorRate)
+	if s.config.LatencyReference > 0 {
+		score /= 1 + float64(state.latency)/float64(s.config.LatencyReference)
+	}
+	score -= s.config.PriorityPenalty * float64(state.priority)
+	if score < 0 {
+		score = 0
+	}
+	return score
+}
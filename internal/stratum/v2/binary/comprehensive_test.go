@@ -474,6 +474,12 @@ func TestErrorCodeConstants(t *testing.T) {
 	assert.Equal(t, uint8(0x08), ErrLowDifficultyShare)
 }
 
+func TestErrorCodeName(t *testing.T) {
+	assert.Equal(t, STR0_255("stale-share"), ErrorCodeName(ErrStaleShare))
+	assert.Equal(t, STR0_255("duplicate-share"), ErrorCodeName(ErrDuplicateShare))
+	assert.Equal(t, STR0_255("unknown-message"), ErrorCodeName(0xFF))
+}
+
 func TestErrorVariables(t *testing.T) {
 	assert.NotNil(t, ErrInvalidMessageLength)
 	assert.NotNil(t, ErrUnsupportedMessage)
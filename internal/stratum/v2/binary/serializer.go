@@ -117,6 +117,86 @@ func (s *Serializer) WriteSTR0_255(str string) {
 	s.buf.WriteString(str)
 }
 
+// WriteB0_32 writes a byte field with a 1-byte length prefix (max 32 bytes)
+func (s *Serializer) WriteB0_32(b []byte) {
+	if len(b) > MaxB0_32Len {
+		b = b[:MaxB0_32Len]
+	}
+	s.buf.WriteByte(byte(len(b)))
+	s.buf.Write(b)
+}
+
+// WriteB0_64K writes a byte field with a 2-byte length prefix (max 65535 bytes)
+func (s *Serializer) WriteB0_64K(b []byte) {
+	if len(b) > MaxB0_64KLen {
+		b = b[:MaxB0_64KLen]
+	}
+	s.WriteU16(uint16(len(b)))
+	s.buf.Write(b)
+}
+
+// WriteSeq0_255U256 writes a sequence of 32-byte values with a 1-byte count
+func (s *Serializer) WriteSeq0_255U256(seq [][32]byte) {
+	if len(seq) > MaxMerklePathLen {
+		seq = seq[:MaxMerklePathLen]
+	}
+	s.buf.WriteByte(byte(len(seq)))
+	for i := range seq {
+		s.buf.Write(seq[i][:])
+	}
+}
+
+// WriteB0_255 writes a byte field with a 1-byte length prefix (max 255 bytes)
+func (s *Serializer) WriteB0_255(b []byte) {
+	if len(b) > MaxB0_255Len {
+		b = b[:MaxB0_255Len]
+	}
+	s.buf.WriteByte(byte(len(b)))
+	s.buf.Write(b)
+}
+
+// WriteB0_16M writes a byte field with a 3-byte length prefix
+func (s *Serializer) WriteB0_16M(b []byte) {
+	if len(b) > MaxB0_16MLen {
+		b = b[:MaxB0_16MLen]
+	}
+	s.WriteU24(uint32(len(b)))
+	s.buf.Write(b)
+}
+
+// WriteSeq0_64KU16 writes a sequence of U16 values with a 2-byte count
+func (s *Serializer) WriteSeq0_64KU16(seq []uint16) {
+	if len(seq) > MaxSeq0_64KLen {
+		seq = seq[:MaxSeq0_64KLen]
+	}
+	s.WriteU16(uint16(len(seq)))
+	for _, v := range seq {
+		s.WriteU16(v)
+	}
+}
+
+// WriteSeq0_64KU256 writes a sequence of 32-byte values with a 2-byte count
+func (s *Serializer) WriteSeq0_64KU256(seq [][32]byte) {
+	if len(seq) > MaxSeq0_64KLen {
+		seq = seq[:MaxSeq0_64KLen]
+	}
+	s.WriteU16(uint16(len(seq)))
+	for i := range seq {
+		s.buf.Write(seq[i][:])
+	}
+}
+
+// WriteSeq0_64KB0_16M writes a sequence of B0_16M fields with a 2-byte count
+func (s *Serializer) WriteSeq0_64KB0_16M(seq [][]byte) {
+	if len(seq) > MaxSeq0_64KLen {
+		seq = seq[:MaxSeq0_64KLen]
+	}
+	s.WriteU16(uint16(len(seq)))
+	for _, b := range seq {
+		s.WriteB0_16M(b)
+	}
+}
+
 // WriteHeader writes a frame header
 func (s *Serializer) WriteHeader(h *FrameHeader) {
 	s.WriteU16(h.ExtensionType)
@@ -209,6 +289,7 @@ func (s *Serializer) SerializeNewMiningJob(msg *NewMiningJob) []byte {
 	s.WriteBool(msg.FuturePrevHash)
 	s.WriteU32(msg.Version)
 	s.WriteU32(msg.VersionMask)
+	s.WriteFixedBytes(msg.MerkleRoot[:], 32)
 
 	return s.Bytes()
 }
@@ -263,6 +344,83 @@ func (s *Serializer) SerializeSubmitSharesError(msg *SubmitSharesError) []byte {
 	return s.Bytes()
 }
 
+// SerializeOpenExtendedMiningChannel serializes an OpenExtendedMiningChannel message
+func (s *Serializer) SerializeOpenExtendedMiningChannel(msg *OpenExtendedMiningChannel) []byte {
+	s.Reset()
+
+	s.WriteU32(msg.RequestID)
+	s.WriteSTR0_255(string(msg.UserIdentity))
+	s.WriteF32(msg.NominalHashrate)
+	s.WriteFixedBytes(msg.MaxTarget[:], 32)
+	s.WriteU16(msg.MinExtranonceSize)
+
+	return s.Bytes()
+}
+
+// SerializeOpenExtendedMiningChannelSuccess serializes extended channel success response
+func (s *Serializer) SerializeOpenExtendedMiningChannelSuccess(msg *OpenExtendedMiningChannelSuccess) []byte {
+	s.Reset()
+
+	s.WriteU32(msg.RequestID)
+	s.WriteU32(msg.ChannelID)
+	s.WriteFixedBytes(msg.Target[:], 32)
+	s.WriteU16(msg.ExtranonceSize)
+	s.WriteB0_32(msg.ExtranoncePrefix)
+
+	return s.Bytes()
+}
+
+// SerializeOpenExtendedMiningChannelError serializes extended channel error response
+func (s *Serializer) SerializeOpenExtendedMiningChannelError(msg *OpenExtendedMiningChannelError) []byte {
+	s.Reset()
+
+	s.WriteU32(msg.RequestID)
+	s.WriteSTR0_255(string(msg.ErrorCode))
+
+	return s.Bytes()
+}
+
+// SerializeNewExtendedMiningJob serializes a NewExtendedMiningJob message
+func (s *Serializer) SerializeNewExtendedMiningJob(msg *NewExtendedMiningJob) []byte {
+	s.Reset()
+
+	s.WriteU32(msg.ChannelID)
+	s.WriteU32(msg.JobID)
+	s.WriteBool(msg.FuturePrevHash)
+	s.WriteU32(msg.Version)
+	s.WriteBool(msg.VersionRollingAllowed)
+	s.WriteSeq0_255U256(msg.MerklePath)
+	s.WriteB0_64K(msg.CoinbaseTxPrefix)
+	s.WriteB0_64K(msg.CoinbaseTxSuffix)
+
+	return s.Bytes()
+}
+
+// SerializeSubmitSharesExtended serializes a SubmitSharesExtended message
+func (s *Serializer) SerializeSubmitSharesExtended(msg *SubmitSharesExtended) []byte {
+	s.Reset()
+
+	s.WriteU32(msg.ChannelID)
+	s.WriteU32(msg.SequenceNum)
+	s.WriteU32(msg.JobID)
+	s.WriteU32(msg.Nonce)
+	s.WriteU32(msg.NTime)
+	s.WriteU32(msg.Version)
+	s.WriteB0_32(msg.Extranonce)
+
+	return s.Bytes()
+}
+
+// SerializeSetExtranoncePrefix serializes a SetExtranoncePrefix message
+func (s *Serializer) SerializeSetExtranoncePrefix(msg *SetExtranoncePrefix) []byte {
+	s.Reset()
+
+	s.WriteU32(msg.ChannelID)
+	s.WriteB0_32(msg.ExtranoncePrefix)
+
+	return s.Bytes()
+}
+
 // SerializeSetTarget serializes a SetTarget message
 func (s *Serializer) SerializeSetTarget(msg *SetTarget) []byte {
 	s.Reset()
@@ -283,6 +441,214 @@ func (s *Serializer) SerializeReconnect(msg *Reconnect) []byte {
 	return s.Bytes()
 }
 
+// SerializeAllocateMiningJobToken serializes an AllocateMiningJobToken message
+func (s *Serializer) SerializeAllocateMiningJobToken(msg *AllocateMiningJobToken) []byte {
+	s.Reset()
+
+	s.WriteSTR0_255(string(msg.UserIdentifier))
+	s.WriteU32(msg.RequestID)
+
+	return s.Bytes()
+}
+
+// SerializeAllocateMiningJobTokenSuccess serializes a token allocation response
+func (s *Serializer) SerializeAllocateMiningJobTokenSuccess(msg *AllocateMiningJobTokenSuccess) []byte {
+	s.Reset()
+
+	s.WriteU32(msg.RequestID)
+	s.WriteB0_255(msg.MiningJobToken)
+	s.WriteU32(msg.CoinbaseOutputMaxAdditionalSize)
+	s.WriteB0_64K(msg.CoinbaseOutputs)
+	s.WriteBool(msg.AsyncMiningAllowed)
+
+	return s.Bytes()
+}
+
+// SerializeDeclareMiningJob serializes a DeclareMiningJob message
+func (s *Serializer) SerializeDeclareMiningJob(msg *DeclareMiningJob) []byte {
+	s.Reset()
+
+	s.WriteU32(msg.RequestID)
+	s.WriteB0_255(msg.MiningJobToken)
+	s.WriteU32(msg.Version)
+	s.WriteB0_64K(msg.CoinbasePrefix)
+	s.WriteB0_64K(msg.CoinbaseSuffix)
+	s.WriteSeq0_64KU256(msg.WTXIDList)
+	s.WriteB0_64K(msg.ExcessData)
+
+	return s.Bytes()
+}
+
+// SerializeDeclareMiningJobSuccess serializes a declaration acceptance
+func (s *Serializer) SerializeDeclareMiningJobSuccess(msg *DeclareMiningJobSuccess) []byte {
+	s.Reset()
+
+	s.WriteU32(msg.RequestID)
+	s.WriteB0_255(msg.NewMiningJobToken)
+
+	return s.Bytes()
+}
+
+// SerializeDeclareMiningJobError serializes a declaration rejection
+func (s *Serializer) SerializeDeclareMiningJobError(msg *DeclareMiningJobError) []byte {
+	s.Reset()
+
+	s.WriteU32(msg.RequestID)
+	s.WriteSTR0_255(string(msg.ErrorCode))
+	s.WriteB0_64K(msg.ErrorDetails)
+
+	return s.Bytes()
+}
+
+// SerializeProvideMissingTransactions serializes a ProvideMissingTransactions message
+func (s *Serializer) SerializeProvideMissingTransactions(msg *ProvideMissingTransactions) []byte {
+	s.Reset()
+
+	s.WriteU32(msg.RequestID)
+	s.WriteSeq0_64KU16(msg.UnknownTxPositionList)
+
+	return s.Bytes()
+}
+
+// SerializeProvideMissingTransactionsSuccess serializes the missing transactions response
+func (s *Serializer) SerializeProvideMissingTransactionsSuccess(msg *ProvideMissingTransactionsSuccess) []byte {
+	s.Reset()
+
+	s.WriteU32(msg.RequestID)
+	s.WriteSeq0_64KB0_16M(msg.TransactionList)
+
+	return s.Bytes()
+}
+
+// SerializeSetCustomMiningJob serializes a SetCustomMiningJob message
+func (s *Serializer) SerializeSetCustomMiningJob(msg *SetCustomMiningJob) []byte {
+	s.Reset()
+
+	s.WriteU32(msg.ChannelID)
+	s.WriteU32(msg.RequestID)
+	s.WriteB0_255(msg.MiningJobToken)
+	s.WriteU32(msg.Version)
+	s.WriteFixedBytes(msg.PrevHash[:], 32)
+	s.WriteU32(msg.MinNTime)
+	s.WriteU32(msg.NBits)
+	s.WriteU32(msg.CoinbaseTxVersion)
+	s.WriteB0_255(msg.CoinbasePrefix)
+	s.WriteU32(msg.CoinbaseTxInputNSequence)
+	s.WriteU64(msg.CoinbaseTxValueRemaining)
+	s.WriteB0_64K(msg.CoinbaseTxOutputs)
+	s.WriteU32(msg.CoinbaseTxLocktime)
+	s.WriteSeq0_255U256(msg.MerklePath)
+	s.WriteU16(msg.ExtranonceSize)
+
+	return s.Bytes()
+}
+
+// SerializeSetCustomMiningJobSuccess serializes a custom job acceptance
+func (s *Serializer) SerializeSetCustomMiningJobSuccess(msg *SetCustomMiningJobSuccess) []byte {
+	s.Reset()
+
+	s.WriteU32(msg.ChannelID)
+	s.WriteU32(msg.RequestID)
+	s.WriteU32(msg.JobID)
+
+	return s.Bytes()
+}
+
+// SerializeSetCustomMiningJobError serializes a custom job rejection
+func (s *Serializer) SerializeSetCustomMiningJobError(msg *SetCustomMiningJobError) []byte {
+	s.Reset()
+
+	s.WriteU32(msg.ChannelID)
+	s.WriteU32(msg.RequestID)
+	s.WriteSTR0_255(string(msg.ErrorCode))
+
+	return s.Bytes()
+}
+
+// SerializeCoinbaseOutputDataSize serializes a CoinbaseOutputDataSize message
+func (s *Serializer) SerializeCoinbaseOutputDataSize(msg *CoinbaseOutputDataSize) []byte {
+	s.Reset()
+
+	s.WriteU32(msg.CoinbaseOutputMaxAdditionalSize)
+
+	return s.Bytes()
+}
+
+// SerializeNewTemplate serializes a NewTemplate message
+func (s *Serializer) SerializeNewTemplate(msg *NewTemplate) []byte {
+	s.Reset()
+
+	s.WriteU64(msg.TemplateID)
+	s.WriteBool(msg.FutureTemplate)
+	s.WriteU32(msg.Version)
+	s.WriteU32(msg.CoinbaseTxVersion)
+	s.WriteB0_255(msg.CoinbasePrefix)
+	s.WriteU32(msg.CoinbaseTxInputSequence)
+	s.WriteU64(msg.CoinbaseTxValueRemaining)
+	s.WriteU32(msg.CoinbaseTxOutputsCount)
+	s.WriteB0_64K(msg.CoinbaseTxOutputs)
+	s.WriteU32(msg.CoinbaseTxLocktime)
+	s.WriteSeq0_255U256(msg.MerklePath)
+
+	return s.Bytes()
+}
+
+// SerializeTemplateSetNewPrevHash serializes a Template Distribution SetNewPrevHash message
+func (s *Serializer) SerializeTemplateSetNewPrevHash(msg *TemplateSetNewPrevHash) []byte {
+	s.Reset()
+
+	s.WriteU64(msg.TemplateID)
+	s.WriteFixedBytes(msg.PrevHash[:], 32)
+	s.WriteU32(msg.HeaderTimestamp)
+	s.WriteU32(msg.NBits)
+	s.WriteFixedBytes(msg.Target[:], 32)
+
+	return s.Bytes()
+}
+
+// SerializeRequestTransactionData serializes a RequestTransactionData message
+func (s *Serializer) SerializeRequestTransactionData(msg *RequestTransactionData) []byte {
+	s.Reset()
+
+	s.WriteU64(msg.TemplateID)
+
+	return s.Bytes()
+}
+
+// SerializeRequestTransactionDataSuccess serializes a transaction data response
+func (s *Serializer) SerializeRequestTransactionDataSuccess(msg *RequestTransactionDataSuccess) []byte {
+	s.Reset()
+
+	s.WriteU64(msg.TemplateID)
+	s.WriteB0_64K(msg.ExcessData)
+	s.WriteSeq0_64KB0_16M(msg.TransactionList)
+
+	return s.Bytes()
+}
+
+// SerializeRequestTransactionDataError serializes a transaction data error
+func (s *Serializer) SerializeRequestTransactionDataError(msg *RequestTransactionDataError) []byte {
+	s.Reset()
+
+	s.WriteU64(msg.TemplateID)
+	s.WriteSTR0_255(string(msg.ErrorCode))
+
+	return s.Bytes()
+}
+
+// SerializeSubmitSolution serializes a SubmitSolution message
+func (s *Serializer) SerializeSubmitSolution(msg *SubmitSolution) []byte {
+	s.Reset()
+
+	s.WriteU64(msg.TemplateID)
+	s.WriteU32(msg.Version)
+	s.WriteU32(msg.HeaderTimestamp)
+	s.WriteU32(msg.HeaderNonce)
+	s.WriteB0_64K(msg.CoinbaseTx)
+
+	return s.Bytes()
+}
+
 // -----------------------------------------------------------------------------
 // Full Frame Serialization (Header + Payload)
 // -----------------------------------------------------------------------------
@@ -438,44 +804,151 @@ func (d *Deserializer) ReadSTR0_255() (STR0_255, error) {
 	return STR0_255(v), nil
 }
 
-// ReadHeader reads a frame header
-func (d *Deserializer) ReadHeader() (*FrameHeader, error) {
-	extType, err := d.ReadU16()
+// ReadB0_32 reads a byte field with a 1-byte length prefix (max 32 bytes)
+func (d *Deserializer) ReadB0_32() ([]byte, error) {
+	length, err := d.ReadU8()
 	if err != nil {
 		return nil, err
 	}
-	msgType, err := d.ReadU8()
+	if length > MaxB0_32Len {
+		return nil, ErrFieldTooLong
+	}
+	return d.ReadBytes(int(length))
+}
+
+// ReadB0_64K reads a byte field with a 2-byte length prefix
+func (d *Deserializer) ReadB0_64K() ([]byte, error) {
+	length, err := d.ReadU16()
 	if err != nil {
 		return nil, err
 	}
-	msgLen, err := d.ReadU24()
+	return d.ReadBytes(int(length))
+}
+
+// ReadSeq0_255U256 reads a sequence of 32-byte values with a 1-byte count
+func (d *Deserializer) ReadSeq0_255U256() ([][32]byte, error) {
+	count, err := d.ReadU8()
 	if err != nil {
 		return nil, err
 	}
-
-	return &FrameHeader{
-		ExtensionType: extType,
-		MsgType:       msgType,
-		MsgLength:     msgLen,
-	}, nil
+	seq := make([][32]byte, count)
+	for i := range seq {
+		if seq[i], err = d.ReadFixedBytes32(); err != nil {
+			return nil, err
+		}
+	}
+	return seq, nil
 }
 
-// -----------------------------------------------------------------------------
-// Message Deserializers
-// -----------------------------------------------------------------------------
-
-// DeserializeSetupConnection deserializes a SetupConnection message
-func (d *Deserializer) DeserializeSetupConnection() (*SetupConnection, error) {
-	msg := &SetupConnection{}
-	var err error
-
-	if msg.Protocol, err = d.ReadU8(); err != nil {
+// ReadB0_255 reads a byte field with a 1-byte length prefix
+func (d *Deserializer) ReadB0_255() ([]byte, error) {
+	length, err := d.ReadU8()
+	if err != nil {
 		return nil, err
 	}
-	if msg.MinVersion, err = d.ReadU16(); err != nil {
+	return d.ReadBytes(int(length))
+}
+
+// ReadB0_16M reads a byte field with a 3-byte length prefix
+func (d *Deserializer) ReadB0_16M() ([]byte, error) {
+	length, err := d.ReadU24()
+	if err != nil {
 		return nil, err
 	}
-	if msg.MaxVersion, err = d.ReadU16(); err != nil {
+	return d.ReadBytes(int(length))
+}
+
+// ReadSeq0_64KU16 reads a sequence of U16 values with a 2-byte count
+func (d *Deserializer) ReadSeq0_64KU16() ([]uint16, error) {
+	count, err := d.ReadU16()
+	if err != nil {
+		return nil, err
+	}
+	if d.Remaining() < int(count)*2 {
+		return nil, io.ErrUnexpectedEOF
+	}
+	seq := make([]uint16, count)
+	for i := range seq {
+		if seq[i], err = d.ReadU16(); err != nil {
+			return nil, err
+		}
+	}
+	return seq, nil
+}
+
+// ReadSeq0_64KU256 reads a sequence of 32-byte values with a 2-byte count
+func (d *Deserializer) ReadSeq0_64KU256() ([][32]byte, error) {
+	count, err := d.ReadU16()
+	if err != nil {
+		return nil, err
+	}
+	if d.Remaining() < int(count)*32 {
+		return nil, io.ErrUnexpectedEOF
+	}
+	seq := make([][32]byte, count)
+	for i := range seq {
+		if seq[i], err = d.ReadFixedBytes32(); err != nil {
+			return nil, err
+		}
+	}
+	return seq, nil
+}
+
+// ReadSeq0_64KB0_16M reads a sequence of B0_16M fields with a 2-byte count
+func (d *Deserializer) ReadSeq0_64KB0_16M() ([][]byte, error) {
+	count, err := d.ReadU16()
+	if err != nil {
+		return nil, err
+	}
+	seq := make([][]byte, 0, count)
+	for i := 0; i < int(count); i++ {
+		b, err := d.ReadB0_16M()
+		if err != nil {
+			return nil, err
+		}
+		seq = append(seq, b)
+	}
+	return seq, nil
+}
+
+// ReadHeader reads a frame header
+func (d *Deserializer) ReadHeader() (*FrameHeader, error) {
+	extType, err := d.ReadU16()
+	if err != nil {
+		return nil, err
+	}
+	msgType, err := d.ReadU8()
+	if err != nil {
+		return nil, err
+	}
+	msgLen, err := d.ReadU24()
+	if err != nil {
+		return nil, err
+	}
+
+	return &FrameHeader{
+		ExtensionType: extType,
+		MsgType:       msgType,
+		MsgLength:     msgLen,
+	}, nil
+}
+
+// -----------------------------------------------------------------------------
+// Message Deserializers
+// -----------------------------------------------------------------------------
+
+// DeserializeSetupConnection deserializes a SetupConnection message
+func (d *Deserializer) DeserializeSetupConnection() (*SetupConnection, error) {
+	msg := &SetupConnection{}
+	var err error
+
+	if msg.Protocol, err = d.ReadU8(); err != nil {
+		return nil, err
+	}
+	if msg.MinVersion, err = d.ReadU16(); err != nil {
+		return nil, err
+	}
+	if msg.MaxVersion, err = d.ReadU16(); err != nil {
 		return nil, err
 	}
 	if msg.Flags, err = d.ReadU32(); err != nil {
@@ -610,6 +1083,9 @@ func (d *Deserializer) DeserializeNewMiningJob() (*NewMiningJob, error) {
 	if msg.VersionMask, err = d.ReadU32(); err != nil {
 		return nil, err
 	}
+	if msg.MerkleRoot, err = d.ReadFixedBytes32(); err != nil {
+		return nil, err
+	}
 
 	return msg, nil
 }
@@ -704,6 +1180,147 @@ func (d *Deserializer) DeserializeSubmitSharesError() (*SubmitSharesError, error
 	return msg, nil
 }
 
+// DeserializeOpenExtendedMiningChannel deserializes an OpenExtendedMiningChannel message
+func (d *Deserializer) DeserializeOpenExtendedMiningChannel() (*OpenExtendedMiningChannel, error) {
+	msg := &OpenExtendedMiningChannel{}
+	var err error
+
+	if msg.RequestID, err = d.ReadU32(); err != nil {
+		return nil, err
+	}
+	if msg.UserIdentity, err = d.ReadSTR0_255(); err != nil {
+		return nil, err
+	}
+	if msg.NominalHashrate, err = d.ReadF32(); err != nil {
+		return nil, err
+	}
+	if msg.MaxTarget, err = d.ReadFixedBytes32(); err != nil {
+		return nil, err
+	}
+	if msg.MinExtranonceSize, err = d.ReadU16(); err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
+
+// DeserializeOpenExtendedMiningChannelSuccess deserializes extended channel success response
+func (d *Deserializer) DeserializeOpenExtendedMiningChannelSuccess() (*OpenExtendedMiningChannelSuccess, error) {
+	msg := &OpenExtendedMiningChannelSuccess{}
+	var err error
+
+	if msg.RequestID, err = d.ReadU32(); err != nil {
+		return nil, err
+	}
+	if msg.ChannelID, err = d.ReadU32(); err != nil {
+		return nil, err
+	}
+	if msg.Target, err = d.ReadFixedBytes32(); err != nil {
+		return nil, err
+	}
+	if msg.ExtranonceSize, err = d.ReadU16(); err != nil {
+		return nil, err
+	}
+	if msg.ExtranoncePrefix, err = d.ReadB0_32(); err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
+
+// DeserializeOpenExtendedMiningChannelError deserializes extended channel error response
+func (d *Deserializer) DeserializeOpenExtendedMiningChannelError() (*OpenExtendedMiningChannelError, error) {
+	msg := &OpenExtendedMiningChannelError{}
+	var err error
+
+	if msg.RequestID, err = d.ReadU32(); err != nil {
+		return nil, err
+	}
+	if msg.ErrorCode, err = d.ReadSTR0_255(); err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
+
+// DeserializeNewExtendedMiningJob deserializes a NewExtendedMiningJob message
+func (d *Deserializer) DeserializeNewExtendedMiningJob() (*NewExtendedMiningJob, error) {
+	msg := &NewExtendedMiningJob{}
+	var err error
+
+	if msg.ChannelID, err = d.ReadU32(); err != nil {
+		return nil, err
+	}
+	if msg.JobID, err = d.ReadU32(); err != nil {
+		return nil, err
+	}
+	if msg.FuturePrevHash, err = d.ReadBool(); err != nil {
+		return nil, err
+	}
+	if msg.Version, err = d.ReadU32(); err != nil {
+		return nil, err
+	}
+	if msg.VersionRollingAllowed, err = d.ReadBool(); err != nil {
+		return nil, err
+	}
+	if msg.MerklePath, err = d.ReadSeq0_255U256(); err != nil {
+		return nil, err
+	}
+	if msg.CoinbaseTxPrefix, err = d.ReadB0_64K(); err != nil {
+		return nil, err
+	}
+	if msg.CoinbaseTxSuffix, err = d.ReadB0_64K(); err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
+
+// DeserializeSubmitSharesExtended deserializes a SubmitSharesExtended message
+func (d *Deserializer) DeserializeSubmitSharesExtended() (*SubmitSharesExtended, error) {
+	msg := &SubmitSharesExtended{}
+	var err error
+
+	if msg.ChannelID, err = d.ReadU32(); err != nil {
+		return nil, err
+	}
+	if msg.SequenceNum, err = d.ReadU32(); err != nil {
+		return nil, err
+	}
+	if msg.JobID, err = d.ReadU32(); err != nil {
+		return nil, err
+	}
+	if msg.Nonce, err = d.ReadU32(); err != nil {
+		return nil, err
+	}
+	if msg.NTime, err = d.ReadU32(); err != nil {
+		return nil, err
+	}
+	if msg.Version, err = d.ReadU32(); err != nil {
+		return nil, err
+	}
+	if msg.Extranonce, err = d.ReadB0_32(); err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
+
+// DeserializeSetExtranoncePrefix deserializes a SetExtranoncePrefix message
+func (d *Deserializer) DeserializeSetExtranoncePrefix() (*SetExtranoncePrefix, error) {
+	msg := &SetExtranoncePrefix{}
+	var err error
+
+	if msg.ChannelID, err = d.ReadU32(); err != nil {
+		return nil, err
+	}
+	if msg.ExtranoncePrefix, err = d.ReadB0_32(); err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
+
 // DeserializeSetTarget deserializes a SetTarget message
 func (d *Deserializer) DeserializeSetTarget() (*SetTarget, error) {
 	msg := &SetTarget{}
@@ -733,3 +1350,372 @@ func (d *Deserializer) DeserializeReconnect() (*Reconnect, error) {
 
 	return msg, nil
 }
+
+// DeserializeAllocateMiningJobToken deserializes an AllocateMiningJobToken message
+func (d *Deserializer) DeserializeAllocateMiningJobToken() (*AllocateMiningJobToken, error) {
+	msg := &AllocateMiningJobToken{}
+	var err error
+
+	if msg.UserIdentifier, err = d.ReadSTR0_255(); err != nil {
+		return nil, err
+	}
+	if msg.RequestID, err = d.ReadU32(); err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
+
+// DeserializeAllocateMiningJobTokenSuccess deserializes a token allocation response
+func (d *Deserializer) DeserializeAllocateMiningJobTokenSuccess() (*AllocateMiningJobTokenSuccess, error) {
+	msg := &AllocateMiningJobTokenSuccess{}
+	var err error
+
+	if msg.RequestID, err = d.ReadU32(); err != nil {
+		return nil, err
+	}
+	if msg.MiningJobToken, err = d.ReadB0_255(); err != nil {
+		return nil, err
+	}
+	if msg.CoinbaseOutputMaxAdditionalSize, err = d.ReadU32(); err != nil {
+		return nil, err
+	}
+	if msg.CoinbaseOutputs, err = d.ReadB0_64K(); err != nil {
+		return nil, err
+	}
+	if msg.AsyncMiningAllowed, err = d.ReadBool(); err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
+
+// DeserializeDeclareMiningJob deserializes a DeclareMiningJob message
+func (d *Deserializer) DeserializeDeclareMiningJob() (*DeclareMiningJob, error) {
+	msg := &DeclareMiningJob{}
+	var err error
+
+	if msg.RequestID, err = d.ReadU32(); err != nil {
+		return nil, err
+	}
+	if msg.MiningJobToken, err = d.ReadB0_255(); err != nil {
+		return nil, err
+	}
+	if msg.Version, err = d.ReadU32(); err != nil {
+		return nil, err
+	}
+	if msg.CoinbasePrefix, err = d.ReadB0_64K(); err != nil {
+		return nil, err
+	}
+	if msg.CoinbaseSuffix, err = d.ReadB0_64K(); err != nil {
+		return nil, err
+	}
+	if msg.WTXIDList, err = d.ReadSeq0_64KU256(); err != nil {
+		return nil, err
+	}
+	if msg.ExcessData, err = d.ReadB0_64K(); err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
+
+// DeserializeDeclareMiningJobSuccess deserializes a declaration acceptance
+func (d *Deserializer) DeserializeDeclareMiningJobSuccess() (*DeclareMiningJobSuccess, error) {
+	msg := &DeclareMiningJobSuccess{}
+	var err error
+
+	if msg.RequestID, err = d.ReadU32(); err != nil {
+		return nil, err
+	}
+	if msg.NewMiningJobToken, err = d.ReadB0_255(); err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
+
+// DeserializeDeclareMiningJobError deserializes a declaration rejection
+func (d *Deserializer) DeserializeDeclareMiningJobError() (*DeclareMiningJobError, error) {
+	msg := &DeclareMiningJobError{}
+	var err error
+
+	if msg.RequestID, err = d.ReadU32(); err != nil {
+		return nil, err
+	}
+	if msg.ErrorCode, err = d.ReadSTR0_255(); err != nil {
+		return nil, err
+	}
+	if msg.ErrorDetails, err = d.ReadB0_64K(); err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
+
+// DeserializeProvideMissingTransactions deserializes a ProvideMissingTransactions message
+func (d *Deserializer) DeserializeProvideMissingTransactions() (*ProvideMissingTransactions, error) {
+	msg := &ProvideMissingTransactions{}
+	var err error
+
+	if msg.RequestID, err = d.ReadU32(); err != nil {
+		return nil, err
+	}
+	if msg.UnknownTxPositionList, err = d.ReadSeq0_64KU16(); err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
+
+// DeserializeProvideMissingTransactionsSuccess deserializes the missing transactions response
+func (d *Deserializer) DeserializeProvideMissingTransactionsSuccess() (*ProvideMissingTransactionsSuccess, error) {
+	msg := &ProvideMissingTransactionsSuccess{}
+	var err error
+
+	if msg.RequestID, err = d.ReadU32(); err != nil {
+		return nil, err
+	}
+	if msg.TransactionList, err = d.ReadSeq0_64KB0_16M(); err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
+
+// DeserializeSetCustomMiningJob deserializes a SetCustomMiningJob message
+func (d *Deserializer) DeserializeSetCustomMiningJob() (*SetCustomMiningJob, error) {
+	msg := &SetCustomMiningJob{}
+	var err error
+
+	if msg.ChannelID, err = d.ReadU32(); err != nil {
+		return nil, err
+	}
+	if msg.RequestID, err = d.ReadU32(); err != nil {
+		return nil, err
+	}
+	if msg.MiningJobToken, err = d.ReadB0_255(); err != nil {
+		return nil, err
+	}
+	if msg.Version, err = d.ReadU32(); err != nil {
+		return nil, err
+	}
+	if msg.PrevHash, err = d.ReadFixedBytes32(); err != nil {
+		return nil, err
+	}
+	if msg.MinNTime, err = d.ReadU32(); err != nil {
+		return nil, err
+	}
+	if msg.NBits, err = d.ReadU32(); err != nil {
+		return nil, err
+	}
+	if msg.CoinbaseTxVersion, err = d.ReadU32(); err != nil {
+		return nil, err
+	}
+	if msg.CoinbasePrefix, err = d.ReadB0_255(); err != nil {
+		return nil, err
+	}
+	if msg.CoinbaseTxInputNSequence, err = d.ReadU32(); err != nil {
+		return nil, err
+	}
+	if msg.CoinbaseTxValueRemaining, err = d.ReadU64(); err != nil {
+		return nil, err
+	}
+	if msg.CoinbaseTxOutputs, err = d.ReadB0_64K(); err != nil {
+		return nil, err
+	}
+	if msg.CoinbaseTxLocktime, err = d.ReadU32(); err != nil {
+		return nil, err
+	}
+	if msg.MerklePath, err = d.ReadSeq0_255U256(); err != nil {
+		return nil, err
+	}
+	if msg.ExtranonceSize, err = d.ReadU16(); err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
+
+// DeserializeSetCustomMiningJobSuccess deserializes a custom job acceptance
+func (d *Deserializer) DeserializeSetCustomMiningJobSuccess() (*SetCustomMiningJobSuccess, error) {
+	msg := &SetCustomMiningJobSuccess{}
+	var err error
+
+	if msg.ChannelID, err = d.ReadU32(); err != nil {
+		return nil, err
+	}
+	if msg.RequestID, err = d.ReadU32(); err != nil {
+		return nil, err
+	}
+	if msg.JobID, err = d.ReadU32(); err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
+
+// DeserializeSetCustomMiningJobError deserializes a custom job rejection
+func (d *Deserializer) DeserializeSetCustomMiningJobError() (*SetCustomMiningJobError, error) {
+	msg := &SetCustomMiningJobError{}
+	var err error
+
+	if msg.ChannelID, err = d.ReadU32(); err != nil {
+		return nil, err
+	}
+	if msg.RequestID, err = d.ReadU32(); err != nil {
+		return nil, err
+	}
+	if msg.ErrorCode, err = d.ReadSTR0_255(); err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
+
+// DeserializeCoinbaseOutputDataSize deserializes a CoinbaseOutputDataSize message
+func (d *Deserializer) DeserializeCoinbaseOutputDataSize() (*CoinbaseOutputDataSize, error) {
+	msg := &CoinbaseOutputDataSize{}
+	var err error
+
+	if msg.CoinbaseOutputMaxAdditionalSize, err = d.ReadU32(); err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
+
+// DeserializeNewTemplate deserializes a NewTemplate message
+func (d *Deserializer) DeserializeNewTemplate() (*NewTemplate, error) {
+	msg := &NewTemplate{}
+	var err error
+
+	if msg.TemplateID, err = d.ReadU64(); err != nil {
+		return nil, err
+	}
+	if msg.FutureTemplate, err = d.ReadBool(); err != nil {
+		return nil, err
+	}
+	if msg.Version, err = d.ReadU32(); err != nil {
+		return nil, err
+	}
+	if msg.CoinbaseTxVersion, err = d.ReadU32(); err != nil {
+		return nil, err
+	}
+	if msg.CoinbasePrefix, err = d.ReadB0_255(); err != nil {
+		return nil, err
+	}
+	if msg.CoinbaseTxInputSequence, err = d.ReadU32(); err != nil {
+		return nil, err
+	}
+	if msg.CoinbaseTxValueRemaining, err = d.ReadU64(); err != nil {
+		return nil, err
+	}
+	if msg.CoinbaseTxOutputsCount, err = d.ReadU32(); err != nil {
+		return nil, err
+	}
+	if msg.CoinbaseTxOutputs, err = d.ReadB0_64K(); err != nil {
+		return nil, err
+	}
+	if msg.CoinbaseTxLocktime, err = d.ReadU32(); err != nil {
+		return nil, err
+	}
+	if msg.MerklePath, err = d.ReadSeq0_255U256(); err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
+
+// DeserializeTemplateSetNewPrevHash deserializes a Template Distribution SetNewPrevHash message
+func (d *Deserializer) DeserializeTemplateSetNewPrevHash() (*TemplateSetNewPrevHash, error) {
+	msg := &TemplateSetNewPrevHash{}
+	var err error
+
+	if msg.TemplateID, err = d.ReadU64(); err != nil {
+		return nil, err
+	}
+	if msg.PrevHash, err = d.ReadFixedBytes32(); err != nil {
+		return nil, err
+	}
+	if msg.HeaderTimestamp, err = d.ReadU32(); err != nil {
+		return nil, err
+	}
+	if msg.NBits, err = d.ReadU32(); err != nil {
+		return nil, err
+	}
+	if msg.Target, err = d.ReadFixedBytes32(); err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
+
+// DeserializeRequestTransactionData deserializes a RequestTransactionData message
+func (d *Deserializer) DeserializeRequestTransactionData() (*RequestTransactionData, error) {
+	msg := &RequestTransactionData{}
+	var err error
+
+	if msg.TemplateID, err = d.ReadU64(); err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
+
+// DeserializeRequestTransactionDataSuccess deserializes a transaction data response
+func (d *Deserializer) DeserializeRequestTransactionDataSuccess() (*RequestTransactionDataSuccess, error) {
+	msg := &RequestTransactionDataSuccess{}
+	var err error
+
+	if msg.TemplateID, err = d.ReadU64(); err != nil {
+		return nil, err
+	}
+	if msg.ExcessData, err = d.ReadB0_64K(); err != nil {
+		return nil, err
+	}
+	if msg.TransactionList, err = d.ReadSeq0_64KB0_16M(); err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
+
+// DeserializeRequestTransactionDataError deserializes a transaction data error
+func (d *Deserializer) DeserializeRequestTransactionDataError() (*RequestTransactionDataError, error) {
+	msg := &RequestTransactionDataError{}
+	var err error
+
+	if msg.TemplateID, err = d.ReadU64(); err != nil {
+		return nil, err
+	}
+	if msg.ErrorCode, err = d.ReadSTR0_255(); err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
+
+// DeserializeSubmitSolution deserializes a SubmitSolution message
+func (d *Deserializer) DeserializeSubmitSolution() (*SubmitSolution, error) {
+	msg := &SubmitSolution{}
+	var err error
+
+	if msg.TemplateID, err = d.ReadU64(); err != nil {
+		return nil, err
+	}
+	if msg.Version, err = d.ReadU32(); err != nil {
+		return nil, err
+	}
+	if msg.HeaderTimestamp, err = d.ReadU32(); err != nil {
+		return nil, err
+	}
+	if msg.HeaderNonce, err = d.ReadU32(); err != nil {
+		return nil, err
+	}
+	if msg.CoinbaseTx, err = d.ReadB0_64K(); err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
@@ -306,6 +306,7 @@ func TestNewMiningJob_RoundTrip(t *testing.T) {
 		FuturePrevHash: false,
 		Version:        0x20000000,
 		VersionMask:    0x1fffe000,
+		MerkleRoot:     [32]byte{0x4a, 0x5e, 0x1e},
 	}
 
 	s := NewSerializer()
@@ -320,6 +321,7 @@ func TestNewMiningJob_RoundTrip(t *testing.T) {
 	assert.Equal(t, original.FuturePrevHash, parsed.FuturePrevHash)
 	assert.Equal(t, original.Version, parsed.Version)
 	assert.Equal(t, original.VersionMask, parsed.VersionMask)
+	assert.Equal(t, original.MerkleRoot, parsed.MerkleRoot)
 }
 
 func TestSetNewPrevHash_RoundTrip(t *testing.T) {
@@ -444,6 +446,364 @@ func TestReconnect_RoundTrip(t *testing.T) {
 	assert.Equal(t, original.NewPort, parsed.NewPort)
 }
 
+func TestOpenExtendedMiningChannel_RoundTrip(t *testing.T) {
+	original := &OpenExtendedMiningChannel{
+		RequestID:         7,
+		UserIdentity:      "farm-proxy",
+		NominalHashrate:   1.5e12,
+		MaxTarget:         [32]byte{0xff, 0xff, 0xff, 0x00},
+		MinExtranonceSize: 4,
+	}
+
+	s := NewSerializer()
+	payload := s.SerializeOpenExtendedMiningChannel(original)
+
+	d := NewDeserializer(payload)
+	parsed, err := d.DeserializeOpenExtendedMiningChannel()
+	require.NoError(t, err)
+	assert.Equal(t, original, parsed)
+	assert.Equal(t, 0, d.Remaining())
+}
+
+func TestOpenExtendedMiningChannelSuccess_RoundTrip(t *testing.T) {
+	original := &OpenExtendedMiningChannelSuccess{
+		RequestID:        7,
+		ChannelID:        3,
+		Target:           [32]byte{0x00, 0x00, 0xff},
+		ExtranonceSize:   4,
+		ExtranoncePrefix: []byte{0xde, 0xad, 0xbe, 0xef},
+	}
+
+	s := NewSerializer()
+	payload := s.SerializeOpenExtendedMiningChannelSuccess(original)
+
+	d := NewDeserializer(payload)
+	parsed, err := d.DeserializeOpenExtendedMiningChannelSuccess()
+	require.NoError(t, err)
+	assert.Equal(t, original, parsed)
+}
+
+func TestOpenExtendedMiningChannelError_RoundTrip(t *testing.T) {
+	original := &OpenExtendedMiningChannelError{
+		RequestID: 7,
+		ErrorCode: "min-extranonce-size-too-large",
+	}
+
+	s := NewSerializer()
+	payload := s.SerializeOpenExtendedMiningChannelError(original)
+
+	d := NewDeserializer(payload)
+	parsed, err := d.DeserializeOpenExtendedMiningChannelError()
+	require.NoError(t, err)
+	assert.Equal(t, original, parsed)
+}
+
+func TestNewExtendedMiningJob_RoundTrip(t *testing.T) {
+	original := &NewExtendedMiningJob{
+		ChannelID:             3,
+		JobID:                 0x65000001,
+		FuturePrevHash:        false,
+		Version:               0x20000000,
+		VersionRollingAllowed: true,
+		MerklePath:            [][32]byte{{0x01}, {0x02}, {0x03}},
+		CoinbaseTxPrefix:      []byte{0x01, 0x00, 0x00, 0x00, 0x01},
+		CoinbaseTxSuffix:      []byte{0xff, 0xff, 0xff, 0xff, 0x00},
+	}
+
+	s := NewSerializer()
+	payload := s.SerializeNewExtendedMiningJob(original)
+
+	d := NewDeserializer(payload)
+	parsed, err := d.DeserializeNewExtendedMiningJob()
+	require.NoError(t, err)
+	assert.Equal(t, original, parsed)
+	assert.Equal(t, 0, d.Remaining())
+}
+
+func TestNewExtendedMiningJob_EmptyMerklePath(t *testing.T) {
+	original := &NewExtendedMiningJob{
+		ChannelID:        3,
+		JobID:            1,
+		MerklePath:       [][32]byte{},
+		CoinbaseTxPrefix: []byte{},
+		CoinbaseTxSuffix: []byte{},
+	}
+
+	s := NewSerializer()
+	payload := s.SerializeNewExtendedMiningJob(original)
+
+	d := NewDeserializer(payload)
+	parsed, err := d.DeserializeNewExtendedMiningJob()
+	require.NoError(t, err)
+	assert.Empty(t, parsed.MerklePath)
+	assert.Empty(t, parsed.CoinbaseTxPrefix)
+}
+
+func TestSubmitSharesExtended_RoundTrip(t *testing.T) {
+	original := &SubmitSharesExtended{
+		ChannelID:   3,
+		SequenceNum: 11,
+		JobID:       0x65000001,
+		Nonce:       0x7c3f51cd,
+		NTime:       0x4e8eaab9,
+		Version:     0x20000000,
+		Extranonce:  []byte{0x00, 0x00, 0x00, 0x2a},
+	}
+
+	s := NewSerializer()
+	payload := s.SerializeSubmitSharesExtended(original)
+
+	d := NewDeserializer(payload)
+	parsed, err := d.DeserializeSubmitSharesExtended()
+	require.NoError(t, err)
+	assert.Equal(t, original, parsed)
+}
+
+func TestSubmitSharesExtended_ExtranonceTooLong(t *testing.T) {
+	s := NewSerializer()
+	s.WriteU32(3)
+	s.WriteU32(11)
+	s.WriteU32(1)
+	s.WriteU32(0)
+	s.WriteU32(0)
+	s.WriteU32(0)
+	s.WriteU8(MaxB0_32Len + 1)
+	s.WriteFixedBytes(nil, MaxB0_32Len+1)
+
+	d := NewDeserializer(s.Bytes())
+	_, err := d.DeserializeSubmitSharesExtended()
+	assert.Equal(t, ErrFieldTooLong, err)
+}
+
+func TestSetExtranoncePrefix_RoundTrip(t *testing.T) {
+	original := &SetExtranoncePrefix{
+		ChannelID:        3,
+		ExtranoncePrefix: []byte{0x00, 0x00, 0x00, 0x09},
+	}
+
+	s := NewSerializer()
+	payload := s.SerializeSetExtranoncePrefix(original)
+
+	d := NewDeserializer(payload)
+	parsed, err := d.DeserializeSetExtranoncePrefix()
+	require.NoError(t, err)
+	assert.Equal(t, original, parsed)
+}
+
+func TestAllocateMiningJobToken_RoundTrip(t *testing.T) {
+	original := &AllocateMiningJobToken{UserIdentifier: "alice", RequestID: 1}
+
+	s := NewSerializer()
+	payload := s.SerializeAllocateMiningJobToken(original)
+
+	d := NewDeserializer(payload)
+	parsed, err := d.DeserializeAllocateMiningJobToken()
+	require.NoError(t, err)
+	assert.Equal(t, original, parsed)
+}
+
+func TestAllocateMiningJobTokenSuccess_RoundTrip(t *testing.T) {
+	original := &AllocateMiningJobTokenSuccess{
+		RequestID:                       1,
+		MiningJobToken:                  []byte{0xaa, 0xbb, 0xcc, 0xdd},
+		CoinbaseOutputMaxAdditionalSize: 31,
+		CoinbaseOutputs:                 []byte{0x00, 0x16, 0x00, 0x14},
+		AsyncMiningAllowed:              true,
+	}
+
+	s := NewSerializer()
+	payload := s.SerializeAllocateMiningJobTokenSuccess(original)
+
+	d := NewDeserializer(payload)
+	parsed, err := d.DeserializeAllocateMiningJobTokenSuccess()
+	require.NoError(t, err)
+	assert.Equal(t, original, parsed)
+	assert.Equal(t, 0, d.Remaining())
+}
+
+func TestDeclareMiningJob_RoundTrip(t *testing.T) {
+	original := &DeclareMiningJob{
+		RequestID:      2,
+		MiningJobToken: []byte{0xaa, 0xbb},
+		Version:        0x20000000,
+		CoinbasePrefix: []byte{0x01, 0x00, 0x00, 0x00},
+		CoinbaseSuffix: []byte{0xff, 0xff, 0xff, 0xff},
+		WTXIDList:      [][32]byte{{0x01}, {0x02}},
+		ExcessData:     []byte{},
+	}
+
+	s := NewSerializer()
+	payload := s.SerializeDeclareMiningJob(original)
+
+	d := NewDeserializer(payload)
+	parsed, err := d.DeserializeDeclareMiningJob()
+	require.NoError(t, err)
+	assert.Equal(t, original, parsed)
+	assert.Equal(t, 0, d.Remaining())
+}
+
+func TestDeclareMiningJobResponses_RoundTrip(t *testing.T) {
+	s := NewSerializer()
+
+	success := &DeclareMiningJobSuccess{RequestID: 2, NewMiningJobToken: []byte{0x01, 0x02}}
+	parsedSuccess, err := NewDeserializer(s.SerializeDeclareMiningJobSuccess(success)).DeserializeDeclareMiningJobSuccess()
+	require.NoError(t, err)
+	assert.Equal(t, success, parsedSuccess)
+
+	failure := &DeclareMiningJobError{RequestID: 2, ErrorCode: "invalid-mining-job-token", ErrorDetails: []byte("expired")}
+	parsedFailure, err := NewDeserializer(s.SerializeDeclareMiningJobError(failure)).DeserializeDeclareMiningJobError()
+	require.NoError(t, err)
+	assert.Equal(t, failure, parsedFailure)
+}
+
+func TestProvideMissingTransactions_RoundTrip(t *testing.T) {
+	s := NewSerializer()
+
+	request := &ProvideMissingTransactions{RequestID: 2, UnknownTxPositionList: []uint16{0, 3, 700}}
+	parsedRequest, err := NewDeserializer(s.SerializeProvideMissingTransactions(request)).DeserializeProvideMissingTransactions()
+	require.NoError(t, err)
+	assert.Equal(t, request, parsedRequest)
+
+	response := &ProvideMissingTransactionsSuccess{
+		RequestID:       2,
+		TransactionList: [][]byte{{0x02, 0x00, 0x00, 0x00}, make([]byte, 70000)},
+	}
+	d := NewDeserializer(s.SerializeProvideMissingTransactionsSuccess(response))
+	parsedResponse, err := d.DeserializeProvideMissingTransactionsSuccess()
+	require.NoError(t, err)
+	assert.Equal(t, response, parsedResponse)
+	assert.Equal(t, 0, d.Remaining())
+}
+
+func TestProvideMissingTransactions_TruncatedList(t *testing.T) {
+	s := NewSerializer()
+	s.WriteU32(2)
+	s.WriteU16(5000)
+	s.WriteU16(1)
+
+	_, err := NewDeserializer(s.Bytes()).DeserializeProvideMissingTransactions()
+	assert.Error(t, err)
+}
+
+func TestSetCustomMiningJob_RoundTrip(t *testing.T) {
+	original := &SetCustomMiningJob{
+		ChannelID:                1,
+		RequestID:                3,
+		MiningJobToken:           []byte{0x01, 0x02},
+		Version:                  0x20000000,
+		PrevHash:                 [32]byte{0xab},
+		MinNTime:                 0x4e8eaab9,
+		NBits:                    0x1d00ffff,
+		CoinbaseTxVersion:        2,
+		CoinbasePrefix:           []byte{0x03, 0x01, 0x02, 0x03},
+		CoinbaseTxInputNSequence: 0xffffffff,
+		CoinbaseTxValueRemaining: 625000000,
+		CoinbaseTxOutputs:        []byte{0x01},
+		CoinbaseTxLocktime:       0,
+		MerklePath:               [][32]byte{{0x09}},
+		ExtranonceSize:           8,
+	}
+
+	s := NewSerializer()
+	payload := s.SerializeSetCustomMiningJob(original)
+
+	d := NewDeserializer(payload)
+	parsed, err := d.DeserializeSetCustomMiningJob()
+	require.NoError(t, err)
+	assert.Equal(t, original, parsed)
+	assert.Equal(t, 0, d.Remaining())
+
+	success := &SetCustomMiningJobSuccess{ChannelID: 1, RequestID: 3, JobID: 42}
+	parsedSuccess, err := NewDeserializer(s.SerializeSetCustomMiningJobSuccess(success)).DeserializeSetCustomMiningJobSuccess()
+	require.NoError(t, err)
+	assert.Equal(t, success, parsedSuccess)
+
+	failure := &SetCustomMiningJobError{ChannelID: 1, RequestID: 3, ErrorCode: "invalid-mining-job-token"}
+	parsedFailure, err := NewDeserializer(s.SerializeSetCustomMiningJobError(failure)).DeserializeSetCustomMiningJobError()
+	require.NoError(t, err)
+	assert.Equal(t, failure, parsedFailure)
+}
+
+func TestNewTemplate_RoundTrip(t *testing.T) {
+	original := &NewTemplate{
+		TemplateID:               9,
+		FutureTemplate:           true,
+		Version:                  0x20000000,
+		CoinbaseTxVersion:        2,
+		CoinbasePrefix:           []byte{0x03, 0xa0, 0x25, 0x26},
+		CoinbaseTxInputSequence:  0xffffffff,
+		CoinbaseTxValueRemaining: 625000000,
+		CoinbaseTxOutputsCount:   1,
+		CoinbaseTxOutputs:        []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x6a},
+		CoinbaseTxLocktime:       0,
+		MerklePath:               [][32]byte{{0x01}, {0x02}},
+	}
+
+	s := NewSerializer()
+	payload := s.SerializeNewTemplate(original)
+
+	d := NewDeserializer(payload)
+	parsed, err := d.DeserializeNewTemplate()
+	require.NoError(t, err)
+	assert.Equal(t, original, parsed)
+	assert.Equal(t, 0, d.Remaining())
+}
+
+func TestTemplateSetNewPrevHash_RoundTrip(t *testing.T) {
+	original := &TemplateSetNewPrevHash{
+		TemplateID:      9,
+		PrevHash:        [32]byte{0xaa, 0xbb},
+		HeaderTimestamp: 0x65000000,
+		NBits:           0x1a0ffff0,
+		Target:          [32]byte{0x00, 0x00, 0xf0},
+	}
+
+	s := NewSerializer()
+	d := NewDeserializer(s.SerializeTemplateSetNewPrevHash(original))
+	parsed, err := d.DeserializeTemplateSetNewPrevHash()
+	require.NoError(t, err)
+	assert.Equal(t, original, parsed)
+}
+
+func TestRequestTransactionData_RoundTrip(t *testing.T) {
+	s := NewSerializer()
+
+	request := &RequestTransactionData{TemplateID: 9}
+	parsedRequest, err := NewDeserializer(s.SerializeRequestTransactionData(request)).DeserializeRequestTransactionData()
+	require.NoError(t, err)
+	assert.Equal(t, request, parsedRequest)
+
+	success := &RequestTransactionDataSuccess{
+		TemplateID:      9,
+		ExcessData:      []byte{},
+		TransactionList: [][]byte{{0x02, 0x00, 0x00, 0x00}, {0x01, 0x00, 0x00, 0x00}},
+	}
+	parsedSuccess, err := NewDeserializer(s.SerializeRequestTransactionDataSuccess(success)).DeserializeRequestTransactionDataSuccess()
+	require.NoError(t, err)
+	assert.Equal(t, success, parsedSuccess)
+
+	failure := &RequestTransactionDataError{TemplateID: 9, ErrorCode: "template-id-not-found"}
+	parsedFailure, err := NewDeserializer(s.SerializeRequestTransactionDataError(failure)).DeserializeRequestTransactionDataError()
+	require.NoError(t, err)
+	assert.Equal(t, failure, parsedFailure)
+}
+
+func TestSubmitSolution_RoundTrip(t *testing.T) {
+	original := &SubmitSolution{
+		TemplateID:      9,
+		Version:         0x20000000,
+		HeaderTimestamp: 0x65000000,
+		HeaderNonce:     0xdeadbeef,
+		CoinbaseTx:      []byte{0x01, 0x00, 0x00, 0x00, 0x01},
+	}
+
+	s := NewSerializer()
+	d := NewDeserializer(s.SerializeSubmitSolution(original))
+	parsed, err := d.DeserializeSubmitSolution()
+	require.NoError(t, err)
+	assert.Equal(t, original, parsed)
+}
+
 // -----------------------------------------------------------------------------
 // Frame Serialization Tests
 // -----------------------------------------------------------------------------
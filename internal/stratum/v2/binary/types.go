@@ -53,6 +53,37 @@ const (
 	MsgTypeSetExtranoncePrefix uint8 = 0x51
 )
 
+// Job Declaration protocol message types. They travel on a separate
+// connection, so the numbers may overlap the mining protocol's.
+const (
+	MsgTypeAllocateMiningJobToken            uint8 = 0x50
+	MsgTypeAllocateMiningJobTokenSuccess     uint8 = 0x51
+	MsgTypeProvideMissingTransactions        uint8 = 0x55
+	MsgTypeProvideMissingTransactionsSuccess uint8 = 0x56
+	MsgTypeDeclareMiningJob                  uint8 = 0x57
+	MsgTypeDeclareMiningJobSuccess           uint8 = 0x58
+	MsgTypeDeclareMiningJobError             uint8 = 0x59
+)
+
+// Template Distribution protocol message types (separate connection to a
+// node-side Template Provider)
+const (
+	MsgTypeCoinbaseOutputDataSize        uint8 = 0x70
+	MsgTypeNewTemplate                   uint8 = 0x71
+	MsgTypeTemplateSetNewPrevHash        uint8 = 0x72
+	MsgTypeRequestTransactionData        uint8 = 0x73
+	MsgTypeRequestTransactionDataSuccess uint8 = 0x74
+	MsgTypeRequestTransactionDataError   uint8 = 0x75
+	MsgTypeSubmitSolution                uint8 = 0x76
+)
+
+// Sub-protocols selected by SetupConnection.Protocol
+const (
+	ProtocolMining               uint8 = 0
+	ProtocolJobDeclaration       uint8 = 1
+	ProtocolTemplateDistribution uint8 = 2
+)
+
 // Extension type flags
 const (
 	ExtensionTypeNone           uint16 = 0x0000
@@ -76,6 +107,29 @@ const (
 	ErrNotSubscribed        uint8 = 0x0A
 )
 
+// errorCodeNames maps error codes to the strings sent in *Error messages
+var errorCodeNames = map[uint8]STR0_255{
+	ErrUnknownMessage:       "unknown-message",
+	ErrInvalidExtensionType: "invalid-extension-type",
+	ErrInvalidChannelID:     "invalid-channel-id",
+	ErrInvalidJobID:         "invalid-job-id",
+	ErrInvalidTarget:        "invalid-target",
+	ErrInvalidShare:         "invalid-share",
+	ErrStaleShare:           "stale-share",
+	ErrDuplicateShare:       "duplicate-share",
+	ErrLowDifficultyShare:   "difficulty-too-low",
+	ErrUnauthorized:         "unauthorized",
+	ErrNotSubscribed:        "not-subscribed",
+}
+
+// ErrorCodeName returns the wire string for an error code
+func ErrorCodeName(code uint8) STR0_255 {
+	if name, ok := errorCodeNames[code]; ok {
+		return name
+	}
+	return errorCodeNames[ErrUnknownMessage]
+}
+
 // Errors
 var (
 	ErrInvalidMessageLength = errors.New("invalid message length")
@@ -83,6 +137,18 @@ var (
 	ErrInvalidHeader        = errors.New("invalid message header")
 	ErrTruncatedMessage     = errors.New("truncated message")
 	ErrBufferTooSmall       = errors.New("buffer too small")
+	ErrFieldTooLong         = errors.New("field exceeds maximum length")
+)
+
+// Variable-length byte field limits
+const (
+	MaxB0_32Len       = 32
+	MaxB0_255Len      = 0xFF
+	MaxB0_64KLen      = 0xFFFF
+	MaxB0_16MLen      = 0xFFFFFF
+	MaxSeq0_64KLen    = 0xFFFF
+	MaxMerklePathLen  = 255
+	MaxExtranonceSize = MaxB0_32Len
 )
 
 // =============================================================================
@@ -211,11 +277,12 @@ type OpenStandardMiningChannelError struct {
 
 // NewMiningJob contains a new mining job
 type NewMiningJob struct {
-	ChannelID      uint32 // Target channel
-	JobID          uint32 // Unique job identifier
-	FuturePrevHash bool   // If true, prevhash not yet available
-	Version        uint32 // Block version
-	VersionMask    uint32 // Mask for version rolling
+	ChannelID      uint32   // Target channel
+	JobID          uint32   // Unique job identifier
+	FuturePrevHash bool     // If true, prevhash not yet available
+	Version        uint32   // Block version
+	VersionMask    uint32   // Mask for version rolling
+	MerkleRoot     [32]byte // Merkle root over the channel's coinbase, in header byte order
 }
 
 // SetNewPrevHash updates the previous block hash
@@ -252,6 +319,65 @@ type SubmitSharesError struct {
 	ErrorCode   STR0_255 // Error code
 }
 
+// =============================================================================
+// Extended Channel Messages
+// Used by proxies that roll their own extranonce and build the coinbase
+// =============================================================================
+
+// OpenExtendedMiningChannel requests an extended channel (proxies, farms)
+type OpenExtendedMiningChannel struct {
+	RequestID         uint32   // Client-assigned request ID
+	UserIdentity      STR0_255 // User/worker identity
+	NominalHashrate   float32  // Expected hashrate in H/s
+	MaxTarget         [32]byte // Maximum target the client accepts
+	MinExtranonceSize uint16   // Minimum extranonce bytes the client needs to roll
+}
+
+// OpenExtendedMiningChannelSuccess confirms an extended channel
+type OpenExtendedMiningChannelSuccess struct {
+	RequestID        uint32   // Matching request ID
+	ChannelID        uint32   // Server-assigned channel ID
+	Target           [32]byte // Initial mining target
+	ExtranonceSize   uint16   // Extranonce bytes the client rolls
+	ExtranoncePrefix []byte   // Server-assigned prefix (B0_32)
+}
+
+// OpenExtendedMiningChannelError indicates extended channel open failure
+type OpenExtendedMiningChannelError struct {
+	RequestID uint32   // Matching request ID
+	ErrorCode STR0_255 // Error code
+}
+
+// NewExtendedMiningJob carries a job whose coinbase the client completes
+// as CoinbaseTxPrefix + ExtranoncePrefix + extranonce + CoinbaseTxSuffix
+type NewExtendedMiningJob struct {
+	ChannelID             uint32     // Target channel
+	JobID                 uint32     // Unique job identifier
+	FuturePrevHash        bool       // If true, prevhash not yet available
+	Version               uint32     // Block version
+	VersionRollingAllowed bool       // Whether the client may roll version bits
+	MerklePath            [][32]byte // Merkle branch for the coinbase (SEQ0_255[U256])
+	CoinbaseTxPrefix      []byte     // Coinbase bytes before the extranonce (B0_64K)
+	CoinbaseTxSuffix      []byte     // Coinbase bytes after the extranonce (B0_64K)
+}
+
+// SubmitSharesExtended submits a share from an extended channel
+type SubmitSharesExtended struct {
+	ChannelID   uint32 // Channel ID
+	SequenceNum uint32 // Sequence number for tracking
+	JobID       uint32 // Job being mined
+	Nonce       uint32 // Nonce solution
+	NTime       uint32 // Block time
+	Version     uint32 // Block version (if version rolling)
+	Extranonce  []byte // Client-rolled extranonce (B0_32)
+}
+
+// SetExtranoncePrefix assigns a new extranonce prefix to an extended channel
+type SetExtranoncePrefix struct {
+	ChannelID        uint32 // Target channel
+	ExtranoncePrefix []byte // New prefix (B0_32)
+}
+
 // SetTarget updates the mining target
 type SetTarget struct {
 	ChannelID uint32   // Target channel
@@ -263,3 +389,154 @@ type Reconnect struct {
 	NewHost STR0_255 // New host to connect to
 	NewPort uint16   // New port
 }
+
+// =============================================================================
+// Job Declaration Protocol Messages
+// =============================================================================
+
+// AllocateMiningJobToken asks the pool for a token to declare a job with
+type AllocateMiningJobToken struct {
+	UserIdentifier STR0_255 // Account the declared work is credited to
+	RequestID      uint32   // Client-assigned request ID
+}
+
+// AllocateMiningJobTokenSuccess hands out a token and the outputs the
+// declared coinbase must pay
+type AllocateMiningJobTokenSuccess struct {
+	RequestID                       uint32 // Matching request ID
+	MiningJobToken                  []byte // Single-use token (B0_255)
+	CoinbaseOutputMaxAdditionalSize uint32 // Bytes the pool outputs add to the coinbase
+	CoinbaseOutputs                 []byte // Serialized pool outputs (B0_64K)
+	AsyncMiningAllowed              bool   // Whether mining may start before the declaration is acknowledged
+}
+
+// DeclareMiningJob proposes a job built from the client's own template
+type DeclareMiningJob struct {
+	RequestID      uint32     // Client-assigned request ID
+	MiningJobToken []byte     // Token from AllocateMiningJobToken.Success (B0_255)
+	Version        uint32     // Block version
+	CoinbasePrefix []byte     // Coinbase bytes before the extranonce (B0_64K)
+	CoinbaseSuffix []byte     // Coinbase bytes after the extranonce (B0_64K)
+	WTXIDList      [][32]byte // Witness txids of the block's transactions (SEQ0_64K[U256])
+	ExcessData     []byte     // Reserved for extensions (B0_64K)
+}
+
+// DeclareMiningJobSuccess accepts a declared job
+type DeclareMiningJobSuccess struct {
+	RequestID         uint32 // Matching request ID
+	NewMiningJobToken []byte // Token to reference the job in SetCustomMiningJob (B0_255)
+}
+
+// DeclareMiningJobError rejects a declared job
+type DeclareMiningJobError struct {
+	RequestID    uint32   // Matching request ID
+	ErrorCode    STR0_255 // Error code
+	ErrorDetails []byte   // Optional details (B0_64K)
+}
+
+// ProvideMissingTransactions asks the client for transactions the pool does not know
+type ProvideMissingTransactions struct {
+	RequestID             uint32   // Matching DeclareMiningJob request ID
+	UnknownTxPositionList []uint16 // Positions in the declared WTXIDList (SEQ0_64K[U16])
+}
+
+// ProvideMissingTransactionsSuccess carries the requested transactions
+type ProvideMissingTransactionsSuccess struct {
+	RequestID       uint32   // Matching request ID
+	TransactionList [][]byte // Raw transactions in requested order (SEQ0_64K[B0_16M])
+}
+
+// SetCustomMiningJob activates a declared job on a mining channel
+type SetCustomMiningJob struct {
+	ChannelID                uint32     // Channel the job is mined on
+	RequestID                uint32     // Client-assigned request ID
+	MiningJobToken           []byte     // Token from DeclareMiningJob.Success (B0_255)
+	Version                  uint32     // Block version
+	PrevHash                 [32]byte   // Previous block hash
+	MinNTime                 uint32     // Smallest ntime the job may use
+	NBits                    uint32     // Compact network target
+	CoinbaseTxVersion        uint32     // Coinbase transaction version
+	CoinbasePrefix           []byte     // Coinbase script prefix (B0_255)
+	CoinbaseTxInputNSequence uint32     // Coinbase input sequence
+	CoinbaseTxValueRemaining uint64     // Value not assigned to the listed outputs
+	CoinbaseTxOutputs        []byte     // Serialized coinbase outputs (B0_64K)
+	CoinbaseTxLocktime       uint32     // Coinbase locktime
+	MerklePath               [][32]byte // Merkle branch for the coinbase (SEQ0_255[U256])
+	ExtranonceSize           uint16     // Extranonce bytes in the coinbase script
+}
+
+// SetCustomMiningJobSuccess confirms a custom job
+type SetCustomMiningJobSuccess struct {
+	ChannelID uint32 // Channel the job is mined on
+	RequestID uint32 // Matching request ID
+	JobID     uint32 // Pool-assigned job ID for share submission
+}
+
+// SetCustomMiningJobError rejects a custom job
+type SetCustomMiningJobError struct {
+	ChannelID uint32   // Channel the job was meant for
+	RequestID uint32   // Matching request ID
+	ErrorCode STR0_255 // Error code
+}
+
+// =============================================================================
+// Template Distribution Protocol Messages
+// =============================================================================
+
+// CoinbaseOutputDataSize tells the Template Provider how many bytes of
+// coinbase outputs the pool will add
+type CoinbaseOutputDataSize struct {
+	CoinbaseOutputMaxAdditionalSize uint32 // Bytes reserved for pool outputs
+}
+
+// NewTemplate carries a block template without its previous block hash
+type NewTemplate struct {
+	TemplateID               uint64     // Provider-assigned template ID
+	FutureTemplate           bool       // True if the template waits for a SetNewPrevHash
+	Version                  uint32     // Block version
+	CoinbaseTxVersion        uint32     // Coinbase transaction version
+	CoinbasePrefix           []byte     // Start of the coinbase script, incl. BIP34 height (B0_255)
+	CoinbaseTxInputSequence  uint32     // Coinbase input sequence
+	CoinbaseTxValueRemaining uint64     // Value the pool may assign to its own outputs
+	CoinbaseTxOutputsCount   uint32     // Number of outputs in CoinbaseTxOutputs
+	CoinbaseTxOutputs        []byte     // Serialized outputs required by the node (B0_64K)
+	CoinbaseTxLocktime       uint32     // Coinbase locktime
+	MerklePath               [][32]byte // Merkle branch for the coinbase (SEQ0_255[U256])
+}
+
+// TemplateSetNewPrevHash is the Template Distribution SetNewPrevHash: it
+// activates a template on a new chain tip
+type TemplateSetNewPrevHash struct {
+	TemplateID      uint64   // Template that becomes valid
+	PrevHash        [32]byte // Previous block hash
+	HeaderTimestamp uint32   // Timestamp for the block header
+	NBits           uint32   // Compact network target
+	Target          [32]byte // Full network target (little-endian U256)
+}
+
+// RequestTransactionData asks for the transactions of a template
+type RequestTransactionData struct {
+	TemplateID uint64 // Template to fetch
+}
+
+// RequestTransactionDataSuccess returns a template's transactions
+type RequestTransactionDataSuccess struct {
+	TemplateID      uint64   // Matching template ID
+	ExcessData      []byte   // Extra data such as the witness reserved value (B0_64K)
+	TransactionList [][]byte // Raw transactions in block order (SEQ0_64K[B0_16M])
+}
+
+// RequestTransactionDataError reports that a template's transactions are unavailable
+type RequestTransactionDataError struct {
+	TemplateID uint64   // Matching template ID
+	ErrorCode  STR0_255 // Error code
+}
+
+// SubmitSolution hands a solved block back to the Template Provider
+type SubmitSolution struct {
+	TemplateID      uint64 // Template the block was built from
+	Version         uint32 // Block header version
+	HeaderTimestamp uint32 // Block header timestamp
+	HeaderNonce     uint32 // Block header nonce
+	CoinbaseTx      []byte // Complete coinbase transaction (B0_64K)
+}
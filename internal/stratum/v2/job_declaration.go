@@ -0,0 +1,528 @@
+package v2
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/chimera-pool/chimera-pool-core/internal/stratum"
+	v2binary "github.com/chimera-pool/chimera-pool-core/internal/stratum/v2/binary"
+)
+
+// =============================================================================
+// JOB DECLARATION PROTOCOL (SV2 sub-protocol 1)
+// Miners allocate a token, declare a job built from their own template and
+// activate it with SetCustomMiningJob. Declarations are checked by
+// HandleDeclaration before the pool acknowledges them.
+// =============================================================================
+
+// Job Declaration protocol settings
+const (
+	jdProtocolVersion = 2
+	jdIdleTimeout     = 10 * time.Minute
+	jdTokenSize       = 8
+)
+
+// Job Declaration error codes sent on the wire
+const (
+	jdErrInvalidToken        = "invalid-mining-job-token"
+	jdErrNoPoolTemplate      = "no-pool-template"
+	jdErrMissingPoolOutput   = "pool-output-missing"
+	jdErrMissingTxMismatch   = "invalid-missing-transactions"
+	jdErrStaleChainTip       = "stale-chain-tip"
+	jdErrUnsupportedProtocol = "unsupported-protocol"
+)
+
+// errJDSetupRequired is returned when a client skips SetupConnection
+var errJDSetupRequired = errors.New("job declaration: SetupConnection required")
+
+// miningJobToken tracks a token handed out to a job declarator
+type miningJobToken struct {
+	user        string
+	expiresAt   time.Time
+	declaration *stratum.JobDeclaration // Set once the token identifies a declared job
+}
+
+// pendingDeclaration is a DeclareMiningJob waiting for ProvideMissingTransactions.Success
+type pendingDeclaration struct {
+	user    string
+	msg     *v2binary.DeclareMiningJob
+	txs     [][]byte
+	missing []uint16
+}
+
+// jdSession is the per-connection state of a Job Declaration client
+type jdSession struct {
+	server  *jobDeclaratorServer
+	conn    net.Conn
+	remote  string
+	setup   bool
+	pending map[uint32]*pendingDeclaration
+}
+
+// SetPoolOutputScript sets the scriptPubKey every declared coinbase must pay
+func (s *jobDeclaratorServer) SetPoolOutputScript(script []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.poolOutputScript = append([]byte(nil), script...)
+}
+
+// SetDeclarationHandler registers a callback for declarations accepted over the wire
+func (s *jobDeclaratorServer) SetDeclarationHandler(handler func(*stratum.JobDeclaration, *v2binary.DeclareMiningJob)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onDeclared = handler
+}
+
+// SetCustomJobHandler registers a callback for declared jobs activated with SetCustomMiningJob
+func (s *jobDeclaratorServer) SetCustomJobHandler(handler func(*stratum.JobDeclaration, *v2binary.SetCustomMiningJob)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onCustomJob = handler
+}
+
+// SetConnWrapper installs a function run on each accepted connection before
+// the protocol starts, e.g. a Noise handshake
+func (s *jobDeclaratorServer) SetConnWrapper(wrap func(net.Conn) (net.Conn, error)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.wrapConn = wrap
+}
+
+// ServeConn speaks the Job Declaration protocol on conn until it closes
+func (s *jobDeclaratorServer) ServeConn(conn net.Conn) {
+	s.mu.RLock()
+	wrap := s.wrapConn
+	s.mu.RUnlock()
+
+	if wrap != nil {
+		wrapped, err := wrap(conn)
+		if err != nil {
+			log.Printf("Job declaration handshake failed for %s: %v", conn.RemoteAddr(), err)
+			return
+		}
+		conn = wrapped
+	}
+
+	session := &jdSession{
+		server:  s,
+		conn:    conn,
+		remote:  conn.RemoteAddr().String(),
+		pending: make(map[uint32]*pendingDeclaration),
+	}
+	if err := session.run(); err != nil && err != io.EOF {
+		log.Printf("Job declaration connection %s closed: %v", session.remote, err)
+	}
+}
+
+// run reads frames and dispatches them until the connection fails
+func (js *jdSession) run() error {
+	header := make([]byte, v2binary.HeaderSize)
+	for {
+		if js.server.ctx.Err() != nil {
+			return nil
+		}
+		js.conn.SetReadDeadline(time.Now().Add(jdIdleTimeout))
+		if _, err := io.ReadFull(js.conn, header); err != nil {
+			return err
+		}
+		h, err := v2binary.ParseHeader(header)
+		if err != nil {
+			return err
+		}
+		payload := make([]byte, h.MsgLength)
+		if _, err := io.ReadFull(js.conn, payload); err != nil {
+			return err
+		}
+		if err := js.handleMessage(h.MsgType, payload); err != nil {
+			return err
+		}
+	}
+}
+
+// handleMessage dispatches a single Job Declaration message
+func (js *jdSession) handleMessage(msgType uint8, payload []byte) error {
+	d := v2binary.NewDeserializer(payload)
+
+	if msgType == v2binary.MsgTypeSetupConnection {
+		msg, err := d.DeserializeSetupConnection()
+		if err != nil {
+			return fmt.Errorf("deserialize SetupConnection: %w", err)
+		}
+		return js.handleSetupConnection(msg)
+	}
+	if !js.setup {
+		return errJDSetupRequired
+	}
+
+	switch msgType {
+	case v2binary.MsgTypeAllocateMiningJobToken:
+		msg, err := d.DeserializeAllocateMiningJobToken()
+		if err != nil {
+			return fmt.Errorf("deserialize AllocateMiningJobToken: %w", err)
+		}
+		return js.handleAllocateToken(msg)
+
+	case v2binary.MsgTypeDeclareMiningJob:
+		msg, err := d.DeserializeDeclareMiningJob()
+		if err != nil {
+			return fmt.Errorf("deserialize DeclareMiningJob: %w", err)
+		}
+		return js.handleDeclareMiningJob(msg)
+
+	case v2binary.MsgTypeProvideMissingTransactionsSuccess:
+		msg, err := d.DeserializeProvideMissingTransactionsSuccess()
+		if err != nil {
+			return fmt.Errorf("deserialize ProvideMissingTransactionsSuccess: %w", err)
+		}
+		return js.handleMissingTransactions(msg)
+
+	case v2binary.MsgTypeSetCustomMiningJob:
+		msg, err := d.DeserializeSetCustomMiningJob()
+		if err != nil {
+			return fmt.Errorf("deserialize SetCustomMiningJob: %w", err)
+		}
+		return js.handleSetCustomMiningJob(msg)
+
+	default:
+		log.Printf("Job declaration: ignoring message 0x%02x from %s", msgType, js.remote)
+		return nil
+	}
+}
+
+// handleSetupConnection accepts connections that ask for the Job Declaration protocol
+func (js *jdSession) handleSetupConnection(msg *v2binary.SetupConnection) error {
+	if msg.Protocol != v2binary.ProtocolJobDeclaration ||
+		msg.MinVersion > jdProtocolVersion || msg.MaxVersion < jdProtocolVersion {
+		ser := v2binary.NewSerializer()
+		payload := ser.SerializeSetupConnectionError(&v2binary.SetupConnectionError{
+			ErrorCode: jdErrUnsupportedProtocol,
+		})
+		if err := js.send(v2binary.MsgTypeSetupConnectionError, payload); err != nil {
+			return err
+		}
+		return fmt.Errorf("unsupported protocol %d (versions %d-%d)", msg.Protocol, msg.MinVersion, msg.MaxVersion)
+	}
+
+	js.setup = true
+	ser := v2binary.NewSerializer()
+	payload := ser.SerializeSetupConnectionSuccess(&v2binary.SetupConnectionSuccess{
+		UsedVersion: jdProtocolVersion,
+	})
+	return js.send(v2binary.MsgTypeSetupConnectionSuccess, payload)
+}
+
+// handleAllocateToken issues a single-use token for one declaration
+func (js *jdSession) handleAllocateToken(msg *v2binary.AllocateMiningJobToken) error {
+	s := js.server
+	user := strings.TrimSpace(string(msg.UserIdentifier))
+
+	token := make([]byte, jdTokenSize)
+	rand.Read(token)
+
+	s.tokensMu.Lock()
+	s.tokens[string(token)] = &miningJobToken{
+		user:      user,
+		expiresAt: time.Now().Add(s.GetTemplatePolicy().DeclarationTTL),
+	}
+	s.tokensMu.Unlock()
+
+	outputs := s.poolCoinbaseOutputs()
+	ser := v2binary.NewSerializer()
+	payload := ser.SerializeAllocateMiningJobTokenSuccess(&v2binary.AllocateMiningJobTokenSuccess{
+		RequestID:                       msg.RequestID,
+		MiningJobToken:                  token,
+		CoinbaseOutputMaxAdditionalSize: uint32(len(outputs)),
+		CoinbaseOutputs:                 outputs,
+		AsyncMiningAllowed:              false,
+	})
+	return js.send(v2binary.MsgTypeAllocateMiningJobTokenSuccess, payload)
+}
+
+// handleDeclareMiningJob resolves the declared transactions and asks the
+// client for any the pool does not already know
+func (js *jdSession) handleDeclareMiningJob(msg *v2binary.DeclareMiningJob) error {
+	s := js.server
+	token := string(msg.MiningJobToken)
+
+	s.tokensMu.Lock()
+	entry, ok := s.tokens[token]
+	if ok && (entry.declaration != nil || time.Now().After(entry.expiresAt)) {
+		ok = false
+	}
+	if ok {
+		delete(s.tokens, token) // Tokens from AllocateMiningJobToken are single use
+	}
+	s.tokensMu.Unlock()
+	if !ok {
+		return js.sendDeclareError(msg.RequestID, jdErrInvalidToken, "")
+	}
+
+	known := s.knownTransactions()
+	pending := &pendingDeclaration{user: entry.user, msg: msg, txs: make([][]byte, len(msg.WTXIDList))}
+	for i, wtxid := range msg.WTXIDList {
+		if tx, found := known[wtxid]; found {
+			pending.txs[i] = tx
+		} else {
+			pending.missing = append(pending.missing, uint16(i))
+		}
+	}
+
+	if len(pending.missing) == 0 {
+		return js.completeDeclaration(pending)
+	}
+
+	js.pending[msg.RequestID] = pending
+	ser := v2binary.NewSerializer()
+	payload := ser.SerializeProvideMissingTransactions(&v2binary.ProvideMissingTransactions{
+		RequestID:             msg.RequestID,
+		UnknownTxPositionList: pending.missing,
+	})
+	return js.send(v2binary.MsgTypeProvideMissingTransactions, payload)
+}
+
+// handleMissingTransactions fills in the transactions requested for a pending declaration
+func (js *jdSession) handleMissingTransactions(msg *v2binary.ProvideMissingTransactionsSuccess) error {
+	pending, ok := js.pending[msg.RequestID]
+	if !ok {
+		log.Printf("Job declaration: unexpected ProvideMissingTransactions.Success %d from %s", msg.RequestID, js.remote)
+		return nil
+	}
+	delete(js.pending, msg.RequestID)
+
+	if len(msg.TransactionList) != len(pending.missing) {
+		return js.sendDeclareError(msg.RequestID, jdErrMissingTxMismatch, "wrong number of transactions")
+	}
+	for i, pos := range pending.missing {
+		tx := msg.TransactionList[i]
+		if wtxid(tx) != pending.msg.WTXIDList[pos] {
+			return js.sendDeclareError(msg.RequestID, jdErrMissingTxMismatch, fmt.Sprintf("transaction %d does not match its wtxid", pos))
+		}
+		pending.txs[pos] = tx
+	}
+	return js.completeDeclaration(pending)
+}
+
+// completeDeclaration builds the declared template and runs it through HandleDeclaration
+func (js *jdSession) completeDeclaration(pending *pendingDeclaration) error {
+	s := js.server
+	msg := pending.msg
+	user := pending.user
+
+	poolTemplate, err := s.currentPoolTemplate()
+	if err != nil {
+		return js.sendDeclareError(msg.RequestID, jdErrNoPoolTemplate, err.Error())
+	}
+
+	if script := s.poolScript(); len(script) > 0 && s.GetTemplatePolicy().RequirePoolCoinbase {
+		if !bytes.Contains(msg.CoinbaseSuffix, script) {
+			return js.sendDeclareError(msg.RequestID, jdErrMissingPoolOutput, "coinbase does not pay the pool output")
+		}
+	}
+
+	coinbase := make([]byte, 0, len(msg.CoinbasePrefix)+len(msg.CoinbaseSuffix))
+	coinbase = append(coinbase, msg.CoinbasePrefix...)
+	coinbase = append(coinbase, msg.CoinbaseSuffix...)
+
+	template := &stratum.BlockTemplate{
+		Version:       msg.Version,
+		PrevHash:      poolTemplate.PrevHash,
+		Timestamp:     poolTemplate.Timestamp,
+		Bits:          poolTemplate.Bits,
+		Height:        poolTemplate.Height,
+		Coinbase:      coinbase,
+		CoinbaseValue: poolTemplate.CoinbaseValue,
+		Transactions:  pending.txs,
+		Target:        poolTemplate.Target,
+		Algorithm:     poolTemplate.Algorithm,
+		Coin:          poolTemplate.Coin,
+		MinTime:       poolTemplate.MinTime,
+		MaxTime:       poolTemplate.MaxTime,
+		CreatedAt:     time.Now(),
+	}
+	declaration := &stratum.JobDeclaration{Template: template}
+
+	result, err := s.HandleDeclaration(user, declaration)
+	if err != nil || !result.Accepted {
+		code, details := "TEMPLATE_INVALID", ""
+		if result != nil {
+			code, details = result.ErrorCode, result.ErrorMessage
+		} else if err != nil {
+			details = err.Error()
+		}
+		return js.sendDeclareError(msg.RequestID, wireErrorCode(code), details)
+	}
+
+	newToken := make([]byte, jdTokenSize)
+	rand.Read(newToken)
+	s.tokensMu.Lock()
+	s.tokens[string(newToken)] = &miningJobToken{
+		user:        user,
+		expiresAt:   declaration.ExpiresAt,
+		declaration: declaration,
+	}
+	s.tokensMu.Unlock()
+
+	s.mu.RLock()
+	onDeclared := s.onDeclared
+	s.mu.RUnlock()
+	if onDeclared != nil {
+		onDeclared(declaration, msg)
+	}
+
+	ser := v2binary.NewSerializer()
+	payload := ser.SerializeDeclareMiningJobSuccess(&v2binary.DeclareMiningJobSuccess{
+		RequestID:         msg.RequestID,
+		NewMiningJobToken: newToken,
+	})
+	return js.send(v2binary.MsgTypeDeclareMiningJobSuccess, payload)
+}
+
+// handleSetCustomMiningJob activates a declared job
+func (js *jdSession) handleSetCustomMiningJob(msg *v2binary.SetCustomMiningJob) error {
+	s := js.server
+
+	s.tokensMu.Lock()
+	entry, ok := s.tokens[string(msg.MiningJobToken)]
+	if ok && (entry.declaration == nil || time.Now().After(entry.expiresAt)) {
+		ok = false
+	}
+	s.tokensMu.Unlock()
+	if !ok {
+		return js.sendCustomJobError(msg, jdErrInvalidToken)
+	}
+
+	if !bytes.Equal(msg.PrevHash[:], entry.declaration.Template.PrevHash) {
+		return js.sendCustomJobError(msg, jdErrStaleChainTip)
+	}
+
+	jobID := s.customJobSeq.Add(1)
+
+	s.mu.RLock()
+	onCustomJob := s.onCustomJob
+	s.mu.RUnlock()
+	if onCustomJob != nil {
+		onCustomJob(entry.declaration, msg)
+	}
+
+	ser := v2binary.NewSerializer()
+	payload := ser.SerializeSetCustomMiningJobSuccess(&v2binary.SetCustomMiningJobSuccess{
+		ChannelID: msg.ChannelID,
+		RequestID: msg.RequestID,
+		JobID:     jobID,
+	})
+	return js.send(v2binary.MsgTypeSetCustomMiningJobSuccess, payload)
+}
+
+// sendDeclareError rejects a DeclareMiningJob
+func (js *jdSession) sendDeclareError(requestID uint32, code, details string) error {
+	log.Printf("Job declaration %d from %s rejected: %s %s", requestID, js.remote, code, details)
+
+	ser := v2binary.NewSerializer()
+	payload := ser.SerializeDeclareMiningJobError(&v2binary.DeclareMiningJobError{
+		RequestID:    requestID,
+		ErrorCode:    v2binary.STR0_255(code),
+		ErrorDetails: []byte(details),
+	})
+	return js.send(v2binary.MsgTypeDeclareMiningJobError, payload)
+}
+
+// sendCustomJobError rejects a SetCustomMiningJob
+func (js *jdSession) sendCustomJobError(msg *v2binary.SetCustomMiningJob, code string) error {
+	ser := v2binary.NewSerializer()
+	payload := ser.SerializeSetCustomMiningJobError(&v2binary.SetCustomMiningJobError{
+		ChannelID: msg.ChannelID,
+		RequestID: msg.RequestID,
+		ErrorCode: v2binary.STR0_255(code),
+	})
+	return js.send(v2binary.MsgTypeSetCustomMiningJobError, payload)
+}
+
+// send writes a single frame
+func (js *jdSession) send(msgType uint8, payload []byte) error {
+	frame := v2binary.NewSerializer().SerializeFrame(msgType, 0, payload)
+	_, err := js.conn.Write(frame)
+	return err
+}
+
+// currentPoolTemplate returns the pool's template; declared jobs build on its chain tip
+func (s *jobDeclaratorServer) currentPoolTemplate() (*stratum.BlockTemplate, error) {
+	s.mu.RLock()
+	provider := s.provider
+	s.mu.RUnlock()
+
+	if provider == nil {
+		return nil, errors.New("no template provider configured")
+	}
+	return provider.GetTemplate()
+}
+
+// knownTransactions indexes the pool template's transactions by wtxid
+func (s *jobDeclaratorServer) knownTransactions() map[[32]byte][]byte {
+	template, err := s.currentPoolTemplate()
+	if err != nil {
+		return nil
+	}
+
+	s.knownTxMu.Lock()
+	defer s.knownTxMu.Unlock()
+
+	if s.knownTxTemplateID != template.TemplateID || s.knownTx == nil {
+		s.knownTx = make(map[[32]byte][]byte, len(template.Transactions))
+		for _, tx := range template.Transactions {
+			s.knownTx[wtxid(tx)] = tx
+		}
+		s.knownTxTemplateID = template.TemplateID
+	}
+	return s.knownTx
+}
+
+// poolScript returns the configured pool output script
+func (s *jobDeclaratorServer) poolScript() []byte {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.poolOutputScript
+}
+
+// poolCoinbaseOutputs serializes the pool output (value left to the declarator)
+func (s *jobDeclaratorServer) poolCoinbaseOutputs() []byte {
+	script := s.poolScript()
+	if len(script) == 0 {
+		return nil
+	}
+	out := make([]byte, 8, 8+1+len(script))
+	binary.LittleEndian.PutUint64(out, 0)
+	out = append(out, byte(len(script)))
+	return append(out, script...)
+}
+
+// pruneTokens drops expired mining job tokens
+func (s *jobDeclaratorServer) pruneTokens(now time.Time) {
+	s.tokensMu.Lock()
+	defer s.tokensMu.Unlock()
+	for token, entry := range s.tokens {
+		if now.After(entry.expiresAt) {
+			delete(s.tokens, token)
+		}
+	}
+}
+
+// wtxid hashes a serialized transaction (including witness data)
+func wtxid(tx []byte) [32]byte {
+	first := sha256.Sum256(tx)
+	return sha256.Sum256(first[:])
+}
+
+// wireErrorCode converts an internal error code (e.g. "RATE_LIMIT_EXCEEDED")
+// to the lowercase dashed form used by SV2 error messages
+func wireErrorCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(code, "_", "-"))
+}
@@ -0,0 +1,276 @@
+package v2
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/chimera-pool/chimera-pool-core/internal/stratum"
+	v2binary "github.com/chimera-pool/chimera-pool-core/internal/stratum/v2/binary"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// Job Declaration Protocol Tests
+// =============================================================================
+
+// staticTemplateProvider serves a fixed template
+type staticTemplateProvider struct {
+	template *stratum.BlockTemplate
+}
+
+func (p *staticTemplateProvider) GetTemplate() (*stratum.BlockTemplate, error) {
+	return p.template, nil
+}
+
+func (p *staticTemplateProvider) GetTemplateForHeight(height uint64) (*stratum.BlockTemplate, error) {
+	return p.template, nil
+}
+
+func (p *staticTemplateProvider) SubscribeTemplates(handler func(*stratum.BlockTemplate)) stratum.Subscription {
+	return &templateSubscription{}
+}
+
+func (p *staticTemplateProvider) GetCurrentHeight() (uint64, error) {
+	return p.template.Height, nil
+}
+
+func (p *staticTemplateProvider) GetNetworkDifficulty() (uint64, error) {
+	return 1, nil
+}
+
+var (
+	jdPoolScript = []byte{0x00, 0x14, 0x84, 0x6e, 0x29, 0x2b, 0x56, 0x70, 0x11, 0x6e, 0x21, 0x75,
+		0x63, 0x46, 0x8f, 0x6d, 0xe8, 0x63, 0xfc, 0x79, 0xc8, 0x22}
+	jdKnownTx   = bytes.Repeat([]byte{0x02}, 64)
+	jdUnknownTx = bytes.Repeat([]byte{0x03}, 80)
+)
+
+// jdClient drives the server side of a net.Pipe
+type jdClient struct {
+	t    *testing.T
+	conn net.Conn
+	ser  *v2binary.Serializer
+}
+
+func (c *jdClient) send(msgType uint8, payload []byte) {
+	_, err := c.conn.Write(c.ser.SerializeFrame(msgType, 0, payload))
+	require.NoError(c.t, err)
+}
+
+func (c *jdClient) recv() (uint8, *v2binary.Deserializer) {
+	c.conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	header := make([]byte, v2binary.HeaderSize)
+	_, err := io.ReadFull(c.conn, header)
+	require.NoError(c.t, err)
+	h, err := v2binary.ParseHeader(header)
+	require.NoError(c.t, err)
+	payload := make([]byte, h.MsgLength)
+	_, err = io.ReadFull(c.conn, payload)
+	require.NoError(c.t, err)
+	return h.MsgType, v2binary.NewDeserializer(payload)
+}
+
+func (c *jdClient) setup() {
+	c.send(v2binary.MsgTypeSetupConnection, c.ser.SerializeSetupConnection(&v2binary.SetupConnection{
+		Protocol:   v2binary.ProtocolJobDeclaration,
+		MinVersion: 2,
+		MaxVersion: 2,
+	}))
+	msgType, _ := c.recv()
+	require.Equal(c.t, v2binary.MsgTypeSetupConnectionSuccess, msgType)
+}
+
+func (c *jdClient) allocateToken(user string) []byte {
+	c.send(v2binary.MsgTypeAllocateMiningJobToken, c.ser.SerializeAllocateMiningJobToken(&v2binary.AllocateMiningJobToken{
+		UserIdentifier: v2binary.STR0_255(user),
+		RequestID:      1,
+	}))
+	msgType, d := c.recv()
+	require.Equal(c.t, v2binary.MsgTypeAllocateMiningJobTokenSuccess, msgType)
+	success, err := d.DeserializeAllocateMiningJobTokenSuccess()
+	require.NoError(c.t, err)
+	assert.True(c.t, bytes.Contains(success.CoinbaseOutputs, jdPoolScript))
+	return success.MiningJobToken
+}
+
+func newTestJDServer(t *testing.T) (*jobDeclaratorServer, *jdClient) {
+	config := DefaultJobNegotiationConfig()
+	config.Enabled = true
+	config.ListenAddress = "127.0.0.1:0"
+
+	server := NewJobDeclaratorServer(config)
+	server.SetTemplateProvider(&staticTemplateProvider{template: &stratum.BlockTemplate{
+		TemplateID:   "pool-1",
+		PrevHash:     bytes.Repeat([]byte{0xab}, 32),
+		Bits:         0x1d00ffff,
+		Height:       2500000,
+		Transactions: [][]byte{jdKnownTx},
+	}})
+	server.SetPoolOutputScript(jdPoolScript)
+	require.NoError(t, server.Start())
+	t.Cleanup(func() { server.Stop() })
+
+	clientConn, serverConn := net.Pipe()
+	t.Cleanup(func() { clientConn.Close() })
+	go server.ServeConn(serverConn)
+
+	return server, &jdClient{t: t, conn: clientConn, ser: v2binary.NewSerializer()}
+}
+
+// declaredCoinbase returns a coinbase split whose suffix pays the pool
+func declaredCoinbase() (prefix, suffix []byte) {
+	prefix = append([]byte{0x02, 0x00, 0x00, 0x00, 0x01}, bytes.Repeat([]byte{0x00}, 36)...)
+	suffix = append([]byte{0xff, 0xff, 0xff, 0xff, 0x01}, bytes.Repeat([]byte{0x00}, 8)...)
+	suffix = append(suffix, byte(len(jdPoolScript)))
+	suffix = append(suffix, jdPoolScript...)
+	suffix = append(suffix, bytes.Repeat([]byte{0x00}, 40)...)
+	return prefix, suffix
+}
+
+func TestJobDeclaration_FullFlow(t *testing.T) {
+	server, client := newTestJDServer(t)
+
+	var declared *stratum.JobDeclaration
+	var declaredMsg *v2binary.DeclareMiningJob
+	server.SetDeclarationHandler(func(decl *stratum.JobDeclaration, msg *v2binary.DeclareMiningJob) {
+		declared, declaredMsg = decl, msg
+	})
+	var activated *stratum.JobDeclaration
+	server.SetCustomJobHandler(func(decl *stratum.JobDeclaration, msg *v2binary.SetCustomMiningJob) {
+		activated = decl
+	})
+
+	client.setup()
+	token := client.allocateToken("alice")
+
+	prefix, suffix := declaredCoinbase()
+	declare := &v2binary.DeclareMiningJob{
+		RequestID:      2,
+		MiningJobToken: token,
+		Version:        0x20000000,
+		CoinbasePrefix: prefix,
+		CoinbaseSuffix: suffix,
+		WTXIDList:      [][32]byte{wtxid(jdKnownTx), wtxid(jdUnknownTx)},
+	}
+	client.send(v2binary.MsgTypeDeclareMiningJob, client.ser.SerializeDeclareMiningJob(declare))
+
+	// The pool only knows the first transaction
+	msgType, d := client.recv()
+	require.Equal(t, v2binary.MsgTypeProvideMissingTransactions, msgType)
+	missing, err := d.DeserializeProvideMissingTransactions()
+	require.NoError(t, err)
+	assert.Equal(t, []uint16{1}, missing.UnknownTxPositionList)
+
+	client.send(v2binary.MsgTypeProvideMissingTransactionsSuccess, client.ser.SerializeProvideMissingTransactionsSuccess(
+		&v2binary.ProvideMissingTransactionsSuccess{RequestID: 2, TransactionList: [][]byte{jdUnknownTx}}))
+
+	msgType, d = client.recv()
+	require.Equal(t, v2binary.MsgTypeDeclareMiningJobSuccess, msgType)
+	success, err := d.DeserializeDeclareMiningJobSuccess()
+	require.NoError(t, err)
+	assert.Equal(t, uint32(2), success.RequestID)
+
+	require.NotNil(t, declared)
+	assert.Equal(t, "alice", declared.MinerID)
+	assert.Equal(t, [][]byte{jdKnownTx, jdUnknownTx}, declared.Template.Transactions)
+	assert.Equal(t, uint64(2500000), declared.Template.Height)
+	assert.Equal(t, declare.CoinbasePrefix, declaredMsg.CoinbasePrefix)
+
+	active, ok := server.GetDeclarationByMiner("alice")
+	require.True(t, ok)
+	assert.Equal(t, declared.DeclarationID, active.DeclarationID)
+
+	// Activate the declared job
+	custom := &v2binary.SetCustomMiningJob{ChannelID: 1, RequestID: 3, MiningJobToken: success.NewMiningJobToken}
+	copy(custom.PrevHash[:], bytes.Repeat([]byte{0xab}, 32))
+	client.send(v2binary.MsgTypeSetCustomMiningJob, client.ser.SerializeSetCustomMiningJob(custom))
+
+	msgType, d = client.recv()
+	require.Equal(t, v2binary.MsgTypeSetCustomMiningJobSuccess, msgType)
+	customSuccess, err := d.DeserializeSetCustomMiningJobSuccess()
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1), customSuccess.JobID)
+	assert.Same(t, declared, activated)
+
+	// Allocation tokens are single use
+	declare.RequestID = 4
+	client.send(v2binary.MsgTypeDeclareMiningJob, client.ser.SerializeDeclareMiningJob(declare))
+	msgType, d = client.recv()
+	require.Equal(t, v2binary.MsgTypeDeclareMiningJobError, msgType)
+	declareErr, err := d.DeserializeDeclareMiningJobError()
+	require.NoError(t, err)
+	assert.Equal(t, v2binary.STR0_255(jdErrInvalidToken), declareErr.ErrorCode)
+}
+
+func TestJobDeclaration_MissingPoolOutput(t *testing.T) {
+	_, client := newTestJDServer(t)
+	client.setup()
+	token := client.allocateToken("alice")
+
+	prefix, _ := declaredCoinbase()
+	client.send(v2binary.MsgTypeDeclareMiningJob, client.ser.SerializeDeclareMiningJob(&v2binary.DeclareMiningJob{
+		RequestID:      2,
+		MiningJobToken: token,
+		CoinbasePrefix: prefix,
+		CoinbaseSuffix: bytes.Repeat([]byte{0x00}, 80),
+		WTXIDList:      [][32]byte{wtxid(jdKnownTx)},
+	}))
+
+	msgType, d := client.recv()
+	require.Equal(t, v2binary.MsgTypeDeclareMiningJobError, msgType)
+	declareErr, err := d.DeserializeDeclareMiningJobError()
+	require.NoError(t, err)
+	assert.Equal(t, v2binary.STR0_255(jdErrMissingPoolOutput), declareErr.ErrorCode)
+}
+
+func TestJobDeclaration_SetCustomMiningJobStaleTip(t *testing.T) {
+	_, client := newTestJDServer(t)
+	client.setup()
+	token := client.allocateToken("alice")
+
+	prefix, suffix := declaredCoinbase()
+	client.send(v2binary.MsgTypeDeclareMiningJob, client.ser.SerializeDeclareMiningJob(&v2binary.DeclareMiningJob{
+		RequestID:      2,
+		MiningJobToken: token,
+		CoinbasePrefix: prefix,
+		CoinbaseSuffix: suffix,
+		WTXIDList:      [][32]byte{wtxid(jdKnownTx)},
+	}))
+	msgType, d := client.recv()
+	require.Equal(t, v2binary.MsgTypeDeclareMiningJobSuccess, msgType)
+	success, err := d.DeserializeDeclareMiningJobSuccess()
+	require.NoError(t, err)
+
+	client.send(v2binary.MsgTypeSetCustomMiningJob, client.ser.SerializeSetCustomMiningJob(&v2binary.SetCustomMiningJob{
+		ChannelID:      1,
+		RequestID:      3,
+		MiningJobToken: success.NewMiningJobToken,
+		PrevHash:       [32]byte{0x01},
+	}))
+	msgType, d = client.recv()
+	require.Equal(t, v2binary.MsgTypeSetCustomMiningJobError, msgType)
+	customErr, err := d.DeserializeSetCustomMiningJobError()
+	require.NoError(t, err)
+	assert.Equal(t, v2binary.STR0_255(jdErrStaleChainTip), customErr.ErrorCode)
+}
+
+func TestJobDeclaration_WrongProtocol(t *testing.T) {
+	_, client := newTestJDServer(t)
+
+	client.send(v2binary.MsgTypeSetupConnection, client.ser.SerializeSetupConnection(&v2binary.SetupConnection{
+		Protocol:   v2binary.ProtocolMining,
+		MinVersion: 2,
+		MaxVersion: 2,
+	}))
+	msgType, _ := client.recv()
+	assert.Equal(t, v2binary.MsgTypeSetupConnectionError, msgType)
+}
+
+func TestWireErrorCode(t *testing.T) {
+	assert.Equal(t, "rate-limit-exceeded", wireErrorCode("RATE_LIMIT_EXCEEDED"))
+	assert.Equal(t, "invalid-prev-hash", wireErrorCode("INVALID_PREV_HASH"))
+}
@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/chimera-pool/chimera-pool-core/internal/stratum"
+	v2binary "github.com/chimera-pool/chimera-pool-core/internal/stratum/v2/binary"
 )
 
 // =============================================================================
@@ -54,6 +55,18 @@ type jobDeclaratorServer struct {
 	rateLimiter map[string]*rateLimitEntry
 	rateMu      sync.Mutex
 
+	// Job Declaration protocol
+	tokens            map[string]*miningJobToken
+	tokensMu          sync.Mutex
+	poolOutputScript  []byte
+	onDeclared        func(*stratum.JobDeclaration, *v2binary.DeclareMiningJob)
+	onCustomJob       func(*stratum.JobDeclaration, *v2binary.SetCustomMiningJob)
+	wrapConn          func(net.Conn) (net.Conn, error)
+	customJobSeq      atomic.Uint32
+	knownTx           map[[32]byte][]byte
+	knownTxTemplateID string
+	knownTxMu         sync.Mutex
+
 	// Lifecycle
 	listener  net.Listener
 	ctx       context.Context
@@ -84,6 +97,7 @@ func NewJobDeclaratorServer(config stratum.JobNegotiationConfig) *jobDeclaratorS
 		declarations:     make(map[string]*stratum.JobDeclaration),
 		declarationsByID: make(map[string]*stratum.JobDeclaration),
 		rateLimiter:      make(map[string]*rateLimitEntry),
+		tokens:           make(map[string]*miningJobToken),
 		ctx:              ctx,
 		cancel:           cancel,
 	}
@@ -338,25 +352,18 @@ func (s *jobDeclaratorServer) handleConnection(conn net.Conn) {
 	defer s.wg.Done()
 	defer conn.Close()
 
-	// Connection handling will be implemented with V2 binary protocol
-	// For now, this is a placeholder for the connection loop
-	for {
+	// Unblock the read loop when the server stops
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
 		select {
 		case <-s.ctx.Done():
-			return
-		default:
-			// Read and process messages
-			conn.SetReadDeadline(time.Now().Add(30 * time.Second))
-			buf := make([]byte, 4096)
-			n, err := conn.Read(buf)
-			if err != nil {
-				return
-			}
-
-			// Process declaration message (to be implemented with binary protocol)
-			_ = n
+			conn.Close()
+		case <-done:
 		}
-	}
+	}()
+
+	s.ServeConn(conn)
 }
 
 func (s *jobDeclaratorServer) cleanupExpiredDeclarations() {
@@ -379,6 +386,7 @@ func (s *jobDeclaratorServer) cleanupExpiredDeclarations() {
 				}
 			}
 			s.mu.Unlock()
+			s.pruneTokens(now)
 		}
 	}
 }
@@ -0,0 +1,140 @@
+package noise
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// =============================================================================
+// SV2 SIGNATURE NOISE MESSAGE (CERTIFICATE)
+// The responder proves its static key belongs to the pool by sending a
+// certificate signed with the pool's long-lived secp256k1 authority key
+// (BIP340 Schnorr, as the SV2 spec requires). Miners are configured with the
+// x-only authority public key out of band.
+// =============================================================================
+
+// Certificate constants
+const (
+	CertificateVersion = 0
+	SignatureSize      = SchnorrSignatureSize
+	// CertificateSize is version(2) + valid_from(4) + not_valid_after(4) + signature(64)
+	CertificateSize = 2 + 4 + 4 + SignatureSize
+)
+
+// Certificate errors
+var (
+	ErrInvalidCertificate = errors.New("invalid certificate")
+	ErrCertificateExpired = errors.New("certificate is not valid at this time")
+	ErrBadSignature       = errors.New("certificate signature does not match authority key")
+)
+
+// Certificate is the SignatureNoiseMessage sent in the responder's handshake payload
+type Certificate struct {
+	Version       uint16
+	ValidFrom     uint32 // Unix seconds
+	NotValidAfter uint32 // Unix seconds
+	Signature     [SignatureSize]byte
+}
+
+// NewCertificate signs a server static key with the authority key for the given window
+func NewCertificate(authority *AuthorityKey, staticPublic [DHKeySize]byte, validFrom time.Time, validity time.Duration) (*Certificate, error) {
+	cert := &Certificate{
+		Version:       CertificateVersion,
+		ValidFrom:     uint32(validFrom.Unix()),
+		NotValidAfter: uint32(validFrom.Add(validity).Unix()),
+	}
+	sig, err := authority.Sign(cert.signedMessage(staticPublic))
+	if err != nil {
+		return nil, fmt.Errorf("sign certificate: %w", err)
+	}
+	cert.Signature = sig
+	return cert, nil
+}
+
+// signedMessage is the data covered by the signature: SHA-256 of the
+// certificate fields followed by the static key being certified
+func (c *Certificate) signedMessage(staticPublic [DHKeySize]byte) []byte {
+	msg := make([]byte, 10+DHKeySize)
+	binary.LittleEndian.PutUint16(msg[0:2], c.Version)
+	binary.LittleEndian.PutUint32(msg[2:6], c.ValidFrom)
+	binary.LittleEndian.PutUint32(msg[6:10], c.NotValidAfter)
+	copy(msg[10:], staticPublic[:])
+	hash := sha256.Sum256(msg)
+	return hash[:]
+}
+
+// Serialize encodes the certificate in wire format
+func (c *Certificate) Serialize() []byte {
+	buf := make([]byte, CertificateSize)
+	binary.LittleEndian.PutUint16(buf[0:2], c.Version)
+	binary.LittleEndian.PutUint32(buf[2:6], c.ValidFrom)
+	binary.LittleEndian.PutUint32(buf[6:10], c.NotValidAfter)
+	copy(buf[10:], c.Signature[:])
+	return buf
+}
+
+// ParseCertificate decodes a certificate from wire format
+func ParseCertificate(data []byte) (*Certificate, error) {
+	if len(data) != CertificateSize {
+		return nil, ErrInvalidCertificate
+	}
+	cert := &Certificate{
+		Version:       binary.LittleEndian.Uint16(data[0:2]),
+		ValidFrom:     binary.LittleEndian.Uint32(data[2:6]),
+		NotValidAfter: binary.LittleEndian.Uint32(data[6:10]),
+	}
+	copy(cert.Signature[:], data[10:])
+	return cert, nil
+}
+
+// Verify checks the certificate covers staticPublic, was signed by the
+// authority and is valid at the given time
+func (c *Certificate) Verify(authority AuthorityPublicKey, staticPublic [DHKeySize]byte, now time.Time) error {
+	if c.Version != CertificateVersion {
+		return ErrInvalidCertificate
+	}
+	unix := now.Unix()
+	if unix < int64(c.ValidFrom) || unix > int64(c.NotValidAfter) {
+		return ErrCertificateExpired
+	}
+	if !authority.Verify(c.signedMessage(staticPublic), c.Signature) {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+// LoadOrCreateAuthorityKey reads a hex-encoded 32-byte secp256k1 secret from
+// path. If the file does not exist a new key is generated and written with
+// 0600 permissions, so the authority key stays stable across restarts.
+func LoadOrCreateAuthorityKey(path string) (*AuthorityKey, error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		secret, err := hex.DecodeString(strings.TrimSpace(string(data)))
+		if err != nil {
+			return nil, fmt.Errorf("authority key %s: expected 32 hex-encoded bytes", path)
+		}
+		key, err := NewAuthorityKey(secret)
+		if err != nil {
+			return nil, fmt.Errorf("authority key %s: %w", path, err)
+		}
+		return key, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("read authority key: %w", err)
+	}
+
+	key, err := GenerateAuthorityKey()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, []byte(hex.EncodeToString(key.Secret())+"\n"), 0600); err != nil {
+		return nil, fmt.Errorf("write authority key: %w", err)
+	}
+	return key, nil
+}
@@ -0,0 +1,68 @@
+package noise
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCertificate_SignAndVerify(t *testing.T) {
+	authority, err := GenerateAuthorityKey()
+	require.NoError(t, err)
+	static, err := GenerateKeyPair()
+	require.NoError(t, err)
+
+	now := time.Now()
+	cert, err := NewCertificate(authority, static.PublicKey, now, time.Hour)
+	require.NoError(t, err)
+
+	serialized := cert.Serialize()
+	require.Len(t, serialized, 74, "SignatureNoiseMessage is version, valid_from, not_valid_after and a 64-byte Schnorr signature")
+	parsed, err := ParseCertificate(serialized)
+	require.NoError(t, err)
+	assert.Equal(t, cert, parsed)
+
+	pub := authority.PublicKey()
+	assert.NoError(t, parsed.Verify(pub, static.PublicKey, now.Add(time.Minute)))
+	assert.Equal(t, ErrCertificateExpired, parsed.Verify(pub, static.PublicKey, now.Add(2*time.Hour)))
+
+	other, err := GenerateKeyPair()
+	require.NoError(t, err)
+	assert.Equal(t, ErrBadSignature, parsed.Verify(pub, other.PublicKey, now), "certificate is bound to the static key")
+
+	otherAuthority, err := GenerateAuthorityKey()
+	require.NoError(t, err)
+	assert.Equal(t, ErrBadSignature, parsed.Verify(otherAuthority.PublicKey(), static.PublicKey, now))
+}
+
+func TestParseCertificate_WrongSize(t *testing.T) {
+	_, err := ParseCertificate(make([]byte, CertificateSize-1))
+	assert.Equal(t, ErrInvalidCertificate, err)
+}
+
+func TestLoadOrCreateAuthorityKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "authority.key")
+
+	created, err := LoadOrCreateAuthorityKey(path)
+	require.NoError(t, err)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	loaded, err := LoadOrCreateAuthorityKey(path)
+	require.NoError(t, err)
+	assert.Equal(t, created.PublicKey(), loaded.PublicKey(), "key is stable across restarts")
+}
+
+func TestLoadOrCreateAuthorityKey_Malformed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "authority.key")
+	require.NoError(t, os.WriteFile(path, []byte("not-hex"), 0600))
+
+	_, err := LoadOrCreateAuthorityKey(path)
+	assert.Error(t, err)
+}
@@ -0,0 +1,220 @@
+package noise
+
+import (
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// =============================================================================
+// NOISE TRANSPORT FOR SV2 FRAMES
+// After the NX handshake every SV2 frame is sent as an encrypted header
+// followed by the payload encrypted in chunks of at most MaxMessageSize bytes.
+// =============================================================================
+
+// Transport constants
+const (
+	// MaxMessageSize is the largest Noise message (ciphertext including tag)
+	MaxMessageSize = 65535
+	// maxChunkPlaintext is the largest payload chunk that fits one Noise message
+	maxChunkPlaintext = MaxMessageSize - TagSize
+
+	// frameHeaderSize is the SV2 frame header: extension_type(2) msg_type(1) msg_length(3)
+	frameHeaderSize = 6
+
+	// InitiatorHandshakeSize is the initiator's "-> e" message (no payload)
+	InitiatorHandshakeSize = DHKeySize
+	// ResponderHandshakeSize is "<- e, ee, s, es" carrying the certificate
+	ResponderHandshakeSize = DHKeySize + DHKeySize + TagSize + CertificateSize + TagSize
+)
+
+// ServerConfig holds the pool-side keys used to answer handshakes
+type ServerConfig struct {
+	StaticKey    *KeyPair      // Noise static key, certified per handshake
+	AuthorityKey *AuthorityKey // Long-lived secp256k1 key miners pin
+	CertValidity time.Duration // Validity window of issued certificates
+}
+
+// ServerHandshake performs the responder side of the NX handshake on conn
+// and returns a connection that encrypts and decrypts SV2 frames
+func ServerHandshake(conn net.Conn, cfg *ServerConfig) (*Conn, error) {
+	hs, err := NewResponderHandshake(cfg.StaticKey)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := make([]byte, InitiatorHandshakeSize)
+	if _, err := io.ReadFull(conn, msg); err != nil {
+		return nil, err
+	}
+	if _, err := hs.ReadMessage(msg); err != nil {
+		return nil, err
+	}
+
+	cert, err := NewCertificate(cfg.AuthorityKey, cfg.StaticKey.PublicKey, time.Now(), cfg.CertValidity)
+	if err != nil {
+		return nil, err
+	}
+	reply, err := hs.WriteMessage(cert.Serialize())
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.Write(reply); err != nil {
+		return nil, err
+	}
+
+	send, recv, err := hs.Split()
+	if err != nil {
+		return nil, err
+	}
+	return NewConn(conn, NewSecureChannel(send, recv)), nil
+}
+
+// ClientHandshake performs the initiator side of the NX handshake and
+// verifies the server's certificate against the pinned authority key
+func ClientHandshake(conn net.Conn, authority AuthorityPublicKey) (*Conn, error) {
+	hs, err := NewInitiatorHandshake()
+	if err != nil {
+		return nil, err
+	}
+
+	msg, err := hs.WriteMessage(nil)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.Write(msg); err != nil {
+		return nil, err
+	}
+
+	reply := make([]byte, ResponderHandshakeSize)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return nil, err
+	}
+	payload, err := hs.ReadMessage(reply)
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err := ParseCertificate(payload)
+	if err != nil {
+		return nil, err
+	}
+	if err := cert.Verify(authority, hs.GetRemoteStatic(), time.Now()); err != nil {
+		return nil, err
+	}
+
+	send, recv, err := hs.Split()
+	if err != nil {
+		return nil, err
+	}
+	return NewConn(conn, NewSecureChannel(send, recv)), nil
+}
+
+// Conn wraps a net.Conn so plaintext SV2 frames written to it are encrypted
+// and frames read from it are decrypted. Each Write must contain whole frames.
+type Conn struct {
+	net.Conn
+	channel *SecureChannel
+	readBuf []byte
+	readMu  sync.Mutex
+	writeMu sync.Mutex
+}
+
+// NewConn wraps conn with an established secure channel
+func NewConn(conn net.Conn, channel *SecureChannel) *Conn {
+	return &Conn{Conn: conn, channel: channel}
+}
+
+// Read returns decrypted frame bytes
+func (c *Conn) Read(b []byte) (int, error) {
+	c.readMu.Lock()
+	defer c.readMu.Unlock()
+
+	if len(c.readBuf) == 0 {
+		frame, err := c.readFrame()
+		if err != nil {
+			return 0, err
+		}
+		c.readBuf = frame
+	}
+	n := copy(b, c.readBuf)
+	c.readBuf = c.readBuf[n:]
+	return n, nil
+}
+
+// readFrame reads and decrypts one frame: header, then payload chunks
+func (c *Conn) readFrame() ([]byte, error) {
+	encHeader := make([]byte, frameHeaderSize+TagSize)
+	if _, err := io.ReadFull(c.Conn, encHeader); err != nil {
+		return nil, err
+	}
+	header, err := c.channel.Decrypt(encHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	length := int(header[3]) | int(header[4])<<8 | int(header[5])<<16
+	frame := make([]byte, 0, frameHeaderSize+length)
+	frame = append(frame, header...)
+
+	for remaining := length; remaining > 0; {
+		chunk := remaining
+		if chunk > maxChunkPlaintext {
+			chunk = maxChunkPlaintext
+		}
+		enc := make([]byte, chunk+TagSize)
+		if _, err := io.ReadFull(c.Conn, enc); err != nil {
+			return nil, err
+		}
+		plain, err := c.channel.Decrypt(enc)
+		if err != nil {
+			return nil, err
+		}
+		frame = append(frame, plain...)
+		remaining -= chunk
+	}
+	return frame, nil
+}
+
+// Write encrypts and sends one or more complete SV2 frames
+func (c *Conn) Write(b []byte) (int, error) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	written := 0
+	for len(b) > 0 {
+		if len(b) < frameHeaderSize {
+			return written, ErrInvalidMessage
+		}
+		length := int(b[3]) | int(b[4])<<8 | int(b[5])<<16
+		if len(b) < frameHeaderSize+length {
+			return written, ErrInvalidMessage
+		}
+
+		out, err := c.channel.Encrypt(b[:frameHeaderSize])
+		if err != nil {
+			return written, err
+		}
+		payload := b[frameHeaderSize : frameHeaderSize+length]
+		for len(payload) > 0 {
+			chunk := payload
+			if len(chunk) > maxChunkPlaintext {
+				chunk = chunk[:maxChunkPlaintext]
+			}
+			enc, err := c.channel.Encrypt(chunk)
+			if err != nil {
+				return written, err
+			}
+			out = append(out, enc...)
+			payload = payload[len(chunk):]
+		}
+
+		if _, err := c.Conn.Write(out); err != nil {
+			return written, err
+		}
+		written += frameHeaderSize + length
+		b = b[frameHeaderSize+length:]
+	}
+	return written, nil
+}
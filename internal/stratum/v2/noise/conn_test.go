@@ -0,0 +1,113 @@
+package noise
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testFrame builds a plaintext SV2 frame with the given payload
+func testFrame(msgType byte, payload []byte) []byte {
+	frame := []byte{0x00, 0x00, msgType, byte(len(payload)), byte(len(payload) >> 8), byte(len(payload) >> 16)}
+	return append(frame, payload...)
+}
+
+// handshakePair connects a client and server over net.Pipe
+func handshakePair(t *testing.T) (client, server *Conn, authority *AuthorityKey) {
+	authority, err := GenerateAuthorityKey()
+	require.NoError(t, err)
+	static, err := GenerateKeyPair()
+	require.NoError(t, err)
+
+	c, s := net.Pipe()
+	t.Cleanup(func() { c.Close(); s.Close() })
+
+	serverErr := make(chan error, 1)
+	go func() {
+		var err error
+		server, err = ServerHandshake(s, &ServerConfig{StaticKey: static, AuthorityKey: authority, CertValidity: time.Hour})
+		serverErr <- err
+	}()
+
+	client, err = ClientHandshake(c, authority.PublicKey())
+	require.NoError(t, err)
+	require.NoError(t, <-serverErr)
+	return client, server, authority
+}
+
+func TestHandshake_ClientServer(t *testing.T) {
+	client, server, _ := handshakePair(t)
+
+	frame := testFrame(0x00, []byte("setup-connection"))
+	go client.Write(frame)
+
+	got := make([]byte, len(frame))
+	_, err := io.ReadFull(server, got)
+	require.NoError(t, err)
+	assert.Equal(t, frame, got)
+
+	// And back the other way
+	reply := testFrame(0x01, []byte{0x02, 0x00, 0x00, 0x00, 0x00, 0x00})
+	go server.Write(reply)
+
+	got = make([]byte, len(reply))
+	_, err = io.ReadFull(client, got)
+	require.NoError(t, err)
+	assert.Equal(t, reply, got)
+}
+
+func TestHandshake_WrongAuthorityRejected(t *testing.T) {
+	authority, err := GenerateAuthorityKey()
+	require.NoError(t, err)
+	static, err := GenerateKeyPair()
+	require.NoError(t, err)
+	pinned, err := GenerateAuthorityKey()
+	require.NoError(t, err)
+
+	c, s := net.Pipe()
+	defer c.Close()
+	defer s.Close()
+
+	go ServerHandshake(s, &ServerConfig{StaticKey: static, AuthorityKey: authority, CertValidity: time.Hour})
+
+	_, err = ClientHandshake(c, pinned.PublicKey())
+	assert.Equal(t, ErrBadSignature, err)
+}
+
+func TestConn_LargeFrameIsChunked(t *testing.T) {
+	client, server, _ := handshakePair(t)
+
+	payload := bytes.Repeat([]byte{0xab}, maxChunkPlaintext+100)
+	frame := testFrame(0x21, payload)
+	go client.Write(frame)
+
+	got := make([]byte, len(frame))
+	_, err := io.ReadFull(server, got)
+	require.NoError(t, err)
+	assert.Equal(t, frame, got)
+}
+
+func TestConn_MultipleFramesInOneWrite(t *testing.T) {
+	client, server, _ := handshakePair(t)
+
+	frames := append(testFrame(0x20, []byte{1, 2, 3}), testFrame(0x22, nil)...)
+	go client.Write(frames)
+
+	got := make([]byte, len(frames))
+	_, err := io.ReadFull(server, got)
+	require.NoError(t, err)
+	assert.Equal(t, frames, got)
+}
+
+func TestConn_PartialFrameRejected(t *testing.T) {
+	client, _, _ := handshakePair(t)
+
+	frame := testFrame(0x20, []byte{1, 2, 3})
+	_, err := client.Write(frame[:len(frame)-1])
+	assert.Equal(t, ErrInvalidMessage, err)
+}
@@ -13,7 +13,15 @@ import (
 
 // =============================================================================
 // STRATUM V2 NOISE PROTOCOL IMPLEMENTATION
-// Implements Noise_NX_25519_ChaChaPoly_SHA256 for secure mining communication
+// Implements Noise_NX_25519_ChaChaPoly_SHA256 for secure mining communication.
+//
+// This is NOT the handshake the SV2 spec defines (Noise_NX_Secp256k1+EllSwift
+// _ChaChaPoly_SHA256): ephemeral and static keys are X25519, so only peers
+// built from this package (our own proxies, the Template Distribution client
+// and tests) can connect. Stock SV2 miners and proxies cannot. The
+// certificate in the responder payload does follow the spec's
+// SignatureNoiseMessage format: version, valid_from, not_valid_after and a
+// BIP340 Schnorr signature by the secp256k1 authority key.
 // =============================================================================
 
 // Protocol constants
@@ -0,0 +1,265 @@
+package noise
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+)
+
+// =============================================================================
+// BIP340 SCHNORR SIGNATURES OVER SECP256K1
+// SV2 certificates are signed by the pool's authority key with a BIP340
+// Schnorr signature. Keys are 32-byte x-only secp256k1 public keys.
+// Signing only happens once per handshake, so this uses math/big rather than
+// a constant-time field implementation.
+// =============================================================================
+
+// Schnorr constants
+const (
+	SchnorrPublicKeySize = 32
+	SchnorrSignatureSize = 64
+)
+
+// Schnorr errors
+var (
+	ErrInvalidAuthorityKey = errors.New("invalid authority key")
+)
+
+// secp256k1 domain parameters
+var (
+	secpP, _  = new(big.Int).SetString("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEFFFFFC2F", 16)
+	secpN, _  = new(big.Int).SetString("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364141", 16)
+	secpGx, _ = new(big.Int).SetString("79BE667EF9DCBBAC55A06295CE870B07029BFCDB2DCE28D959F2815B16F81798", 16)
+	secpGy, _ = new(big.Int).SetString("483ADA7726A3C4655DA4FBFC0E1108A8FD17B448A68554199C47D08FFB10D4B8", 16)
+	secpG     = &secpPoint{x: secpGx, y: secpGy}
+	// secpSqrtExp is (p+1)/4, the square root exponent since p = 3 mod 4
+	secpSqrtExp = new(big.Int).Rsh(new(big.Int).Add(secpP, big.NewInt(1)), 2)
+)
+
+// secpPoint is an affine curve point; nil is the point at infinity
+type secpPoint struct {
+	x, y *big.Int
+}
+
+func secpAdd(a, b *secpPoint) *secpPoint {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+
+	var lambda *big.Int
+	if a.x.Cmp(b.x) == 0 {
+		sum := new(big.Int).Add(a.y, b.y)
+		if sum.Mod(sum, secpP).Sign() == 0 {
+			return nil
+		}
+		// Tangent: 3x^2 / 2y
+		num := new(big.Int).Mul(a.x, a.x)
+		num.Mul(num, big.NewInt(3))
+		den := new(big.Int).Lsh(a.y, 1)
+		lambda = num.Mul(num, den.ModInverse(den, secpP))
+	} else {
+		num := new(big.Int).Sub(b.y, a.y)
+		den := new(big.Int).Sub(b.x, a.x)
+		den.Mod(den, secpP)
+		lambda = num.Mul(num, den.ModInverse(den, secpP))
+	}
+	lambda.Mod(lambda, secpP)
+
+	x := new(big.Int).Mul(lambda, lambda)
+	x.Sub(x, a.x).Sub(x, b.x).Mod(x, secpP)
+	y := new(big.Int).Sub(a.x, x)
+	y.Mul(y, lambda).Sub(y, a.y).Mod(y, secpP)
+	return &secpPoint{x: x, y: y}
+}
+
+func secpMul(p *secpPoint, k *big.Int) *secpPoint {
+	var result *secpPoint
+	for i := k.BitLen() - 1; i >= 0; i-- {
+		result = secpAdd(result, result)
+		if k.Bit(i) == 1 {
+			result = secpAdd(result, p)
+		}
+	}
+	return result
+}
+
+// liftX returns the point with the given x coordinate and an even y
+func liftX(x *big.Int) *secpPoint {
+	if x.Cmp(secpP) >= 0 {
+		return nil
+	}
+	c := new(big.Int).Exp(x, big.NewInt(3), secpP)
+	c.Add(c, big.NewInt(7)).Mod(c, secpP)
+	y := new(big.Int).Exp(c, secpSqrtExp, secpP)
+	if new(big.Int).Exp(y, big.NewInt(2), secpP).Cmp(c) != 0 {
+		return nil
+	}
+	if y.Bit(0) == 1 {
+		y.Sub(secpP, y)
+	}
+	return &secpPoint{x: new(big.Int).Set(x), y: y}
+}
+
+// bytes32 encodes n as 32 big-endian bytes
+func bytes32(n *big.Int) []byte {
+	buf := make([]byte, 32)
+	return n.FillBytes(buf)
+}
+
+// taggedHash is BIP340's SHA256(SHA256(tag) || SHA256(tag) || data...)
+func taggedHash(tag string, data ...[]byte) []byte {
+	tagHash := sha256.Sum256([]byte(tag))
+	h := sha256.New()
+	h.Write(tagHash[:])
+	h.Write(tagHash[:])
+	for _, d := range data {
+		h.Write(d)
+	}
+	return h.Sum(nil)
+}
+
+// AuthorityPublicKey is the x-only secp256k1 key miners pin
+type AuthorityPublicKey [SchnorrPublicKeySize]byte
+
+// ParseAuthorityPublicKey decodes a hex-encoded x-only public key
+func ParseAuthorityPublicKey(s string) (AuthorityPublicKey, error) {
+	var pub AuthorityPublicKey
+	raw, err := hex.DecodeString(s)
+	if err != nil || len(raw) != SchnorrPublicKeySize {
+		return pub, ErrInvalidAuthorityKey
+	}
+	copy(pub[:], raw)
+	if liftX(new(big.Int).SetBytes(raw)) == nil {
+		return pub, ErrInvalidAuthorityKey
+	}
+	return pub, nil
+}
+
+// String returns the hex encoding of the key
+func (pub AuthorityPublicKey) String() string {
+	return hex.EncodeToString(pub[:])
+}
+
+// Verify checks a BIP340 signature of msg by this key
+func (pub AuthorityPublicKey) Verify(msg []byte, sig [SchnorrSignatureSize]byte) bool {
+	p := liftX(new(big.Int).SetBytes(pub[:]))
+	if p == nil {
+		return false
+	}
+	r := new(big.Int).SetBytes(sig[:32])
+	s := new(big.Int).SetBytes(sig[32:])
+	if r.Cmp(secpP) >= 0 || s.Cmp(secpN) >= 0 {
+		return false
+	}
+
+	e := new(big.Int).SetBytes(taggedHash("BIP0340/challenge", sig[:32], pub[:], msg))
+	e.Mod(e, secpN)
+	// R = sG - eP
+	negE := new(big.Int).Sub(secpN, e)
+	point := secpAdd(secpMul(secpG, s), secpMul(p, negE))
+	if point == nil || point.y.Bit(0) == 1 {
+		return false
+	}
+	return point.x.Cmp(r) == 0
+}
+
+// AuthorityKey is the pool's long-lived secp256k1 signing key
+type AuthorityKey struct {
+	secret *big.Int
+	public AuthorityPublicKey
+}
+
+// NewAuthorityKey creates a key from a 32-byte secret
+func NewAuthorityKey(secret []byte) (*AuthorityKey, error) {
+	if len(secret) != 32 {
+		return nil, ErrInvalidAuthorityKey
+	}
+	d := new(big.Int).SetBytes(secret)
+	if d.Sign() == 0 || d.Cmp(secpN) >= 0 {
+		return nil, ErrInvalidAuthorityKey
+	}
+	key := &AuthorityKey{secret: d}
+	copy(key.public[:], bytes32(secpMul(secpG, d).x))
+	return key, nil
+}
+
+// GenerateAuthorityKey creates a random authority key
+func GenerateAuthorityKey() (*AuthorityKey, error) {
+	secret := make([]byte, 32)
+	for {
+		if _, err := io.ReadFull(rand.Reader, secret); err != nil {
+			return nil, err
+		}
+		if key, err := NewAuthorityKey(secret); err == nil {
+			return key, nil
+		}
+	}
+}
+
+// Secret returns the 32-byte secret
+func (k *AuthorityKey) Secret() []byte {
+	return bytes32(k.secret)
+}
+
+// PublicKey returns the x-only public key
+func (k *AuthorityKey) PublicKey() AuthorityPublicKey {
+	return k.public
+}
+
+// Sign creates a BIP340 signature of msg with fresh auxiliary randomness
+func (k *AuthorityKey) Sign(msg []byte) ([SchnorrSignatureSize]byte, error) {
+	aux := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, aux); err != nil {
+		return [SchnorrSignatureSize]byte{}, err
+	}
+	return k.sign(msg, aux)
+}
+
+// sign implements BIP340 signing with the given auxiliary data
+func (k *AuthorityKey) sign(msg, aux []byte) ([SchnorrSignatureSize]byte, error) {
+	var sig [SchnorrSignatureSize]byte
+
+	p := secpMul(secpG, k.secret)
+	d := new(big.Int).Set(k.secret)
+	if p.y.Bit(0) == 1 {
+		d.Sub(secpN, d)
+	}
+
+	t := bytes32(d)
+	auxHash := taggedHash("BIP0340/aux", aux)
+	for i := range t {
+		t[i] ^= auxHash[i]
+	}
+	pub := bytes32(p.x)
+
+	kPrime := new(big.Int).SetBytes(taggedHash("BIP0340/nonce", t, pub, msg))
+	kPrime.Mod(kPrime, secpN)
+	if kPrime.Sign() == 0 {
+		return sig, fmt.Errorf("schnorr nonce is zero")
+	}
+	r := secpMul(secpG, kPrime)
+	nonce := kPrime
+	if r.y.Bit(0) == 1 {
+		nonce = new(big.Int).Sub(secpN, kPrime)
+	}
+	rx := bytes32(r.x)
+
+	e := new(big.Int).SetBytes(taggedHash("BIP0340/challenge", rx, pub, msg))
+	e.Mod(e, secpN)
+	s := e.Mul(e, d)
+	s.Add(s, nonce).Mod(s, secpN)
+
+	copy(sig[:32], rx)
+	copy(sig[32:], bytes32(s))
+	if !k.public.Verify(msg, sig) {
+		return sig, fmt.Errorf("schnorr signature failed verification")
+	}
+	return sig, nil
+}
@@ -0,0 +1,61 @@
+package noise
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchnorr_BIP340Vector(t *testing.T) {
+	// BIP340 test vector 0
+	secret, _ := hex.DecodeString("0000000000000000000000000000000000000000000000000000000000000003")
+	key, err := NewAuthorityKey(secret)
+	require.NoError(t, err)
+	assert.Equal(t, "f9308a019258c31049344f85f89d5229b531c845836f99b08601f113bce036f9", key.PublicKey().String())
+
+	msg := make([]byte, 32)
+	sig, err := key.sign(msg, make([]byte, 32))
+	require.NoError(t, err)
+	assert.Equal(t, "e907831f80848d1069a5371b402410364bdf1c5f8307b0084c55f1ce2dca821525f66a4a85ea8b71e482a74f382d2ce5ebeee8fdb2172f477df4900d310536c0",
+		hex.EncodeToString(sig[:]))
+	assert.True(t, key.PublicKey().Verify(msg, sig))
+}
+
+func TestSchnorr_RejectsTampering(t *testing.T) {
+	key, err := GenerateAuthorityKey()
+	require.NoError(t, err)
+	msg := []byte("certificate hash placeholder....")
+
+	sig, err := key.Sign(msg)
+	require.NoError(t, err)
+	assert.True(t, key.PublicKey().Verify(msg, sig))
+
+	tampered := sig
+	tampered[63] ^= 0x01
+	assert.False(t, key.PublicKey().Verify(msg, tampered))
+	assert.False(t, key.PublicKey().Verify([]byte("another message................."), sig))
+}
+
+func TestParseAuthorityPublicKey(t *testing.T) {
+	key, err := GenerateAuthorityKey()
+	require.NoError(t, err)
+
+	parsed, err := ParseAuthorityPublicKey(key.PublicKey().String())
+	require.NoError(t, err)
+	assert.Equal(t, key.PublicKey(), parsed)
+
+	_, err = ParseAuthorityPublicKey("abcd")
+	assert.Equal(t, ErrInvalidAuthorityKey, err)
+	// x = 5 is not on the curve
+	_, err = ParseAuthorityPublicKey("0000000000000000000000000000000000000000000000000000000000000005")
+	assert.Equal(t, ErrInvalidAuthorityKey, err)
+}
+
+func TestNewAuthorityKey_OutOfRange(t *testing.T) {
+	_, err := NewAuthorityKey(make([]byte, 32))
+	assert.Equal(t, ErrInvalidAuthorityKey, err)
+	_, err = NewAuthorityKey(bytes32(secpN))
+	assert.Equal(t, ErrInvalidAuthorityKey, err)
+}
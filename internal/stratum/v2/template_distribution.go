@@ -0,0 +1,619 @@
+package v2
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/chimera-pool/chimera-pool-core/internal/stratum"
+	"github.com/chimera-pool/chimera-pool-core/internal/stratum/blockdag"
+	v2binary "github.com/chimera-pool/chimera-pool-core/internal/stratum/v2/binary"
+	"github.com/chimera-pool/chimera-pool-core/internal/stratum/v2/noise"
+)
+
+// =============================================================================
+// TEMPLATE DISTRIBUTION PROTOCOL CLIENT (SV2 sub-protocol 2)
+// Consumes templates pushed by a node-side Template Provider instead of
+// polling getblocktemplate. NewTemplate and SetNewPrevHash are combined with
+// the template's transactions into a stratum.BlockTemplate; solved blocks are
+// handed back with SubmitSolution.
+// =============================================================================
+
+// Template Distribution protocol settings
+const (
+	tdpProtocolVersion = 2
+)
+
+// Errors
+var (
+	ErrTDPNotConnected    = errors.New("template provider not connected")
+	ErrTDPSetupRejected   = errors.New("template provider rejected SetupConnection")
+	ErrTDPUnknownTemplate = errors.New("unknown template ID")
+)
+
+// TDPConfig configures the connection to a Template Provider
+type TDPConfig struct {
+	Address                         string                    // host:port of the Template Provider
+	AuthorityKey                    *noise.AuthorityPublicKey // Pinned TP authority key; nil connects without Noise
+	CoinbaseOutputMaxAdditionalSize uint32                    // Bytes reserved for the pool's coinbase outputs
+	PoolOutputScript                []byte                    // Script paid CoinbaseTxValueRemaining
+	ExtraNonceSize                  int                       // Extranonce space appended to the coinbase prefix
+	DialTimeout                     time.Duration             // Timeout for connect and handshake
+	ReconnectInterval               time.Duration             // Delay before reconnecting after a failure
+}
+
+// DefaultTDPConfig returns sensible defaults for a Template Provider connection
+func DefaultTDPConfig() TDPConfig {
+	return TDPConfig{
+		CoinbaseOutputMaxAdditionalSize: 64,
+		ExtraNonceSize:                  8,
+		DialTimeout:                     10 * time.Second,
+		ReconnectInterval:               5 * time.Second,
+	}
+}
+
+// tdpTemplate is a NewTemplate together with the data gathered for it
+type tdpTemplate struct {
+	msg *v2binary.NewTemplate
+	txs [][]byte // Filled by RequestTransactionData.Success
+}
+
+// tdpTemplateProvider implements stratum.TemplateProvider on top of the
+// Template Distribution protocol
+type tdpTemplateProvider struct {
+	config     TDPConfig
+	coinConfig CoinConfig
+
+	// Connection
+	conn    net.Conn
+	connMu  sync.RWMutex
+	writeMu sync.Mutex
+
+	// Templates announced by the TP, keyed by template ID
+	templates   map[uint64]*tdpTemplate
+	prevHash    *v2binary.TemplateSetNewPrevHash
+	activeID    uint64
+	stateMu     sync.Mutex
+	current     *stratum.BlockTemplate
+	currentMu   sync.RWMutex
+	subscribers map[int64]func(*stratum.BlockTemplate)
+	subsMu      sync.RWMutex
+	nextSubID   atomic.Int64
+
+	// Lifecycle
+	ctx       context.Context
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+	isRunning atomic.Bool
+
+	// Metrics
+	templatesReceived atomic.Int64
+	solutionsSent     atomic.Int64
+	reconnects        atomic.Int64
+}
+
+// NewTDPTemplateProvider creates a template provider fed by an SV2 Template Provider
+func NewTDPTemplateProvider(config TDPConfig, coinConfig CoinConfig) *tdpTemplateProvider {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &tdpTemplateProvider{
+		config:      config,
+		coinConfig:  coinConfig,
+		templates:   make(map[uint64]*tdpTemplate),
+		subscribers: make(map[int64]func(*stratum.BlockTemplate)),
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+}
+
+// Start connects to the Template Provider and keeps the connection alive
+func (p *tdpTemplateProvider) Start() error {
+	if p.isRunning.Load() {
+		return fmt.Errorf("template provider already running")
+	}
+	p.isRunning.Store(true)
+
+	p.wg.Add(1)
+	go p.connectLoop()
+
+	return nil
+}
+
+// Stop closes the connection and waits for the client to exit
+func (p *tdpTemplateProvider) Stop() error {
+	if !p.isRunning.Load() {
+		return nil
+	}
+
+	p.cancel()
+	p.isRunning.Store(false)
+
+	p.connMu.RLock()
+	if p.conn != nil {
+		p.conn.Close()
+	}
+	p.connMu.RUnlock()
+
+	p.wg.Wait()
+	return nil
+}
+
+// GetTemplate returns the template for the current chain tip
+func (p *tdpTemplateProvider) GetTemplate() (*stratum.BlockTemplate, error) {
+	p.currentMu.RLock()
+	defer p.currentMu.RUnlock()
+
+	if p.current == nil {
+		return nil, ErrNoTemplateAvailable
+	}
+	return cloneTemplate(p.current), nil
+}
+
+// GetTemplateForHeight returns the current template if it is for height
+func (p *tdpTemplateProvider) GetTemplateForHeight(height uint64) (*stratum.BlockTemplate, error) {
+	template, err := p.GetTemplate()
+	if err != nil {
+		return nil, err
+	}
+	if template.Height != height {
+		return nil, fmt.Errorf("no template available for height %d", height)
+	}
+	return template, nil
+}
+
+// SubscribeTemplates registers handler for every template the TP activates
+func (p *tdpTemplateProvider) SubscribeTemplates(handler func(*stratum.BlockTemplate)) stratum.Subscription {
+	id := p.nextSubID.Add(1)
+
+	p.subsMu.Lock()
+	p.subscribers[id] = handler
+	p.subsMu.Unlock()
+
+	return &tdpSubscription{id: id, provider: p}
+}
+
+// GetCurrentHeight returns the height of the block being built
+func (p *tdpTemplateProvider) GetCurrentHeight() (uint64, error) {
+	template, err := p.GetTemplate()
+	if err != nil {
+		return 0, err
+	}
+	return template.Height, nil
+}
+
+// GetNetworkDifficulty returns the difficulty of the current template's target
+func (p *tdpTemplateProvider) GetNetworkDifficulty() (uint64, error) {
+	template, err := p.GetTemplate()
+	if err != nil {
+		return 0, err
+	}
+	return blockdag.TargetToDifficulty(template.Target), nil
+}
+
+// SubmitSolution sends a solved block header and coinbase back to the TP,
+// which assembles and broadcasts the block
+func (p *tdpTemplateProvider) SubmitSolution(templateID uint64, version, timestamp, nonce uint32, coinbaseTx []byte) error {
+	ser := v2binary.NewSerializer()
+	payload := ser.SerializeSubmitSolution(&v2binary.SubmitSolution{
+		TemplateID:      templateID,
+		Version:         version,
+		HeaderTimestamp: timestamp,
+		HeaderNonce:     nonce,
+		CoinbaseTx:      coinbaseTx,
+	})
+	if err := p.send(v2binary.MsgTypeSubmitSolution, payload); err != nil {
+		return err
+	}
+	p.solutionsSent.Add(1)
+	return nil
+}
+
+// GetStats returns client metrics
+func (p *tdpTemplateProvider) GetStats() map[string]int64 {
+	return map[string]int64{
+		"templates_received": p.templatesReceived.Load(),
+		"solutions_sent":     p.solutionsSent.Load(),
+		"reconnects":         p.reconnects.Load(),
+	}
+}
+
+// =============================================================================
+// Connection Handling
+// =============================================================================
+
+// connectLoop runs sessions against the TP until the provider stops
+func (p *tdpTemplateProvider) connectLoop() {
+	defer p.wg.Done()
+
+	for {
+		err := p.runSession()
+		if p.ctx.Err() != nil {
+			return
+		}
+		if err != nil && err != io.EOF {
+			log.Printf("Template Provider %s: %v", p.config.Address, err)
+		}
+		p.reconnects.Add(1)
+
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-time.After(p.config.ReconnectInterval):
+		}
+	}
+}
+
+// runSession connects, sets up the Template Distribution protocol and
+// processes messages until the connection fails
+func (p *tdpTemplateProvider) runSession() error {
+	conn, err := p.dial()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	p.connMu.Lock()
+	p.conn = conn
+	p.connMu.Unlock()
+	defer func() {
+		p.connMu.Lock()
+		p.conn = nil
+		p.connMu.Unlock()
+	}()
+
+	// A reconnect starts from a clean slate; the TP resends its templates
+	p.stateMu.Lock()
+	p.templates = make(map[uint64]*tdpTemplate)
+	p.prevHash = nil
+	p.stateMu.Unlock()
+
+	if p.ctx.Err() != nil {
+		return nil
+	}
+	if err := p.setupConnection(conn); err != nil {
+		return err
+	}
+
+	ser := v2binary.NewSerializer()
+	payload := ser.SerializeCoinbaseOutputDataSize(&v2binary.CoinbaseOutputDataSize{
+		CoinbaseOutputMaxAdditionalSize: p.config.CoinbaseOutputMaxAdditionalSize,
+	})
+	if err := p.send(v2binary.MsgTypeCoinbaseOutputDataSize, payload); err != nil {
+		return err
+	}
+
+	for {
+		msgType, payload, err := readFrame(conn)
+		if err != nil {
+			return err
+		}
+		if err := p.handleMessage(msgType, payload); err != nil {
+			return err
+		}
+	}
+}
+
+// dial opens the TCP connection and runs the Noise handshake when an
+// authority key is configured
+func (p *tdpTemplateProvider) dial() (net.Conn, error) {
+	conn, err := net.DialTimeout("tcp", p.config.Address, p.config.DialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	if p.config.AuthorityKey == nil {
+		return conn, nil
+	}
+
+	conn.SetDeadline(time.Now().Add(p.config.DialTimeout))
+	secure, err := noise.ClientHandshake(conn, *p.config.AuthorityKey)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("noise handshake: %w", err)
+	}
+	conn.SetDeadline(time.Time{})
+	return secure, nil
+}
+
+// setupConnection negotiates the Template Distribution protocol
+func (p *tdpTemplateProvider) setupConnection(conn net.Conn) error {
+	ser := v2binary.NewSerializer()
+	payload := ser.SerializeSetupConnection(&v2binary.SetupConnection{
+		Protocol:   v2binary.ProtocolTemplateDistribution,
+		MinVersion: tdpProtocolVersion,
+		MaxVersion: tdpProtocolVersion,
+	})
+	if err := p.send(v2binary.MsgTypeSetupConnection, payload); err != nil {
+		return err
+	}
+
+	conn.SetReadDeadline(time.Now().Add(p.config.DialTimeout))
+	msgType, _, err := readFrame(conn)
+	if err != nil {
+		return err
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	if msgType != v2binary.MsgTypeSetupConnectionSuccess {
+		return ErrTDPSetupRejected
+	}
+	return nil
+}
+
+// handleMessage dispatches a single Template Distribution message
+func (p *tdpTemplateProvider) handleMessage(msgType uint8, payload []byte) error {
+	d := v2binary.NewDeserializer(payload)
+
+	switch msgType {
+	case v2binary.MsgTypeNewTemplate:
+		msg, err := d.DeserializeNewTemplate()
+		if err != nil {
+			return fmt.Errorf("deserialize NewTemplate: %w", err)
+		}
+		return p.handleNewTemplate(msg)
+
+	case v2binary.MsgTypeTemplateSetNewPrevHash:
+		msg, err := d.DeserializeTemplateSetNewPrevHash()
+		if err != nil {
+			return fmt.Errorf("deserialize SetNewPrevHash: %w", err)
+		}
+		return p.handleSetNewPrevHash(msg)
+
+	case v2binary.MsgTypeRequestTransactionDataSuccess:
+		msg, err := d.DeserializeRequestTransactionDataSuccess()
+		if err != nil {
+			return fmt.Errorf("deserialize RequestTransactionData.Success: %w", err)
+		}
+		p.handleTransactionData(msg)
+		return nil
+
+	case v2binary.MsgTypeRequestTransactionDataError:
+		msg, err := d.DeserializeRequestTransactionDataError()
+		if err != nil {
+			return fmt.Errorf("deserialize RequestTransactionData.Error: %w", err)
+		}
+		log.Printf("Template Provider: no transaction data for template %d: %s", msg.TemplateID, msg.ErrorCode)
+		return nil
+
+	default:
+		log.Printf("Template Provider: ignoring message 0x%02x", msgType)
+		return nil
+	}
+}
+
+// handleNewTemplate stores a template; templates for the current tip are
+// activated straight away, future ones wait for SetNewPrevHash
+func (p *tdpTemplateProvider) handleNewTemplate(msg *v2binary.NewTemplate) error {
+	p.templatesReceived.Add(1)
+
+	p.stateMu.Lock()
+	p.templates[msg.TemplateID] = &tdpTemplate{msg: msg}
+	activate := !msg.FutureTemplate && p.prevHash != nil
+	if activate {
+		p.activeID = msg.TemplateID
+	}
+	p.stateMu.Unlock()
+
+	if !activate {
+		return nil
+	}
+	return p.requestTransactionData(msg.TemplateID)
+}
+
+// handleSetNewPrevHash moves to a new chain tip and activates the template it names
+func (p *tdpTemplateProvider) handleSetNewPrevHash(msg *v2binary.TemplateSetNewPrevHash) error {
+	p.stateMu.Lock()
+	if _, ok := p.templates[msg.TemplateID]; !ok {
+		p.stateMu.Unlock()
+		return fmt.Errorf("SetNewPrevHash for %w %d", ErrTDPUnknownTemplate, msg.TemplateID)
+	}
+	p.prevHash = msg
+	p.activeID = msg.TemplateID
+	// Only the activated template and later future templates stay useful
+	for id := range p.templates {
+		if id < msg.TemplateID {
+			delete(p.templates, id)
+		}
+	}
+	p.stateMu.Unlock()
+
+	return p.requestTransactionData(msg.TemplateID)
+}
+
+// requestTransactionData asks the TP for a template's transactions
+func (p *tdpTemplateProvider) requestTransactionData(templateID uint64) error {
+	ser := v2binary.NewSerializer()
+	payload := ser.SerializeRequestTransactionData(&v2binary.RequestTransactionData{
+		TemplateID: templateID,
+	})
+	return p.send(v2binary.MsgTypeRequestTransactionData, payload)
+}
+
+// handleTransactionData completes a template and publishes it if it is still active
+func (p *tdpTemplateProvider) handleTransactionData(msg *v2binary.RequestTransactionDataSuccess) {
+	p.stateMu.Lock()
+	tmpl, ok := p.templates[msg.TemplateID]
+	if ok {
+		tmpl.txs = msg.TransactionList
+	}
+	active := ok && p.prevHash != nil && p.activeID == msg.TemplateID
+	var prevHash *v2binary.TemplateSetNewPrevHash
+	if active {
+		prevHash = p.prevHash
+	}
+	p.stateMu.Unlock()
+
+	if !active {
+		return
+	}
+
+	template := p.buildTemplate(tmpl, prevHash)
+
+	p.currentMu.Lock()
+	p.current = template
+	p.currentMu.Unlock()
+
+	p.subsMu.RLock()
+	defer p.subsMu.RUnlock()
+	for _, handler := range p.subscribers {
+		go handler(cloneTemplate(template))
+	}
+}
+
+// buildTemplate combines a NewTemplate, its chain tip and its transactions
+func (p *tdpTemplateProvider) buildTemplate(tmpl *tdpTemplate, prev *v2binary.TemplateSetNewPrevHash) *stratum.BlockTemplate {
+	msg := tmpl.msg
+
+	// SV2 carries the target as a little-endian U256
+	target := make([]byte, 32)
+	copy(target, prev.Target[:])
+	reverseBytes(target)
+
+	return &stratum.BlockTemplate{
+		TemplateID:    fmt.Sprintf("%d", msg.TemplateID),
+		Version:       msg.Version,
+		PrevHash:      copyBytes(prev.PrevHash[:]),
+		Timestamp:     prev.HeaderTimestamp,
+		Bits:          prev.NBits,
+		Height:        decodeHeight(msg.CoinbasePrefix),
+		Coinbase:      p.buildCoinbase(msg),
+		CoinbaseValue: msg.CoinbaseTxValueRemaining,
+		Transactions:  tmpl.txs,
+		Target:        target,
+		Algorithm:     p.coinConfig.Algorithm,
+		Coin:          p.coinConfig.Symbol,
+		MinTime:       prev.HeaderTimestamp,
+		MaxTime:       prev.HeaderTimestamp + 7200,
+		CreatedAt:     time.Now(),
+	}
+}
+
+// buildCoinbase assembles the coinbase from the TP's parts: its script
+// prefix, extranonce space, the pool output for the remaining value and the
+// outputs the node requires. The witness is left to SubmitSolution's caller.
+func (p *tdpTemplateProvider) buildCoinbase(msg *v2binary.NewTemplate) []byte {
+	coinbase := make([]byte, 0, 128+len(msg.CoinbaseTxOutputs))
+
+	coinbase = binary.LittleEndian.AppendUint32(coinbase, msg.CoinbaseTxVersion)
+	coinbase = append(coinbase, 0x01)                   // Input count
+	coinbase = append(coinbase, make([]byte, 32)...)    // Null prevout hash
+	coinbase = append(coinbase, 0xFF, 0xFF, 0xFF, 0xFF) // Null prevout index
+
+	scriptLen := len(msg.CoinbasePrefix) + p.config.ExtraNonceSize
+	coinbase = appendVarInt(coinbase, uint64(scriptLen))
+	coinbase = append(coinbase, msg.CoinbasePrefix...)
+	coinbase = append(coinbase, make([]byte, p.config.ExtraNonceSize)...)
+	coinbase = binary.LittleEndian.AppendUint32(coinbase, msg.CoinbaseTxInputSequence)
+
+	outputs := uint64(msg.CoinbaseTxOutputsCount)
+	if len(p.config.PoolOutputScript) > 0 {
+		outputs++
+	}
+	coinbase = appendVarInt(coinbase, outputs)
+	if len(p.config.PoolOutputScript) > 0 {
+		coinbase = binary.LittleEndian.AppendUint64(coinbase, msg.CoinbaseTxValueRemaining)
+		coinbase = appendVarInt(coinbase, uint64(len(p.config.PoolOutputScript)))
+		coinbase = append(coinbase, p.config.PoolOutputScript...)
+	}
+	coinbase = append(coinbase, msg.CoinbaseTxOutputs...)
+
+	coinbase = binary.LittleEndian.AppendUint32(coinbase, msg.CoinbaseTxLocktime)
+	return coinbase
+}
+
+// send writes a single frame to the current connection
+func (p *tdpTemplateProvider) send(msgType uint8, payload []byte) error {
+	p.connMu.RLock()
+	conn := p.conn
+	p.connMu.RUnlock()
+	if conn == nil {
+		return ErrTDPNotConnected
+	}
+
+	frame := v2binary.NewSerializer().SerializeFrame(msgType, 0, payload)
+
+	p.writeMu.Lock()
+	defer p.writeMu.Unlock()
+	_, err := conn.Write(frame)
+	return err
+}
+
+// readFrame reads one SV2 frame and returns its type and payload
+func readFrame(conn net.Conn) (uint8, []byte, error) {
+	header := make([]byte, v2binary.HeaderSize)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return 0, nil, err
+	}
+	h, err := v2binary.ParseHeader(header)
+	if err != nil {
+		return 0, nil, err
+	}
+	payload := make([]byte, h.MsgLength)
+	if _, err := io.ReadFull(conn, payload); err != nil {
+		return 0, nil, err
+	}
+	return h.MsgType, payload, nil
+}
+
+// decodeHeight reads the BIP34 height at the start of a coinbase script
+func decodeHeight(script []byte) uint64 {
+	if len(script) == 0 {
+		return 0
+	}
+	op := script[0]
+	if op >= 0x51 && op <= 0x60 {
+		return uint64(op - 0x50) // OP_1 .. OP_16
+	}
+	n := int(op)
+	if n == 0 || n > 8 || len(script) < 1+n {
+		return 0
+	}
+	var height uint64
+	for i := n; i >= 1; i-- {
+		height = height<<8 | uint64(script[i])
+	}
+	return height
+}
+
+// appendVarInt appends a Bitcoin CompactSize integer
+func appendVarInt(b []byte, v uint64) []byte {
+	switch {
+	case v < 0xFD:
+		return append(b, byte(v))
+	case v <= 0xFFFF:
+		return binary.LittleEndian.AppendUint16(append(b, 0xFD), uint16(v))
+	case v <= 0xFFFFFFFF:
+		return binary.LittleEndian.AppendUint32(append(b, 0xFE), uint32(v))
+	default:
+		return binary.LittleEndian.AppendUint64(append(b, 0xFF), v)
+	}
+}
+
+// =============================================================================
+// Template Distribution Subscription
+// =============================================================================
+
+type tdpSubscription struct {
+	id       int64
+	provider *tdpTemplateProvider
+	done     atomic.Bool
+}
+
+func (s *tdpSubscription) Unsubscribe() {
+	if !s.done.CompareAndSwap(false, true) {
+		return
+	}
+	s.provider.subsMu.Lock()
+	delete(s.provider.subscribers, s.id)
+	s.provider.subsMu.Unlock()
+}
+
+func (s *tdpSubscription) IsActive() bool {
+	return !s.done.Load()
+}
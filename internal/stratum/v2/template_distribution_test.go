@@ -0,0 +1,262 @@
+package v2
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/chimera-pool/chimera-pool-core/internal/stratum"
+	v2binary "github.com/chimera-pool/chimera-pool-core/internal/stratum/v2/binary"
+	"github.com/chimera-pool/chimera-pool-core/internal/stratum/v2/noise"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// Template Distribution Client Tests
+// =============================================================================
+
+// fakeTemplateProvider is an in-process node-side Template Provider
+type fakeTemplateProvider struct {
+	t        *testing.T
+	listener net.Listener
+	noise    *noise.ServerConfig
+	conns    chan net.Conn
+}
+
+func newFakeTemplateProvider(t *testing.T, noiseConfig *noise.ServerConfig) *fakeTemplateProvider {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	tp := &fakeTemplateProvider{t: t, listener: listener, noise: noiseConfig, conns: make(chan net.Conn, 1)}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			if noiseConfig != nil {
+				secure, err := noise.ServerHandshake(conn, noiseConfig)
+				if err != nil {
+					conn.Close()
+					continue
+				}
+				conn = secure
+			}
+			tp.conns <- conn
+		}
+	}()
+	return tp
+}
+
+// accept waits for the client and completes SetupConnection and CoinbaseOutputDataSize
+func (tp *fakeTemplateProvider) accept() net.Conn {
+	var conn net.Conn
+	select {
+	case conn = <-tp.conns:
+	case <-time.After(5 * time.Second):
+		tp.t.Fatal("client did not connect")
+	}
+	tp.t.Cleanup(func() { conn.Close() })
+
+	msgType, payload := tp.read(conn)
+	require.Equal(tp.t, v2binary.MsgTypeSetupConnection, msgType)
+	setup, err := v2binary.NewDeserializer(payload).DeserializeSetupConnection()
+	require.NoError(tp.t, err)
+	assert.Equal(tp.t, v2binary.ProtocolTemplateDistribution, setup.Protocol)
+
+	ser := v2binary.NewSerializer()
+	tp.write(conn, v2binary.MsgTypeSetupConnectionSuccess,
+		ser.SerializeSetupConnectionSuccess(&v2binary.SetupConnectionSuccess{UsedVersion: tdpProtocolVersion}))
+
+	msgType, payload = tp.read(conn)
+	require.Equal(tp.t, v2binary.MsgTypeCoinbaseOutputDataSize, msgType)
+	size, err := v2binary.NewDeserializer(payload).DeserializeCoinbaseOutputDataSize()
+	require.NoError(tp.t, err)
+	assert.Equal(tp.t, uint32(64), size.CoinbaseOutputMaxAdditionalSize)
+	return conn
+}
+
+func (tp *fakeTemplateProvider) read(conn net.Conn) (uint8, []byte) {
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	msgType, payload, err := readFrame(conn)
+	require.NoError(tp.t, err)
+	return msgType, payload
+}
+
+func (tp *fakeTemplateProvider) write(conn net.Conn, msgType uint8, payload []byte) {
+	_, err := conn.Write(v2binary.NewSerializer().SerializeFrame(msgType, 0, payload))
+	require.NoError(tp.t, err)
+}
+
+// serveTransactionData answers the next RequestTransactionData with txs
+func (tp *fakeTemplateProvider) serveTransactionData(conn net.Conn, templateID uint64, txs [][]byte) {
+	msgType, payload := tp.read(conn)
+	require.Equal(tp.t, v2binary.MsgTypeRequestTransactionData, msgType)
+	req, err := v2binary.NewDeserializer(payload).DeserializeRequestTransactionData()
+	require.NoError(tp.t, err)
+	require.Equal(tp.t, templateID, req.TemplateID)
+
+	tp.write(conn, v2binary.MsgTypeRequestTransactionDataSuccess,
+		v2binary.NewSerializer().SerializeRequestTransactionDataSuccess(&v2binary.RequestTransactionDataSuccess{
+			TemplateID:      templateID,
+			ExcessData:      []byte{},
+			TransactionList: txs,
+		}))
+}
+
+func testNewTemplate(id uint64, future bool) *v2binary.NewTemplate {
+	return &v2binary.NewTemplate{
+		TemplateID:               id,
+		FutureTemplate:           future,
+		Version:                  0x20000000,
+		CoinbaseTxVersion:        2,
+		CoinbasePrefix:           []byte{0x03, 0xa0, 0x25, 0x26}, // BIP34 height 2500000
+		CoinbaseTxInputSequence:  0xffffffff,
+		CoinbaseTxValueRemaining: 625000000,
+		CoinbaseTxOutputsCount:   1,
+		CoinbaseTxOutputs:        []byte{0, 0, 0, 0, 0, 0, 0, 0, 0x02, 0x6a, 0x00},
+	}
+}
+
+func startTDPClient(t *testing.T, config TDPConfig) (*tdpTemplateProvider, chan *stratum.BlockTemplate) {
+	provider := NewTDPTemplateProvider(config, LitecoinConfig())
+	templates := make(chan *stratum.BlockTemplate, 4)
+	provider.SubscribeTemplates(func(tmpl *stratum.BlockTemplate) { templates <- tmpl })
+	require.NoError(t, provider.Start())
+	t.Cleanup(func() { provider.Stop() })
+	return provider, templates
+}
+
+func waitTemplate(t *testing.T, templates chan *stratum.BlockTemplate) *stratum.BlockTemplate {
+	select {
+	case tmpl := <-templates:
+		return tmpl
+	case <-time.After(5 * time.Second):
+		t.Fatal("no template published")
+		return nil
+	}
+}
+
+func TestTDPTemplateProvider_NoiseTemplateFlow(t *testing.T) {
+	authority, err := noise.GenerateAuthorityKey()
+	require.NoError(t, err)
+	static, err := noise.GenerateKeyPair()
+	require.NoError(t, err)
+	tp := newFakeTemplateProvider(t, &noise.ServerConfig{StaticKey: static, AuthorityKey: authority, CertValidity: time.Hour})
+
+	config := DefaultTDPConfig()
+	config.Address = tp.listener.Addr().String()
+	pinned := authority.PublicKey()
+	config.AuthorityKey = &pinned
+	config.PoolOutputScript = []byte{0x00, 0x14, 0x01, 0x02}
+	config.ExtraNonceSize = 4
+	provider, templates := startTDPClient(t, config)
+
+	conn := tp.accept()
+	ser := v2binary.NewSerializer()
+
+	// Future template, then the tip that activates it
+	tp.write(conn, v2binary.MsgTypeNewTemplate, ser.SerializeNewTemplate(testNewTemplate(7, true)))
+	target := [32]byte{}
+	target[29] = 0xff // Little-endian: 0x0000ff00...
+	tp.write(conn, v2binary.MsgTypeTemplateSetNewPrevHash, ser.SerializeTemplateSetNewPrevHash(&v2binary.TemplateSetNewPrevHash{
+		TemplateID:      7,
+		PrevHash:        [32]byte{0xaa},
+		HeaderTimestamp: 1700000000,
+		NBits:           0x1a0ffff0,
+		Target:          target,
+	}))
+	txs := [][]byte{{0x02, 0x00, 0x00, 0x00, 0x01}}
+	tp.serveTransactionData(conn, 7, txs)
+
+	tmpl := waitTemplate(t, templates)
+	assert.Equal(t, "7", tmpl.TemplateID)
+	assert.Equal(t, uint64(2500000), tmpl.Height)
+	assert.Equal(t, uint32(0x1a0ffff0), tmpl.Bits)
+	assert.Equal(t, uint32(1700000000), tmpl.Timestamp)
+	assert.Equal(t, byte(0xaa), tmpl.PrevHash[0])
+	assert.Equal(t, byte(0xff), tmpl.Target[2], "target is converted to big-endian")
+	assert.Equal(t, uint64(625000000), tmpl.CoinbaseValue)
+	assert.Equal(t, txs, tmpl.Transactions)
+	assert.Equal(t, "LTC", tmpl.Coin)
+
+	// Coinbase: version, one null input with prefix + extranonce, pool output first
+	coinbase := tmpl.Coinbase
+	assert.Equal(t, []byte{0x02, 0x00, 0x00, 0x00, 0x01}, coinbase[:5])
+	assert.Equal(t, byte(8), coinbase[41], "script is prefix plus extranonce")
+	assert.Equal(t, []byte{0x03, 0xa0, 0x25, 0x26}, coinbase[42:46])
+	assert.Equal(t, byte(2), coinbase[54], "pool output plus node output")
+
+	height, err := provider.GetCurrentHeight()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2500000), height)
+	current, err := provider.GetTemplate()
+	require.NoError(t, err)
+	assert.Equal(t, "7", current.TemplateID)
+
+	// A fee update on the same tip is activated immediately
+	tp.write(conn, v2binary.MsgTypeNewTemplate, ser.SerializeNewTemplate(testNewTemplate(8, false)))
+	tp.serveTransactionData(conn, 8, [][]byte{{0x01}, {0x02}})
+	tmpl = waitTemplate(t, templates)
+	assert.Equal(t, "8", tmpl.TemplateID)
+	assert.Len(t, tmpl.Transactions, 2)
+
+	// Solutions go back to the TP
+	require.NoError(t, provider.SubmitSolution(8, 0x20000000, 1700000001, 0xdeadbeef, []byte{0x01, 0x02}))
+	msgType, payload := tp.read(conn)
+	require.Equal(t, v2binary.MsgTypeSubmitSolution, msgType)
+	solution, err := v2binary.NewDeserializer(payload).DeserializeSubmitSolution()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(8), solution.TemplateID)
+	assert.Equal(t, uint32(0xdeadbeef), solution.HeaderNonce)
+	assert.Equal(t, []byte{0x01, 0x02}, solution.CoinbaseTx)
+	assert.Equal(t, int64(1), provider.GetStats()["solutions_sent"])
+}
+
+func TestTDPTemplateProvider_ReconnectsAfterDisconnect(t *testing.T) {
+	tp := newFakeTemplateProvider(t, nil)
+
+	config := DefaultTDPConfig()
+	config.Address = tp.listener.Addr().String()
+	config.ReconnectInterval = 10 * time.Millisecond
+	provider, templates := startTDPClient(t, config)
+
+	conn := tp.accept()
+	conn.Close()
+
+	conn = tp.accept()
+	ser := v2binary.NewSerializer()
+	tp.write(conn, v2binary.MsgTypeNewTemplate, ser.SerializeNewTemplate(testNewTemplate(1, true)))
+	tp.write(conn, v2binary.MsgTypeTemplateSetNewPrevHash, ser.SerializeTemplateSetNewPrevHash(&v2binary.TemplateSetNewPrevHash{
+		TemplateID: 1,
+		NBits:      0x1d00ffff,
+	}))
+	tp.serveTransactionData(conn, 1, [][]byte{})
+
+	tmpl := waitTemplate(t, templates)
+	assert.Equal(t, "1", tmpl.TemplateID)
+	assert.GreaterOrEqual(t, provider.GetStats()["reconnects"], int64(1))
+}
+
+func TestTDPTemplateProvider_NoTemplateBeforePrevHash(t *testing.T) {
+	provider := NewTDPTemplateProvider(DefaultTDPConfig(), LitecoinConfig())
+
+	_, err := provider.GetTemplate()
+	assert.ErrorIs(t, err, ErrNoTemplateAvailable)
+	assert.ErrorIs(t, provider.SubmitSolution(1, 0, 0, 0, nil), ErrTDPNotConnected)
+
+	sub := provider.SubscribeTemplates(func(*stratum.BlockTemplate) {})
+	assert.True(t, sub.IsActive())
+	sub.Unsubscribe()
+	assert.False(t, sub.IsActive())
+	assert.Empty(t, provider.subscribers)
+}
+
+func TestDecodeHeight(t *testing.T) {
+	for _, height := range []uint64{1, 16, 17, 255, 256, 2500000} {
+		assert.Equal(t, height, decodeHeight(encodeHeight(height)), "height %d", height)
+	}
+	assert.Equal(t, uint64(0), decodeHeight(nil))
+}
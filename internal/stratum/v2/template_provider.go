@@ -477,6 +477,11 @@ func (p *templateProvider) notifySubscribers(template *stratum.BlockTemplate) {
 }
 
 func (p *templateProvider) copyTemplate(t *stratum.BlockTemplate) *stratum.BlockTemplate {
+	return cloneTemplate(t)
+}
+
+// cloneTemplate deep-copies a block template so subscribers cannot modify shared state
+func cloneTemplate(t *stratum.BlockTemplate) *stratum.BlockTemplate {
 	if t == nil {
 		return nil
 	}
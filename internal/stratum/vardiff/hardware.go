@@ -0,0 +1,124 @@
+package vardiff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// =============================================================================
+// HARDWARE-CLASS SEEDING
+// New connections start at a difficulty sized for their likely hardware, so
+// an ASIC does not flood the pool and a CPU miner is not starved while the
+// first retarget gathers data.
+// =============================================================================
+
+// Hardware class names
+const (
+	HardwareUnknown      = "unknown"
+	HardwareCPU          = "cpu"
+	HardwareGPU          = "gpu"
+	HardwareMultipurpose = "multipurpose" // cgminer-style software that drives GPUs or ASICs
+	HardwareASIC         = "asic"
+)
+
+// HardwareClass is a family of mining hardware and its expected hashrate
+type HardwareClass struct {
+	Name             string
+	ExpectedHashrate float64 // H/s
+}
+
+// Predefined hardware classes, sized so a 10s target share time seeds
+// difficulty 0.01 for CPUs, 1000 for GPUs and unknown software and 35000 for
+// ASICs. GPUs start high on purpose: retargeting down from too few shares is
+// cheaper than a flood of shares from an unrecognised ASIC.
+var (
+	ClassCPU          = HardwareClass{Name: HardwareCPU, ExpectedHashrate: 0.01 * Diff1Target / 10}
+	ClassGPU          = HardwareClass{Name: HardwareGPU, ExpectedHashrate: 1000 * Diff1Target / 10}
+	ClassMultipurpose = HardwareClass{Name: HardwareMultipurpose, ExpectedHashrate: 1000 * Diff1Target / 10}
+	ClassASIC         = HardwareClass{Name: HardwareASIC, ExpectedHashrate: 35000 * Diff1Target / 10} // ~15 TH/s, BlockDAG X100 on Scrypt
+	ClassUnknown      = HardwareClass{Name: HardwareUnknown, ExpectedHashrate: 1000 * Diff1Target / 10}
+)
+
+// userAgentPatterns are checked in order; ASICs come before GPUs because some
+// ASIC firmware reports GPU-style software names, and cgminer-style software
+// comes before GPUs because "bfgminer" and "sgminer" contain "gminer"
+var userAgentPatterns = []struct {
+	class    HardwareClass
+	patterns []string
+}{
+	{ClassCPU, []string{"cpuminer", "minerd", "xmrig"}},
+	{ClassASIC, []string{"antminer", "whatsminer", "avalon", "innosilicon", "goldshell", "bitmain",
+		"x100", "x30", "blockdag", "bdag", "asic", "luckyminer"}},
+	{ClassMultipurpose, []string{"cgminer", "bfgminer", "sgminer"}},
+	{ClassGPU, []string{"claymore", "ethminer", "phoenixminer", "t-rex", "gminer", "lolminer", "nbminer",
+		"cuda", "opencl", "geforce", "radeon"}},
+}
+
+// ClassifyUserAgent classifies hardware from a mining.subscribe user agent
+func ClassifyUserAgent(userAgent string) HardwareClass {
+	ua := strings.ToLower(userAgent)
+	for _, entry := range userAgentPatterns {
+		for _, p := range entry.patterns {
+			if strings.Contains(ua, p) {
+				return entry.class
+			}
+		}
+	}
+	return ClassUnknown
+}
+
+// ClassifyHashrate classifies hardware from a reported or observed Scrypt hashrate
+func ClassifyHashrate(hashrate float64) HardwareClass {
+	switch {
+	case hashrate <= 0:
+		return ClassUnknown
+	case hashrate < 1e6: // < 1 MH/s
+		return ClassCPU
+	case hashrate < 1e8: // < 100 MH/s
+		return ClassGPU
+	default:
+		return ClassASIC
+	}
+}
+
+// Hints describe a connection for seeding its difficulty
+type Hints struct {
+	UserAgent string  // From mining.subscribe or SetupConnection
+	Hashrate  float64 // Nominal hashrate in H/s (V2 OpenMiningChannel), 0 if unknown
+}
+
+// Seeder picks a connection's starting difficulty
+type Seeder interface {
+	// Seed returns the starting difficulty and why it was chosen; the
+	// manager clamps it to the policy's bounds
+	Seed(hints Hints, config Config) (difficulty float64, reason string)
+}
+
+// StaticSeeder starts every connection at the configured initial difficulty
+type StaticSeeder struct{}
+
+// Seed implements Seeder
+func (StaticSeeder) Seed(hints Hints, config Config) (float64, string) {
+	return config.InitialDifficulty, "initial difficulty"
+}
+
+// HardwareSeeder sizes the starting difficulty for the connection's hardware.
+// A nominal hashrate wins over the user agent; with neither it falls back to
+// the configured initial difficulty.
+type HardwareSeeder struct{}
+
+// Seed implements Seeder
+func (HardwareSeeder) Seed(hints Hints, config Config) (float64, string) {
+	target := config.TargetShareTime.Seconds()
+	if hints.Hashrate > 0 {
+		class := ClassifyHashrate(hints.Hashrate)
+		return CalculateOptimalDifficulty(hints.Hashrate, target),
+			fmt.Sprintf("nominal hashrate %.3g H/s (%s)", hints.Hashrate, class.Name)
+	}
+	if hints.UserAgent != "" {
+		class := ClassifyUserAgent(hints.UserAgent)
+		return CalculateOptimalDifficulty(class.ExpectedHashrate, target),
+			fmt.Sprintf("%s class from user agent %q", class.Name, hints.UserAgent)
+	}
+	return config.InitialDifficulty, "no hardware hints"
+}
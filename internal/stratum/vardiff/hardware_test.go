@@ -0,0 +1,74 @@
+package vardiff
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyUserAgent(t *testing.T) {
+	tests := []struct {
+		userAgent string
+		expected  string
+	}{
+		{"cpuminer-multi/1.3", HardwareCPU},
+		{"BlockDAG-X100/1.0", HardwareASIC},
+		{"BDAG-X30 Miner", HardwareASIC},
+		{"Antminer L7", HardwareASIC},
+		{"lolMiner 1.82", HardwareGPU},
+		{"CUDA Miner/1.0", HardwareGPU},
+		{"cgminer/4.12.1", HardwareMultipurpose},
+		{"bfgminer/5.5.0", HardwareMultipurpose},
+		{"Unknown Miner", HardwareUnknown},
+		{"", HardwareUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.userAgent, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ClassifyUserAgent(tt.userAgent).Name)
+		})
+	}
+}
+
+func TestClassifyHashrate(t *testing.T) {
+	assert.Equal(t, HardwareUnknown, ClassifyHashrate(0).Name)
+	assert.Equal(t, HardwareCPU, ClassifyHashrate(50e3).Name)
+	assert.Equal(t, HardwareGPU, ClassifyHashrate(2e6).Name)
+	assert.Equal(t, HardwareASIC, ClassifyHashrate(9.5e9).Name)
+}
+
+func TestHardwareSeeder(t *testing.T) {
+	config := X100OptimizedConfig()
+
+	t.Run("keeps the historical difficulties at a 10s target", func(t *testing.T) {
+		for userAgent, expected := range map[string]float64{
+			"cpuminer/2.5.1":  0.01,
+			"cgminer/4.12.1":  1000,
+			"BlockDAG-X100":   35000,
+			"mystery-miner/1": 1000,
+		} {
+			difficulty, reason := HardwareSeeder{}.Seed(Hints{UserAgent: userAgent}, config)
+			assert.InDelta(t, expected, difficulty, expected*1e-9, userAgent)
+			assert.Contains(t, reason, userAgent)
+		}
+	})
+
+	t.Run("scales with the target share time", func(t *testing.T) {
+		slow := config
+		slow.TargetShareTime = 20 * time.Second
+		difficulty, _ := HardwareSeeder{}.Seed(Hints{UserAgent: "BlockDAG-X100"}, slow)
+		assert.InDelta(t, 70000, difficulty, 1e-6)
+	})
+
+	t.Run("nominal hashrate wins over the user agent", func(t *testing.T) {
+		difficulty, reason := HardwareSeeder{}.Seed(Hints{UserAgent: "cpuminer", Hashrate: 1e12}, config)
+		assert.InDelta(t, CalculateOptimalDifficulty(1e12, 10), difficulty, 1e-9)
+		assert.Contains(t, reason, "nominal hashrate")
+	})
+
+	t.Run("falls back to the initial difficulty", func(t *testing.T) {
+		difficulty, _ := HardwareSeeder{}.Seed(Hints{}, config)
+		assert.Equal(t, config.InitialDifficulty, difficulty)
+	})
+}
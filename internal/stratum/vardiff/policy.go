@@ -0,0 +1,440 @@
+package vardiff
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"time"
+)
+
+// =============================================================================
+// VARDIFF POLICIES
+// A policy bundles the bounds and timing (Config), how a connection is seeded,
+// how it is retargeted and how far mining.suggest_difficulty may move it.
+// Policies are assigned per listening port and per user; a user assignment
+// wins over the port's.
+// =============================================================================
+
+// Built-in policy names
+const (
+	PolicyShareWindow = "share-window"
+	PolicyEMA         = "ema"
+)
+
+// ErrUnknownPolicy is returned when a policy name is not registered
+var ErrUnknownPolicy = errors.New("unknown vardiff policy")
+
+// Retargeter decides a miner's next difficulty from its recent share intervals
+type Retargeter interface {
+	// Name identifies the algorithm in traces
+	Name() string
+
+	// Retarget is called once per retarget interval with the share window,
+	// oldest first. ok is false while there is too little data to decide;
+	// otherwise the result is traced even if the difficulty holds.
+	Retarget(difficulty float64, shareTimes []time.Duration, config Config) (result RetargetResult, ok bool)
+}
+
+// RetargetResult is the outcome of a retarget
+type RetargetResult struct {
+	Difficulty  float64       // Next difficulty (unchanged to hold)
+	ShareTime   time.Duration // Observed share time the decision was based on
+	Reason      string
+	ResetWindow bool // Drop the window; its intervals were measured at the old difficulty
+}
+
+// ShareWindowRetargeter waits for a full window of shares, takes a trimmed
+// median of the intervals and moves at most 15% per retarget, smoothed and
+// with a ±15% deadband. It is slow and stable, suited to ASIC farms.
+type ShareWindowRetargeter struct{}
+
+// Name implements Retargeter
+func (ShareWindowRetargeter) Name() string { return PolicyShareWindow }
+
+// Retarget implements Retargeter
+func (ShareWindowRetargeter) Retarget(difficulty float64, shareTimes []time.Duration, config Config) (RetargetResult, bool) {
+	if len(shareTimes) == 0 || len(shareTimes) < config.ShareWindow {
+		return RetargetResult{}, false
+	}
+
+	// Weighted median share time (more resistant to outliers than mean)
+	avgShareTime := weightedMedian(shareTimes)
+	result := RetargetResult{Difficulty: difficulty, ShareTime: avgShareTime}
+
+	targetTime := config.TargetShareTime
+
+	// Deadband: wider inner zone where no adjustment happens
+	// This prevents oscillation around the target
+	deadbandPercent := 15.0 // ±15% deadband
+	deadband := float64(targetTime) * (deadbandPercent / 100.0)
+	minDeadband := targetTime - time.Duration(deadband)
+	maxDeadband := targetTime + time.Duration(deadband)
+
+	// Don't adjust if within deadband (tighter than variance)
+	if avgShareTime >= minDeadband && avgShareTime <= maxDeadband {
+		result.Reason = fmt.Sprintf("median share time %s within ±%.0f%% of %s", avgShareTime, deadbandPercent, targetTime)
+		return result, true
+	}
+
+	// Calculate adjustment ratio
+	// If shares are coming too fast, increase difficulty
+	// If shares are coming too slow, decrease difficulty
+	ratio := float64(targetTime) / float64(avgShareTime)
+
+	// Apply tiered adjustment based on how far off we are
+	// Smaller adjustments when closer to target, larger when far off
+	deviation := float64(avgShareTime-targetTime) / float64(targetTime)
+	if deviation < 0 {
+		deviation = -deviation
+	}
+
+	// Max change scales with deviation: 10% base + up to 5% more if very far off
+	maxChange := 0.10 + (deviation * 0.05)
+	if maxChange > 0.15 {
+		maxChange = 0.15 // Cap at 15% max change per retarget
+	}
+
+	if ratio > 1.0+maxChange {
+		ratio = 1.0 + maxChange
+	} else if ratio < 1.0-maxChange {
+		ratio = 1.0 - maxChange
+	}
+
+	// Apply exponential smoothing - 40% weight to new ratio for stability
+	smoothingFactor := 0.4
+	ratio = (ratio * smoothingFactor) + (1.0 * (1.0 - smoothingFactor))
+
+	newDifficulty := config.clamp(difficulty * ratio)
+
+	// Additional stability: don't adjust if change would be less than 2%
+	changePercent := (newDifficulty - difficulty) / difficulty
+	if changePercent < 0 {
+		changePercent = -changePercent
+	}
+	if changePercent < 0.02 {
+		result.Reason = fmt.Sprintf("median share time %s, change under 2%%", avgShareTime)
+		return result, true
+	}
+
+	result.Difficulty = newDifficulty
+	result.Reason = fmt.Sprintf("median share time %s vs target %s", avgShareTime, targetTime)
+	return result, true
+}
+
+// weightedMedian returns the median of the share times with the top and
+// bottom 10% trimmed once there are enough samples
+func weightedMedian(shareTimes []time.Duration) time.Duration {
+	if len(shareTimes) == 1 {
+		return shareTimes[0]
+	}
+
+	sorted := make([]time.Duration, len(shareTimes))
+	copy(sorted, shareTimes)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	// Trim outliers: remove top and bottom 10% if we have enough samples
+	trimCount := len(sorted) / 10
+	if trimCount > 0 && len(sorted) > 10 {
+		sorted = sorted[trimCount : len(sorted)-trimCount]
+	}
+
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// EMARetargeter follows an exponential moving average of the share interval.
+// It decides after a few shares and may double or halve the difficulty per
+// retarget, so it converges quickly for CPU/GPU miners and new connections.
+type EMARetargeter struct {
+	Alpha     float64 // Weight of the newest interval (default 0.3)
+	MinShares int     // Intervals needed before the first decision (default 3)
+	MaxFactor float64 // Largest change per retarget (default 2.0)
+}
+
+// Name implements Retargeter
+func (EMARetargeter) Name() string { return PolicyEMA }
+
+// Retarget implements Retargeter
+func (r EMARetargeter) Retarget(difficulty float64, shareTimes []time.Duration, config Config) (RetargetResult, bool) {
+	alpha, minShares, maxFactor := r.Alpha, r.MinShares, r.MaxFactor
+	if alpha <= 0 || alpha > 1 {
+		alpha = 0.3
+	}
+	if minShares <= 0 {
+		minShares = 3
+	}
+	if maxFactor <= 1 {
+		maxFactor = 2.0
+	}
+	if len(shareTimes) < minShares {
+		return RetargetResult{}, false
+	}
+
+	ema := float64(shareTimes[0])
+	for _, t := range shareTimes[1:] {
+		ema = alpha*float64(t) + (1-alpha)*ema
+	}
+	shareTime := time.Duration(ema)
+	result := RetargetResult{Difficulty: difficulty, ShareTime: shareTime}
+	if shareTime <= 0 {
+		result.Reason = "no measurable share time"
+		return result, true
+	}
+
+	target := config.TargetShareTime
+	deviation := (ema - float64(target)) / float64(target) * 100
+	if deviation >= -config.VariancePercent && deviation <= config.VariancePercent {
+		result.Reason = fmt.Sprintf("EMA share time %s within ±%.0f%% of %s", shareTime, config.VariancePercent, target)
+		return result, true
+	}
+
+	ratio := float64(target) / ema
+	if ratio > maxFactor {
+		ratio = maxFactor
+	} else if ratio < 1/maxFactor {
+		ratio = 1 / maxFactor
+	}
+
+	newDifficulty := config.clamp(difficulty * ratio)
+	if newDifficulty == difficulty {
+		result.Reason = fmt.Sprintf("EMA share time %s, already at difficulty bound", shareTime)
+		return result, true
+	}
+
+	result.Difficulty = newDifficulty
+	result.Reason = fmt.Sprintf("EMA share time %s vs target %s", shareTime, target)
+	result.ResetWindow = true
+	return result, true
+}
+
+// SuggestRule bounds what mining.suggest_difficulty may set. Zero bounds fall
+// back to the policy's; suggestions are always kept within the policy's bounds.
+type SuggestRule struct {
+	Disabled      bool
+	MinDifficulty float64
+	MaxDifficulty float64
+}
+
+// apply returns the difficulty a suggestion resolves to
+func (r SuggestRule) apply(difficulty float64, config Config) float64 {
+	if r.MinDifficulty > 0 && difficulty < r.MinDifficulty {
+		difficulty = r.MinDifficulty
+	}
+	if r.MaxDifficulty > 0 && difficulty > r.MaxDifficulty {
+		difficulty = r.MaxDifficulty
+	}
+	return config.clamp(difficulty)
+}
+
+// Policy is a complete vardiff behaviour that can be assigned to a port or user
+type Policy struct {
+	Name       string
+	Config     Config
+	Seeder     Seeder
+	Retargeter Retargeter
+	Suggest    SuggestRule
+}
+
+// NewShareWindowPolicy creates the share-window policy with static seeding
+func NewShareWindowPolicy(config Config) *Policy {
+	return &Policy{
+		Name:       PolicyShareWindow,
+		Config:     config,
+		Seeder:     StaticSeeder{},
+		Retargeter: ShareWindowRetargeter{},
+	}
+}
+
+// NewEMAPolicy creates the EMA policy with static seeding
+func NewEMAPolicy(config Config) *Policy {
+	return &Policy{
+		Name:       PolicyEMA,
+		Config:     config,
+		Seeder:     StaticSeeder{},
+		Retargeter: EMARetargeter{},
+	}
+}
+
+// Validate checks the policy is usable
+func (p *Policy) Validate() error {
+	if p.Name == "" {
+		return errors.New("policy name is required")
+	}
+	if p.Seeder == nil || p.Retargeter == nil {
+		return fmt.Errorf("policy %s: seeder and retargeter are required", p.Name)
+	}
+	if err := p.Config.Validate(); err != nil {
+		return fmt.Errorf("policy %s: %w", p.Name, err)
+	}
+	return nil
+}
+
+// PolicySet holds named policies and which ports and users use them
+type PolicySet struct {
+	policies      map[string]*Policy
+	defaultPolicy string
+	ports         map[string]string // port -> policy name
+	users         map[string]string // username -> policy name
+	mu            sync.RWMutex
+}
+
+// NewPolicySet creates a set whose default is the given policy
+func NewPolicySet(defaultPolicy *Policy) *PolicySet {
+	return &PolicySet{
+		policies:      map[string]*Policy{defaultPolicy.Name: defaultPolicy},
+		defaultPolicy: defaultPolicy.Name,
+		ports:         make(map[string]string),
+		users:         make(map[string]string),
+	}
+}
+
+// Add registers a policy, replacing one with the same name
+func (ps *PolicySet) Add(policy *Policy) error {
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.policies[policy.Name] = policy
+	return nil
+}
+
+// Get returns a policy by name
+func (ps *PolicySet) Get(name string) (*Policy, bool) {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+	policy, ok := ps.policies[name]
+	return policy, ok
+}
+
+// Default returns the policy used when no port or user assignment applies
+func (ps *PolicySet) Default() *Policy {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+	return ps.policies[ps.defaultPolicy]
+}
+
+// SetDefault makes a registered policy the default
+func (ps *PolicySet) SetDefault(name string) error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	if _, ok := ps.policies[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownPolicy, name)
+	}
+	ps.defaultPolicy = name
+	return nil
+}
+
+// AssignPort uses a policy for connections accepted on a port
+func (ps *PolicySet) AssignPort(port, name string) error {
+	return ps.assign(ps.ports, port, name)
+}
+
+// AssignUser uses a policy for a user's workers, wherever they connect
+func (ps *PolicySet) AssignUser(username, name string) error {
+	return ps.assign(ps.users, username, name)
+}
+
+// DifficultyBounds overrides a policy's starting and allowed difficulty for
+// one port; zero fields keep the policy's values
+type DifficultyBounds struct {
+	Initial float64
+	Min     float64
+	Max     float64
+}
+
+// IsZero reports whether the bounds override nothing
+func (b DifficultyBounds) IsZero() bool {
+	return b.Initial == 0 && b.Min == 0 && b.Max == 0
+}
+
+// AssignPortBounds assigns a port a policy with its own difficulty bounds.
+// An empty name keeps the port's current policy. The copy is registered as
+// "<policy>@<port>"; a starting difficulty set here replaces its seeder.
+func (ps *PolicySet) AssignPortBounds(port, name string, bounds DifficultyBounds) error {
+	base := ps.Select(port, "")
+	if name != "" {
+		var ok bool
+		if base, ok = ps.Get(name); !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownPolicy, name)
+		}
+	}
+	if bounds.IsZero() {
+		if name == "" {
+			return nil
+		}
+		return ps.AssignPort(port, base.Name)
+	}
+
+	derived := *base
+	derived.Name = base.Name + "@" + port
+	if bounds.Min > 0 {
+		derived.Config.MinDifficulty = bounds.Min
+	}
+	if bounds.Max > 0 {
+		derived.Config.MaxDifficulty = bounds.Max
+	}
+	if bounds.Initial > 0 {
+		derived.Config.InitialDifficulty = bounds.Initial
+		derived.Seeder = StaticSeeder{}
+	}
+	derived.Config.InitialDifficulty = derived.Config.clamp(derived.Config.InitialDifficulty)
+
+	if err := ps.Add(&derived); err != nil {
+		return err
+	}
+	return ps.AssignPort(port, derived.Name)
+}
+
+func (ps *PolicySet) assign(assignments map[string]string, key, name string) error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	if _, ok := ps.policies[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownPolicy, name)
+	}
+	assignments[key] = name
+	return nil
+}
+
+// Select returns the policy for a connection: the user's, else the port's,
+// else the default. Either key may be empty.
+func (ps *PolicySet) Select(port, username string) *Policy {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+	if name, ok := ps.users[username]; ok && username != "" {
+		return ps.policies[name]
+	}
+	if name, ok := ps.ports[port]; ok && port != "" {
+		return ps.policies[name]
+	}
+	return ps.policies[ps.defaultPolicy]
+}
+
+// Names returns the registered policy names, sorted
+func (ps *PolicySet) Names() []string {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+	names := make([]string, 0, len(ps.policies))
+	for name := range ps.policies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// PortOf returns the port of a listener address, the key for port policies
+func PortOf(addr net.Addr) string {
+	if addr == nil {
+		return ""
+	}
+	_, port, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return ""
+	}
+	return port
+}
@@ -0,0 +1,318 @@
+package vardiff
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock is a controllable time source for retarget intervals
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+// newTestManager creates a manager on a fake clock with share-window as the
+// default policy and EMA registered alongside it
+func newTestManager(t *testing.T) (*Manager, *fakeClock) {
+	config := DefaultConfig()
+	config.InitialDifficulty = 1.0
+	config.RetargetInterval = 30 * time.Second
+
+	policies := NewPolicySet(NewShareWindowPolicy(config))
+	require.NoError(t, policies.Add(NewEMAPolicy(config)))
+
+	clock := &fakeClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	manager := NewManagerWithPolicies(policies)
+	manager.now = clock.Now
+	return manager, clock
+}
+
+func TestPolicySet_Select(t *testing.T) {
+	policies := NewPolicySet(NewShareWindowPolicy(DefaultConfig()))
+	require.NoError(t, policies.Add(NewEMAPolicy(DefaultConfig())))
+	fixed := NewShareWindowPolicy(LowLatencyConfig())
+	fixed.Name = "low-latency"
+	require.NoError(t, policies.Add(fixed))
+
+	require.NoError(t, policies.AssignPort("3334", PolicyEMA))
+	require.NoError(t, policies.AssignUser("alice", "low-latency"))
+
+	assert.Equal(t, PolicyShareWindow, policies.Select("3333", "bob").Name)
+	assert.Equal(t, PolicyEMA, policies.Select("3334", "bob").Name)
+	assert.Equal(t, "low-latency", policies.Select("3334", "alice").Name, "user wins over port")
+	assert.Equal(t, "low-latency", policies.Select("", "alice").Name)
+
+	assert.ErrorIs(t, policies.AssignUser("carol", "turbo"), ErrUnknownPolicy)
+	assert.ErrorIs(t, policies.SetDefault("turbo"), ErrUnknownPolicy)
+	assert.Equal(t, []string{PolicyEMA, "low-latency", PolicyShareWindow}, policies.Names())
+}
+
+func TestPolicySet_AssignPortBounds(t *testing.T) {
+	base := NewEMAPolicy(X100OptimizedConfig())
+	base.Seeder = HardwareSeeder{}
+	policies := NewPolicySet(base)
+
+	// Low-end rigs: a fixed low start within tight bounds
+	require.NoError(t, policies.AssignPortBounds("3333", "", DifficultyBounds{Initial: 0.01, Min: 0.001, Max: 64}))
+	lowEnd := policies.Select("3333", "")
+	assert.Equal(t, "ema@3333", lowEnd.Name)
+	assert.Equal(t, 0.001, lowEnd.Config.MinDifficulty)
+	assert.Equal(t, 64.0, lowEnd.Config.MaxDifficulty)
+	assert.Equal(t, 0.01, lowEnd.Config.InitialDifficulty)
+	assert.Equal(t, StaticSeeder{}, lowEnd.Seeder)
+
+	// Only a floor: the start is clamped and hardware seeding is kept
+	require.NoError(t, policies.AssignPortBounds("3334", PolicyEMA, DifficultyBounds{Min: 50000}))
+	x100 := policies.Select("3334", "")
+	assert.Equal(t, 50000.0, x100.Config.InitialDifficulty)
+	assert.Equal(t, HardwareSeeder{}, x100.Seeder)
+
+	// No overrides assigns the named policy itself, or keeps the port's
+	require.NoError(t, policies.AssignPortBounds("3335", PolicyEMA, DifficultyBounds{}))
+	assert.Same(t, base, policies.Select("3335", ""))
+	require.NoError(t, policies.AssignPortBounds("3333", "", DifficultyBounds{}))
+	assert.Same(t, lowEnd, policies.Select("3333", ""))
+
+	assert.ErrorIs(t, policies.AssignPortBounds("3336", "turbo", DifficultyBounds{}), ErrUnknownPolicy)
+	assert.Error(t, policies.AssignPortBounds("3337", "", DifficultyBounds{Min: 10, Max: 1}))
+	assert.Equal(t, X100OptimizedConfig().MinDifficulty, base.Config.MinDifficulty, "base policy untouched")
+}
+
+func TestPolicy_Validate(t *testing.T) {
+	assert.NoError(t, NewEMAPolicy(DefaultConfig()).Validate())
+
+	missing := NewEMAPolicy(DefaultConfig())
+	missing.Retargeter = nil
+	assert.Error(t, missing.Validate())
+
+	bad := NewEMAPolicy(Config{})
+	assert.Error(t, bad.Validate())
+}
+
+func TestEMARetargeter(t *testing.T) {
+	config := DefaultConfig()
+	config.VariancePercent = 20
+
+	t.Run("waits for enough shares", func(t *testing.T) {
+		_, ok := EMARetargeter{}.Retarget(1, []time.Duration{time.Second, time.Second}, config)
+		assert.False(t, ok)
+	})
+
+	t.Run("doubles at most when shares are far too fast", func(t *testing.T) {
+		result, ok := EMARetargeter{}.Retarget(1, []time.Duration{time.Second, time.Second, time.Second}, config)
+		require.True(t, ok)
+		assert.Equal(t, 2.0, result.Difficulty)
+		assert.True(t, result.ResetWindow)
+	})
+
+	t.Run("follows recent shares", func(t *testing.T) {
+		times := []time.Duration{40 * time.Second, 40 * time.Second, 10 * time.Second, 10 * time.Second, 10 * time.Second}
+		result, ok := EMARetargeter{Alpha: 0.5}.Retarget(1, times, config)
+		require.True(t, ok)
+		assert.InDelta(t, 13.75, result.ShareTime.Seconds(), 1e-9)
+		assert.InDelta(t, 10/13.75, result.Difficulty, 1e-9)
+	})
+
+	t.Run("holds within variance", func(t *testing.T) {
+		result, ok := EMARetargeter{}.Retarget(1, []time.Duration{11 * time.Second, 9 * time.Second, 10 * time.Second}, config)
+		require.True(t, ok)
+		assert.Equal(t, 1.0, result.Difficulty)
+		assert.Contains(t, result.Reason, "within")
+	})
+}
+
+func TestManager_AttachSelectsPortPolicy(t *testing.T) {
+	manager, _ := newTestManager(t)
+	require.NoError(t, manager.Policies().AssignPort("3334", PolicyEMA))
+
+	manager.Attach("a", "3333", Hints{})
+	manager.Attach("b", "3334", Hints{})
+
+	assert.Equal(t, PolicyShareWindow, manager.Trace("a")[0].Policy)
+	assert.Equal(t, PolicyEMA, manager.Trace("b")[0].Policy)
+}
+
+func TestManager_SetWorkerAppliesUserPolicy(t *testing.T) {
+	manager, _ := newTestManager(t)
+	tight := NewEMAPolicy(DefaultConfig())
+	tight.Name = "tight"
+	tight.Config.MaxDifficulty = 0.5
+	require.NoError(t, manager.Policies().Add(tight))
+	require.NoError(t, manager.Policies().AssignUser("alice", "tight"))
+
+	manager.Attach("miner-1", "3333", Hints{})
+	difficulty, changed := manager.SetWorker("miner-1", "alice", "alice.rig1")
+	assert.True(t, changed)
+	assert.Equal(t, 0.5, difficulty, "clamped to the new policy's bounds")
+
+	trace := manager.Trace("miner-1")
+	require.Len(t, trace, 2)
+	assert.Equal(t, DecisionPolicy, trace[1].Kind)
+	assert.Equal(t, "tight", trace[1].Policy)
+	assert.Equal(t, "alice.rig1", trace[1].Worker)
+
+	// Users without an assignment keep the port's policy
+	manager.Attach("miner-2", "3333", Hints{})
+	_, changed = manager.SetWorker("miner-2", "bob", "bob.rig1")
+	assert.False(t, changed)
+	assert.Len(t, manager.Trace("miner-2"), 1)
+}
+
+func TestManager_Suggest(t *testing.T) {
+	manager, _ := newTestManager(t)
+	policy := manager.Policies().Default()
+	policy.Suggest = SuggestRule{MinDifficulty: 0.5, MaxDifficulty: 500}
+
+	manager.Attach("miner-1", "", Hints{})
+
+	difficulty, ok := manager.Suggest("miner-1", 64)
+	require.True(t, ok)
+	assert.Equal(t, 64.0, difficulty)
+
+	difficulty, ok = manager.Suggest("miner-1", 1e9)
+	require.True(t, ok)
+	assert.Equal(t, 500.0, difficulty)
+
+	trace := manager.Trace("miner-1")
+	require.Len(t, trace, 3)
+	assert.Equal(t, DecisionSuggest, trace[2].Kind)
+	assert.Equal(t, "suggestion clamped to policy bounds", trace[2].Reason)
+
+	policy.Suggest.Disabled = true
+	difficulty, ok = manager.Suggest("miner-1", 1)
+	assert.False(t, ok)
+	assert.Equal(t, 500.0, difficulty)
+}
+
+func TestManager_RecordShareAt(t *testing.T) {
+	manager, clock := newTestManager(t)
+	require.NoError(t, manager.Policies().AssignPort("3334", PolicyEMA))
+	manager.Attach("miner-1", "3334", Hints{})
+
+	// First share only starts the clock, then three shares 2s apart
+	at := clock.now
+	manager.RecordShareAt("miner-1", at)
+	for i := 0; i < 3; i++ {
+		at = at.Add(2 * time.Second)
+		_, changed := manager.RecordShareAt("miner-1", at)
+		assert.False(t, changed, "retarget interval has not passed")
+	}
+
+	// The retarget interval is measured on the manager's clock, not share times
+	clock.Advance(30 * time.Second)
+	difficulty, changed := manager.RecordShareAt("miner-1", at.Add(2*time.Second))
+	assert.True(t, changed)
+	assert.Equal(t, 2.0, difficulty)
+
+	_, shares, _ := manager.GetMinerStats("miner-1")
+	assert.Equal(t, int64(5), shares)
+
+	retarget := manager.Trace("miner-1")[1]
+	assert.Equal(t, DecisionRetarget, retarget.Kind)
+	assert.Equal(t, 4, retarget.Shares)
+	assert.Equal(t, 1.0, retarget.OldDifficulty)
+	assert.Equal(t, 2.0, retarget.NewDifficulty)
+}
+
+func TestManager_ObserverAndHeldRetargets(t *testing.T) {
+	manager, clock := newTestManager(t)
+	var observed []Decision
+	manager.SetObserver(func(d Decision) { observed = append(observed, d) })
+
+	manager.Attach("miner-1", "", Hints{})
+	for i := 0; i < 5; i++ {
+		clock.Advance(10 * time.Second)
+		manager.RecordShare("miner-1", 10*time.Second)
+	}
+
+	// Seed, then one held retarget once the window filled and 30s had passed
+	require.Len(t, observed, 2)
+	assert.Equal(t, DecisionSeed, observed[0].Kind)
+	assert.Equal(t, DecisionRetarget, observed[1].Kind)
+	assert.False(t, observed[1].Changed())
+	assert.Contains(t, observed[1].Reason, "within")
+	assert.Equal(t, observed, manager.Trace("miner-1"))
+}
+
+func TestManager_TraceIsBounded(t *testing.T) {
+	manager, _ := newTestManager(t)
+	manager.Attach("miner-1", "", Hints{})
+	for i := 1; i <= DefaultTraceSize+10; i++ {
+		manager.SetDifficulty("miner-1", float64(i))
+	}
+
+	trace := manager.Trace("miner-1")
+	require.Len(t, trace, DefaultTraceSize)
+	assert.Equal(t, 11.0, trace[0].NewDifficulty, "oldest decisions are dropped first")
+	assert.Equal(t, float64(DefaultTraceSize+10), trace[len(trace)-1].NewDifficulty)
+	assert.Nil(t, manager.Trace("unknown"))
+}
+
+func TestPortOf(t *testing.T) {
+	assert.Equal(t, "3333", PortOf(&net.TCPAddr{IP: net.IPv4zero, Port: 3333}))
+	assert.Equal(t, "", PortOf(nil))
+}
+
+func TestManager_ParkAndResume(t *testing.T) {
+	manager, clock := newTestManager(t)
+	manager.Attach("conn-1", "3333", Hints{})
+	require.NoError(t, manager.SetDifficulty("conn-1", 64))
+	manager.RecordShareAt("conn-1", clock.now)
+
+	require.True(t, manager.Park("conn-1", "session:abc"))
+	assert.False(t, manager.Park("conn-1", "session:abc"), "already parked")
+	_, _, exists := manager.GetMinerStats("conn-1")
+	assert.False(t, exists)
+
+	// The reconnect attaches fresh state, then resumes the parked one
+	clock.Advance(time.Minute)
+	manager.Attach("conn-2", "3333", Hints{})
+	difficulty, ok := manager.Resume("session:abc", "conn-2")
+	require.True(t, ok)
+	assert.Equal(t, 64.0, difficulty)
+	assert.Equal(t, 64.0, manager.GetDifficulty("conn-2"))
+
+	trace := manager.Trace("conn-2")
+	last := trace[len(trace)-1]
+	assert.Equal(t, DecisionResume, last.Kind)
+	assert.Equal(t, 1.0, last.OldDifficulty)
+
+	// The minute offline is not a share interval
+	manager.RecordShareAt("conn-2", clock.now)
+	_, total, _ := manager.GetMinerStats("conn-2")
+	assert.Equal(t, int64(2), total)
+	assert.Zero(t, manager.EstimatedHashrate())
+
+	_, ok = manager.Resume("session:abc", "conn-3")
+	assert.False(t, ok)
+}
+
+func TestManager_SetMinimum(t *testing.T) {
+	manager, _ := newTestManager(t)
+	manager.Attach("conn-1", "3333", Hints{})
+
+	difficulty, changed := manager.SetMinimum("conn-1", 16)
+	assert.True(t, changed)
+	assert.Equal(t, 16.0, difficulty)
+	assert.Equal(t, DecisionMinimum, manager.Trace("conn-1")[1].Kind)
+
+	// Nothing goes below the floor
+	require.NoError(t, manager.SetDifficulty("conn-1", 2))
+	assert.Equal(t, 16.0, manager.GetDifficulty("conn-1"))
+	difficulty, _ = manager.Suggest("conn-1", 4)
+	assert.Equal(t, 16.0, difficulty)
+	difficulty, _ = manager.Suggest("conn-1", 32)
+	assert.Equal(t, 32.0, difficulty)
+
+	// The floor stays within the policy's bounds
+	max := manager.GetConfig().MaxDifficulty
+	difficulty, _ = manager.SetMinimum("conn-1", max*2)
+	assert.Equal(t, max, difficulty)
+}
@@ -0,0 +1,78 @@
+package vardiff
+
+import (
+	"time"
+)
+
+// =============================================================================
+// RETARGET TRACE
+// Every difficulty decision is kept per miner so operators can see why a
+// worker ended up at its difficulty, including retargets that held steady.
+// =============================================================================
+
+// DefaultTraceSize is the number of decisions kept per miner
+const DefaultTraceSize = 64
+
+// DecisionKind says what triggered a difficulty decision
+type DecisionKind string
+
+const (
+	DecisionSeed     DecisionKind = "seed"     // Initial difficulty from the policy's seeder
+	DecisionRetarget DecisionKind = "retarget" // Periodic retarget from share timing
+	DecisionSuggest  DecisionKind = "suggest"  // mining.suggest_difficulty from the miner
+	DecisionManual   DecisionKind = "manual"   // Set directly by the server
+	DecisionPolicy   DecisionKind = "policy"   // Worker moved to another policy
+	DecisionResume   DecisionKind = "resume"   // Reconnected miner resumed its session
+	DecisionMinimum  DecisionKind = "minimum"  // Miner negotiated a minimum difficulty
+)
+
+// Decision records one difficulty decision for a miner
+type Decision struct {
+	Time          time.Time    `json:"time"`
+	MinerID       string       `json:"miner_id"`
+	Worker        string       `json:"worker,omitempty"`
+	Policy        string       `json:"policy"`
+	Kind          DecisionKind `json:"kind"`
+	OldDifficulty float64      `json:"old_difficulty"`
+	NewDifficulty float64      `json:"new_difficulty"`
+	Shares        int          `json:"shares,omitempty"`             // Share intervals considered
+	ShareTime     float64      `json:"share_time_seconds,omitempty"` // Observed share time the retarget used
+	Reason        string       `json:"reason"`
+}
+
+// Changed reports whether the decision moved the difficulty
+func (d Decision) Changed() bool {
+	return d.NewDifficulty != d.OldDifficulty
+}
+
+// trace is a fixed-size ring of decisions
+type trace struct {
+	decisions []Decision
+	next      int
+	full      bool
+}
+
+func newTrace(size int) *trace {
+	return &trace{decisions: make([]Decision, size)}
+}
+
+func (t *trace) add(d Decision) {
+	if len(t.decisions) == 0 {
+		return
+	}
+	t.decisions[t.next] = d
+	t.next = (t.next + 1) % len(t.decisions)
+	if t.next == 0 {
+		t.full = true
+	}
+}
+
+// list returns the decisions oldest first
+func (t *trace) list() []Decision {
+	if !t.full {
+		return append([]Decision(nil), t.decisions[:t.next]...)
+	}
+	out := make([]Decision, 0, len(t.decisions))
+	out = append(out, t.decisions[t.next:]...)
+	return append(out, t.decisions[:t.next]...)
+}
@@ -3,6 +3,7 @@ package vardiff
 
 import (
 	"errors"
+	"fmt"
 	"sync"
 	"time"
 )
@@ -54,208 +55,348 @@ func (c Config) Validate() error {
 	return nil
 }
 
+// clamp keeps a difficulty within the configured bounds
+func (c Config) clamp(difficulty float64) float64 {
+	if difficulty < c.MinDifficulty {
+		return c.MinDifficulty
+	}
+	if difficulty > c.MaxDifficulty {
+		return c.MaxDifficulty
+	}
+	return difficulty
+}
+
 // minerState holds per-miner difficulty state
 type minerState struct {
+	policy        *Policy
+	port          string
+	worker        string
 	difficulty    float64
 	shareTimes    []time.Duration
 	lastShareTime time.Time
 	lastRetarget  time.Time
 	totalShares   int64
+	minimum       float64 // Floor negotiated by the miner (mining.configure)
+	trace         *trace
 }
 
-// Manager implements variable difficulty management
+// clamp bounds a difficulty by the miner's policy and negotiated minimum
+func (s *minerState) clamp(difficulty float64) float64 {
+	difficulty = s.policy.Config.clamp(difficulty)
+	if difficulty < s.minimum {
+		difficulty = s.policy.Config.clamp(s.minimum)
+	}
+	return difficulty
+}
+
+// Manager is the pool's variable difficulty engine. Each miner runs under a
+// Policy chosen by port and user, and every decision is kept in its trace.
 type Manager struct {
-	config Config
-	miners map[string]*minerState
-	mu     sync.RWMutex
+	policies *PolicySet
+	miners   map[string]*minerState
+	observer func(Decision)
+	now      func() time.Time
+	mu       sync.RWMutex
 }
 
-// NewManager creates a new vardiff manager
+// NewManager creates a manager that runs every miner under the share-window policy
 func NewManager(config Config) *Manager {
-	return &Manager{
-		config: config,
-		miners: make(map[string]*minerState),
-	}
+	return NewManagerWithPolicies(NewPolicySet(NewShareWindowPolicy(config)))
 }
 
-// GetDifficulty returns the current difficulty for a miner
-func (m *Manager) GetDifficulty(minerID string) float64 {
-	m.mu.RLock()
-	state, exists := m.miners[minerID]
-	m.mu.RUnlock()
-
-	if !exists {
-		return m.config.InitialDifficulty
+// NewManagerWithPolicies creates a manager that selects miners' policies from a set
+func NewManagerWithPolicies(policies *PolicySet) *Manager {
+	return &Manager{
+		policies: policies,
+		miners:   make(map[string]*minerState),
+		now:      time.Now,
 	}
-	return state.difficulty
 }
 
-// SetDifficulty sets a specific difficulty for a miner (clamped to bounds)
-func (m *Manager) SetDifficulty(minerID string, difficulty float64) error {
-	// Clamp to bounds
-	if difficulty < m.config.MinDifficulty {
-		difficulty = m.config.MinDifficulty
-	}
-	if difficulty > m.config.MaxDifficulty {
-		difficulty = m.config.MaxDifficulty
-	}
-
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	state, exists := m.miners[minerID]
-	if !exists {
-		state = &minerState{
-			shareTimes: make([]time.Duration, 0, m.config.ShareWindow),
-		}
-		m.miners[minerID] = state
-	}
-	state.difficulty = difficulty
-	return nil
+// Policies returns the manager's policy set, for assigning ports and users
+func (m *Manager) Policies() *PolicySet {
+	return m.policies
 }
 
-// RecordShare records a share submission and potentially adjusts difficulty
-func (m *Manager) RecordShare(minerID string, shareTime time.Duration) {
+// SetObserver registers a callback for every decision, e.g. to persist traces
+// beyond the connection. It is called without the manager's lock held.
+func (m *Manager) SetObserver(observer func(Decision)) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	m.observer = observer
+}
 
-	state, exists := m.miners[minerID]
-	if !exists {
-		state = &minerState{
-			difficulty:   m.config.InitialDifficulty,
-			shareTimes:   make([]time.Duration, 0, m.config.ShareWindow),
-			lastRetarget: time.Now(),
-		}
-		m.miners[minerID] = state
+// newState creates a miner's state under a policy (caller holds the lock)
+func (m *Manager) newState(minerID, port string, policy *Policy) *minerState {
+	state := &minerState{
+		policy:     policy,
+		port:       port,
+		difficulty: policy.Config.InitialDifficulty,
+		shareTimes: make([]time.Duration, 0, policy.Config.ShareWindow),
+		trace:      newTrace(DefaultTraceSize),
 	}
+	m.miners[minerID] = state
+	return state
+}
 
-	// Add share time to window
-	state.shareTimes = append(state.shareTimes, shareTime)
-	if len(state.shareTimes) > m.config.ShareWindow {
-		state.shareTimes = state.shareTimes[1:]
+// stateLocked returns a miner's state, creating it under the default policy
+func (m *Manager) stateLocked(minerID string) *minerState {
+	if state, exists := m.miners[minerID]; exists {
+		return state
 	}
-	state.lastShareTime = time.Now()
-	state.totalShares++
+	return m.newState(minerID, "", m.policies.Default())
+}
 
-	// Check if we should retarget
-	if time.Since(state.lastRetarget) >= m.config.RetargetInterval && len(state.shareTimes) >= m.config.ShareWindow {
-		m.adjustDifficulty(state)
-		state.lastRetarget = time.Now()
-	}
+// record completes a decision with the miner's current state and adds it to
+// the miner's trace (caller holds the lock)
+func (m *Manager) record(minerID string, state *minerState, d Decision) Decision {
+	d.Time = m.now()
+	d.MinerID = minerID
+	d.Worker = state.worker
+	d.Policy = state.policy.Name
+	d.NewDifficulty = state.difficulty
+	state.trace.add(d)
+	return d
 }
 
-// adjustDifficulty calculates and applies new difficulty based on share times
-// Uses a deadband approach with weighted median for outlier resistance
-func (m *Manager) adjustDifficulty(state *minerState) {
-	if len(state.shareTimes) == 0 {
+// notify passes decisions to the observer (caller must not hold the lock)
+func (m *Manager) notify(decisions ...Decision) {
+	m.mu.RLock()
+	observer := m.observer
+	m.mu.RUnlock()
+	if observer == nil {
 		return
 	}
+	for _, d := range decisions {
+		observer(d)
+	}
+}
+
+// Attach starts tracking a connection accepted on a port and returns its
+// seeded difficulty. Hints may be empty; Seed can refine them later.
+func (m *Manager) Attach(minerID, port string, hints Hints) float64 {
+	m.mu.Lock()
+	state := m.newState(minerID, port, m.policies.Select(port, ""))
+	state.lastRetarget = m.now()
+	d := m.seedLocked(minerID, state, hints)
+	m.mu.Unlock()
+
+	m.notify(d)
+	return state.difficulty
+}
 
-	// Calculate weighted median share time (more resistant to outliers than mean)
-	avgShareTime := m.calculateWeightedMedian(state.shareTimes)
+// Seed re-seeds a miner's difficulty from new hints (e.g. the user agent in
+// mining.subscribe) and returns it
+func (m *Manager) Seed(minerID string, hints Hints) float64 {
+	m.mu.Lock()
+	state := m.stateLocked(minerID)
+	d := m.seedLocked(minerID, state, hints)
+	difficulty := state.difficulty
+	m.mu.Unlock()
 
-	targetTime := m.config.TargetShareTime
+	m.notify(d)
+	return difficulty
+}
 
-	// Deadband: wider inner zone where no adjustment happens
-	// This prevents oscillation around the target
-	deadbandPercent := 15.0 // ±15% deadband
-	deadband := float64(targetTime) * (deadbandPercent / 100.0)
-	minDeadband := targetTime - time.Duration(deadband)
-	maxDeadband := targetTime + time.Duration(deadband)
+func (m *Manager) seedLocked(minerID string, state *minerState, hints Hints) Decision {
+	old := state.difficulty
+	difficulty, reason := state.policy.Seeder.Seed(hints, state.policy.Config)
+	state.difficulty = state.clamp(difficulty)
+	return m.record(minerID, state, Decision{Kind: DecisionSeed, OldDifficulty: old, Reason: reason})
+}
 
-	// Don't adjust if within deadband (tighter than variance)
-	if avgShareTime >= minDeadband && avgShareTime <= maxDeadband {
-		return
+// SetWorker labels a miner with the user and worker it authorized as and
+// moves it to the user's policy if one is assigned. It returns the miner's
+// difficulty and whether it changed.
+func (m *Manager) SetWorker(minerID, username, worker string) (float64, bool) {
+	m.mu.Lock()
+	state := m.stateLocked(minerID)
+	state.worker = worker
+	policy := m.policies.Select(state.port, username)
+	if policy == state.policy {
+		m.mu.Unlock()
+		return state.difficulty, false
 	}
 
-	// Calculate adjustment ratio
-	// If shares are coming too fast, increase difficulty
-	// If shares are coming too slow, decrease difficulty
-	ratio := float64(targetTime) / float64(avgShareTime)
+	old := state.difficulty
+	state.policy = policy
+	state.difficulty = state.clamp(state.difficulty)
+	d := m.record(minerID, state, Decision{
+		Kind:          DecisionPolicy,
+		OldDifficulty: old,
+		Reason:        "policy assigned to user " + username,
+	})
+	m.mu.Unlock()
+
+	m.notify(d)
+	return d.NewDifficulty, d.Changed()
+}
 
-	// Apply tiered adjustment based on how far off we are
-	// Smaller adjustments when closer to target, larger when far off
-	deviation := float64(avgShareTime-targetTime) / float64(targetTime)
-	if deviation < 0 {
-		deviation = -deviation
+// Suggest applies a miner's mining.suggest_difficulty within its policy's
+// bounds. It returns the resulting difficulty, or false if the policy
+// ignores suggestions.
+func (m *Manager) Suggest(minerID string, difficulty float64) (float64, bool) {
+	m.mu.Lock()
+	state := m.stateLocked(minerID)
+	if state.policy.Suggest.Disabled {
+		current := state.difficulty
+		m.mu.Unlock()
+		return current, false
 	}
 
-	// Max change scales with deviation: 10% base + up to 5% more if very far off
-	maxChange := 0.10 + (deviation * 0.05)
-	if maxChange > 0.15 {
-		maxChange = 0.15 // Cap at 15% max change per retarget
+	old := state.difficulty
+	state.difficulty = state.clamp(state.policy.Suggest.apply(difficulty, state.policy.Config))
+	reason := "suggested by miner"
+	if state.difficulty != difficulty {
+		reason = "suggestion clamped to policy bounds"
 	}
+	d := m.record(minerID, state, Decision{Kind: DecisionSuggest, OldDifficulty: old, Reason: reason})
+	m.mu.Unlock()
 
-	if ratio > 1.0+maxChange {
-		ratio = 1.0 + maxChange
-	} else if ratio < 1.0-maxChange {
-		ratio = 1.0 - maxChange
-	}
+	m.notify(d)
+	return d.NewDifficulty, true
+}
 
-	// Apply exponential smoothing - 40% weight to new ratio for stability
-	smoothingFactor := 0.4
-	ratio = (ratio * smoothingFactor) + (1.0 * (1.0 - smoothingFactor))
+// SetMinimum sets the lowest difficulty a miner accepts (BIP310
+// minimum-difficulty); retargets and suggestions never go below it. The
+// floor is kept within the policy's bounds. It returns the miner's
+// difficulty and whether it changed.
+func (m *Manager) SetMinimum(minerID string, minimum float64) (float64, bool) {
+	m.mu.Lock()
+	state := m.stateLocked(minerID)
+	state.minimum = minimum
+	old := state.difficulty
+	state.difficulty = state.clamp(state.difficulty)
+	d := m.record(minerID, state, Decision{
+		Kind:          DecisionMinimum,
+		OldDifficulty: old,
+		Reason:        fmt.Sprintf("minimum difficulty %g requested by miner", minimum),
+	})
+	m.mu.Unlock()
+
+	m.notify(d)
+	return d.NewDifficulty, d.Changed()
+}
 
-	newDifficulty := state.difficulty * ratio
+// GetDifficulty returns the current difficulty for a miner
+func (m *Manager) GetDifficulty(minerID string) float64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 
-	// Clamp to bounds
-	if newDifficulty < m.config.MinDifficulty {
-		newDifficulty = m.config.MinDifficulty
+	state, exists := m.miners[minerID]
+	if !exists {
+		return m.policies.Default().Config.InitialDifficulty
 	}
-	if newDifficulty > m.config.MaxDifficulty {
-		newDifficulty = m.config.MaxDifficulty
+	return state.difficulty
+}
+
+// SetDifficulty sets a specific difficulty for a miner (clamped to bounds)
+func (m *Manager) SetDifficulty(minerID string, difficulty float64) error {
+	m.mu.Lock()
+	state := m.stateLocked(minerID)
+	old := state.difficulty
+	state.difficulty = state.clamp(difficulty)
+	d := m.record(minerID, state, Decision{Kind: DecisionManual, OldDifficulty: old, Reason: "set by server"})
+	m.mu.Unlock()
+
+	m.notify(d)
+	return nil
+}
+
+// RecordShare records a share submitted shareTime after the previous one and
+// retargets when the policy's interval has passed. It returns the miner's
+// difficulty and whether this share changed it.
+func (m *Manager) RecordShare(minerID string, shareTime time.Duration) (float64, bool) {
+	m.mu.Lock()
+	state, exists := m.miners[minerID]
+	if !exists {
+		state = m.stateLocked(minerID)
+		state.lastRetarget = m.now()
 	}
+	m.addShareTime(state, shareTime)
+	state.lastShareTime = m.now()
+	return m.retargetAndUnlock(minerID, state)
+}
 
-	// Additional stability: don't adjust if change would be less than 2%
-	changePercent := (newDifficulty - state.difficulty) / state.difficulty
-	if changePercent < 0 {
-		changePercent = -changePercent
+// RecordShareAt records a share accepted at a given time, measuring the
+// interval from the miner's previous share. The first share only starts the clock.
+func (m *Manager) RecordShareAt(minerID string, at time.Time) (float64, bool) {
+	m.mu.Lock()
+	state, exists := m.miners[minerID]
+	if !exists {
+		state = m.stateLocked(minerID)
+		state.lastRetarget = m.now()
 	}
-	if changePercent < 0.02 {
-		return
+	if !state.lastShareTime.IsZero() {
+		m.addShareTime(state, at.Sub(state.lastShareTime))
+	} else {
+		state.totalShares++
 	}
-
-	state.difficulty = newDifficulty
+	state.lastShareTime = at
+	return m.retargetAndUnlock(minerID, state)
 }
 
-// calculateWeightedMedian returns a weighted median of share times
-// More recent shares have higher weight, resistant to outliers
-func (m *Manager) calculateWeightedMedian(shareTimes []time.Duration) time.Duration {
-	if len(shareTimes) == 0 {
-		return m.config.TargetShareTime
+// addShareTime adds an interval to the miner's window (caller holds the lock)
+func (m *Manager) addShareTime(state *minerState, shareTime time.Duration) {
+	state.shareTimes = append(state.shareTimes, shareTime)
+	if window := state.policy.Config.ShareWindow; window > 0 && len(state.shareTimes) > window {
+		state.shareTimes = state.shareTimes[len(state.shareTimes)-window:]
 	}
-	if len(shareTimes) == 1 {
-		return shareTimes[0]
+	state.totalShares++
+}
+
+// retargetAndUnlock runs the policy's retargeter if its interval has passed,
+// releases the lock and reports the decision
+func (m *Manager) retargetAndUnlock(minerID string, state *minerState) (float64, bool) {
+	config := state.policy.Config
+	if m.now().Sub(state.lastRetarget) < config.RetargetInterval {
+		difficulty := state.difficulty
+		m.mu.Unlock()
+		return difficulty, false
 	}
 
-	// Sort a copy to find median
-	sorted := make([]time.Duration, len(shareTimes))
-	copy(sorted, shareTimes)
-	for i := 0; i < len(sorted)-1; i++ {
-		for j := i + 1; j < len(sorted); j++ {
-			if sorted[i] > sorted[j] {
-				sorted[i], sorted[j] = sorted[j], sorted[i]
-			}
-		}
+	result, ok := state.policy.Retargeter.Retarget(state.difficulty, state.shareTimes, config)
+	if !ok {
+		difficulty := state.difficulty
+		m.mu.Unlock()
+		return difficulty, false
 	}
 
-	// Trim outliers: remove top and bottom 10% if we have enough samples
-	trimCount := len(sorted) / 10
-	if trimCount > 0 && len(sorted) > 10 {
-		sorted = sorted[trimCount : len(sorted)-trimCount]
+	old := state.difficulty
+	shares := len(state.shareTimes)
+	state.difficulty = state.clamp(result.Difficulty)
+	state.lastRetarget = m.now()
+	if result.ResetWindow && state.difficulty != old {
+		state.shareTimes = state.shareTimes[:0]
 	}
+	d := m.record(minerID, state, Decision{
+		Kind:          DecisionRetarget,
+		OldDifficulty: old,
+		Shares:        shares,
+		ShareTime:     result.ShareTime.Seconds(),
+		Reason:        result.Reason,
+	})
+	m.mu.Unlock()
+
+	m.notify(d)
+	return d.NewDifficulty, d.Changed()
+}
+
+// Trace returns a miner's difficulty decisions, oldest first
+func (m *Manager) Trace(minerID string) []Decision {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 
-	// Return median of trimmed set
-	mid := len(sorted) / 2
-	if len(sorted)%2 == 0 {
-		return (sorted[mid-1] + sorted[mid]) / 2
+	state, exists := m.miners[minerID]
+	if !exists {
+		return nil
 	}
-	return sorted[mid]
+	return state.trace.list()
 }
 
-// GetTargetShareTime returns the configured target share time
+// GetTargetShareTime returns the default policy's target share time
 func (m *Manager) GetTargetShareTime() time.Duration {
-	return m.config.TargetShareTime
+	return m.policies.Default().Config.TargetShareTime
 }
 
 // RemoveMiner removes a miner's state from the manager
@@ -265,9 +406,52 @@ func (m *Manager) RemoveMiner(minerID string) {
 	delete(m.miners, minerID)
 }
 
-// GetConfig returns the current configuration
+// Park moves a disconnected miner's state under key, keeping its difficulty,
+// policy and share history for a resumed session. Release parked state with
+// RemoveMiner(key). It returns false if the miner is unknown.
+func (m *Manager) Park(minerID, key string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state, exists := m.miners[minerID]
+	if !exists {
+		return false
+	}
+	delete(m.miners, minerID)
+	m.miners[key] = state
+	return true
+}
+
+// Resume moves state parked under key to a new connection, replacing the
+// state attached to it. The share clock restarts so the time spent
+// disconnected does not count as a share interval. It returns the resumed
+// difficulty, or false if nothing is parked under key.
+func (m *Manager) Resume(key, minerID string) (float64, bool) {
+	m.mu.Lock()
+	state, exists := m.miners[key]
+	if !exists {
+		m.mu.Unlock()
+		return 0, false
+	}
+	delete(m.miners, key)
+
+	old := state.difficulty
+	if attached, ok := m.miners[minerID]; ok {
+		old = attached.difficulty
+	}
+	m.miners[minerID] = state
+	state.lastShareTime = time.Time{}
+	state.lastRetarget = m.now()
+	d := m.record(minerID, state, Decision{Kind: DecisionResume, OldDifficulty: old, Reason: "session resumed"})
+	m.mu.Unlock()
+
+	m.notify(d)
+	return d.NewDifficulty, true
+}
+
+// GetConfig returns the default policy's configuration
 func (m *Manager) GetConfig() Config {
-	return m.config
+	return m.policies.Default().Config
 }
 
 // GetMinerStats returns statistics for a miner
@@ -277,7 +461,28 @@ func (m *Manager) GetMinerStats(minerID string) (difficulty float64, totalShares
 
 	state, exists := m.miners[minerID]
 	if !exists {
-		return m.config.InitialDifficulty, 0, false
+		return m.policies.Default().Config.InitialDifficulty, 0, false
 	}
 	return state.difficulty, state.totalShares, true
 }
+
+// EstimatedHashrate returns the pool hashrate implied by every miner's
+// difficulty and average share interval, in H/s
+func (m *Manager) EstimatedHashrate() float64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var total float64
+	for _, state := range m.miners {
+		if len(state.shareTimes) == 0 {
+			continue
+		}
+		var sum time.Duration
+		for _, t := range state.shareTimes {
+			sum += t
+		}
+		avg := sum / time.Duration(len(state.shareTimes))
+		total += CalculateExpectedHashrate(state.difficulty, avg.Seconds())
+	}
+	return total
+}
@@ -0,0 +1,4 @@
+-- Migration 023: Share Reject Reasons - Rollback
+
+DROP INDEX IF EXISTS idx_shares_reject_reason;
+ALTER TABLE shares DROP COLUMN IF EXISTS reject_reason;
@@ -0,0 +1,7 @@
+-- Migration 023: Share Reject Reasons
+-- Records why a share was rejected (stale, duplicate, low-difficulty, ...)
+
+ALTER TABLE shares ADD COLUMN IF NOT EXISTS reject_reason VARCHAR(32);
+
+-- Partial index for reject-reason breakdowns; valid shares have no reason
+CREATE INDEX IF NOT EXISTS idx_shares_reject_reason ON shares(reject_reason) WHERE reject_reason IS NOT NULL;
@@ -0,0 +1,9 @@
+-- Migration 024: Rollback Block Maturity
+
+DROP TABLE IF EXISTS block_audit_log;
+DROP TABLE IF EXISTS block_credits;
+ALTER TABLE blocks DROP COLUMN IF EXISTS confirmations;
+UPDATE blocks SET status = 'pending' WHERE status = 'immature';
+ALTER TABLE blocks DROP CONSTRAINT IF EXISTS blocks_status_check;
+ALTER TABLE blocks ADD CONSTRAINT blocks_status_check
+    CHECK (status IN ('pending', 'confirmed', 'orphaned'));
@@ -0,0 +1,38 @@
+-- Migration 024: Block Maturity
+-- Tracks found blocks through pending, immature, confirmed and orphaned,
+-- the credits applied when a block matures, and an audit trail of every change
+
+ALTER TABLE blocks DROP CONSTRAINT IF EXISTS blocks_status_check;
+ALTER TABLE blocks ADD CONSTRAINT blocks_status_check
+    CHECK (status IN ('pending', 'immature', 'confirmed', 'orphaned'));
+ALTER TABLE blocks ADD COLUMN IF NOT EXISTS confirmations BIGINT NOT NULL DEFAULT 0;
+
+-- Balance credits applied for a matured block, used to claw back after a reorg
+CREATE TABLE IF NOT EXISTS block_credits (
+    id BIGSERIAL PRIMARY KEY,
+    block_id BIGINT NOT NULL REFERENCES blocks(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    amount BIGINT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Every status change of a primary or merged-mined block
+CREATE TABLE IF NOT EXISTS block_audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    block_id BIGINT NOT NULL,
+    chain_id VARCHAR(50) NOT NULL DEFAULT '', -- Empty for the primary chain
+    height BIGINT NOT NULL,
+    hash VARCHAR(128) NOT NULL,
+    from_status VARCHAR(20) NOT NULL,
+    to_status VARCHAR(20) NOT NULL,
+    confirmations BIGINT NOT NULL DEFAULT 0,
+    amount BIGINT NOT NULL DEFAULT 0, -- Credited (positive) or clawed back (negative)
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_block_credits_block ON block_credits(block_id);
+CREATE INDEX IF NOT EXISTS idx_block_audit_log_block ON block_audit_log(chain_id, block_id);
+
+COMMENT ON TABLE block_credits IS 'Balance credits applied when a found block matured';
+COMMENT ON TABLE block_audit_log IS 'Audit trail of found block status changes';
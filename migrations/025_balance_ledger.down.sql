@@ -0,0 +1,32 @@
+-- Migration 025: Rollback Double-Entry Balance Ledger
+
+-- Write ledger-derived balances back before dropping the ledger
+INSERT INTO user_balances (user_id, balance)
+SELECT CAST(substring(account from 6) AS BIGINT), GREATEST(balance, 0)
+FROM v_ledger_balances
+WHERE account LIKE 'user:%' AND currency = 'LTC'
+ON CONFLICT (user_id) DO UPDATE SET balance = EXCLUDED.balance;
+
+CREATE OR REPLACE VIEW v_payout_summary AS
+SELECT 
+    u.id as user_id,
+    u.username,
+    ub.balance as current_balance,
+    ub.pending_balance,
+    ub.total_paid,
+    COUNT(pp.id) FILTER (WHERE pp.status = 'pending') as pending_payouts,
+    COUNT(pp.id) FILTER (WHERE pp.status = 'processed') as completed_payouts,
+    COUNT(pp.id) FILTER (WHERE pp.status = 'failed') as failed_payouts,
+    MAX(pp.processed_at) as last_payout_at
+FROM users u
+LEFT JOIN user_balances ub ON u.id = ub.user_id
+LEFT JOIN pending_payouts pp ON u.id = pp.user_id
+GROUP BY u.id, u.username, ub.balance, ub.pending_balance, ub.total_paid;
+
+DROP VIEW IF EXISTS v_ledger_balances;
+DROP TRIGGER IF EXISTS trigger_ledger_entries_append_only ON ledger_entries;
+DROP TRIGGER IF EXISTS trigger_ledger_postings_append_only ON ledger_postings;
+DROP FUNCTION IF EXISTS reject_ledger_mutation();
+DROP TABLE IF EXISTS ledger_entries;
+DROP TABLE IF EXISTS ledger_postings;
+COMMENT ON COLUMN user_balances.balance IS NULL;
@@ -0,0 +1,112 @@
+-- Migration 025: Double-Entry Balance Ledger
+-- Miner balances are derived from an append-only ledger. Every posting's
+-- entries sum to zero: positive amounts are debits, negative amounts credits.
+
+CREATE TABLE IF NOT EXISTS ledger_postings (
+    id BIGSERIAL PRIMARY KEY,
+    kind VARCHAR(32) NOT NULL,
+    currency VARCHAR(50) NOT NULL,
+    reference VARCHAR(128) NOT NULL, -- Source, e.g. block:42 or payout:7
+    memo TEXT NOT NULL DEFAULT '',
+    created_by VARCHAR(100) NOT NULL DEFAULT '', -- Administrator for manual adjustments
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT unique_posting_source UNIQUE (kind, currency, reference)
+);
+
+CREATE TABLE IF NOT EXISTS ledger_entries (
+    id BIGSERIAL PRIMARY KEY,
+    posting_id BIGINT NOT NULL REFERENCES ledger_postings(id),
+    account VARCHAR(64) NOT NULL, -- user:<id>, pool:wallet, pool:payouts, pool:fees, pool:adjustments
+    currency VARCHAR(50) NOT NULL,
+    amount BIGINT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT amount_non_zero CHECK (amount <> 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_account ON ledger_entries(account, currency);
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_posting ON ledger_entries(posting_id);
+
+-- The ledger is append-only
+CREATE OR REPLACE FUNCTION reject_ledger_mutation()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'ledger is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trigger_ledger_postings_append_only ON ledger_postings;
+CREATE TRIGGER trigger_ledger_postings_append_only
+    BEFORE UPDATE OR DELETE ON ledger_postings
+    FOR EACH ROW
+    EXECUTE FUNCTION reject_ledger_mutation();
+
+DROP TRIGGER IF EXISTS trigger_ledger_entries_append_only ON ledger_entries;
+CREATE TRIGGER trigger_ledger_entries_append_only
+    BEFORE UPDATE OR DELETE ON ledger_entries
+    FOR EACH ROW
+    EXECUTE FUNCTION reject_ledger_mutation();
+
+-- Carry existing balances over as opening postings against the pool wallet
+WITH opening AS (
+    INSERT INTO ledger_postings (kind, currency, reference, memo)
+    SELECT 'opening_balance', 'LTC', 'user_balances:' || user_id, 'balance before the ledger'
+    FROM user_balances
+    WHERE balance > 0
+    RETURNING id, reference
+)
+INSERT INTO ledger_entries (posting_id, account, currency, amount)
+SELECT o.id, e.account, 'LTC', e.amount
+FROM opening o
+JOIN user_balances ub ON o.reference = 'user_balances:' || ub.user_id
+CROSS JOIN LATERAL (VALUES
+    ('pool:wallet', ub.balance),
+    ('user:' || ub.user_id, -ub.balance)
+) AS e(account, amount);
+
+-- Payouts queued but not yet sent were already taken off user_balances, so
+-- they open in pool:payouts and settle from there like any queued payout
+WITH opening AS (
+    INSERT INTO ledger_postings (kind, currency, reference, memo)
+    SELECT 'opening_balance', 'LTC', 'pending_payouts:' || id, 'payout queued before the ledger'
+    FROM pending_payouts
+    WHERE status = 'pending' AND amount > 0
+    RETURNING id, reference
+)
+INSERT INTO ledger_entries (posting_id, account, currency, amount)
+SELECT o.id, e.account, 'LTC', e.amount
+FROM opening o
+JOIN pending_payouts pp ON o.reference = 'pending_payouts:' || pp.id
+CROSS JOIN LATERAL (VALUES
+    ('pool:wallet', pp.amount),
+    ('pool:payouts', -pp.amount)
+) AS e(account, amount);
+
+-- Balances derived from the ledger, on each account's normal side
+CREATE OR REPLACE VIEW v_ledger_balances AS
+SELECT
+    account,
+    currency,
+    CASE WHEN account = 'pool:wallet' THEN SUM(amount) ELSE -SUM(amount) END AS balance
+FROM ledger_entries
+GROUP BY account, currency;
+
+CREATE OR REPLACE VIEW v_payout_summary AS
+SELECT
+    u.id as user_id,
+    u.username,
+    COALESCE(lb.balance, 0) as current_balance,
+    COALESCE(ub.pending_balance, 0) as pending_balance,
+    COALESCE(ub.total_paid, 0) as total_paid,
+    COUNT(pp.id) FILTER (WHERE pp.status = 'pending') as pending_payouts,
+    COUNT(pp.id) FILTER (WHERE pp.status = 'processed') as completed_payouts,
+    COUNT(pp.id) FILTER (WHERE pp.status = 'failed') as failed_payouts,
+    MAX(pp.processed_at) as last_payout_at
+FROM users u
+LEFT JOIN v_ledger_balances lb ON lb.account = 'user:' || u.id AND lb.currency = 'LTC'
+LEFT JOIN user_balances ub ON u.id = ub.user_id
+LEFT JOIN pending_payouts pp ON u.id = pp.user_id
+GROUP BY u.id, u.username, lb.balance, ub.pending_balance, ub.total_paid;
+
+COMMENT ON TABLE ledger_postings IS 'Append-only balance postings with a reference to their source';
+COMMENT ON TABLE ledger_entries IS 'Debit (positive) and credit (negative) lines of each posting';
+COMMENT ON COLUMN user_balances.balance IS 'Superseded by v_ledger_balances; no longer written';
@@ -0,0 +1,4 @@
+-- Migration 026: Solo Shares - Rollback
+
+DROP INDEX IF EXISTS idx_shares_solo;
+ALTER TABLE shares DROP COLUMN IF EXISTS solo;
@@ -0,0 +1,7 @@
+-- Migration 026: Solo Shares
+-- Marks shares mined on a stratum port whose profile forces solo mode
+
+ALTER TABLE shares ADD COLUMN IF NOT EXISTS solo BOOLEAN NOT NULL DEFAULT false;
+
+-- Partial index for solo share lookups; most shares are pooled
+CREATE INDEX IF NOT EXISTS idx_shares_solo ON shares(user_id, timestamp) WHERE solo;
@@ -0,0 +1,5 @@
+-- Migration 027: Rollback Signed Outbound Webhooks
+
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TRIGGER IF EXISTS trigger_update_webhook_endpoints_timestamp ON webhook_endpoints;
+DROP TABLE IF EXISTS webhook_endpoints;
@@ -0,0 +1,48 @@
+-- Migration 027: Signed Outbound Webhooks
+-- Users (and admins, for pool-wide endpoints) register URLs that receive
+-- HMAC-SHA256 signed alert payloads. Deliveries are queued and retried with
+-- exponential backoff; the deliveries table doubles as each endpoint's log.
+
+CREATE TABLE IF NOT EXISTS webhook_endpoints (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT REFERENCES users(id) ON DELETE CASCADE, -- NULL for pool-wide endpoints
+    url VARCHAR(500) NOT NULL,
+    secret VARCHAR(128) NOT NULL,
+    alert_types TEXT[] NOT NULL DEFAULT '{}', -- Empty receives every alert type
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    enabled BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_endpoints_user ON webhook_endpoints(user_id);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    endpoint_id BIGINT NOT NULL REFERENCES webhook_endpoints(id) ON DELETE CASCADE,
+    event_id VARCHAR(50) NOT NULL, -- Alert ID, stable across retries and replays
+    alert_type VARCHAR(50) NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, delivered, failed
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    last_status_code INT,
+    last_error TEXT,
+    replay_of BIGINT REFERENCES webhook_deliveries(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    delivered_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT valid_webhook_delivery_status CHECK (status IN ('pending', 'delivered', 'failed'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at)
+    WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_endpoint ON webhook_deliveries(endpoint_id, created_at DESC);
+
+DROP TRIGGER IF EXISTS trigger_update_webhook_endpoints_timestamp ON webhook_endpoints;
+CREATE TRIGGER trigger_update_webhook_endpoints_timestamp
+    BEFORE UPDATE ON webhook_endpoints
+    FOR EACH ROW
+    EXECUTE FUNCTION update_notification_settings_timestamp();
+
+COMMENT ON TABLE webhook_endpoints IS 'Outbound webhook endpoints for alerts';
+COMMENT ON TABLE webhook_deliveries IS 'Webhook delivery queue and per-endpoint delivery log';
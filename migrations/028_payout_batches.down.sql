@@ -0,0 +1,4 @@
+-- Migration 028: Rollback Batched Payout Transactions
+
+ALTER TABLE pending_payouts DROP COLUMN IF EXISTS batch_id;
+DROP TABLE IF EXISTS payout_batches;
@@ -0,0 +1,26 @@
+-- Migration 028: Batched Payout Transactions
+-- Due payouts are paid together in one sendmany transaction. A batch is
+-- recorded before it is broadcast so an interrupted send can be reconciled
+-- against the wallet instead of paying anyone twice.
+
+CREATE TABLE IF NOT EXISTS payout_batches (
+    id BIGSERIAL PRIMARY KEY,
+    label VARCHAR(64) NOT NULL UNIQUE, -- sendmany comment used to find the transaction in the wallet
+    status VARCHAR(20) NOT NULL DEFAULT 'sending',
+    tx_hash VARCHAR(100),
+    output_count INT NOT NULL,
+    total_amount BIGINT NOT NULL,
+    fee_rate BIGINT NOT NULL DEFAULT 0, -- Estimated litoshis per kB when sent
+    error_message TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    broadcast_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT payout_batches_status_check CHECK (status IN ('sending', 'broadcast', 'failed'))
+);
+
+ALTER TABLE pending_payouts ADD COLUMN IF NOT EXISTS batch_id BIGINT REFERENCES payout_batches(id);
+
+CREATE INDEX IF NOT EXISTS idx_payout_batches_sending ON payout_batches(created_at) WHERE status = 'sending';
+CREATE INDEX IF NOT EXISTS idx_payout_batches_tx ON payout_batches(tx_hash);
+CREATE INDEX IF NOT EXISTS idx_pending_payouts_batch ON pending_payouts(batch_id);
+
+COMMENT ON TABLE payout_batches IS 'Batched payout transactions and their broadcast state';
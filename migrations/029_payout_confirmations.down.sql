@@ -0,0 +1,13 @@
+-- Migration 029: Rollback Payout Confirmation Tracking
+
+DROP INDEX IF EXISTS idx_payout_transactions_watched;
+DROP INDEX IF EXISTS idx_payout_transactions_payout_unique;
+ALTER TABLE payout_transactions DROP COLUMN IF EXISTS resolution;
+ALTER TABLE payout_transactions DROP COLUMN IF EXISTS resolved_at;
+ALTER TABLE payout_transactions DROP COLUMN IF EXISTS checked_at;
+ALTER TABLE payout_transactions DROP COLUMN IF EXISTS last_bumped_at;
+ALTER TABLE payout_transactions DROP COLUMN IF EXISTS bump_count;
+ALTER TABLE payout_transactions DROP COLUMN IF EXISTS replaced_tx_hashes;
+ALTER TABLE payout_transactions DROP CONSTRAINT IF EXISTS payout_transactions_status_check;
+ALTER TABLE payout_transactions ALTER COLUMN status SET DEFAULT 'pending';
+UPDATE payout_transactions SET status = 'pending' WHERE status = 'unconfirmed';
@@ -0,0 +1,27 @@
+-- Migration 029: Payout Confirmation Tracking
+-- Every sent payout's transaction is followed through unconfirmed, confirmed,
+-- conflicted or dropped. Fee bumps replace the tracked tx hash and keep the
+-- hashes it replaced.
+
+UPDATE payout_transactions SET status = 'unconfirmed' WHERE status = 'pending';
+ALTER TABLE payout_transactions ALTER COLUMN status SET DEFAULT 'unconfirmed';
+ALTER TABLE payout_transactions DROP CONSTRAINT IF EXISTS payout_transactions_status_check;
+ALTER TABLE payout_transactions ADD CONSTRAINT payout_transactions_status_check
+    CHECK (status IN ('unconfirmed', 'confirmed', 'conflicted', 'dropped'));
+
+ALTER TABLE payout_transactions ADD COLUMN IF NOT EXISTS replaced_tx_hashes TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE payout_transactions ADD COLUMN IF NOT EXISTS bump_count INT NOT NULL DEFAULT 0;
+ALTER TABLE payout_transactions ADD COLUMN IF NOT EXISTS last_bumped_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE payout_transactions ADD COLUMN IF NOT EXISTS checked_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE payout_transactions ADD COLUMN IF NOT EXISTS resolved_at TIMESTAMP WITH TIME ZONE; -- Set once the outcome is final
+ALTER TABLE payout_transactions ADD COLUMN IF NOT EXISTS resolution TEXT;
+
+-- Payouts sent before tracking started are assumed confirmed
+INSERT INTO payout_transactions (payout_id, tx_hash, amount, status, created_at, confirmed_at, resolved_at, resolution)
+SELECT p.id, p.tx_hash, p.amount, 'confirmed', COALESCE(p.processed_at, p.created_at), p.processed_at, NOW(), 'sent before confirmation tracking'
+FROM pending_payouts p
+WHERE p.status = 'processed' AND COALESCE(p.tx_hash, '') <> ''
+  AND NOT EXISTS (SELECT 1 FROM payout_transactions t WHERE t.payout_id = p.id);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payout_transactions_payout_unique ON payout_transactions(payout_id);
+CREATE INDEX IF NOT EXISTS idx_payout_transactions_watched ON payout_transactions(created_at) WHERE resolved_at IS NULL;
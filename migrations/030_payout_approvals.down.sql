@@ -0,0 +1,11 @@
+-- Migration 030: Rollback Payout Approval Workflow
+-- wallet_address_history is left in place as the API writes to it
+
+DROP INDEX IF EXISTS idx_pending_payouts_processed;
+DROP TABLE IF EXISTS payout_approval_votes;
+DROP TABLE IF EXISTS payout_approvals;
+UPDATE pending_payouts SET status = 'pending' WHERE status = 'held';
+ALTER TABLE pending_payouts DROP COLUMN IF EXISTS approved_at;
+ALTER TABLE pending_payouts DROP CONSTRAINT IF EXISTS valid_status;
+ALTER TABLE pending_payouts ADD CONSTRAINT valid_status
+    CHECK (status IN ('pending', 'processed', 'failed', 'cancelled'));
@@ -0,0 +1,50 @@
+-- Migration 030: Payout Approval Workflow
+-- Payouts over the configured limits, or to a recently changed address, are
+-- held until enough admins approve them. A single rejection fails the payout
+-- and returns it to the miner's balance.
+
+ALTER TABLE pending_payouts DROP CONSTRAINT IF EXISTS valid_status;
+ALTER TABLE pending_payouts ADD CONSTRAINT valid_status
+    CHECK (status IN ('pending', 'held', 'processed', 'failed', 'cancelled'));
+ALTER TABLE pending_payouts ADD COLUMN IF NOT EXISTS approved_at TIMESTAMP WITH TIME ZONE; -- Approved payouts skip the policy checks
+
+CREATE TABLE IF NOT EXISTS payout_approvals (
+    id BIGSERIAL PRIMARY KEY,
+    payout_id BIGINT NOT NULL UNIQUE REFERENCES pending_payouts(id) ON DELETE CASCADE,
+    reasons TEXT[] NOT NULL DEFAULT '{}',
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    required_approvals INT NOT NULL DEFAULT 2,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    resolved_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT payout_approvals_status_check CHECK (status IN ('pending', 'approved', 'rejected'))
+);
+
+CREATE TABLE IF NOT EXISTS payout_approval_votes (
+    id BIGSERIAL PRIMARY KEY,
+    approval_id BIGINT NOT NULL REFERENCES payout_approvals(id) ON DELETE CASCADE,
+    admin_id BIGINT NOT NULL REFERENCES users(id),
+    decision VARCHAR(10) NOT NULL,
+    note TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT payout_approval_votes_decision_check CHECK (decision IN ('approve', 'reject')),
+    UNIQUE (approval_id, admin_id)
+);
+
+-- Address changes are written here by the API; the table was previously only
+-- created by the docker init script
+CREATE TABLE IF NOT EXISTS wallet_address_history (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    address VARCHAR(255) NOT NULL,
+    total_paid DECIMAL(20,8) DEFAULT 0,
+    payout_count INTEGER DEFAULT 0,
+    set_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    replaced_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_wallet_history_user_id ON wallet_address_history(user_id);
+CREATE INDEX IF NOT EXISTS idx_payout_approvals_pending ON payout_approvals(created_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_pending_payouts_processed ON pending_payouts(processed_at) WHERE status = 'processed';
+
+COMMENT ON TABLE payout_approvals IS 'Payouts held for admin approval and their outcome';
+COMMENT ON TABLE payout_approval_votes IS 'Admin approve and reject decisions on held payouts';
@@ -0,0 +1,3 @@
+-- Migration 031: Rollback Payout Allocations
+
+DROP TABLE IF EXISTS payout_allocations;
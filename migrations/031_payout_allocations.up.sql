@@ -0,0 +1,20 @@
+-- Migration 031: Payout Allocations
+-- A payout split across a user's wallets is sent as one output per address.
+-- Each allocation records the split rule that produced it, so an output is
+-- traced through pending_payouts.tx_hash and its address.
+
+CREATE TABLE IF NOT EXISTS payout_allocations (
+    id BIGSERIAL PRIMARY KEY,
+    payout_id BIGINT NOT NULL REFERENCES pending_payouts(id) ON DELETE CASCADE,
+    rule_type VARCHAR(20) NOT NULL CHECK (rule_type IN ('miner_assignment', 'wallet_split', 'payout_address')),
+    wallet_id BIGINT REFERENCES user_wallets(id) ON DELETE SET NULL,
+    miner_id BIGINT REFERENCES miners(id) ON DELETE SET NULL,
+    assignment_id BIGINT, -- miner_wallet_assignments.id, kept after the assignment is removed
+    percentage DECIMAL(5,2) NOT NULL, -- The rule's percentage when the payout was sent
+    address VARCHAR(255) NOT NULL,
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_payout_allocations_payout ON payout_allocations(payout_id);
+CREATE INDEX IF NOT EXISTS idx_payout_allocations_wallet ON payout_allocations(wallet_id);
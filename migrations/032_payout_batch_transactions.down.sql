@@ -0,0 +1,3 @@
+-- Migration 032: Rollback Signed Payout Batch Transactions
+
+ALTER TABLE payout_batches DROP COLUMN IF EXISTS raw_tx;
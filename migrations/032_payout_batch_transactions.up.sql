@@ -0,0 +1,10 @@
+-- Migration 032: Signed Payout Batch Transactions
+-- A batch's transaction is funded and signed first, and its hash and raw
+-- transaction are stored before it is broadcast. An interrupted send is then
+-- looked up with gettransaction and broadcast again from the stored copy,
+-- instead of being searched for by label in the wallet's recent history.
+
+ALTER TABLE payout_batches ADD COLUMN IF NOT EXISTS raw_tx TEXT;
+
+COMMENT ON COLUMN payout_batches.label IS 'Unique label identifying the batch';
+COMMENT ON COLUMN payout_batches.raw_tx IS 'Signed transaction, stored before it is broadcast';
@@ -0,0 +1,8 @@
+-- Migration 033: Rollback Payout Address Set Times
+
+DROP TRIGGER IF EXISTS stamp_user_payout_settings_address_set_at ON user_payout_settings;
+DROP TRIGGER IF EXISTS stamp_user_wallets_address_set_at ON user_wallets;
+DROP FUNCTION IF EXISTS stamp_payout_settings_address_set_at();
+DROP FUNCTION IF EXISTS stamp_wallet_address_set_at();
+ALTER TABLE user_payout_settings DROP COLUMN IF EXISTS address_set_at;
+ALTER TABLE user_wallets DROP COLUMN IF EXISTS address_set_at;
@@ -0,0 +1,51 @@
+-- Migration 033: Payout Address Set Times
+-- Wallet and payout settings addresses are edited in place, so their
+-- created_at does not say when an address was set. Triggers now stamp
+-- address_set_at whenever an address is inserted or changed, whichever code
+-- path edits it. Existing rows take their last update time, the latest an
+-- address could have been set.
+
+ALTER TABLE user_wallets ADD COLUMN IF NOT EXISTS address_set_at TIMESTAMP WITH TIME ZONE;
+UPDATE user_wallets SET address_set_at = COALESCE(updated_at, created_at, NOW()) WHERE address_set_at IS NULL;
+ALTER TABLE user_wallets ALTER COLUMN address_set_at SET DEFAULT NOW();
+
+ALTER TABLE user_payout_settings ADD COLUMN IF NOT EXISTS address_set_at TIMESTAMP WITH TIME ZONE;
+UPDATE user_payout_settings SET address_set_at = COALESCE(updated_at, created_at, NOW())
+WHERE address_set_at IS NULL AND payout_address IS NOT NULL AND payout_address != '';
+
+CREATE OR REPLACE FUNCTION stamp_wallet_address_set_at()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        NEW.address_set_at = NOW();
+    ELSIF NEW.address IS DISTINCT FROM OLD.address THEN
+        NEW.address_set_at = NOW();
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION stamp_payout_settings_address_set_at()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        NEW.address_set_at = NOW();
+    ELSIF NEW.payout_address IS DISTINCT FROM OLD.payout_address THEN
+        NEW.address_set_at = NOW();
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS stamp_user_wallets_address_set_at ON user_wallets;
+CREATE TRIGGER stamp_user_wallets_address_set_at
+    BEFORE INSERT OR UPDATE ON user_wallets
+    FOR EACH ROW EXECUTE FUNCTION stamp_wallet_address_set_at();
+
+DROP TRIGGER IF EXISTS stamp_user_payout_settings_address_set_at ON user_payout_settings;
+CREATE TRIGGER stamp_user_payout_settings_address_set_at
+    BEFORE INSERT OR UPDATE ON user_payout_settings
+    FOR EACH ROW EXECUTE FUNCTION stamp_payout_settings_address_set_at();
+
+COMMENT ON COLUMN user_wallets.address_set_at IS 'When the wallet address was last set; payouts to it are held for a while after';
+COMMENT ON COLUMN user_payout_settings.address_set_at IS 'When the payout address was last set; payouts to it are held for a while after';
@@ -0,0 +1,3 @@
+-- Migration 034: Rollback Payout Batch Network Fees
+
+ALTER TABLE payout_batches DROP COLUMN IF EXISTS fee;
@@ -0,0 +1,8 @@
+-- Migration 034: Payout Batch Network Fees
+-- The fee a batch's transaction pays is stored with the signed transaction,
+-- so it is charged to the pool in the balance ledger once the batch is sent,
+-- including a batch completed on reconciliation after a restart.
+
+ALTER TABLE payout_batches ADD COLUMN IF NOT EXISTS fee BIGINT NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN payout_batches.fee IS 'Network fee in litoshis paid by the signed transaction';